@@ -1,5 +1,8 @@
+import json
+
 from fastapi import APIRouter, HTTPException, Depends
-from app.schemas import ChatbotRequest, ChatbotResponse
+from fastapi.responses import StreamingResponse
+from app.schemas import ChatbotRequest, ChatbotResponse, ChatSummaryRequest, ChatSummaryResponse
 from app.services.chatbot_service import ChatbotService
 
 router = APIRouter()
@@ -17,3 +20,42 @@ async def chat(
         return result
     except Exception as e:
         raise HTTPException(status_code=500, detail=str(e))
+
+
+@router.post("/summarize", response_model=ChatSummaryResponse)
+async def summarize(
+    request: ChatSummaryRequest,
+    service: ChatbotService = Depends(get_chatbot_service)
+):
+    try:
+        return await service.summarize(request)
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+def _sse(event: str, data: dict) -> str:
+    return f"event: {event}\ndata: {json.dumps(data)}\n\n"
+
+@router.post("/stream")
+async def chat_stream(
+    request: ChatbotRequest,
+    service: ChatbotService = Depends(get_chatbot_service)
+):
+    """Streams the answer as `token` events followed by a final `done` event.
+    When the model wants backend tools run first, a `tool_calls` event ends the
+    stream instead and the backend calls again with the results."""
+    async def events():
+        answer = []
+        try:
+            async for event, data in service.stream_with_gym_context(request):
+                if event == "tool_calls":
+                    yield _sse("tool_calls", {"tool_calls": data})
+                    return
+                answer.append(data)
+                yield _sse("token", {"delta": data})
+        except Exception as e:
+            yield _sse("error", {"detail": str(e)})
+            return
+        yield _sse("done", {"answer": "".join(answer), "suggested_actions": []})
+
+    return StreamingResponse(events(), media_type="text/event-stream")
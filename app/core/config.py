@@ -12,6 +12,9 @@ class Settings(BaseSettings):
     OPENAI_API_KEY: str
     OPENAI_MODEL: str = "gpt-4o-mini"
     OPIK_API_KEY: str
+
+    # Shared token expected from the backend; auth is disabled when empty
+    SERVICE_TOKEN: str = ""
     
     class Config:
         env_file = ".env"
@@ -79,7 +79,7 @@ def get_chatbot_prompt(query: str, context: dict) -> str:
     - Avg Duration: {context.get('avg_duration', 0)} min
     - Check-in History: {context.get('checkins', [])}
     - Membership Status: {context.get('membership', 'Unknown')}
-    
+    {_conversation_section(context)}{_tools_section(context)}
     ## USER QUERY
     "{query}"
     
@@ -104,3 +104,83 @@ def get_chatbot_prompt(query: str, context: dict) -> str:
         "suggested_actions": ["Action 1", "Action 2"]
     }}
     """
+
+
+def get_chatbot_stream_prompt(query: str, context: dict) -> str:
+    return f"""You are a helpful, knowledgeable, and motivating Gym Assistant for the OnlyFits app.
+
+    ## USER CONTEXT
+    - Age: {context.get('age', 'N/A')} | Gender: {context.get('gender', 'N/A')}
+    - Recent Activity (Last 30 Days): {context.get('sessions_count', 0)} sessions
+    - Avg Duration: {context.get('avg_duration', 0)} min
+    - Check-in History: {context.get('checkins', [])}
+    - Membership Status: {context.get('membership', 'Unknown')}
+    {_conversation_section(context)}{_tools_section(context)}
+    ## USER QUERY
+    "{query}"
+
+    ## YOUR TASK
+    Answer the user's query specifically tailored to their context.
+    - If they are consistent (high session count), praise them and suggest advanced tips.
+    - If they are inconsistent or new, be encouraging and suggest small, achievable steps.
+    - Use their data to back up your advice.
+    - Keep the tone friendly, professional, and concise.
+    - If the query is NOT related to gym, fitness, wellness, or their membership, gently refuse to answer and remind them of your purpose.
+
+    ## OUTPUT
+    Plain text only, no JSON and no markdown headings.
+    """
+
+
+def _conversation_section(context: dict) -> str:
+    summary = context.get('summary')
+    history = context.get('history') or []
+    if not summary and not history:
+        return ""
+
+    lines = ["", "    ## CONVERSATION SO FAR"]
+    if summary:
+        lines.append(f"    Summary of earlier conversation: {summary}")
+    for message in history:
+        lines.append(f"    {message['role'].capitalize()}: {message['content']}")
+    lines.append("    Use this to keep the answer consistent with what was already discussed.")
+    lines.append("")
+    return "\n".join(lines)
+
+
+def _tools_section(context: dict) -> str:
+    if not context.get('has_tools'):
+        return ""
+
+    return """
+    ## TOOLS
+    You can call the provided tools to look up or act on this member's own account.
+    - Prefer a tool over guessing for membership dates, plans, attendance numbers or QR codes.
+    - Only request a freeze or renewal after the member clearly asked for it and confirmed the details.
+    - Requests are reviewed by staff; never say they are already approved.
+    - Never read out QR tokens; the app shows the code to the member.
+    - If a tool returns an error, explain it briefly and suggest contacting staff.
+"""
+
+
+def get_summary_prompt(previous_summary: str, messages: list) -> str:
+    transcript = "\n".join(f"{m['role'].capitalize()}: {m['content']}" for m in messages)
+    return f"""You maintain the memory of a conversation between a gym member and a fitness assistant.
+
+    ## PREVIOUS SUMMARY
+    {previous_summary or "None"}
+
+    ## NEW MESSAGES
+    {transcript}
+
+    ## YOUR TASK
+    - Merge the previous summary and the new messages into one concise summary (max 150 words).
+    - Keep goals, injuries, preferences, plans and advice already given. Drop small talk.
+    - Suggest a short title (max 6 words) describing the conversation topic.
+
+    ## OUTPUT (JSON only)
+    {{
+        "summary": "...",
+        "title": "..."
+    }}
+    """
@@ -0,0 +1,11 @@
+import hmac
+
+from fastapi import Header, HTTPException
+from app.core.config import settings
+
+async def verify_service_token(authorization: str = Header(default="")):
+    if not settings.SERVICE_TOKEN:
+        return
+    scheme, _, token = authorization.partition(" ")
+    if scheme.lower() != "bearer" or not hmac.compare_digest(token, settings.SERVICE_TOKEN):
+        raise HTTPException(status_code=401, detail="Invalid service token")
@@ -1,5 +1,6 @@
-from fastapi import FastAPI
+from fastapi import FastAPI, Depends
 from app.core.config import settings
+from app.core.security import verify_service_token
 from app.api import analyze, chat
 
 app = FastAPI(
@@ -8,8 +9,8 @@ app = FastAPI(
     description=settings.DESCRIPTION,
 )
 
-app.include_router(analyze.router, prefix="/api/v1/analyze", tags=["analyze"])
-app.include_router(chat.router, prefix="/api/v1/chat", tags=["chat"])
+app.include_router(analyze.router, prefix="/api/v1/analyze", tags=["analyze"], dependencies=[Depends(verify_service_token)])
+app.include_router(chat.router, prefix="/api/v1/chat", tags=["chat"], dependencies=[Depends(verify_service_token)])
 
 @app.get("/health")
 async def health_check():
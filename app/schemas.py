@@ -1,5 +1,5 @@
 from pydantic import BaseModel, Field
-from typing import List, Optional
+from typing import Any, Dict, List, Optional
 from datetime import datetime
 
 
@@ -49,10 +49,42 @@ class WellnessAnalysisResponse(BaseModel):
     attendance_analysis: AttendanceAnalysis
     burnout_analysis: BurnoutAnalysis
 
+class ConversationMessage(BaseModel):
+    role: str
+    content: str
+
+class ToolDefinition(BaseModel):
+    name: str
+    description: str
+    parameters: Dict[str, Any]
+
+class ToolCall(BaseModel):
+    id: str
+    name: str
+    arguments: Dict[str, Any] = Field(default_factory=dict)
+
+class ToolExchange(BaseModel):
+    call: ToolCall
+    result: Any = None
+
 class ChatbotRequest(BaseModel):
     query: str = Field(..., description="User's question about gym/fitness")
     context: WellnessAnalysisRequest
+    history: Optional[List[ConversationMessage]] = Field(None, description="Recent turns not covered by summary")
+    summary: Optional[str] = Field(None, description="Rolling summary of earlier turns")
+    tools: Optional[List[ToolDefinition]] = Field(None, description="Backend tools the model may call")
+    tool_choice: Optional[str] = Field(None, description="'none' forces a final answer")
+    tool_exchanges: Optional[List[ToolExchange]] = Field(None, description="Tool calls already run by the backend this turn")
 
 class ChatbotResponse(BaseModel):
     answer: str
     suggested_actions: Optional[List[str]] = None
+    tool_calls: Optional[List[ToolCall]] = None
+
+class ChatSummaryRequest(BaseModel):
+    previous_summary: Optional[str] = None
+    messages: List[ConversationMessage]
+
+class ChatSummaryResponse(BaseModel):
+    summary: str
+    title: Optional[str] = None
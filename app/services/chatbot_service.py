@@ -1,8 +1,9 @@
 from app.core.clients import get_openai_client
-from app.core.prompts import get_chatbot_prompt
+from app.core.prompts import get_chatbot_prompt, get_chatbot_stream_prompt, get_summary_prompt
 from opik import track
-from app.schemas import ChatbotRequest, ChatbotResponse
+from app.schemas import ChatbotRequest, ChatbotResponse, ChatSummaryRequest, ChatSummaryResponse, ToolCall
 import json
+from typing import AsyncIterator, Tuple, Any
 from app.core.config import settings
 
 client = get_openai_client()
@@ -10,23 +11,120 @@ client = get_openai_client()
 class ChatbotService:
     @track(name="chat_with_gym_context", project_name=settings.PROJECT_NAME)
     async def chat_with_gym_context(self, request: ChatbotRequest) -> ChatbotResponse:
+        context = self._build_context(request)
+        prompt = get_chatbot_prompt(request.query, context)
+
+        response = await client.chat.completions.create(
+            model=settings.OPENAI_MODEL,
+            messages=self._build_messages(prompt, request),
+            response_format={"type": "json_object"},
+            **self._tool_options(request)
+        )
+
+        message = response.choices[0].message
+        if message.tool_calls:
+            return ChatbotResponse(
+                answer=message.content or "",
+                tool_calls=[self._parse_tool_call(c.id, c.function.name, c.function.arguments) for c in message.tool_calls]
+            )
+
+        data = json.loads(message.content)
+        return ChatbotResponse(**data)
+
+    async def stream_with_gym_context(self, request: ChatbotRequest) -> AsyncIterator[Tuple[str, Any]]:
+        """Yields ("token", delta) while the model generates the answer, or a
+        single ("tool_calls", calls) when it wants the backend to run tools."""
+        context = self._build_context(request)
+        prompt = get_chatbot_stream_prompt(request.query, context)
+
+        stream = await client.chat.completions.create(
+            model=settings.OPENAI_MODEL,
+            messages=self._build_messages(prompt, request),
+            stream=True,
+            **self._tool_options(request)
+        )
+
+        # Tool call ids, names and arguments arrive in fragments keyed by index
+        pending = {}
+        async for chunk in stream:
+            if not chunk.choices:
+                continue
+            delta = chunk.choices[0].delta
+            if delta.content:
+                yield "token", delta.content
+            for fragment in delta.tool_calls or []:
+                call = pending.setdefault(fragment.index, {"id": "", "name": "", "arguments": ""})
+                if fragment.id:
+                    call["id"] = fragment.id
+                if fragment.function and fragment.function.name:
+                    call["name"] += fragment.function.name
+                if fragment.function and fragment.function.arguments:
+                    call["arguments"] += fragment.function.arguments
+
+        if pending:
+            yield "tool_calls", [
+                self._parse_tool_call(c["id"], c["name"], c["arguments"]).model_dump()
+                for _, c in sorted(pending.items())
+            ]
+
+    @track(name="summarize_conversation", project_name=settings.PROJECT_NAME)
+    async def summarize(self, request: ChatSummaryRequest) -> ChatSummaryResponse:
+        messages = [m.model_dump() for m in request.messages]
+        prompt = get_summary_prompt(request.previous_summary, messages)
+
+        response = await client.chat.completions.create(
+            model=settings.OPENAI_MODEL,
+            messages=[{"role": "user", "content": prompt}],
+            response_format={"type": "json_object"}
+        )
+
+        data = json.loads(response.choices[0].message.content)
+        return ChatSummaryResponse(**data)
+
+    def _build_context(self, request: ChatbotRequest) -> dict:
         request_context = request.context
-        context = {
+        return {
             "age": request_context.user_profile.age,
             "gender": request_context.user_profile.gender,
             "sessions_count": request_context.activity_data.total_sessions_last_30_days,
             "avg_duration": request_context.activity_data.average_duration_minutes,
             "checkins": request_context.activity_data.last_30_days_checkins,
             "membership": request_context.membership_info.model_dump() if request_context.membership_info else None,
+            "summary": request.summary,
+            "history": [m.model_dump() for m in request.history] if request.history else [],
+            "has_tools": bool(request.tools),
         }
-        
-        prompt = get_chatbot_prompt(request.query, context)
 
-        response = await client.chat.completions.create(
-            model=settings.OPENAI_MODEL,
-            messages=[{"role": "user", "content": prompt}],
-            response_format={"type": "json_object"}
-        )
-        
-        data = json.loads(response.choices[0].message.content)
-        return ChatbotResponse(**data)
+    def _build_messages(self, prompt: str, request: ChatbotRequest) -> list:
+        messages = [{"role": "user", "content": prompt}]
+        for exchange in request.tool_exchanges or []:
+            messages.append({
+                "role": "assistant",
+                "content": None,
+                "tool_calls": [{
+                    "id": exchange.call.id,
+                    "type": "function",
+                    "function": {"name": exchange.call.name, "arguments": json.dumps(exchange.call.arguments)},
+                }],
+            })
+            messages.append({
+                "role": "tool",
+                "tool_call_id": exchange.call.id,
+                "content": json.dumps(exchange.result),
+            })
+        return messages
+
+    def _tool_options(self, request: ChatbotRequest) -> dict:
+        if not request.tools:
+            return {}
+        return {
+            "tools": [{"type": "function", "function": t.model_dump()} for t in request.tools],
+            "tool_choice": request.tool_choice or "auto",
+        }
+
+    def _parse_tool_call(self, call_id: str, name: str, arguments: str) -> ToolCall:
+        try:
+            parsed = json.loads(arguments) if arguments else {}
+        except json.JSONDecodeError:
+            parsed = {}
+        return ToolCall(id=call_id, name=name, arguments=parsed if isinstance(parsed, dict) else {})
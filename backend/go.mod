@@ -15,6 +15,7 @@ require (
 	github.com/resend/resend-go/v3 v3.0.0
 	github.com/standard-webhooks/standard-webhooks/libraries v0.0.0-20260114220421-3f69fd681bb0
 	golang.org/x/crypto v0.46.0
+	golang.org/x/sync v0.19.0
 )
 
 require (
@@ -29,7 +30,6 @@ require (
 	github.com/sethvargo/go-retry v0.3.0 // indirect
 	github.com/spyzhov/ajson v0.8.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
 )
@@ -1,10 +1,20 @@
 package config
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+
+	"fitcore/pkg/redis"
+	"fitcore/pkg/storage"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -15,6 +25,9 @@ type Config struct {
 		Port      int
 		JWTSecret string
 		BaseURL   string
+		// TrustedProxies are the addresses allowed to report the client IP
+		// in CF-Connecting-IP, such as the cloudflared container
+		TrustedProxies []netip.Prefix
 	}
 	Database struct {
 		Host     string
@@ -37,7 +50,50 @@ type Config struct {
 		WebhookSecret  string
 	}
 	Analytics struct {
-		ServiceURL string
+		// BaseURL is the API root of the analytics service
+		BaseURL         string
+		WellnessPath    string
+		ChatPath        string
+		ChatStreamPath  string
+		ChatSummaryPath string
+		ServiceToken    string
+		// Timeout bounds wellness calls before falling back to the native
+		// engine.
+		Timeout     time.Duration
+		ChatTimeout time.Duration
+	}
+	Cache struct {
+		// Driver is postgres, redis or memory
+		Driver string
+		Redis  redis.Options
+		// L1 layers an in-process LRU in front of a shared driver
+		L1               bool
+		L1TTL            time.Duration
+		MemoryMaxEntries int
+		MemoryMaxBytes   int64
+	}
+	Export struct {
+		MaxSyncRows int
+	}
+	Join struct {
+		// CaptchaSecret enables Turnstile verification of online sign-ups
+		CaptchaSecret    string
+		CaptchaVerifyURL string
+		// RateLimit is the number of sign-ups allowed per client IP per
+		// RateWindow
+		RateLimit  int
+		RateWindow time.Duration
+	}
+	Storage struct {
+		// Driver is local or s3
+		Driver   string
+		LocalDir string
+		S3       storage.S3Options
+		// PublicURL is the API root put in front of signed download links;
+		// empty keeps them relative
+		PublicURL  string
+		SigningKey string
+		URLTTL     time.Duration
 	}
 }
 
@@ -67,7 +123,11 @@ func NewConfig() (*Config, error) {
 	polarWebhookSecret := os.Getenv("POLAR_WEBHOOK_SECRET")
 
 	// Analytics config
+	analyticsBaseURL := os.Getenv("ANALYTICS_BASE_URL")
 	analyticsServiceURL := os.Getenv("ANALYTICS_SERVICE_URL")
+	analyticsServiceToken := os.Getenv("ANALYTICS_SERVICE_TOKEN")
+	analyticsTimeoutStr := os.Getenv("ANALYTICS_TIMEOUT_SECONDS")
+	analyticsChatTimeoutStr := os.Getenv("ANALYTICS_CHAT_TIMEOUT_SECONDS")
 
 	if baseURL == "" {
 		baseURL = "http://localhost:8080"
@@ -75,8 +135,112 @@ func NewConfig() (*Config, error) {
 	if emailFromName == "" {
 		emailFromName = "FitCore"
 	}
-	if analyticsServiceURL == "" {
-		analyticsServiceURL = "http://localhost:8000/api/v1/analyze"
+	if analyticsBaseURL == "" {
+		// ANALYTICS_SERVICE_URL used to point at the /analyze router
+		analyticsBaseURL = strings.TrimSuffix(analyticsServiceURL, "/analyze")
+	}
+	if analyticsBaseURL == "" {
+		analyticsBaseURL = "http://localhost:8000/api/v1"
+	}
+
+	analyticsTimeout, err := parseSeconds("ANALYTICS_TIMEOUT_SECONDS", analyticsTimeoutStr, 20*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	analyticsChatTimeout, err := parseSeconds("ANALYTICS_CHAT_TIMEOUT_SECONDS", analyticsChatTimeoutStr, 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache config
+	cacheDriver := envOrDefault("CACHE_DRIVER", "postgres")
+	redisURL := os.Getenv("REDIS_URL")
+
+	var redisOptions redis.Options
+	switch cacheDriver {
+	case "postgres", "memory":
+	case "redis":
+		if redisURL == "" {
+			return nil, errors.New("REDIS_URL is required when CACHE_DRIVER is redis")
+		}
+	default:
+		return nil, fmt.Errorf("error parsing CACHE_DRIVER: %q", cacheDriver)
+	}
+	if redisURL != "" {
+		redisOptions, err = redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing REDIS_URL: %w", err)
+		}
+	}
+	cacheL1 := os.Getenv("CACHE_L1_ENABLED") == "true"
+	cacheL1TTL, err := parseSeconds("CACHE_L1_TTL_SECONDS", os.Getenv("CACHE_L1_TTL_SECONDS"), time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	cacheMaxEntries, err := parsePositiveInt("CACHE_MEMORY_MAX_ENTRIES", os.Getenv("CACHE_MEMORY_MAX_ENTRIES"), 10000)
+	if err != nil {
+		return nil, err
+	}
+	cacheMaxMB, err := parsePositiveInt("CACHE_MEMORY_MAX_MB", os.Getenv("CACHE_MEMORY_MAX_MB"), 64)
+	if err != nil {
+		return nil, err
+	}
+
+	// Export config
+	exportMaxSyncRows, err := parsePositiveInt("EXPORT_MAX_SYNC_ROWS", os.Getenv("EXPORT_MAX_SYNC_ROWS"), 50000)
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := parsePrefixes("TRUSTED_PROXIES", os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Join config
+	joinRateLimit, err := parsePositiveInt("JOIN_RATE_LIMIT", os.Getenv("JOIN_RATE_LIMIT"), 5)
+	if err != nil {
+		return nil, err
+	}
+	joinRateWindow, err := parseSeconds("JOIN_RATE_WINDOW_SECONDS", os.Getenv("JOIN_RATE_WINDOW_SECONDS"), time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	// Storage config
+	storageDriver := envOrDefault("STORAGE_DRIVER", "local")
+	storageS3 := storage.S3Options{
+		Endpoint:  os.Getenv("STORAGE_S3_ENDPOINT"),
+		Region:    envOrDefault("STORAGE_S3_REGION", "us-east-1"),
+		Bucket:    os.Getenv("STORAGE_S3_BUCKET"),
+		AccessKey: os.Getenv("STORAGE_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("STORAGE_S3_SECRET_KEY"),
+		PathStyle: os.Getenv("STORAGE_S3_PATH_STYLE") == "true",
+	}
+	switch storageDriver {
+	case "local":
+	case "s3":
+		if storageS3.Bucket == "" || storageS3.AccessKey == "" || storageS3.SecretKey == "" {
+			return nil, errors.New("STORAGE_S3_BUCKET, STORAGE_S3_ACCESS_KEY and STORAGE_S3_SECRET_KEY are required when STORAGE_DRIVER is s3")
+		}
+	default:
+		return nil, fmt.Errorf("error parsing STORAGE_DRIVER: %q", storageDriver)
+	}
+	storageURLTTL, err := parseSeconds("STORAGE_URL_TTL_SECONDS", os.Getenv("STORAGE_URL_TTL_SECONDS"), 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	// Without its own key, URL signing uses a key derived from the JWT secret
+	// so a leaked download signature says nothing about session tokens.
+	storageSigningKey := os.Getenv("STORAGE_SIGNING_KEY")
+	if storageSigningKey == "" {
+		if jwtSecret == "" {
+			return nil, errors.New("STORAGE_SIGNING_KEY is required when JWT_SECRET is not set")
+		}
+		storageSigningKey, err = deriveKey(jwtSecret, "fitcore storage url signing")
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if database == "" || password == "" || username == "" || dbPortStr == "" || host == "" || schema == "" {
@@ -99,18 +263,19 @@ func NewConfig() (*Config, error) {
 
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable&search_path=%s", username, password, host, dbPort, database, schema)
 
-
 	return &Config{
 		App: struct {
-			Env       string
-			Port      int
-			JWTSecret string
-			BaseURL   string
+			Env            string
+			Port           int
+			JWTSecret      string
+			BaseURL        string
+			TrustedProxies []netip.Prefix
 		}{
-			Env:       env,
-			Port:      serverPort,
-			JWTSecret: jwtSecret,
-			BaseURL:   baseURL,
+			Env:            env,
+			Port:           serverPort,
+			JWTSecret:      jwtSecret,
+			BaseURL:        baseURL,
+			TrustedProxies: trustedProxies,
 		},
 		Database: struct {
 			Host     string
@@ -150,13 +315,138 @@ func NewConfig() (*Config, error) {
 			WebhookSecret:  polarWebhookSecret,
 		},
 		Analytics: struct {
-			ServiceURL string
+			BaseURL         string
+			WellnessPath    string
+			ChatPath        string
+			ChatStreamPath  string
+			ChatSummaryPath string
+			ServiceToken    string
+			Timeout         time.Duration
+			ChatTimeout     time.Duration
+		}{
+			BaseURL:         analyticsBaseURL,
+			WellnessPath:    envOrDefault("ANALYTICS_WELLNESS_PATH", "/analyze/wellness"),
+			ChatPath:        envOrDefault("ANALYTICS_CHAT_PATH", "/chat/"),
+			ChatStreamPath:  envOrDefault("ANALYTICS_CHAT_STREAM_PATH", "/chat/stream"),
+			ChatSummaryPath: envOrDefault("ANALYTICS_CHAT_SUMMARY_PATH", "/chat/summarize"),
+			ServiceToken:    analyticsServiceToken,
+			Timeout:         analyticsTimeout,
+			ChatTimeout:     analyticsChatTimeout,
+		},
+		Cache: struct {
+			Driver           string
+			Redis            redis.Options
+			L1               bool
+			L1TTL            time.Duration
+			MemoryMaxEntries int
+			MemoryMaxBytes   int64
 		}{
-			ServiceURL: analyticsServiceURL,
+			Driver:           cacheDriver,
+			Redis:            redisOptions,
+			L1:               cacheL1,
+			L1TTL:            cacheL1TTL,
+			MemoryMaxEntries: cacheMaxEntries,
+			MemoryMaxBytes:   int64(cacheMaxMB) << 20,
+		},
+		Export: struct {
+			MaxSyncRows int
+		}{
+			MaxSyncRows: exportMaxSyncRows,
+		},
+		Join: struct {
+			CaptchaSecret    string
+			CaptchaVerifyURL string
+			RateLimit        int
+			RateWindow       time.Duration
+		}{
+			CaptchaSecret:    os.Getenv("JOIN_CAPTCHA_SECRET"),
+			CaptchaVerifyURL: envOrDefault("JOIN_CAPTCHA_VERIFY_URL", "https://challenges.cloudflare.com/turnstile/v0/siteverify"),
+			RateLimit:        joinRateLimit,
+			RateWindow:       joinRateWindow,
+		},
+		Storage: struct {
+			Driver     string
+			LocalDir   string
+			S3         storage.S3Options
+			PublicURL  string
+			SigningKey string
+			URLTTL     time.Duration
+		}{
+			Driver:     storageDriver,
+			LocalDir:   envOrDefault("STORAGE_LOCAL_DIR", filepath.Join("data", "uploads")),
+			S3:         storageS3,
+			PublicURL:  strings.TrimSuffix(os.Getenv("STORAGE_PUBLIC_URL"), "/"),
+			SigningKey: storageSigningKey,
+			URLTTL:     storageURLTTL,
 		},
 	}, nil
 }
 
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// deriveKey expands secret into a hex-encoded 32-byte key bound to label,
+// so keys for different purposes cannot be swapped for one another.
+func deriveKey(secret, label string) (string, error) {
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, label, 32)
+	if err != nil {
+		return "", fmt.Errorf("error deriving %s key: %w", label, err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func parseSeconds(key, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("error parsing %s: %q", key, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func parsePositiveInt(key, value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("error parsing %s: %q", key, value)
+	}
+	return n, nil
+}
+
+// parsePrefixes reads a comma-separated list of CIDR ranges or single
+// addresses.
+func parsePrefixes(key, value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s: %q", key, entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %q", key, entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
 func Init() error {
 	var err error
 	cfg, err = NewConfig()
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn on a fixed interval until ctx is cancelled. It is meant to be
+// started in its own goroutine. Runs never overlap: a slow run delays the next
+// tick rather than stacking up.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Jobs: %s scheduled every %s", name, interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Jobs: %s stopped", name)
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				log.Printf("Jobs: %s failed - %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"fitcore/internal/config"
+	"fitcore/internal/response"
+)
+
+// RateLimit allows each client IP at most limit requests per window and
+// answers 429 beyond that. Counters live in process memory, so with several
+// replicas the effective limit is per replica.
+func RateLimit(limit int, window time.Duration) func(http.Handler) http.Handler {
+	l := &rateLimiter{limit: limit, window: window, clients: make(map[string]*rateWindow)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if retryAfter, ok := l.allow(ClientIP(r), time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				response.TooManyRequests(w, "Too many requests, please try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the caller's address. Behind the Cloudflare tunnel the
+// connection comes from cloudflared, so the CF-Connecting-IP header is used
+// when the connection comes from one of the configured trusted proxies.
+// Anyone else could set the header to anything.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := r.Header.Get("CF-Connecting-IP"); ip != "" && trustedProxy(host) {
+		if addr, err := netip.ParseAddr(ip); err == nil {
+			return addr.String()
+		}
+	}
+	return host
+}
+
+func trustedProxy(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range config.Get().App.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	clients   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+// allow counts a request from key in a fixed window and, when it is over
+// the limit, reports how long until the window resets.
+func (l *rateLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Expired windows are dropped once per window so the map stays bounded by
+	// the number of recent clients.
+	if now.Sub(l.lastSweep) >= l.window {
+		for k, cw := range l.clients {
+			if now.Sub(cw.start) >= l.window {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	cw, ok := l.clients[key]
+	if !ok || now.Sub(cw.start) >= l.window {
+		l.clients[key] = &rateWindow{start: now, count: 1}
+		return 0, true
+	}
+	if cw.count >= l.limit {
+		return cw.start.Add(l.window).Sub(now), false
+	}
+	cw.count++
+	return 0, true
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Scope limits which organizations and branches a caller may see; nil
+// slices mean no restriction.
+type Scope struct {
+	OrganizationIDs []uuid.UUID
+	BranchIDs       []uuid.UUID
+}
+
+// Allows reports whether a record of organizationID at branchID is inside
+// the scope. Records without a branch are hidden from branch-scoped callers.
+func (s *Scope) Allows(organizationID uuid.UUID, branchID *uuid.UUID) bool {
+	if s.OrganizationIDs != nil && !slices.Contains(s.OrganizationIDs, organizationID) {
+		return false
+	}
+	if s.BranchIDs != nil && (branchID == nil || !slices.Contains(s.BranchIDs, *branchID)) {
+		return false
+	}
+	return true
+}
+
+// ScopeSource looks up a user's assignments; user.Service satisfies it.
+type ScopeSource interface {
+	GetUserBranchIDs(ctx context.Context, userID uuid.UUID, userRole string) ([]uuid.UUID, error)
+	GetUserOrganizationIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// ResolveScope returns what the caller may see: super admins everything,
+// staff their assigned branches and everyone else their organizations.
+func ResolveScope(ctx context.Context, users ScopeSource, userID uuid.UUID, userRole string) (*Scope, error) {
+	switch userRole {
+	case "super_admin":
+		return &Scope{}, nil
+	case "staff":
+		branchIDs, err := users.GetUserBranchIDs(ctx, userID, userRole)
+		if err != nil {
+			log.Printf("Service: failed to list branches for user %s: %v", userID, err)
+			return nil, err
+		}
+		// Staff without assignments see nothing rather than everything.
+		return &Scope{BranchIDs: append([]uuid.UUID{}, branchIDs...)}, nil
+	default:
+		orgIDs, err := users.GetUserOrganizationIDs(ctx, userID)
+		if err != nil {
+			log.Printf("Service: failed to list organizations for user %s: %v", userID, err)
+			return nil, err
+		}
+		// Likewise for users outside any organization.
+		return &Scope{OrganizationIDs: append([]uuid.UUID{}, orgIDs...)}, nil
+	}
+}
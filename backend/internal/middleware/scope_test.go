@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type fakeScopeSource struct {
+	branchIDs []uuid.UUID
+	orgIDs    []uuid.UUID
+}
+
+func (f *fakeScopeSource) GetUserBranchIDs(ctx context.Context, userID uuid.UUID, userRole string) ([]uuid.UUID, error) {
+	return f.branchIDs, nil
+}
+
+func (f *fakeScopeSource) GetUserOrganizationIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return f.orgIDs, nil
+}
+
+func TestResolveScope(t *testing.T) {
+	org, branch := uuid.New(), uuid.New()
+	tests := []struct {
+		name   string
+		role   string
+		source *fakeScopeSource
+		want   *Scope
+	}{
+		{"super admin sees everything", "super_admin", &fakeScopeSource{orgIDs: []uuid.UUID{org}}, &Scope{}},
+		{"staff see their branches", "staff", &fakeScopeSource{branchIDs: []uuid.UUID{branch}}, &Scope{BranchIDs: []uuid.UUID{branch}}},
+		{"unassigned staff see nothing", "staff", &fakeScopeSource{}, &Scope{BranchIDs: []uuid.UUID{}}},
+		{"admins see their organizations", "admin", &fakeScopeSource{orgIDs: []uuid.UUID{org}}, &Scope{OrganizationIDs: []uuid.UUID{org}}},
+		{"admins without organizations see nothing", "admin", &fakeScopeSource{}, &Scope{OrganizationIDs: []uuid.UUID{}}},
+	}
+	for _, tt := range tests {
+		got, err := ResolveScope(context.Background(), tt.source, uuid.New(), tt.role)
+		if err != nil {
+			t.Errorf("%s: ResolveScope error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ResolveScope = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	org, otherOrg := uuid.New(), uuid.New()
+	branch, otherBranch := uuid.New(), uuid.New()
+	tests := []struct {
+		name     string
+		scope    *Scope
+		org      uuid.UUID
+		branchID *uuid.UUID
+		want     bool
+	}{
+		{"unrestricted", &Scope{}, otherOrg, nil, true},
+		{"own organization", &Scope{OrganizationIDs: []uuid.UUID{org}}, org, &otherBranch, true},
+		{"own organization without branch", &Scope{OrganizationIDs: []uuid.UUID{org}}, org, nil, true},
+		{"other organization", &Scope{OrganizationIDs: []uuid.UUID{org}}, otherOrg, &branch, false},
+		{"no organizations", &Scope{OrganizationIDs: []uuid.UUID{}}, org, &branch, false},
+		{"assigned branch", &Scope{BranchIDs: []uuid.UUID{branch}}, otherOrg, &branch, true},
+		{"other branch", &Scope{BranchIDs: []uuid.UUID{branch}}, org, &otherBranch, false},
+		{"no branch for branch-scoped caller", &Scope{BranchIDs: []uuid.UUID{branch}}, org, nil, false},
+		{"no branches", &Scope{BranchIDs: []uuid.UUID{}}, org, &branch, false},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.Allows(tt.org, tt.branchID); got != tt.want {
+			t.Errorf("%s: Allows = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
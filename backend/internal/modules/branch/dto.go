@@ -7,34 +7,40 @@ import (
 )
 
 type CreateBranchRequest struct {
-	OrganizationID uuid.UUID `json:"organizationId" validate:"required"`
-	Name           string    `json:"name" validate:"required"`
-	Code           *string   `json:"code,omitempty"`
-	Address        *string   `json:"address,omitempty"`
-	Phone          *string   `json:"phone,omitempty"`
-	Email          *string   `json:"email,omitempty"`
-	Timezone       *string   `json:"timezone,omitempty"`
+	OrganizationID          uuid.UUID `json:"organizationId" validate:"required"`
+	Name                    string    `json:"name" validate:"required"`
+	Code                    *string   `json:"code,omitempty"`
+	Address                 *string   `json:"address,omitempty"`
+	Phone                   *string   `json:"phone,omitempty"`
+	Email                   *string   `json:"email,omitempty"`
+	Timezone                *string   `json:"timezone,omitempty"`
+	OccupancyAlertThreshold *int      `json:"occupancyAlertThreshold,omitempty" validate:"omitempty,gt=0"`
+	MaxOccupancy            *int      `json:"maxOccupancy,omitempty" validate:"omitempty,gt=0"`
 }
 
 type UpdateBranchRequest struct {
-	Name     string  `json:"name,omitempty"`
-	Code     *string `json:"code,omitempty"`
-	Address  *string `json:"address,omitempty"`
-	Phone    *string `json:"phone,omitempty"`
-	Email    *string `json:"email,omitempty"`
-	Timezone *string `json:"timezone,omitempty"`
-	IsActive *bool   `json:"isActive,omitempty"`
+	Name                    string  `json:"name,omitempty"`
+	Code                    *string `json:"code,omitempty"`
+	Address                 *string `json:"address,omitempty"`
+	Phone                   *string `json:"phone,omitempty"`
+	Email                   *string `json:"email,omitempty"`
+	Timezone                *string `json:"timezone,omitempty"`
+	IsActive                *bool   `json:"isActive,omitempty"`
+	OccupancyAlertThreshold *int    `json:"occupancyAlertThreshold,omitempty" validate:"omitempty,gt=0"`
+	MaxOccupancy            *int    `json:"maxOccupancy,omitempty" validate:"omitempty,gt=0"`
 }
 
 type BranchResponse struct {
-	ID             uuid.UUID  `json:"id"`
-	OrganizationID uuid.UUID  `json:"organizationId"`
-	Name           string     `json:"name"`
-	Code           *string    `json:"code,omitempty"`
-	Address        *string    `json:"address,omitempty"`
-	Phone          *string    `json:"phone,omitempty"`
-	Email          *string    `json:"email,omitempty"`
-	Timezone       *string    `json:"timezone,omitempty"`
-	IsActive       *bool      `json:"isActive,omitempty"`
-	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+	ID                      uuid.UUID  `json:"id"`
+	OrganizationID          uuid.UUID  `json:"organizationId"`
+	Name                    string     `json:"name"`
+	Code                    *string    `json:"code,omitempty"`
+	Address                 *string    `json:"address,omitempty"`
+	Phone                   *string    `json:"phone,omitempty"`
+	Email                   *string    `json:"email,omitempty"`
+	Timezone                *string    `json:"timezone,omitempty"`
+	IsActive                *bool      `json:"isActive,omitempty"`
+	OccupancyAlertThreshold *int       `json:"occupancyAlertThreshold,omitempty"`
+	MaxOccupancy            *int       `json:"maxOccupancy,omitempty"`
+	UpdatedAt               *time.Time `json:"updatedAt,omitempty"`
 }
@@ -25,8 +25,8 @@ func NewRepository(db *pgxpool.Pool) Repository {
 
 func (r *repositoryImpl) Create(ctx context.Context, branch *Branch) error {
 	query := `
-		INSERT INTO branches (organization_id, name, code, address, phone, email, timezone, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO branches (organization_id, name, code, address, phone, email, timezone, is_active, occupancy_alert_threshold, max_occupancy)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, updated_at
 	`
 	return r.db.QueryRow(ctx, query,
@@ -38,14 +38,16 @@ func (r *repositoryImpl) Create(ctx context.Context, branch *Branch) error {
 		branch.Email,
 		branch.Timezone,
 		branch.IsActive,
+		branch.OccupancyAlertThreshold,
+		branch.MaxOccupancy,
 	).Scan(&branch.ID, &branch.UpdatedAt)
 }
 
 func (r *repositoryImpl) Update(ctx context.Context, branch *Branch) error {
 	query := `
 		UPDATE branches
-		SET name = $1, code = $2, address = $3, phone = $4, email = $5, timezone = $6, is_active = $7, updated_at = NOW()
-		WHERE id = $8 AND deleted_at IS NULL
+		SET name = $1, code = $2, address = $3, phone = $4, email = $5, timezone = $6, is_active = $7, occupancy_alert_threshold = $8, max_occupancy = $9, updated_at = NOW()
+		WHERE id = $10 AND deleted_at IS NULL
 		RETURNING updated_at
 	`
 	return r.db.QueryRow(ctx, query,
@@ -56,6 +58,8 @@ func (r *repositoryImpl) Update(ctx context.Context, branch *Branch) error {
 		branch.Email,
 		branch.Timezone,
 		branch.IsActive,
+		branch.OccupancyAlertThreshold,
+		branch.MaxOccupancy,
 		branch.ID,
 	).Scan(&branch.UpdatedAt)
 }
@@ -68,7 +72,7 @@ func (r *repositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
 
 func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Branch, error) {
 	query := `
-		SELECT id, organization_id, name, code, address, phone, email, timezone, is_active, updated_at
+		SELECT id, organization_id, name, code, address, phone, email, timezone, is_active, occupancy_alert_threshold, max_occupancy, updated_at
 		FROM branches
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -83,6 +87,8 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Branch, er
 		&branch.Email,
 		&branch.Timezone,
 		&branch.IsActive,
+		&branch.OccupancyAlertThreshold,
+		&branch.MaxOccupancy,
 		&branch.UpdatedAt,
 	)
 	if err != nil {
@@ -93,7 +99,7 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Branch, er
 
 func (r *repositoryImpl) List(ctx context.Context, limit, offset int) ([]*Branch, error) {
 	query := `
-		SELECT id, organization_id, name, code, address, phone, email, timezone, is_active, updated_at
+		SELECT id, organization_id, name, code, address, phone, email, timezone, is_active, occupancy_alert_threshold, max_occupancy, updated_at
 		FROM branches
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -118,6 +124,8 @@ func (r *repositoryImpl) List(ctx context.Context, limit, offset int) ([]*Branch
 			&branch.Email,
 			&branch.Timezone,
 			&branch.IsActive,
+			&branch.OccupancyAlertThreshold,
+			&branch.MaxOccupancy,
 			&branch.UpdatedAt,
 		); err != nil {
 			return nil, err
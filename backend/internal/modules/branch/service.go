@@ -24,14 +24,16 @@ func NewService(repo Repository) Service {
 
 func (s *serviceImpl) CreateBranch(ctx context.Context, req *CreateBranchRequest) (*Branch, error) {
 	branch := &Branch{
-		OrganizationID: req.OrganizationID,
-		Name:           req.Name,
-		Code:           req.Code,
-		Address:        req.Address,
-		Phone:          req.Phone,
-		Email:          req.Email,
-		Timezone:       req.Timezone,
-		IsActive:       nil, // DB default is true
+		OrganizationID:          req.OrganizationID,
+		Name:                    req.Name,
+		Code:                    req.Code,
+		Address:                 req.Address,
+		Phone:                   req.Phone,
+		Email:                   req.Email,
+		Timezone:                req.Timezone,
+		IsActive:                nil, // DB default is true
+		OccupancyAlertThreshold: req.OccupancyAlertThreshold,
+		MaxOccupancy:            req.MaxOccupancy,
 	}
 	// default isActive to true if not specified?
 	// The DB defaults to true. If we pass nil, it depends on how we handle it.
@@ -75,6 +77,12 @@ func (s *serviceImpl) UpdateBranch(ctx context.Context, id uuid.UUID, req *Updat
 	if req.IsActive != nil {
 		branch.IsActive = req.IsActive
 	}
+	if req.OccupancyAlertThreshold != nil {
+		branch.OccupancyAlertThreshold = req.OccupancyAlertThreshold
+	}
+	if req.MaxOccupancy != nil {
+		branch.MaxOccupancy = req.MaxOccupancy
+	}
 
 	if err := s.repo.Update(ctx, branch); err != nil {
 		return nil, err
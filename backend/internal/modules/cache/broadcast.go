@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"fitcore/internal/config"
+	"fitcore/pkg/redis"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const invalidationChannel = "cache_invalidation"
+
+// broadcaster carries invalidations between replicas.
+type broadcaster interface {
+	publish(ctx context.Context, payload []byte) error
+	// listen blocks until ctx is cancelled or the connection drops. ready is
+	// called once messages are being received.
+	listen(ctx context.Context, ready func(), handle func(payload []byte)) error
+}
+
+type postgresBroadcaster struct {
+	db *pgxpool.Pool
+}
+
+func (b *postgresBroadcaster) publish(ctx context.Context, payload []byte) error {
+	_, err := b.db.Exec(ctx, `SELECT pg_notify($1, $2)`, invalidationChannel, string(payload))
+	return err
+}
+
+// listen uses a dedicated connection, outside the pool, like the occupancy
+// listener.
+func (b *postgresBroadcaster) listen(ctx context.Context, ready func(), handle func(payload []byte)) error {
+	conn, err := pgx.Connect(ctx, config.Get().Database.ConnStr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+invalidationChannel); err != nil {
+		return err
+	}
+	ready()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle([]byte(notification.Payload))
+	}
+}
+
+type redisBroadcaster struct {
+	client *redis.Client
+}
+
+func (b *redisBroadcaster) publish(ctx context.Context, payload []byte) error {
+	return b.client.Publish(ctx, invalidationChannel, payload)
+}
+
+func (b *redisBroadcaster) listen(ctx context.Context, ready func(), handle func(payload []byte)) error {
+	return b.client.Subscribe(ctx, invalidationChannel, ready, handle)
+}
+
+type invalidation struct {
+	Origin string `json:"o"`
+	Key    string `json:"k,omitempty"`
+	Tag    string `json:"t,omitempty"`
+}
+
+// syncedRepository publishes every write and invalidation so other replicas
+// drop their in-process copies. Publishing is best effort: a lost message
+// leaves a stale L1 copy until it expires.
+type syncedRepository struct {
+	Repository
+	local  *memoryRepository
+	bus    broadcaster
+	origin string
+}
+
+func newSyncedRepository(repo Repository, local *memoryRepository, bus broadcaster) *syncedRepository {
+	return &syncedRepository{Repository: repo, local: local, bus: bus, origin: uuid.NewString()}
+}
+
+func (r *syncedRepository) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	if err := r.Repository.Set(ctx, key, value, expiresAt); err != nil {
+		return err
+	}
+	r.publish(ctx, invalidation{Key: key})
+	return nil
+}
+
+func (r *syncedRepository) Delete(ctx context.Context, key string) error {
+	if err := r.Repository.Delete(ctx, key); err != nil {
+		return err
+	}
+	r.publish(ctx, invalidation{Key: key})
+	return nil
+}
+
+func (r *syncedRepository) SetEntry(ctx context.Context, key string, value []byte, staleAt *time.Time, expiresAt time.Time, tags []string, computedAt time.Time) (bool, error) {
+	stored, err := r.Repository.SetEntry(ctx, key, value, staleAt, expiresAt, tags, computedAt)
+	if stored {
+		r.publish(ctx, invalidation{Key: key})
+	}
+	return stored, err
+}
+
+func (r *syncedRepository) InvalidateTag(ctx context.Context, tag string, invalidatedAt, markerExpiresAt time.Time) (int64, error) {
+	deleted, err := r.Repository.InvalidateTag(ctx, tag, invalidatedAt, markerExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	r.publish(ctx, invalidation{Tag: tag})
+	return deleted, nil
+}
+
+func (r *syncedRepository) publish(ctx context.Context, msg invalidation) {
+	msg.Origin = r.origin
+	payload, err := json.Marshal(msg)
+	if err == nil {
+		err = r.bus.publish(ctx, payload)
+	}
+	if err != nil {
+		log.Printf("Cache: failed to publish invalidation: %v", err)
+	}
+}
+
+func (r *syncedRepository) Describe() StoreInfo {
+	return describe(r.Repository)
+}
+
+// Listen applies invalidations from other replicas to the local tier,
+// reconnecting with a backoff whenever the connection drops.
+func (r *syncedRepository) Listen(ctx context.Context) {
+	backoff := time.Second
+	connected := false
+	for {
+		err := r.bus.listen(ctx, func() {
+			backoff = time.Second
+			log.Printf("Cache: listening for invalidations on %s", invalidationChannel)
+			// Invalidations sent while disconnected were missed.
+			if connected {
+				r.local.clear()
+			}
+			connected = true
+		}, func(payload []byte) {
+			var msg invalidation
+			if err := json.Unmarshal(payload, &msg); err != nil {
+				log.Printf("Cache: invalid invalidation payload: %v", err)
+				return
+			}
+			if msg.Origin == r.origin {
+				return
+			}
+			if msg.Key != "" {
+				r.local.evict(msg.Key)
+			}
+			if msg.Tag != "" {
+				r.local.evictTag(msg.Tag)
+			}
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Cache: invalidation listener stopped, reconnecting in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"net/http"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/cache", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+		r.Get("/stats", h.GetStats)
+	})
+}
+
+// GetStats reports this instance's cache counters since startup.
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	response.Success(w, "Cache stats retrieved successfully", h.service.Stats())
+}
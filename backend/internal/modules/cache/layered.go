@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// layeredRepository serves reads from an in-process L1 in front of a shared
+// L2. Writes go to L2 first; L1 copies live at most localTTL so a missed
+// cross-replica invalidation heals quickly.
+type layeredRepository struct {
+	local    *memoryRepository
+	shared   Repository
+	localTTL time.Duration
+}
+
+func newLayeredRepository(local *memoryRepository, shared Repository, localTTL time.Duration) *layeredRepository {
+	return &layeredRepository{local: local, shared: shared, localTTL: localTTL}
+}
+
+func (r *layeredRepository) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	if err := r.shared.Set(ctx, key, value, expiresAt); err != nil {
+		return err
+	}
+	return r.local.Set(ctx, key, value, r.localExpiry(expiresAt))
+}
+
+func (r *layeredRepository) Get(ctx context.Context, key string) ([]byte, error) {
+	entry, err := r.GetEntry(ctx, key)
+	if entry == nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+func (r *layeredRepository) Delete(ctx context.Context, key string) error {
+	if err := r.shared.Delete(ctx, key); err != nil {
+		return err
+	}
+	return r.local.Delete(ctx, key)
+}
+
+func (r *layeredRepository) GetEntry(ctx context.Context, key string) (*Entry, error) {
+	if entry, _ := r.local.GetEntry(ctx, key); entry != nil {
+		return entry, nil
+	}
+
+	entry, err := r.shared.GetEntry(ctx, key)
+	if entry == nil {
+		return nil, err
+	}
+	r.local.SetEntry(ctx, key, entry.Value, entry.StaleAt, r.localExpiry(entry.ExpiresAt), entry.Tags, time.Now())
+	return entry, nil
+}
+
+func (r *layeredRepository) SetEntry(ctx context.Context, key string, value []byte, staleAt *time.Time, expiresAt time.Time, tags []string, computedAt time.Time) (bool, error) {
+	stored, err := r.shared.SetEntry(ctx, key, value, staleAt, expiresAt, tags, computedAt)
+	if err != nil || !stored {
+		return stored, err
+	}
+	return r.local.SetEntry(ctx, key, value, staleAt, r.localExpiry(expiresAt), tags, computedAt)
+}
+
+func (r *layeredRepository) InvalidateTag(ctx context.Context, tag string, invalidatedAt, markerExpiresAt time.Time) (int64, error) {
+	deleted, err := r.shared.InvalidateTag(ctx, tag, invalidatedAt, markerExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	r.local.InvalidateTag(ctx, tag, invalidatedAt, markerExpiresAt)
+	return deleted, nil
+}
+
+func (r *layeredRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	r.local.DeleteExpired(ctx)
+	return r.shared.DeleteExpired(ctx)
+}
+
+func (r *layeredRepository) Describe() StoreInfo {
+	info := r.local.Describe()
+	info.Backend = BackendMemory + "+" + describe(r.shared).Backend
+	return info
+}
+
+func (r *layeredRepository) localExpiry(expiresAt time.Time) time.Time {
+	if limit := time.Now().Add(r.localTTL); r.localTTL > 0 && limit.Before(expiresAt) {
+		return limit
+	}
+	return expiresAt
+}
@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type MemoryStats struct {
+	Entries    int   `json:"entries"`
+	Bytes      int64 `json:"bytes"`
+	MaxEntries int   `json:"maxEntries"`
+	MaxBytes   int64 `json:"maxBytes"`
+	Evictions  int64 `json:"evictions"`
+}
+
+type memoryItem struct {
+	key   string
+	entry Entry
+	size  int64
+}
+
+// memoryRepository is an in-process LRU bounded by entry count and by the
+// total size of keys and values. Expired entries are dropped on read and by
+// DeleteExpired.
+type memoryRepository struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+
+	items     map[string]*list.Element
+	lru       *list.List
+	byTag     map[string]map[string]struct{}
+	markers   map[string]tagMarker
+	bytes     int64
+	evictions int64
+}
+
+type tagMarker struct {
+	invalidatedAt time.Time
+	expiresAt     time.Time
+}
+
+func newMemoryRepository(maxEntries int, maxBytes int64) *memoryRepository {
+	return &memoryRepository{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		byTag:      make(map[string]map[string]struct{}),
+		markers:    make(map[string]tagMarker),
+	}
+}
+
+func (r *memoryRepository) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store(key, Entry{Value: value, ExpiresAt: expiresAt})
+	return nil
+}
+
+func (r *memoryRepository) Get(ctx context.Context, key string) ([]byte, error) {
+	entry, err := r.GetEntry(ctx, key)
+	if entry == nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.items[key]; ok {
+		r.remove(el)
+	}
+	return nil
+}
+
+func (r *memoryRepository) GetEntry(ctx context.Context, key string) (*Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.items[key]
+	if !ok {
+		return nil, nil
+	}
+	item := el.Value.(*memoryItem)
+	if !time.Now().Before(item.entry.ExpiresAt) {
+		r.remove(el)
+		return nil, nil
+	}
+	r.lru.MoveToFront(el)
+
+	entry := item.entry
+	return &entry, nil
+}
+
+func (r *memoryRepository) SetEntry(ctx context.Context, key string, value []byte, staleAt *time.Time, expiresAt time.Time, tags []string, computedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, tag := range tags {
+		if m, ok := r.markers[tag]; ok && now.Before(m.expiresAt) && m.invalidatedAt.After(computedAt) {
+			return false, nil
+		}
+	}
+	return r.store(key, Entry{Value: value, StaleAt: staleAt, ExpiresAt: expiresAt, Tags: slices.Clone(tags)}), nil
+}
+
+func (r *memoryRepository) InvalidateTag(ctx context.Context, tag string, invalidatedAt, markerExpiresAt time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.markers[tag] = tagMarker{invalidatedAt: invalidatedAt, expiresAt: markerExpiresAt}
+	return r.dropTag(tag), nil
+}
+
+func (r *memoryRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var deleted int64
+	for el := r.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if !now.Before(el.Value.(*memoryItem).entry.ExpiresAt) {
+			r.remove(el)
+			deleted++
+		}
+		el = prev
+	}
+	for tag, m := range r.markers {
+		if !now.Before(m.expiresAt) {
+			delete(r.markers, tag)
+		}
+	}
+	return deleted, nil
+}
+
+// evict drops a key without recording anything, for invalidations that
+// originate on another replica.
+func (r *memoryRepository) evict(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.items[key]; ok {
+		r.remove(el)
+	}
+}
+
+func (r *memoryRepository) evictTag(tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dropTag(tag)
+}
+
+func (r *memoryRepository) clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items = make(map[string]*list.Element)
+	r.lru.Init()
+	r.byTag = make(map[string]map[string]struct{})
+	r.bytes = 0
+}
+
+func (r *memoryRepository) Describe() StoreInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return StoreInfo{
+		Backend: BackendMemory,
+		Memory: &MemoryStats{
+			Entries:    len(r.items),
+			Bytes:      r.bytes,
+			MaxEntries: r.maxEntries,
+			MaxBytes:   r.maxBytes,
+			Evictions:  r.evictions,
+		},
+	}
+}
+
+// store inserts or replaces an entry and evicts least recently used entries
+// until the limits hold. A value larger than the byte limit is not stored.
+func (r *memoryRepository) store(key string, entry Entry) bool {
+	if el, ok := r.items[key]; ok {
+		r.remove(el)
+	}
+
+	item := &memoryItem{key: key, entry: entry, size: int64(len(key) + len(entry.Value))}
+	if r.maxBytes > 0 && item.size > r.maxBytes {
+		return false
+	}
+
+	r.items[key] = r.lru.PushFront(item)
+	r.bytes += item.size
+	for _, tag := range entry.Tags {
+		if r.byTag[tag] == nil {
+			r.byTag[tag] = make(map[string]struct{})
+		}
+		r.byTag[tag][key] = struct{}{}
+	}
+
+	for (r.maxEntries > 0 && len(r.items) > r.maxEntries) || (r.maxBytes > 0 && r.bytes > r.maxBytes) {
+		r.remove(r.lru.Back())
+		r.evictions++
+	}
+	return true
+}
+
+func (r *memoryRepository) dropTag(tag string) int64 {
+	var deleted int64
+	for key := range r.byTag[tag] {
+		if el, ok := r.items[key]; ok {
+			r.remove(el)
+			deleted++
+		}
+	}
+	delete(r.byTag, tag)
+	return deleted
+}
+
+func (r *memoryRepository) remove(el *list.Element) {
+	item := r.lru.Remove(el).(*memoryItem)
+	delete(r.items, item.key)
+	r.bytes -= item.size
+	for _, tag := range item.entry.Tags {
+		delete(r.byTag[tag], item.key)
+		if len(r.byTag[tag]) == 0 {
+			delete(r.byTag, tag)
+		}
+	}
+}
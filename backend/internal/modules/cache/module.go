@@ -1,25 +1,78 @@
 package cache
 
 import (
+	"log"
+	"time"
+
+	"fitcore/pkg/redis"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// StoreConfig selects the cache store. Driver is the shared store
+// (postgres, redis) or memory for a per-process cache only. With L1 set, an
+// in-process LRU is layered in front of the shared store.
+type StoreConfig struct {
+	Driver string
+	Redis  redis.Options
+
+	L1               bool
+	L1TTL            time.Duration
+	MemoryMaxEntries int
+	MemoryMaxBytes   int64
+}
+
 type Module struct {
+	Handler    *Handler
 	Service    Service
 	Repository Repository
 }
 
-func NewModule(db *pgxpool.Pool) *Module {
-	repo := NewRepository(db)
+func NewModule(db *pgxpool.Pool, cfg StoreConfig) *Module {
+	repo := newStore(db, cfg)
 	svc := NewService(repo)
 
 	return &Module{
+		Handler:    NewHandler(svc),
 		Service:    svc,
 		Repository: repo,
 	}
 }
 
 func (m *Module) RegisterRoutes(r chi.Router) {
-	// No HTTP routes for cache module as it's an internal service
+	m.Handler.RegisterRoutes(r)
+}
+
+func newStore(db *pgxpool.Pool, cfg StoreConfig) Repository {
+	var (
+		shared Repository
+		bus    broadcaster = &postgresBroadcaster{db: db}
+	)
+	switch cfg.Driver {
+	case BackendRedis:
+		client := redis.NewClient(cfg.Redis)
+		shared = NewRedisRepository(client)
+		bus = &redisBroadcaster{client: client}
+	case BackendMemory:
+	default:
+		shared = NewPostgresRepository(db)
+	}
+
+	var local *memoryRepository
+	repo := shared
+	switch {
+	case shared == nil:
+		local = newMemoryRepository(cfg.MemoryMaxEntries, cfg.MemoryMaxBytes)
+		repo = local
+	case cfg.L1:
+		local = newMemoryRepository(cfg.MemoryMaxEntries, cfg.MemoryMaxBytes)
+		repo = newLayeredRepository(local, shared, cfg.L1TTL)
+	}
+	if local != nil {
+		repo = newSyncedRepository(repo, local, bus)
+	}
+
+	log.Printf("Cache: using %s store", describe(repo).Backend)
+	return repo
 }
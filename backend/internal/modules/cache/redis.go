@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"fitcore/pkg/redis"
+)
+
+const (
+	redisKeyPrefix = "cache:"
+	// redisTagPrefix holds, per tag, the set of keys carrying it.
+	redisTagPrefix = "cache-tag:"
+)
+
+// redisEnvelope is how an entry is stored; Redis only knows the key's TTL.
+type redisEnvelope struct {
+	Value     []byte     `json:"v"`
+	StaleAt   *time.Time `json:"s,omitempty"`
+	ExpiresAt time.Time  `json:"e"`
+	Tags      []string   `json:"t,omitempty"`
+}
+
+// setEntryScript writes an entry unless one of its tags was invalidated
+// after it was computed. Checking the markers and writing happen in one
+// script, so no invalidation can slip in between.
+//
+// KEYS: the entry, then one marker per tag, then one tag set per tag.
+// ARGV: envelope, TTL in ms, computedAt in µs since the epoch, cache key.
+var setEntryScript = redis.NewScript(`
+local tags = (#KEYS - 1) / 2
+local computedAt = tonumber(ARGV[3])
+for i = 2, tags + 1 do
+	local invalidatedAt = tonumber(redis.call('GET', KEYS[i]))
+	if invalidatedAt and invalidatedAt > computedAt then
+		return 0
+	end
+end
+
+local ttl = tonumber(ARGV[2])
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+for i = tags + 2, #KEYS do
+	redis.call('SADD', KEYS[i], ARGV[4])
+	if redis.call('PTTL', KEYS[i]) < ttl then
+		redis.call('PEXPIRE', KEYS[i], ARGV[2])
+	end
+end
+return 1
+`)
+
+// invalidateTagScript records the tag marker and deletes the tagged entries
+// together, so an entry written concurrently is either rejected by the
+// marker or listed in the set and deleted.
+//
+// KEYS: the marker, the tag set. ARGV: invalidatedAt in µs since the epoch,
+// marker TTL in ms, entry key prefix.
+var invalidateTagScript = redis.NewScript(`
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+local deleted = 0
+for _, key in ipairs(redis.call('SMEMBERS', KEYS[2])) do
+	deleted = deleted + redis.call('DEL', ARGV[3] .. key)
+end
+redis.call('DEL', KEYS[2])
+return deleted
+`)
+
+// redisRepository stores entries as Redis strings and relies on Redis to
+// expire them. Tag membership is tracked in sets that expire with their
+// longest-lived entry.
+type redisRepository struct {
+	client *redis.Client
+}
+
+func NewRedisRepository(client *redis.Client) Repository {
+	return &redisRepository{client: client}
+}
+
+func (r *redisRepository) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+	return r.write(ctx, key, redisEnvelope{Value: value, ExpiresAt: expiresAt})
+}
+
+func (r *redisRepository) Get(ctx context.Context, key string) ([]byte, error) {
+	entry, err := r.GetEntry(ctx, key)
+	if entry == nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+func (r *redisRepository) Delete(ctx context.Context, key string) error {
+	_, err := r.client.Del(ctx, redisKeyPrefix+key)
+	return err
+}
+
+func (r *redisRepository) GetEntry(ctx context.Context, key string) (*Entry, error) {
+	data, err := r.client.Get(ctx, redisKeyPrefix+key)
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var env redisEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	return &Entry{Value: env.Value, StaleAt: env.StaleAt, ExpiresAt: env.ExpiresAt, Tags: env.Tags}, nil
+}
+
+func (r *redisRepository) SetEntry(ctx context.Context, key string, value []byte, staleAt *time.Time, expiresAt time.Time, tags []string, computedAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false, r.Delete(ctx, key)
+	}
+	data, err := json.Marshal(redisEnvelope{Value: value, StaleAt: staleAt, ExpiresAt: expiresAt, Tags: tags})
+	if err != nil {
+		return false, err
+	}
+
+	keys := make([]string, 1, 1+2*len(tags))
+	keys[0] = redisKeyPrefix + key
+	for _, tag := range tags {
+		keys = append(keys, redisKeyPrefix+tagMarkerPrefix+tag)
+	}
+	for _, tag := range tags {
+		keys = append(keys, redisTagPrefix+tag)
+	}
+
+	reply, err := setEntryScript.Run(ctx, r.client, keys, data, max(ttl.Milliseconds(), 1), computedAt.UnixMicro(), key)
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+func (r *redisRepository) InvalidateTag(ctx context.Context, tag string, invalidatedAt, markerExpiresAt time.Time) (int64, error) {
+	keys := []string{redisKeyPrefix + tagMarkerPrefix + tag, redisTagPrefix + tag}
+	reply, err := invalidateTagScript.Run(ctx, r.client, keys, invalidatedAt.UnixMicro(), max(time.Until(markerExpiresAt).Milliseconds(), 1), redisKeyPrefix)
+	if err != nil {
+		return 0, err
+	}
+	deleted, _ := reply.(int64)
+	return deleted, nil
+}
+
+// DeleteExpired is a no-op: Redis expires keys itself.
+func (r *redisRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (r *redisRepository) Describe() StoreInfo {
+	return StoreInfo{Backend: BackendRedis}
+}
+
+func (r *redisRepository) write(ctx context.Context, key string, env redisEnvelope) error {
+	ttl := time.Until(env.ExpiresAt)
+	if ttl <= 0 {
+		return r.Delete(ctx, key)
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, redisKeyPrefix+key, data, ttl)
+}
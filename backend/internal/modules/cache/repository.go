@@ -8,32 +8,80 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// tagMarkerPrefix namespaces the entries that record when a tag was last
+// invalidated.
+const tagMarkerPrefix = "__tag:"
+
+// Entry is a cached value with its freshness. StaleAt is nil for entries
+// that are fresh until they expire.
+type Entry struct {
+	Value     []byte
+	StaleAt   *time.Time
+	ExpiresAt time.Time
+	Tags      []string
+}
+
+// Repository is a cache store. Drivers: Postgres (the unlogged_cache
+// table), Redis and an in-process LRU, optionally layered.
 type Repository interface {
 	Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error
 	Get(ctx context.Context, key string) ([]byte, error)
 	Delete(ctx context.Context, key string) error
+
+	GetEntry(ctx context.Context, key string) (*Entry, error)
+	// SetEntry stores the value unless one of its tags was invalidated after
+	// computedAt, so a slow computation cannot overwrite a newer
+	// invalidation. It reports whether the value was stored.
+	SetEntry(ctx context.Context, key string, value []byte, staleAt *time.Time, expiresAt time.Time, tags []string, computedAt time.Time) (bool, error)
+	// InvalidateTag deletes every entry carrying the tag and records the
+	// invalidation until markerExpiresAt.
+	InvalidateTag(ctx context.Context, tag string, invalidatedAt, markerExpiresAt time.Time) (int64, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+const (
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+	BackendMemory   = "memory"
+)
+
+// StoreInfo describes the configured store for the stats endpoint.
+type StoreInfo struct {
+	Backend string       `json:"backend"`
+	Memory  *MemoryStats `json:"memory,omitempty"`
+}
+
+type describer interface {
+	Describe() StoreInfo
 }
 
-type repository struct {
+func describe(repo Repository) StoreInfo {
+	if d, ok := repo.(describer); ok {
+		return d.Describe()
+	}
+	return StoreInfo{Backend: "unknown"}
+}
+
+type postgresRepository struct {
 	db *pgxpool.Pool
 }
 
-func NewRepository(db *pgxpool.Pool) Repository {
-	return &repository{db: db}
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &postgresRepository{db: db}
 }
 
-func (r *repository) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
+func (r *postgresRepository) Set(ctx context.Context, key string, value []byte, expiresAt time.Time) error {
 	query := `
 		INSERT INTO unlogged_cache (key, value, expires_at)
 		VALUES ($1, $2, $3)
 		ON CONFLICT (key) DO UPDATE
-		SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+		SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at, stale_at = NULL, tags = '{}'
 	`
 	_, err := r.db.Exec(ctx, query, key, value, expiresAt)
 	return err
 }
 
-func (r *repository) Get(ctx context.Context, key string) ([]byte, error) {
+func (r *postgresRepository) Get(ctx context.Context, key string) ([]byte, error) {
 	query := `SELECT value FROM unlogged_cache WHERE key = $1 AND expires_at > NOW()`
 	var value []byte
 	err := r.db.QueryRow(ctx, query, key).Scan(&value)
@@ -46,8 +94,74 @@ func (r *repository) Get(ctx context.Context, key string) ([]byte, error) {
 	return value, nil
 }
 
-func (r *repository) Delete(ctx context.Context, key string) error {
+func (r *postgresRepository) Delete(ctx context.Context, key string) error {
 	query := `DELETE FROM unlogged_cache WHERE key = $1`
 	_, err := r.db.Exec(ctx, query, key)
 	return err
 }
+
+func (r *postgresRepository) GetEntry(ctx context.Context, key string) (*Entry, error) {
+	query := `SELECT value, stale_at, expires_at, tags FROM unlogged_cache WHERE key = $1 AND expires_at > NOW()`
+	var entry Entry
+	err := r.db.QueryRow(ctx, query, key).Scan(&entry.Value, &entry.StaleAt, &entry.ExpiresAt, &entry.Tags)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *postgresRepository) SetEntry(ctx context.Context, key string, value []byte, staleAt *time.Time, expiresAt time.Time, tags []string, computedAt time.Time) (bool, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	query := `
+		INSERT INTO unlogged_cache (key, value, stale_at, expires_at, tags)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM unlogged_cache marker
+			WHERE marker.key IN (SELECT $7 || tag FROM unnest($5::text[]) AS tag)
+				AND marker.expires_at > NOW()
+				AND (marker.value->>'invalidatedAt')::timestamptz > $6
+		)
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value, stale_at = EXCLUDED.stale_at, expires_at = EXCLUDED.expires_at, tags = EXCLUDED.tags
+	`
+	tag, err := r.db.Exec(ctx, query, key, value, staleAt, expiresAt, tags, computedAt, tagMarkerPrefix)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *postgresRepository) InvalidateTag(ctx context.Context, tag string, invalidatedAt, markerExpiresAt time.Time) (int64, error) {
+	query := `
+		WITH deleted AS (
+			DELETE FROM unlogged_cache WHERE tags @> ARRAY[$1]::text[]
+			RETURNING 1
+		), marker AS (
+			INSERT INTO unlogged_cache (key, value, expires_at)
+			VALUES ($2, jsonb_build_object('invalidatedAt', $3::timestamptz), $4)
+			ON CONFLICT (key) DO UPDATE
+			SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+		)
+		SELECT COUNT(*) FROM deleted
+	`
+	var deleted int64
+	err := r.db.QueryRow(ctx, query, tag, tagMarkerPrefix+tag, invalidatedAt, markerExpiresAt).Scan(&deleted)
+	return deleted, err
+}
+
+func (r *postgresRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM unlogged_cache WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *postgresRepository) Describe() StoreInfo {
+	return StoreInfo{Backend: BackendPostgres}
+}
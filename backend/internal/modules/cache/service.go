@@ -4,25 +4,100 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 )
 
 var ErrCacheMiss = errors.New("cache: key not found")
 
+const (
+	// computeTimeout bounds a shared or background computation, which no
+	// longer follows any single caller's context.
+	computeTimeout = 2 * time.Minute
+
+	// tagMarkerTTL must outlive the slowest computation so a value computed
+	// before an invalidation is never stored after it.
+	tagMarkerTTL = 2 * computeTimeout
+)
+
+// Options controls how GetOrCompute stores a computed value.
+type Options struct {
+	// TTL is how long the value is fresh.
+	TTL time.Duration
+	// StaleTTL is how long past TTL the old value is still served while a
+	// refresh runs in the background. Zero disables stale-while-revalidate.
+	StaleTTL time.Duration
+	// Tags group entries for InvalidateTag.
+	Tags []string
+}
+
+// ComputeFunc produces the value on a miss. A non-zero ttl overrides
+// Options.TTL, e.g. to keep a degraded result only briefly. ctx is detached
+// from the caller so one cancelled request cannot fail the others waiting
+// on the same key.
+type ComputeFunc func(ctx context.Context) (value any, ttl time.Duration, err error)
+
+type Stats struct {
+	Hits          int64 `json:"hits"`
+	StaleHits     int64 `json:"staleHits"`
+	Misses        int64 `json:"misses"`
+	Computes      int64 `json:"computes"`
+	ComputeErrors int64 `json:"computeErrors"`
+	// SharedComputes counts callers served by another caller's computation.
+	SharedComputes int64 `json:"sharedComputes"`
+	// SkippedWrites counts results dropped because their tag was
+	// invalidated while they were computed.
+	SkippedWrites int64 `json:"skippedWrites"`
+	Invalidations int64 `json:"invalidations"`
+
+	Store StoreInfo `json:"store"`
+}
+
 type Service interface {
 	Set(ctx context.Context, key string, value any, ttl time.Duration) error
 	Get(ctx context.Context, key string, dest any) error
 	Delete(ctx context.Context, key string) error
+
+	// GetOrCompute decodes the cached value into dest, computing and
+	// storing it on a miss. Concurrent misses for a key share one
+	// computation.
+	GetOrCompute(ctx context.Context, key string, dest any, opts Options, compute ComputeFunc) error
+	InvalidateTag(ctx context.Context, tag string) error
+	// Cleanup deletes expired entries.
+	Cleanup(ctx context.Context) (int64, error)
+	Stats() Stats
+	// Listen receives invalidations from other replicas for the in-process
+	// tier until ctx is cancelled. It returns at once when there is none.
+	Listen(ctx context.Context)
+}
+
+type listener interface {
+	Listen(ctx context.Context)
+}
+
+type counters struct {
+	hits, staleHits, misses, computes, computeErrors, sharedComputes, skippedWrites, invalidations atomic.Int64
 }
 
 type service struct {
-	repo Repository
+	repo     Repository
+	group    singleflight.Group
+	counters counters
 }
 
 func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
+// MemberTag tags entries derived from a member's data.
+func MemberTag(memberID uuid.UUID) string {
+	return "member:" + memberID.String()
+}
+
 func (s *service) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	bytes, err := json.Marshal(value)
 	if err != nil {
@@ -47,3 +122,125 @@ func (s *service) Get(ctx context.Context, key string, dest any) error {
 func (s *service) Delete(ctx context.Context, key string) error {
 	return s.repo.Delete(ctx, key)
 }
+
+func (s *service) GetOrCompute(ctx context.Context, key string, dest any, opts Options, compute ComputeFunc) error {
+	// A failing cache should slow requests down, not break them.
+	entry, err := s.repo.GetEntry(ctx, key)
+	if err != nil {
+		log.Printf("Cache: GetOrCompute failed to read %s: %v", key, err)
+	}
+	if entry != nil {
+		if err := json.Unmarshal(entry.Value, dest); err == nil {
+			if entry.StaleAt == nil || time.Now().Before(*entry.StaleAt) {
+				s.counters.hits.Add(1)
+				return nil
+			}
+			s.counters.staleHits.Add(1)
+			s.refresh(key, opts, compute)
+			return nil
+		}
+		log.Printf("Cache: GetOrCompute found undecodable value for %s, recomputing", key)
+	}
+	s.counters.misses.Add(1)
+
+	result := s.group.DoChan(key, func() (any, error) {
+		return s.compute(key, opts, compute)
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return res.Err
+		}
+		if res.Shared {
+			s.counters.sharedComputes.Add(1)
+		}
+		return json.Unmarshal(res.Val.([]byte), dest)
+	}
+}
+
+// refresh recomputes a stale entry in the background. It joins any
+// computation already running for the key.
+func (s *service) refresh(key string, opts Options, compute ComputeFunc) {
+	s.group.DoChan(key, func() (any, error) {
+		data, err := s.compute(key, opts, compute)
+		if err != nil {
+			log.Printf("Cache: background refresh of %s failed: %v", key, err)
+		}
+		return data, err
+	})
+}
+
+func (s *service) compute(key string, opts Options, compute ComputeFunc) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), computeTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	s.counters.computes.Add(1)
+	value, ttl, err := compute(ctx)
+	if err != nil {
+		s.counters.computeErrors.Add(1)
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.counters.computeErrors.Add(1)
+		return nil, err
+	}
+
+	if ttl <= 0 {
+		ttl = opts.TTL
+	}
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	var staleAt *time.Time
+	if opts.StaleTTL > 0 {
+		staleAt = &expiresAt
+		expiresAt = expiresAt.Add(opts.StaleTTL)
+	}
+
+	stored, err := s.repo.SetEntry(ctx, key, data, staleAt, expiresAt, opts.Tags, startedAt)
+	switch {
+	case err != nil:
+		log.Printf("Cache: failed to store %s: %v", key, err)
+	case !stored:
+		s.counters.skippedWrites.Add(1)
+	}
+	return data, nil
+}
+
+func (s *service) InvalidateTag(ctx context.Context, tag string) error {
+	now := time.Now()
+	deleted, err := s.repo.InvalidateTag(ctx, tag, now, now.Add(tagMarkerTTL))
+	if err != nil {
+		return err
+	}
+	s.counters.invalidations.Add(deleted)
+	return nil
+}
+
+func (s *service) Cleanup(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx)
+}
+
+func (s *service) Stats() Stats {
+	return Stats{
+		Hits:           s.counters.hits.Load(),
+		StaleHits:      s.counters.staleHits.Load(),
+		Misses:         s.counters.misses.Load(),
+		Computes:       s.counters.computes.Load(),
+		ComputeErrors:  s.counters.computeErrors.Load(),
+		SharedComputes: s.counters.sharedComputes.Load(),
+		SkippedWrites:  s.counters.skippedWrites.Load(),
+		Invalidations:  s.counters.invalidations.Load(),
+		Store:          describe(s.repo),
+	}
+}
+
+func (s *service) Listen(ctx context.Context) {
+	if l, ok := s.repo.(listener); ok {
+		l.Listen(ctx)
+	}
+}
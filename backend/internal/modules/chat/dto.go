@@ -83,3 +83,16 @@ type ConversationHistory struct {
 	SessionID uuid.UUID             `json:"sessionId"`
 	Messages  []ConversationMessage `json:"messages"`
 }
+
+// ConversationMemory is what the assistant is told about earlier turns: a
+// rolling summary plus the most recent messages verbatim
+type ConversationMemory struct {
+	Summary  string                `json:"summary,omitempty"`
+	Messages []ConversationMessage `json:"messages"`
+}
+
+// SummaryResult is returned by a Summarizer
+type SummaryResult struct {
+	Summary string `json:"summary"`
+	Title   string `json:"title,omitempty"`
+}
@@ -18,14 +18,17 @@ const (
 
 // ChatSession represents a conversation session for a user
 type ChatSession struct {
-	ID        uuid.UUID       `db:"id"`
-	UserID    uuid.UUID       `db:"user_id"`
-	Name      *string         `db:"name"`
-	Summary   *string         `db:"summary"`
-	Metadata  json.RawMessage `db:"metadata"`
-	IsActive  bool            `db:"is_active"`
-	CreatedAt time.Time       `db:"created_at"`
-	UpdatedAt time.Time       `db:"updated_at"`
+	ID      uuid.UUID `db:"id"`
+	UserID  uuid.UUID `db:"user_id"`
+	Name    *string   `db:"name"`
+	Summary *string   `db:"summary"`
+	// SummarizedUntil is the creation time of the last message folded into Summary
+	SummarizedUntil *time.Time      `db:"summarized_until"`
+	TitleGenerated  bool            `db:"title_generated"`
+	Metadata        json.RawMessage `db:"metadata"`
+	IsActive        bool            `db:"is_active"`
+	CreatedAt       time.Time       `db:"created_at"`
+	UpdatedAt       time.Time       `db:"updated_at"`
 }
 
 // ToResponse converts ChatSession entity to response DTO
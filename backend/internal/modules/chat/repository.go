@@ -3,6 +3,7 @@ package chat
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -22,7 +23,12 @@ type Repository interface {
 	AddMessage(ctx context.Context, message *ChatMessage) error
 	GetMessagesBySessionID(ctx context.Context, sessionID uuid.UUID, limit, offset int) ([]*ChatMessage, error)
 	GetRecentMessages(ctx context.Context, sessionID uuid.UUID, limit int) ([]*ChatMessage, error)
+	GetMessagesSince(ctx context.Context, sessionID uuid.UUID, since *time.Time) ([]*ChatMessage, error)
 	DeleteMessagesBySessionID(ctx context.Context, sessionID uuid.UUID) error
+
+	// Memory operations
+	SaveSummary(ctx context.Context, sessionID uuid.UUID, summary string, until time.Time) error
+	SetGeneratedTitle(ctx context.Context, sessionID uuid.UUID, title string, overwrite bool) error
 }
 
 type repository struct {
@@ -57,8 +63,8 @@ func (r *repository) CreateSession(ctx context.Context, session *ChatSession) er
 func (r *repository) UpdateSession(ctx context.Context, session *ChatSession) error {
 	query := `
 		UPDATE chat_sessions
-		SET name = $1, summary = $2, metadata = $3, is_active = $4, updated_at = NOW()
-		WHERE id = $5
+		SET name = $1, summary = $2, metadata = $3, is_active = $4, title_generated = $5, updated_at = NOW()
+		WHERE id = $6
 		RETURNING updated_at
 	`
 
@@ -72,6 +78,7 @@ func (r *repository) UpdateSession(ctx context.Context, session *ChatSession) er
 		session.Summary,
 		metadata,
 		session.IsActive,
+		session.TitleGenerated,
 		session.ID,
 	).Scan(&session.UpdatedAt)
 }
@@ -89,7 +96,7 @@ func (r *repository) DeleteSession(ctx context.Context, id uuid.UUID) error {
 
 func (r *repository) GetSessionByID(ctx context.Context, id uuid.UUID) (*ChatSession, error) {
 	query := `
-		SELECT id, user_id, name, summary, metadata, is_active, created_at, updated_at
+		SELECT id, user_id, name, summary, summarized_until, title_generated, metadata, is_active, created_at, updated_at
 		FROM chat_sessions
 		WHERE id = $1
 	`
@@ -100,6 +107,8 @@ func (r *repository) GetSessionByID(ctx context.Context, id uuid.UUID) (*ChatSes
 		&session.UserID,
 		&session.Name,
 		&session.Summary,
+		&session.SummarizedUntil,
+		&session.TitleGenerated,
 		&session.Metadata,
 		&session.IsActive,
 		&session.CreatedAt,
@@ -116,7 +125,7 @@ func (r *repository) GetSessionByID(ctx context.Context, id uuid.UUID) (*ChatSes
 
 func (r *repository) GetSessionsByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*ChatSession, error) {
 	query := `
-		SELECT id, user_id, name, summary, metadata, is_active, created_at, updated_at
+		SELECT id, user_id, name, summary, summarized_until, title_generated, metadata, is_active, created_at, updated_at
 		FROM chat_sessions
 		WHERE user_id = $1
 		ORDER BY updated_at DESC
@@ -137,6 +146,8 @@ func (r *repository) GetSessionsByUserID(ctx context.Context, userID uuid.UUID,
 			&session.UserID,
 			&session.Name,
 			&session.Summary,
+			&session.SummarizedUntil,
+			&session.TitleGenerated,
 			&session.Metadata,
 			&session.IsActive,
 			&session.CreatedAt,
@@ -153,7 +164,7 @@ func (r *repository) GetSessionsByUserID(ctx context.Context, userID uuid.UUID,
 
 func (r *repository) GetActiveSessionByUserID(ctx context.Context, userID uuid.UUID) (*ChatSession, error) {
 	query := `
-		SELECT id, user_id, name, summary, metadata, is_active, created_at, updated_at
+		SELECT id, user_id, name, summary, summarized_until, title_generated, metadata, is_active, created_at, updated_at
 		FROM chat_sessions
 		WHERE user_id = $1 AND is_active = true
 		ORDER BY updated_at DESC
@@ -166,6 +177,8 @@ func (r *repository) GetActiveSessionByUserID(ctx context.Context, userID uuid.U
 		&session.UserID,
 		&session.Name,
 		&session.Summary,
+		&session.SummarizedUntil,
+		&session.TitleGenerated,
 		&session.Metadata,
 		&session.IsActive,
 		&session.CreatedAt,
@@ -264,6 +277,48 @@ func (r *repository) GetRecentMessages(ctx context.Context, sessionID uuid.UUID,
 	return r.scanMessages(rows)
 }
 
+// GetMessagesSince returns messages created after since in chronological
+// order, or all messages when since is nil.
+func (r *repository) GetMessagesSince(ctx context.Context, sessionID uuid.UUID, since *time.Time) ([]*ChatMessage, error) {
+	query := `
+		SELECT id, session_id, role, content, context, suggested_actions, metadata, created_at
+		FROM chat_messages
+		WHERE session_id = $1 AND ($2::timestamptz IS NULL OR created_at > $2)
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, sessionID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanMessages(rows)
+}
+
+func (r *repository) SaveSummary(ctx context.Context, sessionID uuid.UUID, summary string, until time.Time) error {
+	query := `
+		UPDATE chat_sessions
+		SET summary = $2, summarized_until = $3
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, sessionID, summary, until)
+	return err
+}
+
+// SetGeneratedTitle names a session. Without overwrite it only fills in an
+// empty name; with overwrite it also replaces earlier generated titles, but
+// never one the user chose.
+func (r *repository) SetGeneratedTitle(ctx context.Context, sessionID uuid.UUID, title string, overwrite bool) error {
+	query := `
+		UPDATE chat_sessions
+		SET name = $2, title_generated = TRUE
+		WHERE id = $1 AND (name IS NULL OR ($3 AND title_generated))
+	`
+	_, err := r.db.Exec(ctx, query, sessionID, title, overwrite)
+	return err
+}
+
 func (r *repository) DeleteMessagesBySessionID(ctx context.Context, sessionID uuid.UUID) error {
 	_, err := r.db.Exec(ctx, `DELETE FROM chat_messages WHERE session_id = $1`, sessionID)
 	return err
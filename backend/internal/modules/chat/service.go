@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -13,6 +14,23 @@ var (
 	ErrUnauthorized    = errors.New("unauthorized access to chat session")
 )
 
+// Memory budgets. Tokens are estimated at four characters each. GetMemory
+// sends at most historyMessageLimit messages and historyTokenBudget tokens of
+// history; Compact summarizes as soon as the unsummarized messages go past
+// either, so history is only ever trimmed of messages already summarized.
+const (
+	historyMessageLimit = 12
+	historyTokenBudget  = 2000
+	keepRecentMessages  = 6
+	maxTitleLength      = 60
+)
+
+// Summarizer condenses older conversation turns into a summary and suggests
+// a session title. It is implemented by whoever talks to the assistant.
+type Summarizer interface {
+	Summarize(ctx context.Context, previousSummary string, messages []ConversationMessage) (*SummaryResult, error)
+}
+
 type Service interface {
 	// Session operations
 	CreateSession(ctx context.Context, userID uuid.UUID, req *CreateSessionRequest) (*ChatSessionResponse, error)
@@ -22,6 +40,9 @@ type Service interface {
 	GetSessionWithMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, messageLimit int) (*ChatSessionWithMessagesResponse, error)
 	ListSessions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*ChatSessionResponse, error)
 	GetOrCreateActiveSession(ctx context.Context, userID uuid.UUID) (*ChatSessionResponse, error)
+	// ResolveSession returns the given session if the user owns it, or the
+	// user's active session when sessionID is nil.
+	ResolveSession(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID) (*ChatSessionResponse, error)
 
 	// Message operations
 	AddUserMessage(ctx context.Context, sessionID uuid.UUID, content string, context map[string]interface{}) (*ChatMessageResponse, error)
@@ -30,6 +51,12 @@ type Service interface {
 	GetMessages(ctx context.Context, sessionID uuid.UUID, page, limit int) ([]*ChatMessageResponse, error)
 	GetConversationHistory(ctx context.Context, sessionID uuid.UUID, limit int) (*ConversationHistory, error)
 	ClearMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+
+	// Memory operations
+	GetMemory(ctx context.Context, sessionID uuid.UUID) (*ConversationMemory, error)
+	// Compact folds older messages into the session summary once the
+	// unsummarized part of the conversation exceeds its budget.
+	Compact(ctx context.Context, sessionID uuid.UUID, summarizer Summarizer) error
 }
 
 type service struct {
@@ -79,6 +106,7 @@ func (s *service) UpdateSession(ctx context.Context, userID uuid.UUID, sessionID
 	// Apply updates
 	if req.Name != nil {
 		session.Name = req.Name
+		session.TitleGenerated = false
 	}
 	if req.Summary != nil {
 		session.Summary = req.Summary
@@ -195,6 +223,13 @@ func (s *service) GetOrCreateActiveSession(ctx context.Context, userID uuid.UUID
 	return s.CreateSession(ctx, userID, &CreateSessionRequest{})
 }
 
+func (s *service) ResolveSession(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID) (*ChatSessionResponse, error) {
+	if sessionID == nil {
+		return s.GetOrCreateActiveSession(ctx, userID)
+	}
+	return s.GetSession(ctx, userID, *sessionID)
+}
+
 func (s *service) AddUserMessage(ctx context.Context, sessionID uuid.UUID, content string, msgContext map[string]interface{}) (*ChatMessageResponse, error) {
 	message := &ChatMessage{
 		SessionID: sessionID,
@@ -214,6 +249,12 @@ func (s *service) AddUserMessage(ctx context.Context, sessionID uuid.UUID, conte
 		return nil, err
 	}
 
+	// Name untitled sessions after their first question until a better
+	// title comes back from summarization
+	if err := s.repo.SetGeneratedTitle(ctx, sessionID, titleFromMessage(content), false); err != nil {
+		return nil, err
+	}
+
 	return message.ToResponse(), nil
 }
 
@@ -323,3 +364,122 @@ func (s *service) ClearMessages(ctx context.Context, userID uuid.UUID, sessionID
 
 	return s.repo.DeleteMessagesBySessionID(ctx, sessionID)
 }
+
+func (s *service) GetMemory(ctx context.Context, sessionID uuid.UUID) (*ConversationMemory, error) {
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	messages, err := s.repo.GetMessagesSince(ctx, sessionID, session.SummarizedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	history := toConversation(messages)
+	if len(history) > historyMessageLimit {
+		history = history[len(history)-historyMessageLimit:]
+	}
+	for len(history) > 0 && estimateTokens(history) > historyTokenBudget {
+		history = history[1:]
+	}
+
+	memory := &ConversationMemory{Messages: history}
+	if session.Summary != nil {
+		memory.Summary = *session.Summary
+	}
+	return memory, nil
+}
+
+func (s *service) Compact(ctx context.Context, sessionID uuid.UUID, summarizer Summarizer) error {
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return ErrSessionNotFound
+	}
+
+	messages, err := s.repo.GetMessagesSince(ctx, sessionID, session.SummarizedUntil)
+	if err != nil {
+		return err
+	}
+	if len(messages) <= historyMessageLimit && estimateTokens(toConversation(messages)) <= historyTokenBudget {
+		return nil
+	}
+
+	// Keep the most recent messages verbatim, but no more than fit the
+	// history budget, or GetMemory would drop some before they are summarized.
+	keep := 0
+	for keep < keepRecentMessages && keep < len(messages) &&
+		estimateTokens(toConversation(messages[len(messages)-keep-1:])) <= historyTokenBudget {
+		keep++
+	}
+	if keep == len(messages) {
+		return nil
+	}
+
+	older := messages[:len(messages)-keep]
+	previous := ""
+	if session.Summary != nil {
+		previous = *session.Summary
+	}
+
+	result, err := summarizer.Summarize(ctx, previous, toConversation(older))
+	if err != nil {
+		return err
+	}
+	if result.Summary == "" {
+		return nil
+	}
+
+	if err := s.repo.SaveSummary(ctx, sessionID, result.Summary, older[len(older)-1].CreatedAt); err != nil {
+		return err
+	}
+	if title := strings.TrimSpace(result.Title); title != "" {
+		return s.repo.SetGeneratedTitle(ctx, sessionID, truncateTitle(title), true)
+	}
+	return nil
+}
+
+// toConversation drops system messages, which are not part of the dialogue
+func toConversation(messages []*ChatMessage) []ConversationMessage {
+	conversation := make([]ConversationMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == RoleSystem {
+			continue
+		}
+		conversation = append(conversation, ConversationMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		})
+	}
+	return conversation
+}
+
+func estimateTokens(messages []ConversationMessage) int {
+	chars := 0
+	for _, msg := range messages {
+		chars += len(msg.Content)
+	}
+	return chars / 4
+}
+
+func titleFromMessage(content string) string {
+	return truncateTitle(strings.Join(strings.Fields(content), " "))
+}
+
+func truncateTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) <= maxTitleLength {
+		return title
+	}
+	cut := string(runes[:maxTitleLength])
+	if i := strings.LastIndex(cut, " "); i > maxTitleLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,98 @@
+package classes
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateClassTypeRequest struct {
+	OrganizationID         uuid.UUID `json:"organizationId" validate:"required"`
+	Name                   string    `json:"name" validate:"required"`
+	Description            *string   `json:"description,omitempty"`
+	DefaultDurationMinutes int       `json:"defaultDurationMinutes,omitempty" validate:"omitempty,gt=0"`
+}
+
+type UpdateClassTypeRequest struct {
+	Name                   string  `json:"name,omitempty"`
+	Description            *string `json:"description,omitempty"`
+	DefaultDurationMinutes *int    `json:"defaultDurationMinutes,omitempty" validate:"omitempty,gt=0"`
+	IsActive               *bool   `json:"isActive,omitempty"`
+}
+
+type CreateScheduleRequest struct {
+	BranchID                  uuid.UUID  `json:"branchId" validate:"required"`
+	ClassTypeID               uuid.UUID  `json:"classTypeId" validate:"required"`
+	InstructorID              *uuid.UUID `json:"instructorId,omitempty"`
+	Room                      *string    `json:"room,omitempty"`
+	Capacity                  int        `json:"capacity" validate:"required,gt=0"`
+	DayOfWeek                 int        `json:"dayOfWeek" validate:"min=0,max=6"`
+	StartTime                 string     `json:"startTime" validate:"required"`
+	DurationMinutes           *int       `json:"durationMinutes,omitempty" validate:"omitempty,gt=0"`
+	CancellationCutoffMinutes *int       `json:"cancellationCutoffMinutes,omitempty" validate:"omitempty,gte=0"`
+	ValidFrom                 *string    `json:"validFrom,omitempty"`
+	ValidUntil                *string    `json:"validUntil,omitempty"`
+}
+
+type ClassTypeResponse struct {
+	ID                     uuid.UUID `json:"id"`
+	OrganizationID         uuid.UUID `json:"organizationId"`
+	Name                   string    `json:"name"`
+	Description            *string   `json:"description,omitempty"`
+	DefaultDurationMinutes int       `json:"defaultDurationMinutes"`
+	IsActive               bool      `json:"isActive"`
+}
+
+type ScheduleResponse struct {
+	ID                        uuid.UUID  `json:"id"`
+	BranchID                  uuid.UUID  `json:"branchId"`
+	ClassTypeID               uuid.UUID  `json:"classTypeId"`
+	InstructorID              *uuid.UUID `json:"instructorId,omitempty"`
+	Room                      *string    `json:"room,omitempty"`
+	Capacity                  int        `json:"capacity"`
+	DayOfWeek                 int        `json:"dayOfWeek"`
+	StartTime                 string     `json:"startTime"`
+	DurationMinutes           int        `json:"durationMinutes"`
+	CancellationCutoffMinutes int        `json:"cancellationCutoffMinutes"`
+	ValidFrom                 string     `json:"validFrom"`
+	ValidUntil                *string    `json:"validUntil,omitempty"`
+	IsActive                  bool       `json:"isActive"`
+}
+
+type SessionResponse struct {
+	ID                        uuid.UUID  `json:"id"`
+	ScheduleID                *uuid.UUID `json:"scheduleId,omitempty"`
+	BranchID                  uuid.UUID  `json:"branchId"`
+	ClassTypeID               uuid.UUID  `json:"classTypeId"`
+	ClassTypeName             string     `json:"classTypeName"`
+	InstructorID              *uuid.UUID `json:"instructorId,omitempty"`
+	Room                      *string    `json:"room,omitempty"`
+	Capacity                  int        `json:"capacity"`
+	StartsAt                  time.Time  `json:"startsAt"`
+	EndsAt                    time.Time  `json:"endsAt"`
+	CancellationCutoffMinutes int        `json:"cancellationCutoffMinutes"`
+	IsCancelled               bool       `json:"isCancelled"`
+	BookedCount               int        `json:"bookedCount"`
+	WaitlistCount             int        `json:"waitlistCount"`
+	SpotsLeft                 int        `json:"spotsLeft"`
+}
+
+type BookingResponse struct {
+	ID             uuid.UUID     `json:"id"`
+	SessionID      uuid.UUID     `json:"sessionId"`
+	MemberID       uuid.UUID     `json:"memberId"`
+	MemberName     string        `json:"memberName,omitempty"`
+	ClassTypeName  string        `json:"classTypeName,omitempty"`
+	StartsAt       time.Time     `json:"startsAt"`
+	SubscriptionID *uuid.UUID    `json:"subscriptionId,omitempty"`
+	Status         BookingStatus `json:"status"`
+	CheckInID      *uuid.UUID    `json:"checkInId,omitempty"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	CancelledAt    *time.Time    `json:"cancelledAt,omitempty"`
+}
+
+type AttendanceResult struct {
+	Sessions int `json:"sessions"`
+	Attended int `json:"attended"`
+	NoShows  int `json:"noShows"`
+}
@@ -0,0 +1,147 @@
+package classes
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BookingStatus string
+
+const (
+	BookingBooked     BookingStatus = "booked"
+	BookingWaitlisted BookingStatus = "waitlisted"
+	BookingCancelled  BookingStatus = "cancelled"
+	BookingAttended   BookingStatus = "attended"
+	BookingNoShow     BookingStatus = "no_show"
+)
+
+type ClassType struct {
+	ID                     uuid.UUID `db:"id"`
+	OrganizationID         uuid.UUID `db:"organization_id"`
+	Name                   string    `db:"name"`
+	Description            *string   `db:"description"`
+	DefaultDurationMinutes int       `db:"default_duration_minutes"`
+	IsActive               bool      `db:"is_active"`
+	CreatedAt              time.Time `db:"created_at"`
+	UpdatedAt              time.Time `db:"updated_at"`
+}
+
+// Schedule is a recurring weekly class slot. StartTime is HH:MM in branch
+// local time; occurrences are materialised into Session rows ahead of time.
+type Schedule struct {
+	ID                        uuid.UUID  `db:"id"`
+	BranchID                  uuid.UUID  `db:"branch_id"`
+	ClassTypeID               uuid.UUID  `db:"class_type_id"`
+	InstructorID              *uuid.UUID `db:"instructor_id"`
+	Room                      *string    `db:"room"`
+	Capacity                  int        `db:"capacity"`
+	DayOfWeek                 int        `db:"day_of_week"`
+	StartTime                 string     `db:"start_time"`
+	DurationMinutes           int        `db:"duration_minutes"`
+	CancellationCutoffMinutes int        `db:"cancellation_cutoff_minutes"`
+	ValidFrom                 time.Time  `db:"valid_from"`
+	ValidUntil                *time.Time `db:"valid_until"`
+	IsActive                  bool       `db:"is_active"`
+	CreatedAt                 time.Time  `db:"created_at"`
+}
+
+type Session struct {
+	ID                        uuid.UUID  `db:"id"`
+	ScheduleID                *uuid.UUID `db:"schedule_id"`
+	BranchID                  uuid.UUID  `db:"branch_id"`
+	ClassTypeID               uuid.UUID  `db:"class_type_id"`
+	ClassTypeName             string     `db:"class_type_name"`
+	InstructorID              *uuid.UUID `db:"instructor_id"`
+	Room                      *string    `db:"room"`
+	Capacity                  int        `db:"capacity"`
+	StartsAt                  time.Time  `db:"starts_at"`
+	EndsAt                    time.Time  `db:"ends_at"`
+	CancellationCutoffMinutes int        `db:"cancellation_cutoff_minutes"`
+	IsCancelled               bool       `db:"is_cancelled"`
+	BookedCount               int        `db:"booked_count"`
+	WaitlistCount             int        `db:"waitlist_count"`
+}
+
+type Booking struct {
+	ID             uuid.UUID     `db:"id"`
+	SessionID      uuid.UUID     `db:"session_id"`
+	MemberID       uuid.UUID     `db:"member_id"`
+	SubscriptionID *uuid.UUID    `db:"subscription_id"`
+	Status         BookingStatus `db:"status"`
+	CheckInID      *uuid.UUID    `db:"check_in_id"`
+	CreatedAt      time.Time     `db:"created_at"`
+	CancelledAt    *time.Time    `db:"cancelled_at"`
+	MemberName     string        `db:"member_name"`
+	ClassTypeName  string        `db:"class_type_name"`
+	StartsAt       time.Time     `db:"starts_at"`
+}
+
+func (t *ClassType) ToResponse() *ClassTypeResponse {
+	return &ClassTypeResponse{
+		ID:                     t.ID,
+		OrganizationID:         t.OrganizationID,
+		Name:                   t.Name,
+		Description:            t.Description,
+		DefaultDurationMinutes: t.DefaultDurationMinutes,
+		IsActive:               t.IsActive,
+	}
+}
+
+func (s *Schedule) ToResponse() *ScheduleResponse {
+	res := &ScheduleResponse{
+		ID:                        s.ID,
+		BranchID:                  s.BranchID,
+		ClassTypeID:               s.ClassTypeID,
+		InstructorID:              s.InstructorID,
+		Room:                      s.Room,
+		Capacity:                  s.Capacity,
+		DayOfWeek:                 s.DayOfWeek,
+		StartTime:                 s.StartTime,
+		DurationMinutes:           s.DurationMinutes,
+		CancellationCutoffMinutes: s.CancellationCutoffMinutes,
+		ValidFrom:                 s.ValidFrom.Format(dateLayout),
+		IsActive:                  s.IsActive,
+	}
+	if s.ValidUntil != nil {
+		validUntil := s.ValidUntil.Format(dateLayout)
+		res.ValidUntil = &validUntil
+	}
+	return res
+}
+
+func (s *Session) ToResponse() *SessionResponse {
+	return &SessionResponse{
+		ID:                        s.ID,
+		ScheduleID:                s.ScheduleID,
+		BranchID:                  s.BranchID,
+		ClassTypeID:               s.ClassTypeID,
+		ClassTypeName:             s.ClassTypeName,
+		InstructorID:              s.InstructorID,
+		Room:                      s.Room,
+		Capacity:                  s.Capacity,
+		StartsAt:                  s.StartsAt,
+		EndsAt:                    s.EndsAt,
+		CancellationCutoffMinutes: s.CancellationCutoffMinutes,
+		IsCancelled:               s.IsCancelled,
+		BookedCount:               s.BookedCount,
+		WaitlistCount:             s.WaitlistCount,
+		SpotsLeft:                 max(s.Capacity-s.BookedCount, 0),
+	}
+}
+
+func (b *Booking) ToResponse() *BookingResponse {
+	return &BookingResponse{
+		ID:             b.ID,
+		SessionID:      b.SessionID,
+		MemberID:       b.MemberID,
+		MemberName:     b.MemberName,
+		ClassTypeName:  b.ClassTypeName,
+		StartsAt:       b.StartsAt,
+		SubscriptionID: b.SubscriptionID,
+		Status:         b.Status,
+		CheckInID:      b.CheckInID,
+		CreatedAt:      b.CreatedAt,
+		CancelledAt:    b.CancelledAt,
+	}
+}
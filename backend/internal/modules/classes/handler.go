@@ -0,0 +1,411 @@
+package classes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/classes", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Get("/types", h.ListClassTypes)
+		r.Get("/sessions", h.ListSessions)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member"))
+			r.Post("/sessions/{sessionId}/book", h.BookSession)
+			r.Delete("/sessions/{sessionId}/book", h.CancelMyBooking)
+			r.Get("/bookings/me", h.ListMyBookings)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("admin", "staff"))
+			r.Get("/schedules", h.ListSchedules)
+			r.Get("/sessions/{sessionId}/bookings", h.ListSessionBookings)
+			r.Delete("/bookings/{bookingId}", h.CancelBooking)
+			r.Post("/sessions/{sessionId}/cancel", h.CancelSession)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Post("/types", h.CreateClassType)
+			r.Put("/types/{id}", h.UpdateClassType)
+			r.Post("/schedules", h.CreateSchedule)
+			r.Delete("/schedules/{id}", h.DeactivateSchedule)
+		})
+	})
+}
+
+func (h *Handler) CreateClassType(w http.ResponseWriter, r *http.Request) {
+	var req CreateClassTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	classType, err := h.service.CreateClassType(r.Context(), &req)
+	if err != nil {
+		if err == ErrClassTypeExists {
+			response.Conflict(w, err.Error(), nil)
+			return
+		}
+		response.InternalServerError(w, "Failed to create class type")
+		return
+	}
+
+	response.Success(w, "Class type created successfully", classType.ToResponse())
+}
+
+func (h *Handler) UpdateClassType(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid class type ID", nil)
+		return
+	}
+
+	var req UpdateClassTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	classType, err := h.service.UpdateClassType(r.Context(), id, &req)
+	if err != nil {
+		switch err {
+		case ErrClassTypeNotFound:
+			response.NotFound(w, err.Error())
+		case ErrClassTypeExists:
+			response.Conflict(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to update class type")
+		}
+		return
+	}
+
+	response.Success(w, "Class type updated successfully", classType.ToResponse())
+}
+
+func (h *Handler) ListClassTypes(w http.ResponseWriter, r *http.Request) {
+	organizationID, err := uuid.Parse(r.URL.Query().Get("organizationId"))
+	if err != nil {
+		response.BadRequest(w, "organizationId is required", nil)
+		return
+	}
+
+	types, err := h.service.ListClassTypes(r.Context(), organizationID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list class types")
+		return
+	}
+
+	responses := make([]*ClassTypeResponse, 0, len(types))
+	for _, t := range types {
+		responses = append(responses, t.ToResponse())
+	}
+	response.Success(w, "Class types retrieved successfully", responses)
+}
+
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	schedule, err := h.service.CreateSchedule(r.Context(), &req)
+	if err != nil {
+		switch err {
+		case ErrClassTypeNotFound:
+			response.NotFound(w, err.Error())
+		case ErrInvalidInstructor, ErrInvalidStartTime, ErrInvalidDate:
+			response.BadRequest(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to create schedule")
+		}
+		return
+	}
+
+	response.Success(w, "Schedule created successfully", schedule.ToResponse())
+}
+
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(r.URL.Query().Get("branchId"))
+	if err != nil {
+		response.BadRequest(w, "branchId is required", nil)
+		return
+	}
+
+	schedules, err := h.service.ListSchedules(r.Context(), branchID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list schedules")
+		return
+	}
+
+	responses := make([]*ScheduleResponse, 0, len(schedules))
+	for _, s := range schedules {
+		responses = append(responses, s.ToResponse())
+	}
+	response.Success(w, "Schedules retrieved successfully", responses)
+}
+
+func (h *Handler) DeactivateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid schedule ID", nil)
+		return
+	}
+
+	if err := h.service.DeactivateSchedule(r.Context(), id); err != nil {
+		if err == ErrScheduleNotFound {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalServerError(w, "Failed to deactivate schedule")
+		return
+	}
+
+	response.OK(w, "Schedule deactivated successfully")
+}
+
+// ListSessions returns class occurrences for a branch. from/to accept
+// YYYY-MM-DD or RFC3339 and default to the coming 7 days.
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(r.URL.Query().Get("branchId"))
+	if err != nil {
+		response.BadRequest(w, "branchId is required", nil)
+		return
+	}
+
+	from := time.Now()
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = parseTimeParam(v); err != nil {
+			response.BadRequest(w, "Invalid from parameter", nil)
+			return
+		}
+	}
+	to := from.AddDate(0, 0, 7)
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = parseTimeParam(v); err != nil {
+			response.BadRequest(w, "Invalid to parameter", nil)
+			return
+		}
+	}
+
+	sessions, err := h.service.ListSessions(r.Context(), branchID, from, to)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list class sessions")
+		return
+	}
+
+	responses := make([]*SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		responses = append(responses, s.ToResponse())
+	}
+	response.Success(w, "Class sessions retrieved successfully", responses)
+}
+
+func (h *Handler) CancelSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid session ID", nil)
+		return
+	}
+
+	if err := h.service.CancelSession(r.Context(), sessionID); err != nil {
+		if err == ErrSessionNotFound {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalServerError(w, "Failed to cancel class session")
+		return
+	}
+
+	response.OK(w, "Class session cancelled successfully")
+}
+
+func (h *Handler) ListSessionBookings(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid session ID", nil)
+		return
+	}
+
+	bookings, err := h.service.ListSessionBookings(r.Context(), sessionID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list bookings")
+		return
+	}
+
+	responses := make([]*BookingResponse, 0, len(bookings))
+	for _, b := range bookings {
+		responses = append(responses, b.ToResponse())
+	}
+	response.Success(w, "Bookings retrieved successfully", responses)
+}
+
+func (h *Handler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := uuid.Parse(chi.URLParam(r, "bookingId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid booking ID", nil)
+		return
+	}
+
+	if err := h.service.CancelBooking(r.Context(), bookingID); err != nil {
+		if err == ErrBookingNotCancellable {
+			response.Conflict(w, err.Error(), nil)
+			return
+		}
+		response.InternalServerError(w, "Failed to cancel booking")
+		return
+	}
+
+	response.OK(w, "Booking cancelled successfully")
+}
+
+func (h *Handler) BookSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid session ID", nil)
+		return
+	}
+
+	booking, err := h.service.BookSession(r.Context(), userID, sessionID)
+	if err != nil {
+		switch err {
+		case ErrMemberNotFound, ErrSessionNotFound:
+			response.NotFound(w, err.Error())
+		case ErrAlreadyBooked, ErrSessionNotBookable:
+			response.Conflict(w, err.Error(), nil)
+		case ErrNoActiveSubscription, ErrSubscriptionFrozen, ErrClassTypeNotAllowed, ErrNoClassCredits:
+			response.Forbidden(w, err.Error())
+		default:
+			response.InternalServerError(w, "Failed to book class")
+		}
+		return
+	}
+
+	message := "Class booked successfully"
+	if booking.Status == BookingWaitlisted {
+		message = "Class is full, you have been added to the waitlist"
+	}
+	response.Success(w, message, booking.ToResponse())
+}
+
+func (h *Handler) CancelMyBooking(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid session ID", nil)
+		return
+	}
+
+	if err := h.service.CancelMyBooking(r.Context(), userID, sessionID); err != nil {
+		switch err {
+		case ErrMemberNotFound, ErrBookingNotFound:
+			response.NotFound(w, err.Error())
+		case ErrCancellationCutoff, ErrBookingNotCancellable:
+			response.Conflict(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to cancel booking")
+		}
+		return
+	}
+
+	response.OK(w, "Booking cancelled successfully")
+}
+
+func (h *Handler) ListMyBookings(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	bookings, err := h.service.ListMyBookings(r.Context(), userID, page, limit)
+	if err != nil {
+		if err == ErrMemberNotFound {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalServerError(w, "Failed to list bookings")
+		return
+	}
+
+	responses := make([]*BookingResponse, 0, len(bookings))
+	for _, b := range bookings {
+		responses = append(responses, b.ToResponse())
+	}
+	response.Success(w, "Bookings retrieved successfully", responses)
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(dateLayout, value)
+}
@@ -0,0 +1,31 @@
+package classes
+
+import (
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, subSvc subscription.Service, plansSvc plans.Service) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, subSvc, plansSvc)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
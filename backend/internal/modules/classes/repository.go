@@ -0,0 +1,660 @@
+package classes
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	CreateType(ctx context.Context, classType *ClassType) error
+	UpdateType(ctx context.Context, classType *ClassType) error
+	GetType(ctx context.Context, id uuid.UUID) (*ClassType, error)
+	ListTypes(ctx context.Context, organizationID uuid.UUID) ([]*ClassType, error)
+
+	GetUserRole(ctx context.Context, userID uuid.UUID) (string, error)
+	CreateSchedule(ctx context.Context, schedule *Schedule) error
+	ListSchedules(ctx context.Context, branchID uuid.UUID) ([]*Schedule, error)
+	DeactivateSchedule(ctx context.Context, id uuid.UUID) error
+	GenerateSessions(ctx context.Context, days int) (int64, error)
+
+	ListSessions(ctx context.Context, branchID uuid.UUID, from, to time.Time) ([]*Session, error)
+	GetSession(ctx context.Context, id uuid.UUID) (*Session, error)
+	CancelSession(ctx context.Context, id uuid.UUID) error
+
+	GetMemberIDByUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	// CreateBooking books the member or waitlists them when the session is
+	// full. With credits set it returns ErrNoClassCredits once the
+	// subscription has used them all.
+	CreateBooking(ctx context.Context, booking *Booking, credits *int) error
+	GetActiveBooking(ctx context.Context, sessionID, memberID uuid.UUID) (*Booking, error)
+	GetBooking(ctx context.Context, id uuid.UUID) (*Booking, error)
+	CancelBooking(ctx context.Context, id uuid.UUID) (*Booking, error)
+	ListSessionBookings(ctx context.Context, sessionID uuid.UUID) ([]*Booking, error)
+	ListMemberBookings(ctx context.Context, memberID uuid.UUID, limit, offset int) ([]*Booking, error)
+	ProcessAttendance(ctx context.Context) (*AttendanceResult, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+const sessionColumns = `
+	s.id, s.schedule_id, s.branch_id, s.class_type_id, t.name, s.instructor_id, s.room, s.capacity,
+	s.starts_at, s.ends_at, s.cancellation_cutoff_minutes, s.is_cancelled,
+	(SELECT COUNT(*) FROM class_bookings b WHERE b.session_id = s.id AND b.status IN ('booked', 'attended', 'no_show')),
+	(SELECT COUNT(*) FROM class_bookings b WHERE b.session_id = s.id AND b.status = 'waitlisted')
+`
+
+func scanSession(row pgx.Row) (*Session, error) {
+	var s Session
+	err := row.Scan(
+		&s.ID,
+		&s.ScheduleID,
+		&s.BranchID,
+		&s.ClassTypeID,
+		&s.ClassTypeName,
+		&s.InstructorID,
+		&s.Room,
+		&s.Capacity,
+		&s.StartsAt,
+		&s.EndsAt,
+		&s.CancellationCutoffMinutes,
+		&s.IsCancelled,
+		&s.BookedCount,
+		&s.WaitlistCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+const bookingColumns = `
+	b.id, b.session_id, b.member_id, b.subscription_id, b.status, b.check_in_id, b.created_at, b.cancelled_at,
+	m.first_name || ' ' || m.last_name, t.name, s.starts_at
+`
+
+const bookingJoins = `
+	FROM class_bookings b
+	JOIN class_sessions s ON s.id = b.session_id
+	JOIN class_types t ON t.id = s.class_type_id
+	JOIN members m ON m.id = b.member_id
+`
+
+func scanBooking(row pgx.Row) (*Booking, error) {
+	var b Booking
+	err := row.Scan(
+		&b.ID,
+		&b.SessionID,
+		&b.MemberID,
+		&b.SubscriptionID,
+		&b.Status,
+		&b.CheckInID,
+		&b.CreatedAt,
+		&b.CancelledAt,
+		&b.MemberName,
+		&b.ClassTypeName,
+		&b.StartsAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *repositoryImpl) CreateType(ctx context.Context, classType *ClassType) error {
+	query := `
+		INSERT INTO class_types (organization_id, name, description, default_duration_minutes, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(ctx, query,
+		classType.OrganizationID,
+		classType.Name,
+		classType.Description,
+		classType.DefaultDurationMinutes,
+		classType.IsActive,
+	).Scan(&classType.ID, &classType.CreatedAt, &classType.UpdatedAt)
+}
+
+func (r *repositoryImpl) UpdateType(ctx context.Context, classType *ClassType) error {
+	query := `
+		UPDATE class_types
+		SET name = $1, description = $2, default_duration_minutes = $3, is_active = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(ctx, query,
+		classType.Name,
+		classType.Description,
+		classType.DefaultDurationMinutes,
+		classType.IsActive,
+		classType.ID,
+	).Scan(&classType.UpdatedAt)
+}
+
+func (r *repositoryImpl) GetType(ctx context.Context, id uuid.UUID) (*ClassType, error) {
+	query := `
+		SELECT id, organization_id, name, description, default_duration_minutes, is_active, created_at, updated_at
+		FROM class_types
+		WHERE id = $1
+	`
+	var t ClassType
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&t.ID,
+		&t.OrganizationID,
+		&t.Name,
+		&t.Description,
+		&t.DefaultDurationMinutes,
+		&t.IsActive,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repositoryImpl) ListTypes(ctx context.Context, organizationID uuid.UUID) ([]*ClassType, error) {
+	query := `
+		SELECT id, organization_id, name, description, default_duration_minutes, is_active, created_at, updated_at
+		FROM class_types
+		WHERE organization_id = $1
+		ORDER BY name
+	`
+	rows, err := r.db.Query(ctx, query, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []*ClassType
+	for rows.Next() {
+		var t ClassType
+		if err := rows.Scan(
+			&t.ID,
+			&t.OrganizationID,
+			&t.Name,
+			&t.Description,
+			&t.DefaultDurationMinutes,
+			&t.IsActive,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		types = append(types, &t)
+	}
+	return types, rows.Err()
+}
+
+func (r *repositoryImpl) GetUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.QueryRow(ctx, `SELECT role::text FROM users WHERE id = $1 AND is_active IS TRUE`, userID).Scan(&role)
+	return role, err
+}
+
+func (r *repositoryImpl) CreateSchedule(ctx context.Context, schedule *Schedule) error {
+	query := `
+		INSERT INTO class_schedules (
+			branch_id, class_type_id, instructor_id, room, capacity, day_of_week, start_time,
+			duration_minutes, cancellation_cutoff_minutes, valid_from, valid_until
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8, $9, $10, $11)
+		RETURNING id, is_active, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		schedule.BranchID,
+		schedule.ClassTypeID,
+		schedule.InstructorID,
+		schedule.Room,
+		schedule.Capacity,
+		schedule.DayOfWeek,
+		schedule.StartTime,
+		schedule.DurationMinutes,
+		schedule.CancellationCutoffMinutes,
+		schedule.ValidFrom,
+		schedule.ValidUntil,
+	).Scan(&schedule.ID, &schedule.IsActive, &schedule.CreatedAt)
+}
+
+func (r *repositoryImpl) ListSchedules(ctx context.Context, branchID uuid.UUID) ([]*Schedule, error) {
+	query := `
+		SELECT id, branch_id, class_type_id, instructor_id, room, capacity, day_of_week, to_char(start_time, 'HH24:MI'),
+			duration_minutes, cancellation_cutoff_minutes, valid_from, valid_until, is_active, created_at
+		FROM class_schedules
+		WHERE branch_id = $1 AND is_active IS TRUE
+		ORDER BY day_of_week, start_time
+	`
+	rows, err := r.db.Query(ctx, query, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*Schedule
+	for rows.Next() {
+		var s Schedule
+		if err := rows.Scan(
+			&s.ID,
+			&s.BranchID,
+			&s.ClassTypeID,
+			&s.InstructorID,
+			&s.Room,
+			&s.Capacity,
+			&s.DayOfWeek,
+			&s.StartTime,
+			&s.DurationMinutes,
+			&s.CancellationCutoffMinutes,
+			&s.ValidFrom,
+			&s.ValidUntil,
+			&s.IsActive,
+			&s.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, &s)
+	}
+	return schedules, rows.Err()
+}
+
+// DeactivateSchedule stops a recurring slot and cancels its future sessions
+// together with their bookings.
+func (r *repositoryImpl) DeactivateSchedule(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE class_schedules SET is_active = FALSE, updated_at = NOW() WHERE id = $1 AND is_active IS TRUE`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	query := `
+		WITH cancelled AS (
+			UPDATE class_sessions
+			SET is_cancelled = TRUE
+			WHERE schedule_id = $1 AND starts_at > NOW() AND is_cancelled IS FALSE
+			RETURNING id
+		)
+		UPDATE class_bookings
+		SET status = 'cancelled', cancelled_at = NOW()
+		WHERE session_id IN (SELECT id FROM cancelled) AND status IN ('booked', 'waitlisted')
+	`
+	if _, err := tx.Exec(ctx, query, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GenerateSessions materialises class_sessions for every active schedule
+// over the next `days` days, evaluating start times in the branch timezone.
+// Existing occurrences are left untouched.
+func (r *repositoryImpl) GenerateSessions(ctx context.Context, days int) (int64, error) {
+	query := `
+		INSERT INTO class_sessions (
+			schedule_id, branch_id, class_type_id, instructor_id, room, capacity,
+			starts_at, ends_at, cancellation_cutoff_minutes
+		)
+		SELECT * FROM (
+			SELECT
+				cs.id, cs.branch_id, cs.class_type_id, cs.instructor_id, cs.room, cs.capacity,
+				(d.day + cs.start_time) AT TIME ZONE COALESCE(b.timezone, 'UTC') AS starts_at,
+				(d.day + cs.start_time) AT TIME ZONE COALESCE(b.timezone, 'UTC') + make_interval(mins => cs.duration_minutes),
+				cs.cancellation_cutoff_minutes
+			FROM class_schedules cs
+			JOIN branches b ON b.id = cs.branch_id
+			JOIN class_types t ON t.id = cs.class_type_id AND t.is_active IS TRUE
+			CROSS JOIN LATERAL (
+				SELECT CURRENT_DATE + offs AS day FROM generate_series(-1, $1::int) AS offs
+			) d
+			WHERE cs.is_active IS TRUE
+				AND EXTRACT(DOW FROM d.day) = cs.day_of_week
+				AND d.day >= cs.valid_from
+				AND (cs.valid_until IS NULL OR d.day <= cs.valid_until)
+		) occurrences
+		WHERE occurrences.starts_at > NOW()
+		ON CONFLICT (schedule_id, starts_at) DO NOTHING
+	`
+	tag, err := r.db.Exec(ctx, query, days)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repositoryImpl) ListSessions(ctx context.Context, branchID uuid.UUID, from, to time.Time) ([]*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM class_sessions s
+		JOIN class_types t ON t.id = s.class_type_id
+		WHERE s.branch_id = $1 AND s.starts_at >= $2 AND s.starts_at < $3
+		ORDER BY s.starts_at
+	`
+	rows, err := r.db.Query(ctx, query, branchID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *repositoryImpl) GetSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM class_sessions s
+		JOIN class_types t ON t.id = s.class_type_id
+		WHERE s.id = $1
+	`
+	return scanSession(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) CancelSession(ctx context.Context, id uuid.UUID) error {
+	query := `
+		WITH cancelled AS (
+			UPDATE class_sessions
+			SET is_cancelled = TRUE
+			WHERE id = $1 AND is_cancelled IS FALSE
+			RETURNING id
+		), bookings AS (
+			UPDATE class_bookings
+			SET status = 'cancelled', cancelled_at = NOW()
+			WHERE session_id IN (SELECT id FROM cancelled) AND status IN ('booked', 'waitlisted')
+		)
+		SELECT COUNT(*) FROM cancelled
+	`
+	var count int
+	if err := r.db.QueryRow(ctx, query, id).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *repositoryImpl) GetMemberIDByUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	var memberID uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM members WHERE user_id = $1`, userID).Scan(&memberID)
+	return memberID, err
+}
+
+// CreateBooking locks the session row so concurrent bookings cannot exceed
+// capacity, then books the member or puts them on the waitlist. When the
+// plan has class credits the subscription row is locked first and the
+// bookings charged to it are counted in the same transaction; waitlist
+// entries count too, since they turn into bookings without another check.
+func (r *repositoryImpl) CreateBooking(ctx context.Context, booking *Booking, credits *int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if credits != nil && booking.SubscriptionID != nil {
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM subscriptions WHERE id = $1 FOR UPDATE`, *booking.SubscriptionID); err != nil {
+			return err
+		}
+		var used int
+		usedQuery := `SELECT COUNT(*) FROM class_bookings WHERE subscription_id = $1 AND status <> 'cancelled'`
+		if err := tx.QueryRow(ctx, usedQuery, *booking.SubscriptionID).Scan(&used); err != nil {
+			return err
+		}
+		if used >= *credits {
+			return ErrNoClassCredits
+		}
+	}
+
+	var capacity int
+	if err := tx.QueryRow(ctx, `SELECT capacity FROM class_sessions WHERE id = $1 FOR UPDATE`, booking.SessionID).Scan(&capacity); err != nil {
+		return err
+	}
+
+	var booked int
+	countQuery := `SELECT COUNT(*) FROM class_bookings WHERE session_id = $1 AND status IN ('booked', 'attended', 'no_show')`
+	if err := tx.QueryRow(ctx, countQuery, booking.SessionID).Scan(&booked); err != nil {
+		return err
+	}
+
+	booking.Status = BookingBooked
+	if booked >= capacity {
+		booking.Status = BookingWaitlisted
+	}
+
+	query := `
+		INSERT INTO class_bookings (session_id, member_id, subscription_id, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	if err := tx.QueryRow(ctx, query,
+		booking.SessionID,
+		booking.MemberID,
+		booking.SubscriptionID,
+		booking.Status,
+	).Scan(&booking.ID, &booking.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetActiveBooking(ctx context.Context, sessionID, memberID uuid.UUID) (*Booking, error) {
+	query := `SELECT ` + bookingColumns + bookingJoins + `
+		WHERE b.session_id = $1 AND b.member_id = $2 AND b.status <> 'cancelled'
+	`
+	return scanBooking(r.db.QueryRow(ctx, query, sessionID, memberID))
+}
+
+func (r *repositoryImpl) GetBooking(ctx context.Context, id uuid.UUID) (*Booking, error) {
+	query := `SELECT ` + bookingColumns + bookingJoins + `WHERE b.id = $1`
+	return scanBooking(r.db.QueryRow(ctx, query, id))
+}
+
+// CancelBooking cancels a booking and, if it held a spot, promotes the
+// oldest waitlisted booking. The promoted booking is returned (nil if none).
+func (r *repositoryImpl) CancelBooking(ctx context.Context, id uuid.UUID) (*Booking, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var sessionID uuid.UUID
+	var previous BookingStatus
+	query := `
+		UPDATE class_bookings b
+		SET status = 'cancelled', cancelled_at = NOW()
+		FROM (SELECT id, status FROM class_bookings WHERE id = $1 FOR UPDATE) old
+		WHERE b.id = old.id AND old.status IN ('booked', 'waitlisted')
+		RETURNING b.session_id, old.status
+	`
+	if err := tx.QueryRow(ctx, query, id).Scan(&sessionID, &previous); err != nil {
+		return nil, err
+	}
+
+	var promoted *Booking
+	if previous == BookingBooked {
+		var promotedID uuid.UUID
+		promoteQuery := `
+			UPDATE class_bookings
+			SET status = 'booked'
+			WHERE id = (
+				SELECT id FROM class_bookings
+				WHERE session_id = $1 AND status = 'waitlisted'
+				ORDER BY created_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id
+		`
+		err := tx.QueryRow(ctx, promoteQuery, sessionID).Scan(&promotedID)
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			promoted = &Booking{ID: promotedID}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if promoted != nil {
+		return r.GetBooking(ctx, promoted.ID)
+	}
+	return nil, nil
+}
+
+func (r *repositoryImpl) ListSessionBookings(ctx context.Context, sessionID uuid.UUID) ([]*Booking, error) {
+	query := `SELECT ` + bookingColumns + bookingJoins + `
+		WHERE b.session_id = $1
+		ORDER BY b.status, b.created_at
+	`
+	rows, err := r.db.Query(ctx, query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []*Booking
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	return bookings, rows.Err()
+}
+
+func (r *repositoryImpl) ListMemberBookings(ctx context.Context, memberID uuid.UUID, limit, offset int) ([]*Booking, error) {
+	query := `SELECT ` + bookingColumns + bookingJoins + `
+		WHERE b.member_id = $1
+		ORDER BY s.starts_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(ctx, query, memberID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []*Booking
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	return bookings, rows.Err()
+}
+
+// ProcessAttendance settles finished sessions: a booking is "attended" when
+// the member has a check-in at the branch overlapping the class, otherwise it
+// becomes a no-show. Leftover waitlist entries are cancelled.
+func (r *repositoryImpl) ProcessAttendance(ctx context.Context) (*AttendanceResult, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id
+		FROM class_sessions
+		WHERE ends_at < NOW() AND attendance_processed_at IS NULL AND is_cancelled IS FALSE
+		FOR UPDATE SKIP LOCKED
+	`)
+	if err != nil {
+		return nil, err
+	}
+	sessionIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AttendanceResult{Sessions: len(sessionIDs)}
+	if len(sessionIDs) == 0 {
+		return result, nil
+	}
+
+	query := `
+		WITH matched AS (
+			SELECT b.id AS booking_id, (
+				SELECT c.id
+				FROM check_ins c
+				WHERE c.member_id = b.member_id
+					AND c.branch_id = s.branch_id
+					AND c.deleted_at IS NULL
+					AND c.check_in_time <= s.ends_at
+					AND (c.check_out_time IS NULL OR c.check_out_time >= s.starts_at)
+				ORDER BY c.check_in_time
+				LIMIT 1
+			) AS check_in_id
+			FROM class_bookings b
+			JOIN class_sessions s ON s.id = b.session_id
+			WHERE b.session_id = ANY($1) AND b.status = 'booked'
+		)
+		UPDATE class_bookings b
+		SET status = CASE WHEN m.check_in_id IS NULL THEN 'no_show' ELSE 'attended' END::class_booking_status_enum,
+			check_in_id = m.check_in_id
+		FROM matched m
+		WHERE b.id = m.booking_id
+		RETURNING b.status
+	`
+	statusRows, err := tx.Query(ctx, query, sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := pgx.CollectRows(statusRows, pgx.RowTo[BookingStatus])
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		if status == BookingAttended {
+			result.Attended++
+		} else {
+			result.NoShows++
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE class_bookings
+		SET status = 'cancelled', cancelled_at = NOW()
+		WHERE session_id = ANY($1) AND status = 'waitlisted'
+	`, sessionIDs); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE class_sessions SET attendance_processed_at = NOW() WHERE id = ANY($1)`, sessionIDs); err != nil {
+		return nil, err
+	}
+
+	return result, tx.Commit(ctx)
+}
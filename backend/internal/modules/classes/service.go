@@ -0,0 +1,401 @@
+package classes
+
+import (
+	"context"
+	"errors"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04"
+
+	// generateDays is how far ahead recurring schedules are materialised.
+	generateDays = 21
+)
+
+var (
+	ErrClassTypeNotFound     = errors.New("class type not found")
+	ErrClassTypeExists       = errors.New("a class type with this name already exists")
+	ErrScheduleNotFound      = errors.New("schedule not found")
+	ErrSessionNotFound       = errors.New("class session not found")
+	ErrBookingNotFound       = errors.New("booking not found")
+	ErrMemberNotFound        = errors.New("member not found")
+	ErrInvalidInstructor     = errors.New("instructor must be an active staff or admin user")
+	ErrInvalidStartTime      = errors.New("start time must be HH:MM")
+	ErrInvalidDate           = errors.New("date must be YYYY-MM-DD")
+	ErrSessionNotBookable    = errors.New("class session is cancelled or has already started")
+	ErrAlreadyBooked         = errors.New("member already has a booking for this class")
+	ErrNoActiveSubscription  = errors.New("an active subscription is required to book classes")
+	ErrSubscriptionFrozen    = errors.New("classes cannot be booked while the membership is frozen")
+	ErrClassTypeNotAllowed   = errors.New("membership plan does not include this class type")
+	ErrNoClassCredits        = errors.New("no class credits left on this subscription")
+	ErrCancellationCutoff    = errors.New("cancellation cutoff has passed for this class")
+	ErrBookingNotCancellable = errors.New("booking can no longer be cancelled")
+)
+
+type Service interface {
+	CreateClassType(ctx context.Context, req *CreateClassTypeRequest) (*ClassType, error)
+	UpdateClassType(ctx context.Context, id uuid.UUID, req *UpdateClassTypeRequest) (*ClassType, error)
+	ListClassTypes(ctx context.Context, organizationID uuid.UUID) ([]*ClassType, error)
+
+	CreateSchedule(ctx context.Context, req *CreateScheduleRequest) (*Schedule, error)
+	ListSchedules(ctx context.Context, branchID uuid.UUID) ([]*Schedule, error)
+	DeactivateSchedule(ctx context.Context, id uuid.UUID) error
+
+	ListSessions(ctx context.Context, branchID uuid.UUID, from, to time.Time) ([]*Session, error)
+	CancelSession(ctx context.Context, id uuid.UUID) error
+	ListSessionBookings(ctx context.Context, sessionID uuid.UUID) ([]*Booking, error)
+
+	BookSession(ctx context.Context, userID, sessionID uuid.UUID) (*Booking, error)
+	CancelMyBooking(ctx context.Context, userID, sessionID uuid.UUID) error
+	CancelBooking(ctx context.Context, bookingID uuid.UUID) error
+	ListMyBookings(ctx context.Context, userID uuid.UUID, page, limit int) ([]*Booking, error)
+
+	// Background jobs
+	GenerateSessions(ctx context.Context) error
+	ProcessAttendance(ctx context.Context) (*AttendanceResult, error)
+}
+
+type serviceImpl struct {
+	repo     Repository
+	subSvc   subscription.Service
+	plansSvc plans.Service
+}
+
+func NewService(repo Repository, subSvc subscription.Service, plansSvc plans.Service) Service {
+	return &serviceImpl{repo: repo, subSvc: subSvc, plansSvc: plansSvc}
+}
+
+func (s *serviceImpl) CreateClassType(ctx context.Context, req *CreateClassTypeRequest) (*ClassType, error) {
+	duration := req.DefaultDurationMinutes
+	if duration == 0 {
+		duration = 60
+	}
+
+	classType := &ClassType{
+		OrganizationID:         req.OrganizationID,
+		Name:                   req.Name,
+		Description:            req.Description,
+		DefaultDurationMinutes: duration,
+		IsActive:               true,
+	}
+	if err := s.repo.CreateType(ctx, classType); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrClassTypeExists
+		}
+		log.Printf("Service: CreateClassType failed - %v", err)
+		return nil, err
+	}
+	return classType, nil
+}
+
+func (s *serviceImpl) UpdateClassType(ctx context.Context, id uuid.UUID, req *UpdateClassTypeRequest) (*ClassType, error) {
+	classType, err := s.repo.GetType(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClassTypeNotFound
+		}
+		return nil, err
+	}
+
+	if req.Name != "" {
+		classType.Name = req.Name
+	}
+	if req.Description != nil {
+		classType.Description = req.Description
+	}
+	if req.DefaultDurationMinutes != nil {
+		classType.DefaultDurationMinutes = *req.DefaultDurationMinutes
+	}
+	if req.IsActive != nil {
+		classType.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateType(ctx, classType); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrClassTypeExists
+		}
+		log.Printf("Service: UpdateClassType failed for %s - %v", id, err)
+		return nil, err
+	}
+	return classType, nil
+}
+
+func (s *serviceImpl) ListClassTypes(ctx context.Context, organizationID uuid.UUID) ([]*ClassType, error) {
+	return s.repo.ListTypes(ctx, organizationID)
+}
+
+func (s *serviceImpl) CreateSchedule(ctx context.Context, req *CreateScheduleRequest) (*Schedule, error) {
+	if _, err := time.Parse(timeLayout, req.StartTime); err != nil {
+		return nil, ErrInvalidStartTime
+	}
+
+	classType, err := s.repo.GetType(ctx, req.ClassTypeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrClassTypeNotFound
+		}
+		return nil, err
+	}
+
+	if req.InstructorID != nil {
+		role, err := s.repo.GetUserRole(ctx, *req.InstructorID)
+		if err != nil || (role != "staff" && role != "admin") {
+			return nil, ErrInvalidInstructor
+		}
+	}
+
+	schedule := &Schedule{
+		BranchID:                  req.BranchID,
+		ClassTypeID:               req.ClassTypeID,
+		InstructorID:              req.InstructorID,
+		Room:                      req.Room,
+		Capacity:                  req.Capacity,
+		DayOfWeek:                 req.DayOfWeek,
+		StartTime:                 req.StartTime,
+		DurationMinutes:           classType.DefaultDurationMinutes,
+		CancellationCutoffMinutes: 120,
+		ValidFrom:                 time.Now(),
+	}
+	if req.DurationMinutes != nil {
+		schedule.DurationMinutes = *req.DurationMinutes
+	}
+	if req.CancellationCutoffMinutes != nil {
+		schedule.CancellationCutoffMinutes = *req.CancellationCutoffMinutes
+	}
+	if req.ValidFrom != nil {
+		validFrom, err := time.Parse(dateLayout, *req.ValidFrom)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		schedule.ValidFrom = validFrom
+	}
+	if req.ValidUntil != nil {
+		validUntil, err := time.Parse(dateLayout, *req.ValidUntil)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		schedule.ValidUntil = &validUntil
+	}
+
+	if err := s.repo.CreateSchedule(ctx, schedule); err != nil {
+		log.Printf("Service: CreateSchedule failed - %v", err)
+		return nil, err
+	}
+
+	// Make the new slot bookable right away rather than on the next job run.
+	if err := s.GenerateSessions(ctx); err != nil {
+		log.Printf("Service: CreateSchedule could not generate sessions - %v", err)
+	}
+	return schedule, nil
+}
+
+func (s *serviceImpl) ListSchedules(ctx context.Context, branchID uuid.UUID) ([]*Schedule, error) {
+	return s.repo.ListSchedules(ctx, branchID)
+}
+
+func (s *serviceImpl) DeactivateSchedule(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.DeactivateSchedule(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrScheduleNotFound
+		}
+		log.Printf("Service: DeactivateSchedule failed for %s - %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (s *serviceImpl) ListSessions(ctx context.Context, branchID uuid.UUID, from, to time.Time) ([]*Session, error) {
+	return s.repo.ListSessions(ctx, branchID, from, to)
+}
+
+func (s *serviceImpl) CancelSession(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.CancelSession(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		log.Printf("Service: CancelSession failed for %s - %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (s *serviceImpl) ListSessionBookings(ctx context.Context, sessionID uuid.UUID) ([]*Booking, error) {
+	return s.repo.ListSessionBookings(ctx, sessionID)
+}
+
+// BookSession books the member behind userID into a class, or waitlists
+// them when it is full. The member's active plan decides which class types
+// are allowed and how many credits they have.
+func (s *serviceImpl) BookSession(ctx context.Context, userID, sessionID uuid.UUID) (*Booking, error) {
+	memberID, err := s.memberIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if session.IsCancelled || !session.StartsAt.After(time.Now()) {
+		return nil, ErrSessionNotBookable
+	}
+
+	sub, err := s.subSvc.CheckAccess(ctx, memberID, session.StartsAt)
+	if errors.Is(err, subscription.ErrSubscriptionFrozen) {
+		return nil, ErrSubscriptionFrozen
+	}
+	if err != nil {
+		log.Printf("Service: BookSession - no active subscription for member %s: %v", memberID, err)
+		return nil, ErrNoActiveSubscription
+	}
+
+	var credits *int
+	if sub.PlanID != nil {
+		plan, err := s.plansSvc.GetPlan(ctx, *sub.PlanID)
+		if err != nil {
+			log.Printf("Service: BookSession failed - get plan %s: %v", *sub.PlanID, err)
+			return nil, err
+		}
+
+		if plan.ClassTypeIDs != nil && !slices.Contains(plan.ClassTypeIDs, session.ClassTypeID.String()) {
+			return nil, ErrClassTypeNotAllowed
+		}
+		credits = plan.ClassCredits
+	}
+
+	booking := &Booking{
+		SessionID:      sessionID,
+		MemberID:       memberID,
+		SubscriptionID: &sub.ID,
+	}
+	if err := s.repo.CreateBooking(ctx, booking, credits); err != nil {
+		if errors.Is(err, ErrNoClassCredits) {
+			return nil, err
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrAlreadyBooked
+		}
+		log.Printf("Service: BookSession failed for member %s, session %s - %v", memberID, sessionID, err)
+		return nil, fmt.Errorf("failed to book class: %w", err)
+	}
+
+	log.Printf("Service: Member %s %s for session %s", memberID, booking.Status, sessionID)
+	return s.repo.GetBooking(ctx, booking.ID)
+}
+
+// CancelMyBooking is the member-facing cancel, which enforces the session's
+// cancellation cutoff for confirmed bookings. Waitlist entries can always be
+// dropped.
+func (s *serviceImpl) CancelMyBooking(ctx context.Context, userID, sessionID uuid.UUID) error {
+	memberID, err := s.memberIDForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	booking, err := s.repo.GetActiveBooking(ctx, sessionID, memberID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBookingNotFound
+		}
+		return err
+	}
+
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if booking.Status == BookingBooked {
+		cutoff := session.StartsAt.Add(-time.Duration(session.CancellationCutoffMinutes) * time.Minute)
+		if time.Now().After(cutoff) {
+			return ErrCancellationCutoff
+		}
+	}
+
+	return s.CancelBooking(ctx, booking.ID)
+}
+
+// CancelBooking cancels without checking the cutoff; staff use it directly.
+func (s *serviceImpl) CancelBooking(ctx context.Context, bookingID uuid.UUID) error {
+	promoted, err := s.repo.CancelBooking(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBookingNotCancellable
+		}
+		log.Printf("Service: CancelBooking failed for %s - %v", bookingID, err)
+		return err
+	}
+
+	if promoted != nil {
+		log.Printf("Service: Booking %s promoted from waitlist for member %s", promoted.ID, promoted.MemberID)
+	}
+	return nil
+}
+
+func (s *serviceImpl) ListMyBookings(ctx context.Context, userID uuid.UUID, page, limit int) ([]*Booking, error) {
+	memberID, err := s.memberIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+	return s.repo.ListMemberBookings(ctx, memberID, limit, offset)
+}
+
+func (s *serviceImpl) GenerateSessions(ctx context.Context) error {
+	created, err := s.repo.GenerateSessions(ctx, generateDays)
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		log.Printf("Service: GenerateSessions created %d class session(s)", created)
+	}
+	return nil
+}
+
+func (s *serviceImpl) ProcessAttendance(ctx context.Context) (*AttendanceResult, error) {
+	result, err := s.repo.ProcessAttendance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Sessions > 0 {
+		log.Printf("Service: ProcessAttendance settled %d session(s): %d attended, %d no-show", result.Sessions, result.Attended, result.NoShows)
+	}
+	return result, nil
+}
+
+func (s *serviceImpl) memberIDForUser(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	memberID, err := s.repo.GetMemberIDByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrMemberNotFound
+		}
+		return uuid.Nil, err
+	}
+	return memberID, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
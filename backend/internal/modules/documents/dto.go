@@ -0,0 +1,140 @@
+package documents
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateTemplateRequest creates a template with its first version.
+// Questions turn a health questionnaire into yes/no questions the signer
+// answers.
+type CreateTemplateRequest struct {
+	OrganizationID uuid.UUID `json:"organizationId" validate:"required"`
+	Kind           string    `json:"kind" validate:"required,oneof=waiver health_questionnaire marketing_consent other"`
+	Title          string    `json:"title" validate:"required,max=255"`
+	// IsRequired blocks check-in until the member has signed; it defaults
+	// to true except for marketing consent.
+	IsRequired *bool    `json:"isRequired,omitempty"`
+	Body       string   `json:"body" validate:"required"`
+	Questions  []string `json:"questions,omitempty" validate:"omitempty,max=50,dive,required,max=500"`
+}
+
+type UpdateTemplateRequest struct {
+	Title      *string `json:"title,omitempty" validate:"omitempty,max=255"`
+	IsRequired *bool   `json:"isRequired,omitempty"`
+	IsActive   *bool   `json:"isActive,omitempty"`
+}
+
+// PublishVersionRequest replaces the text of a template. Every member has
+// to sign the new version before their next check-in.
+type PublishVersionRequest struct {
+	Body      string   `json:"body" validate:"required"`
+	Questions []string `json:"questions,omitempty" validate:"omitempty,max=50,dive,required,max=500"`
+}
+
+// SignRequest signs the version shown to the signer. DocumentHash is the
+// contentHash of that version, so a text changed in the meantime is not
+// signed unseen. Signature is the typed name or a drawn image as a data URL.
+type SignRequest struct {
+	VersionID    uuid.UUID `json:"versionId" validate:"required"`
+	DocumentHash string    `json:"documentHash" validate:"required,len=64,hexadecimal"`
+	SignerName   string    `json:"signerName" validate:"required,max=255"`
+	Signature    string    `json:"signature" validate:"required,max=200000"`
+	Answers      []bool    `json:"answers,omitempty"`
+}
+
+// SignMeta is where a signature came from, kept with the signed record.
+type SignMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+type ReportFilter struct {
+	OrganizationIDs []uuid.UUID
+	BranchIDs       []uuid.UUID
+	BranchID        *uuid.UUID
+	TemplateID      *uuid.UUID
+	Page            int
+	Limit           int
+}
+
+type TemplateResponse struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Kind           string    `json:"kind"`
+	Title          string    `json:"title"`
+	IsRequired     bool      `json:"isRequired"`
+	IsActive       bool      `json:"isActive"`
+	CurrentVersion int       `json:"currentVersion"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type TemplateDetailResponse struct {
+	*TemplateResponse
+	Current *VersionResponse `json:"current,omitempty"`
+}
+
+type VersionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	TemplateID  uuid.UUID `json:"templateId"`
+	Version     int       `json:"version"`
+	Body        string    `json:"body"`
+	Questions   []string  `json:"questions,omitempty"`
+	ContentHash string    `json:"contentHash"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// DocumentResponse is a document as it stands for one member, with the
+// current version to sign.
+type DocumentResponse struct {
+	TemplateID    uuid.UUID        `json:"templateId"`
+	Kind          string           `json:"kind"`
+	Title         string           `json:"title"`
+	IsRequired    bool             `json:"isRequired"`
+	Status        string           `json:"status"`
+	Current       *VersionResponse `json:"current,omitempty"`
+	SignatureID   *uuid.UUID       `json:"signatureId,omitempty"`
+	SignedVersion *int             `json:"signedVersion,omitempty"`
+	SignedAt      *time.Time       `json:"signedAt,omitempty"`
+}
+
+type SignatureResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	MemberID      uuid.UUID  `json:"memberId"`
+	TemplateID    uuid.UUID  `json:"templateId"`
+	TemplateTitle string     `json:"templateTitle,omitempty"`
+	VersionID     uuid.UUID  `json:"versionId"`
+	Version       int        `json:"version"`
+	SignerName    string     `json:"signerName"`
+	Signature     string     `json:"signature"`
+	Answers       []bool     `json:"answers,omitempty"`
+	Flagged       bool       `json:"flagged"`
+	DocumentHash  string     `json:"documentHash"`
+	RecordHash    string     `json:"recordHash"`
+	Channel       string     `json:"channel"`
+	IPAddress     *string    `json:"ipAddress,omitempty"`
+	UserAgent     *string    `json:"userAgent,omitempty"`
+	CollectedBy   *uuid.UUID `json:"collectedBy,omitempty"`
+	SignedAt      time.Time  `json:"signedAt"`
+	AttachmentURL *string    `json:"attachmentUrl,omitempty"`
+}
+
+// SignedRecordResponse is a signature with the text that was signed.
+type SignedRecordResponse struct {
+	*SignatureResponse
+	Document *VersionResponse `json:"document"`
+}
+
+type OutstandingResponse struct {
+	MemberID       uuid.UUID  `json:"memberId"`
+	MemberName     string     `json:"memberName"`
+	BranchID       *uuid.UUID `json:"branchId,omitempty"`
+	BranchName     *string    `json:"branchName,omitempty"`
+	TemplateID     uuid.UUID  `json:"templateId"`
+	TemplateTitle  string     `json:"templateTitle"`
+	Status         string     `json:"status"`
+	CurrentVersion int        `json:"currentVersion"`
+	SignedVersion  *int       `json:"signedVersion,omitempty"`
+}
@@ -0,0 +1,206 @@
+package documents
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindWaiver              Kind = "waiver"
+	KindHealthQuestionnaire Kind = "health_questionnaire"
+	KindMarketingConsent    Kind = "marketing_consent"
+	KindOther               Kind = "other"
+)
+
+// Channels a signature is collected through.
+const (
+	ChannelOnboarding = "onboarding"
+	ChannelPortal     = "portal"
+	ChannelFrontDesk  = "front_desk"
+)
+
+// Document statuses for a member.
+const (
+	StatusSigned   = "signed"
+	StatusOutdated = "outdated"
+	StatusMissing  = "missing"
+)
+
+// Template is a document members of an organization sign, such as a
+// liability waiver. CurrentVersion is the text members must have signed.
+type Template struct {
+	ID             uuid.UUID  `db:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	Kind           Kind       `db:"kind"`
+	Title          string     `db:"title"`
+	IsRequired     bool       `db:"is_required"`
+	IsActive       bool       `db:"is_active"`
+	CurrentVersion int        `db:"current_version"`
+	CreatedBy      *uuid.UUID `db:"created_by"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// Version is the published, unchangeable text of a template.
+type Version struct {
+	ID          uuid.UUID  `db:"id"`
+	TemplateID  uuid.UUID  `db:"template_id"`
+	Version     int        `db:"version"`
+	Body        string     `db:"body"`
+	Questions   []string   `db:"questions"`
+	ContentHash string     `db:"content_hash"`
+	CreatedBy   *uuid.UUID `db:"created_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+// Signature is the signed record of one version by a member.
+type Signature struct {
+	ID           uuid.UUID  `db:"id"`
+	MemberID     uuid.UUID  `db:"member_id"`
+	TemplateID   uuid.UUID  `db:"template_id"`
+	VersionID    uuid.UUID  `db:"version_id"`
+	Version      int        `db:"version"`
+	SignerName   string     `db:"signer_name"`
+	Signature    string     `db:"signature"`
+	Answers      []bool     `db:"answers"`
+	Flagged      bool       `db:"flagged"`
+	DocumentHash string     `db:"document_hash"`
+	RecordHash   string     `db:"record_hash"`
+	Channel      string     `db:"channel"`
+	IPAddress    *string    `db:"ip_address"`
+	UserAgent    *string    `db:"user_agent"`
+	CollectedBy  *uuid.UUID `db:"collected_by"`
+	SignedAt     time.Time  `db:"signed_at"`
+	// AttachmentFileID is a scan of the paper copy, if one was uploaded.
+	AttachmentFileID *uuid.UUID `db:"attachment_file_id"`
+
+	// Joined from document_templates.
+	TemplateTitle string `db:"template_title"`
+}
+
+// Document is a template with its current version and the member's latest
+// signature of it, if any.
+type Document struct {
+	Template  *Template
+	Version   *Version
+	Signature *Signature
+}
+
+// MemberRef is what scoping and signing need to know about a member.
+type MemberRef struct {
+	ID             uuid.UUID  `db:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	HomeBranchID   *uuid.UUID `db:"home_branch_id"`
+}
+
+// Outstanding is a required document an active member has not signed in
+// its current version.
+type Outstanding struct {
+	MemberID       uuid.UUID  `db:"member_id"`
+	MemberName     string     `db:"member_name"`
+	BranchID       *uuid.UUID `db:"branch_id"`
+	BranchName     *string    `db:"branch_name"`
+	TemplateID     uuid.UUID  `db:"template_id"`
+	TemplateTitle  string     `db:"template_title"`
+	CurrentVersion int        `db:"current_version"`
+	SignedVersion  *int       `db:"signed_version"`
+}
+
+func (d *Document) Status() string {
+	switch {
+	case d.Signature == nil:
+		return StatusMissing
+	case d.Signature.Version < d.Template.CurrentVersion:
+		return StatusOutdated
+	default:
+		return StatusSigned
+	}
+}
+
+func (t *Template) ToResponse() *TemplateResponse {
+	return &TemplateResponse{
+		ID:             t.ID,
+		OrganizationID: t.OrganizationID,
+		Kind:           string(t.Kind),
+		Title:          t.Title,
+		IsRequired:     t.IsRequired,
+		IsActive:       t.IsActive,
+		CurrentVersion: t.CurrentVersion,
+		CreatedAt:      t.CreatedAt,
+		UpdatedAt:      t.UpdatedAt,
+	}
+}
+
+func (v *Version) ToResponse() *VersionResponse {
+	return &VersionResponse{
+		ID:          v.ID,
+		TemplateID:  v.TemplateID,
+		Version:     v.Version,
+		Body:        v.Body,
+		Questions:   v.Questions,
+		ContentHash: v.ContentHash,
+		CreatedAt:   v.CreatedAt,
+	}
+}
+
+func (s *Signature) ToResponse() *SignatureResponse {
+	return &SignatureResponse{
+		ID:            s.ID,
+		MemberID:      s.MemberID,
+		TemplateID:    s.TemplateID,
+		TemplateTitle: s.TemplateTitle,
+		VersionID:     s.VersionID,
+		Version:       s.Version,
+		SignerName:    s.SignerName,
+		Signature:     s.Signature,
+		Answers:       s.Answers,
+		Flagged:       s.Flagged,
+		DocumentHash:  s.DocumentHash,
+		RecordHash:    s.RecordHash,
+		Channel:       s.Channel,
+		IPAddress:     s.IPAddress,
+		UserAgent:     s.UserAgent,
+		CollectedBy:   s.CollectedBy,
+		SignedAt:      s.SignedAt,
+	}
+}
+
+func (d *Document) ToResponse() *DocumentResponse {
+	res := &DocumentResponse{
+		TemplateID: d.Template.ID,
+		Kind:       string(d.Template.Kind),
+		Title:      d.Template.Title,
+		IsRequired: d.Template.IsRequired,
+		Status:     d.Status(),
+	}
+	if d.Version != nil {
+		res.Current = d.Version.ToResponse()
+	}
+	if d.Signature != nil {
+		res.SignatureID = &d.Signature.ID
+		res.SignedVersion = &d.Signature.Version
+		res.SignedAt = &d.Signature.SignedAt
+	}
+	return res
+}
+
+func (o *Outstanding) ToResponse() *OutstandingResponse {
+	status := StatusMissing
+	if o.SignedVersion != nil {
+		status = StatusOutdated
+	}
+	return &OutstandingResponse{
+		MemberID:       o.MemberID,
+		MemberName:     o.MemberName,
+		BranchID:       o.BranchID,
+		BranchName:     o.BranchName,
+		TemplateID:     o.TemplateID,
+		TemplateTitle:  o.TemplateTitle,
+		Status:         status,
+		CurrentVersion: o.CurrentVersion,
+		SignedVersion:  o.SignedVersion,
+	}
+}
@@ -0,0 +1,460 @@
+package documents
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/modules/files"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/documents", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member"))
+			r.Get("/me", h.MyDocuments)
+			r.Post("/me/sign", h.SignMine)
+			r.Get("/me/signatures/{id}", h.GetMySignature)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin", "staff"))
+			r.Get("/templates", h.ListTemplates)
+			r.Get("/templates/{id}", h.GetTemplate)
+			r.Get("/templates/{id}/versions", h.ListVersions)
+			r.Get("/members/{memberId}", h.MemberDocuments)
+			r.Get("/members/{memberId}/signatures", h.MemberSignatures)
+			r.Post("/members/{memberId}/sign", h.SignForMember)
+			r.Get("/signatures/{id}", h.GetSignature)
+			r.Put("/signatures/{id}/attachment", h.AttachFile)
+			r.Get("/report", h.Report)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Post("/templates", h.CreateTemplate)
+			r.Put("/templates/{id}", h.UpdateTemplate)
+			r.Delete("/templates/{id}", h.DeleteTemplate)
+			r.Post("/templates/{id}/versions", h.PublishVersion)
+		})
+	})
+}
+
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	organizationID, ok := optionalUUID(w, r, "organizationId")
+	if !ok {
+		return
+	}
+
+	templates, err := h.service.ListTemplates(r.Context(), userID, userRole, organizationID)
+	if err != nil {
+		writeError(w, err, "Failed to list document templates")
+		return
+	}
+	templateResponses := make([]*TemplateResponse, len(templates))
+	for i, t := range templates {
+		templateResponses[i] = t.ToResponse()
+	}
+	response.Success(w, "Document templates retrieved successfully", templateResponses)
+}
+
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+
+	res, err := h.service.GetTemplate(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to get document template")
+		return
+	}
+	response.Success(w, "Document template retrieved successfully", res)
+}
+
+func (h *Handler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req CreateTemplateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.CreateTemplate(r.Context(), userID, userRole, &req)
+	if err != nil {
+		writeError(w, err, "Failed to create document template")
+		return
+	}
+	response.Success(w, "Document template created successfully", res)
+}
+
+func (h *Handler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+	var req UpdateTemplateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	t, err := h.service.UpdateTemplate(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to update document template")
+		return
+	}
+	response.Success(w, "Document template updated successfully", t.ToResponse())
+}
+
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTemplate(r.Context(), userID, userRole, id); err != nil {
+		writeError(w, err, "Failed to delete document template")
+		return
+	}
+	response.Success(w, "Document template deleted successfully", nil)
+}
+
+func (h *Handler) PublishVersion(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+	var req PublishVersionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	v, err := h.service.PublishVersion(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to publish document version")
+		return
+	}
+	response.Success(w, "Document version published successfully", v.ToResponse())
+}
+
+func (h *Handler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+
+	versions, err := h.service.ListVersions(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to list document versions")
+		return
+	}
+	versionResponses := make([]*VersionResponse, len(versions))
+	for i, v := range versions {
+		versionResponses[i] = v.ToResponse()
+	}
+	response.Success(w, "Document versions retrieved successfully", versionResponses)
+}
+
+func (h *Handler) MemberDocuments(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := pathUUID(w, r, "memberId", "Invalid member ID")
+	if !ok {
+		return
+	}
+
+	docs, err := h.service.MemberDocuments(r.Context(), userID, userRole, memberID)
+	if err != nil {
+		writeError(w, err, "Failed to list member documents")
+		return
+	}
+	response.Success(w, "Member documents retrieved successfully", documentResponses(docs))
+}
+
+func (h *Handler) MemberSignatures(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := pathUUID(w, r, "memberId", "Invalid member ID")
+	if !ok {
+		return
+	}
+
+	signatures, err := h.service.MemberSignatures(r.Context(), userID, userRole, memberID)
+	if err != nil {
+		writeError(w, err, "Failed to list member signatures")
+		return
+	}
+	signatureResponses := make([]*SignatureResponse, len(signatures))
+	for i, s := range signatures {
+		signatureResponses[i] = s.ToResponse()
+	}
+	response.Success(w, "Member signatures retrieved successfully", signatureResponses)
+}
+
+func (h *Handler) SignForMember(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := pathUUID(w, r, "memberId", "Invalid member ID")
+	if !ok {
+		return
+	}
+	var req SignRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	sig, err := h.service.SignForMember(r.Context(), userID, userRole, memberID, &req, signMeta(r))
+	if err != nil {
+		writeError(w, err, "Failed to sign document")
+		return
+	}
+	response.Success(w, "Document signed successfully", sig.ToResponse())
+}
+
+func (h *Handler) GetSignature(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid signature ID")
+	if !ok {
+		return
+	}
+
+	res, err := h.service.GetSignature(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to get signature")
+		return
+	}
+	response.Success(w, "Signature retrieved successfully", res)
+}
+
+// AttachFile takes a multipart form with the scan or photo in file.
+func (h *Handler) AttachFile(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid signature ID")
+	if !ok {
+		return
+	}
+	filename, data, ok := files.FormFile(w, r, files.PurposeDocument)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.AttachFile(r.Context(), userID, userRole, id, filename, data)
+	if err != nil {
+		writeError(w, err, "Failed to attach file")
+		return
+	}
+	response.Success(w, "File attached successfully", res)
+}
+
+// Report lists members with a required document missing or outdated,
+// filtered by branchId and templateId.
+func (h *Handler) Report(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &ReportFilter{}
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if filter.BranchID, ok = optionalUUID(w, r, "branchId"); !ok {
+		return
+	}
+	if filter.TemplateID, ok = optionalUUID(w, r, "templateId"); !ok {
+		return
+	}
+
+	outstanding, err := h.service.Report(r.Context(), userID, userRole, filter)
+	if err != nil {
+		writeError(w, err, "Failed to build document report")
+		return
+	}
+	outstandingResponses := make([]*OutstandingResponse, len(outstanding))
+	for i, o := range outstanding {
+		outstandingResponses[i] = o.ToResponse()
+	}
+	response.Success(w, "Document report retrieved successfully", outstandingResponses)
+}
+
+func (h *Handler) MyDocuments(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	docs, err := h.service.MyDocuments(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "Failed to list documents")
+		return
+	}
+	response.Success(w, "Documents retrieved successfully", documentResponses(docs))
+}
+
+func (h *Handler) SignMine(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req SignRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	sig, err := h.service.SignMine(r.Context(), userID, &req, signMeta(r))
+	if err != nil {
+		writeError(w, err, "Failed to sign document")
+		return
+	}
+	response.Success(w, "Document signed successfully", sig.ToResponse())
+}
+
+func (h *Handler) GetMySignature(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid signature ID")
+	if !ok {
+		return
+	}
+
+	res, err := h.service.GetMySignature(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, err, "Failed to get signature")
+		return
+	}
+	response.Success(w, "Signature retrieved successfully", res)
+}
+
+func documentResponses(docs []*Document) []*DocumentResponse {
+	res := make([]*DocumentResponse, len(docs))
+	for i, d := range docs {
+		res[i] = d.ToResponse()
+	}
+	return res
+}
+
+func signMeta(r *http.Request) *SignMeta {
+	return &SignMeta{IPAddress: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+}
+
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrTemplateNotFound), errors.Is(err, ErrVersionNotFound),
+		errors.Is(err, ErrSignatureNotFound), errors.Is(err, ErrMemberNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, ErrOrganizationAccess):
+		response.Forbidden(w, err.Error())
+	case errors.Is(err, ErrOutdatedVersion), errors.Is(err, ErrDocumentChanged):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, ErrTemplateInactive), errors.Is(err, ErrAnswersRequired), files.IsUploadError(err):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return false
+	}
+	return response.ValidateStructAndWrite(w, req)
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		response.BadRequest(w, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func optionalUUID(w http.ResponseWriter, r *http.Request, param string) (*uuid.UUID, bool) {
+	v := r.URL.Query().Get(param)
+	if v == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		response.BadRequest(w, "Invalid "+param+" parameter", nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+func callerFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+	return userID, userRole, true
+}
@@ -0,0 +1,548 @@
+package documents
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// CreateTemplate creates the template and its first version.
+	CreateTemplate(ctx context.Context, template *Template, version *Version) error
+	GetTemplate(ctx context.Context, id uuid.UUID) (*Template, error)
+	ListTemplates(ctx context.Context, organizationIDs []uuid.UUID) ([]*Template, error)
+	UpdateTemplate(ctx context.Context, template *Template) error
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+
+	// PublishVersion adds the next version of a template and makes it the
+	// current one.
+	PublishVersion(ctx context.Context, version *Version) error
+	GetVersion(ctx context.Context, id uuid.UUID) (*Version, error)
+	ListVersions(ctx context.Context, templateID uuid.UUID) ([]*Version, error)
+
+	GetMember(ctx context.Context, id uuid.UUID) (*MemberRef, error)
+	GetMemberByUserID(ctx context.Context, userID uuid.UUID) (*MemberRef, error)
+
+	// ListDocuments lists the active templates of an organization with their
+	// current version and the member's latest signature. A nil memberID
+	// lists them unsigned.
+	ListDocuments(ctx context.Context, organizationID, memberID uuid.UUID) ([]*Document, error)
+	// MissingRequired lists the titles of the required documents the member
+	// has not signed in their current version.
+	MissingRequired(ctx context.Context, memberID uuid.UUID) ([]string, error)
+	ListOutstanding(ctx context.Context, filter *ReportFilter) ([]*Outstanding, error)
+
+	// CreateSignature stores the signed record. Signing marketing consent
+	// also opts the member in to marketing emails.
+	CreateSignature(ctx context.Context, signature *Signature, kind Kind) error
+	GetSignature(ctx context.Context, id uuid.UUID) (*Signature, error)
+	SetAttachment(ctx context.Context, id uuid.UUID, fileID *uuid.UUID) error
+	ListSignatures(ctx context.Context, memberID uuid.UUID) ([]*Signature, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+const templateColumns = `
+	t.id, t.organization_id, t.kind, t.title, t.is_required, t.is_active, t.current_version, t.created_by, t.created_at, t.updated_at
+`
+
+const versionColumns = `
+	v.id, v.template_id, v.version, v.body, v.questions, v.content_hash, v.created_by, v.created_at
+`
+
+const signatureColumns = `
+	s.id, s.member_id, s.template_id, s.version_id, s.version, s.signer_name, s.signature, s.answers, s.flagged,
+	s.document_hash, s.record_hash, s.channel, s.ip_address, s.user_agent, s.collected_by, s.signed_at,
+	s.attachment_file_id, t.title
+`
+
+func scanTemplate(row pgx.Row) (*Template, error) {
+	var t Template
+	err := row.Scan(
+		&t.ID,
+		&t.OrganizationID,
+		&t.Kind,
+		&t.Title,
+		&t.IsRequired,
+		&t.IsActive,
+		&t.CurrentVersion,
+		&t.CreatedBy,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func scanVersion(row pgx.Row) (*Version, error) {
+	var v Version
+	err := row.Scan(
+		&v.ID,
+		&v.TemplateID,
+		&v.Version,
+		&v.Body,
+		&v.Questions,
+		&v.ContentHash,
+		&v.CreatedBy,
+		&v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func scanSignature(row pgx.Row) (*Signature, error) {
+	var s Signature
+	err := row.Scan(
+		&s.ID,
+		&s.MemberID,
+		&s.TemplateID,
+		&s.VersionID,
+		&s.Version,
+		&s.SignerName,
+		&s.Signature,
+		&s.Answers,
+		&s.Flagged,
+		&s.DocumentHash,
+		&s.RecordHash,
+		&s.Channel,
+		&s.IPAddress,
+		&s.UserAgent,
+		&s.CollectedBy,
+		&s.SignedAt,
+		&s.AttachmentFileID,
+		&s.TemplateTitle,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *repositoryImpl) CreateTemplate(ctx context.Context, t *Template, v *Version) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	templateQuery := `
+		INSERT INTO document_templates (organization_id, kind, title, is_required, is_active, current_version, created_by)
+		VALUES ($1, $2, $3, $4, TRUE, 1, $5)
+		RETURNING id, is_active, current_version, created_at, updated_at
+	`
+	if err := tx.QueryRow(ctx, templateQuery,
+		t.OrganizationID,
+		t.Kind,
+		t.Title,
+		t.IsRequired,
+		t.CreatedBy,
+	).Scan(&t.ID, &t.IsActive, &t.CurrentVersion, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return err
+	}
+
+	v.TemplateID = t.ID
+	v.Version = t.CurrentVersion
+	versionQuery := `
+		INSERT INTO document_versions (template_id, version, body, questions, content_hash, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	if err := tx.QueryRow(ctx, versionQuery,
+		v.TemplateID,
+		v.Version,
+		v.Body,
+		v.Questions,
+		v.ContentHash,
+		v.CreatedBy,
+	).Scan(&v.ID, &v.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetTemplate(ctx context.Context, id uuid.UUID) (*Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM document_templates t WHERE t.id = $1 AND t.deleted_at IS NULL`
+	return scanTemplate(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) ListTemplates(ctx context.Context, organizationIDs []uuid.UUID) ([]*Template, error) {
+	query := `
+		SELECT ` + templateColumns + `
+		FROM document_templates t
+		WHERE t.deleted_at IS NULL AND ($1::uuid[] IS NULL OR t.organization_id = ANY($1))
+		ORDER BY t.is_active DESC, t.is_required DESC, t.title
+	`
+	var orgIDs any
+	if organizationIDs != nil {
+		orgIDs = organizationIDs
+	}
+	rows, err := r.db.Query(ctx, query, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*Template{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+func (r *repositoryImpl) UpdateTemplate(ctx context.Context, t *Template) error {
+	query := `
+		UPDATE document_templates
+		SET title = $1, is_required = $2, is_active = $3, updated_at = NOW()
+		WHERE id = $4 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(ctx, query, t.Title, t.IsRequired, t.IsActive, t.ID).Scan(&t.UpdatedAt)
+}
+
+func (r *repositoryImpl) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE document_templates SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *repositoryImpl) PublishVersion(ctx context.Context, v *Version) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	templateQuery := `
+		UPDATE document_templates
+		SET current_version = current_version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING current_version
+	`
+	if err := tx.QueryRow(ctx, templateQuery, v.TemplateID).Scan(&v.Version); err != nil {
+		return err
+	}
+
+	versionQuery := `
+		INSERT INTO document_versions (template_id, version, body, questions, content_hash, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	if err := tx.QueryRow(ctx, versionQuery,
+		v.TemplateID,
+		v.Version,
+		v.Body,
+		v.Questions,
+		v.ContentHash,
+		v.CreatedBy,
+	).Scan(&v.ID, &v.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetVersion(ctx context.Context, id uuid.UUID) (*Version, error) {
+	query := `SELECT ` + versionColumns + ` FROM document_versions v WHERE v.id = $1`
+	return scanVersion(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) ListVersions(ctx context.Context, templateID uuid.UUID) ([]*Version, error) {
+	query := `SELECT ` + versionColumns + ` FROM document_versions v WHERE v.template_id = $1 ORDER BY v.version DESC`
+	rows, err := r.db.Query(ctx, query, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*Version{}
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *repositoryImpl) GetMember(ctx context.Context, id uuid.UUID) (*MemberRef, error) {
+	query := `SELECT id, organization_id, home_branch_id FROM members WHERE id = $1 AND deleted_at IS NULL`
+	var m MemberRef
+	if err := r.db.QueryRow(ctx, query, id).Scan(&m.ID, &m.OrganizationID, &m.HomeBranchID); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repositoryImpl) GetMemberByUserID(ctx context.Context, userID uuid.UUID) (*MemberRef, error) {
+	query := `SELECT id, organization_id, home_branch_id FROM members WHERE user_id = $1 AND deleted_at IS NULL`
+	var m MemberRef
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&m.ID, &m.OrganizationID, &m.HomeBranchID); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repositoryImpl) ListDocuments(ctx context.Context, organizationID, memberID uuid.UUID) ([]*Document, error) {
+	query := `
+		SELECT ` + templateColumns + `, ` + versionColumns + `, ls.id, ls.version, ls.signed_at
+		FROM document_templates t
+		JOIN document_versions v ON v.template_id = t.id AND v.version = t.current_version
+		LEFT JOIN LATERAL (
+			SELECT s.id, s.version, s.signed_at
+			FROM document_signatures s
+			WHERE s.member_id = $2 AND s.template_id = t.id
+			ORDER BY s.version DESC, s.signed_at DESC
+			LIMIT 1
+		) ls ON TRUE
+		WHERE t.organization_id = $1 AND t.is_active AND t.deleted_at IS NULL
+		ORDER BY t.is_required DESC, t.title
+	`
+	rows, err := r.db.Query(ctx, query, organizationID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := []*Document{}
+	for rows.Next() {
+		var (
+			t             Template
+			v             Version
+			signatureID   *uuid.UUID
+			signedVersion *int
+			signedAt      *time.Time
+		)
+		if err := rows.Scan(
+			&t.ID, &t.OrganizationID, &t.Kind, &t.Title, &t.IsRequired, &t.IsActive, &t.CurrentVersion, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
+			&v.ID, &v.TemplateID, &v.Version, &v.Body, &v.Questions, &v.ContentHash, &v.CreatedBy, &v.CreatedAt,
+			&signatureID, &signedVersion, &signedAt,
+		); err != nil {
+			return nil, err
+		}
+		d := &Document{Template: &t, Version: &v}
+		if signatureID != nil {
+			d.Signature = &Signature{ID: *signatureID, MemberID: memberID, TemplateID: t.ID, Version: *signedVersion, SignedAt: *signedAt}
+		}
+		documents = append(documents, d)
+	}
+	return documents, rows.Err()
+}
+
+func (r *repositoryImpl) MissingRequired(ctx context.Context, memberID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT t.title
+		FROM members m
+		JOIN document_templates t ON t.organization_id = m.organization_id
+		WHERE m.id = $1 AND t.is_required AND t.is_active AND t.deleted_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM document_signatures s
+				WHERE s.member_id = m.id AND s.template_id = t.id AND s.version >= t.current_version
+			)
+		ORDER BY t.title
+	`
+	rows, err := r.db.Query(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	titles := []string{}
+	for rows.Next() {
+		var title string
+		if err := rows.Scan(&title); err != nil {
+			return nil, err
+		}
+		titles = append(titles, title)
+	}
+	return titles, rows.Err()
+}
+
+func (r *repositoryImpl) ListOutstanding(ctx context.Context, filter *ReportFilter) ([]*Outstanding, error) {
+	query := `
+		SELECT m.id, TRIM(m.first_name || ' ' || m.last_name), m.home_branch_id, b.name, t.id, t.title, t.current_version, ls.version
+		FROM members m
+		JOIN document_templates t ON t.organization_id = m.organization_id
+			AND t.is_required AND t.is_active AND t.deleted_at IS NULL
+		LEFT JOIN branches b ON b.id = m.home_branch_id
+		LEFT JOIN LATERAL (
+			SELECT MAX(s.version) AS version
+			FROM document_signatures s
+			WHERE s.member_id = m.id AND s.template_id = t.id
+		) ls ON TRUE
+		WHERE m.deleted_at IS NULL AND m.status = 'active'
+			AND (ls.version IS NULL OR ls.version < t.current_version)
+	`
+
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OrganizationIDs != nil {
+		query += " AND m.organization_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.OrganizationIDs)
+		argIndex++
+	}
+
+	if filter.BranchIDs != nil {
+		query += " AND m.home_branch_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.BranchIDs)
+		argIndex++
+	}
+
+	if filter.BranchID != nil {
+		query += " AND m.home_branch_id = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.BranchID)
+		argIndex++
+	}
+
+	if filter.TemplateID != nil {
+		query += " AND t.id = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.TemplateID)
+		argIndex++
+	}
+
+	query += " ORDER BY m.last_name, m.first_name, t.title"
+	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outstanding := []*Outstanding{}
+	for rows.Next() {
+		var o Outstanding
+		if err := rows.Scan(
+			&o.MemberID,
+			&o.MemberName,
+			&o.BranchID,
+			&o.BranchName,
+			&o.TemplateID,
+			&o.TemplateTitle,
+			&o.CurrentVersion,
+			&o.SignedVersion,
+		); err != nil {
+			return nil, err
+		}
+		outstanding = append(outstanding, &o)
+	}
+	return outstanding, rows.Err()
+}
+
+func (r *repositoryImpl) CreateSignature(ctx context.Context, s *Signature, kind Kind) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO document_signatures (
+			member_id, template_id, version_id, version, signer_name, signature, answers, flagged,
+			document_hash, record_hash, channel, ip_address, user_agent, collected_by, signed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, query,
+		s.MemberID,
+		s.TemplateID,
+		s.VersionID,
+		s.Version,
+		s.SignerName,
+		s.Signature,
+		s.Answers,
+		s.Flagged,
+		s.DocumentHash,
+		s.RecordHash,
+		s.Channel,
+		s.IPAddress,
+		s.UserAgent,
+		s.CollectedBy,
+		s.SignedAt,
+	).Scan(&s.ID); err != nil {
+		return err
+	}
+
+	if kind == KindMarketingConsent {
+		preferenceQuery := `
+			INSERT INTO member_notification_preferences (member_id, marketing_emails)
+			VALUES ($1, TRUE)
+			ON CONFLICT (member_id) DO UPDATE SET
+				marketing_emails = TRUE,
+				updated_at = NOW()
+		`
+		if _, err := tx.Exec(ctx, preferenceQuery, s.MemberID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetSignature(ctx context.Context, id uuid.UUID) (*Signature, error) {
+	query := `
+		SELECT ` + signatureColumns + `
+		FROM document_signatures s
+		JOIN document_templates t ON t.id = s.template_id
+		WHERE s.id = $1
+	`
+	return scanSignature(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) SetAttachment(ctx context.Context, id uuid.UUID, fileID *uuid.UUID) error {
+	query := `UPDATE document_signatures SET attachment_file_id = $2 WHERE id = $1`
+	tag, err := r.db.Exec(ctx, query, id, fileID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *repositoryImpl) ListSignatures(ctx context.Context, memberID uuid.UUID) ([]*Signature, error) {
+	query := `
+		SELECT ` + signatureColumns + `
+		FROM document_signatures s
+		JOIN document_templates t ON t.id = s.template_id
+		WHERE s.member_id = $1
+		ORDER BY s.signed_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signatures := []*Signature{}
+	for rows.Next() {
+		s, err := scanSignature(rows)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, s)
+	}
+	return signatures, rows.Err()
+}
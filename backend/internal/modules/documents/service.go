@@ -0,0 +1,588 @@
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fitcore/internal/middleware"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"fitcore/internal/modules/files"
+	"fitcore/internal/modules/user"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrTemplateNotFound   = errors.New("document template not found")
+	ErrVersionNotFound    = errors.New("document version not found")
+	ErrSignatureNotFound  = errors.New("signature not found")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrOrganizationAccess = errors.New("you do not have access to this organization")
+	ErrTemplateInactive   = errors.New("document is no longer in use")
+	ErrOutdatedVersion    = errors.New("a newer version of this document has been published")
+	ErrDocumentChanged    = errors.New("documentHash does not match the document text")
+	ErrAnswersRequired    = errors.New("answers must include one answer per question")
+	ErrSignatureRequired  = errors.New("required documents have not been signed")
+)
+
+// Service manages the documents members sign: waivers, health
+// questionnaires and consents. Members sign during onboarding, in the
+// portal or at the front desk, and check-in is refused while a required
+// document is missing or outdated.
+type Service interface {
+	ListTemplates(ctx context.Context, userID uuid.UUID, userRole string, organizationID *uuid.UUID) ([]*Template, error)
+	GetTemplate(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TemplateDetailResponse, error)
+	CreateTemplate(ctx context.Context, userID uuid.UUID, userRole string, req *CreateTemplateRequest) (*TemplateDetailResponse, error)
+	UpdateTemplate(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateTemplateRequest) (*Template, error)
+	DeleteTemplate(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) error
+	// PublishVersion makes new text current; earlier signatures become
+	// outdated.
+	PublishVersion(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *PublishVersionRequest) (*Version, error)
+	ListVersions(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) ([]*Version, error)
+
+	MemberDocuments(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID) ([]*Document, error)
+	MemberSignatures(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID) ([]*Signature, error)
+	// SignForMember records a signature collected at the front desk.
+	SignForMember(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID, req *SignRequest, meta *SignMeta) (*Signature, error)
+	GetSignature(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*SignedRecordResponse, error)
+	// AttachFile stores a scan of the paper copy with a signature, replacing
+	// any earlier one.
+	AttachFile(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, filename string, data []byte) (*SignedRecordResponse, error)
+	// Report lists who still has to sign a required document.
+	Report(ctx context.Context, userID uuid.UUID, userRole string, filter *ReportFilter) ([]*Outstanding, error)
+
+	MyDocuments(ctx context.Context, userID uuid.UUID) ([]*Document, error)
+	SignMine(ctx context.Context, userID uuid.UUID, req *SignRequest, meta *SignMeta) (*Signature, error)
+	GetMySignature(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*SignedRecordResponse, error)
+
+	// ListOnboardingDocuments lists what a new member of the organization
+	// is asked to sign.
+	ListOnboardingDocuments(ctx context.Context, organizationID uuid.UUID) ([]*Document, error)
+	// ValidateOnboarding checks signatures collected before the member
+	// exists, including that every required document is among them.
+	ValidateOnboarding(ctx context.Context, organizationID uuid.UUID, reqs []*SignRequest) error
+	SignOnboarding(ctx context.Context, memberID uuid.UUID, reqs []*SignRequest, meta *SignMeta) error
+
+	// CheckSigned returns ErrSignatureRequired, naming the documents, when
+	// the member has a required document missing or outdated.
+	CheckSigned(ctx context.Context, memberID uuid.UUID) error
+}
+
+type serviceImpl struct {
+	repo     Repository
+	userSvc  user.Service
+	filesSvc files.Service
+}
+
+func NewService(repo Repository, userSvc user.Service, filesSvc files.Service) Service {
+	return &serviceImpl{
+		repo:     repo,
+		userSvc:  userSvc,
+		filesSvc: filesSvc,
+	}
+}
+
+// ContentHash is the SHA-256 of a version's text as shown to the signer.
+func ContentHash(body string, questions []string) string {
+	sum := sha256.Sum256([]byte(body + "\n" + strings.Join(questions, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordHash seals a signature so that a later change to the stored record
+// can be detected.
+func recordHash(s *Signature) string {
+	answers, _ := json.Marshal(s.Answers)
+	var ip, agent string
+	if s.IPAddress != nil {
+		ip = *s.IPAddress
+	}
+	if s.UserAgent != nil {
+		agent = *s.UserAgent
+	}
+	fields := []string{
+		s.MemberID.String(),
+		s.VersionID.String(),
+		s.DocumentHash,
+		s.SignerName,
+		s.Signature,
+		string(answers),
+		s.SignedAt.UTC().Format(time.RFC3339Nano),
+		ip,
+		agent,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// organizationScope returns the organizations the caller works in; nil means
+// every organization. Templates belong to an organization, so staff are
+// scoped by the organizations of their branches.
+func (s *serviceImpl) organizationScope(ctx context.Context, userID uuid.UUID, userRole string) ([]uuid.UUID, error) {
+	if userRole == "super_admin" {
+		return nil, nil
+	}
+	orgIDs, err := s.userSvc.GetUserOrganizationIDs(ctx, userID)
+	if err != nil {
+		log.Printf("Service: failed to list organizations for user %s: %v", userID, err)
+		return nil, err
+	}
+	return orgIDs, nil
+}
+
+// template loads a template the caller may manage; others are reported as
+// missing.
+func (s *serviceImpl) template(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Template, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.repo.GetTemplate(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if orgIDs != nil && !slices.Contains(orgIDs, t.OrganizationID) {
+		return nil, ErrTemplateNotFound
+	}
+	return t, nil
+}
+
+// member loads a member the caller may reach; others are reported as
+// missing.
+func (s *serviceImpl) member(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*MemberRef, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.repo.GetMember(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !sc.Allows(m.OrganizationID, m.HomeBranchID) {
+		return nil, ErrMemberNotFound
+	}
+	return m, nil
+}
+
+func (s *serviceImpl) me(ctx context.Context, userID uuid.UUID) (*MemberRef, error) {
+	m, err := s.repo.GetMemberByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	return m, err
+}
+
+func (s *serviceImpl) ListTemplates(ctx context.Context, userID uuid.UUID, userRole string, organizationID *uuid.UUID) ([]*Template, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if organizationID != nil {
+		if orgIDs != nil && !slices.Contains(orgIDs, *organizationID) {
+			return nil, ErrOrganizationAccess
+		}
+		orgIDs = []uuid.UUID{*organizationID}
+	}
+	return s.repo.ListTemplates(ctx, orgIDs)
+}
+
+func (s *serviceImpl) GetTemplate(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TemplateDetailResponse, error) {
+	t, err := s.template(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.ListVersions(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	res := &TemplateDetailResponse{TemplateResponse: t.ToResponse()}
+	for _, v := range versions {
+		if v.Version == t.CurrentVersion {
+			res.Current = v.ToResponse()
+			break
+		}
+	}
+	return res, nil
+}
+
+func (s *serviceImpl) CreateTemplate(ctx context.Context, userID uuid.UUID, userRole string, req *CreateTemplateRequest) (*TemplateDetailResponse, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if orgIDs != nil && !slices.Contains(orgIDs, req.OrganizationID) {
+		return nil, ErrOrganizationAccess
+	}
+
+	kind := Kind(req.Kind)
+	isRequired := kind != KindMarketingConsent
+	if req.IsRequired != nil {
+		isRequired = *req.IsRequired
+	}
+
+	t := &Template{
+		OrganizationID: req.OrganizationID,
+		Kind:           kind,
+		Title:          strings.TrimSpace(req.Title),
+		IsRequired:     isRequired,
+		CreatedBy:      &userID,
+	}
+	v := &Version{
+		Body:        req.Body,
+		Questions:   req.Questions,
+		ContentHash: ContentHash(req.Body, req.Questions),
+		CreatedBy:   &userID,
+	}
+	if err := s.repo.CreateTemplate(ctx, t, v); err != nil {
+		log.Printf("Service: failed to create document template: %v", err)
+		return nil, err
+	}
+	return &TemplateDetailResponse{TemplateResponse: t.ToResponse(), Current: v.ToResponse()}, nil
+}
+
+func (s *serviceImpl) UpdateTemplate(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateTemplateRequest) (*Template, error) {
+	t, err := s.template(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Title != nil {
+		t.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.IsRequired != nil {
+		t.IsRequired = *req.IsRequired
+	}
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+	if err := s.repo.UpdateTemplate(ctx, t); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *serviceImpl) DeleteTemplate(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) error {
+	t, err := s.template(ctx, userID, userRole, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteTemplate(ctx, t.ID)
+}
+
+func (s *serviceImpl) PublishVersion(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *PublishVersionRequest) (*Version, error) {
+	t, err := s.template(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	v := &Version{
+		TemplateID:  t.ID,
+		Body:        req.Body,
+		Questions:   req.Questions,
+		ContentHash: ContentHash(req.Body, req.Questions),
+		CreatedBy:   &userID,
+	}
+	if err := s.repo.PublishVersion(ctx, v); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTemplateNotFound
+		}
+		log.Printf("Service: failed to publish version of document template %s: %v", t.ID, err)
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *serviceImpl) ListVersions(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) ([]*Version, error) {
+	t, err := s.template(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListVersions(ctx, t.ID)
+}
+
+func (s *serviceImpl) MemberDocuments(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID) ([]*Document, error) {
+	m, err := s.member(ctx, userID, userRole, memberID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListDocuments(ctx, m.OrganizationID, m.ID)
+}
+
+func (s *serviceImpl) MemberSignatures(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID) ([]*Signature, error) {
+	m, err := s.member(ctx, userID, userRole, memberID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListSignatures(ctx, m.ID)
+}
+
+func (s *serviceImpl) SignForMember(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID, req *SignRequest, meta *SignMeta) (*Signature, error) {
+	m, err := s.member(ctx, userID, userRole, memberID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sign(ctx, m, req, ChannelFrontDesk, &userID, meta)
+}
+
+func (s *serviceImpl) GetSignature(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*SignedRecordResponse, error) {
+	sig, _, err := s.signature(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.signedRecord(ctx, sig)
+}
+
+// signature loads a signature of a member the caller may reach.
+func (s *serviceImpl) signature(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Signature, *MemberRef, error) {
+	sig, err := s.repo.GetSignature(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrSignatureNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := s.member(ctx, userID, userRole, sig.MemberID)
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return nil, nil, ErrSignatureNotFound
+		}
+		return nil, nil, err
+	}
+	return sig, m, nil
+}
+
+func (s *serviceImpl) AttachFile(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, filename string, data []byte) (*SignedRecordResponse, error) {
+	sig, m, err := s.signature(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.filesSvc.Upload(ctx, &files.UploadRequest{
+		OrganizationID: &m.OrganizationID,
+		Purpose:        files.PurposeDocument,
+		Filename:       filename,
+		Data:           data,
+		UploadedBy:     &userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetAttachment(ctx, sig.ID, &file.ID); err != nil {
+		if deleteErr := s.filesSvc.Delete(ctx, file.ID); deleteErr != nil {
+			log.Printf("Service: failed to delete unused attachment %s: %v", file.ID, deleteErr)
+		}
+		return nil, err
+	}
+
+	if sig.AttachmentFileID != nil {
+		if err := s.filesSvc.Delete(ctx, *sig.AttachmentFileID); err != nil && !errors.Is(err, files.ErrFileNotFound) {
+			log.Printf("Service: failed to delete replaced attachment %s: %v", *sig.AttachmentFileID, err)
+		}
+	}
+	sig.AttachmentFileID = &file.ID
+	return s.signedRecord(ctx, sig)
+}
+
+func (s *serviceImpl) Report(ctx context.Context, userID uuid.UUID, userRole string, filter *ReportFilter) ([]*Outstanding, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	filter.OrganizationIDs = sc.OrganizationIDs
+	filter.BranchIDs = sc.BranchIDs
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return s.repo.ListOutstanding(ctx, filter)
+}
+
+func (s *serviceImpl) MyDocuments(ctx context.Context, userID uuid.UUID) ([]*Document, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListDocuments(ctx, m.OrganizationID, m.ID)
+}
+
+func (s *serviceImpl) SignMine(ctx context.Context, userID uuid.UUID, req *SignRequest, meta *SignMeta) (*Signature, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sign(ctx, m, req, ChannelPortal, nil, meta)
+}
+
+func (s *serviceImpl) GetMySignature(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*SignedRecordResponse, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.repo.GetSignature(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && sig.MemberID != m.ID) {
+		return nil, ErrSignatureNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.signedRecord(ctx, sig)
+}
+
+func (s *serviceImpl) ListOnboardingDocuments(ctx context.Context, organizationID uuid.UUID) ([]*Document, error) {
+	return s.repo.ListDocuments(ctx, organizationID, uuid.Nil)
+}
+
+func (s *serviceImpl) ValidateOnboarding(ctx context.Context, organizationID uuid.UUID, reqs []*SignRequest) error {
+	signed := map[uuid.UUID]bool{}
+	for _, req := range reqs {
+		t, _, err := s.prepare(ctx, organizationID, req)
+		if err != nil {
+			return err
+		}
+		signed[t.ID] = true
+	}
+
+	docs, err := s.repo.ListDocuments(ctx, organizationID, uuid.Nil)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, d := range docs {
+		if d.Template.IsRequired && !signed[d.Template.ID] {
+			missing = append(missing, d.Template.Title)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrSignatureRequired, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (s *serviceImpl) SignOnboarding(ctx context.Context, memberID uuid.UUID, reqs []*SignRequest, meta *SignMeta) error {
+	m, err := s.repo.GetMember(ctx, memberID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	for _, req := range reqs {
+		if _, err := s.sign(ctx, m, req, ChannelOnboarding, nil, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *serviceImpl) CheckSigned(ctx context.Context, memberID uuid.UUID) error {
+	missing, err := s.repo.MissingRequired(ctx, memberID)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrSignatureRequired, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// prepare checks that a signature request is for the current text of an
+// active document of the organization.
+func (s *serviceImpl) prepare(ctx context.Context, organizationID uuid.UUID, req *SignRequest) (*Template, *Version, error) {
+	v, err := s.repo.GetVersion(ctx, req.VersionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	t, err := s.repo.GetTemplate(ctx, v.TemplateID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.OrganizationID != organizationID {
+		return nil, nil, ErrVersionNotFound
+	}
+	if !t.IsActive {
+		return nil, nil, ErrTemplateInactive
+	}
+	if v.Version != t.CurrentVersion {
+		return nil, nil, ErrOutdatedVersion
+	}
+	if !strings.EqualFold(req.DocumentHash, v.ContentHash) {
+		return nil, nil, ErrDocumentChanged
+	}
+	if len(req.Answers) != len(v.Questions) {
+		return nil, nil, ErrAnswersRequired
+	}
+	return t, v, nil
+}
+
+func (s *serviceImpl) sign(ctx context.Context, m *MemberRef, req *SignRequest, channel string, collectedBy *uuid.UUID, meta *SignMeta) (*Signature, error) {
+	t, v, err := s.prepare(ctx, m.OrganizationID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &Signature{
+		MemberID:      m.ID,
+		TemplateID:    t.ID,
+		VersionID:     v.ID,
+		Version:       v.Version,
+		SignerName:    strings.TrimSpace(req.SignerName),
+		Signature:     req.Signature,
+		Answers:       req.Answers,
+		Flagged:       slices.Contains(req.Answers, true),
+		DocumentHash:  v.ContentHash,
+		Channel:       channel,
+		CollectedBy:   collectedBy,
+		SignedAt:      time.Now().UTC().Truncate(time.Microsecond),
+		TemplateTitle: t.Title,
+	}
+	if meta != nil {
+		if meta.IPAddress != "" {
+			sig.IPAddress = &meta.IPAddress
+		}
+		if meta.UserAgent != "" {
+			sig.UserAgent = &meta.UserAgent
+		}
+	}
+	sig.RecordHash = recordHash(sig)
+
+	if err := s.repo.CreateSignature(ctx, sig, t.Kind); err != nil {
+		log.Printf("Service: failed to store signature of %s for member %s: %v", t.ID, m.ID, err)
+		return nil, err
+	}
+	return sig, nil
+}
+
+func (s *serviceImpl) signedRecord(ctx context.Context, sig *Signature) (*SignedRecordResponse, error) {
+	v, err := s.repo.GetVersion(ctx, sig.VersionID)
+	if err != nil {
+		return nil, err
+	}
+	res := &SignedRecordResponse{SignatureResponse: sig.ToResponse(), Document: v.ToResponse()}
+	if sig.AttachmentFileID != nil {
+		attachmentURL := s.filesSvc.URL(*sig.AttachmentFileID, files.VariantOriginal)
+		res.AttachmentURL = &attachmentURL
+	}
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package exports
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportFilter takes the same parameters as the list endpoints. The scope
+// fields are set by the service from the caller's role and are stored with a
+// job, so a background export sees exactly what its owner could.
+type ExportFilter struct {
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	BranchID       *uuid.UUID `json:"branchId,omitempty"`
+	MemberID       *uuid.UUID `json:"memberId,omitempty"`
+	SubscriptionID *uuid.UUID `json:"subscriptionId,omitempty"`
+	Status         *string    `json:"status,omitempty"`
+
+	// DateField picks the column StartDate and EndDate apply to; each kind
+	// has its own set and a default.
+	DateField string     `json:"dateField,omitempty"`
+	StartDate *time.Time `json:"startDate,omitempty"`
+	EndDate   *time.Time `json:"endDate,omitempty"`
+
+	// ScopeOrganizationIDs and ScopeBranchIDs restrict the export to the
+	// caller's organizations or branches; nil means unrestricted.
+	ScopeOrganizationIDs []uuid.UUID `json:"scopeOrganizationIds,omitempty"`
+	ScopeBranchIDs       []uuid.UUID `json:"scopeBranchIds,omitempty"`
+}
+
+type CreateExportJobRequest struct {
+	Kind   string       `json:"kind" validate:"required,oneof=members invoices subscriptions check-ins"`
+	Format string       `json:"format" validate:"omitempty,oneof=csv xlsx"`
+	Filter ExportFilter `json:"filter"`
+}
+
+type ExportJobResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Kind        string     `json:"kind"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	RowCount    *int       `json:"rowCount,omitempty"`
+	FileSize    *int64     `json:"fileSize,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	DownloadURL *string    `json:"downloadUrl,omitempty"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
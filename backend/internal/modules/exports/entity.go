@@ -0,0 +1,63 @@
+package exports
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindMembers       = "members"
+	KindInvoices      = "invoices"
+	KindSubscriptions = "subscriptions"
+	KindCheckIns      = "check-ins"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusExpired   JobStatus = "expired"
+)
+
+type ExportJob struct {
+	ID          uuid.UUID     `db:"id"`
+	UserID      uuid.UUID     `db:"user_id"`
+	Kind        string        `db:"kind"`
+	Format      string        `db:"format"`
+	Filter      *ExportFilter `db:"filter"`
+	Status      JobStatus     `db:"status"`
+	RowCount    *int          `db:"row_count"`
+	StorageKey  *string       `db:"storage_key"`
+	FileSize    *int64        `db:"file_size"`
+	Error       *string       `db:"error"`
+	StartedAt   *time.Time    `db:"started_at"`
+	CompletedAt *time.Time    `db:"completed_at"`
+	ExpiresAt   *time.Time    `db:"expires_at"`
+	CreatedAt   time.Time     `db:"created_at"`
+	UpdatedAt   time.Time     `db:"updated_at"`
+}
+
+func (j *ExportJob) ToResponse() *ExportJobResponse {
+	resp := &ExportJobResponse{
+		ID:          j.ID,
+		Kind:        j.Kind,
+		Format:      j.Format,
+		Status:      string(j.Status),
+		RowCount:    j.RowCount,
+		FileSize:    j.FileSize,
+		Error:       j.Error,
+		StartedAt:   j.StartedAt,
+		CompletedAt: j.CompletedAt,
+		ExpiresAt:   j.ExpiresAt,
+		CreatedAt:   j.CreatedAt,
+	}
+	if j.Status == JobStatusCompleted {
+		url := "/api/v1/exports/jobs/" + j.ID.String() + "/download"
+		resp.DownloadURL = &url
+	}
+	return resp
+}
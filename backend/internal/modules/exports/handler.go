@@ -0,0 +1,293 @@
+package exports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+	"fitcore/pkg/export"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/exports", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware("super_admin", "admin", "staff"))
+
+		r.Get("/jobs", h.ListJobs)
+		r.Post("/jobs", h.CreateJob)
+		r.Get("/jobs/{id}", h.GetJob)
+		r.Get("/jobs/{id}/download", h.DownloadJob)
+		r.Get("/{kind}", h.Export)
+	})
+}
+
+// Export streams members, invoices, subscriptions or check-ins as CSV or
+// XLSX. It takes the list endpoint's filters plus format (csv|xlsx); exports
+// over the sync row limit are queued as a job and answered with 202.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	kind := chi.URLParam(r, "kind")
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+	filter, ok := parseFilter(w, r)
+	if !ok {
+		return
+	}
+	if !h.scope(w, r, kind, filter, userID, userRole) {
+		return
+	}
+
+	queue, err := h.service.ShouldQueue(r.Context(), kind, filter)
+	if err != nil {
+		response.InternalServerError(w, "Failed to prepare export")
+		return
+	}
+	if queue {
+		job, err := h.service.CreateJob(r.Context(), userID, kind, format, filter)
+		if err != nil {
+			response.InternalServerError(w, "Failed to queue export")
+			return
+		}
+		response.Accepted(w, "Export is large and was queued as a job", job.ToResponse())
+		return
+	}
+
+	filename := format.Filename(fmt.Sprintf("%s-%s", kind, time.Now().UTC().Format("20060102-150405")))
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+	// Once rows are flowing the status is sent, so failures can only be
+	// logged; the client sees a truncated file.
+	if _, err := h.service.Write(r.Context(), kind, filter, writer); err != nil {
+		log.Printf("Handler: Export %s failed: %v", filename, err)
+	}
+}
+
+func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateExportJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request body", nil)
+		return
+	}
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+	format, err := export.ParseFormat(req.Format)
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+	if !h.scope(w, r, req.Kind, &req.Filter, userID, userRole) {
+		return
+	}
+
+	job, err := h.service.CreateJob(r.Context(), userID, req.Kind, format, &req.Filter)
+	if err != nil {
+		response.InternalServerError(w, "Failed to queue export")
+		return
+	}
+	response.Accepted(w, "Export queued successfully", job.ToResponse())
+}
+
+func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.service.ListJobs(r.Context(), userID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list export jobs")
+		return
+	}
+
+	jobResponses := make([]*ExportJobResponse, len(jobs))
+	for i, job := range jobs {
+		jobResponses[i] = job.ToResponse()
+	}
+	response.Success(w, "Export jobs retrieved successfully", jobResponses)
+}
+
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid export job ID", nil)
+		return
+	}
+
+	job, err := h.service.GetJob(r.Context(), id, userID)
+	if err != nil {
+		switch err {
+		case ErrJobNotFound:
+			response.NotFound(w, err.Error())
+		default:
+			response.InternalServerError(w, "Failed to get export job")
+		}
+		return
+	}
+	response.Success(w, "Export job retrieved successfully", job.ToResponse())
+}
+
+func (h *Handler) DownloadJob(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid export job ID", nil)
+		return
+	}
+
+	body, job, err := h.service.OpenJobFile(r.Context(), id, userID)
+	if err != nil {
+		switch err {
+		case ErrJobNotFound:
+			response.NotFound(w, err.Error())
+		case ErrJobNotReady:
+			response.Conflict(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to open export")
+		}
+		return
+	}
+	defer body.Close()
+
+	format := export.Format(job.Format)
+	filename := format.Filename(fmt.Sprintf("%s-%s", job.Kind, job.CreatedAt.UTC().Format("20060102-150405")))
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if job.FileSize != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*job.FileSize, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Handler: Failed to stream export job %s: %v", job.ID, err)
+	}
+}
+
+// scope applies the caller's role scope to filter and writes the error
+// response when it fails.
+func (h *Handler) scope(w http.ResponseWriter, r *http.Request, kind string, filter *ExportFilter, userID uuid.UUID, userRole string) bool {
+	if err := h.service.ScopeFilter(r.Context(), kind, filter, userID, userRole); err != nil {
+		switch err {
+		case ErrUnknownKind:
+			response.NotFound(w, err.Error())
+		case ErrKindForbidden, ErrScopeAccess:
+			response.Forbidden(w, err.Error())
+		default:
+			response.InternalServerError(w, "Failed to resolve export access")
+		}
+		return false
+	}
+	return true
+}
+
+func callerFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+	return userID, userRole, true
+}
+
+// parseFilter reads the same query parameters as the list endpoints.
+func parseFilter(w http.ResponseWriter, r *http.Request) (*ExportFilter, bool) {
+	query := r.URL.Query()
+	filter := &ExportFilter{DateField: query.Get("dateField")}
+
+	ids := []struct {
+		param string
+		dst   **uuid.UUID
+	}{
+		{"organizationId", &filter.OrganizationID},
+		{"branchId", &filter.BranchID},
+		{"memberId", &filter.MemberID},
+		{"subscriptionId", &filter.SubscriptionID},
+	}
+	for _, p := range ids {
+		v := query.Get(p.param)
+		if v == "" {
+			continue
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.BadRequest(w, "Invalid "+p.param, nil)
+			return nil, false
+		}
+		*p.dst = &id
+	}
+
+	if status := query.Get("status"); status != "" {
+		filter.Status = &status
+	}
+
+	dates := []struct {
+		param string
+		dst   **time.Time
+	}{
+		{"startDate", &filter.StartDate},
+		{"endDate", &filter.EndDate},
+	}
+	for _, p := range dates {
+		v := query.Get(p.param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			response.BadRequest(w, "Invalid "+p.param+" format, use RFC3339", nil)
+			return nil, false
+		}
+		*p.dst = &t
+	}
+	return filter, true
+}
@@ -0,0 +1,37 @@
+package exports
+
+import (
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Config controls when an export is too large to stream in the request.
+// Background exports are written to the file store.
+type Config struct {
+	MaxSyncRows int
+}
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, userSvc user.Service, store storage.Storage, config Config) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, userSvc, store, config)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
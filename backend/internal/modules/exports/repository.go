@@ -0,0 +1,434 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitcore/pkg/export"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrJobNotFound = errors.New("export job not found")
+
+type Repository interface {
+	// Count returns how many rows an export of kind would produce.
+	Count(ctx context.Context, kind string, filter *ExportFilter) (int, error)
+	// Stream passes the columns and then every row of the export to fn as
+	// they are read from the database.
+	Stream(ctx context.Context, kind string, filter *ExportFilter, header func(columns []string) error, fn func(cells []string) error) error
+
+	CreateJob(ctx context.Context, job *ExportJob) error
+	GetJob(ctx context.Context, id uuid.UUID) (*ExportJob, error)
+	ListJobs(ctx context.Context, userID uuid.UUID, limit int) ([]*ExportJob, error)
+	// ClaimJob marks the oldest pending job running and returns it, or nil
+	// when there is none. Jobs left running longer than staleAfter (the
+	// process died) are claimed again.
+	ClaimJob(ctx context.Context, staleAfter time.Duration) (*ExportJob, error)
+	CompleteJob(ctx context.Context, id uuid.UUID, rowCount int, storageKey string, fileSize int64, expiresAt time.Time) error
+	FailJob(ctx context.Context, id uuid.UUID, message string) error
+	// ExpireJobs marks completed jobs past expires_at expired and returns
+	// the storage keys of their files.
+	ExpireJobs(ctx context.Context) ([]string, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+// dataset describes how one kind is read: the FROM clause, the expressions
+// each filter applies to and how a row becomes cells.
+type dataset struct {
+	columns []string
+	selects string
+	from    string
+	// Empty expressions mean the filter does not apply to the kind.
+	organization string
+	branch       string
+	member       string
+	subscription string
+	status       string
+	// dates maps dateField to a column; "" is the default.
+	dates   map[string]string
+	deleted string
+	orderBy string
+	scan    func(rows pgx.Rows) ([]string, error)
+}
+
+var datasets = map[string]*dataset{
+	KindMembers: {
+		columns: []string{"id", "first_name", "last_name", "email", "phone", "date_of_birth", "status", "organization_id", "home_branch", "join_date", "created_at"},
+		selects: `m.id, m.first_name, m.last_name, COALESCE(u.email, ''), COALESCE(m.phone, ''), m.date_of_birth, m.status::text,
+			m.organization_id, COALESCE(b.name, ''), m.join_date, m.created_at`,
+		from: `members m
+			LEFT JOIN users u ON u.id = m.user_id
+			LEFT JOIN branches b ON b.id = m.home_branch_id`,
+		organization: "m.organization_id",
+		branch:       "m.home_branch_id",
+		member:       "m.id",
+		status:       "m.status::text",
+		dates:        map[string]string{"": "m.created_at", "created_at": "m.created_at", "join_date": "m.join_date"},
+		deleted:      "m.deleted_at IS NULL",
+		orderBy:      "m.created_at DESC, m.id",
+		scan: func(rows pgx.Rows) ([]string, error) {
+			var (
+				id, organizationID                                    uuid.UUID
+				firstName, lastName, email, phone, status, homeBranch string
+				dateOfBirth, joinDate                                 *time.Time
+				createdAt                                             time.Time
+			)
+			if err := rows.Scan(&id, &firstName, &lastName, &email, &phone, &dateOfBirth, &status, &organizationID, &homeBranch, &joinDate, &createdAt); err != nil {
+				return nil, err
+			}
+			return []string{id.String(), firstName, lastName, email, phone, formatDate(dateOfBirth), status, organizationID.String(), homeBranch, formatDate(joinDate), formatTime(&createdAt)}, nil
+		},
+	},
+	KindInvoices: {
+		columns: []string{"id", "invoice_number", "member_id", "member_name", "branch", "subscription_id", "amount", "tax_amount", "total_amount", "status", "due_date", "paid_at", "created_at"},
+		selects: `i.id, COALESCE(i.invoice_number, ''), i.member_id, TRIM(m.first_name || ' ' || m.last_name), COALESCE(b.name, ''), i.subscription_id,
+			i.amount::double precision, i.tax_amount::double precision, i.total_amount::double precision, i.status::text, i.due_date, i.paid_at, i.created_at`,
+		from: `invoices i
+			JOIN members m ON m.id = i.member_id
+			LEFT JOIN branches b ON b.id = i.branch_id`,
+		organization: "m.organization_id",
+		branch:       "i.branch_id",
+		member:       "i.member_id",
+		subscription: "i.subscription_id",
+		status:       "i.status::text",
+		dates:        map[string]string{"": "i.created_at", "created_at": "i.created_at", "due_date": "i.due_date", "paid_at": "i.paid_at"},
+		deleted:      "i.deleted_at IS NULL",
+		orderBy:      "i.created_at DESC, i.id",
+		scan: func(rows pgx.Rows) ([]string, error) {
+			var (
+				id, memberID                       uuid.UUID
+				number, memberName, branch, status string
+				subscriptionID                     *uuid.UUID
+				amount, taxAmount, totalAmount     float64
+				dueDate, paidAt                    *time.Time
+				createdAt                          time.Time
+			)
+			if err := rows.Scan(&id, &number, &memberID, &memberName, &branch, &subscriptionID, &amount, &taxAmount, &totalAmount, &status, &dueDate, &paidAt, &createdAt); err != nil {
+				return nil, err
+			}
+			return []string{id.String(), number, memberID.String(), memberName, branch, formatUUID(subscriptionID),
+				export.Money(amount), export.Money(taxAmount), export.Money(totalAmount), status, formatDate(dueDate), formatTime(paidAt), formatTime(&createdAt)}, nil
+		},
+	},
+	KindSubscriptions: {
+		columns: []string{"id", "member_id", "member_name", "plan", "branch", "start_date", "end_date", "status", "created_at", "updated_at"},
+		selects: `s.id, s.member_id, TRIM(m.first_name || ' ' || m.last_name), COALESCE(p.name, ''), COALESCE(b.name, ''),
+			s.start_date, s.end_date, s.status::text, s.created_at, s.updated_at`,
+		from: `subscriptions s
+			JOIN members m ON m.id = s.member_id
+			LEFT JOIN membership_plans p ON p.id = s.plan_id
+			LEFT JOIN branches b ON b.id = s.branch_id`,
+		organization: "m.organization_id",
+		branch:       "s.branch_id",
+		member:       "s.member_id",
+		subscription: "s.id",
+		status:       "s.status::text",
+		dates:        map[string]string{"": "s.created_at", "created_at": "s.created_at", "start_date": "s.start_date", "end_date": "s.end_date"},
+		deleted:      "s.deleted_at IS NULL",
+		orderBy:      "s.created_at DESC, s.id",
+		scan: func(rows pgx.Rows) ([]string, error) {
+			var (
+				id, memberID                     uuid.UUID
+				memberName, plan, branch, status string
+				startDate, endDate               time.Time
+				createdAt, updatedAt             time.Time
+			)
+			if err := rows.Scan(&id, &memberID, &memberName, &plan, &branch, &startDate, &endDate, &status, &createdAt, &updatedAt); err != nil {
+				return nil, err
+			}
+			return []string{id.String(), memberID.String(), memberName, plan, branch, formatDate(&startDate), formatDate(&endDate), status, formatTime(&createdAt), formatTime(&updatedAt)}, nil
+		},
+	},
+	KindCheckIns: {
+		columns: []string{"id", "member_id", "member_name", "branch", "subscription_id", "check_in_time", "check_out_time", "duration_minutes", "method"},
+		selects: `c.id, c.member_id, TRIM(m.first_name || ' ' || m.last_name), b.name, c.subscription_id, c.check_in_time, c.check_out_time,
+			(EXTRACT(EPOCH FROM c.check_out_time - c.check_in_time) / 60)::int, COALESCE(c.method::text, '')`,
+		from: `check_ins c
+			JOIN members m ON m.id = c.member_id
+			JOIN branches b ON b.id = c.branch_id`,
+		organization: "m.organization_id",
+		branch:       "c.branch_id",
+		member:       "c.member_id",
+		subscription: "c.subscription_id",
+		dates:        map[string]string{"": "c.check_in_time", "check_in_time": "c.check_in_time"},
+		deleted:      "c.deleted_at IS NULL",
+		orderBy:      "c.check_in_time DESC, c.id",
+		scan: func(rows pgx.Rows) ([]string, error) {
+			var (
+				id, memberID               uuid.UUID
+				memberName, branch, method string
+				subscriptionID             *uuid.UUID
+				checkIn                    time.Time
+				checkOut                   *time.Time
+				duration                   *int
+			)
+			if err := rows.Scan(&id, &memberID, &memberName, &branch, &subscriptionID, &checkIn, &checkOut, &duration, &method); err != nil {
+				return nil, err
+			}
+			minutes := ""
+			if duration != nil {
+				minutes = strconv.Itoa(*duration)
+			}
+			return []string{id.String(), memberID.String(), memberName, branch, formatUUID(subscriptionID), formatTime(&checkIn), formatTime(checkOut), minutes, method}, nil
+		},
+	},
+}
+
+// where builds the WHERE clause for a dataset in the same argument style as
+// the list endpoints.
+func (d *dataset) where(filter *ExportFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(format string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(format, len(args)))
+	}
+
+	if d.deleted != "" {
+		conds = append(conds, d.deleted)
+	}
+	if filter.ScopeOrganizationIDs != nil {
+		add(d.organization+" = ANY($%d)", filter.ScopeOrganizationIDs)
+	}
+	if filter.ScopeBranchIDs != nil {
+		add(d.branch+" = ANY($%d)", filter.ScopeBranchIDs)
+	}
+	if filter.OrganizationID != nil {
+		add(d.organization+" = $%d", *filter.OrganizationID)
+	}
+	if filter.BranchID != nil {
+		add(d.branch+" = $%d", *filter.BranchID)
+	}
+	if filter.MemberID != nil {
+		add(d.member+" = $%d", *filter.MemberID)
+	}
+	if filter.SubscriptionID != nil && d.subscription != "" {
+		add(d.subscription+" = $%d", *filter.SubscriptionID)
+	}
+	if filter.Status != nil && *filter.Status != "" && d.status != "" {
+		add(d.status+" = $%d", *filter.Status)
+	}
+
+	dateColumn := d.dates[strings.ToLower(strings.TrimSpace(filter.DateField))]
+	if dateColumn == "" {
+		dateColumn = d.dates[""]
+	}
+	if filter.StartDate != nil {
+		add(dateColumn+" >= $%d", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		add(dateColumn+" <= $%d", *filter.EndDate)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (r *repositoryImpl) Count(ctx context.Context, kind string, filter *ExportFilter) (int, error) {
+	d, ok := datasets[kind]
+	if !ok {
+		return 0, ErrUnknownKind
+	}
+	where, args := d.where(filter)
+
+	var count int
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM "+d.from+where, args...).Scan(&count)
+	return count, err
+}
+
+func (r *repositoryImpl) Stream(ctx context.Context, kind string, filter *ExportFilter, header func(columns []string) error, fn func(cells []string) error) error {
+	d, ok := datasets[kind]
+	if !ok {
+		return ErrUnknownKind
+	}
+	if err := header(d.columns); err != nil {
+		return err
+	}
+	where, args := d.where(filter)
+
+	// pgx reads rows off the connection as Next is called, so only the row
+	// being written is held in memory.
+	rows, err := r.db.Query(ctx, "SELECT "+d.selects+" FROM "+d.from+where+" ORDER BY "+d.orderBy, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cells, err := d.scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(cells); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const jobColumns = `id, user_id, kind, format, filter, status, row_count, storage_key, file_size, error,
+	started_at, completed_at, expires_at, created_at, updated_at`
+
+func scanJob(row pgx.Row) (*ExportJob, error) {
+	var job ExportJob
+	if err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Kind,
+		&job.Format,
+		&job.Filter,
+		&job.Status,
+		&job.RowCount,
+		&job.StorageKey,
+		&job.FileSize,
+		&job.Error,
+		&job.StartedAt,
+		&job.CompletedAt,
+		&job.ExpiresAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *repositoryImpl) CreateJob(ctx context.Context, job *ExportJob) error {
+	query := `
+		INSERT INTO export_jobs (user_id, kind, format, filter)
+		VALUES ($1, $2, $3, $4)
+		RETURNING status, created_at, updated_at, id
+	`
+	return r.db.QueryRow(ctx, query, job.UserID, job.Kind, job.Format, job.Filter).Scan(&job.Status, &job.CreatedAt, &job.UpdatedAt, &job.ID)
+}
+
+func (r *repositoryImpl) GetJob(ctx context.Context, id uuid.UUID) (*ExportJob, error) {
+	job, err := scanJob(r.db.QueryRow(ctx, `SELECT `+jobColumns+` FROM export_jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+func (r *repositoryImpl) ListJobs(ctx context.Context, userID uuid.UUID, limit int) ([]*ExportJob, error) {
+	query := `SELECT ` + jobColumns + ` FROM export_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := r.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*ExportJob{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *repositoryImpl) ClaimJob(ctx context.Context, staleAfter time.Duration) (*ExportJob, error) {
+	query := `
+		UPDATE export_jobs
+		SET status = 'running', started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'pending'
+				OR (status = 'running' AND started_at < NOW() - make_interval(secs => $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	job, err := scanJob(r.db.QueryRow(ctx, query, staleAfter.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return job, err
+}
+
+func (r *repositoryImpl) CompleteJob(ctx context.Context, id uuid.UUID, rowCount int, storageKey string, fileSize int64, expiresAt time.Time) error {
+	query := `
+		UPDATE export_jobs
+		SET status = 'completed', row_count = $2, storage_key = $3, file_size = $4, expires_at = $5,
+			error = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, rowCount, storageKey, fileSize, expiresAt)
+	return err
+}
+
+func (r *repositoryImpl) FailJob(ctx context.Context, id uuid.UUID, message string) error {
+	query := `
+		UPDATE export_jobs
+		SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, message)
+	return err
+}
+
+func (r *repositoryImpl) ExpireJobs(ctx context.Context) ([]string, error) {
+	query := `
+		UPDATE export_jobs
+		SET status = 'expired', updated_at = NOW()
+		WHERE status = 'completed' AND expires_at < NOW()
+		RETURNING storage_key
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key *string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, rows.Err()
+}
+
+func formatUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
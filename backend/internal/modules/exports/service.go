@@ -0,0 +1,308 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/export"
+	"fitcore/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// jobRetention is how long a finished export can be downloaded.
+	jobRetention = 24 * time.Hour
+	// jobTimeout bounds one background export; a job running longer than
+	// this is assumed dead and claimed again.
+	jobTimeout      = 30 * time.Minute
+	jobPollInterval = time.Minute
+	listJobsLimit   = 50
+)
+
+var (
+	ErrUnknownKind   = errors.New("kind must be members, invoices, subscriptions or check-ins")
+	ErrKindForbidden = errors.New("you are not allowed to export this data")
+	ErrScopeAccess   = errors.New("organization or branch is outside your access")
+	ErrJobNotReady   = errors.New("export is not ready for download")
+)
+
+type Service interface {
+	// ScopeFilter checks the caller may export kind and restricts filter to
+	// their organizations (admin) or branches (staff).
+	ScopeFilter(ctx context.Context, kind string, filter *ExportFilter, userID uuid.UUID, userRole string) error
+	// ShouldQueue reports whether an export is too large to stream in the
+	// request and should run as a job instead.
+	ShouldQueue(ctx context.Context, kind string, filter *ExportFilter) (bool, error)
+	// Write streams the export into w and closes it, returning the number of
+	// rows written.
+	Write(ctx context.Context, kind string, filter *ExportFilter, w export.RowWriter) (int, error)
+
+	CreateJob(ctx context.Context, userID uuid.UUID, kind string, format export.Format, filter *ExportFilter) (*ExportJob, error)
+	ListJobs(ctx context.Context, userID uuid.UUID) ([]*ExportJob, error)
+	// GetJob returns a job owned by the user.
+	GetJob(ctx context.Context, id, userID uuid.UUID) (*ExportJob, error)
+	// OpenJobFile opens the file of a completed job owned by the user; the
+	// caller closes it.
+	OpenJobFile(ctx context.Context, id, userID uuid.UUID) (io.ReadCloser, *ExportJob, error)
+
+	// Run processes queued jobs until ctx is cancelled.
+	Run(ctx context.Context)
+	// Cleanup expires finished jobs and deletes their files; it is the
+	// scheduled job.
+	Cleanup(ctx context.Context) error
+}
+
+type serviceImpl struct {
+	repo    Repository
+	userSvc user.Service
+	store   storage.Storage
+	config  Config
+	// wake nudges Run when a job is created instead of waiting for the poll.
+	wake chan struct{}
+}
+
+func NewService(repo Repository, userSvc user.Service, store storage.Storage, config Config) Service {
+	return &serviceImpl{
+		repo:    repo,
+		userSvc: userSvc,
+		store:   store,
+		config:  config,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+func (s *serviceImpl) ScopeFilter(ctx context.Context, kind string, filter *ExportFilter, userID uuid.UUID, userRole string) error {
+	if _, ok := datasets[kind]; !ok {
+		return ErrUnknownKind
+	}
+	// Scope fields may arrive in a job request body; only the role decides
+	// them.
+	filter.ScopeOrganizationIDs = nil
+	filter.ScopeBranchIDs = nil
+
+	switch userRole {
+	case "super_admin":
+	case "admin":
+		orgIDs, err := s.userSvc.GetUserOrganizationIDs(ctx, userID)
+		if err != nil {
+			log.Printf("Service: ScopeFilter failed to list organizations for user %s: %v", userID, err)
+			return err
+		}
+		if filter.OrganizationID != nil && !slices.Contains(orgIDs, *filter.OrganizationID) {
+			return ErrScopeAccess
+		}
+		filter.ScopeOrganizationIDs = orgIDs
+	case "staff":
+		// Invoices stay with admins, as with the finance reports.
+		if kind == KindInvoices {
+			return ErrKindForbidden
+		}
+		branchIDs, err := s.userSvc.GetUserBranchIDs(ctx, userID, userRole)
+		if err != nil {
+			log.Printf("Service: ScopeFilter failed to list branches for user %s: %v", userID, err)
+			return err
+		}
+		if filter.BranchID != nil && !slices.Contains(branchIDs, *filter.BranchID) {
+			return ErrScopeAccess
+		}
+		// Staff without assignments export nothing rather than everything.
+		filter.ScopeBranchIDs = append([]uuid.UUID{}, branchIDs...)
+	default:
+		return ErrKindForbidden
+	}
+	return nil
+}
+
+func (s *serviceImpl) ShouldQueue(ctx context.Context, kind string, filter *ExportFilter) (bool, error) {
+	count, err := s.repo.Count(ctx, kind, filter)
+	if err != nil {
+		log.Printf("Service: ShouldQueue failed to count %s: %v", kind, err)
+		return false, err
+	}
+	return count > s.config.MaxSyncRows, nil
+}
+
+func (s *serviceImpl) Write(ctx context.Context, kind string, filter *ExportFilter, w export.RowWriter) (int, error) {
+	rows := 0
+	err := s.repo.Stream(ctx, kind, filter, w.WriteHeader, func(cells []string) error {
+		rows++
+		return w.WriteRow(cells)
+	})
+	if err != nil {
+		return rows, err
+	}
+	return rows, w.Close()
+}
+
+func (s *serviceImpl) CreateJob(ctx context.Context, userID uuid.UUID, kind string, format export.Format, filter *ExportFilter) (*ExportJob, error) {
+	job := &ExportJob{
+		UserID: userID,
+		Kind:   kind,
+		Format: string(format),
+		Filter: filter,
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		log.Printf("Service: CreateJob failed for user %s: %v", userID, err)
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	log.Printf("Service: Export job %s queued (%s, %s)", job.ID, kind, format)
+	return job, nil
+}
+
+func (s *serviceImpl) ListJobs(ctx context.Context, userID uuid.UUID) ([]*ExportJob, error) {
+	return s.repo.ListJobs(ctx, userID, listJobsLimit)
+}
+
+func (s *serviceImpl) GetJob(ctx context.Context, id, userID uuid.UUID) (*ExportJob, error) {
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Someone else's job is reported as missing rather than forbidden.
+	if job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (s *serviceImpl) OpenJobFile(ctx context.Context, id, userID uuid.UUID) (io.ReadCloser, *ExportJob, error) {
+	job, err := s.GetJob(ctx, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != JobStatusCompleted || job.StorageKey == nil {
+		return nil, nil, ErrJobNotReady
+	}
+
+	body, err := s.store.Get(ctx, *job.StorageKey)
+	if err != nil {
+		log.Printf("Service: OpenJobFile failed for job %s: %v", id, err)
+		return nil, nil, err
+	}
+	return body, job, nil
+}
+
+func (s *serviceImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	log.Printf("Service: Export worker started")
+	for {
+		s.drain(ctx)
+		select {
+		case <-ctx.Done():
+			log.Printf("Service: Export worker stopped")
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain runs queued jobs one at a time until none are left.
+func (s *serviceImpl) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := s.repo.ClaimJob(ctx, jobTimeout)
+		if err != nil {
+			log.Printf("Service: Export worker failed to claim a job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		s.runJob(ctx, job)
+	}
+}
+
+func (s *serviceImpl) runJob(ctx context.Context, job *ExportJob) {
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	started := time.Now()
+	key := fmt.Sprintf("exports/%s.%s", job.ID, job.Format)
+	rows, size, err := s.writeFile(ctx, job, key)
+	if err != nil {
+		log.Printf("Service: Export job %s failed: %v", job.ID, err)
+		if err := s.repo.FailJob(context.WithoutCancel(ctx), job.ID, err.Error()); err != nil {
+			log.Printf("Service: Export job %s could not be marked failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := s.repo.CompleteJob(ctx, job.ID, rows, key, size, time.Now().Add(jobRetention)); err != nil {
+		log.Printf("Service: Export job %s could not be marked completed: %v", job.ID, err)
+		if err := s.store.Delete(context.WithoutCancel(ctx), key); err != nil {
+			log.Printf("Service: Export job %s left %s behind: %v", job.ID, key, err)
+		}
+		return
+	}
+	log.Printf("Service: Export job %s wrote %d rows in %s", job.ID, rows, time.Since(started).Round(time.Millisecond))
+}
+
+// writeFile spools the export to a local temporary file, whose size the
+// store needs up front, and uploads it under key once it is complete, so a
+// download never sees a partial export.
+func (s *serviceImpl) writeFile(ctx context.Context, job *ExportJob, key string) (int, int64, error) {
+	filter := job.Filter
+	if filter == nil {
+		filter = &ExportFilter{}
+	}
+
+	f, err := os.CreateTemp("", "fitcore-export-"+job.ID.String()+"-*.tmp")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w, err := export.NewWriter(export.Format(job.Format), f)
+	if err != nil {
+		return 0, 0, err
+	}
+	rows, err := s.Write(ctx, job.Kind, filter, w)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	if err := s.store.Put(ctx, key, f, size, export.Format(job.Format).ContentType()); err != nil {
+		return 0, 0, err
+	}
+	return rows, size, nil
+}
+
+func (s *serviceImpl) Cleanup(ctx context.Context) error {
+	keys, err := s.repo.ExpireJobs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("Service: Export cleanup failed to remove %s: %v", key, err)
+		}
+	}
+	if len(keys) > 0 {
+		log.Printf("Service: Export cleanup expired %d jobs", len(keys))
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package files
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UploadRequest struct {
+	// OrganizationID is nil for platform-wide files
+	OrganizationID *uuid.UUID
+	Purpose        Purpose
+	Filename       string
+	Data           []byte
+	UploadedBy     *uuid.UUID
+}
+
+type FileResponse struct {
+	ID           uuid.UUID `json:"id"`
+	ContentType  string    `json:"contentType"`
+	SizeBytes    int64     `json:"sizeBytes"`
+	OriginalName *string   `json:"originalName,omitempty"`
+	URL          string    `json:"url"`
+	ThumbnailURL *string   `json:"thumbnailUrl,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,86 @@
+package files
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purpose is what a file is for; it decides the accepted types and size.
+type Purpose string
+
+const (
+	PurposeLogo        Purpose = "logo"
+	PurposeMemberPhoto Purpose = "member_photo"
+	PurposeDocument    Purpose = "document"
+)
+
+// Variant selects the original upload or its thumbnail.
+type Variant string
+
+const (
+	VariantOriginal  Variant = "original"
+	VariantThumbnail Variant = "thumbnail"
+)
+
+// thumbnailSize is the longest side of an image thumbnail in pixels.
+const thumbnailSize = 320
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// maxSizes is the largest upload accepted per purpose.
+var maxSizes = map[Purpose]int64{
+	PurposeLogo:        2 << 20,
+	PurposeMemberPhoto: 5 << 20,
+	PurposeDocument:    10 << 20,
+}
+
+// allowedTypes are the sniffed content types accepted per purpose.
+var allowedTypes = map[Purpose][]string{
+	PurposeLogo:        imageTypes,
+	PurposeMemberPhoto: imageTypes,
+	PurposeDocument:    append([]string{"application/pdf"}, imageTypes...),
+}
+
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+// MaxSize is the largest upload accepted for purpose.
+func MaxSize(purpose Purpose) int64 {
+	return maxSizes[purpose]
+}
+
+type File struct {
+	ID             uuid.UUID  `db:"id"`
+	OrganizationID *uuid.UUID `db:"organization_id"`
+	Purpose        Purpose    `db:"purpose"`
+	StorageKey     string     `db:"storage_key"`
+	ThumbnailKey   *string    `db:"thumbnail_key"`
+	ContentType    string     `db:"content_type"`
+	SizeBytes      int64      `db:"size_bytes"`
+	Checksum       string     `db:"checksum"`
+	OriginalName   *string    `db:"original_name"`
+	UploadedBy     *uuid.UUID `db:"uploaded_by"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+// Key returns the storage key of a variant. Files without a thumbnail serve
+// the original for both.
+func (f *File) Key(variant Variant) string {
+	if variant == VariantThumbnail && f.ThumbnailKey != nil {
+		return *f.ThumbnailKey
+	}
+	return f.StorageKey
+}
+
+// ContentTypeOf returns the content type of a variant; thumbnails are JPEG.
+func (f *File) ContentTypeOf(variant Variant) string {
+	if variant == VariantThumbnail && f.ThumbnailKey != nil {
+		return "image/jpeg"
+	}
+	return f.ContentType
+}
@@ -0,0 +1,122 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// formOverhead leaves room for the multipart framing around the file.
+const formOverhead = 1 << 20
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes serves downloads without authentication: the signature in
+// the link is the permission, so links can be used in img tags.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/files", func(r chi.Router) {
+		r.Get("/{id}", h.Download)
+	})
+}
+
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid file ID", nil)
+		return
+	}
+	query := r.URL.Query()
+	variant := VariantOriginal
+	if v := query.Get("variant"); v != "" {
+		if v != string(VariantThumbnail) {
+			response.BadRequest(w, "Invalid variant parameter, use thumbnail", nil)
+			return
+		}
+		variant = VariantThumbnail
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		response.Forbidden(w, ErrInvalidLink.Error())
+		return
+	}
+
+	f, body, err := h.service.Open(r.Context(), id, variant, expires, query.Get("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidLink):
+			response.Forbidden(w, err.Error())
+		case errors.Is(err, ErrFileNotFound):
+			response.NotFound(w, err.Error())
+		default:
+			log.Printf("Handler: Failed to open file %s: %v", id, err)
+			response.InternalServerError(w, "Failed to download file")
+		}
+		return
+	}
+	defer body.Close()
+
+	maxAge := max(0, expires-time.Now().Unix())
+	disposition := "inline"
+	if f.OriginalName != nil && variant == VariantOriginal {
+		disposition = mime.FormatMediaType("inline", map[string]string{"filename": *f.OriginalName})
+	}
+	w.Header().Set("Content-Type", f.ContentTypeOf(variant))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if variant == VariantOriginal {
+		w.Header().Set("Content-Length", strconv.FormatInt(f.SizeBytes, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Handler: Failed to stream file %s: %v", id, err)
+	}
+}
+
+// FormFile reads the file field of a multipart upload meant for purpose. It
+// answers the request itself and returns false when the body is not a
+// usable upload.
+func FormFile(w http.ResponseWriter, r *http.Request, purpose Purpose) (string, []byte, bool) {
+	limit := MaxSize(purpose)
+	r.Body = http.MaxBytesReader(w, r.Body, limit+formOverhead)
+	if err := r.ParseMultipartForm(limit + formOverhead); err != nil {
+		response.BadRequest(w, fmt.Sprintf("File must be a multipart upload of at most %d MB", limit>>20), nil)
+		return "", nil, false
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.BadRequest(w, "A file is required in the file field", nil)
+		return "", nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		response.BadRequest(w, "File could not be read", nil)
+		return "", nil, false
+	}
+	return header.Filename, data, true
+}
+
+// IsUploadError reports whether err rejects the uploaded file itself, which
+// owners answer with 400.
+func IsUploadError(err error) bool {
+	return errors.Is(err, ErrEmptyFile) || errors.Is(err, ErrFileTooLarge) ||
+		errors.Is(err, ErrUnsupportedType) || errors.Is(err, ErrInvalidImage)
+}
@@ -0,0 +1,30 @@
+package files
+
+import (
+	"fitcore/pkg/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, store storage.Storage, signer *storage.Signer, cfg Config) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, store, signer, cfg)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
@@ -0,0 +1,75 @@
+package files
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	Create(ctx context.Context, file *File) error
+	GetByID(ctx context.Context, id uuid.UUID) (*File, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+func (r *repositoryImpl) Create(ctx context.Context, f *File) error {
+	query := `
+		INSERT INTO files (
+			id, organization_id, purpose, storage_key, thumbnail_key, content_type, size_bytes, checksum, original_name, uploaded_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		f.ID,
+		f.OrganizationID,
+		f.Purpose,
+		f.StorageKey,
+		f.ThumbnailKey,
+		f.ContentType,
+		f.SizeBytes,
+		f.Checksum,
+		f.OriginalName,
+		f.UploadedBy,
+	).Scan(&f.CreatedAt)
+}
+
+func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*File, error) {
+	query := `
+		SELECT id, organization_id, purpose, storage_key, thumbnail_key, content_type, size_bytes, checksum, original_name, uploaded_by, created_at
+		FROM files
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	var f File
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&f.ID,
+		&f.OrganizationID,
+		&f.Purpose,
+		&f.StorageKey,
+		&f.ThumbnailKey,
+		&f.ContentType,
+		&f.SizeBytes,
+		&f.Checksum,
+		&f.OriginalName,
+		&f.UploadedBy,
+		&f.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *repositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE files SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
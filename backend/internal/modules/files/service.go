@@ -0,0 +1,228 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"fitcore/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrFileNotFound    = errors.New("file not found")
+	ErrEmptyFile       = errors.New("file is empty")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("file type is not supported")
+	ErrInvalidImage    = errors.New("image could not be read")
+	ErrInvalidLink     = errors.New("download link is invalid or has expired")
+)
+
+type Config struct {
+	// PublicURL is put in front of download links; empty keeps them
+	// relative to the API
+	PublicURL string
+	// URLTTL is how long a download link stays valid
+	URLTTL time.Duration
+}
+
+// Service stores uploads and hands out signed, time-limited links to them.
+// Owners such as organizations and members keep the file ID and decide who
+// may upload; anyone holding a valid link may download.
+type Service interface {
+	// Upload checks the sniffed type and size against the purpose, stores
+	// the file and, for images, a thumbnail.
+	Upload(ctx context.Context, req *UploadRequest) (*File, error)
+	Get(ctx context.Context, id uuid.UUID) (*File, error)
+	// Delete removes a file and its stored objects.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// URL returns a signed download link valid for the configured TTL.
+	URL(id uuid.UUID, variant Variant) string
+	ToResponse(f *File) *FileResponse
+	// Open checks a signed link and opens the file it grants.
+	Open(ctx context.Context, id uuid.UUID, variant Variant, expires int64, signature string) (*File, io.ReadCloser, error)
+}
+
+type serviceImpl struct {
+	repo   Repository
+	store  storage.Storage
+	signer *storage.Signer
+	cfg    Config
+}
+
+func NewService(repo Repository, store storage.Storage, signer *storage.Signer, cfg Config) Service {
+	if cfg.URLTTL <= 0 {
+		cfg.URLTTL = 15 * time.Minute
+	}
+	return &serviceImpl{
+		repo:   repo,
+		store:  store,
+		signer: signer,
+		cfg:    cfg,
+	}
+}
+
+func (s *serviceImpl) Upload(ctx context.Context, req *UploadRequest) (*File, error) {
+	if len(req.Data) == 0 {
+		return nil, ErrEmptyFile
+	}
+	if int64(len(req.Data)) > MaxSize(req.Purpose) {
+		return nil, fmt.Errorf("%w: the limit is %d MB", ErrFileTooLarge, MaxSize(req.Purpose)>>20)
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(req.Data))
+	if err != nil || !slices.Contains(allowedTypes[req.Purpose], contentType) {
+		return nil, ErrUnsupportedType
+	}
+
+	sum := sha256.Sum256(req.Data)
+	f := &File{
+		ID:             uuid.New(),
+		OrganizationID: req.OrganizationID,
+		Purpose:        req.Purpose,
+		ContentType:    contentType,
+		SizeBytes:      int64(len(req.Data)),
+		Checksum:       hex.EncodeToString(sum[:]),
+		UploadedBy:     req.UploadedBy,
+	}
+	if name := filepath.Base(req.Filename); name != "." && name != "/" && req.Filename != "" {
+		if len(name) > 255 {
+			name = name[:255]
+		}
+		f.OriginalName = &name
+	}
+
+	prefix := "platform"
+	if req.OrganizationID != nil {
+		prefix = req.OrganizationID.String()
+	}
+	f.StorageKey = fmt.Sprintf("%s/%s/%s%s", prefix, req.Purpose, f.ID, extensions[contentType])
+
+	var thumbnail []byte
+	if slices.Contains(imageTypes, contentType) {
+		thumbnail, err = storage.Thumbnail(req.Data, thumbnailSize)
+		if err != nil {
+			log.Printf("Service: failed to read uploaded image %s: %v", f.ID, err)
+			return nil, ErrInvalidImage
+		}
+		key := fmt.Sprintf("%s/%s/%s_thumb.jpg", prefix, req.Purpose, f.ID)
+		f.ThumbnailKey = &key
+	}
+
+	if err := s.store.Put(ctx, f.StorageKey, bytes.NewReader(req.Data), f.SizeBytes, f.ContentType); err != nil {
+		log.Printf("Service: failed to store file %s: %v", f.ID, err)
+		return nil, err
+	}
+	if thumbnail != nil {
+		if err := s.store.Put(ctx, *f.ThumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+			log.Printf("Service: failed to store thumbnail of file %s: %v", f.ID, err)
+			s.removeObjects(f)
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Create(ctx, f); err != nil {
+		log.Printf("Service: failed to record file %s: %v", f.ID, err)
+		s.removeObjects(f)
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *serviceImpl) Get(ctx context.Context, id uuid.UUID) (*File, error) {
+	f, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrFileNotFound
+	}
+	return f, err
+}
+
+func (s *serviceImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	f, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.removeObjects(f)
+	return nil
+}
+
+// removeObjects deletes what is stored for a file. Failures only leave an
+// orphaned object behind, so they are logged.
+func (s *serviceImpl) removeObjects(f *File) {
+	ctx := context.Background()
+	if err := s.store.Delete(ctx, f.StorageKey); err != nil {
+		log.Printf("Service: failed to delete stored object %s: %v", f.StorageKey, err)
+	}
+	if f.ThumbnailKey != nil {
+		if err := s.store.Delete(ctx, *f.ThumbnailKey); err != nil {
+			log.Printf("Service: failed to delete stored object %s: %v", *f.ThumbnailKey, err)
+		}
+	}
+}
+
+func resource(id uuid.UUID, variant Variant) string {
+	return id.String() + ":" + string(variant)
+}
+
+func (s *serviceImpl) URL(id uuid.UUID, variant Variant) string {
+	expires, signature := s.signer.Sign(resource(id, variant), time.Now().Add(s.cfg.URLTTL))
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {signature},
+	}
+	if variant != VariantOriginal {
+		query.Set("variant", string(variant))
+	}
+	return s.cfg.PublicURL + "/api/v1/files/" + id.String() + "?" + query.Encode()
+}
+
+func (s *serviceImpl) ToResponse(f *File) *FileResponse {
+	res := &FileResponse{
+		ID:           f.ID,
+		ContentType:  f.ContentType,
+		SizeBytes:    f.SizeBytes,
+		OriginalName: f.OriginalName,
+		URL:          s.URL(f.ID, VariantOriginal),
+		CreatedAt:    f.CreatedAt,
+	}
+	if f.ThumbnailKey != nil {
+		thumbnailURL := s.URL(f.ID, VariantThumbnail)
+		res.ThumbnailURL = &thumbnailURL
+	}
+	return res
+}
+
+func (s *serviceImpl) Open(ctx context.Context, id uuid.UUID, variant Variant, expires int64, signature string) (*File, io.ReadCloser, error) {
+	if err := s.signer.Verify(resource(id, variant), expires, signature, time.Now()); err != nil {
+		return nil, nil, ErrInvalidLink
+	}
+	f, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := s.store.Get(ctx, f.Key(variant))
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Service: stored object missing for file %s", f.ID)
+		return nil, nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, body, nil
+}
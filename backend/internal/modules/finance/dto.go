@@ -0,0 +1,101 @@
+package finance
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GroupByDay    = "day"
+	GroupByMonth  = "month"
+	GroupByBranch = "branch"
+	GroupByPlan   = "plan"
+)
+
+// FinanceFilter scopes every finance query. OrganizationIDs nil means every
+// organization. From and To are inclusive dates in UTC.
+type FinanceFilter struct {
+	OrganizationIDs []uuid.UUID
+	BranchID        *uuid.UUID
+	From            time.Time
+	To              time.Time
+}
+
+type RevenueRowResponse struct {
+	Key      string  `json:"key"`
+	Label    string  `json:"label"`
+	Revenue  float64 `json:"revenue"`
+	Invoices int     `json:"invoices"`
+	Members  int     `json:"members"`
+}
+
+type RevenueReportResponse struct {
+	From    string                `json:"from"`
+	To      string                `json:"to"`
+	GroupBy string                `json:"groupBy"`
+	Total   float64               `json:"total"`
+	Rows    []*RevenueRowResponse `json:"rows"`
+}
+
+type OverdueInvoiceResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	InvoiceNumber *string    `json:"invoiceNumber,omitempty"`
+	MemberID      uuid.UUID  `json:"memberId"`
+	MemberName    string     `json:"memberName"`
+	BranchID      *uuid.UUID `json:"branchId,omitempty"`
+	Amount        float64    `json:"amount"`
+	DueDate       string     `json:"dueDate"`
+	DaysOverdue   int        `json:"daysOverdue"`
+}
+
+type ReceivablesResponse struct {
+	OutstandingCount  int                       `json:"outstandingCount"`
+	OutstandingAmount float64                   `json:"outstandingAmount"`
+	OverdueCount      int                       `json:"overdueCount"`
+	OverdueAmount     float64                   `json:"overdueAmount"`
+	Aging             map[string]float64        `json:"aging"`
+	Overdue           []*OverdueInvoiceResponse `json:"overdue"`
+}
+
+// MRRMonthResponse is MRR at the end of a month and how it moved from the
+// start. Rates are percentages.
+type MRRMonthResponse struct {
+	Month            string  `json:"month"`
+	StartingMRR      float64 `json:"startingMrr"`
+	NewMRR           float64 `json:"newMrr"`
+	ExpansionMRR     float64 `json:"expansionMrr"`
+	ContractionMRR   float64 `json:"contractionMrr"`
+	ChurnedMRR       float64 `json:"churnedMrr"`
+	NetNewMRR        float64 `json:"netNewMrr"`
+	EndingMRR        float64 `json:"endingMrr"`
+	StartingMembers  int     `json:"startingMembers"`
+	NewMembers       int     `json:"newMembers"`
+	ChurnedMembers   int     `json:"churnedMembers"`
+	EndingMembers    int     `json:"endingMembers"`
+	ChurnRate        float64 `json:"churnRate"`
+	RevenueChurnRate float64 `json:"revenueChurnRate"`
+	// ARPM is average revenue per paying member at the end of the month.
+	ARPM float64 `json:"arpm"`
+}
+
+type MRRReportResponse struct {
+	From   string              `json:"from"`
+	To     string              `json:"to"`
+	Months []*MRRMonthResponse `json:"months"`
+}
+
+// SummaryResponse is the finance dashboard headline. LTV is ARPM divided by
+// the average monthly churn rate of the last full months; it is omitted
+// while there is no churn to divide by.
+type SummaryResponse struct {
+	MRR                 float64  `json:"mrr"`
+	PayingMembers       int      `json:"payingMembers"`
+	ARPM                float64  `json:"arpm"`
+	AvgMonthlyChurnRate float64  `json:"avgMonthlyChurnRate"`
+	LTV                 *float64 `json:"ltv,omitempty"`
+	RevenueThisMonth    float64  `json:"revenueThisMonth"`
+	RevenueLastMonth    float64  `json:"revenueLastMonth"`
+	OutstandingAmount   float64  `json:"outstandingAmount"`
+	OverdueAmount       float64  `json:"overdueAmount"`
+}
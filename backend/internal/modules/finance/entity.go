@@ -0,0 +1,48 @@
+package finance
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevenueRow is paid revenue for one group: a day, month, branch or plan.
+type RevenueRow struct {
+	Key      string
+	Label    string
+	Revenue  float64
+	Invoices int
+	Members  int
+}
+
+// Receivables summarises pending invoices. Overdue buckets are by days past
+// the due date.
+type Receivables struct {
+	OutstandingCount  int
+	OutstandingAmount float64
+	OverdueCount      int
+	OverdueAmount     float64
+	Overdue1To30      float64
+	Overdue31To60     float64
+	Overdue61To90     float64
+	OverdueOver90     float64
+}
+
+type OverdueInvoice struct {
+	ID            uuid.UUID
+	InvoiceNumber *string
+	MemberID      uuid.UUID
+	MemberName    string
+	BranchID      *uuid.UUID
+	Amount        float64
+	DueDate       time.Time
+	DaysOverdue   int
+}
+
+// MemberMRR is a member's normalised monthly recurring revenue on a date:
+// each active membership contributes price * 30 / duration_days.
+type MemberMRR struct {
+	At       time.Time
+	MemberID uuid.UUID
+	MRR      float64
+}
@@ -0,0 +1,58 @@
+package finance
+
+import (
+	"strconv"
+
+	"fitcore/pkg/export"
+)
+
+func revenueTable(report *RevenueReportResponse) *export.Table {
+	t := &export.Table{Columns: []string{report.GroupBy, "revenue", "invoices", "members"}}
+	for _, row := range report.Rows {
+		t.Append(row.Label, export.Money(row.Revenue), strconv.Itoa(row.Invoices), strconv.Itoa(row.Members))
+	}
+	return t
+}
+
+func overdueTable(report *ReceivablesResponse) *export.Table {
+	t := &export.Table{Columns: []string{"invoice_id", "invoice_number", "member_id", "member_name", "branch_id", "amount", "due_date", "days_overdue"}}
+	for _, inv := range report.Overdue {
+		var number, branchID string
+		if inv.InvoiceNumber != nil {
+			number = *inv.InvoiceNumber
+		}
+		if inv.BranchID != nil {
+			branchID = inv.BranchID.String()
+		}
+		t.Append(inv.ID.String(), number, inv.MemberID.String(), inv.MemberName, branchID,
+			export.Money(inv.Amount), inv.DueDate, strconv.Itoa(inv.DaysOverdue))
+	}
+	return t
+}
+
+func mrrTable(report *MRRReportResponse) *export.Table {
+	t := &export.Table{Columns: []string{
+		"month", "starting_mrr", "new_mrr", "expansion_mrr", "contraction_mrr", "churned_mrr", "net_new_mrr", "ending_mrr",
+		"starting_members", "new_members", "churned_members", "ending_members", "churn_rate", "revenue_churn_rate", "arpm",
+	}}
+	for _, m := range report.Months {
+		t.Append(
+			m.Month,
+			export.Money(m.StartingMRR),
+			export.Money(m.NewMRR),
+			export.Money(m.ExpansionMRR),
+			export.Money(m.ContractionMRR),
+			export.Money(m.ChurnedMRR),
+			export.Money(m.NetNewMRR),
+			export.Money(m.EndingMRR),
+			strconv.Itoa(m.StartingMembers),
+			strconv.Itoa(m.NewMembers),
+			strconv.Itoa(m.ChurnedMembers),
+			strconv.Itoa(m.EndingMembers),
+			export.Float(m.ChurnRate),
+			export.Float(m.RevenueChurnRate),
+			export.Money(m.ARPM),
+		)
+	}
+	return t
+}
@@ -0,0 +1,208 @@
+package finance
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+	"fitcore/pkg/export"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/finance", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+
+		r.Get("/summary", h.GetSummary)
+		r.Get("/revenue", h.GetRevenue)
+		r.Get("/receivables", h.GetReceivables)
+		r.Get("/mrr", h.GetMRR)
+	})
+}
+
+// GetSummary returns current MRR, ARPM, churn, LTV and receivables.
+// Query: organizationId, branchId.
+func (h *Handler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r, time.Time{})
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.Summary(r.Context(), filter)
+	if err != nil {
+		response.InternalServerError(w, "Failed to build finance summary")
+		return
+	}
+	response.Success(w, "Finance summary retrieved successfully", summary)
+}
+
+// GetRevenue returns paid revenue grouped by day, month, branch or plan.
+// Query: organizationId, branchId, from, to (YYYY-MM-DD, default last 30
+// days), groupBy, format=csv.
+func (h *Handler) GetRevenue(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r, time.Time{})
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Revenue(r.Context(), filter, r.URL.Query().Get("groupBy"))
+	if err != nil {
+		switch err {
+		case ErrInvalidGroupBy:
+			response.BadRequest(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to build revenue report")
+		}
+		return
+	}
+
+	if wantsCSV(r) {
+		writeCSV(w, "revenue-"+report.From+"-"+report.To+".csv", revenueTable(report))
+		return
+	}
+	response.Success(w, "Revenue report retrieved successfully", report)
+}
+
+// GetReceivables returns outstanding and overdue invoices with aging
+// buckets. Query: organizationId, branchId, limit, format=csv (exports every
+// overdue invoice).
+func (h *Handler) GetReceivables(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r, time.Time{})
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	limit = min(limit, maxOverdueLimit)
+	if wantsCSV(r) {
+		limit = exportOverdueLimit
+	}
+
+	report, err := h.service.Receivables(r.Context(), filter, limit)
+	if err != nil {
+		response.InternalServerError(w, "Failed to build receivables report")
+		return
+	}
+
+	if wantsCSV(r) {
+		writeCSV(w, "overdue-invoices-"+today().Format(dateLayout)+".csv", overdueTable(report))
+		return
+	}
+	response.Success(w, "Receivables retrieved successfully", report)
+}
+
+// GetMRR returns MRR movement per month. from/to select months and default
+// to the last 12. Query: organizationId, branchId, from, to, format=csv.
+func (h *Handler) GetMRR(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r, monthStart(today()).AddDate(0, -(defaultMRRMonths-1), 0))
+	if !ok {
+		return
+	}
+
+	report, err := h.service.MRR(r.Context(), filter)
+	if err != nil {
+		switch err {
+		case ErrInvalidRange:
+			response.BadRequest(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to build MRR report")
+		}
+		return
+	}
+
+	if wantsCSV(r) {
+		writeCSV(w, "mrr-"+report.From+"-"+report.To+".csv", mrrTable(report))
+		return
+	}
+	response.Success(w, "MRR report retrieved successfully", report)
+}
+
+// parseFilter reads the shared query parameters and applies the caller's
+// organization scope. defaultFrom is used when from is absent, if set.
+func (h *Handler) parseFilter(w http.ResponseWriter, r *http.Request, defaultFrom time.Time) (*FinanceFilter, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return nil, false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return nil, false
+	}
+
+	query := r.URL.Query()
+	filter := &FinanceFilter{From: defaultFrom}
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(dateLayout, v); err != nil {
+			response.BadRequest(w, "Invalid from parameter", nil)
+			return nil, false
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(dateLayout, v); err != nil {
+			response.BadRequest(w, "Invalid to parameter", nil)
+			return nil, false
+		}
+	}
+	if v := query.Get("branchId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.BadRequest(w, "Invalid branchId parameter", nil)
+			return nil, false
+		}
+		filter.BranchID = &id
+	}
+
+	var organizationID *uuid.UUID
+	if v := query.Get("organizationId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.BadRequest(w, "Invalid organizationId parameter", nil)
+			return nil, false
+		}
+		organizationID = &id
+	}
+
+	if err := h.service.ScopeFilter(r.Context(), filter, organizationID, userID, userRole); err != nil {
+		switch err {
+		case ErrOrganizationAccess:
+			response.Forbidden(w, err.Error())
+		case ErrInvalidRange:
+			response.BadRequest(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to resolve organization access")
+		}
+		return nil, false
+	}
+	return filter, true
+}
+
+func wantsCSV(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv"
+}
+
+// writeCSV can only log once the body has started.
+func writeCSV(w http.ResponseWriter, filename string, t *export.Table) {
+	if err := export.WriteCSV(w, filename, t); err != nil {
+		log.Printf("Handler: finance export %s failed: %v", filename, err)
+	}
+}
@@ -0,0 +1,198 @@
+package finance
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	Revenue(ctx context.Context, filter *FinanceFilter, groupBy string) ([]*RevenueRow, error)
+	Receivables(ctx context.Context, filter *FinanceFilter, asOf time.Time) (*Receivables, error)
+	ListOverdue(ctx context.Context, filter *FinanceFilter, asOf time.Time, limit int) ([]*OverdueInvoice, error)
+	// MemberMRR returns the MRR of every paying member at each date.
+	MemberMRR(ctx context.Context, filter *FinanceFilter, dates []time.Time) ([]*MemberMRR, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+// organizationIDsParam maps a nil filter (all organizations) to SQL NULL.
+func organizationIDsParam(ids []uuid.UUID) any {
+	if ids == nil {
+		return nil
+	}
+	return ids
+}
+
+// revenueGroups are the GROUP BY key and label expressions per groupBy.
+var revenueGroups = map[string][2]string{
+	GroupByDay:    {`to_char(i.paid_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')`, `to_char(i.paid_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')`},
+	GroupByMonth:  {`to_char(i.paid_at AT TIME ZONE 'UTC', 'YYYY-MM')`, `to_char(i.paid_at AT TIME ZONE 'UTC', 'YYYY-MM')`},
+	GroupByBranch: {`COALESCE(i.branch_id::text, '')`, `COALESCE(b.name, 'Unassigned')`},
+	GroupByPlan:   {`COALESCE(p.id::text, CASE WHEN dp.id IS NOT NULL THEN 'day_pass' ELSE '' END)`, `COALESCE(p.name, CASE WHEN dp.id IS NOT NULL THEN 'Day passes' ELSE 'No plan' END)`},
+}
+
+func (r *repositoryImpl) Revenue(ctx context.Context, filter *FinanceFilter, groupBy string) ([]*RevenueRow, error) {
+	group := revenueGroups[groupBy]
+	query := `
+		SELECT ` + group[0] + ` AS key, MIN(` + group[1] + `), SUM(i.total_amount), COUNT(*), COUNT(DISTINCT i.member_id)
+		FROM invoices i
+		JOIN members m ON m.id = i.member_id
+		LEFT JOIN branches b ON b.id = i.branch_id
+		LEFT JOIN subscriptions s ON s.id = i.subscription_id
+		LEFT JOIN membership_plans p ON p.id = s.plan_id
+		LEFT JOIN passes dp ON dp.invoice_id = i.id
+		WHERE i.status = 'paid'
+			AND i.paid_at >= $3::date
+			AND i.paid_at < $4::date + 1
+			AND ($1::uuid[] IS NULL OR m.organization_id = ANY($1))
+			AND ($2::uuid IS NULL OR i.branch_id = $2)
+		GROUP BY key
+		ORDER BY ` + orderForGroup(groupBy) + `
+	`
+	rows, err := r.db.Query(ctx, query, organizationIDsParam(filter.OrganizationIDs), filter.BranchID, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*RevenueRow
+	for rows.Next() {
+		var row RevenueRow
+		if err := rows.Scan(&row.Key, &row.Label, &row.Revenue, &row.Invoices, &row.Members); err != nil {
+			return nil, err
+		}
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
+
+// orderForGroup sorts time series chronologically and breakdowns by revenue.
+func orderForGroup(groupBy string) string {
+	if groupBy == GroupByDay || groupBy == GroupByMonth {
+		return "key"
+	}
+	return "SUM(i.total_amount) DESC"
+}
+
+func (r *repositoryImpl) Receivables(ctx context.Context, filter *FinanceFilter, asOf time.Time) (*Receivables, error) {
+	query := `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(i.total_amount), 0),
+			COUNT(*) FILTER (WHERE i.due_date < $3::date),
+			COALESCE(SUM(i.total_amount) FILTER (WHERE i.due_date < $3::date), 0),
+			COALESCE(SUM(i.total_amount) FILTER (WHERE $3::date - i.due_date BETWEEN 1 AND 30), 0),
+			COALESCE(SUM(i.total_amount) FILTER (WHERE $3::date - i.due_date BETWEEN 31 AND 60), 0),
+			COALESCE(SUM(i.total_amount) FILTER (WHERE $3::date - i.due_date BETWEEN 61 AND 90), 0),
+			COALESCE(SUM(i.total_amount) FILTER (WHERE $3::date - i.due_date > 90), 0)
+		FROM invoices i
+		JOIN members m ON m.id = i.member_id
+		WHERE i.status = 'pending'
+			AND ($1::uuid[] IS NULL OR m.organization_id = ANY($1))
+			AND ($2::uuid IS NULL OR i.branch_id = $2)
+	`
+	var rec Receivables
+	err := r.db.QueryRow(ctx, query, organizationIDsParam(filter.OrganizationIDs), filter.BranchID, asOf).Scan(
+		&rec.OutstandingCount,
+		&rec.OutstandingAmount,
+		&rec.OverdueCount,
+		&rec.OverdueAmount,
+		&rec.Overdue1To30,
+		&rec.Overdue31To60,
+		&rec.Overdue61To90,
+		&rec.OverdueOver90,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *repositoryImpl) ListOverdue(ctx context.Context, filter *FinanceFilter, asOf time.Time, limit int) ([]*OverdueInvoice, error) {
+	query := `
+		SELECT
+			i.id, i.invoice_number, i.member_id, TRIM(m.first_name || ' ' || m.last_name), i.branch_id,
+			i.total_amount, i.due_date, $3::date - i.due_date
+		FROM invoices i
+		JOIN members m ON m.id = i.member_id
+		WHERE i.status = 'pending'
+			AND i.due_date < $3::date
+			AND ($1::uuid[] IS NULL OR m.organization_id = ANY($1))
+			AND ($2::uuid IS NULL OR i.branch_id = $2)
+		ORDER BY i.due_date, i.total_amount DESC
+		LIMIT $4
+	`
+	rows, err := r.db.Query(ctx, query, organizationIDsParam(filter.OrganizationIDs), filter.BranchID, asOf, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []*OverdueInvoice
+	for rows.Next() {
+		var inv OverdueInvoice
+		if err := rows.Scan(
+			&inv.ID,
+			&inv.InvoiceNumber,
+			&inv.MemberID,
+			&inv.MemberName,
+			&inv.BranchID,
+			&inv.Amount,
+			&inv.DueDate,
+			&inv.DaysOverdue,
+		); err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, &inv)
+	}
+	return invoices, rows.Err()
+}
+
+// MemberMRR counts a subscription on a date when the date falls in
+// [start_date, end_date) and it was not cancelled by then. Only subscriptions
+// that were running (active, or expired after running out) or that have a paid
+// invoice count; past-due sign-ups and renewals awaiting payment do not.
+// Session packs are one-off purchases and carry no recurring revenue.
+func (r *repositoryImpl) MemberMRR(ctx context.Context, filter *FinanceFilter, dates []time.Time) ([]*MemberMRR, error) {
+	query := `
+		SELECT d.at, s.member_id, SUM(p.price * 30.0 / p.duration_days)::double precision
+		FROM unnest($3::date[]) AS d(at)
+		JOIN subscriptions s
+			ON s.start_date <= d.at
+			AND s.end_date > d.at
+			AND s.deleted_at IS NULL
+			AND (s.status <> 'cancelled' OR s.cancelled_at::date > d.at)
+			AND (s.status IN ('active', 'expired') OR EXISTS (
+				SELECT 1 FROM invoices i WHERE i.subscription_id = s.id AND i.status = 'paid'
+			))
+		JOIN membership_plans p ON p.id = s.plan_id AND p.session_credits IS NULL
+		JOIN members m ON m.id = s.member_id
+		WHERE ($1::uuid[] IS NULL OR m.organization_id = ANY($1))
+			AND ($2::uuid IS NULL OR COALESCE(s.branch_id, m.home_branch_id) = $2)
+		GROUP BY d.at, s.member_id
+	`
+	rows, err := r.db.Query(ctx, query, organizationIDsParam(filter.OrganizationIDs), filter.BranchID, dates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*MemberMRR
+	for rows.Next() {
+		var m MemberMRR
+		if err := rows.Scan(&m.At, &m.MemberID, &m.MRR); err != nil {
+			return nil, err
+		}
+		result = append(result, &m)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,370 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"fitcore/internal/modules/user"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	monthLayout = "2006-01"
+
+	defaultRangeDays = 30
+	maxRangeDays     = 731
+
+	defaultMRRMonths = 12
+	maxMRRMonths     = 36
+	// churnWindowMonths is how many full months the summary averages churn
+	// over for LTV.
+	churnWindowMonths = 3
+
+	defaultOverdueLimit = 50
+	maxOverdueLimit     = 500
+	// exportOverdueLimit caps the overdue list in exports.
+	exportOverdueLimit = 10000
+)
+
+var (
+	ErrOrganizationAccess = errors.New("organization is outside your access")
+	ErrInvalidRange       = errors.New("from must not be after to, and the range must not exceed two years")
+	ErrInvalidGroupBy     = errors.New("groupBy must be day, month, branch or plan")
+)
+
+type Service interface {
+	// ScopeFilter limits admins to their organizations. organizationID is the
+	// optional organization requested.
+	ScopeFilter(ctx context.Context, filter *FinanceFilter, organizationID *uuid.UUID, userID uuid.UUID, userRole string) error
+
+	Revenue(ctx context.Context, filter *FinanceFilter, groupBy string) (*RevenueReportResponse, error)
+	// Receivables lists up to limit overdue invoices, oldest first.
+	Receivables(ctx context.Context, filter *FinanceFilter, limit int) (*ReceivablesResponse, error)
+	// MRR reports monthly recurring revenue for each month from From to To.
+	MRR(ctx context.Context, filter *FinanceFilter) (*MRRReportResponse, error)
+	Summary(ctx context.Context, filter *FinanceFilter) (*SummaryResponse, error)
+}
+
+type serviceImpl struct {
+	repo    Repository
+	userSvc user.Service
+}
+
+func NewService(repo Repository, userSvc user.Service) Service {
+	return &serviceImpl{repo: repo, userSvc: userSvc}
+}
+
+func (s *serviceImpl) ScopeFilter(ctx context.Context, filter *FinanceFilter, organizationID *uuid.UUID, userID uuid.UUID, userRole string) error {
+	if userRole != "super_admin" {
+		orgIDs, err := s.userSvc.GetUserOrganizationIDs(ctx, userID)
+		if err != nil {
+			log.Printf("Service: ScopeFilter failed to list organizations for user %s: %v", userID, err)
+			return err
+		}
+		if organizationID != nil && !slices.Contains(orgIDs, *organizationID) {
+			return ErrOrganizationAccess
+		}
+		filter.OrganizationIDs = orgIDs
+	}
+	if organizationID != nil {
+		filter.OrganizationIDs = []uuid.UUID{*organizationID}
+	}
+
+	if filter.To.IsZero() {
+		filter.To = today()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(0, 0, -(defaultRangeDays - 1))
+	}
+	if filter.From.After(filter.To) || filter.To.Sub(filter.From) > maxRangeDays*24*time.Hour {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+func (s *serviceImpl) Revenue(ctx context.Context, filter *FinanceFilter, groupBy string) (*RevenueReportResponse, error) {
+	if groupBy == "" {
+		groupBy = GroupByDay
+	}
+	if _, ok := revenueGroups[groupBy]; !ok {
+		return nil, ErrInvalidGroupBy
+	}
+
+	report := &RevenueReportResponse{
+		From:    filter.From.Format(dateLayout),
+		To:      filter.To.Format(dateLayout),
+		GroupBy: groupBy,
+		Rows:    []*RevenueRowResponse{},
+	}
+	if isEmptyScope(filter) {
+		return report, nil
+	}
+
+	rows, err := s.repo.Revenue(ctx, filter, groupBy)
+	if err != nil {
+		log.Printf("Service: Revenue report failed: %v", err)
+		return nil, err
+	}
+	for _, row := range rows {
+		report.Total += row.Revenue
+		report.Rows = append(report.Rows, &RevenueRowResponse{
+			Key:      row.Key,
+			Label:    row.Label,
+			Revenue:  round2(row.Revenue),
+			Invoices: row.Invoices,
+			Members:  row.Members,
+		})
+	}
+	report.Total = round2(report.Total)
+	return report, nil
+}
+
+// Receivables reports pending invoices as of today; the date range does not
+// apply since an old invoice stays outstanding until paid.
+func (s *serviceImpl) Receivables(ctx context.Context, filter *FinanceFilter, limit int) (*ReceivablesResponse, error) {
+	result := &ReceivablesResponse{
+		Aging:   agingBuckets(&Receivables{}),
+		Overdue: []*OverdueInvoiceResponse{},
+	}
+	if isEmptyScope(filter) {
+		return result, nil
+	}
+
+	asOf := today()
+	rec, err := s.repo.Receivables(ctx, filter, asOf)
+	if err != nil {
+		log.Printf("Service: Receivables report failed: %v", err)
+		return nil, err
+	}
+	if limit < 1 {
+		limit = defaultOverdueLimit
+	}
+	overdue, err := s.repo.ListOverdue(ctx, filter, asOf, min(limit, exportOverdueLimit))
+	if err != nil {
+		log.Printf("Service: Receivables report failed to list overdue invoices: %v", err)
+		return nil, err
+	}
+
+	result.OutstandingCount = rec.OutstandingCount
+	result.OutstandingAmount = round2(rec.OutstandingAmount)
+	result.OverdueCount = rec.OverdueCount
+	result.OverdueAmount = round2(rec.OverdueAmount)
+	result.Aging = agingBuckets(rec)
+	for _, inv := range overdue {
+		result.Overdue = append(result.Overdue, &OverdueInvoiceResponse{
+			ID:            inv.ID,
+			InvoiceNumber: inv.InvoiceNumber,
+			MemberID:      inv.MemberID,
+			MemberName:    inv.MemberName,
+			BranchID:      inv.BranchID,
+			Amount:        round2(inv.Amount),
+			DueDate:       inv.DueDate.Format(dateLayout),
+			DaysOverdue:   inv.DaysOverdue,
+		})
+	}
+	return result, nil
+}
+
+func (s *serviceImpl) MRR(ctx context.Context, filter *FinanceFilter) (*MRRReportResponse, error) {
+	first := monthStart(filter.From)
+	last := monthStart(filter.To)
+	if current := monthStart(today()); last.After(current) {
+		last = current
+	}
+	if first.After(last) || monthsBetween(first, last) >= maxMRRMonths {
+		return nil, ErrInvalidRange
+	}
+
+	report := &MRRReportResponse{
+		From:   first.Format(monthLayout),
+		To:     last.Format(monthLayout),
+		Months: []*MRRMonthResponse{},
+	}
+	if isEmptyScope(filter) {
+		return report, nil
+	}
+
+	months, err := s.mrrMonths(ctx, filter, first, last)
+	if err != nil {
+		return nil, err
+	}
+	report.Months = months
+	return report, nil
+}
+
+func (s *serviceImpl) Summary(ctx context.Context, filter *FinanceFilter) (*SummaryResponse, error) {
+	summary := &SummaryResponse{}
+	if isEmptyScope(filter) {
+		return summary, nil
+	}
+
+	// The current month plus the full months churn is averaged over.
+	now := today()
+	current := monthStart(now)
+	months, err := s.mrrMonths(ctx, filter, current.AddDate(0, -churnWindowMonths, 0), current)
+	if err != nil {
+		return nil, err
+	}
+	latest := months[len(months)-1]
+	summary.MRR = latest.EndingMRR
+	summary.PayingMembers = latest.EndingMembers
+	summary.ARPM = latest.ARPM
+
+	churn := 0.0
+	for _, m := range months[:len(months)-1] {
+		churn += m.ChurnRate
+	}
+	summary.AvgMonthlyChurnRate = round1(churn / churnWindowMonths)
+	if summary.AvgMonthlyChurnRate > 0 {
+		ltv := round2(summary.ARPM / (summary.AvgMonthlyChurnRate / 100))
+		summary.LTV = &ltv
+	}
+
+	revenueFilter := *filter
+	revenueFilter.From = current.AddDate(0, -1, 0)
+	revenueFilter.To = now
+	revenue, err := s.repo.Revenue(ctx, &revenueFilter, GroupByMonth)
+	if err != nil {
+		log.Printf("Service: Summary failed to load revenue: %v", err)
+		return nil, err
+	}
+	for _, row := range revenue {
+		switch row.Key {
+		case current.Format(monthLayout):
+			summary.RevenueThisMonth = round2(row.Revenue)
+		case current.AddDate(0, -1, 0).Format(monthLayout):
+			summary.RevenueLastMonth = round2(row.Revenue)
+		}
+	}
+
+	rec, err := s.repo.Receivables(ctx, filter, now)
+	if err != nil {
+		log.Printf("Service: Summary failed to load receivables: %v", err)
+		return nil, err
+	}
+	summary.OutstandingAmount = round2(rec.OutstandingAmount)
+	summary.OverdueAmount = round2(rec.OverdueAmount)
+	return summary, nil
+}
+
+// mrrMonths compares each member's MRR at the start and end of every month
+// from first to last. The current month ends today.
+func (s *serviceImpl) mrrMonths(ctx context.Context, filter *FinanceFilter, first, last time.Time) ([]*MRRMonthResponse, error) {
+	now := today()
+	var dates []time.Time
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		dates = append(dates, m)
+	}
+	end := last.AddDate(0, 1, 0)
+	if end.After(now) {
+		end = now
+	}
+	dates = append(dates, end)
+
+	rows, err := s.repo.MemberMRR(ctx, filter, dates)
+	if err != nil {
+		log.Printf("Service: MRR report failed: %v", err)
+		return nil, err
+	}
+	byDate := make(map[string]map[uuid.UUID]float64, len(dates))
+	for _, row := range rows {
+		key := row.At.Format(dateLayout)
+		if byDate[key] == nil {
+			byDate[key] = make(map[uuid.UUID]float64)
+		}
+		byDate[key][row.MemberID] = row.MRR
+	}
+
+	months := make([]*MRRMonthResponse, 0, len(dates)-1)
+	for i := 0; i < len(dates)-1; i++ {
+		start := byDate[dates[i].Format(dateLayout)]
+		finish := byDate[dates[i+1].Format(dateLayout)]
+		months = append(months, mrrMovement(dates[i].Format(monthLayout), start, finish))
+	}
+	return months, nil
+}
+
+func mrrMovement(month string, start, finish map[uuid.UUID]float64) *MRRMonthResponse {
+	m := &MRRMonthResponse{Month: month, StartingMembers: len(start), EndingMembers: len(finish)}
+	for memberID, before := range start {
+		m.StartingMRR += before
+		after, ok := finish[memberID]
+		switch {
+		case !ok:
+			m.ChurnedMRR += before
+			m.ChurnedMembers++
+		case after > before:
+			m.ExpansionMRR += after - before
+		case after < before:
+			m.ContractionMRR += before - after
+		}
+	}
+	for memberID, after := range finish {
+		m.EndingMRR += after
+		if _, ok := start[memberID]; !ok {
+			m.NewMRR += after
+			m.NewMembers++
+		}
+	}
+
+	m.NetNewMRR = m.NewMRR + m.ExpansionMRR - m.ContractionMRR - m.ChurnedMRR
+	if m.StartingMembers > 0 {
+		m.ChurnRate = round1(float64(m.ChurnedMembers) * 100 / float64(m.StartingMembers))
+	}
+	if m.StartingMRR > 0 {
+		m.RevenueChurnRate = round1((m.ChurnedMRR + m.ContractionMRR) * 100 / m.StartingMRR)
+	}
+	if m.EndingMembers > 0 {
+		m.ARPM = round2(m.EndingMRR / float64(m.EndingMembers))
+	}
+
+	m.StartingMRR = round2(m.StartingMRR)
+	m.NewMRR = round2(m.NewMRR)
+	m.ExpansionMRR = round2(m.ExpansionMRR)
+	m.ContractionMRR = round2(m.ContractionMRR)
+	m.ChurnedMRR = round2(m.ChurnedMRR)
+	m.NetNewMRR = round2(m.NetNewMRR)
+	m.EndingMRR = round2(m.EndingMRR)
+	return m
+}
+
+func agingBuckets(rec *Receivables) map[string]float64 {
+	return map[string]float64{
+		"1-30":  round2(rec.Overdue1To30),
+		"31-60": round2(rec.Overdue31To60),
+		"61-90": round2(rec.Overdue61To90),
+		"90+":   round2(rec.OverdueOver90),
+	}
+}
+
+// isEmptyScope reports an admin without any organization.
+func isEmptyScope(filter *FinanceFilter) bool {
+	return filter.OrganizationIDs != nil && len(filter.OrganizationIDs) == 0
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,93 @@
+package groups
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateGroupRequest starts a group on a plan with maxSeats. A family pays
+// its plan through a checkout sent to the primary member; a company is
+// invoiced monthly at the plan price per active seat from startDate.
+type CreateGroupRequest struct {
+	Kind            string    `json:"kind" validate:"required,oneof=family corporate"`
+	Name            string    `json:"name" validate:"required,max=255"`
+	PlanID          uuid.UUID `json:"planId" validate:"required"`
+	PrimaryMemberID uuid.UUID `json:"primaryMemberId" validate:"required"`
+	// BillingEmail receives the invoices of a corporate group.
+	BillingEmail *string `json:"billingEmail,omitempty" validate:"omitempty,email"`
+	StartDate    *string `json:"startDate,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type UpdateGroupRequest struct {
+	Name         *string `json:"name,omitempty" validate:"omitempty,max=255"`
+	BillingEmail *string `json:"billingEmail,omitempty" validate:"omitempty,email"`
+}
+
+type AddMemberRequest struct {
+	MemberID uuid.UUID `json:"memberId" validate:"required"`
+}
+
+type GroupFilter struct {
+	OrganizationIDs []uuid.UUID
+	BranchIDs       []uuid.UUID
+	Kind            *string
+	Page            int
+	Limit           int
+}
+
+type GroupResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	OrganizationID  uuid.UUID  `json:"organizationId"`
+	BranchID        *uuid.UUID `json:"branchId,omitempty"`
+	Kind            string     `json:"kind"`
+	Name            string     `json:"name"`
+	PlanID          uuid.UUID  `json:"planId"`
+	PlanName        string     `json:"planName"`
+	MaxSeats        *int       `json:"maxSeats,omitempty"`
+	SeatPrice       float64    `json:"seatPrice"`
+	Seats           int        `json:"seats"`
+	PrimaryMemberID uuid.UUID  `json:"primaryMemberId"`
+	PrimaryName     string     `json:"primaryName"`
+	BillingEmail    *string    `json:"billingEmail,omitempty"`
+	NextBillingDate *string    `json:"nextBillingDate,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+type SeatResponse struct {
+	ID         uuid.UUID `json:"id"`
+	MemberID   uuid.UUID `json:"memberId"`
+	MemberName string    `json:"memberName"`
+	IsPrimary  bool      `json:"isPrimary"`
+	AddedAt    time.Time `json:"addedAt"`
+}
+
+// GroupDetailResponse lists the seats of a group; members who are not the
+// primary only see the group itself.
+type GroupDetailResponse struct {
+	*GroupResponse
+	Members []*SeatResponse `json:"members,omitempty"`
+}
+
+// CreateGroupResponse carries the family checkout, or the first invoice of
+// a company billed from today.
+type CreateGroupResponse struct {
+	*GroupResponse
+	SubscriptionID *uuid.UUID `json:"subscriptionId,omitempty"`
+	InvoiceID      *uuid.UUID `json:"invoiceId,omitempty"`
+	CheckoutURL    string     `json:"checkoutUrl,omitempty"`
+}
+
+type BillingResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	InvoiceID     *uuid.UUID `json:"invoiceId,omitempty"`
+	InvoiceNumber *string    `json:"invoiceNumber,omitempty"`
+	InvoiceStatus *string    `json:"invoiceStatus,omitempty"`
+	PeriodStart   string     `json:"periodStart"`
+	PeriodEnd     string     `json:"periodEnd"`
+	Seats         int        `json:"seats"`
+	SeatPrice     float64    `json:"seatPrice"`
+	Amount        *float64   `json:"amount,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
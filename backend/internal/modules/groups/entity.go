@@ -0,0 +1,120 @@
+package groups
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindFamily    Kind = "family"
+	KindCorporate Kind = "corporate"
+)
+
+// Group is a family or company sharing one group plan. The primary seat
+// pays; dependents check in on the primary's subscription.
+type Group struct {
+	ID              uuid.UUID  `db:"id"`
+	OrganizationID  uuid.UUID  `db:"organization_id"`
+	BranchID        *uuid.UUID `db:"branch_id"`
+	Kind            Kind       `db:"kind"`
+	Name            string     `db:"name"`
+	PlanID          uuid.UUID  `db:"plan_id"`
+	BillingEmail    *string    `db:"billing_email"`
+	NextBillingDate *time.Time `db:"next_billing_date"`
+	CreatedBy       *uuid.UUID `db:"created_by"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+
+	// Joined from membership_plans, the primary seat and the seat count.
+	PlanName        string    `db:"plan_name"`
+	MaxSeats        *int      `db:"max_seats"`
+	SeatPrice       float64   `db:"seat_price"`
+	PrimaryMemberID uuid.UUID `db:"primary_member_id"`
+	PrimaryName     string    `db:"primary_name"`
+	Seats           int       `db:"seats"`
+}
+
+// Seat is a member's place in a group.
+type Seat struct {
+	ID        uuid.UUID  `db:"id"`
+	GroupID   uuid.UUID  `db:"group_id"`
+	MemberID  uuid.UUID  `db:"member_id"`
+	IsPrimary bool       `db:"is_primary"`
+	AddedAt   time.Time  `db:"added_at"`
+	RemovedAt *time.Time `db:"removed_at"`
+
+	// Joined from members.
+	MemberName string `db:"member_name"`
+}
+
+// Billing is one billed month of a corporate group.
+type Billing struct {
+	ID          uuid.UUID  `db:"id"`
+	GroupID     uuid.UUID  `db:"group_id"`
+	InvoiceID   *uuid.UUID `db:"invoice_id"`
+	PeriodStart time.Time  `db:"period_start"`
+	PeriodEnd   time.Time  `db:"period_end"`
+	Seats       int        `db:"seats"`
+	SeatPrice   float64    `db:"seat_price"`
+	CreatedAt   time.Time  `db:"created_at"`
+
+	// Joined from invoices.
+	InvoiceNumber *string  `db:"invoice_number"`
+	InvoiceStatus *string  `db:"invoice_status"`
+	Amount        *float64 `db:"amount"`
+}
+
+func (g *Group) ToResponse() *GroupResponse {
+	var nextBillingDate *string
+	if g.NextBillingDate != nil {
+		formatted := g.NextBillingDate.Format(dateLayout)
+		nextBillingDate = &formatted
+	}
+
+	return &GroupResponse{
+		ID:              g.ID,
+		OrganizationID:  g.OrganizationID,
+		BranchID:        g.BranchID,
+		Kind:            string(g.Kind),
+		Name:            g.Name,
+		PlanID:          g.PlanID,
+		PlanName:        g.PlanName,
+		MaxSeats:        g.MaxSeats,
+		SeatPrice:       g.SeatPrice,
+		Seats:           g.Seats,
+		PrimaryMemberID: g.PrimaryMemberID,
+		PrimaryName:     g.PrimaryName,
+		BillingEmail:    g.BillingEmail,
+		NextBillingDate: nextBillingDate,
+		CreatedAt:       g.CreatedAt,
+		UpdatedAt:       g.UpdatedAt,
+	}
+}
+
+func (s *Seat) ToResponse() *SeatResponse {
+	return &SeatResponse{
+		ID:         s.ID,
+		MemberID:   s.MemberID,
+		MemberName: s.MemberName,
+		IsPrimary:  s.IsPrimary,
+		AddedAt:    s.AddedAt,
+	}
+}
+
+func (b *Billing) ToResponse() *BillingResponse {
+	return &BillingResponse{
+		ID:            b.ID,
+		InvoiceID:     b.InvoiceID,
+		InvoiceNumber: b.InvoiceNumber,
+		InvoiceStatus: b.InvoiceStatus,
+		PeriodStart:   b.PeriodStart.Format(dateLayout),
+		PeriodEnd:     b.PeriodEnd.Format(dateLayout),
+		Seats:         b.Seats,
+		SeatPrice:     b.SeatPrice,
+		Amount:        b.Amount,
+		CreatedAt:     b.CreatedAt,
+	}
+}
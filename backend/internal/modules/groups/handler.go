@@ -0,0 +1,286 @@
+package groups
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/groups", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member"))
+			r.Get("/me", h.GetMyGroup)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin", "staff"))
+			r.Get("/", h.ListGroups)
+			r.Post("/", h.CreateGroup)
+			r.Get("/{id}", h.GetGroup)
+			r.Put("/{id}", h.UpdateGroup)
+			r.Post("/{id}/members", h.AddMember)
+			r.Delete("/{id}/members/{memberId}", h.RemoveMember)
+			r.Get("/{id}/billings", h.ListBillings)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Delete("/{id}", h.DeleteGroup)
+		})
+	})
+}
+
+// ListGroups filters by kind (family or corporate).
+func (h *Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &GroupFilter{}
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if v := query.Get("kind"); v != "" {
+		if v != string(KindFamily) && v != string(KindCorporate) {
+			response.BadRequest(w, "Invalid kind parameter, use family or corporate", nil)
+			return
+		}
+		filter.Kind = &v
+	}
+
+	groups, err := h.service.ListGroups(r.Context(), userID, userRole, filter)
+	if err != nil {
+		writeError(w, err, "Failed to list groups")
+		return
+	}
+	groupResponses := make([]*GroupResponse, len(groups))
+	for i, g := range groups {
+		groupResponses[i] = g.ToResponse()
+	}
+	response.Success(w, "Groups retrieved successfully", groupResponses)
+}
+
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req CreateGroupRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.CreateGroup(r.Context(), userID, userRole, &req)
+	if err != nil {
+		writeError(w, err, "Failed to create group")
+		return
+	}
+	response.Success(w, "Group created successfully", res)
+}
+
+func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	res, err := h.service.GetGroup(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to get group")
+		return
+	}
+	response.Success(w, "Group retrieved successfully", res)
+}
+
+func (h *Handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+	var req UpdateGroupRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	g, err := h.service.UpdateGroup(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to update group")
+		return
+	}
+	response.Success(w, "Group updated successfully", g.ToResponse())
+}
+
+func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteGroup(r.Context(), userID, userRole, id); err != nil {
+		writeError(w, err, "Failed to delete group")
+		return
+	}
+	response.Success(w, "Group deleted successfully", nil)
+}
+
+func (h *Handler) AddMember(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+	var req AddMemberRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	seat, err := h.service.AddMember(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to add member to group")
+		return
+	}
+	response.Success(w, "Member added to group successfully", seat.ToResponse())
+}
+
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+	memberID, ok := pathUUID(w, r, "memberId", "Invalid member ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(r.Context(), userID, userRole, id, memberID); err != nil {
+		writeError(w, err, "Failed to remove member from group")
+		return
+	}
+	response.Success(w, "Member removed from group successfully", nil)
+}
+
+func (h *Handler) ListBillings(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	billings, err := h.service.ListBillings(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to list group billings")
+		return
+	}
+	billingResponses := make([]*BillingResponse, len(billings))
+	for i, b := range billings {
+		billingResponses[i] = b.ToResponse()
+	}
+	response.Success(w, "Group billings retrieved successfully", billingResponses)
+}
+
+func (h *Handler) GetMyGroup(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.GetMyGroup(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "Failed to get group")
+		return
+	}
+	response.Success(w, "Group retrieved successfully", res)
+}
+
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrGroupNotFound), errors.Is(err, ErrMemberNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, ErrAlreadyInGroup), errors.Is(err, ErrSeatLimitReached):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, ErrPlanUnavailable), errors.Is(err, ErrNotGroupPlan), errors.Is(err, ErrPrimarySeat),
+		errors.Is(err, ErrBillingEmailRequired), errors.Is(err, ErrInvalidStartDate), errors.Is(err, ErrNotCorporateGroup),
+		errors.Is(err, ErrPrimaryAccountRequired):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return false
+	}
+	return response.ValidateStructAndWrite(w, req)
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		response.BadRequest(w, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func callerFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+	return userID, userRole, true
+}
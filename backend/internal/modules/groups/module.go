@@ -0,0 +1,33 @@
+package groups
+
+import (
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/email"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, userSvc user.Service, memberSvc member.Service, subSvc subscription.Service, emailSvc *email.Service) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, userSvc, memberSvc, subSvc, emailSvc)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
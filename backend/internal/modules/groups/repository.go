@@ -0,0 +1,502 @@
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// CreateGroup creates the group with primaryMemberID in its primary seat.
+	CreateGroup(ctx context.Context, group *Group, primaryMemberID uuid.UUID) error
+	GetGroup(ctx context.Context, id uuid.UUID) (*Group, error)
+	// GetGroupByMember returns the group the member holds a seat in.
+	GetGroupByMember(ctx context.Context, memberID uuid.UUID) (*Group, error)
+	ListGroups(ctx context.Context, filter *GroupFilter) ([]*Group, error)
+	UpdateGroup(ctx context.Context, group *Group) error
+	// DeleteGroup dissolves the group and frees its seats. A corporate
+	// group's subscription is cancelled with it.
+	DeleteGroup(ctx context.Context, id uuid.UUID) error
+
+	ListSeats(ctx context.Context, groupID uuid.UUID) ([]*Seat, error)
+	// AddSeat seats a dependent. It returns pgx.ErrNoRows when the group
+	// already has maxSeats seats.
+	AddSeat(ctx context.Context, groupID, memberID uuid.UUID, maxSeats int) (*Seat, error)
+	// RemoveSeat frees a dependent's seat. It returns pgx.ErrNoRows when the
+	// member holds none in the group.
+	RemoveSeat(ctx context.Context, groupID, memberID uuid.UUID) error
+
+	// ListDueGroupIDs lists the corporate groups to bill on or before asOf.
+	ListDueGroupIDs(ctx context.Context, asOf time.Time) ([]uuid.UUID, error)
+	// BillGroup invoices the next month of a corporate group for its active
+	// seats and extends the primary's subscription to the end of it. It
+	// returns pgx.ErrNoRows when the group is not due on asOf.
+	BillGroup(ctx context.Context, groupID uuid.UUID, asOf time.Time) (*Billing, error)
+	ListBillings(ctx context.Context, groupID uuid.UUID) ([]*Billing, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+// invoiceDueDays is how long a company has to pay a monthly invoice.
+const invoiceDueDays = 14
+
+const groupColumns = `
+	g.id, g.organization_id, g.branch_id, g.kind, g.name, g.plan_id, g.billing_email, g.next_billing_date,
+	g.created_by, g.created_at, g.updated_at,
+	p.name, p.max_seats, p.price, ps.member_id, TRIM(m.first_name || ' ' || m.last_name),
+	(SELECT COUNT(*) FROM membership_group_members c WHERE c.group_id = g.id AND c.removed_at IS NULL)
+`
+
+const groupFrom = `
+	FROM membership_groups g
+	JOIN membership_plans p ON p.id = g.plan_id
+	JOIN membership_group_members ps ON ps.group_id = g.id AND ps.is_primary AND ps.removed_at IS NULL
+	JOIN members m ON m.id = ps.member_id
+`
+
+func scanGroup(row pgx.Row) (*Group, error) {
+	var g Group
+	err := row.Scan(
+		&g.ID,
+		&g.OrganizationID,
+		&g.BranchID,
+		&g.Kind,
+		&g.Name,
+		&g.PlanID,
+		&g.BillingEmail,
+		&g.NextBillingDate,
+		&g.CreatedBy,
+		&g.CreatedAt,
+		&g.UpdatedAt,
+		&g.PlanName,
+		&g.MaxSeats,
+		&g.SeatPrice,
+		&g.PrimaryMemberID,
+		&g.PrimaryName,
+		&g.Seats,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *repositoryImpl) CreateGroup(ctx context.Context, g *Group, primaryMemberID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	groupQuery := `
+		INSERT INTO membership_groups (
+			organization_id, branch_id, kind, name, plan_id, billing_email, next_billing_date, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+	if err := tx.QueryRow(ctx, groupQuery,
+		g.OrganizationID,
+		g.BranchID,
+		g.Kind,
+		g.Name,
+		g.PlanID,
+		g.BillingEmail,
+		g.NextBillingDate,
+		g.CreatedBy,
+	).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt); err != nil {
+		return err
+	}
+
+	seatQuery := `
+		INSERT INTO membership_group_members (group_id, member_id, is_primary)
+		VALUES ($1, $2, TRUE)
+	`
+	if _, err := tx.Exec(ctx, seatQuery, g.ID, primaryMemberID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetGroup(ctx context.Context, id uuid.UUID) (*Group, error) {
+	query := `SELECT ` + groupColumns + groupFrom + ` WHERE g.id = $1 AND g.deleted_at IS NULL`
+	return scanGroup(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) GetGroupByMember(ctx context.Context, memberID uuid.UUID) (*Group, error) {
+	query := `SELECT ` + groupColumns + groupFrom + `
+		JOIN membership_group_members s ON s.group_id = g.id AND s.removed_at IS NULL
+		WHERE s.member_id = $1 AND g.deleted_at IS NULL
+	`
+	return scanGroup(r.db.QueryRow(ctx, query, memberID))
+}
+
+func (r *repositoryImpl) ListGroups(ctx context.Context, filter *GroupFilter) ([]*Group, error) {
+	query := `SELECT ` + groupColumns + groupFrom + ` WHERE g.deleted_at IS NULL`
+
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OrganizationIDs != nil {
+		query += " AND g.organization_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.OrganizationIDs)
+		argIndex++
+	}
+
+	if filter.BranchIDs != nil {
+		query += " AND g.branch_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.BranchIDs)
+		argIndex++
+	}
+
+	if filter.Kind != nil {
+		query += " AND g.kind = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.Kind)
+		argIndex++
+	}
+
+	query += " ORDER BY g.name"
+	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []*Group{}
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func (r *repositoryImpl) UpdateGroup(ctx context.Context, g *Group) error {
+	query := `
+		UPDATE membership_groups
+		SET name = $1, billing_email = $2, updated_at = NOW()
+		WHERE id = $3 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(ctx, query, g.Name, g.BillingEmail, g.ID).Scan(&g.UpdatedAt)
+}
+
+func (r *repositoryImpl) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		kind            Kind
+		planID          uuid.UUID
+		primaryMemberID *uuid.UUID
+	)
+	groupQuery := `
+		UPDATE membership_groups g
+		SET deleted_at = NOW(), next_billing_date = NULL, updated_at = NOW()
+		WHERE g.id = $1 AND g.deleted_at IS NULL
+		RETURNING g.kind, g.plan_id, (
+			SELECT s.member_id FROM membership_group_members s
+			WHERE s.group_id = g.id AND s.is_primary AND s.removed_at IS NULL
+		)
+	`
+	if err := tx.QueryRow(ctx, groupQuery, id).Scan(&kind, &planID, &primaryMemberID); err != nil {
+		return err
+	}
+
+	seatQuery := `
+		UPDATE membership_group_members
+		SET removed_at = NOW()
+		WHERE group_id = $1 AND removed_at IS NULL
+	`
+	if _, err := tx.Exec(ctx, seatQuery, id); err != nil {
+		return err
+	}
+
+	// A family keeps the membership its primary paid for; a company's was
+	// opened by the group and ends with it.
+	if kind == KindCorporate && primaryMemberID != nil {
+		subscriptionQuery := `
+			UPDATE subscriptions
+			SET status = 'cancelled', updated_at = NOW()
+			WHERE member_id = $1 AND plan_id = $2 AND status IN ('active', 'past_due')
+		`
+		if _, err := tx.Exec(ctx, subscriptionQuery, *primaryMemberID, planID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) ListSeats(ctx context.Context, groupID uuid.UUID) ([]*Seat, error) {
+	query := `
+		SELECT s.id, s.group_id, s.member_id, s.is_primary, s.added_at, s.removed_at, TRIM(m.first_name || ' ' || m.last_name)
+		FROM membership_group_members s
+		JOIN members m ON m.id = s.member_id
+		WHERE s.group_id = $1 AND s.removed_at IS NULL
+		ORDER BY s.is_primary DESC, s.added_at
+	`
+	rows, err := r.db.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seats := []*Seat{}
+	for rows.Next() {
+		var s Seat
+		if err := rows.Scan(&s.ID, &s.GroupID, &s.MemberID, &s.IsPrimary, &s.AddedAt, &s.RemovedAt, &s.MemberName); err != nil {
+			return nil, err
+		}
+		seats = append(seats, &s)
+	}
+	return seats, rows.Err()
+}
+
+func (r *repositoryImpl) AddSeat(ctx context.Context, groupID, memberID uuid.UUID, maxSeats int) (*Seat, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the group keeps two additions at once from passing the limit.
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM membership_groups WHERE id = $1 FOR UPDATE`, groupID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO membership_group_members (group_id, member_id)
+		SELECT $1, $2
+		WHERE (SELECT COUNT(*) FROM membership_group_members WHERE group_id = $1 AND removed_at IS NULL) < $3
+		RETURNING id, group_id, member_id, is_primary, added_at
+	`
+	var s Seat
+	if err := tx.QueryRow(ctx, query, groupID, memberID, maxSeats).Scan(&s.ID, &s.GroupID, &s.MemberID, &s.IsPrimary, &s.AddedAt); err != nil {
+		return nil, err
+	}
+
+	return &s, tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) RemoveSeat(ctx context.Context, groupID, memberID uuid.UUID) error {
+	query := `
+		UPDATE membership_group_members
+		SET removed_at = NOW()
+		WHERE group_id = $1 AND member_id = $2 AND NOT is_primary AND removed_at IS NULL
+	`
+	result, err := r.db.Exec(ctx, query, groupID, memberID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *repositoryImpl) ListDueGroupIDs(ctx context.Context, asOf time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM membership_groups
+		WHERE kind = 'corporate' AND deleted_at IS NULL AND next_billing_date <= $1
+		ORDER BY next_billing_date
+	`
+	rows, err := r.db.Query(ctx, query, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *repositoryImpl) BillGroup(ctx context.Context, groupID uuid.UUID, asOf time.Time) (*Billing, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		name            string
+		planID          uuid.UUID
+		branchID        *uuid.UUID
+		primaryMemberID uuid.UUID
+	)
+	b := &Billing{GroupID: groupID}
+	groupQuery := `
+		SELECT g.name, g.plan_id, g.branch_id, g.next_billing_date, p.price, s.member_id
+		FROM membership_groups g
+		JOIN membership_plans p ON p.id = g.plan_id
+		JOIN membership_group_members s ON s.group_id = g.id AND s.is_primary AND s.removed_at IS NULL
+		WHERE g.id = $1 AND g.kind = 'corporate' AND g.deleted_at IS NULL AND g.next_billing_date <= $2
+		FOR UPDATE OF g
+	`
+	if err := tx.QueryRow(ctx, groupQuery, groupID, asOf).Scan(
+		&name, &planID, &branchID, &b.PeriodStart, &b.SeatPrice, &primaryMemberID,
+	); err != nil {
+		return nil, err
+	}
+	b.PeriodEnd = b.PeriodStart.AddDate(0, 1, 0)
+
+	seatQuery := `SELECT COUNT(*) FROM membership_group_members WHERE group_id = $1 AND removed_at IS NULL`
+	if err := tx.QueryRow(ctx, seatQuery, groupID).Scan(&b.Seats); err != nil {
+		return nil, err
+	}
+
+	var subscriptionID uuid.UUID
+	findQuery := `
+		SELECT id FROM subscriptions
+		WHERE member_id = $1 AND plan_id = $2 AND status <> 'cancelled'
+		ORDER BY end_date DESC
+		LIMIT 1
+		FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, findQuery, primaryMemberID, planID).Scan(&subscriptionID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		createQuery := `
+			INSERT INTO subscriptions (member_id, plan_id, branch_id, start_date, end_date, status)
+			VALUES ($1, $2, $3, $4, $5, 'active')
+			RETURNING id
+		`
+		if err := tx.QueryRow(ctx, createQuery, primaryMemberID, planID, branchID, b.PeriodStart, b.PeriodEnd).Scan(&subscriptionID); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		extendQuery := `
+			UPDATE subscriptions
+			SET end_date = $2, status = 'active', updated_at = NOW()
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(ctx, extendQuery, subscriptionID, b.PeriodEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	amount := math.Round(float64(b.Seats)*b.SeatPrice*100) / 100
+	number := invoiceNumber()
+	notes := fmt.Sprintf("%s: %d seats from %s to %s", name, b.Seats, b.PeriodStart.Format(dateLayout), b.PeriodEnd.Format(dateLayout))
+	invoiceQuery := `
+		INSERT INTO invoices (
+			invoice_number, member_id, branch_id, subscription_id, amount, tax_amount, status, due_date, notes
+		) VALUES ($1, $2, $3, $4, $5, 0, 'pending', $6, $7)
+		RETURNING id, status
+	`
+	var invoiceID uuid.UUID
+	var invoiceStatus string
+	if err := tx.QueryRow(ctx, invoiceQuery,
+		number,
+		primaryMemberID,
+		branchID,
+		subscriptionID,
+		amount,
+		b.PeriodStart.AddDate(0, 0, invoiceDueDays),
+		notes,
+	).Scan(&invoiceID, &invoiceStatus); err != nil {
+		return nil, err
+	}
+	b.InvoiceID, b.InvoiceNumber, b.InvoiceStatus, b.Amount = &invoiceID, &number, &invoiceStatus, &amount
+
+	billingQuery := `
+		INSERT INTO membership_group_billings (group_id, invoice_id, period_start, period_end, seats, seat_price)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	if err := tx.QueryRow(ctx, billingQuery,
+		groupID,
+		invoiceID,
+		b.PeriodStart,
+		b.PeriodEnd,
+		b.Seats,
+		b.SeatPrice,
+	).Scan(&b.ID, &b.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	advanceQuery := `
+		UPDATE membership_groups
+		SET next_billing_date = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, advanceQuery, groupID, b.PeriodEnd); err != nil {
+		return nil, err
+	}
+
+	return b, tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) ListBillings(ctx context.Context, groupID uuid.UUID) ([]*Billing, error) {
+	query := `
+		SELECT
+			gb.id, gb.group_id, gb.invoice_id, gb.period_start, gb.period_end, gb.seats, gb.seat_price, gb.created_at,
+			i.invoice_number, i.status, i.total_amount
+		FROM membership_group_billings gb
+		LEFT JOIN invoices i ON i.id = gb.invoice_id
+		WHERE gb.group_id = $1
+		ORDER BY gb.period_start DESC
+	`
+	rows, err := r.db.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	billings := []*Billing{}
+	for rows.Next() {
+		var b Billing
+		if err := rows.Scan(
+			&b.ID,
+			&b.GroupID,
+			&b.InvoiceID,
+			&b.PeriodStart,
+			&b.PeriodEnd,
+			&b.Seats,
+			&b.SeatPrice,
+			&b.CreatedAt,
+			&b.InvoiceNumber,
+			&b.InvoiceStatus,
+			&b.Amount,
+		); err != nil {
+			return nil, err
+		}
+		billings = append(billings, &b)
+	}
+	return billings, rows.Err()
+}
+
+func invoiceNumber() string {
+	return fmt.Sprintf("INV-%d-%s", time.Now().UTC().Year(), strings.Split(uuid.New().String(), "-")[0])
+}
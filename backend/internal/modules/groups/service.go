@@ -0,0 +1,443 @@
+package groups
+
+import (
+	"context"
+	"errors"
+	"fitcore/internal/middleware"
+	"fmt"
+	"html"
+	"log"
+	"time"
+
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/email"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrGroupNotFound          = errors.New("group not found")
+	ErrMemberNotFound         = errors.New("member not found")
+	ErrPlanUnavailable        = errors.New("plan is not offered to this member")
+	ErrNotGroupPlan           = errors.New("plan has no seats for a group")
+	ErrAlreadyInGroup         = errors.New("member already belongs to a group")
+	ErrSeatLimitReached       = errors.New("group has no seats left on its plan")
+	ErrPrimarySeat            = errors.New("the primary member cannot be removed from the group")
+	ErrBillingEmailRequired   = errors.New("billingEmail is required for a corporate group")
+	ErrInvalidStartDate       = errors.New("startDate cannot be in the past")
+	ErrNotCorporateGroup      = errors.New("only corporate groups are billed monthly")
+	ErrPrimaryAccountRequired = errors.New("the primary member needs an account to receive the checkout")
+)
+
+// Service manages family and corporate groups. Staff calls are scoped like
+// the rest of the back office: admins to their organizations, staff to the
+// groups of their branches.
+type Service interface {
+	ListGroups(ctx context.Context, userID uuid.UUID, userRole string, filter *GroupFilter) ([]*Group, error)
+	GetGroup(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*GroupDetailResponse, error)
+	// CreateGroup opens the group with its primary member. A family's plan
+	// is paid through a checkout unless the primary already holds it; a
+	// company starting today gets its first invoice right away.
+	CreateGroup(ctx context.Context, userID uuid.UUID, userRole string, req *CreateGroupRequest) (*CreateGroupResponse, error)
+	UpdateGroup(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateGroupRequest) (*Group, error)
+	DeleteGroup(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) error
+	AddMember(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *AddMemberRequest) (*Seat, error)
+	RemoveMember(ctx context.Context, userID uuid.UUID, userRole string, id, memberID uuid.UUID) error
+	ListBillings(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) ([]*Billing, error)
+
+	// GetMyGroup returns the caller's group; only the primary sees its seats.
+	GetMyGroup(ctx context.Context, userID uuid.UUID) (*GroupDetailResponse, error)
+
+	// BillDueGroups invoices every corporate group whose month starts today
+	// or earlier for the seats active at that moment. It returns how many
+	// months were billed.
+	BillDueGroups(ctx context.Context) (int, error)
+}
+
+type serviceImpl struct {
+	repo      Repository
+	userSvc   user.Service
+	memberSvc member.Service
+	subSvc    subscription.Service
+	emailSvc  *email.Service
+}
+
+func NewService(repo Repository, userSvc user.Service, memberSvc member.Service, subSvc subscription.Service, emailSvc *email.Service) Service {
+	return &serviceImpl{
+		repo:      repo,
+		userSvc:   userSvc,
+		memberSvc: memberSvc,
+		subSvc:    subSvc,
+		emailSvc:  emailSvc,
+	}
+}
+
+// group loads a group the caller may manage; others are reported as missing.
+func (s *serviceImpl) group(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Group, *middleware.Scope, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := s.repo.GetGroup(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !sc.Allows(g.OrganizationID, g.BranchID) {
+		return nil, nil, ErrGroupNotFound
+	}
+	return g, sc, nil
+}
+
+// member loads a member the caller may work with.
+func (s *serviceImpl) member(ctx context.Context, sc *middleware.Scope, id uuid.UUID) (*member.Member, error) {
+	m, err := s.memberSvc.GetMember(ctx, id)
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+	if !sc.Allows(m.OrganizationID, m.HomeBranchID) {
+		return nil, ErrMemberNotFound
+	}
+	return m, nil
+}
+
+func (s *serviceImpl) ListGroups(ctx context.Context, userID uuid.UUID, userRole string, filter *GroupFilter) ([]*Group, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	filter.OrganizationIDs = sc.OrganizationIDs
+	filter.BranchIDs = sc.BranchIDs
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return s.repo.ListGroups(ctx, filter)
+}
+
+func (s *serviceImpl) GetGroup(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*GroupDetailResponse, error) {
+	g, _, err := s.group(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, g)
+}
+
+func (s *serviceImpl) CreateGroup(ctx context.Context, userID uuid.UUID, userRole string, req *CreateGroupRequest) (*CreateGroupResponse, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	primary, err := s.member(ctx, sc, req.PrimaryMemberID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetGroupByMember(ctx, primary.ID); err == nil {
+		return nil, ErrAlreadyInGroup
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	plan, err := s.groupPlan(ctx, primary, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := Kind(req.Kind)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := today
+	if req.StartDate != nil {
+		startDate, err = time.Parse(dateLayout, *req.StartDate)
+		if err != nil || startDate.Before(today) {
+			return nil, ErrInvalidStartDate
+		}
+	}
+	if kind == KindFamily && primary.UserID == nil {
+		return nil, ErrPrimaryAccountRequired
+	}
+
+	g := &Group{
+		OrganizationID: primary.OrganizationID,
+		BranchID:       primary.HomeBranchID,
+		Kind:           kind,
+		Name:           req.Name,
+		PlanID:         plan.ID,
+		CreatedBy:      &userID,
+	}
+	if kind == KindCorporate {
+		if req.BillingEmail == nil {
+			return nil, ErrBillingEmailRequired
+		}
+		g.BillingEmail = req.BillingEmail
+		g.NextBillingDate = &startDate
+	}
+
+	if err := s.repo.CreateGroup(ctx, g, primary.ID); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrAlreadyInGroup
+		}
+		log.Printf("Service: CreateGroup failed for primary member %s: %v", primary.ID, err)
+		return nil, err
+	}
+
+	res := &CreateGroupResponse{}
+	switch kind {
+	case KindFamily:
+		if err := s.startFamilySubscription(ctx, primary, plan, startDate, res); err != nil {
+			log.Printf("Service: CreateGroup failed to start the subscription of group %s: %v", g.ID, err)
+			if err := s.repo.DeleteGroup(ctx, g.ID); err != nil {
+				log.Printf("Service: CreateGroup failed to roll back group %s: %v", g.ID, err)
+			}
+			return nil, err
+		}
+	case KindCorporate:
+		if !startDate.After(today) {
+			b, err := s.repo.BillGroup(ctx, g.ID, today)
+			if err != nil {
+				log.Printf("Service: CreateGroup failed to bill group %s: %v", g.ID, err)
+				return nil, err
+			}
+			res.InvoiceID = b.InvoiceID
+			s.sendInvoice(g, b)
+		}
+	}
+
+	created, err := s.repo.GetGroup(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	res.GroupResponse = created.ToResponse()
+	log.Printf("Service: %s group %s created with primary member %s by user %s", kind, g.ID, primary.ID, userID)
+	return res, nil
+}
+
+// groupPlan returns the plan if it is a group plan offered to the member.
+func (s *serviceImpl) groupPlan(ctx context.Context, m *member.Member, planID uuid.UUID) (*plans.Plan, error) {
+	available, err := s.memberSvc.ListAvailablePlans(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	for _, plan := range available {
+		if plan.ID != planID {
+			continue
+		}
+		// Personal training packs do not grant gym access to share.
+		if plan.MaxSeats == nil || (plan.SessionCredits != nil && *plan.SessionCredits > 0) {
+			return nil, ErrNotGroupPlan
+		}
+		return plan, nil
+	}
+	return nil, ErrPlanUnavailable
+}
+
+// startFamilySubscription sends the primary a checkout for the plan, unless
+// they already hold it.
+func (s *serviceImpl) startFamilySubscription(ctx context.Context, primary *member.Member, plan *plans.Plan, startDate time.Time, res *CreateGroupResponse) error {
+	if current, err := s.subSvc.GetActiveSubscription(ctx, primary.ID); err == nil && current.PlanID != nil && *current.PlanID == plan.ID {
+		res.SubscriptionID = &current.ID
+		return nil
+	}
+
+	// past_due keeps the group from checking in until the plan is paid.
+	status := string(subscription.StatusPastDue)
+	sub, err := s.subSvc.CreateSubscription(ctx, &subscription.CreateSubscriptionRequest{
+		MemberID:  primary.ID,
+		PlanID:    &plan.ID,
+		BranchID:  primary.HomeBranchID,
+		StartDate: startDate.Format(dateLayout),
+		Status:    &status,
+	}, "group")
+	if err != nil {
+		return err
+	}
+	res.SubscriptionID = &sub.ID
+	res.InvoiceID = sub.InvoiceID
+	res.CheckoutURL = sub.CheckoutURL
+	return nil
+}
+
+func (s *serviceImpl) UpdateGroup(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateGroupRequest) (*Group, error) {
+	g, _, err := s.group(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		g.Name = *req.Name
+	}
+	if req.BillingEmail != nil {
+		g.BillingEmail = req.BillingEmail
+	}
+	if err := s.repo.UpdateGroup(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *serviceImpl) DeleteGroup(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) error {
+	g, _, err := s.group(ctx, userID, userRole, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteGroup(ctx, g.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrGroupNotFound
+		}
+		return err
+	}
+	log.Printf("Service: Group %s dissolved by user %s", g.ID, userID)
+	return nil
+}
+
+func (s *serviceImpl) AddMember(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *AddMemberRequest) (*Seat, error) {
+	g, sc, err := s.group(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.member(ctx, sc, req.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	if m.OrganizationID != g.OrganizationID {
+		return nil, ErrMemberNotFound
+	}
+	if g.MaxSeats == nil {
+		return nil, ErrNotGroupPlan
+	}
+
+	seat, err := s.repo.AddSeat(ctx, g.ID, m.ID, *g.MaxSeats)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrSeatLimitReached
+	case isUniqueViolation(err):
+		return nil, ErrAlreadyInGroup
+	case err != nil:
+		return nil, err
+	}
+	seat.MemberName = m.FirstName + " " + m.LastName
+	log.Printf("Service: Member %s added to group %s by user %s", m.ID, g.ID, userID)
+	return seat, nil
+}
+
+func (s *serviceImpl) RemoveMember(ctx context.Context, userID uuid.UUID, userRole string, id, memberID uuid.UUID) error {
+	g, _, err := s.group(ctx, userID, userRole, id)
+	if err != nil {
+		return err
+	}
+	if memberID == g.PrimaryMemberID {
+		return ErrPrimarySeat
+	}
+	if err := s.repo.RemoveSeat(ctx, g.ID, memberID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	log.Printf("Service: Member %s removed from group %s by user %s", memberID, g.ID, userID)
+	return nil
+}
+
+func (s *serviceImpl) ListBillings(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) ([]*Billing, error) {
+	g, _, err := s.group(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if g.Kind != KindCorporate {
+		return nil, ErrNotCorporateGroup
+	}
+	return s.repo.ListBillings(ctx, g.ID)
+}
+
+func (s *serviceImpl) GetMyGroup(ctx context.Context, userID uuid.UUID) (*GroupDetailResponse, error) {
+	m, err := s.memberSvc.GetMemberByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+	g, err := s.repo.GetGroupByMember(ctx, m.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.PrimaryMemberID != m.ID {
+		return &GroupDetailResponse{GroupResponse: g.ToResponse()}, nil
+	}
+	return s.detail(ctx, g)
+}
+
+func (s *serviceImpl) BillDueGroups(ctx context.Context) (int, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	ids, err := s.repo.ListDueGroupIDs(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	billed := 0
+	for _, id := range ids {
+		b, err := s.repo.BillGroup(ctx, id, today)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Billed or dissolved since it was listed.
+			continue
+		}
+		if err != nil {
+			log.Printf("Service: BillDueGroups failed for group %s: %v", id, err)
+			continue
+		}
+		billed++
+		if g, err := s.repo.GetGroup(ctx, id); err == nil {
+			s.sendInvoice(g, b)
+		}
+	}
+	if billed > 0 {
+		log.Printf("Service: BillDueGroups billed %d corporate group months", billed)
+	}
+	return billed, nil
+}
+
+func (s *serviceImpl) detail(ctx context.Context, g *Group) (*GroupDetailResponse, error) {
+	seats, err := s.repo.ListSeats(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*SeatResponse, len(seats))
+	for i, seat := range seats {
+		members[i] = seat.ToResponse()
+	}
+	return &GroupDetailResponse{GroupResponse: g.ToResponse(), Members: members}, nil
+}
+
+// sendInvoice mails a corporate invoice to the group's billing address.
+func (s *serviceImpl) sendInvoice(g *Group, b *Billing) {
+	if s.emailSvc == nil || g.BillingEmail == nil || b.InvoiceNumber == nil || b.Amount == nil {
+		return
+	}
+	period := fmt.Sprintf("%s to %s", b.PeriodStart.Format(dateLayout), b.PeriodEnd.Format(dateLayout))
+	due := b.PeriodStart.AddDate(0, 0, invoiceDueDays).Format(dateLayout)
+	subject := fmt.Sprintf("Invoice %s for %s", *b.InvoiceNumber, g.Name)
+	text := fmt.Sprintf("Invoice %s: %d seats on %s from %s at %.2f each, %.2f in total, due %s.",
+		*b.InvoiceNumber, b.Seats, g.PlanName, period, b.SeatPrice, *b.Amount, due)
+	body := fmt.Sprintf("<p>Invoice <strong>%s</strong> for %s</p><p>%d seats on %s from %s at %.2f each.</p><p>Total: <strong>%.2f</strong>, due %s.</p>",
+		html.EscapeString(*b.InvoiceNumber), html.EscapeString(g.Name), b.Seats, html.EscapeString(g.PlanName), period, b.SeatPrice, *b.Amount, due)
+	to := *g.BillingEmail
+	go func() {
+		if err := s.emailSvc.SendEmail(context.Background(), to, subject, body, text); err != nil {
+			log.Printf("Service: Async invoice email failed for group %s: %v", g.ID, err)
+		}
+	}()
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,77 @@
+package hours
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OpeningHoursRequest struct {
+	DayOfWeek int    `json:"dayOfWeek" validate:"min=0,max=6"`
+	OpenTime  string `json:"openTime" validate:"required"`
+	CloseTime string `json:"closeTime" validate:"required"`
+}
+
+// SetOpeningHoursRequest replaces the whole weekly schedule. An empty list
+// makes the branch open 24/7.
+type SetOpeningHoursRequest struct {
+	Hours []OpeningHoursRequest `json:"hours" validate:"dive"`
+}
+
+type CreateClosureRequest struct {
+	ClosureDate string  `json:"closureDate" validate:"required"`
+	Reason      *string `json:"reason,omitempty"`
+}
+
+type AccessWindowRequest struct {
+	DayOfWeek *int   `json:"dayOfWeek,omitempty" validate:"omitempty,min=0,max=6"`
+	StartTime string `json:"startTime" validate:"required"`
+	EndTime   string `json:"endTime" validate:"required"`
+}
+
+// SetAccessWindowsRequest replaces all access windows for a plan. An empty
+// list removes the restriction.
+type SetAccessWindowsRequest struct {
+	Windows []AccessWindowRequest `json:"windows" validate:"dive"`
+}
+
+type OpeningHoursResponse struct {
+	DayOfWeek int    `json:"dayOfWeek"`
+	OpenTime  string `json:"openTime"`
+	CloseTime string `json:"closeTime"`
+}
+
+type ClosureResponse struct {
+	ID          uuid.UUID `json:"id"`
+	BranchID    uuid.UUID `json:"branchId"`
+	ClosureDate string    `json:"closureDate"`
+	Reason      *string   `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AccessWindowResponse struct {
+	DayOfWeek *int   `json:"dayOfWeek,omitempty"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+}
+
+type BranchScheduleResponse struct {
+	BranchID uuid.UUID               `json:"branchId"`
+	Timezone string                  `json:"timezone"`
+	Hours    []*OpeningHoursResponse `json:"hours"`
+	Closures []*ClosureResponse      `json:"closures"`
+}
+
+type BranchStatusResponse struct {
+	BranchID    uuid.UUID  `json:"branchId"`
+	Timezone    string     `json:"timezone"`
+	IsOpen      bool       `json:"isOpen"`
+	Reason      *string    `json:"reason,omitempty"`
+	ClosesAt    *time.Time `json:"closesAt,omitempty"`
+	NextOpening *time.Time `json:"nextOpening,omitempty"`
+	CheckedAt   time.Time  `json:"checkedAt"`
+}
+
+type AutoCheckoutResponse struct {
+	CheckedOut int `json:"checkedOut"`
+}
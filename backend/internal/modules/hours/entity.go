@@ -0,0 +1,61 @@
+package hours
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OpeningHours is one open interval on a weekday, in branch local time.
+// Times are formatted HH:MM. day_of_week follows time.Weekday (0 = Sunday).
+type OpeningHours struct {
+	ID        uuid.UUID `db:"id"`
+	BranchID  uuid.UUID `db:"branch_id"`
+	DayOfWeek int       `db:"day_of_week"`
+	OpenTime  string    `db:"open_time"`
+	CloseTime string    `db:"close_time"`
+}
+
+type Closure struct {
+	ID          uuid.UUID `db:"id"`
+	BranchID    uuid.UUID `db:"branch_id"`
+	ClosureDate time.Time `db:"closure_date"`
+	Reason      *string   `db:"reason"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// AccessWindow limits when members on a plan may check in. A nil DayOfWeek
+// applies every day.
+type AccessWindow struct {
+	ID        uuid.UUID `db:"id"`
+	PlanID    uuid.UUID `db:"plan_id"`
+	DayOfWeek *int      `db:"day_of_week"`
+	StartTime string    `db:"start_time"`
+	EndTime   string    `db:"end_time"`
+}
+
+func (o *OpeningHours) ToResponse() *OpeningHoursResponse {
+	return &OpeningHoursResponse{
+		DayOfWeek: o.DayOfWeek,
+		OpenTime:  o.OpenTime,
+		CloseTime: o.CloseTime,
+	}
+}
+
+func (c *Closure) ToResponse() *ClosureResponse {
+	return &ClosureResponse{
+		ID:          c.ID,
+		BranchID:    c.BranchID,
+		ClosureDate: c.ClosureDate.Format(dateLayout),
+		Reason:      c.Reason,
+		CreatedAt:   c.CreatedAt,
+	}
+}
+
+func (a *AccessWindow) ToResponse() *AccessWindowResponse {
+	return &AccessWindowResponse{
+		DayOfWeek: a.DayOfWeek,
+		StartTime: a.StartTime,
+		EndTime:   a.EndTime,
+	}
+}
@@ -0,0 +1,245 @@
+package hours
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/hours", func(r chi.Router) {
+		// Public: used by the website and kiosk screens
+		r.Get("/{branchId}/status", h.GetStatus)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware)
+			r.Get("/{branchId}", h.GetSchedule)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+				r.Put("/{branchId}/opening-hours", h.SetOpeningHours)
+				r.Post("/{branchId}/closures", h.CreateClosure)
+				r.Delete("/{branchId}/closures/{closureId}", h.DeleteClosure)
+				r.Get("/plans/{planId}/access-windows", h.GetAccessWindows)
+				r.Put("/plans/{planId}/access-windows", h.SetAccessWindows)
+				r.Post("/auto-checkout", h.AutoCheckout)
+			})
+		})
+	})
+}
+
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	status, err := h.service.GetStatus(r.Context(), branchID, time.Now())
+	if err != nil {
+		if err == ErrBranchNotFound {
+			response.NotFound(w, "Branch not found")
+			return
+		}
+		response.InternalServerError(w, "Failed to get branch status")
+		return
+	}
+
+	response.Success(w, "Branch status retrieved successfully", status)
+}
+
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	schedule, err := h.service.GetSchedule(r.Context(), branchID)
+	if err != nil {
+		if err == ErrBranchNotFound {
+			response.NotFound(w, "Branch not found")
+			return
+		}
+		response.InternalServerError(w, "Failed to get branch schedule")
+		return
+	}
+
+	response.Success(w, "Branch schedule retrieved successfully", schedule)
+}
+
+func (h *Handler) SetOpeningHours(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	var req SetOpeningHoursRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	hours, err := h.service.SetOpeningHours(r.Context(), branchID, &req)
+	if err != nil {
+		switch err {
+		case ErrBranchNotFound:
+			response.NotFound(w, "Branch not found")
+		case ErrInvalidTimeRange:
+			response.BadRequest(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to update opening hours")
+		}
+		return
+	}
+
+	responses := make([]*OpeningHoursResponse, 0, len(hours))
+	for _, oh := range hours {
+		responses = append(responses, oh.ToResponse())
+	}
+	response.Success(w, "Opening hours updated successfully", responses)
+}
+
+func (h *Handler) CreateClosure(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	var req CreateClosureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	closure, err := h.service.CreateClosure(r.Context(), branchID, &req)
+	if err != nil {
+		switch err {
+		case ErrBranchNotFound:
+			response.NotFound(w, "Branch not found")
+		case ErrInvalidDate:
+			response.BadRequest(w, err.Error(), nil)
+		case ErrClosureExists:
+			response.Conflict(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to create closure")
+		}
+		return
+	}
+
+	response.Success(w, "Closure created successfully", closure.ToResponse())
+}
+
+func (h *Handler) DeleteClosure(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	closureID, err := uuid.Parse(chi.URLParam(r, "closureId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid closure ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteClosure(r.Context(), branchID, closureID); err != nil {
+		if errors.Is(err, ErrClosureNotFound) {
+			response.NotFound(w, "Closure not found")
+			return
+		}
+		response.InternalServerError(w, "Failed to delete closure")
+		return
+	}
+
+	response.OK(w, "Closure deleted successfully")
+}
+
+func (h *Handler) GetAccessWindows(w http.ResponseWriter, r *http.Request) {
+	planID, err := uuid.Parse(chi.URLParam(r, "planId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid plan ID", nil)
+		return
+	}
+
+	windows, err := h.service.GetAccessWindows(r.Context(), planID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to get access windows")
+		return
+	}
+
+	responses := make([]*AccessWindowResponse, 0, len(windows))
+	for _, window := range windows {
+		responses = append(responses, window.ToResponse())
+	}
+	response.Success(w, "Access windows retrieved successfully", responses)
+}
+
+func (h *Handler) SetAccessWindows(w http.ResponseWriter, r *http.Request) {
+	planID, err := uuid.Parse(chi.URLParam(r, "planId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid plan ID", nil)
+		return
+	}
+
+	var req SetAccessWindowsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	windows, err := h.service.SetAccessWindows(r.Context(), planID, &req)
+	if err != nil {
+		if err == ErrInvalidTimeRange {
+			response.BadRequest(w, err.Error(), nil)
+			return
+		}
+		response.InternalServerError(w, "Failed to update access windows")
+		return
+	}
+
+	responses := make([]*AccessWindowResponse, 0, len(windows))
+	for _, window := range windows {
+		responses = append(responses, window.ToResponse())
+	}
+	response.Success(w, "Access windows updated successfully", responses)
+}
+
+func (h *Handler) AutoCheckout(w http.ResponseWriter, r *http.Request) {
+	count, err := h.service.AutoCheckout(r.Context())
+	if err != nil {
+		response.InternalServerError(w, "Failed to run auto-checkout")
+		return
+	}
+
+	response.Success(w, "Auto-checkout completed", &AutoCheckoutResponse{CheckedOut: count})
+}
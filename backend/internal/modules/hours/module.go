@@ -0,0 +1,31 @@
+package hours
+
+import (
+	"fitcore/internal/modules/cache"
+	"fitcore/internal/modules/occupancy"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, occupancySvc occupancy.Service, cacheSvc cache.Service) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, occupancySvc, cacheSvc)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
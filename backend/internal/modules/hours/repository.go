@@ -0,0 +1,231 @@
+package hours
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetBranchTimezone(ctx context.Context, branchID uuid.UUID) (string, error)
+	ListOpeningHours(ctx context.Context, branchID uuid.UUID) ([]*OpeningHours, error)
+	ReplaceOpeningHours(ctx context.Context, branchID uuid.UUID, hours []*OpeningHours) error
+	ListClosures(ctx context.Context, branchID uuid.UUID, from, to time.Time) ([]*Closure, error)
+	CreateClosure(ctx context.Context, closure *Closure) error
+	DeleteClosure(ctx context.Context, branchID, id uuid.UUID) error
+	ListAccessWindows(ctx context.Context, planID uuid.UUID) ([]*AccessWindow, error)
+	ReplaceAccessWindows(ctx context.Context, planID uuid.UUID, windows []*AccessWindow) error
+	ListBranchesWithOpenCheckIns(ctx context.Context) ([]uuid.UUID, error)
+	CheckOutAll(ctx context.Context, branchID uuid.UUID, at time.Time) ([]uuid.UUID, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+func (r *repositoryImpl) GetBranchTimezone(ctx context.Context, branchID uuid.UUID) (string, error) {
+	query := `SELECT COALESCE(timezone, 'UTC') FROM branches WHERE id = $1 AND deleted_at IS NULL`
+	var timezone string
+	err := r.db.QueryRow(ctx, query, branchID).Scan(&timezone)
+	return timezone, err
+}
+
+func (r *repositoryImpl) ListOpeningHours(ctx context.Context, branchID uuid.UUID) ([]*OpeningHours, error) {
+	query := `
+		SELECT id, branch_id, day_of_week, to_char(open_time, 'HH24:MI'), to_char(close_time, 'HH24:MI')
+		FROM branch_opening_hours
+		WHERE branch_id = $1
+		ORDER BY day_of_week, open_time
+	`
+	rows, err := r.db.Query(ctx, query, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hours []*OpeningHours
+	for rows.Next() {
+		var h OpeningHours
+		if err := rows.Scan(&h.ID, &h.BranchID, &h.DayOfWeek, &h.OpenTime, &h.CloseTime); err != nil {
+			return nil, err
+		}
+		hours = append(hours, &h)
+	}
+	return hours, rows.Err()
+}
+
+func (r *repositoryImpl) ReplaceOpeningHours(ctx context.Context, branchID uuid.UUID, hours []*OpeningHours) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM branch_opening_hours WHERE branch_id = $1`, branchID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO branch_opening_hours (branch_id, day_of_week, open_time, close_time)
+		VALUES ($1, $2, $3::time, $4::time)
+	`
+	for _, h := range hours {
+		if _, err := tx.Exec(ctx, query, branchID, h.DayOfWeek, h.OpenTime, h.CloseTime); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) ListClosures(ctx context.Context, branchID uuid.UUID, from, to time.Time) ([]*Closure, error) {
+	query := `
+		SELECT id, branch_id, closure_date, reason, created_at
+		FROM branch_closures
+		WHERE branch_id = $1 AND closure_date BETWEEN $2::date AND $3::date
+		ORDER BY closure_date
+	`
+	rows, err := r.db.Query(ctx, query, branchID, from.Format(dateLayout), to.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var closures []*Closure
+	for rows.Next() {
+		var c Closure
+		if err := rows.Scan(&c.ID, &c.BranchID, &c.ClosureDate, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		closures = append(closures, &c)
+	}
+	return closures, rows.Err()
+}
+
+func (r *repositoryImpl) CreateClosure(ctx context.Context, closure *Closure) error {
+	query := `
+		INSERT INTO branch_closures (branch_id, closure_date, reason)
+		VALUES ($1, $2::date, $3)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		closure.BranchID,
+		closure.ClosureDate.Format(dateLayout),
+		closure.Reason,
+	).Scan(&closure.ID, &closure.CreatedAt)
+}
+
+func (r *repositoryImpl) DeleteClosure(ctx context.Context, branchID, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM branch_closures WHERE id = $1 AND branch_id = $2`, id, branchID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *repositoryImpl) ListAccessWindows(ctx context.Context, planID uuid.UUID) ([]*AccessWindow, error) {
+	query := `
+		SELECT id, plan_id, day_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM plan_access_windows
+		WHERE plan_id = $1
+		ORDER BY day_of_week NULLS FIRST, start_time
+	`
+	rows, err := r.db.Query(ctx, query, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []*AccessWindow
+	for rows.Next() {
+		var w AccessWindow
+		if err := rows.Scan(&w.ID, &w.PlanID, &w.DayOfWeek, &w.StartTime, &w.EndTime); err != nil {
+			return nil, err
+		}
+		windows = append(windows, &w)
+	}
+	return windows, rows.Err()
+}
+
+func (r *repositoryImpl) ReplaceAccessWindows(ctx context.Context, planID uuid.UUID, windows []*AccessWindow) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM plan_access_windows WHERE plan_id = $1`, planID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO plan_access_windows (plan_id, day_of_week, start_time, end_time)
+		VALUES ($1, $2, $3::time, $4::time)
+	`
+	for _, w := range windows {
+		if _, err := tx.Exec(ctx, query, planID, w.DayOfWeek, w.StartTime, w.EndTime); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) ListBranchesWithOpenCheckIns(ctx context.Context) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT branch_id
+		FROM check_ins
+		WHERE check_out_time IS NULL AND deleted_at IS NULL
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var branchIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		branchIDs = append(branchIDs, id)
+	}
+	return branchIDs, rows.Err()
+}
+
+// CheckOutAll closes every open session at the branch and returns the
+// affected member IDs.
+func (r *repositoryImpl) CheckOutAll(ctx context.Context, branchID uuid.UUID, at time.Time) ([]uuid.UUID, error) {
+	query := `
+		UPDATE check_ins
+		SET check_out_time = $2
+		WHERE branch_id = $1 AND check_out_time IS NULL AND deleted_at IS NULL
+		RETURNING member_id
+	`
+	rows, err := r.db.Query(ctx, query, branchID, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		memberIDs = append(memberIDs, id)
+	}
+	return memberIDs, rows.Err()
+}
@@ -0,0 +1,457 @@
+package hours
+
+import (
+	"context"
+	"errors"
+	"fitcore/internal/modules/cache"
+	"fitcore/internal/modules/occupancy"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04"
+
+	// lookaheadDays bounds the search for the next opening.
+	lookaheadDays = 14
+
+	minutesPerDay = 24 * 60
+)
+
+var (
+	ErrBranchNotFound      = errors.New("branch not found")
+	ErrClosureNotFound     = errors.New("closure not found")
+	ErrClosureExists       = errors.New("a closure already exists for this date")
+	ErrInvalidTimeRange    = errors.New("times must be HH:MM; an end at or before the start runs into the next day")
+	ErrInvalidDate         = errors.New("date must be YYYY-MM-DD")
+	ErrBranchClosed        = errors.New("branch is closed")
+	ErrOutsideAccessWindow = errors.New("plan does not allow access at this time")
+)
+
+type Service interface {
+	GetSchedule(ctx context.Context, branchID uuid.UUID) (*BranchScheduleResponse, error)
+	SetOpeningHours(ctx context.Context, branchID uuid.UUID, req *SetOpeningHoursRequest) ([]*OpeningHours, error)
+	CreateClosure(ctx context.Context, branchID uuid.UUID, req *CreateClosureRequest) (*Closure, error)
+	DeleteClosure(ctx context.Context, branchID, id uuid.UUID) error
+	GetAccessWindows(ctx context.Context, planID uuid.UUID) ([]*AccessWindow, error)
+	SetAccessWindows(ctx context.Context, planID uuid.UUID, req *SetAccessWindowsRequest) ([]*AccessWindow, error)
+	GetStatus(ctx context.Context, branchID uuid.UUID, at time.Time) (*BranchStatusResponse, error)
+	CheckAccess(ctx context.Context, branchID uuid.UUID, planID *uuid.UUID, at time.Time) error
+	AutoCheckout(ctx context.Context) (int, error)
+}
+
+type serviceImpl struct {
+	repo         Repository
+	occupancySvc occupancy.Service
+	cacheSvc     cache.Service
+}
+
+func NewService(repo Repository, occupancySvc occupancy.Service, cacheSvc cache.Service) Service {
+	return &serviceImpl{repo: repo, occupancySvc: occupancySvc, cacheSvc: cacheSvc}
+}
+
+func (s *serviceImpl) GetSchedule(ctx context.Context, branchID uuid.UUID) (*BranchScheduleResponse, error) {
+	timezone, err := s.repo.GetBranchTimezone(ctx, branchID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBranchNotFound
+		}
+		return nil, err
+	}
+
+	hours, err := s.repo.ListOpeningHours(ctx, branchID)
+	if err != nil {
+		log.Printf("Service: GetSchedule failed - list opening hours for branch %s: %v", branchID, err)
+		return nil, err
+	}
+
+	today := time.Now().In(loadLocation(timezone))
+	closures, err := s.repo.ListClosures(ctx, branchID, today, today.AddDate(1, 0, 0))
+	if err != nil {
+		log.Printf("Service: GetSchedule failed - list closures for branch %s: %v", branchID, err)
+		return nil, err
+	}
+
+	res := &BranchScheduleResponse{
+		BranchID: branchID,
+		Timezone: timezone,
+		Hours:    make([]*OpeningHoursResponse, 0, len(hours)),
+		Closures: make([]*ClosureResponse, 0, len(closures)),
+	}
+	for _, h := range hours {
+		res.Hours = append(res.Hours, h.ToResponse())
+	}
+	for _, c := range closures {
+		res.Closures = append(res.Closures, c.ToResponse())
+	}
+	return res, nil
+}
+
+func (s *serviceImpl) SetOpeningHours(ctx context.Context, branchID uuid.UUID, req *SetOpeningHoursRequest) ([]*OpeningHours, error) {
+	if _, err := s.repo.GetBranchTimezone(ctx, branchID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBranchNotFound
+		}
+		return nil, err
+	}
+
+	hours := make([]*OpeningHours, 0, len(req.Hours))
+	for _, h := range req.Hours {
+		if _, _, err := parseRange(h.OpenTime, h.CloseTime); err != nil {
+			return nil, err
+		}
+		hours = append(hours, &OpeningHours{
+			BranchID:  branchID,
+			DayOfWeek: h.DayOfWeek,
+			OpenTime:  h.OpenTime,
+			CloseTime: h.CloseTime,
+		})
+	}
+
+	if err := s.repo.ReplaceOpeningHours(ctx, branchID, hours); err != nil {
+		log.Printf("Service: SetOpeningHours failed for branch %s: %v", branchID, err)
+		return nil, err
+	}
+	return s.repo.ListOpeningHours(ctx, branchID)
+}
+
+func (s *serviceImpl) CreateClosure(ctx context.Context, branchID uuid.UUID, req *CreateClosureRequest) (*Closure, error) {
+	date, err := time.Parse(dateLayout, req.ClosureDate)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+
+	if _, err := s.repo.GetBranchTimezone(ctx, branchID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBranchNotFound
+		}
+		return nil, err
+	}
+
+	closure := &Closure{
+		BranchID:    branchID,
+		ClosureDate: date,
+		Reason:      req.Reason,
+	}
+	if err := s.repo.CreateClosure(ctx, closure); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrClosureExists
+		}
+		log.Printf("Service: CreateClosure failed for branch %s: %v", branchID, err)
+		return nil, err
+	}
+	return closure, nil
+}
+
+func (s *serviceImpl) DeleteClosure(ctx context.Context, branchID, id uuid.UUID) error {
+	if err := s.repo.DeleteClosure(ctx, branchID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrClosureNotFound
+		}
+		log.Printf("Service: DeleteClosure failed for closure %s: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func (s *serviceImpl) GetAccessWindows(ctx context.Context, planID uuid.UUID) ([]*AccessWindow, error) {
+	return s.repo.ListAccessWindows(ctx, planID)
+}
+
+func (s *serviceImpl) SetAccessWindows(ctx context.Context, planID uuid.UUID, req *SetAccessWindowsRequest) ([]*AccessWindow, error) {
+	windows := make([]*AccessWindow, 0, len(req.Windows))
+	for _, w := range req.Windows {
+		if _, _, err := parseRange(w.StartTime, w.EndTime); err != nil {
+			return nil, err
+		}
+		windows = append(windows, &AccessWindow{
+			PlanID:    planID,
+			DayOfWeek: w.DayOfWeek,
+			StartTime: w.StartTime,
+			EndTime:   w.EndTime,
+		})
+	}
+
+	if err := s.repo.ReplaceAccessWindows(ctx, planID, windows); err != nil {
+		log.Printf("Service: SetAccessWindows failed for plan %s: %v", planID, err)
+		return nil, err
+	}
+	return s.repo.ListAccessWindows(ctx, planID)
+}
+
+func (s *serviceImpl) GetStatus(ctx context.Context, branchID uuid.UUID, at time.Time) (*BranchStatusResponse, error) {
+	sched, err := s.loadSchedule(ctx, branchID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BranchStatusResponse{
+		BranchID:  branchID,
+		Timezone:  sched.loc.String(),
+		CheckedAt: at.In(sched.loc),
+	}
+	res.IsOpen, res.ClosesAt, res.Reason = sched.status(at)
+	if !res.IsOpen {
+		res.NextOpening = sched.nextOpening(at)
+	}
+	return res, nil
+}
+
+// CheckAccess decides whether a member on planID may enter the branch at the
+// given time: the branch must be open and, if the plan has access windows,
+// the time must fall inside one of them.
+func (s *serviceImpl) CheckAccess(ctx context.Context, branchID uuid.UUID, planID *uuid.UUID, at time.Time) error {
+	sched, err := s.loadSchedule(ctx, branchID, at)
+	if err != nil {
+		return err
+	}
+
+	if open, _, _ := sched.status(at); !open {
+		if next := sched.nextOpening(at); next != nil {
+			return fmt.Errorf("%w, next opening %s", ErrBranchClosed, next.Format(time.RFC1123))
+		}
+		return ErrBranchClosed
+	}
+
+	if planID == nil {
+		return nil
+	}
+
+	windows, err := s.repo.ListAccessWindows(ctx, *planID)
+	if err != nil {
+		log.Printf("Service: CheckAccess failed - list access windows for plan %s: %v", *planID, err)
+		return err
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+
+	local := at.In(sched.loc)
+	minute := minuteOfDay(local)
+	today := int(local.Weekday())
+	yesterday := (today + 6) % 7
+	for _, w := range windows {
+		start, end, err := parseRange(w.StartTime, w.EndTime)
+		if err != nil {
+			continue
+		}
+		if (w.DayOfWeek == nil || *w.DayOfWeek == today) && minute >= start && minute < end {
+			return nil
+		}
+		// An overnight window from the day before still covers the small hours.
+		if (w.DayOfWeek == nil || *w.DayOfWeek == yesterday) && minute+minutesPerDay < end {
+			return nil
+		}
+	}
+	return ErrOutsideAccessWindow
+}
+
+// AutoCheckout closes open sessions at every branch that is currently
+// closed, so nobody stays "inside" overnight or through a holiday. A branch
+// that is still inside the previous day's overnight hours counts as open.
+func (s *serviceImpl) AutoCheckout(ctx context.Context) (int, error) {
+	branchIDs, err := s.repo.ListBranchesWithOpenCheckIns(ctx)
+	if err != nil {
+		log.Printf("Service: AutoCheckout failed - list branches with open check-ins: %v", err)
+		return 0, err
+	}
+
+	now := time.Now()
+	total := 0
+	for _, branchID := range branchIDs {
+		sched, err := s.loadSchedule(ctx, branchID, now)
+		if err != nil {
+			log.Printf("Service: AutoCheckout skipped branch %s: %v", branchID, err)
+			continue
+		}
+		if open, _, _ := sched.status(now); open {
+			continue
+		}
+
+		memberIDs, err := s.repo.CheckOutAll(ctx, branchID, now)
+		if err != nil {
+			log.Printf("Service: AutoCheckout failed for branch %s: %v", branchID, err)
+			continue
+		}
+		for _, memberID := range memberIDs {
+			s.occupancySvc.PublishCheckOut(ctx, branchID, memberID)
+			if err := s.cacheSvc.InvalidateTag(ctx, cache.MemberTag(memberID)); err != nil {
+				log.Printf("Service: AutoCheckout failed to invalidate cache for member %s: %v", memberID, err)
+			}
+		}
+		if len(memberIDs) > 0 {
+			log.Printf("Service: AutoCheckout closed %d session(s) at branch %s", len(memberIDs), branchID)
+		}
+		total += len(memberIDs)
+	}
+	return total, nil
+}
+
+func (s *serviceImpl) loadSchedule(ctx context.Context, branchID uuid.UUID, at time.Time) (*schedule, error) {
+	timezone, err := s.repo.GetBranchTimezone(ctx, branchID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBranchNotFound
+		}
+		return nil, err
+	}
+	loc := loadLocation(timezone)
+
+	hours, err := s.repo.ListOpeningHours(ctx, branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	local := at.In(loc)
+	closures, err := s.repo.ListClosures(ctx, branchID, local.AddDate(0, 0, -1), local.AddDate(0, 0, lookaheadDays))
+	if err != nil {
+		return nil, err
+	}
+
+	return newSchedule(loc, hours, closures), nil
+}
+
+// schedule evaluates opening hours and closures for one branch in its own
+// timezone. Intervals are stored as minutes since local midnight; an
+// overnight interval ends past minutesPerDay and spills into the next day.
+type schedule struct {
+	loc      *time.Location
+	hours    map[time.Weekday][][2]int
+	closures map[string]*string
+}
+
+func newSchedule(loc *time.Location, hours []*OpeningHours, closures []*Closure) *schedule {
+	sched := &schedule{
+		loc:      loc,
+		hours:    make(map[time.Weekday][][2]int),
+		closures: make(map[string]*string),
+	}
+	for _, h := range hours {
+		start, end, err := parseRange(h.OpenTime, h.CloseTime)
+		if err != nil {
+			continue
+		}
+		day := time.Weekday(h.DayOfWeek)
+		sched.hours[day] = append(sched.hours[day], [2]int{start, end})
+	}
+	for day := range sched.hours {
+		sort.Slice(sched.hours[day], func(i, j int) bool { return sched.hours[day][i][0] < sched.hours[day][j][0] })
+	}
+	for _, c := range closures {
+		sched.closures[c.ClosureDate.Format(dateLayout)] = c.Reason
+	}
+	return sched
+}
+
+// alwaysOpen is true for branches that never configured opening hours.
+func (sc *schedule) alwaysOpen() bool {
+	return len(sc.hours) == 0
+}
+
+func (sc *schedule) status(at time.Time) (bool, *time.Time, *string) {
+	local := at.In(sc.loc)
+	minute := minuteOfDay(local)
+
+	// The tail of last night's hours belongs to the day they opened on, so
+	// only a closure on that day cuts it short.
+	yesterday := local.AddDate(0, 0, -1)
+	if _, closed := sc.closures[yesterday.Format(dateLayout)]; !closed {
+		for _, interval := range sc.hours[yesterday.Weekday()] {
+			if minute+minutesPerDay < interval[1] {
+				closesAt := atMinute(yesterday, interval[1])
+				return true, &closesAt, nil
+			}
+		}
+	}
+
+	if reason, closed := sc.closures[local.Format(dateLayout)]; closed {
+		if reason == nil {
+			fallback := "Closed today"
+			reason = &fallback
+		}
+		return false, nil, reason
+	}
+
+	if sc.alwaysOpen() {
+		return true, nil, nil
+	}
+
+	for _, interval := range sc.hours[local.Weekday()] {
+		if minute >= interval[0] && minute < interval[1] {
+			closesAt := atMinute(local, interval[1])
+			return true, &closesAt, nil
+		}
+	}
+	return false, nil, nil
+}
+
+func (sc *schedule) nextOpening(at time.Time) *time.Time {
+	local := at.In(sc.loc)
+	for offset := 0; offset <= lookaheadDays; offset++ {
+		day := local.AddDate(0, 0, offset)
+		if _, closed := sc.closures[day.Format(dateLayout)]; closed {
+			continue
+		}
+		if sc.alwaysOpen() {
+			opening := atMinute(day, 0)
+			return &opening
+		}
+		for _, interval := range sc.hours[day.Weekday()] {
+			opening := atMinute(day, interval[0])
+			if opening.After(local) {
+				return &opening
+			}
+		}
+	}
+	return nil
+}
+
+func loadLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Printf("Service: unknown timezone %q, falling back to UTC", timezone)
+		return time.UTC
+	}
+	return loc
+}
+
+// parseRange turns an HH:MM range into minutes since midnight. The end may
+// be 24:00, and an end at or before the start runs into the next day, so
+// 06:00-02:00 yields 360 and 1560.
+func parseRange(start, end string) (int, int, error) {
+	s, err := time.Parse(timeLayout, start)
+	if err != nil {
+		return 0, 0, ErrInvalidTimeRange
+	}
+	from := minuteOfDay(s)
+
+	to := minutesPerDay
+	if end != "24:00" {
+		e, err := time.Parse(timeLayout, end)
+		if err != nil {
+			return 0, 0, ErrInvalidTimeRange
+		}
+		to = minuteOfDay(e)
+		if to <= from {
+			to += minutesPerDay
+		}
+	}
+	return from, to, nil
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+func atMinute(day time.Time, minute int) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, minute/60, minute%60, 0, 0, day.Location())
+}
@@ -0,0 +1,40 @@
+package imports
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemberImportRow is one CSV row. The json names are the canonical column
+// headers, so field errors point at the column to fix.
+type MemberImportRow struct {
+	FirstName     string `json:"first_name" validate:"required,max=255"`
+	LastName      string `json:"last_name" validate:"required,max=255"`
+	Email         string `json:"email" validate:"required,email,max=255"`
+	Phone         string `json:"phone" validate:"omitempty,max=50"`
+	DateOfBirth   string `json:"date_of_birth" validate:"omitempty,datetime=2006-01-02"`
+	BranchCode    string `json:"branch_code" validate:"required,max=50"`
+	PlanName      string `json:"plan_name" validate:"omitempty,max=255"`
+	StartDate     string `json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate       string `json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	PaymentStatus string `json:"payment_status" validate:"omitempty,oneof=paid unpaid"`
+}
+
+type MemberImportResponse struct {
+	ID             uuid.UUID    `json:"id"`
+	OrganizationID uuid.UUID    `json:"organizationId"`
+	Filename       string       `json:"filename"`
+	DryRun         bool         `json:"dryRun"`
+	Status         string       `json:"status"`
+	TotalRows      int          `json:"totalRows"`
+	ValidRows      int          `json:"validRows"`
+	InvalidRows    int          `json:"invalidRows"`
+	CreatedRows    int          `json:"createdRows"`
+	FailedRows     int          `json:"failedRows"`
+	Error          *string      `json:"error,omitempty"`
+	ResultsURL     string       `json:"resultsUrl"`
+	Rows           []*RowResult `json:"rows,omitempty"`
+	CompletedAt    *time.Time   `json:"completedAt,omitempty"`
+	CreatedAt      time.Time    `json:"createdAt"`
+}
@@ -0,0 +1,127 @@
+package imports
+
+import (
+	"time"
+
+	"fitcore/internal/response"
+
+	"github.com/google/uuid"
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusValidated ImportStatus = "validated"
+	ImportStatusRunning   ImportStatus = "running"
+	ImportStatusCompleted ImportStatus = "completed"
+	ImportStatusFailed    ImportStatus = "failed"
+)
+
+// Row outcomes. A dry run leaves rows valid or invalid; a real import turns
+// valid rows into created or failed and invalid rows into skipped.
+const (
+	RowValid   = "valid"
+	RowInvalid = "invalid"
+	RowCreated = "created"
+	RowFailed  = "failed"
+	RowSkipped = "skipped"
+)
+
+type MemberImport struct {
+	ID             uuid.UUID    `db:"id"`
+	UserID         uuid.UUID    `db:"user_id"`
+	OrganizationID uuid.UUID    `db:"organization_id"`
+	Filename       string       `db:"filename"`
+	DryRun         bool         `db:"dry_run"`
+	Status         ImportStatus `db:"status"`
+	TotalRows      int          `db:"total_rows"`
+	ValidRows      int          `db:"valid_rows"`
+	CreatedRows    int          `db:"created_rows"`
+	FailedRows     int          `db:"failed_rows"`
+	Results        []*RowResult `db:"results"`
+	Error          *string      `db:"error"`
+	CompletedAt    *time.Time   `db:"completed_at"`
+	CreatedAt      time.Time    `db:"created_at"`
+	UpdatedAt      time.Time    `db:"updated_at"`
+}
+
+// RowResult is the outcome of one CSV row; Row is the line number in the
+// file, header included.
+type RowResult struct {
+	Row            int                   `json:"row"`
+	Email          string                `json:"email"`
+	FirstName      string                `json:"firstName"`
+	LastName       string                `json:"lastName"`
+	Status         string                `json:"status"`
+	Errors         []response.FieldError `json:"errors,omitempty"`
+	MemberID       *uuid.UUID            `json:"memberId,omitempty"`
+	SubscriptionID *uuid.UUID            `json:"subscriptionId,omitempty"`
+}
+
+// ImportBranch and ImportPlan are the lookups rows are resolved against.
+type ImportBranch struct {
+	ID   uuid.UUID
+	Code string
+	Name string
+}
+
+type ImportPlan struct {
+	ID           uuid.UUID
+	Name         string
+	DurationDays int
+	BranchIDs    []uuid.UUID
+}
+
+// ExistingAccount is what is already registered under an imported email.
+type ExistingAccount struct {
+	Email     string
+	UserID    uuid.UUID
+	Role      string
+	HasMember bool
+}
+
+// ResolvedRow is a valid row with its references looked up, ready to insert.
+type ResolvedRow struct {
+	Result            *RowResult
+	FirstName         string
+	LastName          string
+	Email             string
+	Phone             *string
+	DateOfBirth       *time.Time
+	BranchID          uuid.UUID
+	MemberStatus      string
+	JoinDate          time.Time
+	EncryptedPassword string
+	// Subscription is nil when the row has no plan.
+	Subscription *ResolvedSubscription
+}
+
+type ResolvedSubscription struct {
+	PlanID    uuid.UUID
+	StartDate time.Time
+	EndDate   time.Time
+	Status    string
+}
+
+func (m *MemberImport) ToResponse(includeRows bool) *MemberImportResponse {
+	resp := &MemberImportResponse{
+		ID:             m.ID,
+		OrganizationID: m.OrganizationID,
+		Filename:       m.Filename,
+		DryRun:         m.DryRun,
+		Status:         string(m.Status),
+		TotalRows:      m.TotalRows,
+		ValidRows:      m.ValidRows,
+		InvalidRows:    m.TotalRows - m.ValidRows,
+		CreatedRows:    m.CreatedRows,
+		FailedRows:     m.FailedRows,
+		Error:          m.Error,
+		ResultsURL:     "/api/v1/imports/" + m.ID.String() + "/results",
+		CompletedAt:    m.CompletedAt,
+		CreatedAt:      m.CreatedAt,
+	}
+	if includeRows {
+		resp.Rows = m.Results
+	}
+	return resp
+}
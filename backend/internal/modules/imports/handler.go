@@ -0,0 +1,240 @@
+package imports
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+	"fitcore/pkg/export"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// maxUploadBytes bounds the multipart body of an import.
+const maxUploadBytes = 5 << 20
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/imports", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+
+		r.Get("/", h.ListImports)
+		r.Get("/members/template", h.Template)
+		r.Post("/members", h.ImportMembers)
+		r.Get("/{id}", h.GetImport)
+		r.Get("/{id}/results", h.DownloadResults)
+	})
+}
+
+// ImportMembers takes a multipart form with the CSV in file, organizationId
+// and dryRun. A dry run answers with the per-row report; otherwise the import
+// runs in the background and is answered with 202.
+func (h *Handler) ImportMembers(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		response.BadRequest(w, "File must be a multipart upload of at most 5 MB", nil)
+		return
+	}
+	organizationID, err := uuid.Parse(r.FormValue("organizationId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid organizationId", nil)
+		return
+	}
+	dryRun := false
+	if v := r.FormValue("dryRun"); v != "" {
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(w, "Invalid dryRun, use true or false", nil)
+			return
+		}
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.BadRequest(w, "A CSV file is required in the file field", nil)
+		return
+	}
+	defer file.Close()
+
+	imp, err := h.service.Import(r.Context(), userID, userRole, organizationID, header.Filename, file, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOrganizationAccess):
+			response.Forbidden(w, err.Error())
+		case errors.Is(err, ErrInvalidFile), errors.Is(err, ErrMissingColumns), errors.Is(err, ErrNoRows), errors.Is(err, ErrTooManyRows):
+			response.BadRequest(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to import members")
+		}
+		return
+	}
+
+	if dryRun {
+		response.Success(w, fmt.Sprintf("Dry run finished: %d of %d rows valid", imp.ValidRows, imp.TotalRows), imp.ToResponse(true))
+		return
+	}
+	response.Accepted(w, "Import started", imp.ToResponse(false))
+}
+
+func (h *Handler) ListImports(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	imports, err := h.service.ListImports(r.Context(), userID, userRole)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list imports")
+		return
+	}
+
+	importResponses := make([]*MemberImportResponse, len(imports))
+	for i, imp := range imports {
+		importResponses[i] = imp.ToResponse(false)
+	}
+	response.Success(w, "Imports retrieved successfully", importResponses)
+}
+
+func (h *Handler) GetImport(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.loadImport(w, r)
+	if !ok {
+		return
+	}
+	response.Success(w, "Import retrieved successfully", imp.ToResponse(true))
+}
+
+// DownloadResults writes the per-row report as CSV or XLSX (format).
+func (h *Handler) DownloadResults(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+	imp, ok := h.loadImport(w, r)
+	if !ok {
+		return
+	}
+
+	filename := format.Filename(fmt.Sprintf("import-%s-results", imp.ID))
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+	if err := writeResults(writer, imp.Results); err != nil {
+		log.Printf("Handler: Import results %s failed: %v", filename, err)
+	}
+}
+
+// Template serves an empty CSV with the canonical columns.
+func (h *Handler) Template(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", export.FormatCSV.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="member-import-template.csv"`)
+
+	writer, _ := export.NewWriter(export.FormatCSV, w)
+	err := writer.WriteHeader(TemplateColumns)
+	if err == nil {
+		err = writer.WriteRow([]string{"Jane", "Doe", "jane@example.com", "+15551234567", "1990-04-21", "MAIN", "Monthly", "2026-01-01", "", "paid"})
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("Handler: Import template failed: %v", err)
+	}
+}
+
+func (h *Handler) loadImport(w http.ResponseWriter, r *http.Request) (*MemberImport, bool) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid import ID", nil)
+		return nil, false
+	}
+
+	imp, err := h.service.GetImport(r.Context(), id, userID, userRole)
+	if err != nil {
+		switch err {
+		case ErrImportNotFound:
+			response.NotFound(w, err.Error())
+		default:
+			response.InternalServerError(w, "Failed to get import")
+		}
+		return nil, false
+	}
+	return imp, true
+}
+
+func writeResults(w export.RowWriter, results []*RowResult) error {
+	if err := w.WriteHeader([]string{"row", "email", "first_name", "last_name", "status", "member_id", "subscription_id", "errors"}); err != nil {
+		return err
+	}
+	for _, r := range results {
+		messages := make([]string, len(r.Errors))
+		for i, e := range r.Errors {
+			messages[i] = e.Field + ": " + e.Message
+		}
+		if err := w.WriteRow([]string{
+			strconv.Itoa(r.Row),
+			r.Email,
+			r.FirstName,
+			r.LastName,
+			r.Status,
+			uuidString(r.MemberID),
+			uuidString(r.SubscriptionID),
+			strings.Join(messages, "; "),
+		}); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func callerFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+	return userID, userRole, true
+}
@@ -0,0 +1,303 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrImportNotFound = errors.New("import not found")
+
+type Repository interface {
+	ListBranches(ctx context.Context, organizationID uuid.UUID) ([]*ImportBranch, error)
+	// ListPlans returns the organization's active plans.
+	ListPlans(ctx context.Context, organizationID uuid.UUID) ([]*ImportPlan, error)
+	// FindAccounts returns existing accounts keyed by lower-cased email.
+	FindAccounts(ctx context.Context, emails []string) (map[string]*ExistingAccount, error)
+	// InsertRows creates the users, members and subscriptions of a batch in
+	// one transaction. A row that fails is rolled back on its own and marked
+	// failed; the rest of the batch still commits.
+	InsertRows(ctx context.Context, rows []*ResolvedRow) error
+
+	CreateImport(ctx context.Context, imp *MemberImport) error
+	UpdateImport(ctx context.Context, imp *MemberImport) error
+	GetImport(ctx context.Context, id uuid.UUID) (*MemberImport, error)
+	// ListImports lists imports without their row results. organizationIDs
+	// nil means every organization.
+	ListImports(ctx context.Context, organizationIDs []uuid.UUID, limit int) ([]*MemberImport, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+func (r *repositoryImpl) ListBranches(ctx context.Context, organizationID uuid.UUID) ([]*ImportBranch, error) {
+	query := `
+		SELECT id, COALESCE(code, ''), name
+		FROM branches
+		WHERE organization_id = $1
+	`
+	rows, err := r.db.Query(ctx, query, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var branches []*ImportBranch
+	for rows.Next() {
+		var b ImportBranch
+		if err := rows.Scan(&b.ID, &b.Code, &b.Name); err != nil {
+			return nil, err
+		}
+		branches = append(branches, &b)
+	}
+	return branches, rows.Err()
+}
+
+func (r *repositoryImpl) ListPlans(ctx context.Context, organizationID uuid.UUID) ([]*ImportPlan, error) {
+	query := `
+		SELECT id, name, duration_days, COALESCE(branch_ids, '{}')
+		FROM membership_plans
+		WHERE organization_id = $1 AND is_active AND deleted_at IS NULL
+	`
+	rows, err := r.db.Query(ctx, query, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []*ImportPlan
+	for rows.Next() {
+		var p ImportPlan
+		if err := rows.Scan(&p.ID, &p.Name, &p.DurationDays, &p.BranchIDs); err != nil {
+			return nil, err
+		}
+		plans = append(plans, &p)
+	}
+	return plans, rows.Err()
+}
+
+// FindAccounts counts soft-deleted members too: members.user_id is unique, so
+// their users cannot get a second member.
+func (r *repositoryImpl) FindAccounts(ctx context.Context, emails []string) (map[string]*ExistingAccount, error) {
+	query := `
+		SELECT u.email, u.id, u.role::text, EXISTS (SELECT 1 FROM members m WHERE m.user_id = u.id)
+		FROM users u
+		WHERE LOWER(u.email) = ANY($1)
+	`
+	rows, err := r.db.Query(ctx, query, emails)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make(map[string]*ExistingAccount)
+	for rows.Next() {
+		var a ExistingAccount
+		if err := rows.Scan(&a.Email, &a.UserID, &a.Role, &a.HasMember); err != nil {
+			return nil, err
+		}
+		accounts[normalizeEmail(a.Email)] = &a
+	}
+	return accounts, rows.Err()
+}
+
+func (r *repositoryImpl) InsertRows(ctx context.Context, rows []*ResolvedRow) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, row := range rows {
+		// A nested transaction is a savepoint.
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		if err := insertRow(ctx, sp, row); err != nil {
+			if rbErr := sp.Rollback(ctx); rbErr != nil {
+				return rbErr
+			}
+			log.Printf("Repository: Import row %d (%s) failed: %v", row.Result.Row, row.Email, err)
+			row.Result.Status = RowFailed
+			row.Result.MemberID = nil
+			row.Result.SubscriptionID = nil
+			row.Result.Errors = append(row.Result.Errors, rowError("_row", "Could not be saved: "+err.Error()))
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return err
+		}
+		row.Result.Status = RowCreated
+	}
+	return tx.Commit(ctx)
+}
+
+// insertRow reuses create_new_member_with_user, which creates the user or
+// attaches the member to an existing one. Subscriptions are inserted
+// directly so no checkout starts; memberships not paid in the previous system
+// are recorded past_due for staff to bill.
+func insertRow(ctx context.Context, tx pgx.Tx, row *ResolvedRow) error {
+	query := `
+		SELECT (create_new_member_with_user($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ->> 'member_id')::uuid
+	`
+	var memberID uuid.UUID
+	if err := tx.QueryRow(ctx, query,
+		row.FirstName,
+		row.LastName,
+		row.Email,
+		row.EncryptedPassword,
+		row.Phone,
+		row.DateOfBirth,
+		row.BranchID,
+		row.MemberStatus,
+		row.JoinDate,
+		importNote,
+	).Scan(&memberID); err != nil {
+		return err
+	}
+	row.Result.MemberID = &memberID
+
+	if row.Subscription == nil {
+		return nil
+	}
+
+	subQuery := `
+		INSERT INTO subscriptions (member_id, plan_id, branch_id, start_date, end_date, status)
+		VALUES ($1, $2, $3, $4, $5, $6::subscription_status_enum)
+		RETURNING id
+	`
+	var subscriptionID uuid.UUID
+	if err := tx.QueryRow(ctx, subQuery,
+		memberID,
+		row.Subscription.PlanID,
+		row.BranchID,
+		row.Subscription.StartDate,
+		row.Subscription.EndDate,
+		row.Subscription.Status,
+	).Scan(&subscriptionID); err != nil {
+		return err
+	}
+	row.Result.SubscriptionID = &subscriptionID
+	return nil
+}
+
+const importColumns = `id, user_id, organization_id, filename, dry_run, status, total_rows, valid_rows,
+	created_rows, failed_rows, error, completed_at, created_at, updated_at`
+
+func scanImport(row pgx.Row, dest ...any) (*MemberImport, error) {
+	var imp MemberImport
+	if err := row.Scan(append([]any{
+		&imp.ID,
+		&imp.UserID,
+		&imp.OrganizationID,
+		&imp.Filename,
+		&imp.DryRun,
+		&imp.Status,
+		&imp.TotalRows,
+		&imp.ValidRows,
+		&imp.CreatedRows,
+		&imp.FailedRows,
+		&imp.Error,
+		&imp.CompletedAt,
+		&imp.CreatedAt,
+		&imp.UpdatedAt,
+	}, dest...)...); err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+func (r *repositoryImpl) CreateImport(ctx context.Context, imp *MemberImport) error {
+	query := `
+		INSERT INTO member_imports (
+			user_id, organization_id, filename, dry_run, status, total_rows, valid_rows, results, completed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(ctx, query,
+		imp.UserID,
+		imp.OrganizationID,
+		imp.Filename,
+		imp.DryRun,
+		imp.Status,
+		imp.TotalRows,
+		imp.ValidRows,
+		imp.Results,
+		imp.CompletedAt,
+	).Scan(&imp.ID, &imp.CreatedAt, &imp.UpdatedAt)
+}
+
+func (r *repositoryImpl) UpdateImport(ctx context.Context, imp *MemberImport) error {
+	query := `
+		UPDATE member_imports
+		SET status = $2, created_rows = $3, failed_rows = $4, results = $5, error = $6, completed_at = $7, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query,
+		imp.ID,
+		imp.Status,
+		imp.CreatedRows,
+		imp.FailedRows,
+		imp.Results,
+		imp.Error,
+		imp.CompletedAt,
+	)
+	return err
+}
+
+func (r *repositoryImpl) GetImport(ctx context.Context, id uuid.UUID) (*MemberImport, error) {
+	var results []*RowResult
+	imp, err := scanImport(r.db.QueryRow(ctx, `SELECT `+importColumns+`, results FROM member_imports WHERE id = $1`, id), &results)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	imp.Results = results
+	return imp, nil
+}
+
+func (r *repositoryImpl) ListImports(ctx context.Context, organizationIDs []uuid.UUID, limit int) ([]*MemberImport, error) {
+	var orgIDs any
+	if organizationIDs != nil {
+		orgIDs = organizationIDs
+	}
+	query := `
+		SELECT ` + importColumns + `
+		FROM member_imports
+		WHERE ($1::uuid[] IS NULL OR organization_id = ANY($1))
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, orgIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imports := []*MemberImport{}
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, imp)
+	}
+	return imports, rows.Err()
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,562 @@
+package imports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"fitcore/internal/modules/user"
+	"fitcore/internal/response"
+	"fitcore/pkg/hash"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	maxImportRows    = 5000
+	importBatchSize  = 100
+	listImportsLimit = 50
+	importNote       = "Imported"
+)
+
+var (
+	ErrOrganizationAccess = errors.New("organization is outside your access")
+	ErrInvalidFile        = errors.New("file is not a readable CSV")
+	ErrMissingColumns     = errors.New("missing required columns")
+	ErrNoRows             = errors.New("file has no member rows")
+	ErrTooManyRows        = fmt.Errorf("an import is limited to %d rows", maxImportRows)
+)
+
+// columnAliases maps normalized header names to canonical columns, so files
+// exported from other systems import without renaming.
+var columnAliases = map[string]string{
+	"first_name":          "first_name",
+	"firstname":           "first_name",
+	"last_name":           "last_name",
+	"lastname":            "last_name",
+	"surname":             "last_name",
+	"name":                "name",
+	"full_name":           "name",
+	"email":               "email",
+	"email_address":       "email",
+	"e_mail":              "email",
+	"phone":               "phone",
+	"phone_number":        "phone",
+	"mobile":              "phone",
+	"date_of_birth":       "date_of_birth",
+	"dob":                 "date_of_birth",
+	"birth_date":          "date_of_birth",
+	"branch_code":         "branch_code",
+	"branch":              "branch_code",
+	"plan_name":           "plan_name",
+	"plan":                "plan_name",
+	"start_date":          "start_date",
+	"end_date":            "end_date",
+	"payment_status":      "payment_status",
+	"paid_status":         "payment_status",
+	"paid_through_status": "payment_status",
+	"paid":                "payment_status",
+}
+
+// TemplateColumns is the header of the downloadable template.
+var TemplateColumns = []string{"first_name", "last_name", "email", "phone", "date_of_birth", "branch_code", "plan_name", "start_date", "end_date", "payment_status"}
+
+type Service interface {
+	// Import validates a member CSV for an organization. A dry run stores and
+	// returns the per-row report; otherwise valid rows are created in the
+	// background and the import is returned while it runs.
+	Import(ctx context.Context, userID uuid.UUID, userRole string, organizationID uuid.UUID, filename string, file io.Reader, dryRun bool) (*MemberImport, error)
+	GetImport(ctx context.Context, id, userID uuid.UUID, userRole string) (*MemberImport, error)
+	ListImports(ctx context.Context, userID uuid.UUID, userRole string) ([]*MemberImport, error)
+}
+
+type serviceImpl struct {
+	repo    Repository
+	userSvc user.Service
+}
+
+func NewService(repo Repository, userSvc user.Service) Service {
+	return &serviceImpl{repo: repo, userSvc: userSvc}
+}
+
+// organizationScope returns the organizations the caller may import into;
+// nil means every organization.
+func (s *serviceImpl) organizationScope(ctx context.Context, userID uuid.UUID, userRole string) ([]uuid.UUID, error) {
+	if userRole == "super_admin" {
+		return nil, nil
+	}
+	orgIDs, err := s.userSvc.GetUserOrganizationIDs(ctx, userID)
+	if err != nil {
+		log.Printf("Service: failed to list organizations for user %s: %v", userID, err)
+		return nil, err
+	}
+	return orgIDs, nil
+}
+
+func (s *serviceImpl) checkOrganization(ctx context.Context, organizationID, userID uuid.UUID, userRole string) error {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return err
+	}
+	if orgIDs != nil && !slices.Contains(orgIDs, organizationID) {
+		return ErrOrganizationAccess
+	}
+	return nil
+}
+
+func (s *serviceImpl) Import(ctx context.Context, userID uuid.UUID, userRole string, organizationID uuid.UUID, filename string, file io.Reader, dryRun bool) (*MemberImport, error) {
+	if err := s.checkOrganization(ctx, organizationID, userID, userRole); err != nil {
+		return nil, err
+	}
+
+	rows, err := parseCSV(file)
+	if err != nil {
+		return nil, err
+	}
+	resolved, results, err := s.validate(ctx, organizationID, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &MemberImport{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Filename:       filename,
+		DryRun:         dryRun,
+		TotalRows:      len(results),
+		ValidRows:      len(resolved),
+		Results:        results,
+	}
+
+	if dryRun {
+		now := time.Now()
+		imp.Status = ImportStatusValidated
+		imp.CompletedAt = &now
+		if err := s.repo.CreateImport(ctx, imp); err != nil {
+			log.Printf("Service: Import dry run failed to save report: %v", err)
+			return nil, err
+		}
+		log.Printf("Service: Import dry run %s - %d of %d rows valid", imp.ID, imp.ValidRows, imp.TotalRows)
+		return imp, nil
+	}
+
+	for _, r := range results {
+		if r.Status == RowInvalid {
+			r.Status = RowSkipped
+		}
+	}
+	imp.Status = ImportStatusRunning
+	if err := s.repo.CreateImport(ctx, imp); err != nil {
+		log.Printf("Service: Import failed to save import: %v", err)
+		return nil, err
+	}
+	log.Printf("Service: Import %s started - %d of %d rows valid", imp.ID, imp.ValidRows, imp.TotalRows)
+
+	// The goroutine owns imp from here; the caller gets a summary without
+	// the rows it keeps updating.
+	summary := *imp
+	summary.Results = nil
+	go s.run(context.WithoutCancel(ctx), imp, resolved)
+	return &summary, nil
+}
+
+// run creates the valid rows in batches, saving progress after each batch so
+// GetImport shows how far it got.
+func (s *serviceImpl) run(ctx context.Context, imp *MemberImport, rows []*ResolvedRow) {
+	started := time.Now()
+	for start := 0; start < len(rows); start += importBatchSize {
+		batch := rows[start:min(start+importBatchSize, len(rows))]
+
+		err := s.hashPasswords(batch)
+		if err == nil {
+			err = s.repo.InsertRows(ctx, batch)
+		}
+		if err != nil {
+			log.Printf("Service: Import %s stopped at row %d: %v", imp.ID, batch[0].Result.Row, err)
+			for _, r := range rows[start:] {
+				r.Result.Status = RowFailed
+				r.Result.MemberID = nil
+				r.Result.SubscriptionID = nil
+				r.Result.Errors = append(r.Result.Errors, rowError("_row", "Import stopped before this row was saved"))
+			}
+			message := err.Error()
+			imp.Error = &message
+			s.finish(ctx, imp, ImportStatusFailed)
+			return
+		}
+
+		s.count(imp)
+		if err := s.repo.UpdateImport(ctx, imp); err != nil {
+			log.Printf("Service: Import %s failed to save progress: %v", imp.ID, err)
+		}
+	}
+
+	s.finish(ctx, imp, ImportStatusCompleted)
+	log.Printf("Service: Import %s finished in %s - %d created, %d failed", imp.ID, time.Since(started).Round(time.Millisecond), imp.CreatedRows, imp.FailedRows)
+}
+
+func (s *serviceImpl) finish(ctx context.Context, imp *MemberImport, status ImportStatus) {
+	now := time.Now()
+	imp.Status = status
+	imp.CompletedAt = &now
+	s.count(imp)
+	if err := s.repo.UpdateImport(ctx, imp); err != nil {
+		log.Printf("Service: Import %s failed to save final state: %v", imp.ID, err)
+	}
+}
+
+func (s *serviceImpl) count(imp *MemberImport) {
+	imp.CreatedRows, imp.FailedRows = 0, 0
+	for _, r := range imp.Results {
+		switch r.Status {
+		case RowCreated:
+			imp.CreatedRows++
+		case RowFailed:
+			imp.FailedRows++
+		}
+	}
+}
+
+// hashPasswords gives every new account a random password nobody knows;
+// members set their own through password reset or the app invite.
+func (s *serviceImpl) hashPasswords(rows []*ResolvedRow) error {
+	for _, r := range rows {
+		password, err := hash.GenerateRandomPassword(16)
+		if err != nil {
+			return err
+		}
+		r.EncryptedPassword, err = hash.HashPassword(password)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *serviceImpl) GetImport(ctx context.Context, id, userID uuid.UUID, userRole string) (*MemberImport, error) {
+	imp, err := s.repo.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOrganization(ctx, imp.OrganizationID, userID, userRole); err != nil {
+		if err == ErrOrganizationAccess {
+			return nil, ErrImportNotFound
+		}
+		return nil, err
+	}
+	return imp, nil
+}
+
+func (s *serviceImpl) ListImports(ctx context.Context, userID uuid.UUID, userRole string) ([]*MemberImport, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListImports(ctx, orgIDs, listImportsLimit)
+}
+
+type parsedRow struct {
+	line int
+	row  *MemberImportRow
+}
+
+func parseCSV(file io.Reader) ([]*parsedRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, ErrInvalidFile
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		key := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+		if canonical, ok := columnAliases[key]; ok {
+			if _, seen := columns[canonical]; !seen {
+				columns[canonical] = i
+			}
+		}
+	}
+
+	var missing []string
+	for _, required := range []string{"email", "branch_code"} {
+		if _, ok := columns[required]; !ok {
+			missing = append(missing, required)
+		}
+	}
+	_, hasFirst := columns["first_name"]
+	_, hasLast := columns["last_name"]
+	if _, hasName := columns["name"]; !hasName && (!hasFirst || !hasLast) {
+		missing = append(missing, "first_name and last_name (or name)")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumns, strings.Join(missing, ", "))
+	}
+
+	var rows []*parsedRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, ErrTooManyRows
+		}
+		line, _ := reader.FieldPos(0)
+
+		cell := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := &MemberImportRow{
+			FirstName:     cell("first_name"),
+			LastName:      cell("last_name"),
+			Email:         cell("email"),
+			Phone:         cell("phone"),
+			DateOfBirth:   cell("date_of_birth"),
+			BranchCode:    cell("branch_code"),
+			PlanName:      cell("plan_name"),
+			StartDate:     cell("start_date"),
+			EndDate:       cell("end_date"),
+			PaymentStatus: strings.ToLower(cell("payment_status")),
+		}
+		if row.FirstName == "" && row.LastName == "" {
+			row.FirstName, row.LastName = splitName(cell("name"))
+		}
+		if *row == (MemberImportRow{}) {
+			continue
+		}
+		rows = append(rows, &parsedRow{line: line, row: row})
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+// splitName treats the last word of a full name as the last name.
+func splitName(name string) (string, string) {
+	name = strings.Join(strings.Fields(name), " ")
+	i := strings.LastIndex(name, " ")
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+1:]
+}
+
+// validate checks every row and resolves branches, plans and existing
+// accounts. It returns the rows ready to insert and a result for every row.
+func (s *serviceImpl) validate(ctx context.Context, organizationID uuid.UUID, rows []*parsedRow) ([]*ResolvedRow, []*RowResult, error) {
+	branches, err := s.repo.ListBranches(ctx, organizationID)
+	if err != nil {
+		log.Printf("Service: Import failed to list branches: %v", err)
+		return nil, nil, err
+	}
+	branchByKey := make(map[string]*ImportBranch)
+	for _, b := range branches {
+		branchByKey[strings.ToLower(b.Name)] = b
+	}
+	// Codes win over names when both match.
+	for _, b := range branches {
+		if b.Code != "" {
+			branchByKey[strings.ToLower(b.Code)] = b
+		}
+	}
+
+	plans, err := s.repo.ListPlans(ctx, organizationID)
+	if err != nil {
+		log.Printf("Service: Import failed to list plans: %v", err)
+		return nil, nil, err
+	}
+	planByName := make(map[string]*ImportPlan)
+	for _, p := range plans {
+		planByName[strings.ToLower(p.Name)] = p
+	}
+
+	emails := make([]string, 0, len(rows))
+	for _, r := range rows {
+		emails = append(emails, normalizeEmail(r.row.Email))
+	}
+	accounts, err := s.repo.FindAccounts(ctx, emails)
+	if err != nil {
+		log.Printf("Service: Import failed to look up accounts: %v", err)
+		return nil, nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	firstRowByEmail := make(map[string]int)
+	var resolved []*ResolvedRow
+	results := make([]*RowResult, 0, len(rows))
+
+	for _, r := range rows {
+		row := r.row
+		result := &RowResult{Row: r.line, Email: row.Email, FirstName: row.FirstName, LastName: row.LastName}
+		results = append(results, result)
+
+		errs := response.ValidateStruct(row)
+		if errs == nil {
+			errs = response.NewValidationErrors()
+		}
+		email := normalizeEmail(row.Email)
+
+		if first, ok := firstRowByEmail[email]; ok && email != "" {
+			errs.AddError("email", fmt.Sprintf("Email also appears on row %d", first))
+		} else {
+			firstRowByEmail[email] = r.line
+		}
+		account := accounts[email]
+		switch {
+		case account == nil:
+		case account.HasMember:
+			errs.AddError("email", "Email already belongs to a member")
+		case account.Role != "member":
+			errs.AddError("email", "Email belongs to a staff account")
+		}
+
+		var dateOfBirth *time.Time
+		if row.DateOfBirth != "" {
+			if dob, err := time.Parse(dateLayout, row.DateOfBirth); err == nil {
+				if dob.After(today) {
+					errs.AddError("date_of_birth", "Date of birth must not be in the future")
+				}
+				dateOfBirth = &dob
+			}
+		}
+
+		branch := branchByKey[strings.ToLower(row.BranchCode)]
+		if branch == nil && row.BranchCode != "" {
+			errs.AddError("branch_code", fmt.Sprintf("No branch with code %q in this organization", row.BranchCode))
+		}
+
+		var sub *ResolvedSubscription
+		if row.PlanName == "" {
+			if row.StartDate != "" || row.EndDate != "" || row.PaymentStatus != "" {
+				errs.AddError("plan_name", "Plan name is required when dates or payment status are given")
+			}
+		} else {
+			sub = resolveSubscription(row, planByName, branch, today, errs)
+		}
+
+		if errs.HasErrors() {
+			result.Status = RowInvalid
+			result.Errors = sortedErrors(errs)
+			continue
+		}
+
+		result.Status = RowValid
+		resolvedRow := &ResolvedRow{
+			Result:       result,
+			FirstName:    row.FirstName,
+			LastName:     row.LastName,
+			Email:        row.Email,
+			DateOfBirth:  dateOfBirth,
+			BranchID:     branch.ID,
+			MemberStatus: "lead",
+			JoinDate:     today,
+			Subscription: sub,
+		}
+		if account != nil {
+			// The account function matches emails exactly.
+			resolvedRow.Email = account.Email
+		}
+		if row.Phone != "" {
+			resolvedRow.Phone = &row.Phone
+		}
+		if sub != nil {
+			resolvedRow.JoinDate = sub.StartDate
+			resolvedRow.MemberStatus = "active"
+			if sub.Status == "expired" {
+				resolvedRow.MemberStatus = "expired"
+			}
+		}
+		resolved = append(resolved, resolvedRow)
+	}
+	return resolved, results, nil
+}
+
+// resolveSubscription checks the plan columns of a row. The end date
+// defaults to the plan's duration; unpaid memberships that are still running
+// are past_due.
+func resolveSubscription(row *MemberImportRow, planByName map[string]*ImportPlan, branch *ImportBranch, today time.Time, errs response.ValidationErrors) *ResolvedSubscription {
+	plan := planByName[strings.ToLower(row.PlanName)]
+	if plan == nil {
+		errs.AddError("plan_name", fmt.Sprintf("No active plan named %q in this organization", row.PlanName))
+	} else if branch != nil && len(plan.BranchIDs) > 0 && !slices.Contains(plan.BranchIDs, branch.ID) {
+		errs.AddError("plan_name", fmt.Sprintf("Plan %q is not offered at branch %q", plan.Name, branch.Name))
+	}
+	if row.StartDate == "" {
+		errs.AddError("start_date", "Start date is required when a plan is given")
+	}
+	if row.PaymentStatus == "" {
+		errs.AddError("payment_status", "Payment status is required when a plan is given")
+	}
+
+	startDate, startErr := time.Parse(dateLayout, row.StartDate)
+	if plan == nil || startErr != nil {
+		return nil
+	}
+	endDate := startDate.AddDate(0, 0, plan.DurationDays)
+	if row.EndDate != "" {
+		parsed, err := time.Parse(dateLayout, row.EndDate)
+		if err != nil {
+			return nil
+		}
+		if !parsed.After(startDate) {
+			errs.AddError("end_date", "End date must be after start date")
+			return nil
+		}
+		endDate = parsed
+	}
+
+	status := "active"
+	switch {
+	case !endDate.After(today):
+		status = "expired"
+	case row.PaymentStatus == "unpaid":
+		status = "past_due"
+	}
+	return &ResolvedSubscription{PlanID: plan.ID, StartDate: startDate, EndDate: endDate, Status: status}
+}
+
+// sortedErrors orders field errors by column so reports are stable.
+func sortedErrors(errs response.ValidationErrors) []response.FieldError {
+	fieldErrors := errs.ToFieldErrors()
+	slices.SortStableFunc(fieldErrors, func(a, b response.FieldError) int {
+		return columnOrder(a.Field) - columnOrder(b.Field)
+	})
+	return fieldErrors
+}
+
+func columnOrder(field string) int {
+	if i := slices.Index(TemplateColumns, field); i >= 0 {
+		return i
+	}
+	return len(TemplateColumns)
+}
+
+func rowError(field, message string) response.FieldError {
+	return response.FieldError{Field: field, Message: message}
+}
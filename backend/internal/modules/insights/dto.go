@@ -0,0 +1,36 @@
+package insights
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RiskScoreResponse struct {
+	MemberID     uuid.UUID   `json:"memberId"`
+	MemberName   string      `json:"memberName,omitempty"`
+	Phone        *string     `json:"phone,omitempty"`
+	Email        *string     `json:"email,omitempty"`
+	BranchID     uuid.UUID   `json:"branchId"`
+	ChurnScore   int         `json:"churnScore"`
+	ChurnLevel   string      `json:"churnLevel"`
+	BurnoutScore int         `json:"burnoutScore"`
+	BurnoutLevel string      `json:"burnoutLevel"`
+	Reasons      []string    `json:"reasons"`
+	Metrics      RiskMetrics `json:"metrics"`
+	ComputedAt   time.Time   `json:"computedAt"`
+}
+
+type ScoreRunResponse struct {
+	BranchID      uuid.UUID `json:"branchId"`
+	MembersScored int       `json:"membersScored"`
+	HighRisk      int       `json:"highRisk"`
+	ComputedAt    time.Time `json:"computedAt"`
+}
+
+type AtRiskFilter struct {
+	BranchID uuid.UUID
+	// MinScore is the lowest churn score included.
+	MinScore int
+	Limit    int
+}
@@ -0,0 +1,70 @@
+package insights
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RiskScore is one member's churn and burnout risk from a scoring run.
+type RiskScore struct {
+	ID           uuid.UUID   `db:"id"`
+	MemberID     uuid.UUID   `db:"member_id"`
+	BranchID     uuid.UUID   `db:"branch_id"`
+	ChurnScore   int         `db:"churn_score"`
+	ChurnLevel   string      `db:"churn_level"`
+	BurnoutScore int         `db:"burnout_score"`
+	BurnoutLevel string      `db:"burnout_level"`
+	Reasons      []string    `db:"reasons"`
+	Metrics      RiskMetrics `db:"metrics"`
+	ComputedAt   time.Time   `db:"computed_at"`
+
+	// Populated by list queries for outreach.
+	MemberName string  `db:"member_name"`
+	Phone      *string `db:"phone"`
+	Email      *string `db:"email"`
+}
+
+// RiskMetrics are the inputs behind a score, stored as JSON so staff can see
+// why a member was flagged.
+type RiskMetrics struct {
+	SessionsLast30     int     `json:"sessionsLast30"`
+	SessionsPrevious30 int     `json:"sessionsPrevious30"`
+	SessionsPerWeek    float64 `json:"sessionsPerWeek"`
+	LongestStreak      int     `json:"longestStreak"`
+	LastVisit          *string `json:"lastVisit,omitempty"`
+	DaysSinceLastVisit *int    `json:"daysSinceLastVisit,omitempty"`
+	RenewalDate        string  `json:"renewalDate"`
+	DaysUntilRenewal   int     `json:"daysUntilRenewal"`
+	FailedPayments     int     `json:"failedPayments"`
+	OverduePayments    int     `json:"overduePayments"`
+	TenureDays         int     `json:"tenureDays"`
+}
+
+func (s *RiskScore) ToResponse() *RiskScoreResponse {
+	return &RiskScoreResponse{
+		MemberID:     s.MemberID,
+		MemberName:   s.MemberName,
+		Phone:        s.Phone,
+		Email:        s.Email,
+		BranchID:     s.BranchID,
+		ChurnScore:   s.ChurnScore,
+		ChurnLevel:   s.ChurnLevel,
+		BurnoutScore: s.BurnoutScore,
+		BurnoutLevel: s.BurnoutLevel,
+		Reasons:      s.Reasons,
+		Metrics:      s.Metrics,
+		ComputedAt:   s.ComputedAt,
+	}
+}
+
+// memberFeatures is the per-member data a branch run scores from.
+type memberFeatures struct {
+	MemberID        uuid.UUID
+	DateOfBirth     *time.Time
+	JoinDate        time.Time
+	RenewalDate     time.Time
+	FailedPayments  int
+	OverduePayments int
+	LastVisit       *time.Time
+}
@@ -0,0 +1,151 @@
+package insights
+
+import (
+	"net/http"
+	"strconv"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/modules/user"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+	userSvc user.Service
+}
+
+func NewHandler(service Service, userSvc user.Service) *Handler {
+	return &Handler{service: service, userSvc: userSvc}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/insights", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware("super_admin", "admin", "staff"))
+
+		r.Get("/branches/{branchId}/at-risk", h.ListAtRisk)
+		r.Post("/branches/{branchId}/score", h.ScoreBranch)
+		r.Get("/members/{memberId}/history", h.GetMemberHistory)
+	})
+}
+
+// ListAtRisk lists members of the branch's latest scoring run whose churn
+// score is at least minScore (35 by default), highest risk first.
+func (h *Handler) ListAtRisk(w http.ResponseWriter, r *http.Request) {
+	userRole, userBranchIDs, ok := h.callerScope(w, r)
+	if !ok {
+		return
+	}
+
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	filter := &AtRiskFilter{BranchID: branchID}
+	filter.MinScore, _ = strconv.Atoi(r.URL.Query().Get("minScore"))
+	filter.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+
+	scores, err := h.service.ListAtRisk(r.Context(), filter, userRole, userBranchIDs)
+	if err != nil {
+		switch err {
+		case ErrBranchAccess:
+			response.Forbidden(w, err.Error())
+		default:
+			response.InternalServerError(w, "Failed to list at-risk members")
+		}
+		return
+	}
+
+	response.Success(w, "At-risk members retrieved successfully", riskScoreResponses(scores))
+}
+
+// ScoreBranch runs scoring for a branch now instead of waiting for the
+// daily job.
+func (h *Handler) ScoreBranch(w http.ResponseWriter, r *http.Request) {
+	userRole, userBranchIDs, ok := h.callerScope(w, r)
+	if !ok {
+		return
+	}
+
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	if err := h.service.CheckBranchAccess(branchID, userRole, userBranchIDs); err != nil {
+		response.Forbidden(w, err.Error())
+		return
+	}
+
+	result, err := h.service.ScoreBranch(r.Context(), branchID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to score branch")
+		return
+	}
+
+	response.Success(w, "Branch scored successfully", result)
+}
+
+func (h *Handler) GetMemberHistory(w http.ResponseWriter, r *http.Request) {
+	userRole, userBranchIDs, ok := h.callerScope(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid member ID", nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	scores, err := h.service.GetMemberHistory(r.Context(), memberID, limit, userRole, userBranchIDs)
+	if err != nil {
+		response.InternalServerError(w, "Failed to get risk history")
+		return
+	}
+
+	response.Success(w, "Risk history retrieved successfully", riskScoreResponses(scores))
+}
+
+// callerScope returns the caller's role and, for staff, their branches.
+func (h *Handler) callerScope(w http.ResponseWriter, r *http.Request) (string, []uuid.UUID, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return "", nil, false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return "", nil, false
+	}
+
+	var userBranchIDs []uuid.UUID
+	if userRole == "staff" {
+		userBranchIDs, err = h.userSvc.GetUserBranchIDs(r.Context(), userID, userRole)
+		if err != nil {
+			response.InternalServerError(w, "Failed to get user branches")
+			return "", nil, false
+		}
+	}
+	return userRole, userBranchIDs, true
+}
+
+func riskScoreResponses(scores []*RiskScore) []*RiskScoreResponse {
+	responses := make([]*RiskScoreResponse, len(scores))
+	for i, s := range scores {
+		responses[i] = s.ToResponse()
+	}
+	return responses
+}
@@ -0,0 +1,252 @@
+package insights
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	ListActiveBranchIDs(ctx context.Context) ([]uuid.UUID, error)
+	ListMemberFeatures(ctx context.Context, branchID uuid.UUID, asOf time.Time) ([]*memberFeatures, error)
+	// ListVisitMinutes returns minutes trained per member and day since the
+	// given time, keyed by member and YYYY-MM-DD.
+	ListVisitMinutes(ctx context.Context, memberIDs []uuid.UUID, since time.Time) (map[uuid.UUID]map[string]float64, error)
+	InsertScores(ctx context.Context, scores []*RiskScore) error
+	ListAtRisk(ctx context.Context, filter *AtRiskFilter) ([]*RiskScore, error)
+	ListMemberHistory(ctx context.Context, memberID uuid.UUID, branchIDs []uuid.UUID, limit int) ([]*RiskScore, error)
+	DeleteScoresBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+const riskScoreColumns = `
+	rs.id, rs.member_id, rs.branch_id, rs.churn_score, rs.churn_level, rs.burnout_score, rs.burnout_level,
+	rs.reasons, rs.metrics, rs.computed_at, TRIM(m.first_name || ' ' || m.last_name), m.phone, u.email
+`
+
+func scanRiskScore(row pgx.Row) (*RiskScore, error) {
+	var s RiskScore
+	err := row.Scan(
+		&s.ID,
+		&s.MemberID,
+		&s.BranchID,
+		&s.ChurnScore,
+		&s.ChurnLevel,
+		&s.BurnoutScore,
+		&s.BurnoutLevel,
+		&s.Reasons,
+		&s.Metrics,
+		&s.ComputedAt,
+		&s.MemberName,
+		&s.Phone,
+		&s.Email,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *repositoryImpl) ListActiveBranchIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, `SELECT id FROM branches WHERE is_active IS NOT FALSE ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListMemberFeatures returns members with an active membership at the
+// branch. Personal training packs do not count as a membership.
+func (r *repositoryImpl) ListMemberFeatures(ctx context.Context, branchID uuid.UUID, asOf time.Time) ([]*memberFeatures, error) {
+	query := `
+		SELECT
+			m.id,
+			m.date_of_birth,
+			COALESCE(m.join_date, m.created_at::date),
+			sub.end_date,
+			(SELECT COUNT(*) FROM invoices i
+				WHERE i.member_id = m.id AND i.status = 'failed' AND i.created_at >= $2::timestamptz - INTERVAL '180 days'),
+			(SELECT COUNT(*) FROM invoices i
+				WHERE i.member_id = m.id AND i.status = 'pending' AND i.due_date < $2::date),
+			(SELECT MAX(ci.check_in_time) FROM check_ins ci WHERE ci.member_id = m.id)
+		FROM members m
+		JOIN LATERAL (
+			SELECT s.end_date
+			FROM subscriptions s
+			LEFT JOIN membership_plans p ON p.id = s.plan_id
+			WHERE s.member_id = m.id
+				AND s.status = 'active'
+				AND p.session_credits IS NULL
+				AND COALESCE(s.branch_id, m.home_branch_id) = $1
+			ORDER BY s.end_date DESC
+			LIMIT 1
+		) sub ON TRUE
+		WHERE m.deleted_at IS NULL
+	`
+	rows, err := r.db.Query(ctx, query, branchID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var features []*memberFeatures
+	for rows.Next() {
+		var f memberFeatures
+		if err := rows.Scan(
+			&f.MemberID,
+			&f.DateOfBirth,
+			&f.JoinDate,
+			&f.RenewalDate,
+			&f.FailedPayments,
+			&f.OverduePayments,
+			&f.LastVisit,
+		); err != nil {
+			return nil, err
+		}
+		features = append(features, &f)
+	}
+	return features, rows.Err()
+}
+
+func (r *repositoryImpl) ListVisitMinutes(ctx context.Context, memberIDs []uuid.UUID, since time.Time) (map[uuid.UUID]map[string]float64, error) {
+	query := `
+		SELECT
+			member_id,
+			check_in_time::date::text,
+			SUM(EXTRACT(EPOCH FROM (COALESCE(check_out_time, check_in_time) - check_in_time)) / 60)::float8
+		FROM check_ins
+		WHERE member_id = ANY($1) AND check_in_time >= $2
+		GROUP BY member_id, check_in_time::date
+	`
+	rows, err := r.db.Query(ctx, query, memberIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	visits := make(map[uuid.UUID]map[string]float64)
+	for rows.Next() {
+		var (
+			memberID uuid.UUID
+			day      string
+			minutes  float64
+		)
+		if err := rows.Scan(&memberID, &day, &minutes); err != nil {
+			return nil, err
+		}
+		if visits[memberID] == nil {
+			visits[memberID] = make(map[string]float64)
+		}
+		visits[memberID][day] = minutes
+	}
+	return visits, rows.Err()
+}
+
+func (r *repositoryImpl) InsertScores(ctx context.Context, scores []*RiskScore) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO member_risk_scores (member_id, branch_id, churn_score, churn_level, burnout_score, burnout_level, reasons, metrics, computed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	batch := &pgx.Batch{}
+	for _, s := range scores {
+		batch.Queue(query, s.MemberID, s.BranchID, s.ChurnScore, s.ChurnLevel, s.BurnoutScore, s.BurnoutLevel, s.Reasons, s.Metrics, s.ComputedAt)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListAtRisk returns members from the branch's latest run, highest churn
+// risk first and, within a score, the soonest renewal first.
+func (r *repositoryImpl) ListAtRisk(ctx context.Context, filter *AtRiskFilter) ([]*RiskScore, error) {
+	query := `SELECT ` + riskScoreColumns + `
+		FROM member_risk_scores rs
+		JOIN members m ON m.id = rs.member_id
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE rs.branch_id = $1
+			AND rs.computed_at = (SELECT MAX(computed_at) FROM member_risk_scores WHERE branch_id = $1)
+			AND rs.churn_score >= $2
+			AND m.deleted_at IS NULL
+		ORDER BY rs.churn_score DESC, (rs.metrics->>'daysUntilRenewal')::int ASC
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, filter.BranchID, filter.MinScore, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := []*RiskScore{}
+	for rows.Next() {
+		s, err := scanRiskScore(rows)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, s)
+	}
+	return scores, rows.Err()
+}
+
+// ListMemberHistory returns a member's scores, newest first. A nil
+// branchIDs means no branch restriction.
+func (r *repositoryImpl) ListMemberHistory(ctx context.Context, memberID uuid.UUID, branchIDs []uuid.UUID, limit int) ([]*RiskScore, error) {
+	query := `SELECT ` + riskScoreColumns + `
+		FROM member_risk_scores rs
+		JOIN members m ON m.id = rs.member_id
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE rs.member_id = $1
+			AND ($2::uuid[] IS NULL OR rs.branch_id = ANY($2))
+		ORDER BY rs.computed_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, memberID, branchIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := []*RiskScore{}
+	for rows.Next() {
+		s, err := scanRiskScore(rows)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, s)
+	}
+	return scores, rows.Err()
+}
+
+func (r *repositoryImpl) DeleteScoresBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM member_risk_scores WHERE computed_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,234 @@
+package insights
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"fitcore/pkg/wellness"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// windowDays is the attendance window scored; the window before it is
+	// used to detect a decline.
+	windowDays = 30
+
+	defaultMinScore = 35
+	defaultLimit    = 50
+	maxLimit        = 200
+
+	// historyRetention is how long scores are kept for trends.
+	historyRetention = 365 * 24 * time.Hour
+)
+
+var ErrBranchAccess = errors.New("branch is outside your assignments")
+
+type Service interface {
+	// ScoreBranch scores every member with an active membership at the
+	// branch and stores the results as a new run.
+	ScoreBranch(ctx context.Context, branchID uuid.UUID) (*ScoreRunResponse, error)
+	// ScoreAllBranches is the scheduled job: it scores every active branch
+	// and prunes history past the retention period.
+	ScoreAllBranches(ctx context.Context) error
+
+	ListAtRisk(ctx context.Context, filter *AtRiskFilter, userRole string, userBranchIDs []uuid.UUID) ([]*RiskScore, error)
+	GetMemberHistory(ctx context.Context, memberID uuid.UUID, limit int, userRole string, userBranchIDs []uuid.UUID) ([]*RiskScore, error)
+	CheckBranchAccess(branchID uuid.UUID, userRole string, userBranchIDs []uuid.UUID) error
+}
+
+type serviceImpl struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &serviceImpl{repo: repo}
+}
+
+func (s *serviceImpl) ScoreBranch(ctx context.Context, branchID uuid.UUID) (*ScoreRunResponse, error) {
+	computedAt := time.Now().UTC().Truncate(time.Second)
+	today := computedAt.Truncate(24 * time.Hour)
+
+	features, err := s.repo.ListMemberFeatures(ctx, branchID, computedAt)
+	if err != nil {
+		log.Printf("Service: ScoreBranch failed to load members for branch %s: %v", branchID, err)
+		return nil, err
+	}
+
+	result := &ScoreRunResponse{BranchID: branchID, ComputedAt: computedAt}
+	if len(features) == 0 {
+		return result, nil
+	}
+
+	memberIDs := make([]uuid.UUID, len(features))
+	for i, f := range features {
+		memberIDs[i] = f.MemberID
+	}
+	windowStart := today.AddDate(0, 0, -(windowDays - 1))
+	previousStart := windowStart.AddDate(0, 0, -windowDays)
+	visits, err := s.repo.ListVisitMinutes(ctx, memberIDs, previousStart)
+	if err != nil {
+		log.Printf("Service: ScoreBranch failed to load visits for branch %s: %v", branchID, err)
+		return nil, err
+	}
+
+	scores := make([]*RiskScore, 0, len(features))
+	for _, f := range features {
+		score := scoreMember(f, visits[f.MemberID], today, windowStart, previousStart)
+		score.BranchID = branchID
+		score.ComputedAt = computedAt
+		scores = append(scores, score)
+		if score.ChurnLevel == "High" {
+			result.HighRisk++
+		}
+	}
+
+	if err := s.repo.InsertScores(ctx, scores); err != nil {
+		log.Printf("Service: ScoreBranch failed to store scores for branch %s: %v", branchID, err)
+		return nil, err
+	}
+
+	result.MembersScored = len(scores)
+	return result, nil
+}
+
+// scoreMember turns a member's features and daily visit minutes into churn
+// and burnout risk.
+func scoreMember(f *memberFeatures, visits map[string]float64, today, windowStart, previousStart time.Time) *RiskScore {
+	days := make([]wellness.Day, 0, windowDays)
+	for d := windowStart; !d.After(today); d = d.AddDate(0, 0, 1) {
+		minutes, attended := visits[d.Format(dateLayout)]
+		days = append(days, wellness.Day{Date: d.Format(dateLayout), Attended: attended, DurationMinutes: minutes})
+	}
+
+	previousSessions := 0
+	for d := previousStart; d.Before(windowStart); d = d.AddDate(0, 0, 1) {
+		if _, ok := visits[d.Format(dateLayout)]; ok {
+			previousSessions++
+		}
+	}
+
+	age := 0
+	if f.DateOfBirth != nil {
+		age = int(today.Sub(*f.DateOfBirth).Hours() / 24 / 365)
+	}
+	daysUntilRenewal := int(f.RenewalDate.Sub(today).Hours() / 24)
+
+	metrics := RiskMetrics{
+		SessionsPrevious30: previousSessions,
+		RenewalDate:        f.RenewalDate.Format(dateLayout),
+		DaysUntilRenewal:   daysUntilRenewal,
+		FailedPayments:     f.FailedPayments,
+		OverduePayments:    f.OverduePayments,
+		TenureDays:         max(0, int(today.Sub(f.JoinDate).Hours()/24)),
+	}
+	if f.LastVisit != nil {
+		lastVisit := f.LastVisit.UTC().Format(dateLayout)
+		daysSince := max(0, int(today.Sub(f.LastVisit.UTC().Truncate(24*time.Hour)).Hours()/24))
+		metrics.LastVisit = &lastVisit
+		metrics.DaysSinceLastVisit = &daysSince
+	}
+
+	analysis := wellness.Analyze(days, wellness.Profile{Age: age, DaysUntilRenewal: &daysUntilRenewal})
+	metrics.SessionsLast30 = analysis.Metrics.Sessions
+	metrics.SessionsPerWeek = analysis.Metrics.SessionsPerWeek
+	metrics.LongestStreak = analysis.Metrics.LongestStreak
+
+	churn := wellness.ChurnRisk(wellness.ChurnInput{
+		Current:            analysis.Metrics,
+		PreviousSessions:   previousSessions,
+		DaysSinceLastVisit: metrics.DaysSinceLastVisit,
+		DaysUntilRenewal:   daysUntilRenewal,
+		FailedPayments:     f.FailedPayments,
+		OverduePayments:    f.OverduePayments,
+		TenureDays:         metrics.TenureDays,
+	})
+
+	reasons := churn.Reasons
+	if analysis.Burnout.RiskLevel == "High" {
+		reasons = append(reasons, "High burnout risk: "+analysis.Burnout.RecoverySuggestion)
+	}
+
+	return &RiskScore{
+		MemberID:     f.MemberID,
+		ChurnScore:   churn.RiskScore,
+		ChurnLevel:   churn.RiskLevel,
+		BurnoutScore: min(100, analysis.Burnout.RiskScore),
+		BurnoutLevel: analysis.Burnout.RiskLevel,
+		Reasons:      reasons,
+		Metrics:      metrics,
+	}
+}
+
+func (s *serviceImpl) ScoreAllBranches(ctx context.Context) error {
+	branchIDs, err := s.repo.ListActiveBranchIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	// One failing branch should not stop the rest from being scored.
+	var failed int
+	for _, branchID := range branchIDs {
+		result, err := s.ScoreBranch(ctx, branchID)
+		if err != nil {
+			failed++
+			continue
+		}
+		log.Printf("Service: ScoreAllBranches scored %d members for branch %s (%d high risk)", result.MembersScored, branchID, result.HighRisk)
+	}
+
+	if deleted, err := s.repo.DeleteScoresBefore(ctx, time.Now().Add(-historyRetention)); err != nil {
+		log.Printf("Service: ScoreAllBranches failed to prune history: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Service: ScoreAllBranches pruned %d old scores", deleted)
+	}
+
+	if failed > 0 {
+		return errors.New("scoring failed for some branches")
+	}
+	return nil
+}
+
+func (s *serviceImpl) ListAtRisk(ctx context.Context, filter *AtRiskFilter, userRole string, userBranchIDs []uuid.UUID) ([]*RiskScore, error) {
+	if err := s.CheckBranchAccess(filter.BranchID, userRole, userBranchIDs); err != nil {
+		return nil, err
+	}
+
+	if filter.MinScore <= 0 || filter.MinScore > 100 {
+		filter.MinScore = defaultMinScore
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultLimit
+	}
+	filter.Limit = min(filter.Limit, maxLimit)
+
+	return s.repo.ListAtRisk(ctx, filter)
+}
+
+func (s *serviceImpl) GetMemberHistory(ctx context.Context, memberID uuid.UUID, limit int, userRole string, userBranchIDs []uuid.UUID) ([]*RiskScore, error) {
+	var branchIDs []uuid.UUID
+	if userRole == "staff" {
+		if len(userBranchIDs) == 0 {
+			return []*RiskScore{}, nil
+		}
+		branchIDs = userBranchIDs
+	}
+
+	if limit < 1 {
+		limit = defaultLimit
+	}
+	return s.repo.ListMemberHistory(ctx, memberID, branchIDs, min(limit, maxLimit))
+}
+
+// CheckBranchAccess limits staff to their assigned branches.
+func (s *serviceImpl) CheckBranchAccess(branchID uuid.UUID, userRole string, userBranchIDs []uuid.UUID) error {
+	if userRole == "staff" && !slices.Contains(userBranchIDs, branchID) {
+		return ErrBranchAccess
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package join
+
+import (
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/plans"
+
+	"github.com/google/uuid"
+)
+
+// PageResponse is what the public join page needs to render for an
+// organization.
+type PageResponse struct {
+	Organization OrganizationSummary   `json:"organization"`
+	Branches     []BranchSummary       `json:"branches"`
+	Plans        []*plans.PlanResponse `json:"plans"`
+	// Documents are what a new member is asked to sign; the required ones
+	// must be signed to join.
+	Documents []*documents.DocumentResponse `json:"documents"`
+}
+
+type OrganizationSummary struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Slug    string    `json:"slug"`
+	LogoURL *string   `json:"logoUrl,omitempty"`
+}
+
+type BranchSummary struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Address  *string   `json:"address,omitempty"`
+	Phone    *string   `json:"phone,omitempty"`
+	Email    *string   `json:"email,omitempty"`
+	Timezone *string   `json:"timezone,omitempty"`
+}
+
+type JoinRequest struct {
+	FirstName   string    `json:"firstName" validate:"required,max=100"`
+	LastName    string    `json:"lastName" validate:"required,max=100"`
+	Email       string    `json:"email" validate:"required,email"`
+	Phone       *string   `json:"phone,omitempty" validate:"omitempty,max=50"`
+	DateOfBirth *string   `json:"dateOfBirth,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	BranchID    uuid.UUID `json:"branchId" validate:"required"`
+	PlanID      uuid.UUID `json:"planId" validate:"required"`
+	// AcceptTerms must be true; the sign-up is refused otherwise.
+	AcceptTerms    bool `json:"acceptTerms" validate:"required"`
+	MarketingOptIn bool `json:"marketingOptIn"`
+	// Signatures sign the documents listed on the join page.
+	Signatures []*documents.SignRequest `json:"signatures,omitempty" validate:"omitempty,dive,required"`
+	// CaptchaToken is the Turnstile response, checked when a captcha secret
+	// is configured.
+	CaptchaToken string `json:"captchaToken,omitempty"`
+	// Website is a honeypot field the form hides from people; bots that fill
+	// it in are refused.
+	Website string `json:"website,omitempty"`
+}
+
+// RequestMeta is where a sign-up came from, kept with the consent record.
+type RequestMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+type JoinResponse struct {
+	MemberID       uuid.UUID  `json:"memberId"`
+	SubscriptionID uuid.UUID  `json:"subscriptionId"`
+	InvoiceID      *uuid.UUID `json:"invoiceId,omitempty"`
+	CheckoutURL    string     `json:"checkoutUrl"`
+}
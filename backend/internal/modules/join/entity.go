@@ -0,0 +1,22 @@
+package join
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Signup records the consent given on the public join form.
+type Signup struct {
+	ID              uuid.UUID  `db:"id"`
+	OrganizationID  uuid.UUID  `db:"organization_id"`
+	MemberID        uuid.UUID  `db:"member_id"`
+	PlanID          *uuid.UUID `db:"plan_id"`
+	SubscriptionID  *uuid.UUID `db:"subscription_id"`
+	Email           string     `db:"email"`
+	TermsAcceptedAt time.Time  `db:"terms_accepted_at"`
+	MarketingOptIn  bool       `db:"marketing_opt_in"`
+	IPAddress       *string    `db:"ip_address"`
+	UserAgent       *string    `db:"user_agent"`
+	CreatedAt       time.Time  `db:"created_at"`
+}
@@ -0,0 +1,79 @@
+package join
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service Service
+	// joinLimit throttles sign-ups per client IP.
+	joinLimit func(http.Handler) http.Handler
+}
+
+func NewHandler(service Service, rateLimit int, rateWindow time.Duration) *Handler {
+	return &Handler{service: service, joinLimit: middleware.RateLimit(rateLimit, rateWindow)}
+}
+
+// RegisterRoutes mounts the public join flow; none of it requires a login.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/join/{slug}", func(r chi.Router) {
+		r.Get("/", h.GetPage)
+		r.With(h.joinLimit).Post("/", h.Join)
+	})
+}
+
+func (h *Handler) GetPage(w http.ResponseWriter, r *http.Request) {
+	page, err := h.service.GetPage(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		writeError(w, err, "Failed to load join page")
+		return
+	}
+	response.Success(w, "Join page retrieved successfully", page)
+}
+
+// Join signs up a new member and answers with the checkout URL to pay at.
+func (h *Handler) Join(w http.ResponseWriter, r *http.Request) {
+	var req JoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	meta := RequestMeta{IPAddress: middleware.ClientIP(r), UserAgent: r.UserAgent()}
+	res, err := h.service.Join(r.Context(), chi.URLParam(r, "slug"), &req, meta)
+	if err != nil {
+		writeError(w, err, "Failed to complete sign-up")
+		return
+	}
+	response.Success(w, "Sign-up successful, complete the payment to activate your membership", res)
+}
+
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrOrganizationNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, ErrEmailRegistered):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, ErrBranchUnavailable), errors.Is(err, ErrPlanUnavailable), errors.Is(err, ErrVerificationFailed),
+		errors.Is(err, documents.ErrSignatureRequired), errors.Is(err, documents.ErrVersionNotFound),
+		errors.Is(err, documents.ErrTemplateInactive), errors.Is(err, documents.ErrOutdatedVersion),
+		errors.Is(err, documents.ErrDocumentChanged), errors.Is(err, documents.ErrAnswersRequired):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
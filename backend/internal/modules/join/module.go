@@ -0,0 +1,34 @@
+package join
+
+import (
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/organization"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/user"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, orgSvc organization.Service, plansSvc plans.Service, memberSvc member.Service, userSvc user.Service, documentsSvc documents.Service, cfg Config) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, orgSvc, plansSvc, memberSvc, userSvc, documentsSvc, cfg)
+	handler := NewHandler(service, cfg.RateLimit, cfg.RateWindow)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
@@ -0,0 +1,56 @@
+package join
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	CreateSignup(ctx context.Context, signup *Signup) error
+	// SetMarketingEmails stores the marketing opt-in from the join form as
+	// the member's notification preference.
+	SetMarketingEmails(ctx context.Context, memberID uuid.UUID, enabled bool) error
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+func (r *repositoryImpl) CreateSignup(ctx context.Context, s *Signup) error {
+	query := `
+		INSERT INTO member_signups (
+			organization_id, member_id, plan_id, subscription_id, email,
+			terms_accepted_at, marketing_opt_in, ip_address, user_agent
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		s.OrganizationID,
+		s.MemberID,
+		s.PlanID,
+		s.SubscriptionID,
+		s.Email,
+		s.TermsAcceptedAt,
+		s.MarketingOptIn,
+		s.IPAddress,
+		s.UserAgent,
+	).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *repositoryImpl) SetMarketingEmails(ctx context.Context, memberID uuid.UUID, enabled bool) error {
+	query := `
+		INSERT INTO member_notification_preferences (member_id, marketing_emails)
+		VALUES ($1, $2)
+		ON CONFLICT (member_id) DO UPDATE SET
+			marketing_emails = EXCLUDED.marketing_emails,
+			updated_at = NOW()
+	`
+	_, err := r.db.Exec(ctx, query, memberID, enabled)
+	return err
+}
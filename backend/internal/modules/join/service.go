@@ -0,0 +1,339 @@
+package join
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"fitcore/internal/modules/branch"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/organization"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/user"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	joinNote   = "Joined online"
+	// listLimit covers every branch and plan of an organization.
+	listLimit = 100
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrBranchUnavailable    = errors.New("branch is not available for online sign-up")
+	ErrPlanUnavailable      = errors.New("plan is not available at this branch")
+	ErrEmailRegistered      = errors.New("an account with this email already exists; sign in or reset your password to continue")
+	ErrVerificationFailed   = errors.New("sign-up could not be verified, please try again")
+)
+
+type Config struct {
+	// CaptchaSecret enables Turnstile verification when set
+	CaptchaSecret    string
+	CaptchaVerifyURL string
+	// RateLimit is the number of sign-ups allowed per client IP per
+	// RateWindow
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+type Service interface {
+	// GetPage lists what an organization offers online: its active branches
+	// and the active membership plans, with the documents to sign.
+	GetPage(ctx context.Context, slug string) (*PageResponse, error)
+	// Join creates a lead member with an unpaid subscription and returns the
+	// checkout to pay it. The member and subscription become active when the
+	// payment webhook arrives.
+	Join(ctx context.Context, slug string, req *JoinRequest, meta RequestMeta) (*JoinResponse, error)
+}
+
+type serviceImpl struct {
+	repo         Repository
+	orgSvc       organization.Service
+	plansSvc     plans.Service
+	memberSvc    member.Service
+	userSvc      user.Service
+	documentsSvc documents.Service
+	cfg          Config
+	httpClient   *http.Client
+}
+
+func NewService(repo Repository, orgSvc organization.Service, plansSvc plans.Service, memberSvc member.Service, userSvc user.Service, documentsSvc documents.Service, cfg Config) Service {
+	return &serviceImpl{
+		repo:         repo,
+		orgSvc:       orgSvc,
+		plansSvc:     plansSvc,
+		memberSvc:    memberSvc,
+		userSvc:      userSvc,
+		documentsSvc: documentsSvc,
+		cfg:          cfg,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type offer struct {
+	org      *organization.Organization
+	branches []*branch.Branch
+	plans    []*plans.Plan
+}
+
+func (s *serviceImpl) offer(ctx context.Context, slug string) (*offer, error) {
+	org, err := s.orgSvc.GetOrganizationBySlug(ctx, slug)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	allBranches, err := s.orgSvc.ListBranchesByOrganization(ctx, org.ID, 1, listLimit)
+	if err != nil {
+		log.Printf("Service: failed to list branches for organization %s: %v", org.ID, err)
+		return nil, err
+	}
+	branches := make([]*branch.Branch, 0, len(allBranches))
+	for _, b := range allBranches {
+		if b.IsActive == nil || *b.IsActive {
+			branches = append(branches, b)
+		}
+	}
+
+	allPlans, err := s.plansSvc.ListPlansByOrganization(ctx, org.ID, 1, listLimit)
+	if err != nil {
+		log.Printf("Service: failed to list plans for organization %s: %v", org.ID, err)
+		return nil, err
+	}
+	// Personal training packs are bought on top of a membership, not to join.
+	offered := make([]*plans.Plan, 0, len(allPlans))
+	for _, p := range allPlans {
+		if p.IsActive != nil && !*p.IsActive {
+			continue
+		}
+		if p.SessionCredits != nil && *p.SessionCredits > 0 {
+			continue
+		}
+		offered = append(offered, p)
+	}
+
+	return &offer{org: org, branches: branches, plans: offered}, nil
+}
+
+func (s *serviceImpl) GetPage(ctx context.Context, slug string) (*PageResponse, error) {
+	o, err := s.offer(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PageResponse{
+		Organization: OrganizationSummary{
+			ID:      o.org.ID,
+			Name:    o.org.Name,
+			Slug:    o.org.Slug,
+			LogoURL: o.org.Logo(),
+		},
+		Branches: make([]BranchSummary, len(o.branches)),
+		Plans:    make([]*plans.PlanResponse, len(o.plans)),
+	}
+	for i, b := range o.branches {
+		page.Branches[i] = BranchSummary{
+			ID:       b.ID,
+			Name:     b.Name,
+			Address:  b.Address,
+			Phone:    b.Phone,
+			Email:    b.Email,
+			Timezone: b.Timezone,
+		}
+	}
+	for i, p := range o.plans {
+		page.Plans[i] = p.ToResponse()
+	}
+
+	docs, err := s.documentsSvc.ListOnboardingDocuments(ctx, o.org.ID)
+	if err != nil {
+		log.Printf("Service: failed to list documents for organization %s: %v", o.org.ID, err)
+		return nil, err
+	}
+	page.Documents = make([]*documents.DocumentResponse, len(docs))
+	for i, d := range docs {
+		page.Documents[i] = d.ToResponse()
+	}
+	return page, nil
+}
+
+func (s *serviceImpl) Join(ctx context.Context, slug string, req *JoinRequest, meta RequestMeta) (*JoinResponse, error) {
+	if req.Website != "" {
+		log.Printf("Service: Join rejected a filled honeypot from %s", meta.IPAddress)
+		return nil, ErrVerificationFailed
+	}
+	if err := s.verifyCaptcha(ctx, req.CaptchaToken, meta.IPAddress); err != nil {
+		return nil, err
+	}
+
+	o, err := s.offer(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	var b *branch.Branch
+	for _, candidate := range o.branches {
+		if candidate.ID == req.BranchID {
+			b = candidate
+		}
+	}
+	if b == nil {
+		return nil, ErrBranchUnavailable
+	}
+
+	var plan *plans.Plan
+	for _, candidate := range o.plans {
+		if candidate.ID == req.PlanID && offeredAt(candidate, b.ID) {
+			plan = candidate
+		}
+	}
+	if plan == nil {
+		return nil, ErrPlanUnavailable
+	}
+
+	if err := s.documentsSvc.ValidateOnboarding(ctx, o.org.ID, req.Signatures); err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if _, err := s.userSvc.GetUserByEmail(ctx, email); err == nil {
+		return nil, ErrEmailRegistered
+	}
+
+	createNewUser := true
+	status := string(member.MemberStatusLead)
+	// past_due keeps the membership from granting access until the checkout
+	// is paid.
+	subscriptionStatus := string(subscription.StatusPastDue)
+	joinDate := time.Now().Format(dateLayout)
+	note := joinNote
+	created, err := s.memberSvc.CreateMember(ctx, &member.CreateMemberRequest{
+		Email:              email,
+		CreateNewUser:      &createNewUser,
+		OrganizationID:     o.org.ID,
+		HomeBranchID:       &b.ID,
+		PlanID:             &plan.ID,
+		FirstName:          strings.TrimSpace(req.FirstName),
+		LastName:           strings.TrimSpace(req.LastName),
+		Phone:              req.Phone,
+		DateOfBirth:        req.DateOfBirth,
+		Status:             &status,
+		JoinDate:           &joinDate,
+		Notes:              &note,
+		SubscriptionStatus: &subscriptionStatus,
+	})
+	if err != nil {
+		log.Printf("Service: Join failed to create member %s in organization %s: %v", email, o.org.ID, err)
+		return nil, err
+	}
+
+	// The member and checkout exist by now, so failing to record consent or
+	// signatures is logged rather than failing a sign-up the person can already pay for.
+	signup := &Signup{
+		OrganizationID:  o.org.ID,
+		MemberID:        created.ID,
+		PlanID:          &plan.ID,
+		SubscriptionID:  &created.SubscriptionID,
+		Email:           email,
+		TermsAcceptedAt: time.Now(),
+		MarketingOptIn:  req.MarketingOptIn,
+		IPAddress:       optional(meta.IPAddress),
+		UserAgent:       optional(meta.UserAgent),
+	}
+	if err := s.repo.CreateSignup(ctx, signup); err != nil {
+		log.Printf("Service: Join failed to record consent for member %s: %v", created.ID, err)
+	}
+	if len(req.Signatures) > 0 {
+		docMeta := &documents.SignMeta{IPAddress: meta.IPAddress, UserAgent: meta.UserAgent}
+		if err := s.documentsSvc.SignOnboarding(ctx, created.ID, req.Signatures, docMeta); err != nil {
+			log.Printf("Service: Join failed to store signed documents for member %s: %v", created.ID, err)
+		}
+	}
+	if req.MarketingOptIn {
+		if err := s.repo.SetMarketingEmails(ctx, created.ID, true); err != nil {
+			log.Printf("Service: Join failed to save marketing opt-in for member %s: %v", created.ID, err)
+		}
+	}
+
+	log.Printf("Service: Member %s joined organization %s online with plan %s", created.ID, o.org.ID, plan.ID)
+	return &JoinResponse{
+		MemberID:       created.ID,
+		SubscriptionID: created.SubscriptionID,
+		InvoiceID:      created.InvoiceID,
+		CheckoutURL:    created.CheckoutURL,
+	}, nil
+}
+
+// verifyCaptcha checks a Turnstile token with Cloudflare. It passes
+// everything when no secret is configured.
+func (s *serviceImpl) verifyCaptcha(ctx context.Context, token, remoteIP string) error {
+	if s.cfg.CaptchaSecret == "" {
+		return nil
+	}
+	if token == "" {
+		return ErrVerificationFailed
+	}
+
+	form := url.Values{"secret": {s.cfg.CaptchaSecret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.CaptchaVerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("captcha verification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha verification: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("captcha verification: %w", err)
+	}
+	if !result.Success {
+		log.Printf("Service: Join captcha rejected for %s: %v", remoteIP, result.ErrorCodes)
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
+// offeredAt reports whether a plan is sold at branchID; plans without
+// branches are offered everywhere.
+func offeredAt(plan *plans.Plan, branchID uuid.UUID) bool {
+	if len(plan.BranchIDs) == 0 {
+		return true
+	}
+	for _, id := range plan.BranchIDs {
+		if id == branchID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
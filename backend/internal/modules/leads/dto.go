@@ -0,0 +1,199 @@
+package leads
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateSourceRequest struct {
+	OrganizationID uuid.UUID `json:"organizationId" validate:"required"`
+	Name           string    `json:"name" validate:"required,max=100"`
+}
+
+type UpdateSourceRequest struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	IsActive *bool   `json:"isActive,omitempty"`
+}
+
+type CreateLeadRequest struct {
+	BranchID   uuid.UUID  `json:"branchId" validate:"required"`
+	FirstName  string     `json:"firstName" validate:"required,max=255"`
+	LastName   string     `json:"lastName" validate:"required,max=255"`
+	Email      *string    `json:"email,omitempty" validate:"omitempty,email"`
+	Phone      *string    `json:"phone,omitempty" validate:"omitempty,max=50"`
+	Notes      *string    `json:"notes,omitempty"`
+	SourceID   *uuid.UUID `json:"sourceId,omitempty"`
+	AssignedTo *uuid.UUID `json:"assignedTo,omitempty"`
+}
+
+type UpdateLeadRequest struct {
+	FirstName  *string    `json:"firstName,omitempty" validate:"omitempty,max=255"`
+	LastName   *string    `json:"lastName,omitempty" validate:"omitempty,max=255"`
+	Email      *string    `json:"email,omitempty" validate:"omitempty,email"`
+	Phone      *string    `json:"phone,omitempty" validate:"omitempty,max=50"`
+	Notes      *string    `json:"notes,omitempty"`
+	SourceID   *uuid.UUID `json:"sourceId,omitempty"`
+	AssignedTo *uuid.UUID `json:"assignedTo,omitempty"`
+}
+
+// UpdateStageRequest moves a lead through the pipeline. won is reached by
+// paying, so it cannot be set here; lost needs a reason.
+type UpdateStageRequest struct {
+	Stage      string  `json:"stage" validate:"required,oneof=new contacted trial negotiating lost"`
+	LostReason *string `json:"lostReason,omitempty"`
+}
+
+// ConvertLeadRequest starts the lead's first membership. The lead gets a
+// login and a checkout; the member is activated when it is paid.
+type ConvertLeadRequest struct {
+	PlanID    uuid.UUID `json:"planId" validate:"required"`
+	StartDate *string   `json:"startDate,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type CreateTaskRequest struct {
+	Title string    `json:"title" validate:"required,max=255"`
+	Notes *string   `json:"notes,omitempty"`
+	DueAt time.Time `json:"dueAt" validate:"required"`
+	// AssignedTo defaults to the lead's assignee, then to the caller.
+	AssignedTo *uuid.UUID `json:"assignedTo,omitempty"`
+}
+
+type IssueTrialPassRequest struct {
+	// BranchID defaults to the lead's branch.
+	BranchID  *uuid.UUID `json:"branchId,omitempty"`
+	ValidFrom *time.Time `json:"validFrom,omitempty"`
+	Days      int        `json:"days" validate:"required,min=1,max=30"`
+	MaxVisits int        `json:"maxVisits" validate:"required,min=1,max=30"`
+}
+
+type LeadFilter struct {
+	OrganizationIDs []uuid.UUID
+	BranchIDs       []uuid.UUID
+	Stage           *string
+	SourceID        *uuid.UUID
+	AssignedTo      *uuid.UUID
+	Search          string
+	Page            int
+	Limit           int
+}
+
+type TaskFilter struct {
+	OrganizationIDs []uuid.UUID
+	BranchIDs       []uuid.UUID
+	AssignedTo      *uuid.UUID
+	// Open limits to tasks not completed yet.
+	Open bool
+	// DueBefore limits to tasks due before the time, e.g. now for overdue.
+	DueBefore *time.Time
+	Limit     int
+}
+
+// ConversionFilter selects leads created from From to To inclusive.
+type ConversionFilter struct {
+	OrganizationIDs []uuid.UUID
+	BranchIDs       []uuid.UUID
+	From            time.Time
+	To              time.Time
+}
+
+type SourceResponse struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Name           string    `json:"name"`
+	IsActive       bool      `json:"isActive"`
+}
+
+type LeadResponse struct {
+	ID                      uuid.UUID  `json:"id"`
+	OrganizationID          uuid.UUID  `json:"organizationId"`
+	BranchID                uuid.UUID  `json:"branchId"`
+	MemberID                uuid.UUID  `json:"memberId"`
+	FirstName               string     `json:"firstName"`
+	LastName                string     `json:"lastName"`
+	Email                   *string    `json:"email,omitempty"`
+	Phone                   *string    `json:"phone,omitempty"`
+	Notes                   *string    `json:"notes,omitempty"`
+	SourceID                *uuid.UUID `json:"sourceId,omitempty"`
+	SourceName              *string    `json:"sourceName,omitempty"`
+	AssignedTo              *uuid.UUID `json:"assignedTo,omitempty"`
+	AssignedToName          *string    `json:"assignedToName,omitempty"`
+	Stage                   string     `json:"stage"`
+	StageChangedAt          time.Time  `json:"stageChangedAt"`
+	LostReason              *string    `json:"lostReason,omitempty"`
+	ConvertedAt             *time.Time `json:"convertedAt,omitempty"`
+	ConvertedSubscriptionID *uuid.UUID `json:"convertedSubscriptionId,omitempty"`
+	// HasAccount is true once the lead has a login, after conversion started.
+	HasAccount bool      `json:"hasAccount"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// LeadDetailResponse is a lead with its follow-ups and trial passes.
+type LeadDetailResponse struct {
+	*LeadResponse
+	Tasks       []*TaskResponse      `json:"tasks"`
+	TrialPasses []*TrialPassResponse `json:"trialPasses"`
+}
+
+type TaskResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	LeadID      uuid.UUID  `json:"leadId"`
+	LeadName    string     `json:"leadName,omitempty"`
+	AssignedTo  *uuid.UUID `json:"assignedTo,omitempty"`
+	Title       string     `json:"title"`
+	Notes       *string    `json:"notes,omitempty"`
+	DueAt       time.Time  `json:"dueAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Overdue     bool       `json:"overdue"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type TrialPassResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	LeadID     uuid.UUID  `json:"leadId"`
+	MemberID   uuid.UUID  `json:"memberId"`
+	BranchID   uuid.UUID  `json:"branchId"`
+	ValidFrom  time.Time  `json:"validFrom"`
+	ValidUntil time.Time  `json:"validUntil"`
+	MaxVisits  int        `json:"maxVisits"`
+	VisitsUsed int        `json:"visitsUsed"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Usable     bool       `json:"usable"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type TrialCheckInResponse struct {
+	TrialPassID  uuid.UUID  `json:"trialPassId"`
+	MemberID     uuid.UUID  `json:"memberId"`
+	BranchID     uuid.UUID  `json:"branchId"`
+	CheckInTime  *time.Time `json:"checkInTime,omitempty"`
+	CheckOutTime *time.Time `json:"checkOutTime,omitempty"`
+	VisitsLeft   int        `json:"visitsLeft"`
+}
+
+type ConvertLeadResponse struct {
+	LeadID         uuid.UUID  `json:"leadId"`
+	MemberID       uuid.UUID  `json:"memberId"`
+	SubscriptionID uuid.UUID  `json:"subscriptionId"`
+	InvoiceID      *uuid.UUID `json:"invoiceId,omitempty"`
+	CheckoutURL    string     `json:"checkoutUrl,omitempty"`
+}
+
+type ConversionRowResponse struct {
+	ID             *uuid.UUID `json:"id,omitempty"`
+	Name           string     `json:"name"`
+	Leads          int        `json:"leads"`
+	Converted      int        `json:"converted"`
+	Lost           int        `json:"lost"`
+	ConversionRate float64    `json:"conversionRate"`
+	// AvgDaysToConvert is measured from lead creation to first payment.
+	AvgDaysToConvert *float64 `json:"avgDaysToConvert,omitempty"`
+}
+
+type ConversionReportResponse struct {
+	From     string                   `json:"from"`
+	To       string                   `json:"to"`
+	Total    *ConversionRowResponse   `json:"total"`
+	BySource []*ConversionRowResponse `json:"bySource"`
+	ByStaff  []*ConversionRowResponse `json:"byStaff"`
+}
@@ -0,0 +1,167 @@
+package leads
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Stage string
+
+const (
+	StageNew         Stage = "new"
+	StageContacted   Stage = "contacted"
+	StageTrial       Stage = "trial"
+	StageNegotiating Stage = "negotiating"
+	// StageWon is only reached through a paid checkout; see ProcessConversions.
+	StageWon  Stage = "won"
+	StageLost Stage = "lost"
+)
+
+type Source struct {
+	ID             uuid.UUID `db:"id"`
+	OrganizationID uuid.UUID `db:"organization_id"`
+	Name           string    `db:"name"`
+	IsActive       bool      `db:"is_active"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// Lead is a prospect in the sales pipeline. Name and phone live on the
+// member record, which has status lead until the first payment.
+type Lead struct {
+	ID                      uuid.UUID  `db:"id"`
+	OrganizationID          uuid.UUID  `db:"organization_id"`
+	BranchID                uuid.UUID  `db:"branch_id"`
+	MemberID                uuid.UUID  `db:"member_id"`
+	SourceID                *uuid.UUID `db:"source_id"`
+	AssignedTo              *uuid.UUID `db:"assigned_to"`
+	Email                   *string    `db:"email"`
+	Stage                   Stage      `db:"stage"`
+	StageChangedAt          time.Time  `db:"stage_changed_at"`
+	LostReason              *string    `db:"lost_reason"`
+	ConvertedAt             *time.Time `db:"converted_at"`
+	ConvertedSubscriptionID *uuid.UUID `db:"converted_subscription_id"`
+	CreatedBy               *uuid.UUID `db:"created_by"`
+	CreatedAt               time.Time  `db:"created_at"`
+	UpdatedAt               time.Time  `db:"updated_at"`
+
+	// Joined from members, lead_sources and users.
+	FirstName        string  `db:"first_name"`
+	LastName         string  `db:"last_name"`
+	Phone            *string `db:"phone"`
+	Notes            *string `db:"notes"`
+	SourceName       *string `db:"source_name"`
+	AssignedToName   *string `db:"assigned_to_name"`
+	MemberHasAccount bool    `db:"member_has_account"`
+}
+
+type Task struct {
+	ID          uuid.UUID  `db:"id"`
+	LeadID      uuid.UUID  `db:"lead_id"`
+	AssignedTo  *uuid.UUID `db:"assigned_to"`
+	Title       string     `db:"title"`
+	Notes       *string    `db:"notes"`
+	DueAt       time.Time  `db:"due_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+	CreatedBy   *uuid.UUID `db:"created_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+
+	// Joined from the lead's member record.
+	LeadName string `db:"lead_name"`
+}
+
+// TrialPass lets a lead check in without a subscription between ValidFrom
+// and ValidUntil, up to MaxVisits times.
+type TrialPass struct {
+	ID         uuid.UUID  `db:"id"`
+	LeadID     uuid.UUID  `db:"lead_id"`
+	MemberID   uuid.UUID  `db:"member_id"`
+	BranchID   uuid.UUID  `db:"branch_id"`
+	ValidFrom  time.Time  `db:"valid_from"`
+	ValidUntil time.Time  `db:"valid_until"`
+	MaxVisits  int        `db:"max_visits"`
+	VisitsUsed int        `db:"visits_used"`
+	IssuedBy   *uuid.UUID `db:"issued_by"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// Usable reports whether the pass admits a visit at t.
+func (p *TrialPass) Usable(t time.Time) bool {
+	return p.RevokedAt == nil && !t.Before(p.ValidFrom) && t.Before(p.ValidUntil) && p.VisitsUsed < p.MaxVisits
+}
+
+// ConversionRow is one group of the conversion report.
+type ConversionRow struct {
+	ID               *uuid.UUID
+	Name             string
+	Leads            int
+	Converted        int
+	Lost             int
+	AvgDaysToConvert *float64
+}
+
+func (s *Source) ToResponse() *SourceResponse {
+	return &SourceResponse{
+		ID:             s.ID,
+		OrganizationID: s.OrganizationID,
+		Name:           s.Name,
+		IsActive:       s.IsActive,
+	}
+}
+
+func (l *Lead) ToResponse() *LeadResponse {
+	return &LeadResponse{
+		ID:                      l.ID,
+		OrganizationID:          l.OrganizationID,
+		BranchID:                l.BranchID,
+		MemberID:                l.MemberID,
+		FirstName:               l.FirstName,
+		LastName:                l.LastName,
+		Email:                   l.Email,
+		Phone:                   l.Phone,
+		Notes:                   l.Notes,
+		SourceID:                l.SourceID,
+		SourceName:              l.SourceName,
+		AssignedTo:              l.AssignedTo,
+		AssignedToName:          l.AssignedToName,
+		Stage:                   string(l.Stage),
+		StageChangedAt:          l.StageChangedAt,
+		LostReason:              l.LostReason,
+		ConvertedAt:             l.ConvertedAt,
+		ConvertedSubscriptionID: l.ConvertedSubscriptionID,
+		HasAccount:              l.MemberHasAccount,
+		CreatedAt:               l.CreatedAt,
+	}
+}
+
+func (t *Task) ToResponse() *TaskResponse {
+	return &TaskResponse{
+		ID:          t.ID,
+		LeadID:      t.LeadID,
+		LeadName:    t.LeadName,
+		AssignedTo:  t.AssignedTo,
+		Title:       t.Title,
+		Notes:       t.Notes,
+		DueAt:       t.DueAt,
+		CompletedAt: t.CompletedAt,
+		Overdue:     t.CompletedAt == nil && t.DueAt.Before(time.Now()),
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+func (p *TrialPass) ToResponse() *TrialPassResponse {
+	return &TrialPassResponse{
+		ID:         p.ID,
+		LeadID:     p.LeadID,
+		MemberID:   p.MemberID,
+		BranchID:   p.BranchID,
+		ValidFrom:  p.ValidFrom,
+		ValidUntil: p.ValidUntil,
+		MaxVisits:  p.MaxVisits,
+		VisitsUsed: p.VisitsUsed,
+		RevokedAt:  p.RevokedAt,
+		Usable:     p.Usable(time.Now()),
+		CreatedAt:  p.CreatedAt,
+	}
+}
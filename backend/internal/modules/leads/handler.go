@@ -0,0 +1,536 @@
+package leads
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/occupancy"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/leads", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware("super_admin", "admin", "staff"))
+
+		r.Get("/sources", h.ListSources)
+		r.With(middleware.RoleMiddleware("super_admin", "admin")).Post("/sources", h.CreateSource)
+		r.With(middleware.RoleMiddleware("super_admin", "admin")).Put("/sources/{id}", h.UpdateSource)
+
+		r.Get("/tasks", h.ListTasks)
+		r.Post("/tasks/{taskId}/complete", h.CompleteTask)
+
+		r.Post("/trial-passes/{passId}/revoke", h.RevokeTrialPass)
+		r.Post("/trial-passes/{passId}/check-in", h.TrialCheckIn)
+		r.Post("/trial-passes/{passId}/check-out", h.TrialCheckOut)
+
+		r.Get("/conversion", h.ConversionReport)
+
+		r.Get("/", h.ListLeads)
+		r.Post("/", h.CreateLead)
+		r.Get("/{id}", h.GetLead)
+		r.Put("/{id}", h.UpdateLead)
+		r.Put("/{id}/stage", h.UpdateStage)
+		r.Post("/{id}/convert", h.ConvertLead)
+		r.Post("/{id}/tasks", h.CreateTask)
+		r.Post("/{id}/trial-passes", h.IssueTrialPass)
+	})
+}
+
+func (h *Handler) ListSources(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	organizationID, ok := optionalUUID(w, r, "organizationId")
+	if !ok {
+		return
+	}
+
+	sources, err := h.service.ListSources(r.Context(), userID, userRole, organizationID)
+	if err != nil {
+		writeError(w, err, "Failed to list lead sources")
+		return
+	}
+	sourceResponses := make([]*SourceResponse, len(sources))
+	for i, s := range sources {
+		sourceResponses[i] = s.ToResponse()
+	}
+	response.Success(w, "Lead sources retrieved successfully", sourceResponses)
+}
+
+func (h *Handler) CreateSource(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req CreateSourceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	source, err := h.service.CreateSource(r.Context(), userID, userRole, &req)
+	if err != nil {
+		writeError(w, err, "Failed to create lead source")
+		return
+	}
+	response.Success(w, "Lead source created successfully", source.ToResponse())
+}
+
+func (h *Handler) UpdateSource(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid source ID")
+	if !ok {
+		return
+	}
+	var req UpdateSourceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	source, err := h.service.UpdateSource(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to update lead source")
+		return
+	}
+	response.Success(w, "Lead source updated successfully", source.ToResponse())
+}
+
+// ListLeads filters by stage, sourceId, assignedTo (a user ID or "me") and a
+// search over name, email and phone.
+func (h *Handler) ListLeads(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &LeadFilter{Search: query.Get("search")}
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if v := query.Get("stage"); v != "" {
+		filter.Stage = &v
+	}
+	if filter.SourceID, ok = optionalUUID(w, r, "sourceId"); !ok {
+		return
+	}
+	if filter.AssignedTo, ok = assigneeParam(w, r, userID); !ok {
+		return
+	}
+
+	leads, err := h.service.ListLeads(r.Context(), userID, userRole, filter)
+	if err != nil {
+		writeError(w, err, "Failed to list leads")
+		return
+	}
+	leadResponses := make([]*LeadResponse, len(leads))
+	for i, l := range leads {
+		leadResponses[i] = l.ToResponse()
+	}
+	response.Success(w, "Leads retrieved successfully", leadResponses)
+}
+
+func (h *Handler) CreateLead(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req CreateLeadRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	lead, err := h.service.CreateLead(r.Context(), userID, userRole, &req)
+	if err != nil {
+		writeError(w, err, "Failed to create lead")
+		return
+	}
+	response.Success(w, "Lead created successfully", lead.ToResponse())
+}
+
+func (h *Handler) GetLead(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid lead ID")
+	if !ok {
+		return
+	}
+
+	lead, err := h.service.GetLead(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to get lead")
+		return
+	}
+	response.Success(w, "Lead retrieved successfully", lead)
+}
+
+func (h *Handler) UpdateLead(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid lead ID")
+	if !ok {
+		return
+	}
+	var req UpdateLeadRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	lead, err := h.service.UpdateLead(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to update lead")
+		return
+	}
+	response.Success(w, "Lead updated successfully", lead.ToResponse())
+}
+
+func (h *Handler) UpdateStage(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid lead ID")
+	if !ok {
+		return
+	}
+	var req UpdateStageRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	lead, err := h.service.UpdateStage(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to update lead stage")
+		return
+	}
+	response.Success(w, "Lead stage updated successfully", lead.ToResponse())
+}
+
+// ConvertLead answers with the checkout URL the lead pays their first
+// membership at.
+func (h *Handler) ConvertLead(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid lead ID")
+	if !ok {
+		return
+	}
+	var req ConvertLeadRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.ConvertLead(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to convert lead")
+		return
+	}
+	response.Success(w, "Checkout created, the lead converts once it is paid", res)
+}
+
+func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid lead ID")
+	if !ok {
+		return
+	}
+	var req CreateTaskRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	task, err := h.service.CreateTask(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to create task")
+		return
+	}
+	response.Success(w, "Task created successfully", task.ToResponse())
+}
+
+// ListTasks lists follow-ups across leads. assignedTo takes a user ID or
+// "me", open=true hides completed tasks and overdue=true keeps open tasks
+// past their due time.
+func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &TaskFilter{}
+	if filter.AssignedTo, ok = assigneeParam(w, r, userID); !ok {
+		return
+	}
+	for _, name := range []string{"open", "overdue"} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		set, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(w, "Invalid "+name+" parameter, use true or false", nil)
+			return
+		}
+		if !set {
+			continue
+		}
+		filter.Open = true
+		if name == "overdue" {
+			now := time.Now()
+			filter.DueBefore = &now
+		}
+	}
+
+	tasks, err := h.service.ListTasks(r.Context(), userID, userRole, filter)
+	if err != nil {
+		writeError(w, err, "Failed to list tasks")
+		return
+	}
+	taskResponses := make([]*TaskResponse, len(tasks))
+	for i, t := range tasks {
+		taskResponses[i] = t.ToResponse()
+	}
+	response.Success(w, "Tasks retrieved successfully", taskResponses)
+}
+
+func (h *Handler) CompleteTask(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "taskId", "Invalid task ID")
+	if !ok {
+		return
+	}
+
+	task, err := h.service.CompleteTask(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to complete task")
+		return
+	}
+	response.Success(w, "Task completed successfully", task.ToResponse())
+}
+
+func (h *Handler) IssueTrialPass(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid lead ID")
+	if !ok {
+		return
+	}
+	var req IssueTrialPassRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	pass, err := h.service.IssueTrialPass(r.Context(), userID, userRole, id, &req)
+	if err != nil {
+		writeError(w, err, "Failed to issue trial pass")
+		return
+	}
+	response.Success(w, "Trial pass issued successfully", pass.ToResponse())
+}
+
+func (h *Handler) RevokeTrialPass(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "passId", "Invalid trial pass ID")
+	if !ok {
+		return
+	}
+
+	pass, err := h.service.RevokeTrialPass(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to revoke trial pass")
+		return
+	}
+	response.Success(w, "Trial pass revoked successfully", pass.ToResponse())
+}
+
+func (h *Handler) TrialCheckIn(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "passId", "Invalid trial pass ID")
+	if !ok {
+		return
+	}
+
+	res, err := h.service.TrialCheckIn(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to check in")
+		return
+	}
+	response.Success(w, "Checked in on trial pass", res)
+}
+
+func (h *Handler) TrialCheckOut(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "passId", "Invalid trial pass ID")
+	if !ok {
+		return
+	}
+
+	res, err := h.service.TrialCheckOut(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to check out")
+		return
+	}
+	response.Success(w, "Checked out", res)
+}
+
+// ConversionReport takes from and to (YYYY-MM-DD, default the last 90 days),
+// organizationId and branchId.
+func (h *Handler) ConversionReport(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var from, to time.Time
+	var err error
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(dateLayout, v); err != nil {
+			response.BadRequest(w, "Invalid from parameter", nil)
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(dateLayout, v); err != nil {
+			response.BadRequest(w, "Invalid to parameter", nil)
+			return
+		}
+	}
+	organizationID, ok := optionalUUID(w, r, "organizationId")
+	if !ok {
+		return
+	}
+	branchID, ok := optionalUUID(w, r, "branchId")
+	if !ok {
+		return
+	}
+
+	report, err := h.service.ConversionReport(r.Context(), userID, userRole, organizationID, branchID, from, to)
+	if err != nil {
+		writeError(w, err, "Failed to build conversion report")
+		return
+	}
+	response.Success(w, "Conversion report retrieved successfully", report)
+}
+
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrLeadNotFound), errors.Is(err, ErrSourceNotFound), errors.Is(err, ErrTaskNotFound), errors.Is(err, ErrTrialPassNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, ErrOrganizationAccess), errors.Is(err, ErrBranchAccess),
+		errors.Is(err, hours.ErrBranchClosed), errors.Is(err, hours.ErrOutsideAccessWindow),
+		errors.Is(err, documents.ErrSignatureRequired):
+		response.Forbidden(w, err.Error())
+	case errors.Is(err, ErrSourceExists), errors.Is(err, ErrDuplicateLead), errors.Is(err, ErrLeadConverted),
+		errors.Is(err, ErrEmailRegistered), errors.Is(err, ErrTrialPassActive), errors.Is(err, ErrAlreadyCheckedIn),
+		errors.Is(err, ErrNotCheckedIn), errors.Is(err, occupancy.ErrBranchAtCapacity):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, ErrInvalidSource), errors.Is(err, ErrInvalidAssignee), errors.Is(err, ErrLostReasonRequired),
+		errors.Is(err, ErrEmailRequired), errors.Is(err, ErrPlanUnavailable), errors.Is(err, ErrTrialPassUnusable),
+		errors.Is(err, ErrInvalidRange), errors.Is(err, ErrInvalidTrialPassDay):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return false
+	}
+	return response.ValidateStructAndWrite(w, req)
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		response.BadRequest(w, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func optionalUUID(w http.ResponseWriter, r *http.Request, param string) (*uuid.UUID, bool) {
+	v := r.URL.Query().Get(param)
+	if v == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		response.BadRequest(w, "Invalid "+param+" parameter", nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+// assigneeParam reads assignedTo, where "me" is the caller.
+func assigneeParam(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (*uuid.UUID, bool) {
+	if r.URL.Query().Get("assignedTo") == "me" {
+		return &userID, true
+	}
+	return optionalUUID(w, r, "assignedTo")
+}
+
+func callerFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+	return userID, userRole, true
+}
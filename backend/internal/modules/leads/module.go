@@ -0,0 +1,36 @@
+package leads
+
+import (
+	"fitcore/internal/modules/branch"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/occupancy"
+	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/user"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, userSvc user.Service, branchSvc branch.Service, memberSvc member.Service, subSvc subscription.Service, hoursSvc hours.Service, occupancySvc occupancy.Service, documentsSvc documents.Service) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, userSvc, branchSvc, memberSvc, subSvc, hoursSvc, occupancySvc, documentsSvc)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
@@ -0,0 +1,644 @@
+package leads
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	CreateSource(ctx context.Context, source *Source) error
+	UpdateSource(ctx context.Context, source *Source) error
+	GetSource(ctx context.Context, id uuid.UUID) (*Source, error)
+	ListSources(ctx context.Context, organizationIDs []uuid.UUID) ([]*Source, error)
+
+	// CreateLead creates the lead together with its member record.
+	CreateLead(ctx context.Context, lead *Lead) error
+	// UpdateLead saves the lead and the name, phone and notes on its member.
+	UpdateLead(ctx context.Context, lead *Lead) error
+	GetLead(ctx context.Context, id uuid.UUID) (*Lead, error)
+	ListLeads(ctx context.Context, filter *LeadFilter) ([]*Lead, error)
+
+	CreateTask(ctx context.Context, task *Task) error
+	GetTask(ctx context.Context, id uuid.UUID) (*Task, error)
+	ListTasks(ctx context.Context, filter *TaskFilter) ([]*Task, error)
+	ListLeadTasks(ctx context.Context, leadID uuid.UUID) ([]*Task, error)
+	CompleteTask(ctx context.Context, id uuid.UUID) (*Task, error)
+
+	CreateTrialPass(ctx context.Context, pass *TrialPass) error
+	GetTrialPass(ctx context.Context, id uuid.UUID) (*TrialPass, error)
+	ListLeadTrialPasses(ctx context.Context, leadID uuid.UUID) ([]*TrialPass, error)
+	RevokeTrialPass(ctx context.Context, id uuid.UUID) error
+	HasOpenCheckIn(ctx context.Context, memberID uuid.UUID) (bool, error)
+	// UseTrialVisit uses one visit of the pass inside the check-in
+	// transaction. It returns pgx.ErrNoRows when the pass is no longer usable.
+	UseTrialVisit(ctx context.Context, tx pgx.Tx, pass *TrialPass) error
+	// CheckOutTrialPass closes the open check-in made with the pass and
+	// returns pgx.ErrNoRows when there is none.
+	CheckOutTrialPass(ctx context.Context, passID uuid.UUID) (time.Time, error)
+
+	// ProcessConversions marks leads whose member has paid an invoice since
+	// the lead was created as won.
+	ProcessConversions(ctx context.Context) (int64, error)
+	ConversionBySource(ctx context.Context, filter *ConversionFilter) ([]*ConversionRow, error)
+	ConversionByStaff(ctx context.Context, filter *ConversionFilter) ([]*ConversionRow, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+func (r *repositoryImpl) CreateSource(ctx context.Context, s *Source) error {
+	query := `
+		INSERT INTO lead_sources (organization_id, name, is_active)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query, s.OrganizationID, s.Name, s.IsActive).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *repositoryImpl) UpdateSource(ctx context.Context, s *Source) error {
+	query := `UPDATE lead_sources SET name = $1, is_active = $2 WHERE id = $3`
+	_, err := r.db.Exec(ctx, query, s.Name, s.IsActive, s.ID)
+	return err
+}
+
+func (r *repositoryImpl) GetSource(ctx context.Context, id uuid.UUID) (*Source, error) {
+	query := `
+		SELECT id, organization_id, name, is_active, created_at
+		FROM lead_sources
+		WHERE id = $1
+	`
+	var s Source
+	err := r.db.QueryRow(ctx, query, id).Scan(&s.ID, &s.OrganizationID, &s.Name, &s.IsActive, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *repositoryImpl) ListSources(ctx context.Context, organizationIDs []uuid.UUID) ([]*Source, error) {
+	query := `
+		SELECT id, organization_id, name, is_active, created_at
+		FROM lead_sources
+		WHERE ($1::uuid[] IS NULL OR organization_id = ANY($1))
+		ORDER BY name
+	`
+	rows, err := r.db.Query(ctx, query, organizationIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []*Source{}
+	for rows.Next() {
+		var s Source
+		if err := rows.Scan(&s.ID, &s.OrganizationID, &s.Name, &s.IsActive, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		sources = append(sources, &s)
+	}
+	return sources, rows.Err()
+}
+
+const leadColumns = `
+	l.id, l.organization_id, l.branch_id, l.member_id, l.source_id, l.assigned_to, l.email,
+	l.stage, l.stage_changed_at, l.lost_reason, l.converted_at, l.converted_subscription_id,
+	l.created_by, l.created_at, l.updated_at,
+	m.first_name, m.last_name, m.phone, m.notes, s.name,
+	NULLIF(TRIM(CONCAT_WS(' ', u.first_name, u.last_name)), ''), m.user_id IS NOT NULL
+`
+
+const leadFrom = `
+	FROM leads l
+	JOIN members m ON m.id = l.member_id
+	LEFT JOIN lead_sources s ON s.id = l.source_id
+	LEFT JOIN users u ON u.id = l.assigned_to
+`
+
+func scanLead(row pgx.Row) (*Lead, error) {
+	var l Lead
+	err := row.Scan(
+		&l.ID,
+		&l.OrganizationID,
+		&l.BranchID,
+		&l.MemberID,
+		&l.SourceID,
+		&l.AssignedTo,
+		&l.Email,
+		&l.Stage,
+		&l.StageChangedAt,
+		&l.LostReason,
+		&l.ConvertedAt,
+		&l.ConvertedSubscriptionID,
+		&l.CreatedBy,
+		&l.CreatedAt,
+		&l.UpdatedAt,
+		&l.FirstName,
+		&l.LastName,
+		&l.Phone,
+		&l.Notes,
+		&l.SourceName,
+		&l.AssignedToName,
+		&l.MemberHasAccount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *repositoryImpl) CreateLead(ctx context.Context, l *Lead) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Leads have no login and no join date until they convert.
+	memberQuery := `
+		SELECT id FROM create_new_member(
+			NULL, $1, $2, $3, $4, $5, NULL, 'lead', NULL, $6
+		)
+	`
+	if err := tx.QueryRow(ctx, memberQuery,
+		l.OrganizationID,
+		l.BranchID,
+		l.FirstName,
+		l.LastName,
+		l.Phone,
+		l.Notes,
+	).Scan(&l.MemberID); err != nil {
+		return err
+	}
+
+	leadQuery := `
+		INSERT INTO leads (
+			organization_id, branch_id, member_id, source_id, assigned_to, email, stage, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, stage_changed_at, created_at, updated_at
+	`
+	if err := tx.QueryRow(ctx, leadQuery,
+		l.OrganizationID,
+		l.BranchID,
+		l.MemberID,
+		l.SourceID,
+		l.AssignedTo,
+		l.Email,
+		l.Stage,
+		l.CreatedBy,
+	).Scan(&l.ID, &l.StageChangedAt, &l.CreatedAt, &l.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) UpdateLead(ctx context.Context, l *Lead) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	memberQuery := `
+		UPDATE members
+		SET first_name = $1, last_name = $2, phone = $3, notes = $4, updated_at = NOW()
+		WHERE id = $5
+	`
+	if _, err := tx.Exec(ctx, memberQuery, l.FirstName, l.LastName, l.Phone, l.Notes, l.MemberID); err != nil {
+		return err
+	}
+
+	leadQuery := `
+		UPDATE leads
+		SET source_id = $1,
+			assigned_to = $2,
+			email = $3,
+			stage = $4,
+			stage_changed_at = $5,
+			lost_reason = $6,
+			updated_at = NOW()
+		WHERE id = $7
+		RETURNING updated_at
+	`
+	if err := tx.QueryRow(ctx, leadQuery,
+		l.SourceID,
+		l.AssignedTo,
+		l.Email,
+		l.Stage,
+		l.StageChangedAt,
+		l.LostReason,
+		l.ID,
+	).Scan(&l.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetLead(ctx context.Context, id uuid.UUID) (*Lead, error) {
+	query := `SELECT ` + leadColumns + leadFrom + ` WHERE l.id = $1 AND m.deleted_at IS NULL`
+	return scanLead(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) ListLeads(ctx context.Context, filter *LeadFilter) ([]*Lead, error) {
+	query := `SELECT ` + leadColumns + leadFrom + ` WHERE m.deleted_at IS NULL`
+
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OrganizationIDs != nil {
+		query += " AND l.organization_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.OrganizationIDs)
+		argIndex++
+	}
+
+	if filter.BranchIDs != nil {
+		query += " AND l.branch_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.BranchIDs)
+		argIndex++
+	}
+
+	if filter.Stage != nil {
+		query += " AND l.stage = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.Stage)
+		argIndex++
+	}
+
+	if filter.SourceID != nil {
+		query += " AND l.source_id = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.SourceID)
+		argIndex++
+	}
+
+	if filter.AssignedTo != nil {
+		query += " AND l.assigned_to = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.AssignedTo)
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		placeholder := "$" + strconv.Itoa(argIndex)
+		query += " AND (m.first_name || ' ' || m.last_name ILIKE " + placeholder +
+			" OR l.email ILIKE " + placeholder + " OR m.phone ILIKE " + placeholder + ")"
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	query += " ORDER BY l.created_at DESC"
+	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leads := []*Lead{}
+	for rows.Next() {
+		l, err := scanLead(rows)
+		if err != nil {
+			return nil, err
+		}
+		leads = append(leads, l)
+	}
+	return leads, rows.Err()
+}
+
+const taskColumns = `
+	t.id, t.lead_id, t.assigned_to, t.title, t.notes, t.due_at, t.completed_at, t.created_by, t.created_at,
+	m.first_name || ' ' || m.last_name
+`
+
+const taskFrom = `
+	FROM lead_tasks t
+	JOIN leads l ON l.id = t.lead_id
+	JOIN members m ON m.id = l.member_id
+`
+
+func scanTask(row pgx.Row) (*Task, error) {
+	var t Task
+	err := row.Scan(
+		&t.ID,
+		&t.LeadID,
+		&t.AssignedTo,
+		&t.Title,
+		&t.Notes,
+		&t.DueAt,
+		&t.CompletedAt,
+		&t.CreatedBy,
+		&t.CreatedAt,
+		&t.LeadName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repositoryImpl) CreateTask(ctx context.Context, t *Task) error {
+	query := `
+		INSERT INTO lead_tasks (lead_id, assigned_to, title, notes, due_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		t.LeadID,
+		t.AssignedTo,
+		t.Title,
+		t.Notes,
+		t.DueAt,
+		t.CreatedBy,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *repositoryImpl) GetTask(ctx context.Context, id uuid.UUID) (*Task, error) {
+	query := `SELECT ` + taskColumns + taskFrom + ` WHERE t.id = $1`
+	return scanTask(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) ListTasks(ctx context.Context, filter *TaskFilter) ([]*Task, error) {
+	query := `SELECT ` + taskColumns + taskFrom + ` WHERE m.deleted_at IS NULL`
+
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OrganizationIDs != nil {
+		query += " AND l.organization_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.OrganizationIDs)
+		argIndex++
+	}
+
+	if filter.BranchIDs != nil {
+		query += " AND l.branch_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.BranchIDs)
+		argIndex++
+	}
+
+	if filter.AssignedTo != nil {
+		query += " AND t.assigned_to = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.AssignedTo)
+		argIndex++
+	}
+
+	if filter.Open {
+		query += " AND t.completed_at IS NULL"
+	}
+
+	if filter.DueBefore != nil {
+		query += " AND t.due_at < $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.DueBefore)
+		argIndex++
+	}
+
+	query += " ORDER BY t.due_at LIMIT $" + strconv.Itoa(argIndex)
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *repositoryImpl) ListLeadTasks(ctx context.Context, leadID uuid.UUID) ([]*Task, error) {
+	query := `SELECT ` + taskColumns + taskFrom + ` WHERE t.lead_id = $1 ORDER BY t.due_at`
+	rows, err := r.db.Query(ctx, query, leadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *repositoryImpl) CompleteTask(ctx context.Context, id uuid.UUID) (*Task, error) {
+	query := `
+		UPDATE lead_tasks
+		SET completed_at = COALESCE(completed_at, NOW())
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return nil, err
+	}
+	return r.GetTask(ctx, id)
+}
+
+const trialPassColumns = `
+	id, lead_id, member_id, branch_id, valid_from, valid_until, max_visits, visits_used, issued_by, revoked_at, created_at
+`
+
+func scanTrialPass(row pgx.Row) (*TrialPass, error) {
+	var p TrialPass
+	err := row.Scan(
+		&p.ID,
+		&p.LeadID,
+		&p.MemberID,
+		&p.BranchID,
+		&p.ValidFrom,
+		&p.ValidUntil,
+		&p.MaxVisits,
+		&p.VisitsUsed,
+		&p.IssuedBy,
+		&p.RevokedAt,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *repositoryImpl) CreateTrialPass(ctx context.Context, p *TrialPass) error {
+	query := `
+		INSERT INTO trial_passes (lead_id, member_id, branch_id, valid_from, valid_until, max_visits, issued_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		p.LeadID,
+		p.MemberID,
+		p.BranchID,
+		p.ValidFrom,
+		p.ValidUntil,
+		p.MaxVisits,
+		p.IssuedBy,
+	).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *repositoryImpl) GetTrialPass(ctx context.Context, id uuid.UUID) (*TrialPass, error) {
+	query := `SELECT ` + trialPassColumns + ` FROM trial_passes WHERE id = $1`
+	return scanTrialPass(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) ListLeadTrialPasses(ctx context.Context, leadID uuid.UUID) ([]*TrialPass, error) {
+	query := `SELECT ` + trialPassColumns + ` FROM trial_passes WHERE lead_id = $1 ORDER BY valid_from DESC`
+	rows, err := r.db.Query(ctx, query, leadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passes := []*TrialPass{}
+	for rows.Next() {
+		p, err := scanTrialPass(rows)
+		if err != nil {
+			return nil, err
+		}
+		passes = append(passes, p)
+	}
+	return passes, rows.Err()
+}
+
+func (r *repositoryImpl) RevokeTrialPass(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE trial_passes SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *repositoryImpl) HasOpenCheckIn(ctx context.Context, memberID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM check_ins
+			WHERE member_id = $1 AND check_out_time IS NULL AND deleted_at IS NULL
+		)
+	`
+	var open bool
+	err := r.db.QueryRow(ctx, query, memberID).Scan(&open)
+	return open, err
+}
+
+func (r *repositoryImpl) UseTrialVisit(ctx context.Context, tx pgx.Tx, p *TrialPass) error {
+	// The guard in the WHERE clause keeps concurrent scans from using more
+	// visits than the pass has.
+	query := `
+		UPDATE trial_passes
+		SET visits_used = visits_used + 1
+		WHERE id = $1
+			AND revoked_at IS NULL
+			AND visits_used < max_visits
+			AND NOW() >= valid_from AND NOW() < valid_until
+		RETURNING visits_used
+	`
+	return tx.QueryRow(ctx, query, p.ID).Scan(&p.VisitsUsed)
+}
+
+func (r *repositoryImpl) CheckOutTrialPass(ctx context.Context, passID uuid.UUID) (time.Time, error) {
+	query := `
+		UPDATE check_ins
+		SET check_out_time = NOW()
+		WHERE trial_pass_id = $1 AND check_out_time IS NULL AND deleted_at IS NULL
+		RETURNING check_out_time
+	`
+	var checkOutTime time.Time
+	err := r.db.QueryRow(ctx, query, passID).Scan(&checkOutTime)
+	return checkOutTime, err
+}
+
+func (r *repositoryImpl) ProcessConversions(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE leads l
+		SET stage = 'won',
+			stage_changed_at = p.paid_at,
+			converted_at = p.paid_at,
+			converted_subscription_id = p.subscription_id,
+			updated_at = NOW()
+		FROM (
+			SELECT DISTINCT ON (i.member_id) i.member_id, i.paid_at, i.subscription_id
+			FROM invoices i
+			JOIN leads pending ON pending.member_id = i.member_id AND pending.converted_at IS NULL
+			WHERE i.status = 'paid' AND i.paid_at >= pending.created_at
+			ORDER BY i.member_id, i.paid_at
+		) p
+		WHERE l.member_id = p.member_id AND l.converted_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repositoryImpl) ConversionBySource(ctx context.Context, filter *ConversionFilter) ([]*ConversionRow, error) {
+	return r.conversion(ctx, filter, "s.id", "COALESCE(s.name, 'No source')")
+}
+
+func (r *repositoryImpl) ConversionByStaff(ctx context.Context, filter *ConversionFilter) ([]*ConversionRow, error) {
+	return r.conversion(ctx, filter, "u.id", "COALESCE(NULLIF(TRIM(CONCAT_WS(' ', u.first_name, u.last_name)), ''), u.email, 'Unassigned')")
+}
+
+// conversion counts leads created in the filter range grouped by idExpr.
+// Leads count as converted once paid, whatever stage they were in.
+func (r *repositoryImpl) conversion(ctx context.Context, filter *ConversionFilter, idExpr, nameExpr string) ([]*ConversionRow, error) {
+	query := `
+		SELECT ` + idExpr + `, ` + nameExpr + `,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE l.converted_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE l.converted_at IS NULL AND l.stage = 'lost'),
+			AVG(EXTRACT(EPOCH FROM l.converted_at - l.created_at) / 86400) FILTER (WHERE l.converted_at IS NOT NULL)
+		FROM leads l
+		JOIN members m ON m.id = l.member_id
+		LEFT JOIN lead_sources s ON s.id = l.source_id
+		LEFT JOIN users u ON u.id = l.assigned_to
+		WHERE m.deleted_at IS NULL AND l.created_at >= $1 AND l.created_at < $2
+	`
+	args := []interface{}{filter.From, filter.To.AddDate(0, 0, 1)}
+	argIndex := 3
+
+	if filter.OrganizationIDs != nil {
+		query += " AND l.organization_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.OrganizationIDs)
+		argIndex++
+	}
+
+	if filter.BranchIDs != nil {
+		query += " AND l.branch_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.BranchIDs)
+	}
+
+	query += " GROUP BY 1, 2 ORDER BY 3 DESC, 2"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*ConversionRow{}
+	for rows.Next() {
+		var row ConversionRow
+		if err := rows.Scan(&row.ID, &row.Name, &row.Leads, &row.Converted, &row.Lost, &row.AvgDaysToConvert); err != nil {
+			return nil, err
+		}
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,867 @@
+package leads
+
+import (
+	"context"
+	"errors"
+	"fitcore/internal/middleware"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"fitcore/internal/modules/branch"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/occupancy"
+	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/hash"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	defaultRangeDays = 90
+	maxRangeDays     = 366
+	maxTasks         = 200
+)
+
+var (
+	ErrLeadNotFound        = errors.New("lead not found")
+	ErrSourceNotFound      = errors.New("lead source not found")
+	ErrTaskNotFound        = errors.New("task not found")
+	ErrTrialPassNotFound   = errors.New("trial pass not found")
+	ErrOrganizationAccess  = errors.New("organization is outside your access")
+	ErrBranchAccess        = errors.New("branch is outside your assignments")
+	ErrSourceExists        = errors.New("a lead source with this name already exists")
+	ErrDuplicateLead       = errors.New("a lead with this email already exists")
+	ErrInvalidSource       = errors.New("lead source is not active in this organization")
+	ErrInvalidAssignee     = errors.New("assignee must be staff of this organization")
+	ErrLostReasonRequired  = errors.New("a reason is required to mark a lead as lost")
+	ErrLeadConverted       = errors.New("lead has already converted")
+	ErrEmailRequired       = errors.New("lead needs an email address to convert")
+	ErrEmailRegistered     = errors.New("an account with this email already exists")
+	ErrPlanUnavailable     = errors.New("plan is not available at the lead's branch")
+	ErrTrialPassActive     = errors.New("lead already has an unused trial pass")
+	ErrTrialPassUnusable   = errors.New("trial pass is revoked, expired or used up")
+	ErrAlreadyCheckedIn    = errors.New("lead is already checked in")
+	ErrNotCheckedIn        = errors.New("no open check-in for this trial pass")
+	ErrInvalidRange        = errors.New("from must not be after to, and the range must not exceed 366 days")
+	ErrInvalidTrialPassDay = errors.New("validFrom must not be in the past")
+)
+
+// Service is the sales pipeline. Every call is scoped to the caller: admins
+// to their organizations and staff to their branches.
+type Service interface {
+	ListSources(ctx context.Context, userID uuid.UUID, userRole string, organizationID *uuid.UUID) ([]*Source, error)
+	CreateSource(ctx context.Context, userID uuid.UUID, userRole string, req *CreateSourceRequest) (*Source, error)
+	UpdateSource(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateSourceRequest) (*Source, error)
+
+	CreateLead(ctx context.Context, userID uuid.UUID, userRole string, req *CreateLeadRequest) (*Lead, error)
+	GetLead(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*LeadDetailResponse, error)
+	ListLeads(ctx context.Context, userID uuid.UUID, userRole string, filter *LeadFilter) ([]*Lead, error)
+	UpdateLead(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateLeadRequest) (*Lead, error)
+	UpdateStage(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateStageRequest) (*Lead, error)
+	// ConvertLead gives the lead a login and starts a checkout for their
+	// first membership. The conversion is recorded once it is paid.
+	ConvertLead(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *ConvertLeadRequest) (*ConvertLeadResponse, error)
+
+	CreateTask(ctx context.Context, userID uuid.UUID, userRole string, leadID uuid.UUID, req *CreateTaskRequest) (*Task, error)
+	ListTasks(ctx context.Context, userID uuid.UUID, userRole string, filter *TaskFilter) ([]*Task, error)
+	CompleteTask(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Task, error)
+
+	IssueTrialPass(ctx context.Context, userID uuid.UUID, userRole string, leadID uuid.UUID, req *IssueTrialPassRequest) (*TrialPass, error)
+	RevokeTrialPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TrialPass, error)
+	// TrialCheckIn admits a lead at the front desk on their trial pass,
+	// under the same opening hours and capacity rules as members.
+	TrialCheckIn(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TrialCheckInResponse, error)
+	TrialCheckOut(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TrialCheckInResponse, error)
+
+	// ConversionReport compares leads created between from and to, and how
+	// many converted, by source and by assigned staff member.
+	ConversionReport(ctx context.Context, userID uuid.UUID, userRole string, organizationID, branchID *uuid.UUID, from, to time.Time) (*ConversionReportResponse, error)
+	// ProcessConversions marks paid leads as won; it is the scheduled job.
+	ProcessConversions(ctx context.Context) (int64, error)
+}
+
+type serviceImpl struct {
+	repo         Repository
+	userSvc      user.Service
+	branchSvc    branch.Service
+	memberSvc    member.Service
+	subSvc       subscription.Service
+	hoursSvc     hours.Service
+	occupancySvc occupancy.Service
+	documentsSvc documents.Service
+}
+
+func NewService(repo Repository, userSvc user.Service, branchSvc branch.Service, memberSvc member.Service, subSvc subscription.Service, hoursSvc hours.Service, occupancySvc occupancy.Service, documentsSvc documents.Service) Service {
+	return &serviceImpl{
+		repo:         repo,
+		userSvc:      userSvc,
+		branchSvc:    branchSvc,
+		memberSvc:    memberSvc,
+		subSvc:       subSvc,
+		hoursSvc:     hoursSvc,
+		occupancySvc: occupancySvc,
+		documentsSvc: documentsSvc,
+	}
+}
+
+// lead loads a lead the caller may see; others are reported as missing.
+func (s *serviceImpl) lead(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Lead, *middleware.Scope, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := s.repo.GetLead(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrLeadNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !sc.Allows(l.OrganizationID, &l.BranchID) {
+		return nil, nil, ErrLeadNotFound
+	}
+	return l, sc, nil
+}
+
+func (s *serviceImpl) ListSources(ctx context.Context, userID uuid.UUID, userRole string, organizationID *uuid.UUID) ([]*Source, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if organizationID != nil {
+		if orgIDs != nil && !slices.Contains(orgIDs, *organizationID) {
+			return nil, ErrOrganizationAccess
+		}
+		orgIDs = []uuid.UUID{*organizationID}
+	}
+	return s.repo.ListSources(ctx, orgIDs)
+}
+
+// organizationScope returns the organizations the caller works in; nil means
+// every organization. Staff are scoped by the organizations of their
+// branches.
+func (s *serviceImpl) organizationScope(ctx context.Context, userID uuid.UUID, userRole string) ([]uuid.UUID, error) {
+	if userRole == "super_admin" {
+		return nil, nil
+	}
+	orgIDs, err := s.userSvc.GetUserOrganizationIDs(ctx, userID)
+	if err != nil {
+		log.Printf("Service: failed to list organizations for user %s: %v", userID, err)
+		return nil, err
+	}
+	return orgIDs, nil
+}
+
+func (s *serviceImpl) CreateSource(ctx context.Context, userID uuid.UUID, userRole string, req *CreateSourceRequest) (*Source, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if orgIDs != nil && !slices.Contains(orgIDs, req.OrganizationID) {
+		return nil, ErrOrganizationAccess
+	}
+
+	source := &Source{OrganizationID: req.OrganizationID, Name: strings.TrimSpace(req.Name), IsActive: true}
+	if err := s.repo.CreateSource(ctx, source); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrSourceExists
+		}
+		log.Printf("Service: CreateSource failed for organization %s: %v", req.OrganizationID, err)
+		return nil, err
+	}
+	return source, nil
+}
+
+func (s *serviceImpl) UpdateSource(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateSourceRequest) (*Source, error) {
+	source, err := s.repo.GetSource(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if orgIDs != nil && !slices.Contains(orgIDs, source.OrganizationID) {
+		return nil, ErrSourceNotFound
+	}
+
+	if req.Name != nil {
+		source.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.IsActive != nil {
+		source.IsActive = *req.IsActive
+	}
+	if err := s.repo.UpdateSource(ctx, source); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrSourceExists
+		}
+		log.Printf("Service: UpdateSource failed for source %s: %v", id, err)
+		return nil, err
+	}
+	return source, nil
+}
+
+func (s *serviceImpl) CreateLead(ctx context.Context, userID uuid.UUID, userRole string, req *CreateLeadRequest) (*Lead, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.branchSvc.GetBranch(ctx, req.BranchID)
+	if err != nil {
+		return nil, ErrBranchAccess
+	}
+	if !sc.Allows(b.OrganizationID, &b.ID) {
+		return nil, ErrBranchAccess
+	}
+
+	lead := &Lead{
+		OrganizationID: b.OrganizationID,
+		BranchID:       b.ID,
+		FirstName:      strings.TrimSpace(req.FirstName),
+		LastName:       strings.TrimSpace(req.LastName),
+		Email:          normalizeEmail(req.Email),
+		Phone:          req.Phone,
+		Notes:          req.Notes,
+		SourceID:       req.SourceID,
+		AssignedTo:     req.AssignedTo,
+		Stage:          StageNew,
+		CreatedBy:      &userID,
+	}
+	if err := s.checkSource(ctx, lead.OrganizationID, lead.SourceID); err != nil {
+		return nil, err
+	}
+	if err := s.checkAssignee(ctx, lead.OrganizationID, lead.AssignedTo); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateLead(ctx, lead); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateLead
+		}
+		log.Printf("Service: CreateLead failed in branch %s: %v", b.ID, err)
+		return nil, err
+	}
+	log.Printf("Service: Lead %s created in branch %s by user %s", lead.ID, b.ID, userID)
+	return s.repo.GetLead(ctx, lead.ID)
+}
+
+func (s *serviceImpl) GetLead(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*LeadDetailResponse, error) {
+	l, _, err := s.lead(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.repo.ListLeadTasks(ctx, l.ID)
+	if err != nil {
+		return nil, err
+	}
+	passes, err := s.repo.ListLeadTrialPasses(ctx, l.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &LeadDetailResponse{
+		LeadResponse: l.ToResponse(),
+		Tasks:        make([]*TaskResponse, len(tasks)),
+		TrialPasses:  make([]*TrialPassResponse, len(passes)),
+	}
+	for i, t := range tasks {
+		detail.Tasks[i] = t.ToResponse()
+	}
+	for i, p := range passes {
+		detail.TrialPasses[i] = p.ToResponse()
+	}
+	return detail, nil
+}
+
+func (s *serviceImpl) ListLeads(ctx context.Context, userID uuid.UUID, userRole string, filter *LeadFilter) ([]*Lead, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	filter.OrganizationIDs = sc.OrganizationIDs
+	filter.BranchIDs = sc.BranchIDs
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return s.repo.ListLeads(ctx, filter)
+}
+
+func (s *serviceImpl) UpdateLead(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateLeadRequest) (*Lead, error) {
+	l, _, err := s.lead(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FirstName != nil {
+		l.FirstName = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		l.LastName = strings.TrimSpace(*req.LastName)
+	}
+	if req.Email != nil {
+		// The email becomes the login at conversion, so it is fixed after.
+		if l.MemberHasAccount {
+			return nil, ErrLeadConverted
+		}
+		l.Email = normalizeEmail(req.Email)
+	}
+	if req.Phone != nil {
+		l.Phone = req.Phone
+	}
+	if req.Notes != nil {
+		l.Notes = req.Notes
+	}
+	if req.SourceID != nil && (l.SourceID == nil || *l.SourceID != *req.SourceID) {
+		if err := s.checkSource(ctx, l.OrganizationID, req.SourceID); err != nil {
+			return nil, err
+		}
+		l.SourceID = req.SourceID
+	}
+	if req.AssignedTo != nil {
+		if err := s.checkAssignee(ctx, l.OrganizationID, req.AssignedTo); err != nil {
+			return nil, err
+		}
+		l.AssignedTo = req.AssignedTo
+	}
+
+	if err := s.repo.UpdateLead(ctx, l); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateLead
+		}
+		log.Printf("Service: UpdateLead failed for lead %s: %v", id, err)
+		return nil, err
+	}
+	return s.repo.GetLead(ctx, l.ID)
+}
+
+func (s *serviceImpl) UpdateStage(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *UpdateStageRequest) (*Lead, error) {
+	l, _, err := s.lead(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if l.ConvertedAt != nil {
+		return nil, ErrLeadConverted
+	}
+
+	stage := Stage(req.Stage)
+	if stage == StageLost {
+		if req.LostReason == nil || strings.TrimSpace(*req.LostReason) == "" {
+			return nil, ErrLostReasonRequired
+		}
+		l.LostReason = req.LostReason
+	} else {
+		l.LostReason = nil
+	}
+	if stage != l.Stage {
+		l.Stage = stage
+		l.StageChangedAt = time.Now()
+	}
+
+	if err := s.repo.UpdateLead(ctx, l); err != nil {
+		log.Printf("Service: UpdateStage failed for lead %s: %v", id, err)
+		return nil, err
+	}
+	log.Printf("Service: Lead %s moved to stage %s by user %s", l.ID, l.Stage, userID)
+	return l, nil
+}
+
+func (s *serviceImpl) ConvertLead(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, req *ConvertLeadRequest) (*ConvertLeadResponse, error) {
+	l, _, err := s.lead(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if l.ConvertedAt != nil {
+		return nil, ErrLeadConverted
+	}
+	if l.Email == nil {
+		return nil, ErrEmailRequired
+	}
+
+	m, err := s.memberSvc.GetMember(ctx, l.MemberID)
+	if err != nil {
+		log.Printf("Service: ConvertLead failed to get member %s: %v", l.MemberID, err)
+		return nil, err
+	}
+	available, err := s.memberSvc.ListAvailablePlans(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	// Personal training packs are bought on top of a membership, not to join.
+	offered := false
+	for _, plan := range available {
+		if plan.ID == req.PlanID && (plan.SessionCredits == nil || *plan.SessionCredits == 0) {
+			offered = true
+		}
+	}
+	if !offered {
+		return nil, ErrPlanUnavailable
+	}
+
+	startDate := time.Now().Format(dateLayout)
+	if req.StartDate != nil {
+		startDate = *req.StartDate
+	}
+
+	if m.UserID == nil {
+		password, err := hash.GenerateRandomPassword(16)
+		if err != nil {
+			return nil, err
+		}
+		// The paid webhook resets the password and sends the welcome email,
+		// so this one is never shared.
+		u, err := s.userSvc.CreateUser(ctx, &user.CreateUserRequest{
+			Email:     *l.Email,
+			Password:  password,
+			FirstName: l.FirstName,
+			LastName:  l.LastName,
+			Role:      "member",
+			BranchId:  &l.BranchID,
+		})
+		if errors.Is(err, user.ErrEmailAlreadyUsed) {
+			return nil, ErrEmailRegistered
+		}
+		if err != nil {
+			log.Printf("Service: ConvertLead failed to create account for lead %s: %v", l.ID, err)
+			return nil, err
+		}
+		if _, err := s.memberSvc.UpdateMember(ctx, m.ID, &member.UpdateMemberRequest{UserID: &u.ID, JoinDate: &startDate}); err != nil {
+			log.Printf("Service: ConvertLead failed to link account %s to member %s: %v", u.ID, m.ID, err)
+			return nil, err
+		}
+	}
+
+	// past_due keeps the membership from granting access until it is paid.
+	status := string(subscription.StatusPastDue)
+	res, err := s.subSvc.CreateSubscription(ctx, &subscription.CreateSubscriptionRequest{
+		MemberID:  m.ID,
+		PlanID:    &req.PlanID,
+		BranchID:  &l.BranchID,
+		StartDate: startDate,
+		Status:    &status,
+	}, "new")
+	if err != nil {
+		log.Printf("Service: ConvertLead failed to start checkout for lead %s: %v", l.ID, err)
+		return nil, err
+	}
+
+	if l.Stage != StageNegotiating {
+		l.Stage = StageNegotiating
+		l.StageChangedAt = time.Now()
+		l.LostReason = nil
+		if err := s.repo.UpdateLead(ctx, l); err != nil {
+			log.Printf("Service: ConvertLead failed to update stage of lead %s: %v", l.ID, err)
+		}
+	}
+
+	log.Printf("Service: Lead %s sent a checkout for plan %s by user %s", l.ID, req.PlanID, userID)
+	return &ConvertLeadResponse{
+		LeadID:         l.ID,
+		MemberID:       m.ID,
+		SubscriptionID: res.ID,
+		InvoiceID:      res.InvoiceID,
+		CheckoutURL:    res.CheckoutURL,
+	}, nil
+}
+
+func (s *serviceImpl) CreateTask(ctx context.Context, userID uuid.UUID, userRole string, leadID uuid.UUID, req *CreateTaskRequest) (*Task, error) {
+	l, _, err := s.lead(ctx, userID, userRole, leadID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignee := req.AssignedTo
+	if assignee != nil {
+		if err := s.checkAssignee(ctx, l.OrganizationID, assignee); err != nil {
+			return nil, err
+		}
+	} else if l.AssignedTo != nil {
+		assignee = l.AssignedTo
+	} else {
+		assignee = &userID
+	}
+
+	task := &Task{
+		LeadID:     l.ID,
+		AssignedTo: assignee,
+		Title:      strings.TrimSpace(req.Title),
+		Notes:      req.Notes,
+		DueAt:      req.DueAt,
+		CreatedBy:  &userID,
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		log.Printf("Service: CreateTask failed for lead %s: %v", l.ID, err)
+		return nil, err
+	}
+	task.LeadName = l.FirstName + " " + l.LastName
+	return task, nil
+}
+
+func (s *serviceImpl) ListTasks(ctx context.Context, userID uuid.UUID, userRole string, filter *TaskFilter) ([]*Task, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	filter.OrganizationIDs = sc.OrganizationIDs
+	filter.BranchIDs = sc.BranchIDs
+	filter.Limit = maxTasks
+	return s.repo.ListTasks(ctx, filter)
+}
+
+func (s *serviceImpl) CompleteTask(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Task, error) {
+	task, err := s.repo.GetTask(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := s.lead(ctx, userID, userRole, task.LeadID); err != nil {
+		if errors.Is(err, ErrLeadNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return s.repo.CompleteTask(ctx, id)
+}
+
+func (s *serviceImpl) IssueTrialPass(ctx context.Context, userID uuid.UUID, userRole string, leadID uuid.UUID, req *IssueTrialPassRequest) (*TrialPass, error) {
+	l, sc, err := s.lead(ctx, userID, userRole, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if l.ConvertedAt != nil {
+		return nil, ErrLeadConverted
+	}
+
+	branchID := l.BranchID
+	if req.BranchID != nil && *req.BranchID != l.BranchID {
+		b, err := s.branchSvc.GetBranch(ctx, *req.BranchID)
+		if err != nil || b.OrganizationID != l.OrganizationID || !sc.Allows(b.OrganizationID, &b.ID) {
+			return nil, ErrBranchAccess
+		}
+		branchID = b.ID
+	}
+
+	now := time.Now()
+	validFrom := now
+	if req.ValidFrom != nil {
+		if req.ValidFrom.Before(now.Add(-time.Minute)) {
+			return nil, ErrInvalidTrialPassDay
+		}
+		validFrom = *req.ValidFrom
+	}
+
+	passes, err := s.repo.ListLeadTrialPasses(ctx, l.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range passes {
+		if p.RevokedAt == nil && p.ValidUntil.After(now) && p.VisitsUsed < p.MaxVisits {
+			return nil, ErrTrialPassActive
+		}
+	}
+
+	pass := &TrialPass{
+		LeadID:     l.ID,
+		MemberID:   l.MemberID,
+		BranchID:   branchID,
+		ValidFrom:  validFrom,
+		ValidUntil: validFrom.AddDate(0, 0, req.Days),
+		MaxVisits:  req.MaxVisits,
+		IssuedBy:   &userID,
+	}
+	if err := s.repo.CreateTrialPass(ctx, pass); err != nil {
+		log.Printf("Service: IssueTrialPass failed for lead %s: %v", l.ID, err)
+		return nil, err
+	}
+
+	if l.Stage == StageNew || l.Stage == StageContacted || l.Stage == StageLost {
+		l.Stage = StageTrial
+		l.StageChangedAt = now
+		l.LostReason = nil
+		if err := s.repo.UpdateLead(ctx, l); err != nil {
+			log.Printf("Service: IssueTrialPass failed to update stage of lead %s: %v", l.ID, err)
+		}
+	}
+
+	log.Printf("Service: Trial pass %s issued to lead %s for %d days by user %s", pass.ID, l.ID, req.Days, userID)
+	return pass, nil
+}
+
+// trialPass loads a pass whose lead the caller may see.
+func (s *serviceImpl) trialPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TrialPass, error) {
+	pass, err := s.repo.GetTrialPass(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTrialPassNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := s.lead(ctx, userID, userRole, pass.LeadID); err != nil {
+		if errors.Is(err, ErrLeadNotFound) {
+			return nil, ErrTrialPassNotFound
+		}
+		return nil, err
+	}
+	return pass, nil
+}
+
+func (s *serviceImpl) RevokeTrialPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TrialPass, error) {
+	if _, err := s.trialPass(ctx, userID, userRole, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RevokeTrialPass(ctx, id); err != nil {
+		log.Printf("Service: RevokeTrialPass failed for pass %s: %v", id, err)
+		return nil, err
+	}
+	return s.repo.GetTrialPass(ctx, id)
+}
+
+func (s *serviceImpl) TrialCheckIn(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TrialCheckInResponse, error) {
+	pass, err := s.trialPass(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !pass.Usable(now) {
+		return nil, ErrTrialPassUnusable
+	}
+
+	open, err := s.repo.HasOpenCheckIn(ctx, pass.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, ErrAlreadyCheckedIn
+	}
+
+	if err := s.documentsSvc.CheckSigned(ctx, pass.MemberID); err != nil {
+		return nil, err
+	}
+
+	// Trial visitors follow the branch's hours but no plan access windows.
+	if err := s.hoursSvc.CheckAccess(ctx, pass.BranchID, nil, now); err != nil {
+		return nil, err
+	}
+	checkIn := &occupancy.CheckIn{
+		BranchID:    pass.BranchID,
+		MemberID:    pass.MemberID,
+		TrialPassID: &pass.ID,
+		Method:      "manual",
+		Claim: func(ctx context.Context, tx pgx.Tx) error {
+			return s.repo.UseTrialVisit(ctx, tx, pass)
+		},
+	}
+	err = s.occupancySvc.CheckIn(ctx, checkIn)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTrialPassUnusable
+	}
+	if err != nil {
+		log.Printf("Service: TrialCheckIn failed for pass %s: %v", pass.ID, err)
+		return nil, err
+	}
+
+	log.Printf("Service: Lead member %s checked in at branch %s on trial pass %s", pass.MemberID, pass.BranchID, pass.ID)
+	return &TrialCheckInResponse{
+		TrialPassID: pass.ID,
+		MemberID:    pass.MemberID,
+		BranchID:    pass.BranchID,
+		CheckInTime: &checkIn.CheckInTime,
+		VisitsLeft:  pass.MaxVisits - pass.VisitsUsed,
+	}, nil
+}
+
+func (s *serviceImpl) TrialCheckOut(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*TrialCheckInResponse, error) {
+	pass, err := s.trialPass(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+
+	checkOutTime, err := s.repo.CheckOutTrialPass(ctx, pass.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotCheckedIn
+	}
+	if err != nil {
+		log.Printf("Service: TrialCheckOut failed for pass %s: %v", pass.ID, err)
+		return nil, err
+	}
+	s.occupancySvc.PublishCheckOut(ctx, pass.BranchID, pass.MemberID)
+
+	return &TrialCheckInResponse{
+		TrialPassID:  pass.ID,
+		MemberID:     pass.MemberID,
+		BranchID:     pass.BranchID,
+		CheckOutTime: &checkOutTime,
+		VisitsLeft:   pass.MaxVisits - pass.VisitsUsed,
+	}, nil
+}
+
+func (s *serviceImpl) ConversionReport(ctx context.Context, userID uuid.UUID, userRole string, organizationID, branchID *uuid.UUID, from, to time.Time) (*ConversionReportResponse, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	filter := &ConversionFilter{OrganizationIDs: sc.OrganizationIDs, BranchIDs: sc.BranchIDs, From: from, To: to}
+	if organizationID != nil {
+		if sc.OrganizationIDs != nil && !slices.Contains(sc.OrganizationIDs, *organizationID) {
+			return nil, ErrOrganizationAccess
+		}
+		filter.OrganizationIDs = []uuid.UUID{*organizationID}
+	}
+	if branchID != nil {
+		if sc.BranchIDs != nil && !slices.Contains(sc.BranchIDs, *branchID) {
+			return nil, ErrBranchAccess
+		}
+		filter.BranchIDs = []uuid.UUID{*branchID}
+	}
+
+	if filter.To.IsZero() {
+		now := time.Now().UTC()
+		filter.To = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(0, 0, -(defaultRangeDays - 1))
+	}
+	if filter.From.After(filter.To) || filter.To.Sub(filter.From) > maxRangeDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+
+	bySource, err := s.repo.ConversionBySource(ctx, filter)
+	if err != nil {
+		log.Printf("Service: ConversionReport failed by source: %v", err)
+		return nil, err
+	}
+	byStaff, err := s.repo.ConversionByStaff(ctx, filter)
+	if err != nil {
+		log.Printf("Service: ConversionReport failed by staff: %v", err)
+		return nil, err
+	}
+
+	report := &ConversionReportResponse{
+		From:     filter.From.Format(dateLayout),
+		To:       filter.To.Format(dateLayout),
+		BySource: make([]*ConversionRowResponse, len(bySource)),
+		ByStaff:  make([]*ConversionRowResponse, len(byStaff)),
+	}
+	total := &ConversionRow{Name: "All leads"}
+	var convertedDays float64
+	for i, row := range bySource {
+		report.BySource[i] = conversionRowResponse(row)
+		total.Leads += row.Leads
+		total.Converted += row.Converted
+		total.Lost += row.Lost
+		if row.AvgDaysToConvert != nil {
+			convertedDays += *row.AvgDaysToConvert * float64(row.Converted)
+		}
+	}
+	if total.Converted > 0 {
+		avg := convertedDays / float64(total.Converted)
+		total.AvgDaysToConvert = &avg
+	}
+	report.Total = conversionRowResponse(total)
+	for i, row := range byStaff {
+		report.ByStaff[i] = conversionRowResponse(row)
+	}
+	return report, nil
+}
+
+func (s *serviceImpl) ProcessConversions(ctx context.Context) (int64, error) {
+	n, err := s.repo.ProcessConversions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		log.Printf("Service: %d leads converted to paying members", n)
+	}
+	return n, nil
+}
+
+func (s *serviceImpl) checkSource(ctx context.Context, organizationID uuid.UUID, sourceID *uuid.UUID) error {
+	if sourceID == nil {
+		return nil
+	}
+	source, err := s.repo.GetSource(ctx, *sourceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInvalidSource
+	}
+	if err != nil {
+		return err
+	}
+	if source.OrganizationID != organizationID || !source.IsActive {
+		return ErrInvalidSource
+	}
+	return nil
+}
+
+func (s *serviceImpl) checkAssignee(ctx context.Context, organizationID uuid.UUID, assigneeID *uuid.UUID) error {
+	if assigneeID == nil {
+		return nil
+	}
+	u, err := s.userSvc.GetUserByID(ctx, *assigneeID)
+	if err != nil {
+		return ErrInvalidAssignee
+	}
+	switch u.Role {
+	case "super_admin":
+		return nil
+	case "admin", "staff":
+	default:
+		return ErrInvalidAssignee
+	}
+	orgIDs, err := s.userSvc.GetUserOrganizationIDs(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(orgIDs, organizationID) {
+		return ErrInvalidAssignee
+	}
+	return nil
+}
+
+func conversionRowResponse(row *ConversionRow) *ConversionRowResponse {
+	resp := &ConversionRowResponse{
+		ID:               row.ID,
+		Name:             row.Name,
+		Leads:            row.Leads,
+		Converted:        row.Converted,
+		Lost:             row.Lost,
+		AvgDaysToConvert: row.AvgDaysToConvert,
+	}
+	if row.Leads > 0 {
+		resp.ConversionRate = math.Round(float64(row.Converted)/float64(row.Leads)*10000) / 100
+	}
+	return resp
+}
+
+func normalizeEmail(email *string) *string {
+	if email == nil {
+		return nil
+	}
+	normalized := strings.ToLower(strings.TrimSpace(*email))
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -1,8 +1,11 @@
 package member
 
 import (
+	"encoding/json"
 	"time"
 
+	"fitcore/internal/modules/chat"
+
 	"github.com/google/uuid"
 )
 
@@ -19,6 +22,9 @@ type CreateMemberRequest struct {
 	Status         *string    `json:"status,omitempty"`
 	JoinDate       *string    `json:"joinDate,omitempty"`
 	Notes          *string    `json:"notes,omitempty"`
+	// SubscriptionStatus is past_due for a membership that should only start
+	// once its checkout is paid; it defaults to active.
+	SubscriptionStatus *string `json:"subscriptionStatus,omitempty" validate:"omitempty,oneof=active past_due"`
 }
 
 type UpdateMemberRequest struct {
@@ -31,6 +37,23 @@ type UpdateMemberRequest struct {
 	Status       *string    `json:"status,omitempty"`
 	JoinDate     *string    `json:"joinDate,omitempty"`
 	Notes        *string    `json:"notes,omitempty"`
+
+	EmergencyContactName         *string `json:"emergencyContactName,omitempty"`
+	EmergencyContactPhone        *string `json:"emergencyContactPhone,omitempty"`
+	EmergencyContactRelationship *string `json:"emergencyContactRelationship,omitempty"`
+}
+
+// UpdateProfileRequest is what members may change about themselves; status,
+// branch and notes stay with staff.
+type UpdateProfileRequest struct {
+	FirstName   *string `json:"firstName,omitempty" validate:"omitempty,min=1,max=255"`
+	LastName    *string `json:"lastName,omitempty" validate:"omitempty,min=1,max=255"`
+	Phone       *string `json:"phone,omitempty" validate:"omitempty,max=50"`
+	DateOfBirth *string `json:"dateOfBirth,omitempty" validate:"omitempty,datetime=2006-01-02"`
+
+	EmergencyContactName         *string `json:"emergencyContactName,omitempty" validate:"omitempty,max=255"`
+	EmergencyContactPhone        *string `json:"emergencyContactPhone,omitempty" validate:"omitempty,max=50"`
+	EmergencyContactRelationship *string `json:"emergencyContactRelationship,omitempty" validate:"omitempty,max=100"`
 }
 
 type MemberResponse struct {
@@ -45,6 +68,12 @@ type MemberResponse struct {
 	Status         string     `json:"status"`
 	JoinDate       *string    `json:"joinDate,omitempty"`
 	Notes          *string    `json:"notes,omitempty"`
+
+	EmergencyContactName         *string `json:"emergencyContactName,omitempty"`
+	EmergencyContactPhone        *string `json:"emergencyContactPhone,omitempty"`
+	EmergencyContactRelationship *string `json:"emergencyContactRelationship,omitempty"`
+
+	PhotoURL *string `json:"photoUrl,omitempty"`
 }
 
 type CreateMemberResponse struct {
@@ -53,6 +82,7 @@ type CreateMemberResponse struct {
 	PlanID         *uuid.UUID `json:"planId,omitempty"`
 	BranchID       *uuid.UUID `json:"branchId,omitempty"`
 	InvoiceID      *uuid.UUID `json:"invoiceId,omitempty"`
+	CheckoutURL    string     `json:"checkoutUrl,omitempty"`
 }
 
 type MemberListFilter struct {
@@ -83,6 +113,9 @@ type CheckInWithMemberResponse struct {
 	CheckOutTime   *time.Time `json:"check_out_time,omitempty"`
 	Method         string     `json:"method,omitempty"`
 	MemberName     string     `json:"member_name"`
+	// PhotoURL is a signed thumbnail link so staff can match the face at
+	// the desk.
+	PhotoURL *string `json:"photo_url,omitempty"`
 }
 
 type VisitorCountResponse struct {
@@ -139,17 +172,129 @@ type BurnoutAnalysis struct {
 	KeyMetrics         BurnoutKeyMetrics `json:"key_metrics"`
 }
 
+// Analytics engines that can produce a WellnessAnalysisResponse.
+const (
+	AnalyticsEngineAI     = "ai"
+	AnalyticsEngineNative = "native"
+)
+
 type WellnessAnalysisResponse struct {
 	AttendanceAnalysis AttendanceAnalysis `json:"attendance_analysis"`
 	BurnoutAnalysis    BurnoutAnalysis    `json:"burnout_analysis"`
+	Engine             string             `json:"engine"`
 }
 
 type ChatbotRequest struct {
 	Query   string                  `json:"query"`
 	Context WellnessAnalysisRequest `json:"context"`
+	// History holds recent turns not yet folded into Summary.
+	History []chat.ConversationMessage `json:"history,omitempty"`
+	Summary string                     `json:"summary,omitempty"`
+	// Tools the model may call; ToolChoice "none" forces a final answer.
+	Tools         []ToolDefinition `json:"tools,omitempty"`
+	ToolChoice    string           `json:"tool_choice,omitempty"`
+	ToolExchanges []ToolExchange   `json:"tool_exchanges,omitempty"`
 }
 
 type ChatbotResponse struct {
-	Answer           string   `json:"answer"`
-	SuggestedActions []string `json:"suggested_actions,omitempty"`
+	Answer           string     `json:"answer"`
+	SuggestedActions []string   `json:"suggested_actions,omitempty"`
+	SessionID        *uuid.UUID `json:"session_id,omitempty"`
+	// ToolCalls is set by the chat service instead of an answer when it
+	// needs the backend to run tools first.
+	ToolCalls   []ToolCall       `json:"tool_calls,omitempty"`
+	ToolResults []ToolCallRecord `json:"tool_results,omitempty"`
+}
+
+// ToolDefinition describes a backend tool in function-calling format.
+// Parameters is a JSON schema.
+type ToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ToolExchange pairs a tool call with the result given back to the model.
+type ToolExchange struct {
+	Call   ToolCall    `json:"call"`
+	Result interface{} `json:"result"`
+}
+
+// ToolCallRecord is a tool call as executed by the backend. It is saved in
+// the assistant message metadata and returned to the client.
+type ToolCallRecord struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	Result     interface{}     `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"durationMs"`
+	// Display holds data only the client needs, such as a QR token. It is
+	// neither sent to the model nor stored.
+	Display interface{} `json:"display,omitempty"`
+}
+
+// ChatMessageRequest is the body of the chat endpoints. SessionID picks the
+// conversation; the active session is used when omitted.
+type ChatMessageRequest struct {
+	Query     string     `json:"query"`
+	SessionID *uuid.UUID `json:"sessionId,omitempty"`
+}
+
+// ChatSummaryRequest asks the chat service to fold older turns into the
+// running summary.
+type ChatSummaryRequest struct {
+	PreviousSummary string                     `json:"previous_summary,omitempty"`
+	Messages        []chat.ConversationMessage `json:"messages"`
+}
+
+// ChatStreamChunk is one piece of a streamed chat answer.
+type ChatStreamChunk struct {
+	Delta string `json:"delta"`
+}
+
+type CreateMemberRequestRequest struct {
+	Type        string     `json:"type" validate:"required,oneof=freeze renewal cancellation"`
+	PlanID      *uuid.UUID `json:"planId,omitempty"`
+	FreezeStart *string    `json:"freezeStart,omitempty"`
+	FreezeDays  *int       `json:"freezeDays,omitempty"`
+	Note        *string    `json:"note,omitempty"`
+}
+
+type ResolveMemberRequestRequest struct {
+	Note *string `json:"note,omitempty"`
+}
+
+type MemberRequestFilter struct {
+	Status *string
+	Type   *string
+	// BranchIDs limits results to members whose home branch is listed.
+	BranchIDs []uuid.UUID
+	Page      int
+	Limit     int
+}
+
+type MemberRequestResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	MemberID       uuid.UUID  `json:"memberId"`
+	MemberName     string     `json:"memberName,omitempty"`
+	Type           string     `json:"type"`
+	Status         string     `json:"status"`
+	PlanID         *uuid.UUID `json:"planId,omitempty"`
+	FreezeStart    *string    `json:"freezeStart,omitempty"`
+	FreezeDays     *int       `json:"freezeDays,omitempty"`
+	Note           *string    `json:"note,omitempty"`
+	Source         string     `json:"source"`
+	ChatSessionID  *uuid.UUID `json:"chatSessionId,omitempty"`
+	ResolvedBy     *uuid.UUID `json:"resolvedBy,omitempty"`
+	ResolvedAt     *time.Time `json:"resolvedAt,omitempty"`
+	ResolutionNote *string    `json:"resolutionNote,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
 }
@@ -30,6 +30,16 @@ type Member struct {
 	CreatedAt      time.Time    `db:"created_at"`
 	UpdatedAt      time.Time    `db:"updated_at"`
 	DeletedAt      *time.Time   `db:"deleted_at"`
+
+	// The emergency contact is only loaded for single members, not lists.
+	EmergencyContactName         *string `db:"emergency_contact_name"`
+	EmergencyContactPhone        *string `db:"emergency_contact_phone"`
+	EmergencyContactRelationship *string `db:"emergency_contact_relationship"`
+
+	// The photo is only loaded for single members; PhotoURL is its signed
+	// link, populated separately.
+	PhotoFileID *uuid.UUID `db:"photo_file_id"`
+	PhotoURL    *string    `db:"-"`
 }
 
 func (m *Member) ToResponse() *MemberResponse {
@@ -57,6 +67,12 @@ func (m *Member) ToResponse() *MemberResponse {
 		Status:         string(m.Status),
 		JoinDate:       joinDate,
 		Notes:          m.Notes,
+
+		EmergencyContactName:         m.EmergencyContactName,
+		EmergencyContactPhone:        m.EmergencyContactPhone,
+		EmergencyContactRelationship: m.EmergencyContactRelationship,
+
+		PhotoURL: m.PhotoURL,
 	}
 }
 
@@ -79,6 +95,7 @@ type CheckInWithMember struct {
 	CheckOutTime   *time.Time `db:"check_out_time"`
 	Method         string     `db:"method"`
 	MemberName     string     `db:"member_name"`
+	PhotoFileID    *uuid.UUID `db:"photo_file_id"`
 }
 
 func (c *CheckInWithMember) ToResponse() *CheckInWithMemberResponse {
@@ -98,4 +115,76 @@ type Attendance struct {
 	Date         string  `db:"date" json:"date"`
 	IsAttendance bool    `db:"is_attendance" json:"isAttendance"`
 	Duration     float32 `db:"duration" json:"duration"`
-}
\ No newline at end of file
+}
+type MemberRequestType string
+
+const (
+	MemberRequestFreeze       MemberRequestType = "freeze"
+	MemberRequestRenewal      MemberRequestType = "renewal"
+	MemberRequestCancellation MemberRequestType = "cancellation"
+)
+
+type MemberRequestStatus string
+
+const (
+	MemberRequestPending   MemberRequestStatus = "pending"
+	MemberRequestApproved  MemberRequestStatus = "approved"
+	MemberRequestRejected  MemberRequestStatus = "rejected"
+	MemberRequestCancelled MemberRequestStatus = "cancelled"
+)
+
+// Sources a member request can come from.
+const (
+	MemberRequestSourceApp  = "app"
+	MemberRequestSourceChat = "chat"
+)
+
+// MemberRequest is a freeze, renewal or cancellation request waiting for
+// staff review.
+type MemberRequest struct {
+	ID             uuid.UUID           `db:"id"`
+	MemberID       uuid.UUID           `db:"member_id"`
+	Type           MemberRequestType   `db:"type"`
+	Status         MemberRequestStatus `db:"status"`
+	PlanID         *uuid.UUID          `db:"plan_id"`
+	FreezeStart    *time.Time          `db:"freeze_start"`
+	FreezeDays     *int                `db:"freeze_days"`
+	Note           *string             `db:"note"`
+	Source         string              `db:"source"`
+	ChatSessionID  *uuid.UUID          `db:"chat_session_id"`
+	ResolvedBy     *uuid.UUID          `db:"resolved_by"`
+	ResolvedAt     *time.Time          `db:"resolved_at"`
+	ResolutionNote *string             `db:"resolution_note"`
+	CreatedAt      time.Time           `db:"created_at"`
+	UpdatedAt      time.Time           `db:"updated_at"`
+
+	// MemberName is only populated by list queries.
+	MemberName string `db:"member_name"`
+}
+
+func (m *MemberRequest) ToResponse() *MemberRequestResponse {
+	var freezeStart *string
+	if m.FreezeStart != nil {
+		formatted := m.FreezeStart.Format("2006-01-02")
+		freezeStart = &formatted
+	}
+
+	return &MemberRequestResponse{
+		ID:             m.ID,
+		MemberID:       m.MemberID,
+		MemberName:     m.MemberName,
+		Type:           string(m.Type),
+		Status:         string(m.Status),
+		PlanID:         m.PlanID,
+		FreezeStart:    freezeStart,
+		FreezeDays:     m.FreezeDays,
+		Note:           m.Note,
+		Source:         m.Source,
+		ChatSessionID:  m.ChatSessionID,
+		ResolvedBy:     m.ResolvedBy,
+		ResolvedAt:     m.ResolvedAt,
+		ResolutionNote: m.ResolutionNote,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
@@ -1,19 +1,28 @@
 package member
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"fitcore/internal/middleware"
 	"fitcore/internal/modules/chat"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/files"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/occupancy"
 	"fitcore/internal/modules/user"
 	"fitcore/internal/response"
 
 	"github.com/go-chi/chi/v5"
 	gojwt "github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type Handler struct {
@@ -36,6 +45,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Get("/attendance", h.GetAttendance)
 		r.Get("/analytics", h.GetAnalytics)
 		r.Post("/chat", h.Chat)
+		r.Post("/chat/stream", h.ChatStream)
 		r.Get("/chat/sessions", h.GetChatSessions)
 		r.Post("/chat/sessions", h.CreateChatSession)
 		r.Get("/chat/sessions/{sessionId}", h.GetChatSession)
@@ -45,6 +55,16 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RoleMiddleware("member"))
 			r.Get("/qr", h.GetDataQR)
+			r.Get("/me/requests", h.ListMyRequests)
+			r.Post("/me/requests", h.CreateMyRequest)
+			r.Post("/me/requests/{requestId}/cancel", h.CancelMyRequest)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("admin", "staff"))
+			r.Get("/requests", h.ListMemberRequests)
+			r.Post("/requests/{requestId}/approve", h.ApproveMemberRequest)
+			r.Post("/requests/{requestId}/reject", h.RejectMemberRequest)
 		})
 
 		r.Group(func(r chi.Router) {
@@ -60,6 +80,8 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Post("/", h.CreateMember)
 			r.Put("/{id}", h.UpdateMember)
 			r.Delete("/{id}", h.DeleteMember)
+			r.Put("/{id}/photo", h.UploadPhoto)
+			r.Delete("/{id}/photo", h.RemovePhoto)
 		})
 	})
 }
@@ -389,6 +411,66 @@ func (h *Handler) UpdateMember(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, "Member updated successfully", member.ToResponse())
 }
 
+// UploadPhoto takes a multipart form with the image in file.
+func (h *Handler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		response.BadRequest(w, "Invalid member ID", nil)
+		return
+	}
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return
+	}
+	filename, data, ok := files.FormFile(w, r, files.PurposeMemberPhoto)
+	if !ok {
+		return
+	}
+
+	member, err := h.service.SetPhoto(r.Context(), id, filename, data, userID)
+	if err != nil {
+		writePhotoError(w, err, "Failed to upload photo")
+		return
+	}
+	response.Success(w, "Photo uploaded successfully", member.ToResponse())
+}
+
+func (h *Handler) RemovePhoto(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		response.BadRequest(w, "Invalid member ID", nil)
+		return
+	}
+
+	member, err := h.service.RemovePhoto(r.Context(), id)
+	if err != nil {
+		writePhotoError(w, err, "Failed to remove photo")
+		return
+	}
+	response.Success(w, "Photo removed successfully", member.ToResponse())
+}
+
+func writePhotoError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		response.NotFound(w, "Member not found")
+	case files.IsUploadError(err):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
+
 func (h *Handler) DeleteMember(w http.ResponseWriter, r *http.Request) {
 	idParam := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idParam)
@@ -436,6 +518,15 @@ func (h *Handler) Scanner(w http.ResponseWriter, r *http.Request) {
 
 	_, err := h.service.Scanner(r.Context(), req.Token)
 	if err != nil {
+		if errors.Is(err, occupancy.ErrBranchAtCapacity) {
+			response.Conflict(w, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, hours.ErrBranchClosed) || errors.Is(err, hours.ErrOutsideAccessWindow) ||
+			errors.Is(err, documents.ErrSignatureRequired) || errors.Is(err, ErrSubscriptionFrozen) {
+			response.Forbidden(w, err.Error())
+			return
+		}
 		response.InternalServerError(w, err.Error())
 		return
 	}
@@ -601,9 +692,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Query string `json:"query"`
-	}
+	var req ChatMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.BadRequest(w, "Invalid request payload", err.Error())
 		return
@@ -614,12 +703,300 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.Chat(r.Context(), userID, req.Query)
+	resp, err := h.service.Chat(r.Context(), userID, req.SessionID, req.Query)
 	if err != nil {
 		log.Printf("Handler: Chat failed: %v", err)
-		response.InternalServerError(w, err.Error())
+		writeChatError(w, err)
 		return
 	}
 
 	response.Success(w, "Chat processed successfully", resp)
 }
+
+// ChatStream relays the assistant answer as Server-Sent Events: a "token"
+// event per chunk, then "done" with the full answer or "error". Errors before
+// the first chunk are returned as regular JSON responses.
+func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req ChatMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", err.Error())
+		return
+	}
+
+	if req.Query == "" {
+		response.BadRequest(w, "Query is required", nil)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The server WriteTimeout would otherwise cut long answers off, or the
+	// whole response when the first chunk is slow to arrive.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Handler: ChatStream could not clear write deadline: %v", err)
+	}
+
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	resp, err := h.service.ChatStream(r.Context(), userID, req.SessionID, req.Query, func(delta string) error {
+		start()
+		if err := writeSSE(w, "token", ChatStreamChunk{Delta: delta}); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+
+	if r.Context().Err() != nil {
+		// Client disconnected; the partial answer has already been saved.
+		return
+	}
+	if err != nil {
+		log.Printf("Handler: ChatStream failed: %v", err)
+		if !started {
+			writeChatError(w, err)
+			return
+		}
+		writeSSE(w, "error", map[string]string{"message": err.Error()})
+		rc.Flush()
+		return
+	}
+
+	start()
+	writeSSE(w, "done", resp)
+	rc.Flush()
+}
+
+func writeChatError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, chat.ErrSessionNotFound):
+		response.NotFound(w, "Chat session not found")
+	case errors.Is(err, chat.ErrUnauthorized):
+		response.Forbidden(w, err.Error())
+	default:
+		response.InternalServerError(w, err.Error())
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+func (h *Handler) CreateMyRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateMemberRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", err.Error())
+		return
+	}
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	created, err := h.service.CreateMemberRequest(r.Context(), userID, &req)
+	if err != nil {
+		writeMemberRequestError(w, err)
+		return
+	}
+
+	response.Success(w, "Request submitted successfully", created.ToResponse())
+}
+
+func (h *Handler) ListMyRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := h.service.ListMyRequests(r.Context(), userID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list requests")
+		return
+	}
+
+	responses := make([]*MemberRequestResponse, len(requests))
+	for i, req := range requests {
+		responses[i] = req.ToResponse()
+	}
+	response.Success(w, "Requests retrieved successfully", responses)
+}
+
+func (h *Handler) CancelMyRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid request ID", nil)
+		return
+	}
+
+	if err := h.service.CancelMyRequest(r.Context(), userID, requestID); err != nil {
+		writeMemberRequestError(w, err)
+		return
+	}
+
+	response.OK(w, "Request cancelled successfully")
+}
+
+func (h *Handler) ListMemberRequests(w http.ResponseWriter, r *http.Request) {
+	_, userRole, userBranchIDs, ok := h.reviewerScope(w, r)
+	if !ok {
+		return
+	}
+
+	filter := &MemberRequestFilter{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter.Status = &status
+	}
+	if requestType := r.URL.Query().Get("type"); requestType != "" {
+		filter.Type = &requestType
+	}
+	filter.Page, _ = strconv.Atoi(r.URL.Query().Get("page"))
+	filter.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+
+	requests, err := h.service.ListMemberRequests(r.Context(), filter, userRole, userBranchIDs)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list requests")
+		return
+	}
+
+	responses := make([]*MemberRequestResponse, len(requests))
+	for i, req := range requests {
+		responses[i] = req.ToResponse()
+	}
+	response.Success(w, "Requests retrieved successfully", responses)
+}
+
+func (h *Handler) ApproveMemberRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveMemberRequest(w, r, h.service.ApproveMemberRequest, "Request approved successfully")
+}
+
+func (h *Handler) RejectMemberRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveMemberRequest(w, r, h.service.RejectMemberRequest, "Request rejected successfully")
+}
+
+type resolveMemberRequestFunc func(ctx context.Context, requestID uuid.UUID, reviewerID uuid.UUID, userRole string, userBranchIDs []uuid.UUID, note *string) (*MemberRequest, error)
+
+func (h *Handler) resolveMemberRequest(w http.ResponseWriter, r *http.Request, resolve resolveMemberRequestFunc, message string) {
+	userID, userRole, userBranchIDs, ok := h.reviewerScope(w, r)
+	if !ok {
+		return
+	}
+
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid request ID", nil)
+		return
+	}
+
+	// The note is optional, so an empty body is fine
+	var req ResolveMemberRequestRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.BadRequest(w, "Invalid request payload", err.Error())
+			return
+		}
+	}
+
+	resolved, err := resolve(r.Context(), requestID, userID, userRole, userBranchIDs, req.Note)
+	if err != nil {
+		writeMemberRequestError(w, err)
+		return
+	}
+
+	response.Success(w, message, resolved.ToResponse())
+}
+
+// reviewerScope returns the caller and, for staff, the branches whose
+// members they may review.
+func (h *Handler) reviewerScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, []uuid.UUID, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", nil, false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", nil, false
+	}
+
+	var userBranchIDs []uuid.UUID
+	if userRole == "staff" {
+		userBranchIDs, err = h.userSvc.GetUserBranchIDs(r.Context(), userID, userRole)
+		if err != nil {
+			response.InternalServerError(w, "Failed to get user branches")
+			return uuid.Nil, "", nil, false
+		}
+	}
+	return userID, userRole, userBranchIDs, true
+}
+
+func requestUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, false
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func writeMemberRequestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrMemberRequestNotFound), errors.Is(err, pgx.ErrNoRows):
+		response.NotFound(w, "Request not found")
+	case errors.Is(err, ErrMemberRequestPending), errors.Is(err, ErrMemberRequestResolved):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, ErrMemberRequestAccess):
+		response.Forbidden(w, err.Error())
+	case errors.Is(err, ErrInvalidFreeze), errors.Is(err, ErrNoActiveSubscription), errors.Is(err, ErrPlanUnavailable):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: member request failed: %v", err)
+		response.InternalServerError(w, "Failed to process request")
+	}
+}
@@ -3,9 +3,14 @@ package member
 import (
 	"fitcore/internal/modules/cache"
 	"fitcore/internal/modules/chat"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/files"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/occupancy"
 	"fitcore/internal/modules/plans"
 	"fitcore/internal/modules/subscription"
 	"fitcore/internal/modules/user"
+	"fitcore/pkg/analytics"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -17,9 +22,9 @@ type Provider struct {
 	Repository Repository
 }
 
-func NewProvider(db *pgxpool.Pool, userSvc user.Service, subSvc subscription.Service, plansSvc plans.Service, cacheSvc cache.Service, chatSvc chat.Service) *Provider {
+func NewProvider(db *pgxpool.Pool, userSvc user.Service, subSvc subscription.Service, plansSvc plans.Service, cacheSvc cache.Service, chatSvc chat.Service, occupancySvc occupancy.Service, hoursSvc hours.Service, documentsSvc documents.Service, filesSvc files.Service, analyticsClient *analytics.Client) *Provider {
 	repo := NewRepository(db)
-	service := NewService(repo, subSvc, plansSvc, userSvc, cacheSvc, chatSvc)
+	service := NewService(repo, subSvc, plansSvc, userSvc, cacheSvc, chatSvc, occupancySvc, hoursSvc, documentsSvc, filesSvc, analyticsClient)
 	handler := NewHandler(service, userSvc)
 
 	return &Provider{
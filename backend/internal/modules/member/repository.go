@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,6 +17,7 @@ type Repository interface {
 	CreateWithUser(ctx context.Context, member *Member, email string, encryptedPassword string) error
 	Update(ctx context.Context, member *Member) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	SetPhotoFile(ctx context.Context, id uuid.UUID, fileID *uuid.UUID) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Member, error)
 	GetByUserID(ctx context.Context, id uuid.UUID) (*Member, error)
 	GetSessionActivity(ctx context.Context, id uuid.UUID) (*CheckIn, error)
@@ -26,6 +28,12 @@ type Repository interface {
 	ListByOrganizationID(ctx context.Context, organizationID uuid.UUID, limit, offset int) ([]*Member, error)
 	ListWithFilter(ctx context.Context, filter *MemberListFilter) ([]*Member, error)
 	GetAttendance(ctx context.Context, memberID uuid.UUID, startDate, endDate string) ([]*Attendance, error)
+	CreateRequest(ctx context.Context, req *MemberRequest) error
+	GetRequestByID(ctx context.Context, id uuid.UUID) (*MemberRequest, error)
+	ListRequests(ctx context.Context, filter *MemberRequestFilter) ([]*MemberRequest, error)
+	ListRequestsByMemberID(ctx context.Context, memberID uuid.UUID) ([]*MemberRequest, error)
+	ResolveRequest(ctx context.Context, id uuid.UUID, status MemberRequestStatus, resolvedBy *uuid.UUID, note *string) error
+	ReopenRequest(ctx context.Context, id uuid.UUID) error
 }
 
 type repositoryImpl struct {
@@ -138,7 +146,8 @@ func (r *repositoryImpl) CreateWithUser(ctx context.Context, member *Member, ema
 func (r *repositoryImpl) Update(ctx context.Context, member *Member) error {
 	query := `
 		UPDATE members
-		SET user_id = $1, home_branch_id = $2, first_name = $3, last_name = $4, phone = $5, date_of_birth = $6, status = $7, join_date = $8, notes = $9, updated_at = NOW()
+		SET user_id = $1, home_branch_id = $2, first_name = $3, last_name = $4, phone = $5, date_of_birth = $6, status = $7, join_date = $8, notes = $9,
+			emergency_contact_name = $11, emergency_contact_phone = $12, emergency_contact_relationship = $13, updated_at = NOW()
 		WHERE id = $10 AND deleted_at IS NULL
 		RETURNING updated_at
 	`
@@ -153,6 +162,9 @@ func (r *repositoryImpl) Update(ctx context.Context, member *Member) error {
 		member.JoinDate,
 		member.Notes,
 		member.ID,
+		member.EmergencyContactName,
+		member.EmergencyContactPhone,
+		member.EmergencyContactRelationship,
 	).Scan(&member.UpdatedAt)
 }
 
@@ -162,9 +174,22 @@ func (r *repositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+func (r *repositoryImpl) SetPhotoFile(ctx context.Context, id uuid.UUID, fileID *uuid.UUID) error {
+	query := `UPDATE members SET photo_file_id = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, fileID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Member, error) {
 	query := `
-		SELECT id, user_id, organization_id, home_branch_id, first_name, last_name, phone, date_of_birth, status, join_date, notes, created_at, updated_at
+		SELECT id, user_id, organization_id, home_branch_id, first_name, last_name, phone, date_of_birth, status, join_date, notes, created_at, updated_at,
+			emergency_contact_name, emergency_contact_phone, emergency_contact_relationship, photo_file_id
 		FROM members
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -183,6 +208,10 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Member, er
 		&member.Notes,
 		&member.CreatedAt,
 		&member.UpdatedAt,
+		&member.EmergencyContactName,
+		&member.EmergencyContactPhone,
+		&member.EmergencyContactRelationship,
+		&member.PhotoFileID,
 	)
 	if err != nil {
 		return nil, err
@@ -192,7 +221,8 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Member, er
 
 func (r *repositoryImpl) GetByUserID(ctx context.Context, id uuid.UUID) (*Member, error) {
 	query := `
-		SELECT id, user_id, organization_id, home_branch_id, first_name, last_name, phone, date_of_birth, status, join_date, notes, created_at, updated_at
+		SELECT id, user_id, organization_id, home_branch_id, first_name, last_name, phone, date_of_birth, status, join_date, notes, created_at, updated_at,
+			emergency_contact_name, emergency_contact_phone, emergency_contact_relationship, photo_file_id
 		FROM members
 		WHERE user_id = $1 AND deleted_at IS NULL
 	`
@@ -211,6 +241,10 @@ func (r *repositoryImpl) GetByUserID(ctx context.Context, id uuid.UUID) (*Member
 		&member.Notes,
 		&member.CreatedAt,
 		&member.UpdatedAt,
+		&member.EmergencyContactName,
+		&member.EmergencyContactPhone,
+		&member.EmergencyContactRelationship,
+		&member.PhotoFileID,
 	)
 	if err != nil {
 		return nil, err
@@ -291,7 +325,8 @@ func (r *repositoryImpl) GetSessionActivities(ctx context.Context, branchID uuid
 			c.check_in_time,
 			c.check_out_time,
 			c.method,
-			CONCAT(m.first_name, ' ', m.last_name) as member_name
+			CONCAT(m.first_name, ' ', m.last_name) as member_name,
+			m.photo_file_id
 		FROM check_ins c
 		JOIN members m ON c.member_id = m.id
 		WHERE c.branch_id = $1 AND c.deleted_at IS NULL
@@ -315,6 +350,7 @@ func (r *repositoryImpl) GetSessionActivities(ctx context.Context, branchID uuid
 			&checkIn.CheckOutTime,
 			&checkIn.Method,
 			&checkIn.MemberName,
+			&checkIn.PhotoFileID,
 		)
 		if err != nil {
 			return nil, err
@@ -523,3 +559,167 @@ func (r *repositoryImpl) ListWithFilter(ctx context.Context, filter *MemberListF
 	}
 	return members, nil
 }
+
+const memberRequestColumns = `
+	mr.id, mr.member_id, mr.type, mr.status, mr.plan_id, mr.freeze_start, mr.freeze_days, mr.note, mr.source,
+	mr.chat_session_id, mr.resolved_by, mr.resolved_at, mr.resolution_note, mr.created_at, mr.updated_at,
+	TRIM(m.first_name || ' ' || m.last_name)
+`
+
+func scanMemberRequest(row pgx.Row) (*MemberRequest, error) {
+	var req MemberRequest
+	err := row.Scan(
+		&req.ID,
+		&req.MemberID,
+		&req.Type,
+		&req.Status,
+		&req.PlanID,
+		&req.FreezeStart,
+		&req.FreezeDays,
+		&req.Note,
+		&req.Source,
+		&req.ChatSessionID,
+		&req.ResolvedBy,
+		&req.ResolvedAt,
+		&req.ResolutionNote,
+		&req.CreatedAt,
+		&req.UpdatedAt,
+		&req.MemberName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *repositoryImpl) CreateRequest(ctx context.Context, req *MemberRequest) error {
+	query := `
+		INSERT INTO member_requests (member_id, type, status, plan_id, freeze_start, freeze_days, note, source, chat_session_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(ctx, query,
+		req.MemberID,
+		req.Type,
+		req.Status,
+		req.PlanID,
+		req.FreezeStart,
+		req.FreezeDays,
+		req.Note,
+		req.Source,
+		req.ChatSessionID,
+	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt)
+}
+
+func (r *repositoryImpl) GetRequestByID(ctx context.Context, id uuid.UUID) (*MemberRequest, error) {
+	query := `SELECT ` + memberRequestColumns + `
+		FROM member_requests mr
+		JOIN members m ON m.id = mr.member_id
+		WHERE mr.id = $1
+	`
+	return scanMemberRequest(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) ListRequests(ctx context.Context, filter *MemberRequestFilter) ([]*MemberRequest, error) {
+	query := `SELECT ` + memberRequestColumns + `
+		FROM member_requests mr
+		JOIN members m ON m.id = mr.member_id
+		WHERE m.deleted_at IS NULL
+	`
+
+	var args []interface{}
+	argIndex := 1
+
+	if filter.Status != nil {
+		query += " AND mr.status = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	if filter.Type != nil {
+		query += " AND mr.type = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.Type)
+		argIndex++
+	}
+
+	if filter.BranchIDs != nil {
+		query += " AND m.home_branch_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.BranchIDs)
+		argIndex++
+	}
+
+	query += " ORDER BY mr.created_at DESC"
+	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*MemberRequest{}
+	for rows.Next() {
+		req, err := scanMemberRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+func (r *repositoryImpl) ListRequestsByMemberID(ctx context.Context, memberID uuid.UUID) ([]*MemberRequest, error) {
+	query := `SELECT ` + memberRequestColumns + `
+		FROM member_requests mr
+		JOIN members m ON m.id = mr.member_id
+		WHERE mr.member_id = $1
+		ORDER BY mr.created_at DESC
+		LIMIT 50
+	`
+	rows, err := r.db.Query(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*MemberRequest{}
+	for rows.Next() {
+		req, err := scanMemberRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+// ResolveRequest moves a pending request to its final status. It returns
+// pgx.ErrNoRows when the request is missing or no longer pending, so two
+// reviewers cannot both act on it.
+func (r *repositoryImpl) ResolveRequest(ctx context.Context, id uuid.UUID, status MemberRequestStatus, resolvedBy *uuid.UUID, note *string) error {
+	query := `
+		UPDATE member_requests
+		SET status = $2, resolved_by = $3, resolved_at = NOW(), resolution_note = $4, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+	tag, err := r.db.Exec(ctx, query, id, status, resolvedBy, note)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ReopenRequest undoes an approval whose side effect could not be applied.
+func (r *repositoryImpl) ReopenRequest(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE member_requests
+		SET status = 'pending', resolved_by = NULL, resolved_at = NULL, resolution_note = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
@@ -1,25 +1,33 @@
 package member
 
 import (
+	"bufio"
 	"context"
-	"fitcore/internal/config"
 	"fitcore/internal/modules/cache"
 	"fitcore/internal/modules/chat"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/files"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/occupancy"
 	"fitcore/internal/modules/plans"
 	"fitcore/internal/modules/subscription"
 	"fitcore/internal/modules/user"
+	"fitcore/pkg/analytics"
 	"fitcore/pkg/hash"
 	"fitcore/pkg/jwt"
+	"fitcore/pkg/wellness"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
-	"bytes"
 	"encoding/json"
-	"net/http"
+	"errors"
+	"io"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Service interface {
@@ -27,6 +35,16 @@ type Service interface {
 	UpdateMember(ctx context.Context, id uuid.UUID, req *UpdateMemberRequest) (*Member, error)
 	DeleteMember(ctx context.Context, id uuid.UUID) error
 	GetMember(ctx context.Context, id uuid.UUID) (*Member, error)
+	GetMemberByUserID(ctx context.Context, userID uuid.UUID) (*Member, error)
+	// SetPhoto uploads the photo staff use to recognise the member at
+	// check-in, replacing any earlier one.
+	SetPhoto(ctx context.Context, id uuid.UUID, filename string, data []byte, uploadedBy uuid.UUID) (*Member, error)
+	RemovePhoto(ctx context.Context, id uuid.UUID) (*Member, error)
+	// UpdateProfile applies a member's own profile edit.
+	UpdateProfile(ctx context.Context, userID uuid.UUID, req *UpdateProfileRequest) (*Member, error)
+	// ListAvailablePlans lists the plans the member can renew onto or change
+	// to.
+	ListAvailablePlans(ctx context.Context, member *Member) ([]*plans.Plan, error)
 	GetDataQR(ctx context.Context, id uuid.UUID) (*QRCodeResponse, error)
 	ListMembers(ctx context.Context, page, limit int) ([]*Member, error)
 	ListMembersByOrganization(ctx context.Context, organizationID uuid.UUID, page, limit int) ([]*Member, error)
@@ -36,7 +54,10 @@ type Service interface {
 	GetVisitorCount(ctx context.Context, branchID uuid.UUID) (*VisitorCountResponse, error)
 	GetAttendance(ctx context.Context, uid uuid.UUID, startDate, endDate string) ([]*Attendance, error)
 	GetAnalytics(ctx context.Context, userID uuid.UUID) (*WellnessAnalysisResponse, error)
-	Chat(ctx context.Context, userID uuid.UUID, query string) (*ChatbotResponse, error)
+	Chat(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID, query string) (*ChatbotResponse, error)
+	// ChatStream relays the answer as it is generated, calling onDelta for
+	// each chunk. The full answer is persisted once the stream ends.
+	ChatStream(ctx context.Context, userID uuid.UUID, sessionID *uuid.UUID, query string, onDelta func(delta string) error) (*ChatbotResponse, error)
 
 	// Chat session methods
 	GetChatSessions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*chat.ChatSessionResponse, error)
@@ -44,19 +65,43 @@ type Service interface {
 	GetChatSessionWithMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, messageLimit int) (*chat.ChatSessionWithMessagesResponse, error)
 	DeleteChatSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
 	GetChatMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, page, limit int) ([]*chat.ChatMessageResponse, error)
+
+	// Member request methods
+	CreateMemberRequest(ctx context.Context, userID uuid.UUID, req *CreateMemberRequestRequest) (*MemberRequest, error)
+	ListMyRequests(ctx context.Context, userID uuid.UUID) ([]*MemberRequest, error)
+	CancelMyRequest(ctx context.Context, userID uuid.UUID, requestID uuid.UUID) error
+	ListMemberRequests(ctx context.Context, filter *MemberRequestFilter, userRole string, userBranchIDs []uuid.UUID) ([]*MemberRequest, error)
+	ApproveMemberRequest(ctx context.Context, requestID uuid.UUID, reviewerID uuid.UUID, userRole string, userBranchIDs []uuid.UUID, note *string) (*MemberRequest, error)
+	RejectMemberRequest(ctx context.Context, requestID uuid.UUID, reviewerID uuid.UUID, userRole string, userBranchIDs []uuid.UUID, note *string) (*MemberRequest, error)
 }
 
+var (
+	ErrMemberRequestNotFound = errors.New("member request not found")
+	ErrMemberRequestPending  = errors.New("a pending request of this type already exists")
+	ErrMemberRequestResolved = errors.New("member request is no longer pending")
+	ErrMemberRequestAccess   = errors.New("member is outside your branches")
+	ErrInvalidFreeze         = errors.New("freeze needs a start date from today and between 1 and 90 days")
+	ErrNoActiveSubscription  = errors.New("no active subscription")
+	ErrSubscriptionFrozen    = errors.New("subscription is frozen")
+	ErrPlanUnavailable       = errors.New("plan is not available")
+)
+
 type serviceImpl struct {
-	repo     Repository
-	subSvc   subscription.Service
-	plansSvc plans.Service
-	userSvc  user.Service
-	cacheSvc cache.Service
-	chatSvc  chat.Service
+	repo         Repository
+	subSvc       subscription.Service
+	plansSvc     plans.Service
+	userSvc      user.Service
+	cacheSvc     cache.Service
+	chatSvc      chat.Service
+	occupancySvc occupancy.Service
+	hoursSvc     hours.Service
+	documentsSvc documents.Service
+	filesSvc     files.Service
+	analytics    *analytics.Client
 }
 
-func NewService(repo Repository, subSvc subscription.Service, plansSvc plans.Service, userSvc user.Service, cacheSvc cache.Service, chatSvc chat.Service) Service {
-	return &serviceImpl{repo: repo, subSvc: subSvc, plansSvc: plansSvc, userSvc: userSvc, cacheSvc: cacheSvc, chatSvc: chatSvc}
+func NewService(repo Repository, subSvc subscription.Service, plansSvc plans.Service, userSvc user.Service, cacheSvc cache.Service, chatSvc chat.Service, occupancySvc occupancy.Service, hoursSvc hours.Service, documentsSvc documents.Service, filesSvc files.Service, analyticsClient *analytics.Client) Service {
+	return &serviceImpl{repo: repo, subSvc: subSvc, plansSvc: plansSvc, userSvc: userSvc, cacheSvc: cacheSvc, chatSvc: chatSvc, occupancySvc: occupancySvc, hoursSvc: hoursSvc, documentsSvc: documentsSvc, filesSvc: filesSvc, analytics: analyticsClient}
 }
 
 func (s *serviceImpl) CreateMember(ctx context.Context, req *CreateMemberRequest) (*CreateMemberResponse, error) {
@@ -183,6 +228,7 @@ func (s *serviceImpl) CreateMember(ctx context.Context, req *CreateMemberRequest
 		PlanID:    &plan.ID,
 		BranchID:  member.HomeBranchID,
 		StartDate: *req.JoinDate,
+		Status:    req.SubscriptionStatus,
 	}
 
 	resSub, err := s.subSvc.CreateSubscription(ctx, subsReq, "new")
@@ -197,6 +243,7 @@ func (s *serviceImpl) CreateMember(ctx context.Context, req *CreateMemberRequest
 		PlanID:         resSub.PlanID,
 		BranchID:       resSub.BranchID,
 		InvoiceID:      resSub.InvoiceID,
+		CheckoutURL:    resSub.CheckoutURL,
 	}
 
 	if isNewUser {
@@ -248,19 +295,151 @@ func (s *serviceImpl) UpdateMember(ctx context.Context, id uuid.UUID, req *Updat
 	if req.Notes != nil {
 		member.Notes = req.Notes
 	}
+	if req.EmergencyContactName != nil {
+		member.EmergencyContactName = req.EmergencyContactName
+	}
+	if req.EmergencyContactPhone != nil {
+		member.EmergencyContactPhone = req.EmergencyContactPhone
+	}
+	if req.EmergencyContactRelationship != nil {
+		member.EmergencyContactRelationship = req.EmergencyContactRelationship
+	}
+
+	if err := s.repo.Update(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *serviceImpl) UpdateProfile(ctx context.Context, userID uuid.UUID, req *UpdateProfileRequest) (*Member, error) {
+	member, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FirstName != nil {
+		member.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		member.LastName = *req.LastName
+	}
+	if req.Phone != nil {
+		member.Phone = emptyToNil(req.Phone)
+	}
+	if req.DateOfBirth != nil {
+		parsed, err := time.Parse("2006-01-02", *req.DateOfBirth)
+		if err != nil {
+			return nil, err
+		}
+		member.DateOfBirth = &parsed
+	}
+	// An empty string clears an emergency contact field.
+	if req.EmergencyContactName != nil {
+		member.EmergencyContactName = emptyToNil(req.EmergencyContactName)
+	}
+	if req.EmergencyContactPhone != nil {
+		member.EmergencyContactPhone = emptyToNil(req.EmergencyContactPhone)
+	}
+	if req.EmergencyContactRelationship != nil {
+		member.EmergencyContactRelationship = emptyToNil(req.EmergencyContactRelationship)
+	}
 
 	if err := s.repo.Update(ctx, member); err != nil {
+		log.Printf("Service: UpdateProfile failed for member %s: %v", member.ID, err)
 		return nil, err
 	}
 	return member, nil
 }
 
+func emptyToNil(s *string) *string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	return s
+}
+
 func (s *serviceImpl) DeleteMember(ctx context.Context, id uuid.UUID) error {
 	return s.repo.Delete(ctx, id)
 }
 
 func (s *serviceImpl) GetMember(ctx context.Context, id uuid.UUID) (*Member, error) {
-	return s.repo.GetByID(ctx, id)
+	member, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.signPhoto(member)
+	return member, nil
+}
+
+func (s *serviceImpl) GetMemberByUserID(ctx context.Context, userID uuid.UUID) (*Member, error) {
+	member, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.signPhoto(member)
+	return member, nil
+}
+
+// signPhoto links an uploaded photo for the response.
+func (s *serviceImpl) signPhoto(member *Member) {
+	if member.PhotoFileID != nil {
+		photoURL := s.filesSvc.URL(*member.PhotoFileID, files.VariantOriginal)
+		member.PhotoURL = &photoURL
+	}
+}
+
+func (s *serviceImpl) SetPhoto(ctx context.Context, id uuid.UUID, filename string, data []byte, uploadedBy uuid.UUID) (*Member, error) {
+	member, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.filesSvc.Upload(ctx, &files.UploadRequest{
+		OrganizationID: &member.OrganizationID,
+		Purpose:        files.PurposeMemberPhoto,
+		Filename:       filename,
+		Data:           data,
+		UploadedBy:     &uploadedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPhotoFile(ctx, member.ID, &file.ID); err != nil {
+		if deleteErr := s.filesSvc.Delete(ctx, file.ID); deleteErr != nil {
+			log.Printf("Service: SetPhoto failed to delete unused photo %s: %v", file.ID, deleteErr)
+		}
+		return nil, err
+	}
+
+	s.deletePhoto(ctx, member.PhotoFileID)
+	member.PhotoFileID = &file.ID
+	s.signPhoto(member)
+	return member, nil
+}
+
+func (s *serviceImpl) RemovePhoto(ctx context.Context, id uuid.UUID) (*Member, error) {
+	member, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPhotoFile(ctx, member.ID, nil); err != nil {
+		return nil, err
+	}
+
+	s.deletePhoto(ctx, member.PhotoFileID)
+	member.PhotoFileID = nil
+	return member, nil
+}
+
+// deletePhoto removes a photo that is no longer used. A failure only leaves
+// the old file behind, so it is logged.
+func (s *serviceImpl) deletePhoto(ctx context.Context, fileID *uuid.UUID) {
+	if fileID == nil {
+		return
+	}
+	if err := s.filesSvc.Delete(ctx, *fileID); err != nil && !errors.Is(err, files.ErrFileNotFound) {
+		log.Printf("Service: failed to delete replaced photo %s: %v", *fileID, err)
+	}
 }
 
 func (s *serviceImpl) GetAttendance(ctx context.Context, uid uuid.UUID, startDate, endDate string) ([]*Attendance, error) {
@@ -351,12 +530,21 @@ func (s *serviceImpl) Scanner(ctx context.Context, token string) (*CheckIn, erro
 	if qrData.Type == "check-in" {
 		log.Printf("Scanner: Processing CHECK-IN for member %s", qrData.MID)
 
-		subscription, err := s.subSvc.GetActiveSubscription(ctx, qrData.MID)
+		activeSub, err := s.subSvc.CheckAccess(ctx, qrData.MID, time.Now())
+		if errors.Is(err, subscription.ErrSubscriptionFrozen) {
+			log.Printf("Scanner: Subscription for member %s is frozen", qrData.MID)
+			return nil, ErrSubscriptionFrozen
+		}
 		if err != nil {
 			log.Printf("Scanner: Failed to get active subscription for member %s - %v", qrData.MID, err)
 			return nil, fmt.Errorf("no active subscription found: %w", err)
 		}
-		log.Printf("Scanner: Found active subscription %s for member %s", subscription.ID, qrData.MID)
+		log.Printf("Scanner: Found active subscription %s for member %s", activeSub.ID, qrData.MID)
+
+		if err := s.documentsSvc.CheckSigned(ctx, qrData.MID); err != nil {
+			log.Printf("Scanner: Document check refused member %s - %v", qrData.MID, err)
+			return nil, err
+		}
 
 		members, err := s.repo.GetByID(ctx, qrData.MID)
 		if err != nil {
@@ -370,20 +558,36 @@ func (s *serviceImpl) Scanner(ctx context.Context, token string) (*CheckIn, erro
 			return nil, fmt.Errorf("member has no home branch assigned")
 		}
 
-		checkIn := &CheckIn{
-			MemberID:       qrData.MID,
-			SubscriptionID: subscription.ID,
+		if err := s.hoursSvc.CheckAccess(ctx, *members.HomeBranchID, activeSub.PlanID, time.Now()); err != nil {
+			log.Printf("Scanner: Access rules refused member %s at branch %s - %v", qrData.MID, *members.HomeBranchID, err)
+			return nil, err
+		}
+
+		admission := &occupancy.CheckIn{
 			BranchID:       *members.HomeBranchID,
+			MemberID:       qrData.MID,
+			SubscriptionID: &activeSub.ID,
 			Method:         "qr",
 		}
-		log.Printf("Scanner: Creating check-in record: %+v", checkIn)
-
-		err = s.repo.UpsertSessionActivity(ctx, checkIn)
-		if err != nil {
-			log.Printf("Scanner: Failed to upsert session activity for check-in - %v", err)
+		log.Printf("Scanner: Creating check-in record: %+v", admission)
+
+		if err := s.occupancySvc.CheckIn(ctx, admission); err != nil {
+			if errors.Is(err, occupancy.ErrBranchAtCapacity) || errors.Is(err, occupancy.ErrBranchNotFound) {
+				log.Printf("Scanner: Capacity check refused member %s at branch %s - %v", qrData.MID, *members.HomeBranchID, err)
+				return nil, err
+			}
+			log.Printf("Scanner: Failed to record check-in - %v", err)
 			return nil, fmt.Errorf("failed to record check-in: %w", err)
 		}
 
+		checkIn := &CheckIn{
+			ID:             admission.ID,
+			MemberID:       admission.MemberID,
+			SubscriptionID: activeSub.ID,
+			BranchID:       admission.BranchID,
+			CheckInTime:    admission.CheckInTime,
+			Method:         admission.Method,
+		}
 		log.Printf("Scanner: CHECK-IN successful for member %s at branch %s", qrData.MID, *members.HomeBranchID)
 		return checkIn, nil
 	}
@@ -414,6 +618,10 @@ func (s *serviceImpl) Scanner(ctx context.Context, token string) (*CheckIn, erro
 	}
 
 	log.Printf("Scanner: CHECK-OUT successful for member %s at %s", qrData.MID, now.Format(time.RFC3339))
+	s.occupancySvc.PublishCheckOut(ctx, checkIn.BranchID, checkIn.MemberID)
+	if err := s.cacheSvc.InvalidateTag(ctx, cache.MemberTag(checkIn.MemberID)); err != nil {
+		log.Printf("Scanner: Failed to invalidate cache for member %s - %v", checkIn.MemberID, err)
+	}
 	return checkIn, nil
 }
 
@@ -449,6 +657,10 @@ func (s *serviceImpl) GetSessionActivities(ctx context.Context, branchID uuid.UU
 	responses := make([]*CheckInWithMemberResponse, len(checkIns))
 	for i, checkIn := range checkIns {
 		responses[i] = checkIn.ToResponse()
+		if checkIn.PhotoFileID != nil {
+			photoURL := s.filesSvc.URL(*checkIn.PhotoFileID, files.VariantThumbnail)
+			responses[i].PhotoURL = &photoURL
+		}
 	}
 
 	log.Printf("Service: GetSessionActivities succeeded - found %d active sessions for branch %s", len(checkIns), branchID)
@@ -495,21 +707,42 @@ func (s *serviceImpl) ListMembersWithFilter(ctx context.Context, filter *MemberL
 }
 
 func (s *serviceImpl) GetAnalytics(ctx context.Context, userID uuid.UUID) (*WellnessAnalysisResponse, error) {
-	// 0. Check Cache
-	cacheKey := fmt.Sprintf("analytic:%s", userID.String())
-	var cachedResponse WellnessAnalysisResponse
-	if err := s.cacheSvc.Get(ctx, cacheKey, &cachedResponse); err == nil {
-		log.Printf("Service: GetAnalytics - Cache hit for key: %s", cacheKey)
-		return &cachedResponse, nil
+	member, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 1. Get Member
-	member, err := s.repo.GetByUserID(ctx, userID)
+	// Check-outs invalidate the member tag, so the analysis reflects the
+	// latest visit; the TTL only bounds how long an idle member's result lives.
+	var analysis WellnessAnalysisResponse
+	err = s.cacheSvc.GetOrCompute(ctx, fmt.Sprintf("analytic:%s", userID.String()), &analysis, cache.Options{
+		TTL:      analyticsCacheTTL,
+		StaleTTL: analyticsStaleTTL,
+		Tags:     []string{cache.MemberTag(member.ID)},
+	}, func(ctx context.Context) (any, time.Duration, error) {
+		return s.computeAnalytics(ctx, userID, member)
+	})
 	if err != nil {
 		return nil, err
 	}
+	if analysis.Engine == "" {
+		analysis.Engine = AnalyticsEngineAI
+	}
+	return &analysis, nil
+}
+
+const (
+	analyticsCacheTTL = 12 * time.Hour
+	// analyticsStaleTTL is how long an expired analysis is still served
+	// while a fresh one is computed in the background.
+	analyticsStaleTTL = 24 * time.Hour
+)
 
-	// 2. Prepare User Profile
+// wellnessContext assembles the member's profile, the last 30 days of
+// attendance and their membership, as sent to both the wellness and the chat
+// service. The raw attendance is returned for the native engine.
+func (s *serviceImpl) wellnessContext(ctx context.Context, userID uuid.UUID, member *Member) (*WellnessAnalysisRequest, []*Attendance, error) {
+	// 1. Prepare User Profile
 	age := 0
 	if member.DateOfBirth != nil {
 		age = int(time.Since(*member.DateOfBirth).Hours() / 24 / 365)
@@ -531,13 +764,13 @@ func (s *serviceImpl) GetAnalytics(ctx context.Context, userID uuid.UUID) (*Well
 		JoinDate: joinDateStr,
 	}
 
-	// 3. Activity Data
+	// 2. Activity Data
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -30)
 
 	attendance, err := s.GetAttendance(ctx, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	checkins := []string{}
@@ -564,9 +797,9 @@ func (s *serviceImpl) GetAnalytics(ctx context.Context, userID uuid.UUID) (*Well
 		TotalSessionsLast30Days: totalSessions,
 	}
 
-	// 4. Membership Info
+	// 3. Membership Info
 	// Get Active Subscription
-	sub, err := s.subSvc.GetActiveSubscription(ctx, member.ID)
+	sub, err := s.subSvc.GetAccessSubscription(ctx, member.ID)
 
 	var membershipInfo *MembershipInfo
 	if err == nil && sub != nil {
@@ -577,211 +810,992 @@ func (s *serviceImpl) GetAnalytics(ctx context.Context, userID uuid.UUID) (*Well
 		}
 	}
 
-	reqPayload := WellnessAnalysisRequest{
+	return &WellnessAnalysisRequest{
 		UserProfile:    userProfile,
 		ActivityData:   activityData,
 		MembershipInfo: membershipInfo,
+	}, attendance, nil
+}
+
+// computeAnalytics builds the wellness analysis and the TTL to cache it for.
+func (s *serviceImpl) computeAnalytics(ctx context.Context, userID uuid.UUID, member *Member) (*WellnessAnalysisResponse, time.Duration, error) {
+	reqPayload, attendance, err := s.wellnessContext(ctx, userID, member)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 4. Call FastAPI, falling back to the native engine if it is unavailable
+	analysis, err := s.callWellnessService(ctx, *reqPayload)
+	if err != nil {
+		log.Printf("Service: GetAnalytics falling back to native engine: %v", err)
+		// Cache briefly so the AI service is retried soon
+		return nativeWellnessAnalysis(attendance, reqPayload.UserProfile.Age, reqPayload.MembershipInfo), nativeAnalyticsCacheTTL, nil
+	}
+	return analysis, 0, nil
+}
+
+// nativeAnalyticsCacheTTL keeps fallback results short-lived so the AI
+// service is tried again once it recovers.
+const nativeAnalyticsCacheTTL = 15 * time.Minute
+
+func (s *serviceImpl) callWellnessService(ctx context.Context, payload WellnessAnalysisRequest) (*WellnessAnalysisResponse, error) {
+	var analysis WellnessAnalysisResponse
+	if err := s.analytics.Wellness(ctx, payload, &analysis); err != nil {
+		return nil, fmt.Errorf("failed to call analytics service: %w", err)
 	}
+	analysis.Engine = AnalyticsEngineAI
 
-	// 5. Call FastAPI
-	fastApiUrl := config.Get().Analytics.ServiceURL + "/wellness"
+	return &analysis, nil
+}
 
-	bodyBytes, err := json.Marshal(reqPayload)
+func nativeWellnessAnalysis(attendance []*Attendance, age int, membershipInfo *MembershipInfo) *WellnessAnalysisResponse {
+	days := make([]wellness.Day, 0, len(attendance))
+	for _, a := range attendance {
+		days = append(days, wellness.Day{
+			Date:            a.Date,
+			Attended:        a.IsAttendance,
+			DurationMinutes: float64(a.Duration),
+		})
+	}
+
+	profile := wellness.Profile{Age: age}
+	if membershipInfo != nil {
+		profile.DaysUntilRenewal = &membershipInfo.DaysUntilRenewal
+	}
+
+	result := wellness.Analyze(days, profile)
+	return &WellnessAnalysisResponse{
+		AttendanceAnalysis: AttendanceAnalysis{
+			Score:                  result.Attendance.Score,
+			ConsistencyLevel:       result.Attendance.ConsistencyLevel,
+			ScoreExplanation:       result.Attendance.ScoreExplanation,
+			PatternInsight:         result.Attendance.PatternInsight,
+			RenewalBehaviorInsight: result.Attendance.RenewalBehaviorInsight,
+			PositiveNudge:          result.Attendance.PositiveNudge,
+			Recommendation:         result.Attendance.Recommendation,
+		},
+		BurnoutAnalysis: BurnoutAnalysis{
+			RiskScore:          result.Burnout.RiskScore,
+			RiskLevel:          result.Burnout.RiskLevel,
+			WarningSigns:       result.Burnout.WarningSigns,
+			RecoverySuggestion: result.Burnout.RecoverySuggestion,
+			KeyMetrics: BurnoutKeyMetrics{
+				AvgSessionsPerWeek:         result.Metrics.SessionsPerWeek,
+				ConsecutiveTrainingDaysMax: result.Metrics.LongestStreak,
+				RestDaysLast30:             result.Metrics.RestDays,
+			},
+		},
+		Engine: AnalyticsEngineNative,
+	}
+}
+
+// maxToolRounds bounds how many times the chat service may ask for tools
+// before it must answer.
+const maxToolRounds = 3
+
+func (s *serviceImpl) Chat(ctx context.Context, userID uuid.UUID, chatSessionID *uuid.UUID, query string) (*ChatbotResponse, error) {
+	tc, reqPayload, err := s.prepareChat(ctx, userID, chatSessionID, query)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", fastApiUrl, bytes.NewBuffer(bodyBytes))
+	var (
+		chatResponse ChatbotResponse
+		records      []ToolCallRecord
+	)
+	for round := 0; ; round++ {
+		if round == maxToolRounds {
+			reqPayload.ToolChoice = "none"
+		}
+
+		chatResponse = ChatbotResponse{}
+		if err := s.analytics.Chat(ctx, reqPayload, &chatResponse); err != nil {
+			log.Printf("Service: Chat failed to call chat service: %v", err)
+			return nil, fmt.Errorf("failed to call chat service")
+		}
+		if len(chatResponse.ToolCalls) == 0 || round == maxToolRounds {
+			break
+		}
+
+		exchanges, executed := s.runChatTools(ctx, tc, chatResponse.ToolCalls)
+		reqPayload.ToolExchanges = append(reqPayload.ToolExchanges, exchanges...)
+		records = append(records, executed...)
+	}
+	chatResponse.ToolCalls = nil
+	chatResponse.ToolResults = records
+
+	_, err = s.chatSvc.AddAssistantMessage(ctx, tc.sessionID, chatResponse.Answer, chatResponse.SuggestedActions, toolCallMetadata(records))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	s.compactChatSession(tc.sessionID)
+
+	chatResponse.SessionID = &tc.sessionID
+	return &chatResponse, nil
+}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
+func (s *serviceImpl) ChatStream(ctx context.Context, userID uuid.UUID, chatSessionID *uuid.UUID, query string, onDelta func(delta string) error) (*ChatbotResponse, error) {
+	tc, reqPayload, err := s.prepareChat(ctx, userID, chatSessionID, query)
 	if err != nil {
-		log.Printf("Service: GetAnalytics failed to call FastAPI: %v", err)
-		return nil, fmt.Errorf("failed to call analytics service")
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Service: GetAnalytics FastAPI returned status: %d", resp.StatusCode)
-		return nil, fmt.Errorf("analytics service returned error")
+	var (
+		answer    strings.Builder
+		final     *ChatbotResponse
+		records   []ToolCallRecord
+		streamErr error
+	)
+	for round := 0; ; round++ {
+		if round == maxToolRounds {
+			reqPayload.ToolChoice = "none"
+		}
+
+		body, err := s.analytics.ChatStream(ctx, reqPayload)
+		if err != nil {
+			log.Printf("Service: ChatStream failed to call chat service: %v", err)
+			if round == 0 {
+				return nil, fmt.Errorf("failed to call chat service")
+			}
+			streamErr = fmt.Errorf("failed to call chat service")
+			break
+		}
+
+		var calls []ToolCall
+		final, calls, streamErr = readChatStream(ctx, body, &answer, onDelta)
+		body.Close()
+		if streamErr != nil || final != nil {
+			break
+		}
+		if round == maxToolRounds {
+			streamErr = fmt.Errorf("chat service did not produce an answer")
+			break
+		}
+
+		exchanges, executed := s.runChatTools(ctx, tc, calls)
+		reqPayload.ToolExchanges = append(reqPayload.ToolExchanges, exchanges...)
+		records = append(records, executed...)
 	}
 
-	var analysis WellnessAnalysisResponse
-	if err := json.NewDecoder(resp.Body).Decode(&analysis); err != nil {
-		return nil, err
+	if final == nil {
+		final = &ChatbotResponse{}
 	}
+	// The streamed text covers every round, including any text the model
+	// produced before calling tools.
+	final.Answer = answer.String()
+	final.ToolResults = records
 
-	// 6. Set Cache (e.g., 24 hours)
-	if err := s.cacheSvc.Set(ctx, cacheKey, analysis, 24*time.Hour); err != nil {
-		log.Printf("Service: GetAnalytics failed to set cache: %v", err)
+	// Persist whatever was produced, even if the client went away mid-stream.
+	if final.Answer != "" || len(records) > 0 {
+		metadata := toolCallMetadata(records)
+		if streamErr != nil {
+			if metadata == nil {
+				metadata = map[string]interface{}{}
+			}
+			metadata["interrupted"] = true
+		}
+		persistCtx := context.WithoutCancel(ctx)
+		if _, err := s.chatSvc.AddAssistantMessage(persistCtx, tc.sessionID, final.Answer, final.SuggestedActions, metadata); err != nil {
+			log.Printf("Service: ChatStream failed to save assistant message: %v", err)
+			if streamErr == nil {
+				streamErr = err
+			}
+		} else {
+			s.compactChatSession(tc.sessionID)
+		}
 	}
 
-	return &analysis, nil
+	final.SessionID = &tc.sessionID
+
+	return final, streamErr
 }
 
-func (s *serviceImpl) Chat(ctx context.Context, userID uuid.UUID, query string) (*ChatbotResponse, error) {
+// readChatStream consumes one Server-Sent Events response from the chat
+// service. It returns the final result on a done event, or the requested
+// tool calls when the model wants tools run first.
+func readChatStream(ctx context.Context, body io.Reader, answer *strings.Builder, onDelta func(delta string) error) (*ChatbotResponse, []ToolCall, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var event, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			continue
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			continue
+		case line != "":
+			continue
+		}
 
+		// A blank line dispatches the buffered event
+		switch event {
+		case "token":
+			var chunk ChatStreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return nil, nil, fmt.Errorf("invalid chat stream chunk: %w", err)
+			}
+			answer.WriteString(chunk.Delta)
+			if err := onDelta(chunk.Delta); err != nil {
+				return nil, nil, err
+			}
+		case "tool_calls":
+			var requested ChatbotResponse
+			if err := json.Unmarshal([]byte(data), &requested); err != nil {
+				return nil, nil, fmt.Errorf("invalid chat stream tool calls: %w", err)
+			}
+			if len(requested.ToolCalls) > 0 {
+				return nil, requested.ToolCalls, nil
+			}
+		case "done":
+			var done ChatbotResponse
+			if err := json.Unmarshal([]byte(data), &done); err != nil {
+				return nil, nil, fmt.Errorf("invalid chat stream result: %w", err)
+			}
+			return &done, nil, nil
+		case "error":
+			log.Printf("Service: ChatStream upstream error: %s", data)
+			return nil, nil, fmt.Errorf("chat service returned error")
+		}
+		event, data = "", ""
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, fmt.Errorf("chat stream ended unexpectedly")
+}
+
+// prepareChat records the user's message on the chosen (or active) chat
+// session and builds the payload sent to the chat service, including the
+// conversation memory from before this message.
+func (s *serviceImpl) prepareChat(ctx context.Context, userID uuid.UUID, chatSessionID *uuid.UUID, query string) (*chatToolContext, *ChatbotRequest, error) {
 	member, err := s.repo.GetByUserID(ctx, userID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	session, err := s.chatSvc.GetOrCreateActiveSession(ctx, userID)
+	session, err := s.chatSvc.ResolveSession(ctx, userID, chatSessionID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	age := 0
-	if member.DateOfBirth != nil {
-		age = int(time.Since(*member.DateOfBirth).Hours() / 24 / 365)
+	memory, err := s.chatSvc.GetMemory(ctx, session.ID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	gender := "Unknown"
+	wellnessCtx, _, err := s.wellnessContext(ctx, userID, member)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	var joinDateStr *string
-	if member.JoinDate != nil {
-		s := member.JoinDate.Format("2006-01-02")
-		joinDateStr = &s
+	reqPayload := ChatbotRequest{
+		Query:      query,
+		Context:    *wellnessCtx,
+		History:    memory.Messages,
+		Summary:    memory.Summary,
+		Tools:      chatToolDefinitions(),
+		ToolChoice: "auto",
 	}
 
-	userProfile := UserProfile{
-		UserID:   member.ID.String(),
-		Age:      age,
-		Gender:   gender,
-		JoinDate: joinDateStr,
+	contextData := map[string]interface{}{
+		"user_profile":    wellnessCtx.UserProfile,
+		"activity_data":   wellnessCtx.ActivityData,
+		"membership_info": wellnessCtx.MembershipInfo,
+	}
+	_, err = s.chatSvc.AddUserMessage(ctx, session.ID, query, contextData)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	endDate := time.Now()
-	startDate := endDate.AddDate(0, 0, -30)
+	tc := &chatToolContext{userID: userID, member: member, sessionID: session.ID}
+	return tc, &reqPayload, nil
+}
 
-	attendance, err := s.GetAttendance(ctx, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+// compactChatSession summarizes the session in the background once it grows
+// past the memory budget, so it never delays the reply.
+func (s *serviceImpl) compactChatSession(sessionID uuid.UUID) {
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		if err := s.chatSvc.Compact(bgCtx, sessionID, chatSummarizer{client: s.analytics}); err != nil {
+			log.Printf("Service: compactChatSession failed for %s: %v", sessionID, err)
+		}
+	}()
+}
+
+// chatSummarizer implements chat.Summarizer against the chat service.
+type chatSummarizer struct {
+	client *analytics.Client
+}
+
+func (c chatSummarizer) Summarize(ctx context.Context, previousSummary string, messages []chat.ConversationMessage) (*chat.SummaryResult, error) {
+	var result chat.SummaryResult
+	err := c.client.ChatSummary(ctx, ChatSummaryRequest{
+		PreviousSummary: previousSummary,
+		Messages:        messages,
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call chat summary service: %w", err)
+	}
+	return &result, nil
+}
+
+// ============================================
+// Chat Session Methods
+// ============================================
+
+func (s *serviceImpl) GetChatSessions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*chat.ChatSessionResponse, error) {
+	return s.chatSvc.ListSessions(ctx, userID, page, limit)
+}
+
+func (s *serviceImpl) CreateChatSession(ctx context.Context, userID uuid.UUID, req *chat.CreateSessionRequest) (*chat.ChatSessionResponse, error) {
+	return s.chatSvc.CreateSession(ctx, userID, req)
+}
+
+func (s *serviceImpl) GetChatSessionWithMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, messageLimit int) (*chat.ChatSessionWithMessagesResponse, error) {
+	return s.chatSvc.GetSessionWithMessages(ctx, userID, sessionID, messageLimit)
+}
+
+func (s *serviceImpl) DeleteChatSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	return s.chatSvc.DeleteSession(ctx, userID, sessionID)
+}
+
+func (s *serviceImpl) GetChatMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, page, limit int) ([]*chat.ChatMessageResponse, error) {
+	// First verify the user has access to this session
+	_, err := s.chatSvc.GetSession(ctx, userID, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	checkins := []string{}
-	totalDuration := 0.0
-	totalSessions := 0
+	return s.chatSvc.GetMessages(ctx, sessionID, page, limit)
+}
+
+// chatToolContext is everything a chat tool may act on: the calling member's
+// own account and the chat session the call came from.
+type chatToolContext struct {
+	userID    uuid.UUID
+	member    *Member
+	sessionID uuid.UUID
+}
+
+// chatTool is a backend action the chat service can request. run returns
+// the result given to the model and optional display data for the client.
+type chatTool struct {
+	definition ToolDefinition
+	run        func(s *serviceImpl, ctx context.Context, tc *chatToolContext, args json.RawMessage) (result, display interface{}, err error)
+}
+
+var errUnknownTool = errors.New("unknown tool")
+
+var noToolParameters = map[string]interface{}{
+	"type":                 "object",
+	"properties":           map[string]interface{}{},
+	"additionalProperties": false,
+}
+
+var chatTools = []chatTool{
+	{
+		definition: ToolDefinition{
+			Name:        "get_subscription_status",
+			Description: "Get the member's current membership: plan, start and end date and days remaining.",
+			Parameters:  noToolParameters,
+		},
+		run: (*serviceImpl).toolSubscriptionStatus,
+	},
+	{
+		definition: ToolDefinition{
+			Name:        "get_attendance_stats",
+			Description: "Get the member's visit statistics over a recent period.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"days": map[string]interface{}{"type": "integer", "minimum": 7, "maximum": 365, "description": "Look-back window in days, 30 if omitted"},
+				},
+				"additionalProperties": false,
+			},
+		},
+		run: (*serviceImpl).toolAttendanceStats,
+	},
+	{
+		definition: ToolDefinition{
+			Name:        "generate_check_in_qr",
+			Description: "Generate the member's check-in (or check-out) QR code. The app shows the code to the member.",
+			Parameters:  noToolParameters,
+		},
+		run: (*serviceImpl).toolCheckInQR,
+	},
+	{
+		definition: ToolDefinition{
+			Name:        "list_plans",
+			Description: "List the membership plans the member can buy or renew onto at their gym.",
+			Parameters:  noToolParameters,
+		},
+		run: (*serviceImpl).toolListPlans,
+	},
+	{
+		definition: ToolDefinition{
+			Name:        "request_membership_freeze",
+			Description: "Ask staff to freeze the member's membership. Only call after the member confirmed the start date and length.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"start_date": map[string]interface{}{"type": "string", "format": "date", "description": "First frozen day, YYYY-MM-DD"},
+					"days":       map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxFreezeDays},
+					"reason":     map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"start_date", "days"},
+				"additionalProperties": false,
+			},
+		},
+		run: (*serviceImpl).toolRequestFreeze,
+	},
+	{
+		definition: ToolDefinition{
+			Name:        "request_membership_renewal",
+			Description: "Ask staff to renew the member's membership, optionally onto another plan from list_plans. Only call after the member confirmed.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"plan_id": map[string]interface{}{"type": "string", "description": "Plan ID from list_plans; the current plan if omitted"},
+					"note":    map[string]interface{}{"type": "string"},
+				},
+				"additionalProperties": false,
+			},
+		},
+		run: (*serviceImpl).toolRequestRenewal,
+	},
+}
+
+func chatToolDefinitions() []ToolDefinition {
+	definitions := make([]ToolDefinition, len(chatTools))
+	for i, tool := range chatTools {
+		definitions[i] = tool.definition
+	}
+	return definitions
+}
+
+// runChatTools executes the requested calls in order. Failures are reported
+// back to the model as results rather than aborting the chat.
+func (s *serviceImpl) runChatTools(ctx context.Context, tc *chatToolContext, calls []ToolCall) ([]ToolExchange, []ToolCallRecord) {
+	exchanges := make([]ToolExchange, 0, len(calls))
+	records := make([]ToolCallRecord, 0, len(calls))
+
+	for _, call := range calls {
+		record := ToolCallRecord{ID: call.ID, Name: call.Name, Arguments: call.Arguments}
+		start := time.Now()
+
+		var (
+			result, display interface{}
+			err             error
+		)
+		tool := findChatTool(call.Name)
+		if tool == nil {
+			err = fmt.Errorf("%w %q", errUnknownTool, call.Name)
+		} else {
+			result, display, err = tool.run(s, ctx, tc, call.Arguments)
+		}
+
+		record.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			log.Printf("Service: chat tool %s failed for member %s: %v", call.Name, tc.member.ID, err)
+			record.Error = toolErrorMessage(err)
+			result = map[string]string{"error": record.Error}
+		} else {
+			record.Result = result
+			record.Display = display
+		}
+
+		exchanges = append(exchanges, ToolExchange{Call: call, Result: result})
+		records = append(records, record)
+	}
+	return exchanges, records
+}
+
+func findChatTool(name string) *chatTool {
+	for i := range chatTools {
+		if chatTools[i].definition.Name == name {
+			return &chatTools[i]
+		}
+	}
+	return nil
+}
+
+// toolErrorMessage keeps internal errors away from the model and the
+// member; only expected, user-facing failures are passed through.
+func toolErrorMessage(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrMemberRequestPending),
+		errors.Is(err, ErrInvalidFreeze),
+		errors.Is(err, ErrNoActiveSubscription),
+		errors.Is(err, ErrPlanUnavailable):
+		return err.Error()
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return "invalid tool arguments"
+	case errors.Is(err, errUnknownTool):
+		return err.Error()
+	default:
+		return "the request could not be completed right now"
+	}
+}
+
+// toolCallMetadata is stored on the assistant message. Display data is
+// dropped since it may contain short-lived credentials.
+func toolCallMetadata(records []ToolCallRecord) map[string]interface{} {
+	if len(records) == 0 {
+		return nil
+	}
+	stored := make([]ToolCallRecord, len(records))
+	for i, record := range records {
+		record.Display = nil
+		stored[i] = record
+	}
+	return map[string]interface{}{"tool_calls": stored}
+}
+
+func decodeToolArgs(args json.RawMessage, v interface{}) error {
+	if len(args) == 0 || string(args) == "null" {
+		return nil
+	}
+	return json.Unmarshal(args, v)
+}
+
+func (s *serviceImpl) toolSubscriptionStatus(ctx context.Context, tc *chatToolContext, _ json.RawMessage) (interface{}, interface{}, error) {
+	sub, err := s.subSvc.GetAccessSubscription(ctx, tc.member.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return map[string]interface{}{"active": false}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := map[string]interface{}{
+		"active":        true,
+		"status":        string(sub.Status),
+		"startDate":     sub.StartDate.Format("2006-01-02"),
+		"endDate":       sub.EndDate.Format("2006-01-02"),
+		"daysRemaining": max(0, int(time.Until(sub.EndDate).Hours()/24)),
+		"frozen":        sub.IsFrozen(time.Now()),
+		"cancelsAtEnd":  sub.CancelledAt != nil,
+	}
+	if sub.PlanID != nil {
+		if plan, err := s.plansSvc.GetPlan(ctx, *sub.PlanID); err == nil {
+			result["plan"] = plan.Name
+		}
+	}
+	return result, nil, nil
+}
+
+func (s *serviceImpl) toolAttendanceStats(ctx context.Context, tc *chatToolContext, args json.RawMessage) (interface{}, interface{}, error) {
+	var in struct {
+		Days int `json:"days"`
+	}
+	if err := decodeToolArgs(args, &in); err != nil {
+		return nil, nil, err
+	}
+	if in.Days < 7 || in.Days > 365 {
+		in.Days = 30
+	}
 
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -in.Days)
+	attendance, err := s.GetAttendance(ctx, tc.userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	days := make([]wellness.Day, 0, len(attendance))
+	var lastVisit string
 	for _, a := range attendance {
+		days = append(days, wellness.Day{Date: a.Date, Attended: a.IsAttendance, DurationMinutes: float64(a.Duration)})
 		if a.IsAttendance {
-			checkins = append(checkins, a.Date)
-			totalDuration += float64(a.Duration)
-			totalSessions++
+			lastVisit = a.Date
 		}
 	}
+	m := wellness.ComputeMetrics(days)
 
-	avgDurationMinutes := 0.0
-	if totalSessions > 0 {
-		avgDurationMinutes = totalDuration / float64(totalSessions)
+	result := map[string]interface{}{
+		"days":               in.Days,
+		"visits":             m.Sessions,
+		"visitsPerWeek":      m.SessionsPerWeek,
+		"avgDurationMinutes": m.AvgDurationMinutes,
+		"longestStreakDays":  m.LongestStreak,
+		"restDays":           m.RestDays,
 	}
+	if lastVisit != "" {
+		result["lastVisit"] = lastVisit
+	}
+	return result, nil, nil
+}
 
-	activityData := ActivityData{
-		Last30DaysCheckins:      checkins,
-		AverageDurationMinutes:  avgDurationMinutes,
-		TotalSessionsLast30Days: totalSessions,
+func (s *serviceImpl) toolCheckInQR(ctx context.Context, tc *chatToolContext, _ json.RawMessage) (interface{}, interface{}, error) {
+	qr, err := s.GetDataQR(ctx, tc.userID)
+	if err != nil {
+		return nil, nil, err
 	}
+	return map[string]interface{}{"generated": true, "shownInApp": true}, map[string]string{"qrToken": qr.Token}, nil
+}
 
-	sub, err := s.subSvc.GetActiveSubscription(ctx, member.ID)
+func (s *serviceImpl) toolListPlans(ctx context.Context, tc *chatToolContext, _ json.RawMessage) (interface{}, interface{}, error) {
+	available, err := s.availablePlans(ctx, tc.member)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	var membershipInfo *MembershipInfo
-	if err == nil && sub != nil {
-		daysUntil := int(time.Until(sub.EndDate).Hours() / 24)
-		membershipInfo = &MembershipInfo{
-			DaysUntilRenewal: daysUntil,
-			RenewalHistory:   []string{},
+	result := make([]map[string]interface{}, 0, len(available))
+	for _, plan := range available {
+		item := map[string]interface{}{
+			"id":           plan.ID,
+			"name":         plan.Name,
+			"price":        plan.Price,
+			"durationDays": plan.DurationDays,
+		}
+		if plan.Description != nil {
+			item["description"] = *plan.Description
 		}
+		if plan.ClassCredits != nil {
+			item["classCredits"] = *plan.ClassCredits
+		}
+		if plan.SessionCredits != nil {
+			item["trainingSessions"] = *plan.SessionCredits
+		}
+		result = append(result, item)
 	}
+	return map[string]interface{}{"plans": result}, nil, nil
+}
 
-	reqPayload := ChatbotRequest{
-		Query: query,
-		Context: WellnessAnalysisRequest{
-			UserProfile:    userProfile,
-			ActivityData:   activityData,
-			MembershipInfo: membershipInfo,
-		},
+func (s *serviceImpl) toolRequestFreeze(ctx context.Context, tc *chatToolContext, args json.RawMessage) (interface{}, interface{}, error) {
+	var in struct {
+		StartDate string `json:"start_date"`
+		Days      int    `json:"days"`
+		Reason    string `json:"reason"`
+	}
+	if err := decodeToolArgs(args, &in); err != nil {
+		return nil, nil, err
 	}
 
-	contextData := map[string]interface{}{
-		"user_profile":    userProfile,
-		"activity_data":   activityData,
-		"membership_info": membershipInfo,
+	req := &CreateMemberRequestRequest{
+		Type:        string(MemberRequestFreeze),
+		FreezeStart: &in.StartDate,
+		FreezeDays:  &in.Days,
 	}
-	_, err = s.chatSvc.AddUserMessage(ctx, session.ID, query, contextData)
+	if in.Reason != "" {
+		req.Note = &in.Reason
+	}
+
+	created, err := s.createMemberRequest(ctx, tc.member, req, MemberRequestSourceChat, &tc.sessionID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return memberRequestToolResult(created), nil, nil
+}
 
-	chatURL := config.Get().Analytics.ServiceURL
-	chatURL = strings.Replace(chatURL, "/analyze", "/chat", 1)
+func (s *serviceImpl) toolRequestRenewal(ctx context.Context, tc *chatToolContext, args json.RawMessage) (interface{}, interface{}, error) {
+	var in struct {
+		PlanID string `json:"plan_id"`
+		Note   string `json:"note"`
+	}
+	if err := decodeToolArgs(args, &in); err != nil {
+		return nil, nil, err
+	}
 
-	bodyBytes, err := json.Marshal(reqPayload)
+	req := &CreateMemberRequestRequest{Type: string(MemberRequestRenewal)}
+	if in.PlanID != "" {
+		planID, err := uuid.Parse(in.PlanID)
+		if err != nil {
+			return nil, nil, ErrPlanUnavailable
+		}
+		req.PlanID = &planID
+	}
+	if in.Note != "" {
+		req.Note = &in.Note
+	}
+
+	created, err := s.createMemberRequest(ctx, tc.member, req, MemberRequestSourceChat, &tc.sessionID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return memberRequestToolResult(created), nil, nil
+}
+
+func memberRequestToolResult(req *MemberRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"requestId": req.ID,
+		"type":      string(req.Type),
+		"status":    string(req.Status),
+		"message":   "Request sent to staff for review.",
+	}
+}
+
+// maxFreezeDays caps a single freeze request.
+const maxFreezeDays = 90
+
+func (s *serviceImpl) ListAvailablePlans(ctx context.Context, member *Member) ([]*plans.Plan, error) {
+	return s.availablePlans(ctx, member)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", chatURL, bytes.NewBuffer(bodyBytes))
+// availablePlans lists the active plans of the member's organization that
+// are offered at their home branch.
+func (s *serviceImpl) availablePlans(ctx context.Context, member *Member) ([]*plans.Plan, error) {
+	all, err := s.plansSvc.ListPlansByOrganization(ctx, member.OrganizationID, 1, 100)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
+	available := make([]*plans.Plan, 0, len(all))
+	for _, plan := range all {
+		if plan.IsActive != nil && !*plan.IsActive {
+			continue
+		}
+		if !planOfferedAt(plan, member.HomeBranchID) {
+			continue
+		}
+		available = append(available, plan)
+	}
+	return available, nil
+}
+
+// planOfferedAt reports whether a plan is sold at branchID; plans without
+// branches are offered everywhere.
+func planOfferedAt(plan *plans.Plan, branchID *uuid.UUID) bool {
+	if len(plan.BranchIDs) == 0 || branchID == nil {
+		return true
+	}
+	for _, id := range plan.BranchIDs {
+		if id == branchID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *serviceImpl) CreateMemberRequest(ctx context.Context, userID uuid.UUID, req *CreateMemberRequestRequest) (*MemberRequest, error) {
+	member, err := s.repo.GetByUserID(ctx, userID)
 	if err != nil {
-		log.Printf("Service: Chat failed to call FastAPI: %v", err)
-		return nil, fmt.Errorf("failed to call chat service")
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return s.createMemberRequest(ctx, member, req, MemberRequestSourceApp, nil)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Service: Chat FastAPI returned status: %d", resp.StatusCode)
-		return nil, fmt.Errorf("chat service returned error")
+func (s *serviceImpl) createMemberRequest(ctx context.Context, member *Member, req *CreateMemberRequestRequest, source string, chatSessionID *uuid.UUID) (*MemberRequest, error) {
+	request := &MemberRequest{
+		MemberID:      member.ID,
+		Type:          MemberRequestType(req.Type),
+		Status:        MemberRequestPending,
+		Note:          req.Note,
+		Source:        source,
+		ChatSessionID: chatSessionID,
+	}
+
+	switch request.Type {
+	case MemberRequestFreeze:
+		if req.FreezeStart == nil || req.FreezeDays == nil || *req.FreezeDays < 1 || *req.FreezeDays > maxFreezeDays {
+			return nil, ErrInvalidFreeze
+		}
+		freezeStart, err := time.Parse("2006-01-02", *req.FreezeStart)
+		if err != nil || freezeStart.Before(time.Now().Truncate(24*time.Hour)) {
+			return nil, ErrInvalidFreeze
+		}
+		if _, err := s.subSvc.GetActiveSubscription(ctx, member.ID); err != nil {
+			return nil, ErrNoActiveSubscription
+		}
+		request.FreezeStart = &freezeStart
+		request.FreezeDays = req.FreezeDays
+	case MemberRequestRenewal:
+		if req.PlanID != nil {
+			available, err := s.availablePlans(ctx, member)
+			if err != nil {
+				return nil, err
+			}
+			offered := false
+			for _, plan := range available {
+				offered = offered || plan.ID == *req.PlanID
+			}
+			if !offered {
+				return nil, ErrPlanUnavailable
+			}
+		}
+		request.PlanID = req.PlanID
+	case MemberRequestCancellation:
+		if _, err := s.subSvc.GetActiveSubscription(ctx, member.ID); err != nil {
+			return nil, ErrNoActiveSubscription
+		}
+	default:
+		return nil, fmt.Errorf("unknown request type %q", req.Type)
 	}
 
-	var chatResponse ChatbotResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chatResponse); err != nil {
+	if err := s.repo.CreateRequest(ctx, request); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrMemberRequestPending
+		}
+		log.Printf("Service: CreateMemberRequest failed for member %s: %v", member.ID, err)
 		return nil, err
 	}
+	return request, nil
+}
 
-	_, err = s.chatSvc.AddAssistantMessage(ctx, session.ID, chatResponse.Answer, chatResponse.SuggestedActions, nil)
+func (s *serviceImpl) ListMyRequests(ctx context.Context, userID uuid.UUID) ([]*MemberRequest, error) {
+	member, err := s.repo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-
-	return &chatResponse, nil
+	return s.repo.ListRequestsByMemberID(ctx, member.ID)
 }
 
-// ============================================
-// Chat Session Methods
-// ============================================
+func (s *serviceImpl) CancelMyRequest(ctx context.Context, userID uuid.UUID, requestID uuid.UUID) error {
+	member, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
 
-func (s *serviceImpl) GetChatSessions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*chat.ChatSessionResponse, error) {
-	return s.chatSvc.ListSessions(ctx, userID, page, limit)
+	request, err := s.repo.GetRequestByID(ctx, requestID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && request.MemberID != member.ID) {
+		return ErrMemberRequestNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.ResolveRequest(ctx, requestID, MemberRequestCancelled, &userID, nil); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMemberRequestResolved
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *serviceImpl) CreateChatSession(ctx context.Context, userID uuid.UUID, req *chat.CreateSessionRequest) (*chat.ChatSessionResponse, error) {
-	return s.chatSvc.CreateSession(ctx, userID, req)
+func (s *serviceImpl) ListMemberRequests(ctx context.Context, filter *MemberRequestFilter, userRole string, userBranchIDs []uuid.UUID) ([]*MemberRequest, error) {
+	switch userRole {
+	case "staff":
+		if len(userBranchIDs) == 0 {
+			return []*MemberRequest{}, nil
+		}
+		filter.BranchIDs = userBranchIDs
+	case "admin", "super_admin":
+	default:
+		return []*MemberRequest{}, nil
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+
+	return s.repo.ListRequests(ctx, filter)
 }
 
-func (s *serviceImpl) GetChatSessionWithMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, messageLimit int) (*chat.ChatSessionWithMessagesResponse, error) {
-	return s.chatSvc.GetSessionWithMessages(ctx, userID, sessionID, messageLimit)
+// ApproveMemberRequest applies the request: a renewal renews the active
+// subscription, a freeze blocks access for the frozen days and pushes its end
+// date back by as many, and a cancellation lets it run to its end date
+// without renewal.
+func (s *serviceImpl) ApproveMemberRequest(ctx context.Context, requestID uuid.UUID, reviewerID uuid.UUID, userRole string, userBranchIDs []uuid.UUID, note *string) (*MemberRequest, error) {
+	request, err := s.getReviewableRequest(ctx, requestID, userRole, userBranchIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim the request first so a concurrent review cannot apply it twice.
+	if err := s.repo.ResolveRequest(ctx, requestID, MemberRequestApproved, &reviewerID, note); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemberRequestResolved
+		}
+		return nil, err
+	}
+
+	if err := s.applyMemberRequest(ctx, request); err != nil {
+		log.Printf("Service: ApproveMemberRequest failed to apply %s: %v", requestID, err)
+		if reopenErr := s.repo.ReopenRequest(ctx, requestID); reopenErr != nil {
+			log.Printf("Service: ApproveMemberRequest failed to reopen %s: %v", requestID, reopenErr)
+		}
+		return nil, err
+	}
+
+	return s.repo.GetRequestByID(ctx, requestID)
 }
 
-func (s *serviceImpl) DeleteChatSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
-	return s.chatSvc.DeleteSession(ctx, userID, sessionID)
+func (s *serviceImpl) RejectMemberRequest(ctx context.Context, requestID uuid.UUID, reviewerID uuid.UUID, userRole string, userBranchIDs []uuid.UUID, note *string) (*MemberRequest, error) {
+	if _, err := s.getReviewableRequest(ctx, requestID, userRole, userBranchIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ResolveRequest(ctx, requestID, MemberRequestRejected, &reviewerID, note); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemberRequestResolved
+		}
+		return nil, err
+	}
+
+	return s.repo.GetRequestByID(ctx, requestID)
 }
 
-func (s *serviceImpl) GetChatMessages(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, page, limit int) ([]*chat.ChatMessageResponse, error) {
-	// First verify the user has access to this session
-	_, err := s.chatSvc.GetSession(ctx, userID, sessionID)
+func (s *serviceImpl) getReviewableRequest(ctx context.Context, requestID uuid.UUID, userRole string, userBranchIDs []uuid.UUID) (*MemberRequest, error) {
+	request, err := s.repo.GetRequestByID(ctx, requestID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberRequestNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	if request.Status != MemberRequestPending {
+		return nil, ErrMemberRequestResolved
+	}
 
-	return s.chatSvc.GetMessages(ctx, sessionID, page, limit)
+	if userRole == "staff" {
+		member, err := s.repo.GetByID(ctx, request.MemberID)
+		if err != nil {
+			return nil, err
+		}
+		allowed := false
+		for _, id := range userBranchIDs {
+			allowed = allowed || (member.HomeBranchID != nil && *member.HomeBranchID == id)
+		}
+		if !allowed {
+			return nil, ErrMemberRequestAccess
+		}
+	}
+	return request, nil
+}
+
+func (s *serviceImpl) applyMemberRequest(ctx context.Context, request *MemberRequest) error {
+	switch request.Type {
+	case MemberRequestRenewal:
+		_, err := s.subSvc.RenewSubscription(ctx, request.MemberID, &subscription.RenewSubscriptionRequest{PlanID: request.PlanID})
+		if errors.Is(err, subscription.ErrNoActiveSubscription) {
+			return ErrNoActiveSubscription
+		}
+		return err
+	case MemberRequestFreeze:
+		sub, err := s.subSvc.GetActiveSubscription(ctx, request.MemberID)
+		if err != nil {
+			return ErrNoActiveSubscription
+		}
+		_, err = s.subSvc.FreezeSubscription(ctx, sub.ID, *request.FreezeStart, *request.FreezeDays)
+		return err
+	case MemberRequestCancellation:
+		sub, err := s.subSvc.GetActiveSubscription(ctx, request.MemberID)
+		if err != nil {
+			return ErrNoActiveSubscription
+		}
+		// Access runs until the paid period ends; the expiry job closes it out.
+		_, err = s.subSvc.CancelAtPeriodEnd(ctx, sub.ID)
+		if errors.Is(err, subscription.ErrNoActiveSubscription) {
+			return ErrNoActiveSubscription
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown request type %q", request.Type)
+	}
 }
@@ -0,0 +1,33 @@
+package occupancy
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateCapacityWindowRequest struct {
+	DayOfWeek    *int   `json:"dayOfWeek,omitempty" validate:"omitempty,min=0,max=6"`
+	StartTime    string `json:"startTime" validate:"required"`
+	EndTime      string `json:"endTime" validate:"required"`
+	MaxOccupancy int    `json:"maxOccupancy" validate:"required,gt=0"`
+}
+
+type CapacityWindowResponse struct {
+	ID           uuid.UUID `json:"id"`
+	BranchID     uuid.UUID `json:"branchId"`
+	DayOfWeek    *int      `json:"dayOfWeek,omitempty"`
+	StartTime    string    `json:"startTime"`
+	EndTime      string    `json:"endTime"`
+	MaxOccupancy int       `json:"maxOccupancy"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type WaitlistEntryResponse struct {
+	ID         uuid.UUID      `json:"id"`
+	BranchID   uuid.UUID      `json:"branchId"`
+	MemberID   uuid.UUID      `json:"memberId"`
+	Status     WaitlistStatus `json:"status"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	NotifiedAt *time.Time     `json:"notifiedAt,omitempty"`
+}
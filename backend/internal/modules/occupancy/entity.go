@@ -0,0 +1,105 @@
+package occupancy
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type EventType string
+
+const (
+	EventSnapshot     EventType = "snapshot"
+	EventCheckIn      EventType = "check_in"
+	EventCheckOut     EventType = "check_out"
+	EventNearCapacity EventType = "near_capacity"
+	EventAtCapacity   EventType = "at_capacity"
+	// EventSpaceAvailable is sent when a waitlisted member has been notified.
+	EventSpaceAvailable EventType = "space_available"
+)
+
+// Event is a single occupancy change for a branch. Count is the number of
+// people inside the branch after the change was applied.
+type Event struct {
+	Type       EventType  `json:"type"`
+	BranchID   uuid.UUID  `json:"branchId"`
+	MemberID   *uuid.UUID `json:"memberId,omitempty"`
+	Count      int        `json:"count"`
+	Threshold  *int       `json:"threshold,omitempty"`
+	Capacity   *int       `json:"capacity,omitempty"`
+	OccurredAt time.Time  `json:"occurredAt"`
+}
+
+// CheckIn is a visit to record once the branch has room for it. At most one
+// of SubscriptionID, TrialPassID and PassID is set. Claim, when set, runs in
+// the check-in transaction before the row is inserted, e.g. to use a visit of
+// a pass; an error from it aborts the check-in and is returned unchanged.
+// ID and CheckInTime are filled in once the check-in is recorded.
+type CheckIn struct {
+	BranchID       uuid.UUID
+	MemberID       uuid.UUID
+	SubscriptionID *uuid.UUID
+	TrialPassID    *uuid.UUID
+	PassID         *uuid.UUID
+	Method         string
+	Claim          func(ctx context.Context, tx pgx.Tx) error
+
+	ID          uuid.UUID
+	CheckInTime time.Time
+}
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "waiting"
+	WaitlistNotified  WaitlistStatus = "notified"
+	WaitlistAdmitted  WaitlistStatus = "admitted"
+	WaitlistCancelled WaitlistStatus = "cancelled"
+)
+
+// CapacityWindow overrides the branch max occupancy between StartTime and
+// EndTime (HH:MM, branch local time). A nil DayOfWeek applies every day.
+type CapacityWindow struct {
+	ID           uuid.UUID `db:"id"`
+	BranchID     uuid.UUID `db:"branch_id"`
+	DayOfWeek    *int      `db:"day_of_week"`
+	StartTime    string    `db:"start_time"`
+	EndTime      string    `db:"end_time"`
+	MaxOccupancy int       `db:"max_occupancy"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+type WaitlistEntry struct {
+	ID         uuid.UUID      `db:"id"`
+	BranchID   uuid.UUID      `db:"branch_id"`
+	MemberID   uuid.UUID      `db:"member_id"`
+	Status     WaitlistStatus `db:"status"`
+	CreatedAt  time.Time      `db:"created_at"`
+	NotifiedAt *time.Time     `db:"notified_at"`
+	Email      string         `db:"email"`
+}
+
+func (w *CapacityWindow) ToResponse() *CapacityWindowResponse {
+	return &CapacityWindowResponse{
+		ID:           w.ID,
+		BranchID:     w.BranchID,
+		DayOfWeek:    w.DayOfWeek,
+		StartTime:    w.StartTime,
+		EndTime:      w.EndTime,
+		MaxOccupancy: w.MaxOccupancy,
+		CreatedAt:    w.CreatedAt,
+	}
+}
+
+func (e *WaitlistEntry) ToResponse() *WaitlistEntryResponse {
+	return &WaitlistEntryResponse{
+		ID:         e.ID,
+		BranchID:   e.BranchID,
+		MemberID:   e.MemberID,
+		Status:     e.Status,
+		CreatedAt:  e.CreatedAt,
+		NotifiedAt: e.NotifiedAt,
+	}
+}
@@ -0,0 +1,312 @@
+package occupancy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// heartbeatInterval keeps idle SSE connections from being closed by proxies.
+const heartbeatInterval = 15 * time.Second
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/occupancy", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member"))
+			r.Post("/{branchId}/waitlist", h.JoinWaitlist)
+			r.Delete("/{branchId}/waitlist", h.LeaveWaitlist)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("admin", "staff"))
+			r.Get("/{branchId}", h.GetSnapshot)
+			r.Get("/{branchId}/stream", h.Stream)
+			r.Get("/{branchId}/waitlist", h.ListWaitlist)
+			r.Get("/{branchId}/capacity-windows", h.ListCapacityWindows)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Post("/{branchId}/capacity-windows", h.CreateCapacityWindow)
+			r.Delete("/{branchId}/capacity-windows/{windowId}", h.DeleteCapacityWindow)
+		})
+	})
+}
+
+func (h *Handler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	snapshot, err := h.service.GetSnapshot(r.Context(), branchID)
+	if err != nil {
+		if err == ErrBranchNotFound {
+			response.NotFound(w, "Branch not found")
+			return
+		}
+		response.InternalServerError(w, "Failed to get occupancy")
+		return
+	}
+
+	response.Success(w, "Occupancy retrieved successfully", snapshot)
+}
+
+// Stream pushes occupancy events for a branch as Server-Sent Events. The
+// first event is always a snapshot of the current count.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	// Subscribe before taking the snapshot so no event is missed in between.
+	events, unsubscribe := h.service.Subscribe(branchID)
+	defer unsubscribe()
+
+	snapshot, err := h.service.GetSnapshot(r.Context(), branchID)
+	if err != nil {
+		if err == ErrBranchNotFound {
+			response.NotFound(w, "Branch not found")
+			return
+		}
+		response.InternalServerError(w, "Failed to get occupancy")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The server WriteTimeout would otherwise cut the stream after 30s.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Handler: Stream could not clear write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeEvent(w, snapshot); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("Handler: Stream flush failed: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+func (h *Handler) ListCapacityWindows(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	windows, err := h.service.ListCapacityWindows(r.Context(), branchID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list capacity windows")
+		return
+	}
+
+	responses := make([]*CapacityWindowResponse, 0, len(windows))
+	for _, window := range windows {
+		responses = append(responses, window.ToResponse())
+	}
+	response.Success(w, "Capacity windows retrieved successfully", responses)
+}
+
+func (h *Handler) CreateCapacityWindow(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	var req CreateCapacityWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	window, err := h.service.CreateCapacityWindow(r.Context(), branchID, &req)
+	if err != nil {
+		if err == ErrInvalidCapacityWindow {
+			response.BadRequest(w, err.Error(), nil)
+			return
+		}
+		response.InternalServerError(w, "Failed to create capacity window")
+		return
+	}
+
+	response.Success(w, "Capacity window created successfully", window.ToResponse())
+}
+
+func (h *Handler) DeleteCapacityWindow(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	windowID, err := uuid.Parse(chi.URLParam(r, "windowId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid capacity window ID", nil)
+		return
+	}
+
+	if err := h.service.DeleteCapacityWindow(r.Context(), branchID, windowID); err != nil {
+		if err == ErrCapacityWindowNotFound {
+			response.NotFound(w, "Capacity window not found")
+			return
+		}
+		response.InternalServerError(w, "Failed to delete capacity window")
+		return
+	}
+
+	response.OK(w, "Capacity window deleted successfully")
+}
+
+func (h *Handler) ListWaitlist(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	entries, err := h.service.ListWaitlist(r.Context(), branchID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list waitlist")
+		return
+	}
+
+	responses := make([]*WaitlistEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		responses = append(responses, entry.ToResponse())
+	}
+	response.Success(w, "Waitlist retrieved successfully", responses)
+}
+
+func (h *Handler) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+
+	entry, err := h.service.JoinWaitlist(r.Context(), branchID, userID)
+	if err != nil {
+		switch err {
+		case ErrMemberNotFound, ErrBranchNotFound:
+			response.NotFound(w, err.Error())
+		case ErrAlreadyWaitlisted, ErrWaitlistNotNeeded:
+			response.Conflict(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to join waitlist")
+		}
+		return
+	}
+
+	response.Success(w, "Joined waitlist successfully", entry.ToResponse())
+}
+
+func (h *Handler) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	branchID, err := uuid.Parse(chi.URLParam(r, "branchId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid branch ID", nil)
+		return
+	}
+
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+
+	if err := h.service.LeaveWaitlist(r.Context(), branchID, userID); err != nil {
+		if err == ErrMemberNotFound || err == ErrWaitlistEntryNotFound {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalServerError(w, "Failed to leave waitlist")
+		return
+	}
+
+	response.OK(w, "Left waitlist successfully")
+}
+
+func userIDFromContext(r *http.Request) (uuid.UUID, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		return uuid.Nil, false
+	}
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
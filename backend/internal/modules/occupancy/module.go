@@ -0,0 +1,31 @@
+package occupancy
+
+import (
+	"fitcore/pkg/email"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+// emailSvc can be nil if waitlist emails are not needed
+func NewProvider(db *pgxpool.Pool, emailSvc *email.Service) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, emailSvc)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
@@ -0,0 +1,348 @@
+package occupancy
+
+import (
+	"context"
+	"errors"
+	"fitcore/internal/config"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// notifyChannel is the Postgres channel used to fan occupancy events out to every replica.
+const notifyChannel = "branch_occupancy"
+
+type Repository interface {
+	CountActive(ctx context.Context, branchID uuid.UUID) (int, error)
+	GetAlertThreshold(ctx context.Context, branchID uuid.UUID) (*int, error)
+	GetCapacity(ctx context.Context, branchID uuid.UUID) (*int, error)
+	// CheckIn records the check-in unless the branch is full, returning
+	// ErrBranchAtCapacity or ErrBranchNotFound otherwise.
+	CheckIn(ctx context.Context, checkIn *CheckIn) error
+	ListCapacityWindows(ctx context.Context, branchID uuid.UUID) ([]*CapacityWindow, error)
+	CreateCapacityWindow(ctx context.Context, window *CapacityWindow) error
+	DeleteCapacityWindow(ctx context.Context, branchID, id uuid.UUID) error
+	GetMemberIDByUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	JoinWaitlist(ctx context.Context, entry *WaitlistEntry) error
+	CancelWaitlist(ctx context.Context, branchID, memberID uuid.UUID) error
+	MarkWaitlistAdmitted(ctx context.Context, branchID, memberID uuid.UUID) error
+	ListWaitlist(ctx context.Context, branchID uuid.UUID) ([]*WaitlistEntry, error)
+	NotifyNextWaiting(ctx context.Context, branchID uuid.UUID) (*WaitlistEntry, error)
+	Notify(ctx context.Context, payload string) error
+	Listen(ctx context.Context, ready func(), handle func(payload string)) error
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+const countActiveQuery = `
+	SELECT COUNT(*)
+	FROM check_ins
+	WHERE branch_id = $1
+		AND check_in_time IS NOT NULL
+		AND check_out_time IS NULL
+		AND deleted_at IS NULL
+`
+
+// capacityQuery returns the max occupancy in effect right now, preferring a
+// matching capacity window (evaluated in the branch timezone) over the branch
+// default. A window whose end is at or before its start runs past midnight,
+// so after midnight it is matched against yesterday's day of week. A NULL
+// result means the branch has no limit.
+const capacityQuery = `
+	SELECT COALESCE(
+		(
+			SELECT w.max_occupancy
+			FROM branch_capacity_windows w
+			WHERE w.branch_id = b.id
+				AND (
+					(
+						(w.day_of_week IS NULL OR w.day_of_week = l.dow)
+						AND l.at::time >= w.start_time
+						AND (w.end_time <= w.start_time OR l.at::time < w.end_time)
+					)
+					OR (
+						w.end_time <= w.start_time
+						AND (w.day_of_week IS NULL OR w.day_of_week = (l.dow + 6) % 7)
+						AND l.at::time < w.end_time
+					)
+				)
+			ORDER BY w.day_of_week NULLS LAST, w.max_occupancy ASC
+			LIMIT 1
+		),
+		b.max_occupancy
+	)
+	FROM branches b
+	CROSS JOIN LATERAL (
+		SELECT NOW() AT TIME ZONE COALESCE(b.timezone, 'UTC') AS at,
+			EXTRACT(DOW FROM NOW() AT TIME ZONE COALESCE(b.timezone, 'UTC'))::int AS dow
+	) l
+	WHERE b.id = $1 AND b.deleted_at IS NULL
+`
+
+func (r *repositoryImpl) CountActive(ctx context.Context, branchID uuid.UUID) (int, error) {
+	var count int
+	if err := r.db.QueryRow(ctx, countActiveQuery, branchID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *repositoryImpl) GetAlertThreshold(ctx context.Context, branchID uuid.UUID) (*int, error) {
+	query := `SELECT occupancy_alert_threshold FROM branches WHERE id = $1 AND deleted_at IS NULL`
+	var threshold *int
+	if err := r.db.QueryRow(ctx, query, branchID).Scan(&threshold); err != nil {
+		return nil, err
+	}
+	return threshold, nil
+}
+
+// GetCapacity returns the max occupancy in effect right now. A nil result
+// means the branch has no limit.
+func (r *repositoryImpl) GetCapacity(ctx context.Context, branchID uuid.UUID) (*int, error) {
+	var capacity *int
+	if err := r.db.QueryRow(ctx, capacityQuery, branchID).Scan(&capacity); err != nil {
+		return nil, err
+	}
+	return capacity, nil
+}
+
+func (r *repositoryImpl) CheckIn(ctx context.Context, c *CheckIn) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Check-ins at one branch take turns, so two scans can never both see
+	// the last free spot. The lock is released with the transaction.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))`, c.BranchID); err != nil {
+		return err
+	}
+
+	var capacity *int
+	if err := tx.QueryRow(ctx, capacityQuery, c.BranchID).Scan(&capacity); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBranchNotFound
+		}
+		return err
+	}
+	if capacity != nil {
+		var count int
+		if err := tx.QueryRow(ctx, countActiveQuery, c.BranchID).Scan(&count); err != nil {
+			return err
+		}
+		if count >= *capacity {
+			return ErrBranchAtCapacity
+		}
+	}
+
+	if c.Claim != nil {
+		if err := c.Claim(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO check_ins (member_id, branch_id, subscription_id, trial_pass_id, pass_id, check_in_time, method)
+		VALUES ($1, $2, $3, $4, $5, NOW(), $6)
+		RETURNING id, check_in_time
+	`
+	if err := tx.QueryRow(ctx, query,
+		c.MemberID,
+		c.BranchID,
+		c.SubscriptionID,
+		c.TrialPassID,
+		c.PassID,
+		c.Method,
+	).Scan(&c.ID, &c.CheckInTime); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) ListCapacityWindows(ctx context.Context, branchID uuid.UUID) ([]*CapacityWindow, error) {
+	query := `
+		SELECT id, branch_id, day_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), max_occupancy, created_at
+		FROM branch_capacity_windows
+		WHERE branch_id = $1
+		ORDER BY day_of_week NULLS FIRST, start_time
+	`
+	rows, err := r.db.Query(ctx, query, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []*CapacityWindow
+	for rows.Next() {
+		var w CapacityWindow
+		if err := rows.Scan(&w.ID, &w.BranchID, &w.DayOfWeek, &w.StartTime, &w.EndTime, &w.MaxOccupancy, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		windows = append(windows, &w)
+	}
+	return windows, rows.Err()
+}
+
+func (r *repositoryImpl) CreateCapacityWindow(ctx context.Context, window *CapacityWindow) error {
+	query := `
+		INSERT INTO branch_capacity_windows (branch_id, day_of_week, start_time, end_time, max_occupancy)
+		VALUES ($1, $2, $3::time, $4::time, $5)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		window.BranchID,
+		window.DayOfWeek,
+		window.StartTime,
+		window.EndTime,
+		window.MaxOccupancy,
+	).Scan(&window.ID, &window.CreatedAt)
+}
+
+func (r *repositoryImpl) DeleteCapacityWindow(ctx context.Context, branchID, id uuid.UUID) error {
+	query := `DELETE FROM branch_capacity_windows WHERE id = $1 AND branch_id = $2`
+	tag, err := r.db.Exec(ctx, query, id, branchID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *repositoryImpl) GetMemberIDByUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	var memberID uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM members WHERE user_id = $1`, userID).Scan(&memberID)
+	return memberID, err
+}
+
+func (r *repositoryImpl) JoinWaitlist(ctx context.Context, entry *WaitlistEntry) error {
+	query := `
+		INSERT INTO branch_waitlist (branch_id, member_id)
+		VALUES ($1, $2)
+		RETURNING id, status, created_at
+	`
+	return r.db.QueryRow(ctx, query, entry.BranchID, entry.MemberID).Scan(&entry.ID, &entry.Status, &entry.CreatedAt)
+}
+
+func (r *repositoryImpl) CancelWaitlist(ctx context.Context, branchID, memberID uuid.UUID) error {
+	query := `UPDATE branch_waitlist SET status = 'cancelled' WHERE branch_id = $1 AND member_id = $2 AND status = 'waiting'`
+	tag, err := r.db.Exec(ctx, query, branchID, memberID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// MarkWaitlistAdmitted closes any open waitlist entry once the member is
+// actually inside, whether or not they were notified first.
+func (r *repositoryImpl) MarkWaitlistAdmitted(ctx context.Context, branchID, memberID uuid.UUID) error {
+	query := `
+		UPDATE branch_waitlist
+		SET status = 'admitted'
+		WHERE branch_id = $1 AND member_id = $2 AND status IN ('waiting', 'notified')
+	`
+	_, err := r.db.Exec(ctx, query, branchID, memberID)
+	return err
+}
+
+func (r *repositoryImpl) ListWaitlist(ctx context.Context, branchID uuid.UUID) ([]*WaitlistEntry, error) {
+	query := `
+		SELECT id, branch_id, member_id, status, created_at, notified_at
+		FROM branch_waitlist
+		WHERE branch_id = $1 AND status IN ('waiting', 'notified')
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*WaitlistEntry
+	for rows.Next() {
+		var e WaitlistEntry
+		if err := rows.Scan(&e.ID, &e.BranchID, &e.MemberID, &e.Status, &e.CreatedAt, &e.NotifiedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// NotifyNextWaiting marks the oldest waiting entry as notified and returns it
+// together with the member's email, which is empty when they opted out of
+// waitlist emails. SKIP LOCKED keeps concurrent check-outs on different
+// replicas from notifying the same member twice.
+func (r *repositoryImpl) NotifyNextWaiting(ctx context.Context, branchID uuid.UUID) (*WaitlistEntry, error) {
+	query := `
+		WITH next AS (
+			SELECT id
+			FROM branch_waitlist
+			WHERE branch_id = $1 AND status = 'waiting'
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		), updated AS (
+			UPDATE branch_waitlist w
+			SET status = 'notified', notified_at = NOW()
+			FROM next
+			WHERE w.id = next.id
+			RETURNING w.id, w.branch_id, w.member_id, w.status, w.created_at, w.notified_at
+		)
+		SELECT u.id, u.branch_id, u.member_id, u.status, u.created_at, u.notified_at,
+			CASE WHEN COALESCE(p.waitlist_emails, TRUE) THEN COALESCE(usr.email, '') ELSE '' END
+		FROM updated u
+		JOIN members m ON m.id = u.member_id
+		LEFT JOIN users usr ON usr.id = m.user_id
+		LEFT JOIN member_notification_preferences p ON p.member_id = u.member_id
+	`
+	var e WaitlistEntry
+	err := r.db.QueryRow(ctx, query, branchID).Scan(&e.ID, &e.BranchID, &e.MemberID, &e.Status, &e.CreatedAt, &e.NotifiedAt, &e.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *repositoryImpl) Notify(ctx context.Context, payload string) error {
+	_, err := r.db.Exec(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, payload)
+	return err
+}
+
+// Listen blocks on a dedicated connection (outside the pool, which is small)
+// and calls handle for every notification until ctx is cancelled or the
+// connection drops. ready is called once LISTEN has been issued.
+func (r *repositoryImpl) Listen(ctx context.Context, ready func(), handle func(payload string)) error {
+	conn, err := pgx.Connect(ctx, config.Get().Database.ConnStr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	log.Printf("Repository: listening for occupancy events on channel %s", notifyChannel)
+	ready()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
@@ -0,0 +1,433 @@
+package occupancy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fitcore/pkg/email"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrBranchAtCapacity       = errors.New("branch is at full capacity")
+	ErrBranchNotFound         = errors.New("branch not found")
+	ErrCapacityWindowNotFound = errors.New("capacity window not found")
+	ErrInvalidCapacityWindow  = errors.New("capacity window times must be HH:MM; an end at or before the start runs into the next day")
+	ErrMemberNotFound         = errors.New("member not found")
+	ErrAlreadyWaitlisted      = errors.New("member is already on the waitlist")
+	ErrWaitlistEntryNotFound  = errors.New("waitlist entry not found")
+	ErrWaitlistNotNeeded      = errors.New("branch has space available")
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events are dropped for it.
+const subscriberBuffer = 16
+
+type Service interface {
+	GetSnapshot(ctx context.Context, branchID uuid.UUID) (*Event, error)
+	PublishCheckOut(ctx context.Context, branchID, memberID uuid.UUID)
+	Subscribe(branchID uuid.UUID) (<-chan *Event, func())
+	Listen(ctx context.Context)
+
+	// CheckIn admits a visit when the branch has room and publishes it.
+	// Every check-in path goes through it so that the capacity check and the
+	// insert cannot race.
+	CheckIn(ctx context.Context, checkIn *CheckIn) error
+
+	// Capacity
+	ListCapacityWindows(ctx context.Context, branchID uuid.UUID) ([]*CapacityWindow, error)
+	CreateCapacityWindow(ctx context.Context, branchID uuid.UUID, req *CreateCapacityWindowRequest) (*CapacityWindow, error)
+	DeleteCapacityWindow(ctx context.Context, branchID, id uuid.UUID) error
+
+	// Waitlist
+	JoinWaitlist(ctx context.Context, branchID, userID uuid.UUID) (*WaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, branchID, userID uuid.UUID) error
+	ListWaitlist(ctx context.Context, branchID uuid.UUID) ([]*WaitlistEntry, error)
+}
+
+type serviceImpl struct {
+	repo Repository
+	// emailSvc can be nil, in which case waitlist notifications are only
+	// pushed to the occupancy stream.
+	emailSvc *email.Service
+
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[chan *Event]struct{}
+
+	// listening is true while the LISTEN connection is up. When it is down
+	// events are delivered to local subscribers only.
+	listening atomic.Bool
+}
+
+func NewService(repo Repository, emailSvc *email.Service) Service {
+	return &serviceImpl{
+		repo:        repo,
+		emailSvc:    emailSvc,
+		subscribers: make(map[uuid.UUID]map[chan *Event]struct{}),
+	}
+}
+
+func (s *serviceImpl) GetSnapshot(ctx context.Context, branchID uuid.UUID) (*Event, error) {
+	count, err := s.repo.CountActive(ctx, branchID)
+	if err != nil {
+		log.Printf("Service: GetSnapshot failed - count active check-ins for branch %s: %v", branchID, err)
+		return nil, err
+	}
+
+	threshold, err := s.repo.GetAlertThreshold(ctx, branchID)
+	if err != nil {
+		log.Printf("Service: GetSnapshot failed - get alert threshold for branch %s: %v", branchID, err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBranchNotFound
+		}
+		return nil, err
+	}
+
+	capacity, err := s.repo.GetCapacity(ctx, branchID)
+	if err != nil {
+		log.Printf("Service: GetSnapshot failed - get capacity for branch %s: %v", branchID, err)
+		return nil, err
+	}
+
+	return &Event{
+		Type:       EventSnapshot,
+		BranchID:   branchID,
+		Count:      count,
+		Threshold:  threshold,
+		Capacity:   capacity,
+		OccurredAt: time.Now(),
+	}, nil
+}
+
+// PublishCheckIn broadcasts a check-in and, when the check-in pushes the
+// branch to its alert threshold, a near_capacity event. Failures are logged
+// only so that occupancy tracking never blocks the scanner.
+func (s *serviceImpl) PublishCheckIn(ctx context.Context, branchID, memberID uuid.UUID) {
+	snapshot, err := s.GetSnapshot(ctx, branchID)
+	if err != nil {
+		return
+	}
+
+	if err := s.repo.MarkWaitlistAdmitted(ctx, branchID, memberID); err != nil {
+		log.Printf("Service: PublishCheckIn failed - close waitlist entry for member %s: %v", memberID, err)
+	}
+
+	s.publish(ctx, &Event{
+		Type:       EventCheckIn,
+		BranchID:   branchID,
+		MemberID:   &memberID,
+		Count:      snapshot.Count,
+		Threshold:  snapshot.Threshold,
+		Capacity:   snapshot.Capacity,
+		OccurredAt: snapshot.OccurredAt,
+	})
+
+	if snapshot.Threshold != nil && snapshot.Count == *snapshot.Threshold {
+		s.publish(ctx, &Event{
+			Type:       EventNearCapacity,
+			BranchID:   branchID,
+			Count:      snapshot.Count,
+			Threshold:  snapshot.Threshold,
+			Capacity:   snapshot.Capacity,
+			OccurredAt: snapshot.OccurredAt,
+		})
+	}
+
+	if snapshot.Capacity != nil && snapshot.Count == *snapshot.Capacity {
+		s.publish(ctx, &Event{
+			Type:       EventAtCapacity,
+			BranchID:   branchID,
+			Count:      snapshot.Count,
+			Threshold:  snapshot.Threshold,
+			Capacity:   snapshot.Capacity,
+			OccurredAt: snapshot.OccurredAt,
+		})
+	}
+}
+
+func (s *serviceImpl) PublishCheckOut(ctx context.Context, branchID, memberID uuid.UUID) {
+	snapshot, err := s.GetSnapshot(ctx, branchID)
+	if err != nil {
+		return
+	}
+
+	s.publish(ctx, &Event{
+		Type:       EventCheckOut,
+		BranchID:   branchID,
+		MemberID:   &memberID,
+		Count:      snapshot.Count,
+		Threshold:  snapshot.Threshold,
+		Capacity:   snapshot.Capacity,
+		OccurredAt: snapshot.OccurredAt,
+	})
+
+	if snapshot.Capacity != nil && snapshot.Count < *snapshot.Capacity {
+		s.notifyNextWaiting(ctx, snapshot)
+	}
+}
+
+// notifyNextWaiting tells the member at the head of the waitlist that a
+// spot has opened, both on the occupancy stream and by email.
+func (s *serviceImpl) notifyNextWaiting(ctx context.Context, snapshot *Event) {
+	entry, err := s.repo.NotifyNextWaiting(ctx, snapshot.BranchID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Service: notifyNextWaiting failed for branch %s: %v", snapshot.BranchID, err)
+		}
+		return
+	}
+
+	s.publish(ctx, &Event{
+		Type:       EventSpaceAvailable,
+		BranchID:   snapshot.BranchID,
+		MemberID:   &entry.MemberID,
+		Count:      snapshot.Count,
+		Threshold:  snapshot.Threshold,
+		Capacity:   snapshot.Capacity,
+		OccurredAt: time.Now(),
+	})
+
+	if s.emailSvc == nil || entry.Email == "" {
+		return
+	}
+	go func(to string) {
+		// Create a new context for the background operation since the request context may be cancelled
+		bgCtx := context.Background()
+		subject := "A spot just opened up"
+		text := "Good news! Space is now available at your branch. Scan your QR code at the front desk to check in."
+		html := "<p>Good news! Space is now available at your branch.</p><p>Scan your QR code at the front desk to check in.</p>"
+		if err := s.emailSvc.SendEmail(bgCtx, to, subject, html, text); err != nil {
+			log.Printf("Service: Async waitlist email failed for %s: %v", to, err)
+		}
+	}(entry.Email)
+}
+
+func (s *serviceImpl) CheckIn(ctx context.Context, checkIn *CheckIn) error {
+	if err := s.repo.CheckIn(ctx, checkIn); err != nil {
+		if errors.Is(err, ErrBranchAtCapacity) {
+			log.Printf("Service: CheckIn - branch %s is full, refused member %s", checkIn.BranchID, checkIn.MemberID)
+		}
+		return err
+	}
+	s.PublishCheckIn(ctx, checkIn.BranchID, checkIn.MemberID)
+	return nil
+}
+
+// CheckCapacity returns ErrBranchAtCapacity when the live count has reached
+// the limit currently in effect. Branches without a limit always pass. The
+// answer is only a snapshot; admitting someone goes through CheckIn.
+func (s *serviceImpl) CheckCapacity(ctx context.Context, branchID uuid.UUID) error {
+	capacity, err := s.repo.GetCapacity(ctx, branchID)
+	if err != nil {
+		log.Printf("Service: CheckCapacity failed - get capacity for branch %s: %v", branchID, err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBranchNotFound
+		}
+		return err
+	}
+	if capacity == nil {
+		return nil
+	}
+
+	count, err := s.repo.CountActive(ctx, branchID)
+	if err != nil {
+		log.Printf("Service: CheckCapacity failed - count active check-ins for branch %s: %v", branchID, err)
+		return err
+	}
+
+	if count >= *capacity {
+		log.Printf("Service: CheckCapacity - branch %s is full (%d/%d)", branchID, count, *capacity)
+		return ErrBranchAtCapacity
+	}
+	return nil
+}
+
+func (s *serviceImpl) ListCapacityWindows(ctx context.Context, branchID uuid.UUID) ([]*CapacityWindow, error) {
+	return s.repo.ListCapacityWindows(ctx, branchID)
+}
+
+func (s *serviceImpl) CreateCapacityWindow(ctx context.Context, branchID uuid.UUID, req *CreateCapacityWindowRequest) (*CapacityWindow, error) {
+	// Like opening hours, a window may run past midnight (22:00-02:00); the
+	// spill-over counts towards the next day.
+	if _, err := time.Parse("15:04", req.StartTime); err != nil {
+		return nil, ErrInvalidCapacityWindow
+	}
+	if req.EndTime != "24:00" {
+		if _, err := time.Parse("15:04", req.EndTime); err != nil {
+			return nil, ErrInvalidCapacityWindow
+		}
+	}
+
+	window := &CapacityWindow{
+		BranchID:     branchID,
+		DayOfWeek:    req.DayOfWeek,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		MaxOccupancy: req.MaxOccupancy,
+	}
+	if err := s.repo.CreateCapacityWindow(ctx, window); err != nil {
+		log.Printf("Service: CreateCapacityWindow failed for branch %s: %v", branchID, err)
+		return nil, err
+	}
+	return window, nil
+}
+
+func (s *serviceImpl) DeleteCapacityWindow(ctx context.Context, branchID, id uuid.UUID) error {
+	if err := s.repo.DeleteCapacityWindow(ctx, branchID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCapacityWindowNotFound
+		}
+		log.Printf("Service: DeleteCapacityWindow failed for window %s: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// JoinWaitlist queues the member behind userID for the branch. Joining is
+// only allowed while the branch is actually full.
+func (s *serviceImpl) JoinWaitlist(ctx context.Context, branchID, userID uuid.UUID) (*WaitlistEntry, error) {
+	memberID, err := s.repo.GetMemberIDByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.CheckCapacity(ctx, branchID); err == nil {
+		return nil, ErrWaitlistNotNeeded
+	} else if !errors.Is(err, ErrBranchAtCapacity) {
+		return nil, err
+	}
+
+	entry := &WaitlistEntry{BranchID: branchID, MemberID: memberID}
+	if err := s.repo.JoinWaitlist(ctx, entry); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrAlreadyWaitlisted
+		}
+		log.Printf("Service: JoinWaitlist failed for member %s at branch %s: %v", memberID, branchID, err)
+		return nil, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+	return entry, nil
+}
+
+func (s *serviceImpl) LeaveWaitlist(ctx context.Context, branchID, userID uuid.UUID) error {
+	memberID, err := s.repo.GetMemberIDByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+
+	if err := s.repo.CancelWaitlist(ctx, branchID, memberID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrWaitlistEntryNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *serviceImpl) ListWaitlist(ctx context.Context, branchID uuid.UUID) ([]*WaitlistEntry, error) {
+	return s.repo.ListWaitlist(ctx, branchID)
+}
+
+// publish sends the event through Postgres so every replica (including this
+// one, via Listen) receives it. If the listener is down or NOTIFY fails the
+// event is delivered to local subscribers directly.
+func (s *serviceImpl) publish(ctx context.Context, event *Event) {
+	if s.listening.Load() {
+		payload, err := json.Marshal(event)
+		if err == nil {
+			err = s.repo.Notify(ctx, string(payload))
+		}
+		if err == nil {
+			return
+		}
+		log.Printf("Service: publish occupancy event via NOTIFY failed, delivering locally: %v", err)
+	}
+	s.deliver(event)
+}
+
+func (s *serviceImpl) deliver(event *Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ch := range s.subscribers[event.BranchID] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up; drop rather than block publishers.
+		}
+	}
+}
+
+func (s *serviceImpl) Subscribe(branchID uuid.UUID) (<-chan *Event, func()) {
+	ch := make(chan *Event, subscriberBuffer)
+
+	s.mu.Lock()
+	if s.subscribers[branchID] == nil {
+		s.subscribers[branchID] = make(map[chan *Event]struct{})
+	}
+	s.subscribers[branchID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers[branchID], ch)
+			if len(s.subscribers[branchID]) == 0 {
+				delete(s.subscribers, branchID)
+			}
+			s.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Listen keeps a LISTEN connection open for the lifetime of ctx, reconnecting
+// with a backoff whenever it drops.
+func (s *serviceImpl) Listen(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := s.repo.Listen(ctx, func() {
+			s.listening.Store(true)
+			backoff = time.Second
+		}, func(payload string) {
+			var event Event
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				log.Printf("Service: Listen failed - invalid occupancy payload: %v", err)
+				return
+			}
+			s.deliver(&event)
+		})
+		s.listening.Store(false)
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Service: occupancy listener stopped, reconnecting in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
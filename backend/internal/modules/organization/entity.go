@@ -8,14 +8,16 @@ import (
 )
 
 type Organization struct {
-	ID        uuid.UUID       `db:"id"`
-	Name      string          `db:"name"`
-	Slug      string          `db:"slug"`
-	LogoURL   *string         `db:"logo_url"`
-	Config    json.RawMessage `db:"config"`
-	Modules   []ModuleInfo    `db:"-"` // Not a DB column, populated separately
-	CreatedAt time.Time       `db:"created_at"`
-	UpdatedAt time.Time       `db:"updated_at"`
+	ID          uuid.UUID       `db:"id"`
+	Name        string          `db:"name"`
+	Slug        string          `db:"slug"`
+	LogoURL     *string         `db:"logo_url"`
+	LogoFileID  *uuid.UUID      `db:"logo_file_id"` // Uploaded logo, shown instead of LogoURL
+	LogoFileURL *string         `db:"-"`            // Signed link to LogoFileID, populated separately
+	Config      json.RawMessage `db:"config"`
+	Modules     []ModuleInfo    `db:"-"` // Not a DB column, populated separately
+	CreatedAt   time.Time       `db:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at"`
 }
 
 type OrganizationModule struct {
@@ -31,10 +33,17 @@ func (o *Organization) ToResponse() *OrganizationResponse {
 		ID:        o.ID,
 		Name:      o.Name,
 		Slug:      o.Slug,
-		LogoURL:   o.LogoURL,
+		LogoURL:   o.Logo(),
 		Config:    o.Config,
 		Modules:   o.Modules,
 		UpdatedAt: &o.UpdatedAt,
 	}
 }
 
+// Logo returns the link to show for the organization's logo.
+func (o *Organization) Logo() *string {
+	if o.LogoFileURL != nil {
+		return o.LogoFileURL
+	}
+	return o.LogoURL
+}
@@ -2,15 +2,20 @@ package organization
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
 	"fitcore/internal/middleware"
 	"fitcore/internal/modules/branch"
+	"fitcore/internal/modules/files"
 	"fitcore/internal/response"
 
 	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type Handler struct {
@@ -34,6 +39,8 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Post("/", h.CreateOrganization)
 			r.Put("/{id}", h.UpdateOrganization)
 			r.Delete("/{id}", h.DeleteOrganization)
+			r.Put("/{id}/logo", h.UploadLogo)
+			r.Delete("/{id}/logo", h.RemoveLogo)
 		})
 	})
 }
@@ -156,3 +163,61 @@ func (h *Handler) ListBranchesByOrganization(w http.ResponseWriter, r *http.Requ
 
 	response.Success(w, "Branches retrieved successfully", branchResponses)
 }
+
+// UploadLogo takes a multipart form with the image in file.
+func (h *Handler) UploadLogo(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid organization ID", nil)
+		return
+	}
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return
+	}
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return
+	}
+	filename, data, ok := files.FormFile(w, r, files.PurposeLogo)
+	if !ok {
+		return
+	}
+
+	org, err := h.service.SetLogo(r.Context(), id, filename, data, userID)
+	if err != nil {
+		writeLogoError(w, err, "Failed to upload logo")
+		return
+	}
+	response.Success(w, "Logo uploaded successfully", org.ToResponse())
+}
+
+func (h *Handler) RemoveLogo(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid organization ID", nil)
+		return
+	}
+
+	org, err := h.service.RemoveLogo(r.Context(), id)
+	if err != nil {
+		writeLogoError(w, err, "Failed to remove logo")
+		return
+	}
+	response.Success(w, "Logo removed successfully", org.ToResponse())
+}
+
+func writeLogoError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		response.NotFound(w, "Organization not found")
+	case files.IsUploadError(err):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
@@ -1,6 +1,8 @@
 package organization
 
 import (
+	"fitcore/internal/modules/files"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -11,9 +13,9 @@ type Module struct {
 	Repository Repository
 }
 
-func NewModule(db *pgxpool.Pool) *Module {
+func NewModule(db *pgxpool.Pool, filesSvc files.Service) *Module {
 	repo := NewRepository(db)
-	service := NewService(repo)
+	service := NewService(repo, filesSvc)
 	handler := NewHandler(service)
 
 	return &Module{
@@ -16,6 +16,7 @@ type Repository interface {
 	GetModulesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]ModuleInfo, error)
 	Update(ctx context.Context, org *Organization) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	SetLogoFile(ctx context.Context, id uuid.UUID, fileID *uuid.UUID) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Organization, error)
 	GetBySlug(ctx context.Context, slug string) (*Organization, error)
 	List(ctx context.Context, limit, offset int) ([]*Organization, error)
@@ -128,9 +129,21 @@ func (r *repositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+func (r *repositoryImpl) SetLogoFile(ctx context.Context, id uuid.UUID, fileID *uuid.UUID) error {
+	query := `UPDATE organization SET logo_file_id = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, fileID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Organization, error) {
 	query := `
-		SELECT id, name, slug, logo_url, config, created_at, updated_at
+		SELECT id, name, slug, logo_url, logo_file_id, config, created_at, updated_at
 		FROM organization
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -140,6 +153,7 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Organizati
 		&org.Name,
 		&org.Slug,
 		&org.LogoURL,
+		&org.LogoFileID,
 		&org.Config,
 		&org.CreatedAt,
 		&org.UpdatedAt,
@@ -152,7 +166,7 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Organizati
 
 func (r *repositoryImpl) GetBySlug(ctx context.Context, slug string) (*Organization, error) {
 	query := `
-		SELECT id, name, slug, logo_url, config, created_at, updated_at
+		SELECT id, name, slug, logo_url, logo_file_id, config, created_at, updated_at
 		FROM organization
 		WHERE slug = $1 AND deleted_at IS NULL
 	`
@@ -162,6 +176,7 @@ func (r *repositoryImpl) GetBySlug(ctx context.Context, slug string) (*Organizat
 		&org.Name,
 		&org.Slug,
 		&org.LogoURL,
+		&org.LogoFileID,
 		&org.Config,
 		&org.CreatedAt,
 		&org.UpdatedAt,
@@ -174,7 +189,7 @@ func (r *repositoryImpl) GetBySlug(ctx context.Context, slug string) (*Organizat
 
 func (r *repositoryImpl) List(ctx context.Context, limit, offset int) ([]*Organization, error) {
 	query := `
-		SELECT id, name, slug, logo_url, config, created_at, updated_at
+		SELECT id, name, slug, logo_url, logo_file_id, config, created_at, updated_at
 		FROM organization
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -194,6 +209,7 @@ func (r *repositoryImpl) List(ctx context.Context, limit, offset int) ([]*Organi
 			&org.Name,
 			&org.Slug,
 			&org.LogoURL,
+			&org.LogoFileID,
 			&org.Config,
 			&org.CreatedAt,
 			&org.UpdatedAt,
@@ -2,8 +2,11 @@ package organization
 
 import (
 	"context"
+	"errors"
+	"log"
 
 	"fitcore/internal/modules/branch"
+	"fitcore/internal/modules/files"
 
 	"github.com/google/uuid"
 )
@@ -16,14 +19,27 @@ type Service interface {
 	GetOrganizationBySlug(ctx context.Context, slug string) (*Organization, error)
 	ListOrganizations(ctx context.Context, page, limit int) ([]*Organization, error)
 	ListBranchesByOrganization(ctx context.Context, organizationID uuid.UUID, page, limit int) ([]*branch.Branch, error)
+	// SetLogo uploads an image as the organization's logo, replacing any
+	// earlier upload.
+	SetLogo(ctx context.Context, id uuid.UUID, filename string, data []byte, uploadedBy uuid.UUID) (*Organization, error)
+	RemoveLogo(ctx context.Context, id uuid.UUID) (*Organization, error)
 }
 
 type serviceImpl struct {
-	repo Repository
+	repo     Repository
+	filesSvc files.Service
 }
 
-func NewService(repo Repository) Service {
-	return &serviceImpl{repo: repo}
+func NewService(repo Repository, filesSvc files.Service) Service {
+	return &serviceImpl{repo: repo, filesSvc: filesSvc}
+}
+
+// signLogo links an uploaded logo for the response.
+func (s *serviceImpl) signLogo(org *Organization) {
+	if org.LogoFileID != nil {
+		logoURL := s.filesSvc.URL(*org.LogoFileID, files.VariantOriginal)
+		org.LogoFileURL = &logoURL
+	}
 }
 
 func (s *serviceImpl) CreateOrganization(ctx context.Context, req *CreateOrganizationRequest) (*Organization, error) {
@@ -54,6 +70,7 @@ func (s *serviceImpl) CreateOrganization(ctx context.Context, req *CreateOrganiz
 		return nil, err
 	}
 	org.Modules = modules
+	s.signLogo(org)
 
 	return org, nil
 }
@@ -94,6 +111,7 @@ func (s *serviceImpl) UpdateOrganization(ctx context.Context, id uuid.UUID, req
 		return nil, err
 	}
 	org.Modules = modules
+	s.signLogo(org)
 
 	return org, nil
 }
@@ -114,12 +132,18 @@ func (s *serviceImpl) GetOrganization(ctx context.Context, id uuid.UUID) (*Organ
 		return nil, err
 	}
 	org.Modules = modules
+	s.signLogo(org)
 
 	return org, nil
 }
 
 func (s *serviceImpl) GetOrganizationBySlug(ctx context.Context, slug string) (*Organization, error) {
-	return s.repo.GetBySlug(ctx, slug)
+	org, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	s.signLogo(org)
+	return org, nil
 }
 
 func (s *serviceImpl) ListOrganizations(ctx context.Context, page, limit int) ([]*Organization, error) {
@@ -130,7 +154,14 @@ func (s *serviceImpl) ListOrganizations(ctx context.Context, page, limit int) ([
 		limit = 10
 	}
 	offset := (page - 1) * limit
-	return s.repo.List(ctx, limit, offset)
+	orgs, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range orgs {
+		s.signLogo(org)
+	}
+	return orgs, nil
 }
 
 func (s *serviceImpl) ListBranchesByOrganization(ctx context.Context, organizationID uuid.UUID, page, limit int) ([]*branch.Branch, error) {
@@ -143,3 +174,58 @@ func (s *serviceImpl) ListBranchesByOrganization(ctx context.Context, organizati
 	offset := (page - 1) * limit
 	return s.repo.ListBranchesByOrganizationID(ctx, organizationID, limit, offset)
 }
+
+func (s *serviceImpl) SetLogo(ctx context.Context, id uuid.UUID, filename string, data []byte, uploadedBy uuid.UUID) (*Organization, error) {
+	org, err := s.GetOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.filesSvc.Upload(ctx, &files.UploadRequest{
+		OrganizationID: &org.ID,
+		Purpose:        files.PurposeLogo,
+		Filename:       filename,
+		Data:           data,
+		UploadedBy:     &uploadedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetLogoFile(ctx, org.ID, &file.ID); err != nil {
+		if deleteErr := s.filesSvc.Delete(ctx, file.ID); deleteErr != nil {
+			log.Printf("Service: failed to delete unused logo %s: %v", file.ID, deleteErr)
+		}
+		return nil, err
+	}
+
+	s.replaceFile(ctx, org.LogoFileID)
+	org.LogoFileID = &file.ID
+	s.signLogo(org)
+	return org, nil
+}
+
+func (s *serviceImpl) RemoveLogo(ctx context.Context, id uuid.UUID) (*Organization, error) {
+	org, err := s.GetOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetLogoFile(ctx, org.ID, nil); err != nil {
+		return nil, err
+	}
+
+	s.replaceFile(ctx, org.LogoFileID)
+	org.LogoFileID = nil
+	org.LogoFileURL = nil
+	return org, nil
+}
+
+// replaceFile deletes a logo that is no longer used. A failure only leaves
+// the old file behind, so it is logged.
+func (s *serviceImpl) replaceFile(ctx context.Context, fileID *uuid.UUID) {
+	if fileID == nil {
+		return
+	}
+	if err := s.filesSvc.Delete(ctx, *fileID); err != nil && !errors.Is(err, files.ErrFileNotFound) {
+		log.Printf("Service: failed to delete replaced logo %s: %v", *fileID, err)
+	}
+}
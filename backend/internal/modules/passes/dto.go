@@ -0,0 +1,112 @@
+package passes
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SetDayPassPriceRequest struct {
+	Price    float64 `json:"price" validate:"gte=0"`
+	IsActive *bool   `json:"isActive,omitempty"`
+}
+
+// SellDayPassRequest sells a day pass at the front desk. With paymentMethod
+// the pass is paid on the spot; without it the visitor gets a checkout link
+// at email and the pass works once that is paid.
+type SellDayPassRequest struct {
+	BranchID uuid.UUID `json:"branchId" validate:"required"`
+	// ValidOn defaults to today at the branch.
+	ValidOn *string `json:"validOn,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	// MemberID sells to an existing member record, e.g. a lead, instead of
+	// creating one from the name below.
+	MemberID      *uuid.UUID `json:"memberId,omitempty"`
+	FirstName     string     `json:"firstName" validate:"required_without=MemberID,max=255"`
+	LastName      string     `json:"lastName" validate:"required_without=MemberID,max=255"`
+	Email         *string    `json:"email,omitempty" validate:"omitempty,email"`
+	Phone         *string    `json:"phone,omitempty" validate:"omitempty,max=50"`
+	PaymentMethod *string    `json:"paymentMethod,omitempty" validate:"omitempty,oneof=cash credit_card bank_transfer e_wallet qris"`
+}
+
+// IssueGuestPassRequest is a member inviting a guest.
+type IssueGuestPassRequest struct {
+	// BranchID defaults to the member's home branch.
+	BranchID  *uuid.UUID `json:"branchId,omitempty"`
+	ValidOn   string     `json:"validOn" validate:"required,datetime=2006-01-02"`
+	FirstName string     `json:"firstName" validate:"required,max=255"`
+	LastName  string     `json:"lastName" validate:"required,max=255"`
+	Email     *string    `json:"email,omitempty" validate:"omitempty,email"`
+	Phone     *string    `json:"phone,omitempty" validate:"omitempty,max=50"`
+}
+
+// RedeemPassRequest is a pass QR scanned at the front desk of BranchID.
+type RedeemPassRequest struct {
+	Code     string    `json:"code" validate:"required,max=64"`
+	BranchID uuid.UUID `json:"branchId" validate:"required"`
+}
+
+type PassFilter struct {
+	OrganizationIDs []uuid.UUID
+	BranchIDs       []uuid.UUID
+	HostMemberID    *uuid.UUID
+	Kind            *string
+	From            *time.Time
+	To              *time.Time
+	Page            int
+	Limit           int
+}
+
+type DayPassPriceResponse struct {
+	BranchID       uuid.UUID `json:"branchId"`
+	BranchName     string    `json:"branchName,omitempty"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Price          float64   `json:"price"`
+	IsActive       bool      `json:"isActive"`
+	// OnlineCheckout is true when day passes can be paid by checkout link.
+	OnlineCheckout bool      `json:"onlineCheckout"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type PassResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	BranchID       uuid.UUID  `json:"branchId"`
+	BranchName     string     `json:"branchName,omitempty"`
+	Kind           string     `json:"kind"`
+	Code           string     `json:"code"`
+	ValidOn        string     `json:"validOn"`
+	GuestMemberID  uuid.UUID  `json:"guestMemberId"`
+	GuestName      string     `json:"guestName"`
+	HostMemberID   *uuid.UUID `json:"hostMemberId,omitempty"`
+	HostName       *string    `json:"hostName,omitempty"`
+	InvoiceID      *uuid.UUID `json:"invoiceId,omitempty"`
+	Amount         *float64   `json:"amount,omitempty"`
+	Status         string     `json:"status"`
+	RedeemedAt     *time.Time `json:"redeemedAt,omitempty"`
+	CancelledAt    *time.Time `json:"cancelledAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type SellDayPassResponse struct {
+	*PassResponse
+	CheckoutURL string `json:"checkoutUrl,omitempty"`
+}
+
+// GuestPassQuotaResponse is a member's guest pass allowance for a month.
+type GuestPassQuotaResponse struct {
+	Month     string `json:"month"`
+	Allowance int    `json:"allowance"`
+	Used      int    `json:"used"`
+	Remaining int    `json:"remaining"`
+}
+
+type GuestPassesResponse struct {
+	Quota  *GuestPassQuotaResponse `json:"quota"`
+	Passes []*PassResponse         `json:"passes"`
+}
+
+type RedeemPassResponse struct {
+	*PassResponse
+	CheckInID   uuid.UUID `json:"checkInId"`
+	CheckInTime time.Time `json:"checkInTime"`
+}
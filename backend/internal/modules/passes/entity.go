@@ -0,0 +1,131 @@
+package passes
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindDay   Kind = "day"
+	KindGuest Kind = "guest"
+)
+
+const (
+	StatusActive          = "active"
+	StatusAwaitingPayment = "awaiting_payment"
+	StatusRedeemed        = "redeemed"
+	StatusCancelled       = "cancelled"
+)
+
+// DayPassPrice is what a branch charges walk-ins for a day pass.
+type DayPassPrice struct {
+	BranchID       uuid.UUID `db:"branch_id"`
+	OrganizationID uuid.UUID `db:"organization_id"`
+	Price          float64   `db:"price"`
+	ProductID      *string   `db:"product_id"`
+	IsActive       bool      `db:"is_active"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+
+	// Joined from branches.
+	BranchName string `db:"branch_name"`
+}
+
+// Pass admits one visitor once at BranchID on ValidOn. Code is the content
+// of the QR shown at the front desk.
+type Pass struct {
+	ID             uuid.UUID  `db:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	BranchID       uuid.UUID  `db:"branch_id"`
+	Kind           Kind       `db:"kind"`
+	Code           string     `db:"code"`
+	ValidOn        time.Time  `db:"valid_on"`
+	GuestMemberID  uuid.UUID  `db:"guest_member_id"`
+	HostMemberID   *uuid.UUID `db:"host_member_id"`
+	InvoiceID      *uuid.UUID `db:"invoice_id"`
+	RedeemedAt     *time.Time `db:"redeemed_at"`
+	CancelledAt    *time.Time `db:"cancelled_at"`
+	IssuedBy       *uuid.UUID `db:"issued_by"`
+	CreatedAt      time.Time  `db:"created_at"`
+
+	// Joined from members, branches and invoices.
+	GuestName     string   `db:"guest_name"`
+	HostName      *string  `db:"host_name"`
+	BranchName    string   `db:"branch_name"`
+	InvoiceStatus *string  `db:"invoice_status"`
+	Amount        *float64 `db:"amount"`
+}
+
+// Guest is the visitor a new pass creates a member record for.
+type Guest struct {
+	FirstName string
+	LastName  string
+	Phone     *string
+	Notes     *string
+}
+
+// Sale is the invoice of a day pass. Front desk sales are paid on the spot;
+// online sales wait for the checkout identified by ExternalID.
+type Sale struct {
+	Paid          bool
+	Amount        float64
+	TaxAmount     float64
+	PaymentMethod *string
+	ExternalID    *uuid.UUID
+	DueDate       *time.Time
+}
+
+// Paid reports whether the pass no longer waits for a payment.
+func (p *Pass) Paid() bool {
+	return p.Kind != KindDay || (p.InvoiceStatus != nil && *p.InvoiceStatus == "paid")
+}
+
+func (p *Pass) Status() string {
+	switch {
+	case p.CancelledAt != nil:
+		return StatusCancelled
+	case p.RedeemedAt != nil:
+		return StatusRedeemed
+	case !p.Paid():
+		return StatusAwaitingPayment
+	default:
+		return StatusActive
+	}
+}
+
+func (d *DayPassPrice) ToResponse() *DayPassPriceResponse {
+	return &DayPassPriceResponse{
+		BranchID:       d.BranchID,
+		BranchName:     d.BranchName,
+		OrganizationID: d.OrganizationID,
+		Price:          d.Price,
+		IsActive:       d.IsActive,
+		OnlineCheckout: d.ProductID != nil,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func (p *Pass) ToResponse() *PassResponse {
+	return &PassResponse{
+		ID:             p.ID,
+		OrganizationID: p.OrganizationID,
+		BranchID:       p.BranchID,
+		BranchName:     p.BranchName,
+		Kind:           string(p.Kind),
+		Code:           p.Code,
+		ValidOn:        p.ValidOn.Format(dateLayout),
+		GuestMemberID:  p.GuestMemberID,
+		GuestName:      p.GuestName,
+		HostMemberID:   p.HostMemberID,
+		HostName:       p.HostName,
+		InvoiceID:      p.InvoiceID,
+		Amount:         p.Amount,
+		Status:         p.Status(),
+		RedeemedAt:     p.RedeemedAt,
+		CancelledAt:    p.CancelledAt,
+		CreatedAt:      p.CreatedAt,
+	}
+}
@@ -0,0 +1,357 @@
+package passes
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/occupancy"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/passes", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member"))
+			r.Get("/me/guest", h.ListGuestPasses)
+			r.Post("/me/guest", h.IssueGuestPass)
+			r.Post("/me/guest/{id}/cancel", h.CancelGuestPass)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin", "staff"))
+			r.Get("/day-prices", h.ListDayPassPrices)
+			r.Post("/day", h.SellDayPass)
+			r.Post("/redeem", h.RedeemPass)
+			r.Get("/", h.ListPasses)
+			r.Get("/{id}", h.GetPass)
+			r.Post("/{id}/cancel", h.CancelPass)
+			r.Post("/{id}/check-out", h.CheckOutPass)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Put("/day-prices/{branchId}", h.SetDayPassPrice)
+		})
+	})
+}
+
+func (h *Handler) ListDayPassPrices(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	organizationID, ok := optionalUUID(w, r, "organizationId")
+	if !ok {
+		return
+	}
+
+	prices, err := h.service.ListDayPassPrices(r.Context(), userID, userRole, organizationID)
+	if err != nil {
+		writeError(w, err, "Failed to list day pass prices")
+		return
+	}
+	priceResponses := make([]*DayPassPriceResponse, len(prices))
+	for i, p := range prices {
+		priceResponses[i] = p.ToResponse()
+	}
+	response.Success(w, "Day pass prices retrieved successfully", priceResponses)
+}
+
+func (h *Handler) SetDayPassPrice(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	branchID, ok := pathUUID(w, r, "branchId", "Invalid branch ID")
+	if !ok {
+		return
+	}
+	var req SetDayPassPriceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	price, err := h.service.SetDayPassPrice(r.Context(), userID, userRole, branchID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to set day pass price")
+		return
+	}
+	response.Success(w, "Day pass price saved successfully", price.ToResponse())
+}
+
+// SellDayPass answers with the pass, whose code is the QR content, and the
+// checkout URL when it is paid online.
+func (h *Handler) SellDayPass(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req SellDayPassRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.SellDayPass(r.Context(), userID, userRole, &req)
+	if err != nil {
+		writeError(w, err, "Failed to sell day pass")
+		return
+	}
+	response.Success(w, "Day pass sold successfully", res)
+}
+
+// ListPasses filters by kind (day or guest) and a validOn range with from
+// and to (YYYY-MM-DD).
+func (h *Handler) ListPasses(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &PassFilter{}
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if v := query.Get("kind"); v != "" {
+		if v != string(KindDay) && v != string(KindGuest) {
+			response.BadRequest(w, "Invalid kind parameter, use day or guest", nil)
+			return
+		}
+		filter.Kind = &v
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		date, err := time.Parse(dateLayout, v)
+		if err != nil {
+			response.BadRequest(w, "Invalid "+name+" parameter", nil)
+			return
+		}
+		*target = &date
+	}
+
+	passes, err := h.service.ListPasses(r.Context(), userID, userRole, filter)
+	if err != nil {
+		writeError(w, err, "Failed to list passes")
+		return
+	}
+	passResponses := make([]*PassResponse, len(passes))
+	for i, p := range passes {
+		passResponses[i] = p.ToResponse()
+	}
+	response.Success(w, "Passes retrieved successfully", passResponses)
+}
+
+func (h *Handler) GetPass(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid pass ID")
+	if !ok {
+		return
+	}
+
+	pass, err := h.service.GetPass(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to get pass")
+		return
+	}
+	response.Success(w, "Pass retrieved successfully", pass.ToResponse())
+}
+
+func (h *Handler) CancelPass(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid pass ID")
+	if !ok {
+		return
+	}
+
+	pass, err := h.service.CancelPass(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to cancel pass")
+		return
+	}
+	response.Success(w, "Pass cancelled successfully", pass.ToResponse())
+}
+
+// RedeemPass checks in the visitor of a scanned pass QR.
+func (h *Handler) RedeemPass(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req RedeemPassRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.RedeemPass(r.Context(), userID, userRole, &req)
+	if err != nil {
+		writeError(w, err, "Failed to redeem pass")
+		return
+	}
+	response.Success(w, "Pass redeemed, visitor checked in", res)
+}
+
+func (h *Handler) CheckOutPass(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid pass ID")
+	if !ok {
+		return
+	}
+
+	pass, err := h.service.CheckOutPass(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to check out")
+		return
+	}
+	response.Success(w, "Visitor checked out", pass.ToResponse())
+}
+
+// ListGuestPasses takes month (YYYY-MM, default the current month).
+func (h *Handler) ListGuestPasses(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.ListGuestPasses(r.Context(), userID, r.URL.Query().Get("month"))
+	if err != nil {
+		writeError(w, err, "Failed to list guest passes")
+		return
+	}
+	response.Success(w, "Guest passes retrieved successfully", res)
+}
+
+func (h *Handler) IssueGuestPass(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req IssueGuestPassRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	pass, err := h.service.IssueGuestPass(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to issue guest pass")
+		return
+	}
+	response.Success(w, "Guest pass issued successfully", pass.ToResponse())
+}
+
+func (h *Handler) CancelGuestPass(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid pass ID")
+	if !ok {
+		return
+	}
+
+	pass, err := h.service.CancelGuestPass(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, err, "Failed to cancel guest pass")
+		return
+	}
+	response.Success(w, "Guest pass cancelled successfully", pass.ToResponse())
+}
+
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrPassNotFound), errors.Is(err, ErrMemberNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, ErrOrganizationAccess), errors.Is(err, ErrBranchAccess), errors.Is(err, ErrNoActiveSubscription),
+		errors.Is(err, ErrHostFrozen), errors.Is(err, hours.ErrBranchClosed), errors.Is(err, hours.ErrOutsideAccessWindow),
+		errors.Is(err, documents.ErrSignatureRequired):
+		response.Forbidden(w, err.Error())
+	case errors.Is(err, ErrGuestQuotaExceeded), errors.Is(err, ErrPassUsed), errors.Is(err, ErrPassCancelled),
+		errors.Is(err, ErrPaymentPending), errors.Is(err, ErrNotCheckedIn), errors.Is(err, occupancy.ErrBranchAtCapacity):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, ErrBranchUnavailable), errors.Is(err, ErrDayPassUnavailable), errors.Is(err, ErrCheckoutUnavailable),
+		errors.Is(err, ErrEmailRequired), errors.Is(err, ErrInvalidValidOn), errors.Is(err, ErrInvalidMonth),
+		errors.Is(err, ErrWrongBranch), errors.Is(err, ErrWrongDate):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return false
+	}
+	return response.ValidateStructAndWrite(w, req)
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		response.BadRequest(w, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func optionalUUID(w http.ResponseWriter, r *http.Request, param string) (*uuid.UUID, bool) {
+	v := r.URL.Query().Get(param)
+	if v == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		response.BadRequest(w, "Invalid "+param+" parameter", nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+func callerFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+	return userID, userRole, true
+}
@@ -0,0 +1,39 @@
+package passes
+
+import (
+	"fitcore/internal/modules/branch"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/occupancy"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/email"
+	"fitcore/pkg/polar"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, userSvc user.Service, branchSvc branch.Service, memberSvc member.Service, subSvc subscription.Service, plansSvc plans.Service, hoursSvc hours.Service, occupancySvc occupancy.Service, documentsSvc documents.Service, polarSvc *polar.Service, emailSvc *email.Service) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, userSvc, branchSvc, memberSvc, subSvc, plansSvc, hoursSvc, occupancySvc, documentsSvc, polarSvc, emailSvc)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
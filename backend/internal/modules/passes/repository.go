@@ -0,0 +1,431 @@
+package passes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetDayPassPrice(ctx context.Context, branchID uuid.UUID) (*DayPassPrice, error)
+	ListDayPassPrices(ctx context.Context, organizationIDs []uuid.UUID) ([]*DayPassPrice, error)
+	UpsertDayPassPrice(ctx context.Context, price *DayPassPrice) error
+
+	// CreatePass creates the pass, a member record for guest when the pass
+	// has no GuestMemberID, and the invoice of sale when it is a day pass.
+	CreatePass(ctx context.Context, pass *Pass, guest *Guest, sale *Sale) error
+	// CreateGuestPass creates a guest pass unless its host already holds
+	// allowance passes valid from from to to, and returns
+	// ErrGuestQuotaExceeded then. Passes for one host are issued in turn,
+	// so two requests cannot both take the last one.
+	CreateGuestPass(ctx context.Context, pass *Pass, guest *Guest, allowance int, from, to time.Time) error
+	GetPass(ctx context.Context, id uuid.UUID) (*Pass, error)
+	GetPassByCode(ctx context.Context, code string) (*Pass, error)
+	ListPasses(ctx context.Context, filter *PassFilter) ([]*Pass, error)
+	// CountGuestPasses counts the guest passes a host has not cancelled that
+	// are valid from from to to inclusive.
+	CountGuestPasses(ctx context.Context, hostMemberID uuid.UUID, from, to time.Time) (int, error)
+	// CancelPass cancels an unredeemed pass and voids its unpaid invoice. It
+	// returns pgx.ErrNoRows when the pass was redeemed or cancelled already.
+	CancelPass(ctx context.Context, id uuid.UUID) error
+	// RedeemPass marks the pass used inside the check-in transaction. It
+	// returns pgx.ErrNoRows when the pass was redeemed or cancelled
+	// concurrently.
+	RedeemPass(ctx context.Context, tx pgx.Tx, pass *Pass) error
+	// CheckOutPass closes the open check-in of a pass.
+	CheckOutPass(ctx context.Context, passID uuid.UUID) (time.Time, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+const dayPassPriceColumns = `
+	d.branch_id, d.organization_id, d.price, d.product_id, d.is_active, d.created_at, d.updated_at, b.name
+`
+
+func scanDayPassPrice(row pgx.Row) (*DayPassPrice, error) {
+	var d DayPassPrice
+	err := row.Scan(
+		&d.BranchID,
+		&d.OrganizationID,
+		&d.Price,
+		&d.ProductID,
+		&d.IsActive,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+		&d.BranchName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *repositoryImpl) GetDayPassPrice(ctx context.Context, branchID uuid.UUID) (*DayPassPrice, error) {
+	query := `
+		SELECT ` + dayPassPriceColumns + `
+		FROM day_pass_prices d
+		JOIN branches b ON b.id = d.branch_id
+		WHERE d.branch_id = $1
+	`
+	return scanDayPassPrice(r.db.QueryRow(ctx, query, branchID))
+}
+
+func (r *repositoryImpl) ListDayPassPrices(ctx context.Context, organizationIDs []uuid.UUID) ([]*DayPassPrice, error) {
+	query := `
+		SELECT ` + dayPassPriceColumns + `
+		FROM day_pass_prices d
+		JOIN branches b ON b.id = d.branch_id
+		WHERE ($1::uuid[] IS NULL OR d.organization_id = ANY($1))
+		ORDER BY b.name
+	`
+	var orgIDs any
+	if organizationIDs != nil {
+		orgIDs = organizationIDs
+	}
+	rows, err := r.db.Query(ctx, query, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []*DayPassPrice{}
+	for rows.Next() {
+		d, err := scanDayPassPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, d)
+	}
+	return prices, rows.Err()
+}
+
+func (r *repositoryImpl) UpsertDayPassPrice(ctx context.Context, d *DayPassPrice) error {
+	query := `
+		INSERT INTO day_pass_prices (branch_id, organization_id, price, product_id, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (branch_id) DO UPDATE
+		SET price = EXCLUDED.price,
+			product_id = EXCLUDED.product_id,
+			is_active = EXCLUDED.is_active,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+	return r.db.QueryRow(ctx, query,
+		d.BranchID,
+		d.OrganizationID,
+		d.Price,
+		d.ProductID,
+		d.IsActive,
+	).Scan(&d.CreatedAt, &d.UpdatedAt)
+}
+
+const passColumns = `
+	p.id, p.organization_id, p.branch_id, p.kind, p.code, p.valid_on, p.guest_member_id, p.host_member_id,
+	p.invoice_id, p.redeemed_at, p.cancelled_at, p.issued_by, p.created_at,
+	g.first_name || ' ' || g.last_name, h.first_name || ' ' || h.last_name, b.name, i.status, i.total_amount
+`
+
+const passFrom = `
+	FROM passes p
+	JOIN members g ON g.id = p.guest_member_id
+	LEFT JOIN members h ON h.id = p.host_member_id
+	JOIN branches b ON b.id = p.branch_id
+	LEFT JOIN invoices i ON i.id = p.invoice_id
+`
+
+func scanPass(row pgx.Row) (*Pass, error) {
+	var p Pass
+	err := row.Scan(
+		&p.ID,
+		&p.OrganizationID,
+		&p.BranchID,
+		&p.Kind,
+		&p.Code,
+		&p.ValidOn,
+		&p.GuestMemberID,
+		&p.HostMemberID,
+		&p.InvoiceID,
+		&p.RedeemedAt,
+		&p.CancelledAt,
+		&p.IssuedBy,
+		&p.CreatedAt,
+		&p.GuestName,
+		&p.HostName,
+		&p.BranchName,
+		&p.InvoiceStatus,
+		&p.Amount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *repositoryImpl) CreatePass(ctx context.Context, p *Pass, guest *Guest, sale *Sale) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertPass(ctx, tx, p, guest, sale); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) CreateGuestPass(ctx context.Context, p *Pass, guest *Guest, allowance int, from, to time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// The lock is released with the transaction.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('guest-passes:' || $1::text, 0))`, *p.HostMemberID); err != nil {
+		return err
+	}
+	var used int
+	if err := tx.QueryRow(ctx, countGuestPassesQuery, *p.HostMemberID, from, to).Scan(&used); err != nil {
+		return err
+	}
+	if used >= allowance {
+		return ErrGuestQuotaExceeded
+	}
+
+	if err := insertPass(ctx, tx, p, guest, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertPass writes the pass and, as needed, its guest member and invoice
+// inside tx.
+func insertPass(ctx context.Context, tx pgx.Tx, p *Pass, guest *Guest, sale *Sale) error {
+	if p.GuestMemberID == uuid.Nil {
+		// Visitors have no login; they stay leads unless they join.
+		memberQuery := `
+			SELECT id FROM create_new_member(
+				NULL, $1, $2, $3, $4, $5, NULL, 'lead', NULL, $6
+			)
+		`
+		if err := tx.QueryRow(ctx, memberQuery,
+			p.OrganizationID,
+			p.BranchID,
+			guest.FirstName,
+			guest.LastName,
+			guest.Phone,
+			guest.Notes,
+		).Scan(&p.GuestMemberID); err != nil {
+			return err
+		}
+	}
+
+	if sale != nil {
+		status := "pending"
+		var paidAt *time.Time
+		if sale.Paid {
+			now := time.Now()
+			status, paidAt = "paid", &now
+		}
+		notes := fmt.Sprintf("Day pass for %s", p.ValidOn.Format(dateLayout))
+		invoiceQuery := `
+			INSERT INTO invoices (
+				invoice_number, member_id, branch_id, amount, tax_amount, status, due_date, paid_at, notes, external_id, payment_method
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id
+		`
+		var invoiceID uuid.UUID
+		if err := tx.QueryRow(ctx, invoiceQuery,
+			invoiceNumber(),
+			p.GuestMemberID,
+			p.BranchID,
+			sale.Amount,
+			sale.TaxAmount,
+			status,
+			sale.DueDate,
+			paidAt,
+			notes,
+			sale.ExternalID,
+			sale.PaymentMethod,
+		).Scan(&invoiceID); err != nil {
+			return err
+		}
+		p.InvoiceID = &invoiceID
+	}
+
+	passQuery := `
+		INSERT INTO passes (
+			organization_id, branch_id, kind, code, valid_on, guest_member_id, host_member_id, invoice_id, issued_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	return tx.QueryRow(ctx, passQuery,
+		p.OrganizationID,
+		p.BranchID,
+		p.Kind,
+		p.Code,
+		p.ValidOn,
+		p.GuestMemberID,
+		p.HostMemberID,
+		p.InvoiceID,
+		p.IssuedBy,
+	).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *repositoryImpl) GetPass(ctx context.Context, id uuid.UUID) (*Pass, error) {
+	query := `SELECT ` + passColumns + passFrom + ` WHERE p.id = $1`
+	return scanPass(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) GetPassByCode(ctx context.Context, code string) (*Pass, error) {
+	query := `SELECT ` + passColumns + passFrom + ` WHERE p.code = $1`
+	return scanPass(r.db.QueryRow(ctx, query, code))
+}
+
+func (r *repositoryImpl) ListPasses(ctx context.Context, filter *PassFilter) ([]*Pass, error) {
+	query := `SELECT ` + passColumns + passFrom + ` WHERE TRUE`
+
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OrganizationIDs != nil {
+		query += " AND p.organization_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.OrganizationIDs)
+		argIndex++
+	}
+
+	if filter.BranchIDs != nil {
+		query += " AND p.branch_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.BranchIDs)
+		argIndex++
+	}
+
+	if filter.HostMemberID != nil {
+		query += " AND p.host_member_id = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.HostMemberID)
+		argIndex++
+	}
+
+	if filter.Kind != nil {
+		query += " AND p.kind = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.Kind)
+		argIndex++
+	}
+
+	if filter.From != nil {
+		query += " AND p.valid_on >= $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.From)
+		argIndex++
+	}
+
+	if filter.To != nil {
+		query += " AND p.valid_on <= $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.To)
+		argIndex++
+	}
+
+	query += " ORDER BY p.valid_on DESC, p.created_at DESC"
+	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passes := []*Pass{}
+	for rows.Next() {
+		p, err := scanPass(rows)
+		if err != nil {
+			return nil, err
+		}
+		passes = append(passes, p)
+	}
+	return passes, rows.Err()
+}
+
+const countGuestPassesQuery = `
+	SELECT COUNT(*) FROM passes
+	WHERE host_member_id = $1 AND cancelled_at IS NULL AND valid_on BETWEEN $2 AND $3
+`
+
+func (r *repositoryImpl) CountGuestPasses(ctx context.Context, hostMemberID uuid.UUID, from, to time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, countGuestPassesQuery, hostMemberID, from, to).Scan(&count)
+	return count, err
+}
+
+func (r *repositoryImpl) CancelPass(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cancelQuery := `
+		UPDATE passes
+		SET cancelled_at = NOW()
+		WHERE id = $1 AND redeemed_at IS NULL AND cancelled_at IS NULL
+		RETURNING invoice_id
+	`
+	var invoiceID *uuid.UUID
+	if err := tx.QueryRow(ctx, cancelQuery, id).Scan(&invoiceID); err != nil {
+		return err
+	}
+
+	// Paid day passes are refunded outside the system; only an open
+	// checkout is voided.
+	if invoiceID != nil {
+		voidQuery := `
+			UPDATE invoices SET status = 'void', updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+		`
+		if _, err := tx.Exec(ctx, voidQuery, *invoiceID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) RedeemPass(ctx context.Context, tx pgx.Tx, p *Pass) error {
+	// The guard keeps a code scanned twice at once from admitting twice.
+	query := `
+		UPDATE passes
+		SET redeemed_at = NOW()
+		WHERE id = $1 AND redeemed_at IS NULL AND cancelled_at IS NULL
+		RETURNING redeemed_at
+	`
+	return tx.QueryRow(ctx, query, p.ID).Scan(&p.RedeemedAt)
+}
+
+func (r *repositoryImpl) CheckOutPass(ctx context.Context, passID uuid.UUID) (time.Time, error) {
+	query := `
+		UPDATE check_ins
+		SET check_out_time = NOW()
+		WHERE pass_id = $1 AND check_out_time IS NULL AND deleted_at IS NULL
+		RETURNING check_out_time
+	`
+	var checkOutTime time.Time
+	err := r.db.QueryRow(ctx, query, passID).Scan(&checkOutTime)
+	return checkOutTime, err
+}
+
+// invoiceNumber follows the invoice module's INV-<year>-<random> numbers.
+func invoiceNumber() string {
+	return fmt.Sprintf("INV-%d-%s", time.Now().UTC().Year(), strings.Split(uuid.New().String(), "-")[0])
+}
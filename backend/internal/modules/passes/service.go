@@ -0,0 +1,670 @@
+package passes
+
+import (
+	"context"
+	"errors"
+	"fitcore/internal/middleware"
+	"fmt"
+	"html"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"fitcore/internal/config"
+	"fitcore/internal/modules/branch"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/occupancy"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/email"
+	"fitcore/pkg/hash"
+	"fitcore/pkg/polar"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	monthLayout = "2006-01"
+
+	// codeLength is the length of the one-time code in the pass QR.
+	codeLength = 32
+	// maxAdvanceDays is how far ahead a pass can be bought or issued.
+	maxAdvanceDays = 60
+)
+
+var (
+	ErrPassNotFound         = errors.New("pass not found")
+	ErrMemberNotFound       = errors.New("member profile not found")
+	ErrOrganizationAccess   = errors.New("organization is outside your access")
+	ErrBranchAccess         = errors.New("branch is outside your assignments")
+	ErrBranchUnavailable    = errors.New("branch is not available")
+	ErrDayPassUnavailable   = errors.New("day passes are not sold at this branch")
+	ErrCheckoutUnavailable  = errors.New("online payment is not set up for day passes at this branch")
+	ErrEmailRequired        = errors.New("an email is required to send the checkout link")
+	ErrInvalidValidOn       = errors.New("validOn must be today or a date up to 60 days ahead")
+	ErrInvalidMonth         = errors.New("month must be formatted as YYYY-MM")
+	ErrNoActiveSubscription = errors.New("an active membership is required to invite guests")
+	ErrGuestQuotaExceeded   = errors.New("no guest passes left for that month")
+	ErrHostFrozen           = errors.New("guests cannot be invited while the membership is frozen")
+	ErrWrongBranch          = errors.New("pass is for another branch")
+	ErrWrongDate            = errors.New("pass is not valid today")
+	ErrPassUsed             = errors.New("pass has already been used")
+	ErrPassCancelled        = errors.New("pass has been cancelled")
+	ErrPaymentPending       = errors.New("day pass has not been paid yet")
+	ErrNotCheckedIn         = errors.New("no open check-in for this pass")
+)
+
+// Service sells day passes and lets members invite guests. Both kinds are a
+// one-time QR for one branch and date, redeemed at the front desk.
+type Service interface {
+	ListDayPassPrices(ctx context.Context, userID uuid.UUID, userRole string, organizationID *uuid.UUID) ([]*DayPassPrice, error)
+	// SetDayPassPrice prices day passes at a branch and keeps the Polar
+	// product used for online checkouts in sync.
+	SetDayPassPrice(ctx context.Context, userID uuid.UUID, userRole string, branchID uuid.UUID, req *SetDayPassPriceRequest) (*DayPassPrice, error)
+
+	SellDayPass(ctx context.Context, userID uuid.UUID, userRole string, req *SellDayPassRequest) (*SellDayPassResponse, error)
+	ListPasses(ctx context.Context, userID uuid.UUID, userRole string, filter *PassFilter) ([]*Pass, error)
+	GetPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Pass, error)
+	CancelPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Pass, error)
+	// RedeemPass admits the visitor of a scanned pass under the branch's
+	// hours and capacity rules.
+	RedeemPass(ctx context.Context, userID uuid.UUID, userRole string, req *RedeemPassRequest) (*RedeemPassResponse, error)
+	CheckOutPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Pass, error)
+
+	// ListGuestPasses returns the member's guest passes and their quota for
+	// month (YYYY-MM, default the current month).
+	ListGuestPasses(ctx context.Context, userID uuid.UUID, month string) (*GuestPassesResponse, error)
+	IssueGuestPass(ctx context.Context, userID uuid.UUID, req *IssueGuestPassRequest) (*Pass, error)
+	CancelGuestPass(ctx context.Context, userID, id uuid.UUID) (*Pass, error)
+}
+
+type serviceImpl struct {
+	repo         Repository
+	userSvc      user.Service
+	branchSvc    branch.Service
+	memberSvc    member.Service
+	subSvc       subscription.Service
+	plansSvc     plans.Service
+	hoursSvc     hours.Service
+	occupancySvc occupancy.Service
+	documentsSvc documents.Service
+	polarSvc     *polar.Service
+	emailSvc     *email.Service
+}
+
+func NewService(repo Repository, userSvc user.Service, branchSvc branch.Service, memberSvc member.Service, subSvc subscription.Service, plansSvc plans.Service, hoursSvc hours.Service, occupancySvc occupancy.Service, documentsSvc documents.Service, polarSvc *polar.Service, emailSvc *email.Service) Service {
+	return &serviceImpl{
+		repo:         repo,
+		userSvc:      userSvc,
+		branchSvc:    branchSvc,
+		memberSvc:    memberSvc,
+		subSvc:       subSvc,
+		plansSvc:     plansSvc,
+		hoursSvc:     hoursSvc,
+		occupancySvc: occupancySvc,
+		documentsSvc: documentsSvc,
+		polarSvc:     polarSvc,
+		emailSvc:     emailSvc,
+	}
+}
+
+// branch loads an active branch the caller may work at.
+func (s *serviceImpl) branch(ctx context.Context, sc *middleware.Scope, branchID uuid.UUID) (*branch.Branch, error) {
+	b, err := s.branchSvc.GetBranch(ctx, branchID)
+	if err != nil {
+		return nil, ErrBranchUnavailable
+	}
+	if !sc.Allows(b.OrganizationID, &b.ID) {
+		return nil, ErrBranchAccess
+	}
+	if b.IsActive != nil && !*b.IsActive {
+		return nil, ErrBranchUnavailable
+	}
+	return b, nil
+}
+
+// pass loads a pass the caller may see; others are reported as missing.
+func (s *serviceImpl) pass(ctx context.Context, sc *middleware.Scope, id uuid.UUID) (*Pass, error) {
+	p, err := s.repo.GetPass(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPassNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !sc.Allows(p.OrganizationID, &p.BranchID) {
+		return nil, ErrPassNotFound
+	}
+	return p, nil
+}
+
+func (s *serviceImpl) ListDayPassPrices(ctx context.Context, userID uuid.UUID, userRole string, organizationID *uuid.UUID) ([]*DayPassPrice, error) {
+	var orgIDs []uuid.UUID
+	if userRole != "super_admin" {
+		ids, err := s.userSvc.GetUserOrganizationIDs(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		orgIDs = ids
+	}
+	if organizationID != nil {
+		if orgIDs != nil && !slices.Contains(orgIDs, *organizationID) {
+			return nil, ErrOrganizationAccess
+		}
+		orgIDs = []uuid.UUID{*organizationID}
+	}
+	return s.repo.ListDayPassPrices(ctx, orgIDs)
+}
+
+func (s *serviceImpl) SetDayPassPrice(ctx context.Context, userID uuid.UUID, userRole string, branchID uuid.UUID, req *SetDayPassPriceRequest) (*DayPassPrice, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.branch(ctx, sc, branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetDayPassPrice(ctx, b.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	price := &DayPassPrice{BranchID: b.ID, OrganizationID: b.OrganizationID, Price: req.Price, IsActive: true, BranchName: b.Name}
+	if current != nil {
+		price.ProductID = current.ProductID
+		price.IsActive = current.IsActive
+	}
+	if req.IsActive != nil {
+		price.IsActive = *req.IsActive
+	}
+
+	cents := int64(req.Price * 100)
+	switch {
+	case price.ProductID == nil && req.Price > 0:
+		name := "Day pass - " + b.Name
+		product, err := s.polarSvc.CreateProduct(ctx, cents, name, "Single-day entry at "+b.Name)
+		if err != nil {
+			log.Printf("Service: SetDayPassPrice failed to create product for branch %s: %v", b.ID, err)
+			return nil, err
+		}
+		price.ProductID = &product.Product.ID
+	case price.ProductID != nil && current != nil && current.Price != req.Price:
+		if _, err := s.polarSvc.UpdateProduct(ctx, *price.ProductID, polar.ProductUpdateParams{Price: &cents}); err != nil {
+			log.Printf("Service: SetDayPassPrice failed to update product %s: %v", *price.ProductID, err)
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpsertDayPassPrice(ctx, price); err != nil {
+		log.Printf("Service: SetDayPassPrice failed for branch %s: %v", b.ID, err)
+		return nil, err
+	}
+	log.Printf("Service: Day pass price at branch %s set to %.2f by user %s", b.ID, price.Price, userID)
+	return price, nil
+}
+
+func (s *serviceImpl) SellDayPass(ctx context.Context, userID uuid.UUID, userRole string, req *SellDayPassRequest) (*SellDayPassResponse, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.branch(ctx, sc, req.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	price, err := s.repo.GetDayPassPrice(ctx, b.ID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !price.IsActive) {
+		return nil, ErrDayPassUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	validOn, err := validOnAt(b, req.ValidOn)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pass{OrganizationID: b.OrganizationID, BranchID: b.ID, Kind: KindDay, ValidOn: validOn, IssuedBy: &userID}
+	guest := &Guest{FirstName: strings.TrimSpace(req.FirstName), LastName: strings.TrimSpace(req.LastName), Phone: req.Phone}
+	if req.MemberID != nil {
+		m, err := s.memberSvc.GetMember(ctx, *req.MemberID)
+		if err != nil || m.OrganizationID != b.OrganizationID {
+			return nil, ErrMemberNotFound
+		}
+		p.GuestMemberID = m.ID
+		guest.FirstName, guest.LastName = m.FirstName, m.LastName
+	} else {
+		note := "Day pass visitor"
+		guest.Notes = &note
+	}
+
+	if p.Code, err = hash.GenerateRandomPassword(codeLength); err != nil {
+		return nil, err
+	}
+
+	// Front desk sales and free passes are paid on the spot.
+	sale := &Sale{Amount: price.Price, PaymentMethod: req.PaymentMethod, Paid: req.PaymentMethod != nil || price.Price == 0}
+	var checkoutURL string
+	if !sale.Paid {
+		if price.ProductID == nil {
+			return nil, ErrCheckoutUnavailable
+		}
+		if req.Email == nil {
+			return nil, ErrEmailRequired
+		}
+		successURL := fmt.Sprintf("%s/login", config.Get().App.BaseURL)
+		res, err := s.polarSvc.CreateCheckout(ctx, []string{*price.ProductID}, *req.Email, successURL, "day_pass")
+		if err != nil {
+			log.Printf("Service: SellDayPass failed to create checkout at branch %s: %v", b.ID, err)
+			return nil, err
+		}
+		if res.Checkout.TaxAmount != nil {
+			sale.TaxAmount = float64(*res.Checkout.TaxAmount) / 100
+		}
+		if id, err := uuid.Parse(res.Checkout.ID); err == nil {
+			sale.ExternalID = &id
+		}
+		sale.DueDate = &res.Checkout.ExpiresAt
+		checkoutURL = res.Checkout.URL
+	}
+
+	if err := s.repo.CreatePass(ctx, p, guest, sale); err != nil {
+		log.Printf("Service: SellDayPass failed at branch %s: %v", b.ID, err)
+		return nil, err
+	}
+	created, err := s.repo.GetPass(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		s.sendPass(created, *req.Email, checkoutURL)
+	}
+	log.Printf("Service: Day pass %s for %s sold at branch %s by user %s", created.ID, created.ValidOn.Format(dateLayout), b.ID, userID)
+	return &SellDayPassResponse{PassResponse: created.ToResponse(), CheckoutURL: checkoutURL}, nil
+}
+
+func (s *serviceImpl) ListPasses(ctx context.Context, userID uuid.UUID, userRole string, filter *PassFilter) ([]*Pass, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	filter.OrganizationIDs = sc.OrganizationIDs
+	filter.BranchIDs = sc.BranchIDs
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return s.repo.ListPasses(ctx, filter)
+}
+
+func (s *serviceImpl) GetPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Pass, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	return s.pass(ctx, sc, id)
+}
+
+func (s *serviceImpl) CancelPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Pass, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.pass(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancel(ctx, p)
+}
+
+func (s *serviceImpl) cancel(ctx context.Context, p *Pass) (*Pass, error) {
+	if err := passUsable(p); err != nil && !errors.Is(err, ErrPaymentPending) {
+		return nil, err
+	}
+	if err := s.repo.CancelPass(ctx, p.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPassUsed
+		}
+		log.Printf("Service: CancelPass failed for pass %s: %v", p.ID, err)
+		return nil, err
+	}
+	return s.repo.GetPass(ctx, p.ID)
+}
+
+func (s *serviceImpl) RedeemPass(ctx context.Context, userID uuid.UUID, userRole string, req *RedeemPassRequest) (*RedeemPassResponse, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.repo.GetPassByCode(ctx, strings.TrimSpace(req.Code))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPassNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !sc.Allows(p.OrganizationID, &p.BranchID) {
+		return nil, ErrPassNotFound
+	}
+	if p.BranchID != req.BranchID {
+		return nil, ErrWrongBranch
+	}
+	if err := passUsable(p); err != nil {
+		return nil, err
+	}
+
+	b, err := s.branchSvc.GetBranch(ctx, p.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if p.ValidOn.Format(dateLayout) != now.In(branchLocation(b)).Format(dateLayout) {
+		return nil, ErrWrongDate
+	}
+
+	// A guest is only admitted while their host is still a member and not
+	// on a freeze.
+	if p.Kind == KindGuest {
+		_, err := s.subSvc.CheckAccess(ctx, *p.HostMemberID, now)
+		if errors.Is(err, subscription.ErrSubscriptionFrozen) {
+			return nil, ErrHostFrozen
+		}
+		if err != nil {
+			return nil, ErrNoActiveSubscription
+		}
+	}
+
+	// Guests sign the organization's waivers like members do.
+	if err := s.documentsSvc.CheckSigned(ctx, p.GuestMemberID); err != nil {
+		return nil, err
+	}
+	if err := s.hoursSvc.CheckAccess(ctx, p.BranchID, nil, now); err != nil {
+		return nil, err
+	}
+	checkIn := &occupancy.CheckIn{
+		BranchID: p.BranchID,
+		MemberID: p.GuestMemberID,
+		PassID:   &p.ID,
+		Method:   "qr",
+		Claim: func(ctx context.Context, tx pgx.Tx) error {
+			return s.repo.RedeemPass(ctx, tx, p)
+		},
+	}
+	err = s.occupancySvc.CheckIn(ctx, checkIn)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPassUsed
+	}
+	if err != nil {
+		log.Printf("Service: RedeemPass failed for pass %s: %v", p.ID, err)
+		return nil, err
+	}
+
+	log.Printf("Service: Pass %s redeemed at branch %s by user %s", p.ID, p.BranchID, userID)
+	return &RedeemPassResponse{PassResponse: p.ToResponse(), CheckInID: checkIn.ID, CheckInTime: checkIn.CheckInTime}, nil
+}
+
+func (s *serviceImpl) CheckOutPass(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Pass, error) {
+	sc, err := middleware.ResolveScope(ctx, s.userSvc, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.pass(ctx, sc, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.CheckOutPass(ctx, p.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotCheckedIn
+		}
+		log.Printf("Service: CheckOutPass failed for pass %s: %v", p.ID, err)
+		return nil, err
+	}
+	s.occupancySvc.PublishCheckOut(ctx, p.BranchID, p.GuestMemberID)
+	return p, nil
+}
+
+// host is the calling member with the guest allowance of their plan. A
+// frozen host has no allowance until the freeze ends.
+type host struct {
+	member    *member.Member
+	plan      *plans.Plan
+	allowance int
+	frozen    bool
+}
+
+func (s *serviceImpl) host(ctx context.Context, userID uuid.UUID) (*host, error) {
+	m, err := s.memberSvc.GetMemberByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h := &host{member: m}
+	sub, err := s.subSvc.CheckAccess(ctx, m.ID, time.Now())
+	if errors.Is(err, subscription.ErrSubscriptionFrozen) {
+		h.frozen = true
+		return h, nil
+	}
+	if err != nil || sub.PlanID == nil {
+		return h, nil
+	}
+	plan, err := s.plansSvc.GetPlan(ctx, *sub.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	h.plan = plan
+	if plan.GuestPasses != nil {
+		h.allowance = *plan.GuestPasses
+	}
+	return h, nil
+}
+
+// monthRange returns the first and last day of the month of t.
+func monthRange(t time.Time) (time.Time, time.Time) {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, -1)
+}
+
+func (s *serviceImpl) quota(ctx context.Context, h *host, month time.Time) (*GuestPassQuotaResponse, error) {
+	from, to := monthRange(month)
+	used, err := s.repo.CountGuestPasses(ctx, h.member.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &GuestPassQuotaResponse{
+		Month:     from.Format(monthLayout),
+		Allowance: h.allowance,
+		Used:      used,
+		Remaining: max(h.allowance-used, 0),
+	}, nil
+}
+
+func (s *serviceImpl) ListGuestPasses(ctx context.Context, userID uuid.UUID, month string) (*GuestPassesResponse, error) {
+	h, err := s.host(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now().UTC()
+	if month != "" {
+		if at, err = time.Parse(monthLayout, month); err != nil {
+			return nil, ErrInvalidMonth
+		}
+	}
+	quota, err := s.quota(ctx, h, at)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := monthRange(at)
+	passes, err := s.repo.ListPasses(ctx, &PassFilter{HostMemberID: &h.member.ID, From: &from, To: &to, Page: 1, Limit: 100})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GuestPassesResponse{Quota: quota, Passes: make([]*PassResponse, len(passes))}
+	for i, p := range passes {
+		res.Passes[i] = p.ToResponse()
+	}
+	return res, nil
+}
+
+func (s *serviceImpl) IssueGuestPass(ctx context.Context, userID uuid.UUID, req *IssueGuestPassRequest) (*Pass, error) {
+	h, err := s.host(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if h.frozen {
+		return nil, ErrHostFrozen
+	}
+	if h.plan == nil {
+		return nil, ErrNoActiveSubscription
+	}
+
+	branchID := h.member.HomeBranchID
+	if req.BranchID != nil {
+		branchID = req.BranchID
+	}
+	if branchID == nil {
+		return nil, ErrBranchUnavailable
+	}
+	b, err := s.branch(ctx, &middleware.Scope{OrganizationIDs: []uuid.UUID{h.member.OrganizationID}}, *branchID)
+	if err != nil {
+		return nil, ErrBranchUnavailable
+	}
+	if len(h.plan.BranchIDs) > 0 && !slices.Contains(h.plan.BranchIDs, b.ID.String()) {
+		return nil, ErrBranchUnavailable
+	}
+
+	validOn, err := validOnAt(b, &req.ValidOn)
+	if err != nil {
+		return nil, err
+	}
+	note := fmt.Sprintf("Guest of %s %s", h.member.FirstName, h.member.LastName)
+	p := &Pass{
+		OrganizationID: b.OrganizationID,
+		BranchID:       b.ID,
+		Kind:           KindGuest,
+		ValidOn:        validOn,
+		HostMemberID:   &h.member.ID,
+		IssuedBy:       &userID,
+	}
+	if p.Code, err = hash.GenerateRandomPassword(codeLength); err != nil {
+		return nil, err
+	}
+	guest := &Guest{FirstName: strings.TrimSpace(req.FirstName), LastName: strings.TrimSpace(req.LastName), Phone: req.Phone, Notes: &note}
+	from, to := monthRange(validOn)
+	if err := s.repo.CreateGuestPass(ctx, p, guest, h.allowance, from, to); err != nil {
+		if errors.Is(err, ErrGuestQuotaExceeded) {
+			return nil, err
+		}
+		log.Printf("Service: IssueGuestPass failed for member %s: %v", h.member.ID, err)
+		return nil, err
+	}
+	created, err := s.repo.GetPass(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		s.sendPass(created, *req.Email, "")
+	}
+	log.Printf("Service: Guest pass %s for %s issued by member %s", created.ID, created.ValidOn.Format(dateLayout), h.member.ID)
+	return created, nil
+}
+
+func (s *serviceImpl) CancelGuestPass(ctx context.Context, userID, id uuid.UUID) (*Pass, error) {
+	m, err := s.memberSvc.GetMemberByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.repo.GetPass(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPassNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.HostMemberID == nil || *p.HostMemberID != m.ID {
+		return nil, ErrPassNotFound
+	}
+	return s.cancel(ctx, p)
+}
+
+// sendPass emails the pass code, and the checkout link while it is unpaid,
+// in the background.
+func (s *serviceImpl) sendPass(p *Pass, to, checkoutURL string) {
+	if s.emailSvc == nil {
+		return
+	}
+	subject := fmt.Sprintf("Your pass for %s on %s", p.BranchName, p.ValidOn.Format(dateLayout))
+	text := fmt.Sprintf("Show this code at the front desk of %s on %s: %s", p.BranchName, p.ValidOn.Format(dateLayout), p.Code)
+	body := fmt.Sprintf("<p>Show this code at the front desk of %s on %s:</p><p><strong>%s</strong></p>",
+		html.EscapeString(p.BranchName), p.ValidOn.Format(dateLayout), p.Code)
+	if checkoutURL != "" {
+		text += "\nComplete your payment before your visit: " + checkoutURL
+		body += fmt.Sprintf(`<p><a href="%s">Complete your payment</a> before your visit.</p>`, html.EscapeString(checkoutURL))
+	}
+	go func() {
+		if err := s.emailSvc.SendEmail(context.Background(), to, subject, body, text); err != nil {
+			log.Printf("Service: Async pass email failed for pass %s: %v", p.ID, err)
+		}
+	}()
+}
+
+// passUsable reports why a pass cannot be redeemed, if it cannot.
+func passUsable(p *Pass) error {
+	switch p.Status() {
+	case StatusCancelled:
+		return ErrPassCancelled
+	case StatusRedeemed:
+		return ErrPassUsed
+	case StatusAwaitingPayment:
+		return ErrPaymentPending
+	}
+	return nil
+}
+
+// validOnAt parses a pass date, defaulting to today at the branch, and
+// checks it is within the advance window.
+func validOnAt(b *branch.Branch, value *string) (time.Time, error) {
+	now := time.Now().In(branchLocation(b))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value == nil {
+		return today, nil
+	}
+	validOn, err := time.Parse(dateLayout, *value)
+	if err != nil || validOn.Before(today) || validOn.After(today.AddDate(0, 0, maxAdvanceDays)) {
+		return time.Time{}, ErrInvalidValidOn
+	}
+	return validOn, nil
+}
+
+func branchLocation(b *branch.Branch) *time.Location {
+	if b.Timezone == nil || *b.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(*b.Timezone)
+	if err != nil {
+		log.Printf("Service: unknown timezone %q, falling back to UTC", *b.Timezone)
+		return time.UTC
+	}
+	return loc
+}
@@ -11,15 +11,31 @@ type CreatePlanRequest struct {
 	Description    *string     `json:"description,omitempty"`
 	Price          float64     `json:"price" validate:"required,gte=0"`
 	DurationDays   int         `json:"durationDays" validate:"required,gt=0"`
+	ClassCredits   *int        `json:"classCredits,omitempty" validate:"omitempty,gte=0"`
+	ClassTypeIDs   []uuid.UUID `json:"classTypeIds,omitempty"`
+	// SessionCredits turns the plan into a personal training pack that
+	// expires after DurationDays instead of granting gym access.
+	SessionCredits *int `json:"sessionCredits,omitempty" validate:"omitempty,gt=0"`
+	// GuestPasses is how many guest passes a member on the plan can issue
+	// per calendar month.
+	GuestPasses *int `json:"guestPasses,omitempty" validate:"omitempty,gte=0"`
+	// MaxSeats makes the plan a group plan covering up to that many members,
+	// the primary payer included.
+	MaxSeats *int `json:"maxSeats,omitempty" validate:"omitempty,gt=1"`
 }
 
 type UpdatePlanRequest struct {
-	BranchIDs    []uuid.UUID `json:"branchIds,omitempty"`
-	Name         string      `json:"name,omitempty"`
-	Description  *string     `json:"description,omitempty"`
-	Price        *float64    `json:"price,omitempty"`
-	DurationDays *int        `json:"durationDays,omitempty"`
-	IsActive     *bool       `json:"isActive,omitempty"`
+	BranchIDs      []uuid.UUID `json:"branchIds,omitempty"`
+	Name           string      `json:"name,omitempty"`
+	Description    *string     `json:"description,omitempty"`
+	Price          *float64    `json:"price,omitempty"`
+	DurationDays   *int        `json:"durationDays,omitempty"`
+	IsActive       *bool       `json:"isActive,omitempty"`
+	ClassCredits   *int        `json:"classCredits,omitempty" validate:"omitempty,gte=0"`
+	ClassTypeIDs   []uuid.UUID `json:"classTypeIds,omitempty"`
+	SessionCredits *int        `json:"sessionCredits,omitempty" validate:"omitempty,gt=0"`
+	GuestPasses    *int        `json:"guestPasses,omitempty" validate:"omitempty,gte=0"`
+	MaxSeats       *int        `json:"maxSeats,omitempty" validate:"omitempty,gt=1"`
 }
 
 type PlanResponse struct {
@@ -31,4 +47,9 @@ type PlanResponse struct {
 	Price          float64     `json:"price"`
 	DurationDays   int         `json:"durationDays"`
 	IsActive       *bool       `json:"isActive,omitempty"`
+	ClassCredits   *int        `json:"classCredits,omitempty"`
+	ClassTypeIDs   []uuid.UUID `json:"classTypeIds,omitempty"`
+	SessionCredits *int        `json:"sessionCredits,omitempty"`
+	GuestPasses    *int        `json:"guestPasses,omitempty"`
+	MaxSeats       *int        `json:"maxSeats,omitempty"`
 }
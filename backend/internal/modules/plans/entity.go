@@ -15,6 +15,11 @@ type Plan struct {
 	Price          float64    `db:"price"`
 	DurationDays   int        `db:"duration_days"`
 	IsActive       *bool      `db:"is_active"`
+	ClassCredits   *int       `db:"class_credits"`
+	ClassTypeIDs   []string   `db:"class_type_ids"`
+	SessionCredits *int       `db:"session_credits"`
+	GuestPasses    *int       `db:"guest_passes_per_month"`
+	MaxSeats       *int       `db:"max_seats"`
 	CreatedAt      time.Time  `db:"created_at"`
 	UpdatedAt      time.Time  `db:"updated_at"`
 	DeletedAt      *time.Time `db:"deleted_at"`
@@ -28,6 +33,13 @@ func (p *Plan) ToResponse() *PlanResponse {
 		}
 	}
 
+	classTypeIDs := make([]uuid.UUID, 0, len(p.ClassTypeIDs))
+	for _, id := range p.ClassTypeIDs {
+		if uid, err := uuid.Parse(id); err == nil {
+			classTypeIDs = append(classTypeIDs, uid)
+		}
+	}
+
 	return &PlanResponse{
 		ID:             p.ID,
 		OrganizationID: p.OrganizationID,
@@ -37,5 +49,10 @@ func (p *Plan) ToResponse() *PlanResponse {
 		Price:          p.Price,
 		DurationDays:   p.DurationDays,
 		IsActive:       p.IsActive,
+		ClassCredits:   p.ClassCredits,
+		ClassTypeIDs:   classTypeIDs,
+		SessionCredits: p.SessionCredits,
+		GuestPasses:    p.GuestPasses,
+		MaxSeats:       p.MaxSeats,
 	}
 }
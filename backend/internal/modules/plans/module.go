@@ -1,6 +1,7 @@
 package plans
 
 import (
+	"fitcore/internal/modules/cache"
 	"fitcore/pkg/polar"
 
 	"github.com/go-chi/chi/v5"
@@ -13,9 +14,9 @@ type Provider struct {
 	Repository Repository
 }
 
-func NewProvider(db *pgxpool.Pool, polarSvc *polar.Service) *Provider {
+func NewProvider(db *pgxpool.Pool, polarSvc *polar.Service, cacheSvc cache.Service) *Provider {
 	repo := NewRepository(db)
-	service := NewService(repo, polarSvc)
+	service := NewService(repo, polarSvc, cacheSvc)
 	handler := NewHandler(service)
 
 	return &Provider{
@@ -26,8 +26,8 @@ func NewRepository(db *pgxpool.Pool) Repository {
 
 func (r *repositoryImpl) Create(ctx context.Context, plan *Plan) error {
 	query := `
-		INSERT INTO membership_plans (id, organization_id, branch_ids, name, description, price, duration_days, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO membership_plans (id, organization_id, branch_ids, name, description, price, duration_days, is_active, class_credits, class_type_ids, session_credits, guest_passes_per_month, max_seats)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.QueryRow(ctx, query,
@@ -39,14 +39,19 @@ func (r *repositoryImpl) Create(ctx context.Context, plan *Plan) error {
 		plan.Price,
 		plan.DurationDays,
 		plan.IsActive,
+		plan.ClassCredits,
+		plan.ClassTypeIDs,
+		plan.SessionCredits,
+		plan.GuestPasses,
+		plan.MaxSeats,
 	).Scan(&plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
 }
 
 func (r *repositoryImpl) Update(ctx context.Context, plan *Plan) error {
 	query := `
 		UPDATE membership_plans
-		SET branch_ids = $1, name = $2, description = $3, price = $4, duration_days = $5, is_active = $6, updated_at = NOW()
-		WHERE id = $7 AND deleted_at IS NULL
+		SET branch_ids = $1, name = $2, description = $3, price = $4, duration_days = $5, is_active = $6, class_credits = $7, class_type_ids = $8, session_credits = $9, guest_passes_per_month = $10, max_seats = $11, updated_at = NOW()
+		WHERE id = $12 AND deleted_at IS NULL
 		RETURNING updated_at
 	`
 	return r.db.QueryRow(ctx, query,
@@ -56,6 +61,11 @@ func (r *repositoryImpl) Update(ctx context.Context, plan *Plan) error {
 		plan.Price,
 		plan.DurationDays,
 		plan.IsActive,
+		plan.ClassCredits,
+		plan.ClassTypeIDs,
+		plan.SessionCredits,
+		plan.GuestPasses,
+		plan.MaxSeats,
 		plan.ID,
 	).Scan(&plan.UpdatedAt)
 }
@@ -72,7 +82,7 @@ func (r *repositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
 
 func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Plan, error) {
 	query := `
-		SELECT id, organization_id, branch_ids, name, description, price, duration_days, is_active, created_at, updated_at
+		SELECT id, organization_id, branch_ids, name, description, price, duration_days, is_active, class_credits, class_type_ids, session_credits, guest_passes_per_month, max_seats, created_at, updated_at
 		FROM membership_plans
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -86,6 +96,11 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Plan, erro
 		&plan.Price,
 		&plan.DurationDays,
 		&plan.IsActive,
+		&plan.ClassCredits,
+		&plan.ClassTypeIDs,
+		&plan.SessionCredits,
+		&plan.GuestPasses,
+		&plan.MaxSeats,
 		&plan.CreatedAt,
 		&plan.UpdatedAt,
 	)
@@ -97,7 +112,7 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Plan, erro
 
 func (r *repositoryImpl) List(ctx context.Context, limit, offset int) ([]*Plan, error) {
 	query := `
-		SELECT id, organization_id, branch_ids, name, description, price, duration_days, is_active, created_at, updated_at
+		SELECT id, organization_id, branch_ids, name, description, price, duration_days, is_active, class_credits, class_type_ids, session_credits, guest_passes_per_month, max_seats, created_at, updated_at
 		FROM membership_plans
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -121,6 +136,11 @@ func (r *repositoryImpl) List(ctx context.Context, limit, offset int) ([]*Plan,
 			&plan.Price,
 			&plan.DurationDays,
 			&plan.IsActive,
+			&plan.ClassCredits,
+			&plan.ClassTypeIDs,
+			&plan.SessionCredits,
+			&plan.GuestPasses,
+			&plan.MaxSeats,
 			&plan.CreatedAt,
 			&plan.UpdatedAt,
 		); err != nil {
@@ -133,7 +153,7 @@ func (r *repositoryImpl) List(ctx context.Context, limit, offset int) ([]*Plan,
 
 func (r *repositoryImpl) ListByOrganizationID(ctx context.Context, organizationID uuid.UUID, limit, offset int) ([]*Plan, error) {
 	query := `
-		SELECT id, organization_id, branch_ids, name, description, price, duration_days, is_active, created_at, updated_at
+		SELECT id, organization_id, branch_ids, name, description, price, duration_days, is_active, class_credits, class_type_ids, session_credits, guest_passes_per_month, max_seats, created_at, updated_at
 		FROM membership_plans
 		WHERE organization_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -157,6 +177,11 @@ func (r *repositoryImpl) ListByOrganizationID(ctx context.Context, organizationI
 			&plan.Price,
 			&plan.DurationDays,
 			&plan.IsActive,
+			&plan.ClassCredits,
+			&plan.ClassTypeIDs,
+			&plan.SessionCredits,
+			&plan.GuestPasses,
+			&plan.MaxSeats,
 			&plan.CreatedAt,
 			&plan.UpdatedAt,
 		); err != nil {
@@ -2,64 +2,20 @@ package plans
 
 import (
 	"context"
+	"fitcore/internal/modules/cache"
 	"fitcore/pkg/polar"
 	"log"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// PlanCache provides in-memory caching for plans
-type PlanCache struct {
-	mu       sync.RWMutex
-	plans    map[uuid.UUID]*Plan
-	expireAt map[uuid.UUID]time.Time
-	ttl      time.Duration
-}
+// planCacheTTL bounds how long a plan is served from cache; writes
+// invalidate it sooner.
+const planCacheTTL = 5 * time.Minute
 
-func NewPlanCache(ttl time.Duration) *PlanCache {
-	return &PlanCache{
-		plans:    make(map[uuid.UUID]*Plan),
-		expireAt: make(map[uuid.UUID]time.Time),
-		ttl:      ttl,
-	}
-}
-
-func (c *PlanCache) Get(id uuid.UUID) (*Plan, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	if expiry, ok := c.expireAt[id]; ok && time.Now().After(expiry) {
-		return nil, false
-	}
-
-	plan, ok := c.plans[id]
-	return plan, ok
-}
-
-func (c *PlanCache) Set(plan *Plan) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.plans[plan.ID] = plan
-	c.expireAt[plan.ID] = time.Now().Add(c.ttl)
-}
-
-func (c *PlanCache) Invalidate(id uuid.UUID) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	delete(c.plans, id)
-	delete(c.expireAt, id)
-}
-
-func (c *PlanCache) InvalidateAll() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.plans = make(map[uuid.UUID]*Plan)
-	c.expireAt = make(map[uuid.UUID]time.Time)
+func planCacheKey(id uuid.UUID) string {
+	return "plan:" + id.String()
 }
 
 type Service interface {
@@ -74,14 +30,14 @@ type Service interface {
 type serviceImpl struct {
 	repo     Repository
 	polarSvc *polar.Service
-	cache    *PlanCache
+	cacheSvc cache.Service
 }
 
-func NewService(repo Repository, polarSvc *polar.Service) Service {
+func NewService(repo Repository, polarSvc *polar.Service, cacheSvc cache.Service) Service {
 	return &serviceImpl{
 		repo:     repo,
 		polarSvc: polarSvc,
-		cache:    NewPlanCache(5 * time.Minute), // Cache plans for 5 minutes
+		cacheSvc: cacheSvc,
 	}
 }
 
@@ -91,6 +47,14 @@ func (s *serviceImpl) CreatePlan(ctx context.Context, req *CreatePlanRequest) (*
 		branchIDs = append(branchIDs, id.String())
 	}
 
+	var classTypeIDs []string
+	if req.ClassTypeIDs != nil {
+		classTypeIDs = make([]string, 0, len(req.ClassTypeIDs))
+		for _, id := range req.ClassTypeIDs {
+			classTypeIDs = append(classTypeIDs, id.String())
+		}
+	}
+
 	isActive := true
 	plan := &Plan{
 		OrganizationID: req.OrganizationID,
@@ -100,6 +64,11 @@ func (s *serviceImpl) CreatePlan(ctx context.Context, req *CreatePlanRequest) (*
 		Price:          req.Price,
 		DurationDays:   req.DurationDays,
 		IsActive:       &isActive,
+		ClassCredits:   req.ClassCredits,
+		ClassTypeIDs:   classTypeIDs,
+		SessionCredits: req.SessionCredits,
+		GuestPasses:    req.GuestPasses,
+		MaxSeats:       req.MaxSeats,
 	}
 
 	price := int64(req.Price * 100)
@@ -114,9 +83,6 @@ func (s *serviceImpl) CreatePlan(ctx context.Context, req *CreatePlanRequest) (*
 		return nil, err
 	}
 
-	// Cache the newly created plan
-	s.cache.Set(plan)
-
 	return plan, nil
 }
 
@@ -160,6 +126,30 @@ func (s *serviceImpl) UpdatePlan(ctx context.Context, id uuid.UUID, req *UpdateP
 		plan.IsActive = req.IsActive
 	}
 
+	if req.ClassCredits != nil {
+		plan.ClassCredits = req.ClassCredits
+	}
+
+	if req.ClassTypeIDs != nil {
+		classTypeIDs := make([]string, 0, len(req.ClassTypeIDs))
+		for _, cid := range req.ClassTypeIDs {
+			classTypeIDs = append(classTypeIDs, cid.String())
+		}
+		plan.ClassTypeIDs = classTypeIDs
+	}
+
+	if req.SessionCredits != nil {
+		plan.SessionCredits = req.SessionCredits
+	}
+
+	if req.GuestPasses != nil {
+		plan.GuestPasses = req.GuestPasses
+	}
+
+	if req.MaxSeats != nil {
+		plan.MaxSeats = req.MaxSeats
+	}
+
 	if polarParams.Name != nil || polarParams.Description != nil || polarParams.Price != nil {
 		_, err := s.polarSvc.UpdateProduct(ctx, id.String(), polarParams)
 		if err != nil {
@@ -172,9 +162,7 @@ func (s *serviceImpl) UpdatePlan(ctx context.Context, id uuid.UUID, req *UpdateP
 	}
 
 	// Invalidate cache on update
-	s.cache.Invalidate(id)
-	// Cache the updated plan
-	s.cache.Set(plan)
+	s.invalidate(ctx, id)
 
 	return plan, nil
 }
@@ -185,29 +173,32 @@ func (s *serviceImpl) DeletePlan(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 
-	// Invalidate cache on delete
-	s.cache.Invalidate(id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
 
-	return s.repo.Delete(ctx, id)
+	// Invalidate cache on delete
+	s.invalidate(ctx, id)
+	return nil
 }
 
 func (s *serviceImpl) GetPlan(ctx context.Context, id uuid.UUID) (*Plan, error) {
-	// Check cache first
-	if plan, ok := s.cache.Get(id); ok {
-		log.Printf("Service: GetPlan cache hit for ID: %s", id)
-		return plan, nil
-	}
-
-	log.Printf("Service: GetPlan cache miss for ID: %s, fetching from database", id)
-	plan, err := s.repo.GetByID(ctx, id)
+	var plan Plan
+	err := s.cacheSvc.GetOrCompute(ctx, planCacheKey(id), &plan, cache.Options{TTL: planCacheTTL}, func(ctx context.Context) (any, time.Duration, error) {
+		log.Printf("Service: GetPlan cache miss for ID: %s, fetching from database", id)
+		plan, err := s.repo.GetByID(ctx, id)
+		return plan, 0, err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return &plan, nil
+}
 
-	// Cache the plan for future requests
-	s.cache.Set(plan)
-
-	return plan, nil
+func (s *serviceImpl) invalidate(ctx context.Context, id uuid.UUID) {
+	if err := s.cacheSvc.Delete(ctx, planCacheKey(id)); err != nil {
+		log.Printf("Service: failed to invalidate cached plan %s: %v", id, err)
+	}
 }
 
 func (s *serviceImpl) ListPlans(ctx context.Context, page, limit int) ([]*Plan, error) {
@@ -0,0 +1,40 @@
+package portal
+
+import (
+	"time"
+
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/subscription"
+
+	"github.com/google/uuid"
+)
+
+type MeResponse struct {
+	Member *member.MemberResponse `json:"member"`
+	// Subscription is the membership granting access today, if any.
+	Subscription *subscription.SubscriptionResponse `json:"subscription,omitempty"`
+	// PendingRequests are freeze, renewal and cancellation requests waiting
+	// for staff.
+	PendingRequests []*member.MemberRequestResponse `json:"pendingRequests"`
+}
+
+// CheckoutRequest starts a renewal checkout; a different planId changes the
+// plan from the next period.
+type CheckoutRequest struct {
+	PlanID *uuid.UUID `json:"planId,omitempty"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	WaitlistEmails        *bool `json:"waitlistEmails,omitempty"`
+	ClassReminderEmails   *bool `json:"classReminderEmails,omitempty"`
+	RenewalReminderEmails *bool `json:"renewalReminderEmails,omitempty"`
+	MarketingEmails       *bool `json:"marketingEmails,omitempty"`
+}
+
+type NotificationPreferencesResponse struct {
+	WaitlistEmails        bool       `json:"waitlistEmails"`
+	ClassReminderEmails   bool       `json:"classReminderEmails"`
+	RenewalReminderEmails bool       `json:"renewalReminderEmails"`
+	MarketingEmails       bool       `json:"marketingEmails"`
+	UpdatedAt             *time.Time `json:"updatedAt,omitempty"`
+}
@@ -0,0 +1,40 @@
+package portal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationPreferences are a member's opt-outs for optional emails.
+// Payment and account emails are not optional.
+type NotificationPreferences struct {
+	MemberID              uuid.UUID  `db:"member_id"`
+	WaitlistEmails        bool       `db:"waitlist_emails"`
+	ClassReminderEmails   bool       `db:"class_reminder_emails"`
+	RenewalReminderEmails bool       `db:"renewal_reminder_emails"`
+	MarketingEmails       bool       `db:"marketing_emails"`
+	UpdatedAt             *time.Time `db:"updated_at"`
+}
+
+// defaultNotificationPreferences mirrors the column defaults for members
+// who never saved preferences.
+func defaultNotificationPreferences(memberID uuid.UUID) *NotificationPreferences {
+	return &NotificationPreferences{
+		MemberID:              memberID,
+		WaitlistEmails:        true,
+		ClassReminderEmails:   true,
+		RenewalReminderEmails: true,
+		MarketingEmails:       false,
+	}
+}
+
+func (p *NotificationPreferences) ToResponse() *NotificationPreferencesResponse {
+	return &NotificationPreferencesResponse{
+		WaitlistEmails:        p.WaitlistEmails,
+		ClassReminderEmails:   p.ClassReminderEmails,
+		RenewalReminderEmails: p.RenewalReminderEmails,
+		MarketingEmails:       p.MarketingEmails,
+		UpdatedAt:             p.UpdatedAt,
+	}
+}
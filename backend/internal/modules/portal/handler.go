@@ -0,0 +1,331 @@
+package portal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/modules/invoice"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type Handler struct {
+	service   Service
+	memberSvc member.Service
+}
+
+func NewHandler(service Service, memberSvc member.Service) *Handler {
+	return &Handler{service: service, memberSvc: memberSvc}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/me", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RoleMiddleware("member"))
+
+		r.Get("/", h.GetMe)
+		r.Put("/profile", h.UpdateProfile)
+
+		r.Get("/plans", h.ListPlans)
+		r.Post("/checkout", h.StartCheckout)
+
+		r.Get("/invoices", h.ListInvoices)
+		r.Get("/invoices/{id}", h.GetInvoice)
+		r.Get("/invoices/{id}/download", h.DownloadInvoice)
+
+		r.Get("/requests", h.ListRequests)
+		r.Post("/requests", h.CreateRequest)
+		r.Post("/requests/{requestId}/cancel", h.CancelRequest)
+
+		r.Get("/notification-preferences", h.GetNotificationPreferences)
+		r.Put("/notification-preferences", h.UpdateNotificationPreferences)
+	})
+}
+
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	me, err := h.service.GetMe(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "Failed to get profile")
+		return
+	}
+	response.Success(w, "Profile retrieved successfully", me)
+}
+
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req member.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	m, err := h.service.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to update profile")
+		return
+	}
+	response.Success(w, "Profile updated successfully", m.ToResponse())
+}
+
+func (h *Handler) ListPlans(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	available, err := h.service.ListPlans(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "Failed to list plans")
+		return
+	}
+
+	planResponses := make([]*plans.PlanResponse, len(available))
+	for i, plan := range available {
+		planResponses[i] = plan.ToResponse()
+	}
+	response.Success(w, "Plans retrieved successfully", planResponses)
+}
+
+// StartCheckout renews the membership, or changes plan from the next period
+// when planId differs, and answers with the checkout URL to pay at.
+func (h *Handler) StartCheckout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// The plan is optional, so an empty body renews the current plan
+	var req CheckoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.BadRequest(w, "Invalid request payload", nil)
+			return
+		}
+	}
+
+	checkout, err := h.service.StartCheckout(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to start checkout")
+		return
+	}
+	response.Success(w, "Checkout started successfully", checkout)
+}
+
+func (h *Handler) ListInvoices(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	invoices, err := h.service.ListInvoices(r.Context(), userID, page, limit)
+	if err != nil {
+		writeError(w, err, "Failed to list invoices")
+		return
+	}
+
+	invoiceResponses := make([]*invoice.InvoiceResponse, len(invoices))
+	for i, inv := range invoices {
+		invoiceResponses[i] = inv.ToResponse()
+	}
+	response.Success(w, "Invoices retrieved successfully", invoiceResponses)
+}
+
+func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid invoice ID", nil)
+		return
+	}
+
+	inv, err := h.service.GetInvoice(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, err, "Failed to get invoice")
+		return
+	}
+	response.Success(w, "Invoice retrieved successfully", inv.ToResponse())
+}
+
+func (h *Handler) DownloadInvoice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid invoice ID", nil)
+		return
+	}
+
+	inv, doc, err := h.service.InvoiceDocument(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, err, "Failed to render invoice")
+		return
+	}
+
+	name := inv.InvoiceNumber
+	if name == "" {
+		name = inv.ID.String()
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".pdf"))
+	if _, err := doc.WriteTo(w); err != nil {
+		log.Printf("Handler: DownloadInvoice failed for invoice %s: %v", inv.ID, err)
+	}
+}
+
+func (h *Handler) ListRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := h.memberSvc.ListMyRequests(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "Failed to list requests")
+		return
+	}
+
+	responses := make([]*member.MemberRequestResponse, len(requests))
+	for i, req := range requests {
+		responses[i] = req.ToResponse()
+	}
+	response.Success(w, "Requests retrieved successfully", responses)
+}
+
+// CreateRequest asks staff for a freeze, a renewal or a cancellation.
+func (h *Handler) CreateRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req member.CreateMemberRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	created, err := h.memberSvc.CreateMemberRequest(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to submit request")
+		return
+	}
+	response.Success(w, "Request submitted successfully", created.ToResponse())
+}
+
+func (h *Handler) CancelRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid request ID", nil)
+		return
+	}
+
+	if err := h.memberSvc.CancelMyRequest(r.Context(), userID, requestID); err != nil {
+		writeError(w, err, "Failed to cancel request")
+		return
+	}
+	response.OK(w, "Request cancelled successfully")
+}
+
+func (h *Handler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.service.GetNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "Failed to get notification preferences")
+		return
+	}
+	response.Success(w, "Notification preferences retrieved successfully", prefs.ToResponse())
+}
+
+func (h *Handler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	prefs, err := h.service.UpdateNotificationPreferences(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to update notification preferences")
+		return
+	}
+	response.Success(w, "Notification preferences updated successfully", prefs.ToResponse())
+}
+
+func requestUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, false
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrMemberNotFound), errors.Is(err, ErrInvoiceNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, member.ErrMemberRequestNotFound), errors.Is(err, pgx.ErrNoRows):
+		response.NotFound(w, "Request not found")
+	case errors.Is(err, member.ErrMemberRequestPending), errors.Is(err, member.ErrMemberRequestResolved):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, member.ErrInvalidFreeze), errors.Is(err, member.ErrNoActiveSubscription), errors.Is(err, member.ErrPlanUnavailable):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
@@ -0,0 +1,33 @@
+package portal
+
+import (
+	"fitcore/internal/modules/invoice"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, memberSvc member.Service, subSvc subscription.Service, invoiceSvc invoice.Service, plansSvc plans.Service) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, memberSvc, subSvc, invoiceSvc, plansSvc)
+	handler := NewHandler(service, memberSvc)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
@@ -0,0 +1,71 @@
+package portal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// GetPreferences returns the saved preferences, or the defaults when the
+	// member has none.
+	GetPreferences(ctx context.Context, memberID uuid.UUID) (*NotificationPreferences, error)
+	SavePreferences(ctx context.Context, prefs *NotificationPreferences) error
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+func (r *repositoryImpl) GetPreferences(ctx context.Context, memberID uuid.UUID) (*NotificationPreferences, error) {
+	query := `
+		SELECT member_id, waitlist_emails, class_reminder_emails, renewal_reminder_emails, marketing_emails, updated_at
+		FROM member_notification_preferences
+		WHERE member_id = $1
+	`
+	var p NotificationPreferences
+	err := r.db.QueryRow(ctx, query, memberID).Scan(
+		&p.MemberID,
+		&p.WaitlistEmails,
+		&p.ClassReminderEmails,
+		&p.RenewalReminderEmails,
+		&p.MarketingEmails,
+		&p.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultNotificationPreferences(memberID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *repositoryImpl) SavePreferences(ctx context.Context, p *NotificationPreferences) error {
+	query := `
+		INSERT INTO member_notification_preferences (
+			member_id, waitlist_emails, class_reminder_emails, renewal_reminder_emails, marketing_emails
+		) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (member_id) DO UPDATE SET
+			waitlist_emails = EXCLUDED.waitlist_emails,
+			class_reminder_emails = EXCLUDED.class_reminder_emails,
+			renewal_reminder_emails = EXCLUDED.renewal_reminder_emails,
+			marketing_emails = EXCLUDED.marketing_emails,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(ctx, query,
+		p.MemberID,
+		p.WaitlistEmails,
+		p.ClassReminderEmails,
+		p.RenewalReminderEmails,
+		p.MarketingEmails,
+	).Scan(&p.UpdatedAt)
+}
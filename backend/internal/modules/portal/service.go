@@ -0,0 +1,289 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"fitcore/internal/modules/invoice"
+	"fitcore/internal/modules/member"
+	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/subscription"
+	"fitcore/pkg/export"
+	"fitcore/pkg/pdf"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrMemberNotFound  = errors.New("no member profile for this account")
+	ErrInvoiceNotFound = errors.New("invoice not found")
+)
+
+// Service is the member-facing side of the member, subscription and invoice
+// modules. Every method is scoped to the member record of userID.
+type Service interface {
+	GetMe(ctx context.Context, userID uuid.UUID) (*MeResponse, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, req *member.UpdateProfileRequest) (*member.Member, error)
+
+	ListPlans(ctx context.Context, userID uuid.UUID) ([]*plans.Plan, error)
+	// StartCheckout creates the next subscription period, unpaid until the
+	// checkout completes, and returns the checkout URL.
+	StartCheckout(ctx context.Context, userID uuid.UUID, req *CheckoutRequest) (*subscription.CreateSubscriptionResponse, error)
+
+	ListInvoices(ctx context.Context, userID uuid.UUID, page, limit int) ([]*invoice.Invoice, error)
+	GetInvoice(ctx context.Context, userID, id uuid.UUID) (*invoice.Invoice, error)
+	// InvoiceDocument renders an invoice, or a receipt once it is paid.
+	InvoiceDocument(ctx context.Context, userID, id uuid.UUID) (*invoice.Invoice, *pdf.Document, error)
+
+	GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*NotificationPreferences, error)
+	UpdateNotificationPreferences(ctx context.Context, userID uuid.UUID, req *UpdateNotificationPreferencesRequest) (*NotificationPreferences, error)
+}
+
+type serviceImpl struct {
+	repo       Repository
+	memberSvc  member.Service
+	subSvc     subscription.Service
+	invoiceSvc invoice.Service
+	plansSvc   plans.Service
+}
+
+func NewService(repo Repository, memberSvc member.Service, subSvc subscription.Service, invoiceSvc invoice.Service, plansSvc plans.Service) Service {
+	return &serviceImpl{
+		repo:       repo,
+		memberSvc:  memberSvc,
+		subSvc:     subSvc,
+		invoiceSvc: invoiceSvc,
+		plansSvc:   plansSvc,
+	}
+}
+
+func (s *serviceImpl) me(ctx context.Context, userID uuid.UUID) (*member.Member, error) {
+	m, err := s.memberSvc.GetMemberByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		log.Printf("Service: failed to get member for user %s: %v", userID, err)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *serviceImpl) GetMe(ctx context.Context, userID uuid.UUID) (*MeResponse, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &MeResponse{Member: m.ToResponse(), PendingRequests: []*member.MemberRequestResponse{}}
+	if sub, err := s.subSvc.GetAccessSubscription(ctx, m.ID); err == nil {
+		resp.Subscription = sub.ToResponse()
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Service: GetMe failed to get subscription for member %s: %v", m.ID, err)
+		return nil, err
+	}
+
+	requests, err := s.memberSvc.ListMyRequests(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range requests {
+		if req.Status == member.MemberRequestPending {
+			resp.PendingRequests = append(resp.PendingRequests, req.ToResponse())
+		}
+	}
+	return resp, nil
+}
+
+func (s *serviceImpl) UpdateProfile(ctx context.Context, userID uuid.UUID, req *member.UpdateProfileRequest) (*member.Member, error) {
+	m, err := s.memberSvc.UpdateProfile(ctx, userID, req)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	return m, err
+}
+
+func (s *serviceImpl) ListPlans(ctx context.Context, userID uuid.UUID) ([]*plans.Plan, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.memberSvc.ListAvailablePlans(ctx, m)
+}
+
+func (s *serviceImpl) StartCheckout(ctx context.Context, userID uuid.UUID, req *CheckoutRequest) (*subscription.CreateSubscriptionResponse, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := time.Now()
+	branchID := m.HomeBranchID
+	var planID *uuid.UUID
+	current, err := s.subSvc.GetActiveSubscription(ctx, m.ID)
+	switch {
+	case err == nil:
+		// The new period follows the current one, so nothing paid is lost.
+		startDate = current.EndDate
+		planID = current.PlanID
+		if current.BranchID != nil {
+			branchID = current.BranchID
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		log.Printf("Service: StartCheckout failed to get subscription for member %s: %v", m.ID, err)
+		return nil, err
+	}
+	if req.PlanID != nil {
+		planID = req.PlanID
+	}
+	if planID == nil {
+		return nil, member.ErrPlanUnavailable
+	}
+
+	available, err := s.memberSvc.ListAvailablePlans(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	offered := false
+	for _, plan := range available {
+		offered = offered || plan.ID == *planID
+	}
+	if !offered {
+		return nil, member.ErrPlanUnavailable
+	}
+
+	// past_due keeps the period from granting access until the paid webhook
+	// activates it.
+	status := string(subscription.StatusPastDue)
+	res, err := s.subSvc.CreateSubscription(ctx, &subscription.CreateSubscriptionRequest{
+		MemberID:  m.ID,
+		PlanID:    planID,
+		BranchID:  branchID,
+		StartDate: startDate.Format(dateLayout),
+		Status:    &status,
+	}, "renewal")
+	if err != nil {
+		log.Printf("Service: StartCheckout failed for member %s: %v", m.ID, err)
+		return nil, err
+	}
+	log.Printf("Service: Member %s started a checkout for plan %s from %s", m.ID, *planID, startDate.Format(dateLayout))
+	return res, nil
+}
+
+func (s *serviceImpl) ListInvoices(ctx context.Context, userID uuid.UUID, page, limit int) ([]*invoice.Invoice, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.invoiceSvc.ListInvoices(ctx, invoice.ListInvoicesFilter{MemberID: &m.ID, Page: page, Limit: limit})
+}
+
+func (s *serviceImpl) GetInvoice(ctx context.Context, userID, id uuid.UUID) (*invoice.Invoice, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.invoiceSvc.GetInvoice(ctx, id)
+	// Other members' invoices are reported as missing.
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && inv.MemberID != m.ID) {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (s *serviceImpl) InvoiceDocument(ctx context.Context, userID, id uuid.UUID) (*invoice.Invoice, *pdf.Document, error) {
+	inv, err := s.GetInvoice(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	number := inv.InvoiceNumber
+	if number == "" {
+		number = inv.ID.String()
+	}
+	title := "Invoice"
+	if inv.Status == "paid" {
+		title = "Receipt"
+	}
+
+	doc := pdf.New(fmt.Sprintf("%s %s", title, number))
+	doc.Heading(title)
+	doc.Space()
+	doc.Row("Number", number, false)
+	doc.Row("Issued", inv.CreatedAt.Format(dateLayout), false)
+	if inv.DueDate != nil {
+		doc.Row("Due", inv.DueDate.Format(dateLayout), false)
+	}
+	if inv.PaidAt != nil {
+		doc.Row("Paid", inv.PaidAt.Format(dateLayout), false)
+	}
+	doc.Row("Status", inv.Status, false)
+	doc.Space()
+	doc.Row("Billed to", m.FirstName+" "+m.LastName, false)
+
+	if inv.SubscriptionID != nil {
+		if sub, err := s.subSvc.GetSubscription(ctx, *inv.SubscriptionID); err == nil && sub.PlanID != nil {
+			description := "Membership"
+			if plan, err := s.plansSvc.GetPlan(ctx, *sub.PlanID); err == nil {
+				description = plan.Name
+			}
+			doc.Row(description, sub.StartDate.Format(dateLayout)+" to "+sub.EndDate.Format(dateLayout), false)
+		}
+	}
+	if inv.Notes != nil {
+		doc.Text(*inv.Notes)
+	}
+
+	doc.Space()
+	doc.Row("Amount", export.Money(inv.Amount), false)
+	doc.Row("Tax", export.Money(inv.TaxAmount), false)
+	doc.Row("Total", export.Money(inv.TotalAmount), true)
+	return inv, doc, nil
+}
+
+func (s *serviceImpl) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (*NotificationPreferences, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPreferences(ctx, m.ID)
+}
+
+func (s *serviceImpl) UpdateNotificationPreferences(ctx context.Context, userID uuid.UUID, req *UpdateNotificationPreferencesRequest) (*NotificationPreferences, error) {
+	prefs, err := s.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.WaitlistEmails != nil {
+		prefs.WaitlistEmails = *req.WaitlistEmails
+	}
+	if req.ClassReminderEmails != nil {
+		prefs.ClassReminderEmails = *req.ClassReminderEmails
+	}
+	if req.RenewalReminderEmails != nil {
+		prefs.RenewalReminderEmails = *req.RenewalReminderEmails
+	}
+	if req.MarketingEmails != nil {
+		prefs.MarketingEmails = *req.MarketingEmails
+	}
+
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		log.Printf("Service: UpdateNotificationPreferences failed for member %s: %v", prefs.MemberID, err)
+		return nil, err
+	}
+	return prefs, nil
+}
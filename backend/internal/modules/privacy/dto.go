@@ -0,0 +1,56 @@
+package privacy
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateRequestRequest struct {
+	Kind   string  `json:"kind" validate:"required,oneof=export erasure"`
+	Reason *string `json:"reason" validate:"omitempty,max=1000"`
+}
+
+type RejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+type ListFilter struct {
+	OrganizationIDs []uuid.UUID
+	MemberID        *uuid.UUID
+	Kind            *string
+	Status          *string
+	Page            int
+	Limit           int
+}
+
+type RequestResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	MemberID       uuid.UUID  `json:"memberId"`
+	MemberName     string     `json:"memberName"`
+	Kind           string     `json:"kind"`
+	Status         string     `json:"status"`
+	RequestedBy    *uuid.UUID `json:"requestedBy,omitempty"`
+	Reason         *string    `json:"reason,omitempty"`
+	FileSize       *int64     `json:"fileSize,omitempty"`
+	Error          *string    `json:"error,omitempty"`
+	DownloadURL    *string    `json:"downloadUrl,omitempty"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type EventResponse struct {
+	Action    string     `json:"action"`
+	ActorID   *uuid.UUID `json:"actorId,omitempty"`
+	Detail    *string    `json:"detail,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// RequestDetailResponse is a request with its audit trail.
+type RequestDetailResponse struct {
+	*RequestResponse
+	Events []*EventResponse `json:"events"`
+}
@@ -0,0 +1,119 @@
+package privacy
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindExport  Kind = "export"
+	KindErasure Kind = "erasure"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusRejected  Status = "rejected"
+	StatusExpired   Status = "expired"
+)
+
+// Actions recorded in the audit trail.
+const (
+	ActionRequested  = "requested"
+	ActionStarted    = "started"
+	ActionCompleted  = "completed"
+	ActionFailed     = "failed"
+	ActionRejected   = "rejected"
+	ActionDownloaded = "downloaded"
+	ActionExpired    = "expired"
+)
+
+type Request struct {
+	ID             uuid.UUID  `db:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	MemberID       uuid.UUID  `db:"member_id"`
+	Kind           Kind       `db:"kind"`
+	Status         Status     `db:"status"`
+	RequestedBy    *uuid.UUID `db:"requested_by"`
+	Reason         *string    `db:"reason"`
+	StorageKey     *string    `db:"storage_key"`
+	FileSize       *int64     `db:"file_size"`
+	Error          *string    `db:"error"`
+	StartedAt      *time.Time `db:"started_at"`
+	CompletedAt    *time.Time `db:"completed_at"`
+	ExpiresAt      *time.Time `db:"expires_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+
+	// Joined from members.
+	MemberName string `db:"member_name"`
+}
+
+type Event struct {
+	ID        uuid.UUID  `db:"id"`
+	RequestID uuid.UUID  `db:"request_id"`
+	Action    string     `db:"action"`
+	ActorID   *uuid.UUID `db:"actor_id"`
+	Detail    *string    `db:"detail"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// MemberRef is what scoping and the request flow need to know about a
+// member.
+type MemberRef struct {
+	ID             uuid.UUID  `db:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	UserID         *uuid.UUID `db:"user_id"`
+	AnonymizedAt   *time.Time `db:"anonymized_at"`
+}
+
+// Erased is what an erasure left for the service to clean up outside the
+// transaction.
+type Erased struct {
+	PhotoFileID *uuid.UUID
+	// ArchiveKeys are the stored files holding the member's data, their own
+	// archives and bulk exports, now expired.
+	ArchiveKeys []string
+}
+
+// ToResponse links a ready archive under basePath, which differs between
+// the member and admin routes.
+func (r *Request) ToResponse(basePath string) *RequestResponse {
+	res := &RequestResponse{
+		ID:             r.ID,
+		OrganizationID: r.OrganizationID,
+		MemberID:       r.MemberID,
+		MemberName:     r.MemberName,
+		Kind:           string(r.Kind),
+		Status:         string(r.Status),
+		RequestedBy:    r.RequestedBy,
+		Reason:         r.Reason,
+		FileSize:       r.FileSize,
+		Error:          r.Error,
+		StartedAt:      r.StartedAt,
+		CompletedAt:    r.CompletedAt,
+		ExpiresAt:      r.ExpiresAt,
+		CreatedAt:      r.CreatedAt,
+	}
+	if r.Kind == KindExport && r.Status == StatusCompleted {
+		url := basePath + "/" + r.ID.String() + "/download"
+		res.DownloadURL = &url
+	}
+	return res
+}
+
+func (e *Event) ToResponse() *EventResponse {
+	return &EventResponse{
+		Action:    e.Action,
+		ActorID:   e.ActorID,
+		Detail:    e.Detail,
+		CreatedAt: e.CreatedAt,
+	}
+}
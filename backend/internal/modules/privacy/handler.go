@@ -0,0 +1,360 @@
+package privacy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Download links point at the route group the response was served from.
+const (
+	memberBasePath = "/api/v1/privacy/me/requests"
+	adminBasePath  = "/api/v1/privacy/requests"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/privacy", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member"))
+			r.Get("/me/requests", h.ListMine)
+			r.Post("/me/requests", h.RequestMine)
+			r.Get("/me/requests/{id}", h.GetMine)
+			r.Get("/me/requests/{id}/download", h.DownloadMine)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Get("/requests", h.List)
+			r.Get("/requests/{id}", h.Get)
+			r.Get("/requests/{id}/download", h.Download)
+			r.Post("/requests/{id}/approve", h.Approve)
+			r.Post("/requests/{id}/reject", h.Reject)
+			r.Post("/members/{memberId}/requests", h.RequestForMember)
+		})
+	})
+}
+
+func (h *Handler) RequestMine(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	var req CreateRequestRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.RequestMine(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to create privacy request")
+		return
+	}
+	response.Accepted(w, "Privacy request received successfully", res.ToResponse(memberBasePath))
+}
+
+func (h *Handler) ListMine(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := h.service.ListMine(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "Failed to list privacy requests")
+		return
+	}
+	response.Success(w, "Privacy requests retrieved successfully", requestResponses(requests, memberBasePath))
+}
+
+func (h *Handler) GetMine(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid privacy request ID")
+	if !ok {
+		return
+	}
+
+	req, events, err := h.service.GetMine(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, err, "Failed to get privacy request")
+		return
+	}
+	response.Success(w, "Privacy request retrieved successfully", detailResponse(req, events, memberBasePath))
+}
+
+func (h *Handler) DownloadMine(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid privacy request ID")
+	if !ok {
+		return
+	}
+
+	req, body, err := h.service.OpenMine(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, err, "Failed to open export")
+		return
+	}
+	serveArchive(w, req, body)
+}
+
+// RequestForMember records a request the member made to staff, e.g. by
+// email or at the front desk.
+func (h *Handler) RequestForMember(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := pathUUID(w, r, "memberId", "Invalid member ID")
+	if !ok {
+		return
+	}
+	var req CreateRequestRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	res, err := h.service.RequestForMember(r.Context(), userID, userRole, memberID, &req)
+	if err != nil {
+		writeError(w, err, "Failed to create privacy request")
+		return
+	}
+	response.Accepted(w, "Privacy request received successfully", res.ToResponse(adminBasePath))
+}
+
+// List takes memberId, kind (export|erasure), status, page and limit.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &ListFilter{}
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if filter.MemberID, ok = optionalUUID(w, r, "memberId"); !ok {
+		return
+	}
+	if kind := query.Get("kind"); kind != "" {
+		if kind != string(KindExport) && kind != string(KindErasure) {
+			response.BadRequest(w, "Invalid kind parameter, use export or erasure", nil)
+			return
+		}
+		filter.Kind = &kind
+	}
+	if status := query.Get("status"); status != "" {
+		switch Status(status) {
+		case StatusPending, StatusRunning, StatusCompleted, StatusFailed, StatusRejected, StatusExpired:
+		default:
+			response.BadRequest(w, "Invalid status parameter", nil)
+			return
+		}
+		filter.Status = &status
+	}
+
+	requests, err := h.service.List(r.Context(), userID, userRole, filter)
+	if err != nil {
+		writeError(w, err, "Failed to list privacy requests")
+		return
+	}
+	response.Success(w, "Privacy requests retrieved successfully", requestResponses(requests, adminBasePath))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid privacy request ID")
+	if !ok {
+		return
+	}
+
+	req, events, err := h.service.Get(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to get privacy request")
+		return
+	}
+	response.Success(w, "Privacy request retrieved successfully", detailResponse(req, events, adminBasePath))
+}
+
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid privacy request ID")
+	if !ok {
+		return
+	}
+
+	req, body, err := h.service.Open(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to open export")
+		return
+	}
+	serveArchive(w, req, body)
+}
+
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid privacy request ID")
+	if !ok {
+		return
+	}
+
+	req, err := h.service.Approve(r.Context(), userID, userRole, id)
+	if err != nil {
+		writeError(w, err, "Failed to erase member data")
+		return
+	}
+	response.Success(w, "Member data erased successfully", req.ToResponse(adminBasePath))
+}
+
+func (h *Handler) Reject(w http.ResponseWriter, r *http.Request) {
+	userID, userRole, ok := callerFromContext(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathUUID(w, r, "id", "Invalid privacy request ID")
+	if !ok {
+		return
+	}
+	var body RejectRequest
+	if !decode(w, r, &body) {
+		return
+	}
+
+	req, err := h.service.Reject(r.Context(), userID, userRole, id, body.Reason)
+	if err != nil {
+		writeError(w, err, "Failed to reject privacy request")
+		return
+	}
+	response.Success(w, "Privacy request rejected successfully", req.ToResponse(adminBasePath))
+}
+
+func serveArchive(w http.ResponseWriter, req *Request, body io.ReadCloser) {
+	defer body.Close()
+
+	filename := fmt.Sprintf("member-data-%s.zip", req.CreatedAt.UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	if req.FileSize != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*req.FileSize, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Handler: Failed to stream privacy export %s: %v", req.ID, err)
+	}
+}
+
+func requestResponses(requests []*Request, basePath string) []*RequestResponse {
+	res := make([]*RequestResponse, len(requests))
+	for i, req := range requests {
+		res[i] = req.ToResponse(basePath)
+	}
+	return res
+}
+
+func detailResponse(req *Request, events []*Event, basePath string) *RequestDetailResponse {
+	res := &RequestDetailResponse{
+		RequestResponse: req.ToResponse(basePath),
+		Events:          make([]*EventResponse, len(events)),
+	}
+	for i, e := range events {
+		res.Events[i] = e.ToResponse()
+	}
+	return res
+}
+
+func writeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrMemberNotFound), errors.Is(err, ErrRequestNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, ErrRequestOpen), errors.Is(err, ErrNotPending), errors.Is(err, ErrArchiveNotReady),
+		errors.Is(err, ErrErasureBlocked), errors.Is(err, ErrAlreadyErased):
+		response.Conflict(w, err.Error(), nil)
+	case errors.Is(err, ErrNotErasure):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		log.Printf("Handler: %s: %v", message, err)
+		response.InternalServerError(w, message)
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return false
+	}
+	return response.ValidateStructAndWrite(w, req)
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request, param, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		response.BadRequest(w, message, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func optionalUUID(w http.ResponseWriter, r *http.Request, param string) (*uuid.UUID, bool) {
+	v := r.URL.Query().Get(param)
+	if v == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		response.BadRequest(w, "Invalid "+param+" parameter", nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+func callerFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+	return userID, userRole, true
+}
@@ -0,0 +1,33 @@
+package privacy
+
+import (
+	"fitcore/internal/modules/cache"
+	"fitcore/internal/modules/files"
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Provider struct {
+	Handler    *Handler
+	Service    Service
+	Repository Repository
+}
+
+func NewProvider(db *pgxpool.Pool, userSvc user.Service, filesSvc files.Service, cacheSvc cache.Service, store storage.Storage) *Provider {
+	repo := NewRepository(db)
+	service := NewService(repo, userSvc, filesSvc, cacheSvc, store)
+	handler := NewHandler(service)
+
+	return &Provider{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
+
+func (m *Provider) RegisterRoutes(r chi.Router) {
+	m.Handler.RegisterRoutes(r)
+}
@@ -0,0 +1,665 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	// GetMember also finds deleted members, whose data is still held until
+	// it is erased.
+	GetMember(ctx context.Context, id uuid.UUID) (*MemberRef, error)
+	GetMemberByUserID(ctx context.Context, userID uuid.UUID) (*MemberRef, error)
+
+	// CreateRequest stores a request and its requested event.
+	CreateRequest(ctx context.Context, req *Request) error
+	GetRequest(ctx context.Context, id uuid.UUID) (*Request, error)
+	ListRequests(ctx context.Context, filter *ListFilter) ([]*Request, error)
+	ListEvents(ctx context.Context, requestID uuid.UUID) ([]*Event, error)
+	AddEvent(ctx context.Context, requestID uuid.UUID, action string, actorID *uuid.UUID, detail *string) error
+
+	// ClaimExport marks the oldest pending export running and returns it, or
+	// nil when there is none. Exports left running longer than staleAfter
+	// (the process died) are claimed again.
+	ClaimExport(ctx context.Context, staleAfter time.Duration) (*Request, error)
+	CompleteExport(ctx context.Context, id uuid.UUID, storageKey string, fileSize int64, expiresAt time.Time) error
+	FailExport(ctx context.Context, id uuid.UUID, message string) error
+	// ExportData returns each section of the member's archive as JSON.
+	ExportData(ctx context.Context, member *MemberRef) ([]*Section, error)
+
+	// Blockers counts what has to be settled before a member can be erased.
+	Blockers(ctx context.Context, memberID uuid.UUID) (subscriptions int, invoices int, err error)
+	// Erase anonymizes the member of a pending erasure request and completes
+	// it. It returns pgx.ErrNoRows when the request is no longer pending.
+	Erase(ctx context.Context, requestID uuid.UUID, actorID uuid.UUID) (*Erased, error)
+	Reject(ctx context.Context, requestID uuid.UUID, actorID uuid.UUID, reason string) error
+	// ExpireExports marks completed exports past expires_at expired and
+	// returns their storage keys.
+	ExpireExports(ctx context.Context) ([]string, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+// Section is one file of an export archive.
+type Section struct {
+	Name string
+	Data []byte
+}
+
+// sections are the files of an export archive. Each query takes the member
+// ID, or the user ID when byUser is set, and returns one JSON value.
+var sections = []struct {
+	name   string
+	query  string
+	byUser bool
+}{
+	{name: "profile", query: `
+		SELECT row_to_json(p) FROM (
+			SELECT m.id, m.first_name, m.last_name, u.email, m.phone, m.date_of_birth, m.status, m.join_date,
+				m.emergency_contact_name, m.emergency_contact_phone, m.emergency_contact_relationship, m.notes,
+				o.name AS organization, b.name AS home_branch, m.created_at, m.updated_at, u.last_login_at,
+				np.waitlist_emails, np.class_reminder_emails, np.renewal_reminder_emails, np.marketing_emails
+			FROM members m
+			JOIN organization o ON o.id = m.organization_id
+			LEFT JOIN users u ON u.id = m.user_id
+			LEFT JOIN branches b ON b.id = m.home_branch_id
+			LEFT JOIN member_notification_preferences np ON np.member_id = m.id
+			WHERE m.id = $1
+		) p
+	`},
+	{name: "subscriptions", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.start_date), '[]') FROM (
+			SELECT s.id, mp.name AS plan, b.name AS branch, s.start_date, s.end_date, s.status, s.created_at
+			FROM subscriptions s
+			LEFT JOIN membership_plans mp ON mp.id = s.plan_id
+			LEFT JOIN branches b ON b.id = s.branch_id
+			WHERE s.member_id = $1
+		) x
+	`},
+	{name: "invoices", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.created_at), '[]') FROM (
+			SELECT i.id, i.invoice_number, b.name AS branch, i.amount, i.tax_amount, i.total_amount, i.status,
+				i.payment_method, i.due_date, i.paid_at, i.created_at
+			FROM invoices i
+			LEFT JOIN branches b ON b.id = i.branch_id
+			WHERE i.member_id = $1
+		) x
+	`},
+	{name: "check_ins", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.check_in_time), '[]') FROM (
+			SELECT c.id, b.name AS branch, c.check_in_time, c.check_out_time, c.method
+			FROM check_ins c
+			JOIN branches b ON b.id = c.branch_id
+			WHERE c.member_id = $1
+		) x
+	`},
+	{name: "class_bookings", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.starts_at), '[]') FROM (
+			SELECT cb.id, ct.name AS class, b.name AS branch, cs.starts_at, cs.ends_at, cb.status,
+				cb.created_at, cb.cancelled_at
+			FROM class_bookings cb
+			JOIN class_sessions cs ON cs.id = cb.session_id
+			JOIN class_types ct ON ct.id = cs.class_type_id
+			JOIN branches b ON b.id = cs.branch_id
+			WHERE cb.member_id = $1
+		) x
+	`},
+	{name: "training_sessions", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.starts_at), '[]') FROM (
+			SELECT t.id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')) AS trainer,
+				b.name AS branch, t.starts_at, t.ends_at, t.status, t.notes, t.completed_at, t.cancelled_at
+			FROM training_sessions t
+			JOIN branches b ON b.id = t.branch_id
+			LEFT JOIN users u ON u.id = t.trainer_id
+			WHERE t.member_id = $1
+		) x
+	`},
+	{name: "requests", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.created_at), '[]') FROM (
+			SELECT r.id, r.type, r.status, r.freeze_start, r.freeze_days, r.note, r.source, r.resolved_at,
+				r.resolution_note, r.created_at
+			FROM member_requests r
+			WHERE r.member_id = $1
+		) x
+	`},
+	{name: "document_signatures", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.signed_at), '[]') FROM (
+			SELECT s.id, t.title AS document, s.version, s.signer_name, s.answers, s.channel, s.ip_address,
+				s.user_agent, s.signed_at
+			FROM document_signatures s
+			JOIN document_templates t ON t.id = s.template_id
+			WHERE s.member_id = $1
+		) x
+	`},
+	{name: "chat_history", query: `
+		SELECT COALESCE(json_agg(x ORDER BY x.created_at), '[]') FROM (
+			SELECT cs.id, cs.name, cs.created_at, (
+				SELECT COALESCE(json_agg(json_build_object(
+					'role', cm.role, 'content', cm.content, 'created_at', cm.created_at
+				) ORDER BY cm.created_at), '[]')
+				FROM chat_messages cm
+				WHERE cm.session_id = cs.id
+			) AS messages
+			FROM chat_sessions cs
+			WHERE cs.user_id = $1
+		) x
+	`, byUser: true},
+}
+
+func (r *repositoryImpl) GetMember(ctx context.Context, id uuid.UUID) (*MemberRef, error) {
+	query := `SELECT id, organization_id, user_id, anonymized_at FROM members WHERE id = $1`
+	var m MemberRef
+	if err := r.db.QueryRow(ctx, query, id).Scan(&m.ID, &m.OrganizationID, &m.UserID, &m.AnonymizedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repositoryImpl) GetMemberByUserID(ctx context.Context, userID uuid.UUID) (*MemberRef, error) {
+	query := `SELECT id, organization_id, user_id, anonymized_at FROM members WHERE user_id = $1 AND deleted_at IS NULL`
+	var m MemberRef
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&m.ID, &m.OrganizationID, &m.UserID, &m.AnonymizedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+const requestColumns = `
+	r.id, r.organization_id, r.member_id, r.kind, r.status, r.requested_by, r.reason, r.storage_key, r.file_size,
+	r.error, r.started_at, r.completed_at, r.expires_at, r.created_at, r.updated_at,
+	TRIM(m.first_name || ' ' || m.last_name)
+`
+
+func scanRequest(row pgx.Row) (*Request, error) {
+	var req Request
+	err := row.Scan(
+		&req.ID,
+		&req.OrganizationID,
+		&req.MemberID,
+		&req.Kind,
+		&req.Status,
+		&req.RequestedBy,
+		&req.Reason,
+		&req.StorageKey,
+		&req.FileSize,
+		&req.Error,
+		&req.StartedAt,
+		&req.CompletedAt,
+		&req.ExpiresAt,
+		&req.CreatedAt,
+		&req.UpdatedAt,
+		&req.MemberName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func addEvent(ctx context.Context, tx pgx.Tx, requestID uuid.UUID, action string, actorID *uuid.UUID, detail *string) error {
+	query := `INSERT INTO privacy_request_events (request_id, action, actor_id, detail) VALUES ($1, $2, $3, $4)`
+	_, err := tx.Exec(ctx, query, requestID, action, actorID, detail)
+	return err
+}
+
+func (r *repositoryImpl) CreateRequest(ctx context.Context, req *Request) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO privacy_requests (organization_id, member_id, kind, requested_by, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at, updated_at
+	`
+	if err := tx.QueryRow(ctx, query,
+		req.OrganizationID,
+		req.MemberID,
+		string(req.Kind),
+		req.RequestedBy,
+		req.Reason,
+	).Scan(&req.ID, &req.Status, &req.CreatedAt, &req.UpdatedAt); err != nil {
+		return err
+	}
+	if err := addEvent(ctx, tx, req.ID, ActionRequested, req.RequestedBy, req.Reason); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetRequest(ctx context.Context, id uuid.UUID) (*Request, error) {
+	query := `
+		SELECT ` + requestColumns + `
+		FROM privacy_requests r
+		JOIN members m ON m.id = r.member_id
+		WHERE r.id = $1
+	`
+	return scanRequest(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *repositoryImpl) ListRequests(ctx context.Context, filter *ListFilter) ([]*Request, error) {
+	query := `
+		SELECT ` + requestColumns + `
+		FROM privacy_requests r
+		JOIN members m ON m.id = r.member_id
+		WHERE TRUE
+	`
+
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OrganizationIDs != nil {
+		query += " AND r.organization_id = ANY($" + strconv.Itoa(argIndex) + ")"
+		args = append(args, filter.OrganizationIDs)
+		argIndex++
+	}
+
+	if filter.MemberID != nil {
+		query += " AND r.member_id = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.MemberID)
+		argIndex++
+	}
+
+	if filter.Kind != nil {
+		query += " AND r.kind = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.Kind)
+		argIndex++
+	}
+
+	if filter.Status != nil {
+		query += " AND r.status = $" + strconv.Itoa(argIndex)
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+
+	query += " ORDER BY r.created_at DESC"
+	query += " LIMIT $" + strconv.Itoa(argIndex) + " OFFSET $" + strconv.Itoa(argIndex+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*Request{}
+	for rows.Next() {
+		req, err := scanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+func (r *repositoryImpl) ListEvents(ctx context.Context, requestID uuid.UUID) ([]*Event, error) {
+	query := `
+		SELECT id, request_id, action, actor_id, detail, created_at
+		FROM privacy_request_events
+		WHERE request_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(ctx, query, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.RequestID, &e.Action, &e.ActorID, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+func (r *repositoryImpl) AddEvent(ctx context.Context, requestID uuid.UUID, action string, actorID *uuid.UUID, detail *string) error {
+	query := `INSERT INTO privacy_request_events (request_id, action, actor_id, detail) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(ctx, query, requestID, action, actorID, detail)
+	return err
+}
+
+func (r *repositoryImpl) ClaimExport(ctx context.Context, staleAfter time.Duration) (*Request, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		WITH claimed AS (
+			UPDATE privacy_requests
+			SET status = 'running', started_at = NOW(), updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM privacy_requests
+				WHERE kind = 'export' AND (status = 'pending'
+					OR (status = 'running' AND started_at < NOW() - make_interval(secs => $1)))
+				ORDER BY created_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + requestColumns + `
+		FROM claimed r
+		JOIN members m ON m.id = r.member_id
+	`
+	req, err := scanRequest(tx.QueryRow(ctx, query, staleAfter.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := addEvent(ctx, tx, req.ID, ActionStarted, nil, nil); err != nil {
+		return nil, err
+	}
+	return req, tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) CompleteExport(ctx context.Context, id uuid.UUID, storageKey string, fileSize int64, expiresAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE privacy_requests
+		SET status = 'completed', storage_key = $2, file_size = $3, expires_at = $4,
+			error = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, query, id, storageKey, fileSize, expiresAt); err != nil {
+		return err
+	}
+	if err := addEvent(ctx, tx, id, ActionCompleted, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) FailExport(ctx context.Context, id uuid.UUID, message string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE privacy_requests
+		SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, query, id, message); err != nil {
+		return err
+	}
+	if err := addEvent(ctx, tx, id, ActionFailed, nil, &message); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) ExportData(ctx context.Context, member *MemberRef) ([]*Section, error) {
+	result := make([]*Section, 0, len(sections))
+	for _, s := range sections {
+		arg := member.ID
+		if s.byUser {
+			// Members without a login have nothing kept by user.
+			if member.UserID == nil {
+				result = append(result, &Section{Name: s.name, Data: []byte("[]")})
+				continue
+			}
+			arg = *member.UserID
+		}
+		var data []byte
+		if err := r.db.QueryRow(ctx, s.query, arg).Scan(&data); err != nil {
+			return nil, err
+		}
+		result = append(result, &Section{Name: s.name, Data: data})
+	}
+	return result, nil
+}
+
+func (r *repositoryImpl) Blockers(ctx context.Context, memberID uuid.UUID) (int, int, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM subscriptions
+				WHERE member_id = $1 AND status IN ('active', 'past_due') AND end_date >= CURRENT_DATE),
+			(SELECT COUNT(*) FROM invoices WHERE member_id = $1 AND status = 'pending')
+	`
+	var subscriptions, invoices int
+	err := r.db.QueryRow(ctx, query, memberID).Scan(&subscriptions, &invoices)
+	return subscriptions, invoices, err
+}
+
+// erasures clear the personal data the member left around the system. Each
+// takes the member ID. Invoices, subscriptions and check-ins are kept: they
+// carry no personal fields of their own once the member row is anonymized,
+// and invoices must be retained for tax purposes. Signed documents are kept
+// as evidence for legal claims.
+var erasures = []string{
+	`UPDATE members SET
+		first_name = 'Erased', last_name = 'Member', phone = NULL, date_of_birth = NULL, notes = NULL,
+		emergency_contact_name = NULL, emergency_contact_phone = NULL, emergency_contact_relationship = NULL,
+		photo_file_id = NULL, user_id = NULL, anonymized_at = NOW(), deleted_at = COALESCE(deleted_at, NOW()),
+		updated_at = NOW()
+	WHERE id = $1`,
+	`DELETE FROM member_notification_preferences WHERE member_id = $1`,
+	`DELETE FROM member_risk_scores WHERE member_id = $1`,
+	`UPDATE member_requests SET note = NULL, resolution_note = NULL WHERE member_id = $1`,
+	`UPDATE training_sessions SET notes = NULL WHERE member_id = $1`,
+	`UPDATE lead_tasks SET notes = NULL WHERE lead_id IN (SELECT id FROM leads WHERE member_id = $1)`,
+	`UPDATE leads SET email = NULL, lost_reason = NULL WHERE member_id = $1`,
+	`UPDATE member_signups SET email = '', ip_address = NULL, user_agent = NULL WHERE member_id = $1`,
+	// The result file of a bulk import repeats the name and email of every
+	// row it created.
+	`UPDATE member_imports SET
+		results = (
+			SELECT jsonb_agg(CASE WHEN r->>'memberId' = $1::text THEN r - 'email' - 'firstName' - 'lastName' ELSE r END ORDER BY n)
+			FROM jsonb_array_elements(results) WITH ORDINALITY AS e(r, n)
+		),
+		updated_at = NOW()
+	WHERE results @> jsonb_build_array(jsonb_build_object('memberId', $1::text))`,
+}
+
+// userErasures remove the login of an erased member. Each takes the user ID;
+// staff accounts that also had a member profile are only unlinked.
+var userErasures = []string{
+	`DELETE FROM chat_sessions WHERE user_id = $1`,
+	`DELETE FROM refresh_tokens WHERE user_id = $1`,
+	`DELETE FROM password_reset_tokens WHERE user_id = $1`,
+	`UPDATE users SET
+		email = 'erased-' || id::text || '@erased.invalid', encrypted_password = '', first_name = NULL,
+		last_name = NULL, is_active = FALSE, deleted_at = COALESCE(deleted_at, NOW()), updated_at = NOW()
+	WHERE id = $1 AND role = 'member'`,
+}
+
+func (r *repositoryImpl) Erase(ctx context.Context, requestID uuid.UUID, actorID uuid.UUID) (*Erased, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var memberID uuid.UUID
+	lockQuery := `SELECT member_id FROM privacy_requests WHERE id = $1 AND kind = 'erasure' AND status = 'pending' FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, requestID).Scan(&memberID); err != nil {
+		return nil, err
+	}
+
+	var userID *uuid.UUID
+	erased := &Erased{}
+	memberQuery := `SELECT user_id, photo_file_id FROM members WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, memberQuery, memberID).Scan(&userID, &erased.PhotoFileID); err != nil {
+		return nil, err
+	}
+
+	for _, query := range erasures {
+		if _, err := tx.Exec(ctx, query, memberID); err != nil {
+			return nil, err
+		}
+	}
+	if userID != nil {
+		for _, query := range userErasures {
+			if _, err := tx.Exec(ctx, query, *userID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Archives already built hold the data being erased, and exports still
+	// queued would only find the anonymized row.
+	closeQuery := `
+		WITH closed AS (
+			UPDATE privacy_requests
+			SET status = CASE WHEN status = 'completed' THEN 'expired' ELSE 'failed' END::privacy_request_status_enum,
+				error = CASE WHEN status = 'completed' THEN error ELSE 'member data was erased' END,
+				completed_at = COALESCE(completed_at, NOW()), updated_at = NOW()
+			WHERE member_id = $1 AND kind = 'export' AND status IN ('pending', 'running', 'completed')
+			RETURNING id, status, storage_key
+		), events AS (
+			INSERT INTO privacy_request_events (request_id, action, actor_id, detail)
+			SELECT id, CASE WHEN status = 'expired' THEN 'expired' ELSE 'failed' END, $2::uuid, 'member data was erased'
+			FROM closed
+		)
+		SELECT storage_key FROM closed WHERE storage_key IS NOT NULL
+	`
+	rows, err := tx.Query(ctx, closeQuery, memberID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if erased.ArchiveKeys, err = scanKeys(rows); err != nil {
+		return nil, err
+	}
+
+	// Bulk exports finished before the erasure may list the member too. Any
+	// whose filter could have matched them is expired early; the branch
+	// scope is not looked at, so some unrelated ones may go as well.
+	exportsQuery := `
+		UPDATE export_jobs j
+		SET status = 'expired', updated_at = NOW()
+		FROM members m
+		WHERE m.id = $1 AND j.status = 'completed' AND j.storage_key IS NOT NULL
+			AND (j.filter->>'memberId' IS NULL OR j.filter->>'memberId' = m.id::text)
+			AND (j.filter->>'organizationId' IS NULL OR j.filter->>'organizationId' = m.organization_id::text)
+			AND (NOT (j.filter ? 'scopeOrganizationIds') OR j.filter->'scopeOrganizationIds' ? m.organization_id::text)
+		RETURNING j.storage_key
+	`
+	rows, err = tx.Query(ctx, exportsQuery, memberID)
+	if err != nil {
+		return nil, err
+	}
+	exportKeys, err := scanKeys(rows)
+	if err != nil {
+		return nil, err
+	}
+	erased.ArchiveKeys = append(erased.ArchiveKeys, exportKeys...)
+
+	completeQuery := `
+		UPDATE privacy_requests
+		SET status = 'completed', completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, completeQuery, requestID); err != nil {
+		return nil, err
+	}
+	if err := addEvent(ctx, tx, requestID, ActionCompleted, &actorID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return erased, nil
+}
+
+// scanKeys reads a single column of storage keys and closes rows.
+func scanKeys(rows pgx.Rows) ([]string, error) {
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *repositoryImpl) Reject(ctx context.Context, requestID uuid.UUID, actorID uuid.UUID, reason string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE privacy_requests
+		SET status = 'rejected', error = $2, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND kind = 'erasure' AND status = 'pending'
+	`
+	tag, err := tx.Exec(ctx, query, requestID, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := addEvent(ctx, tx, requestID, ActionRejected, &actorID, &reason); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) ExpireExports(ctx context.Context) ([]string, error) {
+	query := `
+		WITH expired AS (
+			UPDATE privacy_requests
+			SET status = 'expired', updated_at = NOW()
+			WHERE kind = 'export' AND status = 'completed' AND expires_at < NOW()
+			RETURNING id, storage_key
+		), events AS (
+			INSERT INTO privacy_request_events (request_id, action)
+			SELECT id, 'expired' FROM expired
+		)
+		SELECT storage_key FROM expired
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key *string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,460 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"time"
+
+	"fitcore/internal/modules/cache"
+	"fitcore/internal/modules/files"
+	"fitcore/internal/modules/user"
+	"fitcore/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// archiveRetention is how long a finished export can be downloaded.
+	archiveRetention = 7 * 24 * time.Hour
+	// exportTimeout bounds building one archive; an export running longer
+	// than this is assumed dead and claimed again.
+	exportTimeout      = 30 * time.Minute
+	exportPollInterval = time.Minute
+)
+
+var (
+	ErrMemberNotFound  = errors.New("member not found")
+	ErrRequestNotFound = errors.New("privacy request not found")
+	ErrRequestOpen     = errors.New("a request of this kind is already open for the member")
+	ErrAlreadyErased   = errors.New("member data has already been erased")
+	ErrNotErasure      = errors.New("only erasure requests are reviewed")
+	ErrNotPending      = errors.New("request is no longer pending")
+	ErrErasureBlocked  = errors.New("member has an active subscription or unpaid invoices")
+	ErrArchiveNotReady = errors.New("export is not ready for download")
+)
+
+// Service handles data subject requests. A member, or an admin on their
+// behalf, asks for an export or an erasure. Exports are built in the
+// background into a zip archive of JSON files; erasures wait for an admin,
+// who approves them once nothing is owed. Every step is recorded in the
+// request's audit trail.
+type Service interface {
+	RequestMine(ctx context.Context, userID uuid.UUID, req *CreateRequestRequest) (*Request, error)
+	ListMine(ctx context.Context, userID uuid.UUID) ([]*Request, error)
+	GetMine(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Request, []*Event, error)
+	// OpenMine opens the archive of a completed export of the member.
+	OpenMine(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Request, io.ReadCloser, error)
+
+	RequestForMember(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID, req *CreateRequestRequest) (*Request, error)
+	List(ctx context.Context, userID uuid.UUID, userRole string, filter *ListFilter) ([]*Request, error)
+	Get(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Request, []*Event, error)
+	Open(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Request, io.ReadCloser, error)
+	// Approve carries out a pending erasure: personal fields are anonymized
+	// while invoices and other financial records are kept.
+	Approve(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Request, error)
+	Reject(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, reason string) (*Request, error)
+
+	// Run builds queued exports until ctx is cancelled.
+	Run(ctx context.Context)
+	// Cleanup expires finished exports and deletes their archives; it is the
+	// scheduled job.
+	Cleanup(ctx context.Context) error
+}
+
+type serviceImpl struct {
+	repo     Repository
+	userSvc  user.Service
+	filesSvc files.Service
+	cacheSvc cache.Service
+	store    storage.Storage
+	// wake nudges Run when an export is requested instead of waiting for the
+	// poll.
+	wake chan struct{}
+}
+
+func NewService(repo Repository, userSvc user.Service, filesSvc files.Service, cacheSvc cache.Service, store storage.Storage) Service {
+	return &serviceImpl{
+		repo:     repo,
+		userSvc:  userSvc,
+		filesSvc: filesSvc,
+		cacheSvc: cacheSvc,
+		store:    store,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// organizationScope returns the organizations the caller administers; nil
+// means every organization.
+func (s *serviceImpl) organizationScope(ctx context.Context, userID uuid.UUID, userRole string) ([]uuid.UUID, error) {
+	if userRole == "super_admin" {
+		return nil, nil
+	}
+	orgIDs, err := s.userSvc.GetUserOrganizationIDs(ctx, userID)
+	if err != nil {
+		log.Printf("Service: failed to list organizations for user %s: %v", userID, err)
+		return nil, err
+	}
+	return orgIDs, nil
+}
+
+// request loads a request the caller may see; others are reported as
+// missing.
+func (s *serviceImpl) request(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Request, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.repo.GetRequest(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if orgIDs != nil && !slices.Contains(orgIDs, req.OrganizationID) {
+		return nil, ErrRequestNotFound
+	}
+	return req, nil
+}
+
+// mine loads a request of the caller's own member profile.
+func (s *serviceImpl) mine(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Request, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.repo.GetRequest(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && req.MemberID != m.ID) {
+		return nil, ErrRequestNotFound
+	}
+	return req, err
+}
+
+func (s *serviceImpl) me(ctx context.Context, userID uuid.UUID) (*MemberRef, error) {
+	m, err := s.repo.GetMemberByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	return m, err
+}
+
+func (s *serviceImpl) RequestMine(ctx context.Context, userID uuid.UUID, req *CreateRequestRequest) (*Request, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, m, userID, req)
+}
+
+func (s *serviceImpl) ListMine(ctx context.Context, userID uuid.UUID) ([]*Request, error) {
+	m, err := s.me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListRequests(ctx, &ListFilter{MemberID: &m.ID, Page: 1, Limit: 100})
+}
+
+func (s *serviceImpl) GetMine(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Request, []*Event, error) {
+	req, err := s.mine(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	events, err := s.repo.ListEvents(ctx, req.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, events, nil
+}
+
+func (s *serviceImpl) OpenMine(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*Request, io.ReadCloser, error) {
+	req, err := s.mine(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, req, userID)
+}
+
+func (s *serviceImpl) RequestForMember(ctx context.Context, userID uuid.UUID, userRole string, memberID uuid.UUID, req *CreateRequestRequest) (*Request, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.repo.GetMember(ctx, memberID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && orgIDs != nil && !slices.Contains(orgIDs, m.OrganizationID)) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, m, userID, req)
+}
+
+func (s *serviceImpl) create(ctx context.Context, m *MemberRef, userID uuid.UUID, in *CreateRequestRequest) (*Request, error) {
+	if m.AnonymizedAt != nil {
+		return nil, ErrAlreadyErased
+	}
+	req := &Request{
+		OrganizationID: m.OrganizationID,
+		MemberID:       m.ID,
+		Kind:           Kind(in.Kind),
+		RequestedBy:    &userID,
+		Reason:         in.Reason,
+	}
+	if err := s.repo.CreateRequest(ctx, req); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrRequestOpen
+		}
+		log.Printf("Service: failed to create %s request for member %s: %v", in.Kind, m.ID, err)
+		return nil, err
+	}
+
+	if req.Kind == KindExport {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	log.Printf("Service: Privacy request %s queued (%s, member %s)", req.ID, req.Kind, m.ID)
+	return s.repo.GetRequest(ctx, req.ID)
+}
+
+func (s *serviceImpl) List(ctx context.Context, userID uuid.UUID, userRole string, filter *ListFilter) ([]*Request, error) {
+	orgIDs, err := s.organizationScope(ctx, userID, userRole)
+	if err != nil {
+		return nil, err
+	}
+	filter.OrganizationIDs = orgIDs
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return s.repo.ListRequests(ctx, filter)
+}
+
+func (s *serviceImpl) Get(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Request, []*Event, error) {
+	req, err := s.request(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	events, err := s.repo.ListEvents(ctx, req.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, events, nil
+}
+
+func (s *serviceImpl) Open(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Request, io.ReadCloser, error) {
+	req, err := s.request(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, req, userID)
+}
+
+// open streams the archive of a completed export and records who took it.
+func (s *serviceImpl) open(ctx context.Context, req *Request, userID uuid.UUID) (*Request, io.ReadCloser, error) {
+	if req.Kind != KindExport || req.Status != StatusCompleted || req.StorageKey == nil {
+		return nil, nil, ErrArchiveNotReady
+	}
+	body, err := s.store.Get(ctx, *req.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Service: archive missing for privacy request %s", req.ID)
+		return nil, nil, ErrArchiveNotReady
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.AddEvent(ctx, req.ID, ActionDownloaded, &userID, nil); err != nil {
+		log.Printf("Service: failed to record download of privacy request %s: %v", req.ID, err)
+	}
+	return req, body, nil
+}
+
+func (s *serviceImpl) Approve(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID) (*Request, error) {
+	req, err := s.request(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Kind != KindErasure {
+		return nil, ErrNotErasure
+	}
+	if req.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+
+	// What is still owed or running has to be settled first; erasing would
+	// leave it without a person to bill.
+	subscriptions, invoices, err := s.repo.Blockers(ctx, req.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	if subscriptions > 0 || invoices > 0 {
+		return nil, fmt.Errorf("%w: %d active subscriptions, %d unpaid invoices", ErrErasureBlocked, subscriptions, invoices)
+	}
+
+	erased, err := s.repo.Erase(ctx, req.ID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotPending
+	}
+	if err != nil {
+		log.Printf("Service: failed to erase member %s for request %s: %v", req.MemberID, req.ID, err)
+		return nil, err
+	}
+	log.Printf("Service: Member %s erased by user %s (request %s)", req.MemberID, userID, req.ID)
+
+	// The database no longer points at these; failures leave orphaned
+	// objects behind, so they are logged.
+	if erased.PhotoFileID != nil {
+		if err := s.filesSvc.Delete(ctx, *erased.PhotoFileID); err != nil && !errors.Is(err, files.ErrFileNotFound) {
+			log.Printf("Service: failed to delete photo %s of erased member %s: %v", *erased.PhotoFileID, req.MemberID, err)
+		}
+	}
+	s.deleteArchives(ctx, erased.ArchiveKeys)
+	if err := s.cacheSvc.InvalidateTag(ctx, cache.MemberTag(req.MemberID)); err != nil {
+		log.Printf("Service: failed to invalidate cache of erased member %s: %v", req.MemberID, err)
+	}
+
+	return s.repo.GetRequest(ctx, req.ID)
+}
+
+func (s *serviceImpl) Reject(ctx context.Context, userID uuid.UUID, userRole string, id uuid.UUID, reason string) (*Request, error) {
+	req, err := s.request(ctx, userID, userRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Kind != KindErasure {
+		return nil, ErrNotErasure
+	}
+	if err := s.repo.Reject(ctx, req.ID, userID, reason); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotPending
+		}
+		return nil, err
+	}
+	return s.repo.GetRequest(ctx, req.ID)
+}
+
+func (s *serviceImpl) deleteArchives(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Service: failed to delete export file %s: %v", key, err)
+		}
+	}
+}
+
+func (s *serviceImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+
+	log.Printf("Service: Privacy export worker started")
+	for {
+		s.drain(ctx)
+		select {
+		case <-ctx.Done():
+			log.Printf("Service: Privacy export worker stopped")
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain builds queued exports one at a time until none are left.
+func (s *serviceImpl) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		req, err := s.repo.ClaimExport(ctx, exportTimeout)
+		if err != nil {
+			log.Printf("Service: Privacy export worker failed to claim a request: %v", err)
+			return
+		}
+		if req == nil {
+			return
+		}
+		s.runExport(ctx, req)
+	}
+}
+
+func (s *serviceImpl) runExport(ctx context.Context, req *Request) {
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	started := time.Now()
+	key := fmt.Sprintf("%s/privacy/%s.zip", req.OrganizationID, req.ID)
+	size, err := s.writeArchive(ctx, req, key)
+	if err != nil {
+		log.Printf("Service: Privacy export %s failed: %v", req.ID, err)
+		if err := s.repo.FailExport(context.WithoutCancel(ctx), req.ID, "export could not be built"); err != nil {
+			log.Printf("Service: Privacy export %s could not be marked failed: %v", req.ID, err)
+		}
+		return
+	}
+
+	if err := s.repo.CompleteExport(ctx, req.ID, key, size, time.Now().Add(archiveRetention)); err != nil {
+		log.Printf("Service: Privacy export %s could not be marked completed: %v", req.ID, err)
+		s.deleteArchives(context.WithoutCancel(ctx), []string{key})
+		return
+	}
+	log.Printf("Service: Privacy export %s built in %s", req.ID, time.Since(started).Round(time.Millisecond))
+}
+
+// writeArchive stores a zip with one indented JSON file per section and
+// returns its size.
+func (s *serviceImpl) writeArchive(ctx context.Context, req *Request, key string) (int64, error) {
+	m, err := s.repo.GetMember(ctx, req.MemberID)
+	if err != nil {
+		return 0, err
+	}
+	sections, err := s.repo.ExportData(ctx, m)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, section := range sections {
+		w, err := zw.Create(section.Name + ".json")
+		if err != nil {
+			return 0, err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, section.Data, "", "  "); err != nil {
+			return 0, err
+		}
+		if _, err := w.Write(indented.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	size := int64(buf.Len())
+	if err := s.store.Put(ctx, key, &buf, size, "application/zip"); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *serviceImpl) Cleanup(ctx context.Context) error {
+	keys, err := s.repo.ExpireExports(ctx)
+	if err != nil {
+		return err
+	}
+	s.deleteArchives(ctx, keys)
+	if len(keys) > 0 {
+		log.Printf("Service: Privacy cleanup expired %d exports", len(keys))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package reports
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GranularityDay  = "day"
+	GranularityWeek = "week"
+)
+
+// ReportFilter selects branch-local dates from From to To inclusive.
+// BranchIDs nil means every branch.
+type ReportFilter struct {
+	BranchIDs   []uuid.UUID
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+type AttendancePointResponse struct {
+	Period             string  `json:"period"`
+	Visits             int     `json:"visits"`
+	UniqueMembers      int     `json:"uniqueMembers"`
+	AvgDurationMinutes float64 `json:"avgDurationMinutes"`
+}
+
+type AttendanceReportResponse struct {
+	From        string                     `json:"from"`
+	To          string                     `json:"to"`
+	Granularity string                     `json:"granularity"`
+	Summary     *AttendancePointResponse   `json:"summary"`
+	Series      []*AttendancePointResponse `json:"series"`
+	DataAsOf    *time.Time                 `json:"dataAsOf,omitempty"`
+}
+
+type VisitorPointResponse struct {
+	Period    string `json:"period"`
+	New       int    `json:"new"`
+	Returning int    `json:"returning"`
+}
+
+type VisitorReportResponse struct {
+	From        string                  `json:"from"`
+	To          string                  `json:"to"`
+	Granularity string                  `json:"granularity"`
+	New         int                     `json:"new"`
+	Returning   int                     `json:"returning"`
+	Series      []*VisitorPointResponse `json:"series"`
+	DataAsOf    *time.Time              `json:"dataAsOf,omitempty"`
+}
+
+type HeatmapCellResponse struct {
+	DayOfWeek int `json:"dayOfWeek"`
+	Hour      int `json:"hour"`
+	Visits    int `json:"visits"`
+	// AvgVisits is Visits divided by how often the weekday occurs in range.
+	AvgVisits float64 `json:"avgVisits"`
+}
+
+type PeakHoursReportResponse struct {
+	From     string                 `json:"from"`
+	To       string                 `json:"to"`
+	Cells    []*HeatmapCellResponse `json:"cells"`
+	Peak     *HeatmapCellResponse   `json:"peak,omitempty"`
+	DataAsOf *time.Time             `json:"dataAsOf,omitempty"`
+}
+
+type RetentionPointResponse struct {
+	MonthOffset   int     `json:"monthOffset"`
+	ActiveMembers int     `json:"activeMembers"`
+	Rate          float64 `json:"rate"`
+}
+
+type RetentionCohortResponse struct {
+	Cohort    string                    `json:"cohort"`
+	Size      int                       `json:"size"`
+	Retention []*RetentionPointResponse `json:"retention"`
+}
+
+type RetentionReportResponse struct {
+	From     string                     `json:"from"`
+	To       string                     `json:"to"`
+	Cohorts  []*RetentionCohortResponse `json:"cohorts"`
+	DataAsOf *time.Time                 `json:"dataAsOf,omitempty"`
+}
+
+type RefreshResponse struct {
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
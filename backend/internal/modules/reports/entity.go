@@ -0,0 +1,46 @@
+package reports
+
+import "time"
+
+// AttendancePoint aggregates visits over one day or week, in branch-local
+// dates.
+type AttendancePoint struct {
+	Period          time.Time
+	Visits          int
+	UniqueMembers   int
+	CompletedVisits int
+	TotalMinutes    float64
+}
+
+// AvgDurationMinutes averages over visits with a recorded check-out.
+func (p *AttendancePoint) AvgDurationMinutes() float64 {
+	if p.CompletedVisits == 0 {
+		return 0
+	}
+	return round1(p.TotalMinutes / float64(p.CompletedVisits))
+}
+
+// VisitorPoint splits a period's visitors by whether it contains their first
+// visit to the branch.
+type VisitorPoint struct {
+	Period    time.Time
+	New       int
+	Returning int
+}
+
+// HourlyVisits is the number of check-ins in one hour of one ISO weekday
+// (1 = Monday) over the report range.
+type HourlyVisits struct {
+	DayOfWeek int
+	Hour      int
+	Visits    int
+}
+
+// CohortActivity is how many members of a join-month cohort visited in the
+// month MonthOffset months after joining. MonthOffset is nil on the row
+// carrying the cohort size.
+type CohortActivity struct {
+	CohortMonth time.Time
+	MonthOffset *int
+	Members     int
+}
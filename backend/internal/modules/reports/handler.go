@@ -0,0 +1,181 @@
+package reports
+
+import (
+	"net/http"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/modules/user"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+	userSvc user.Service
+}
+
+func NewHandler(service Service, userSvc user.Service) *Handler {
+	return &Handler{service: service, userSvc: userSvc}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/reports", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin", "staff"))
+			r.Get("/attendance", h.GetAttendance)
+			r.Get("/visitors", h.GetVisitors)
+			r.Get("/peak-hours", h.GetPeakHours)
+			r.Get("/retention", h.GetRetention)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Post("/refresh", h.Refresh)
+		})
+	})
+}
+
+// GetAttendance returns visits, unique members and average session duration
+// per day or week. Query: branchId, from, to (YYYY-MM-DD, branch-local,
+// default last 30 days), granularity (day|week).
+func (h *Handler) GetAttendance(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r, time.Time{})
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Attendance(r.Context(), filter)
+	if err != nil {
+		response.InternalServerError(w, "Failed to build attendance report")
+		return
+	}
+	response.Success(w, "Attendance report retrieved successfully", report)
+}
+
+// GetVisitors splits visitors into first-time and returning per period.
+func (h *Handler) GetVisitors(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r, time.Time{})
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Visitors(r.Context(), filter)
+	if err != nil {
+		response.InternalServerError(w, "Failed to build visitors report")
+		return
+	}
+	response.Success(w, "Visitors report retrieved successfully", report)
+}
+
+// GetPeakHours returns check-ins by weekday and hour in each branch's
+// timezone.
+func (h *Handler) GetPeakHours(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r, time.Time{})
+	if !ok {
+		return
+	}
+
+	report, err := h.service.PeakHours(r.Context(), filter)
+	if err != nil {
+		response.InternalServerError(w, "Failed to build peak hours report")
+		return
+	}
+	response.Success(w, "Peak hours report retrieved successfully", report)
+}
+
+// GetRetention returns retention by join-month cohort. from/to select join
+// dates and default to the last 12 months.
+func (h *Handler) GetRetention(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	defaultFrom := time.Date(now.Year()-1, now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	filter, ok := h.parseFilter(w, r, defaultFrom)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Retention(r.Context(), filter)
+	if err != nil {
+		response.InternalServerError(w, "Failed to build retention report")
+		return
+	}
+	response.Success(w, "Retention report retrieved successfully", report)
+}
+
+// Refresh rebuilds the report views now instead of waiting for the job.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Refresh(r.Context())
+	if err != nil {
+		response.InternalServerError(w, "Failed to refresh reports")
+		return
+	}
+	response.Success(w, "Reports refreshed successfully", result)
+}
+
+// parseFilter reads the shared query parameters and applies the caller's
+// branch scope. defaultFrom is used when from is absent, if set.
+func (h *Handler) parseFilter(w http.ResponseWriter, r *http.Request, defaultFrom time.Time) (*ReportFilter, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return nil, false
+	}
+
+	userRole, _ := claims["role"].(string)
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return nil, false
+	}
+
+	query := r.URL.Query()
+	filter := &ReportFilter{From: defaultFrom, Granularity: query.Get("granularity")}
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(dateLayout, v); err != nil {
+			response.BadRequest(w, "Invalid from parameter", nil)
+			return nil, false
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(dateLayout, v); err != nil {
+			response.BadRequest(w, "Invalid to parameter", nil)
+			return nil, false
+		}
+	}
+
+	var branchID *uuid.UUID
+	if v := query.Get("branchId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.BadRequest(w, "Invalid branchId parameter", nil)
+			return nil, false
+		}
+		branchID = &id
+	}
+
+	var userBranchIDs []uuid.UUID
+	if userRole == "staff" {
+		userBranchIDs, err = h.userSvc.GetUserBranchIDs(r.Context(), userID, userRole)
+		if err != nil {
+			response.InternalServerError(w, "Failed to get user branches")
+			return nil, false
+		}
+	}
+
+	if err := h.service.ScopeFilter(filter, branchID, userRole, userBranchIDs); err != nil {
+		switch err {
+		case ErrBranchAccess:
+			response.Forbidden(w, err.Error())
+		default:
+			response.BadRequest(w, err.Error(), nil)
+		}
+		return nil, false
+	}
+	return filter, true
+}
@@ -0,0 +1,240 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// reportViews are refreshed in this order by Refresh.
+var reportViews = []string{"report_member_daily_visits", "report_hourly_visits"}
+
+type Repository interface {
+	AttendanceSeries(ctx context.Context, filter *ReportFilter) ([]*AttendancePoint, error)
+	AttendanceSummary(ctx context.Context, filter *ReportFilter) (*AttendancePoint, error)
+	VisitorSeries(ctx context.Context, filter *ReportFilter) ([]*VisitorPoint, error)
+	HourlyVisits(ctx context.Context, filter *ReportFilter) ([]*HourlyVisits, error)
+	// CohortActivity groups members by join month (From to To) and counts
+	// the ones visiting in each following month, up to maxOffset months.
+	CohortActivity(ctx context.Context, filter *ReportFilter, maxOffset int) ([]*CohortActivity, error)
+
+	// Refresh rebuilds the report views and returns when it finished.
+	Refresh(ctx context.Context) (time.Time, error)
+	// RefreshedAt returns the oldest refresh time across the views.
+	RefreshedAt(ctx context.Context) (*time.Time, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+// branchIDsParam maps a nil filter (all branches) to SQL NULL.
+func branchIDsParam(ids []uuid.UUID) any {
+	if ids == nil {
+		return nil
+	}
+	return ids
+}
+
+func (r *repositoryImpl) AttendanceSeries(ctx context.Context, filter *ReportFilter) ([]*AttendancePoint, error) {
+	query := `
+		SELECT
+			date_trunc($4, day)::date AS period,
+			SUM(visits),
+			COUNT(DISTINCT member_id),
+			SUM(completed_visits),
+			SUM(total_minutes)
+		FROM report_member_daily_visits
+		WHERE ($1::uuid[] IS NULL OR branch_id = ANY($1))
+			AND day BETWEEN $2 AND $3
+		GROUP BY period
+		ORDER BY period
+	`
+	rows, err := r.db.Query(ctx, query, branchIDsParam(filter.BranchIDs), filter.From, filter.To, filter.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []*AttendancePoint
+	for rows.Next() {
+		var p AttendancePoint
+		if err := rows.Scan(&p.Period, &p.Visits, &p.UniqueMembers, &p.CompletedVisits, &p.TotalMinutes); err != nil {
+			return nil, err
+		}
+		points = append(points, &p)
+	}
+	return points, rows.Err()
+}
+
+func (r *repositoryImpl) AttendanceSummary(ctx context.Context, filter *ReportFilter) (*AttendancePoint, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(visits), 0),
+			COUNT(DISTINCT member_id),
+			COALESCE(SUM(completed_visits), 0),
+			COALESCE(SUM(total_minutes), 0)
+		FROM report_member_daily_visits
+		WHERE ($1::uuid[] IS NULL OR branch_id = ANY($1))
+			AND day BETWEEN $2 AND $3
+	`
+	p := AttendancePoint{Period: filter.From}
+	err := r.db.QueryRow(ctx, query, branchIDsParam(filter.BranchIDs), filter.From, filter.To).
+		Scan(&p.Visits, &p.UniqueMembers, &p.CompletedVisits, &p.TotalMinutes)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// VisitorSeries counts a member as new in the period holding their first
+// visit to the branch, so a member can be new at one branch and returning at
+// another.
+func (r *repositoryImpl) VisitorSeries(ctx context.Context, filter *ReportFilter) ([]*VisitorPoint, error) {
+	query := `
+		WITH firsts AS (
+			SELECT branch_id, member_id, date_trunc($4, MIN(day))::date AS first_period
+			FROM report_member_daily_visits
+			WHERE ($1::uuid[] IS NULL OR branch_id = ANY($1))
+				AND day <= $3
+			GROUP BY branch_id, member_id
+		), visits AS (
+			SELECT DISTINCT branch_id, member_id, date_trunc($4, day)::date AS period
+			FROM report_member_daily_visits
+			WHERE ($1::uuid[] IS NULL OR branch_id = ANY($1))
+				AND day BETWEEN $2 AND $3
+		)
+		SELECT
+			v.period,
+			COUNT(DISTINCT v.member_id) FILTER (WHERE f.first_period = v.period),
+			COUNT(DISTINCT v.member_id) FILTER (WHERE f.first_period < v.period)
+		FROM visits v
+		JOIN firsts f ON f.branch_id = v.branch_id AND f.member_id = v.member_id
+		GROUP BY v.period
+		ORDER BY v.period
+	`
+	rows, err := r.db.Query(ctx, query, branchIDsParam(filter.BranchIDs), filter.From, filter.To, filter.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []*VisitorPoint
+	for rows.Next() {
+		var p VisitorPoint
+		if err := rows.Scan(&p.Period, &p.New, &p.Returning); err != nil {
+			return nil, err
+		}
+		points = append(points, &p)
+	}
+	return points, rows.Err()
+}
+
+func (r *repositoryImpl) HourlyVisits(ctx context.Context, filter *ReportFilter) ([]*HourlyVisits, error) {
+	query := `
+		SELECT EXTRACT(ISODOW FROM day)::int, hour, SUM(visits)
+		FROM report_hourly_visits
+		WHERE ($1::uuid[] IS NULL OR branch_id = ANY($1))
+			AND day BETWEEN $2 AND $3
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	rows, err := r.db.Query(ctx, query, branchIDsParam(filter.BranchIDs), filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hours []*HourlyVisits
+	for rows.Next() {
+		var h HourlyVisits
+		if err := rows.Scan(&h.DayOfWeek, &h.Hour, &h.Visits); err != nil {
+			return nil, err
+		}
+		hours = append(hours, &h)
+	}
+	return hours, rows.Err()
+}
+
+func (r *repositoryImpl) CohortActivity(ctx context.Context, filter *ReportFilter, maxOffset int) ([]*CohortActivity, error) {
+	query := `
+		WITH cohort AS (
+			SELECT m.id, date_trunc('month', COALESCE(m.join_date, m.created_at::date))::date AS cohort_month
+			FROM members m
+			WHERE m.deleted_at IS NULL
+				AND ($1::uuid[] IS NULL OR m.home_branch_id = ANY($1))
+				AND COALESCE(m.join_date, m.created_at::date) BETWEEN $2 AND $3
+		), activity AS (
+			SELECT DISTINCT
+				c.id AS member_id,
+				((EXTRACT(YEAR FROM v.day) - EXTRACT(YEAR FROM c.cohort_month)) * 12
+					+ EXTRACT(MONTH FROM v.day) - EXTRACT(MONTH FROM c.cohort_month))::int AS month_offset
+			FROM cohort c
+			JOIN report_member_daily_visits v ON v.member_id = c.id AND v.day >= c.cohort_month
+		)
+		SELECT c.cohort_month, a.month_offset, GROUPING(a.month_offset) = 1, COUNT(DISTINCT c.id)
+		FROM cohort c
+		LEFT JOIN activity a ON a.member_id = c.id AND a.month_offset <= $4
+		GROUP BY GROUPING SETS ((c.cohort_month), (c.cohort_month, a.month_offset))
+		ORDER BY 1, 2 NULLS FIRST
+	`
+	rows, err := r.db.Query(ctx, query, branchIDsParam(filter.BranchIDs), filter.From, filter.To, maxOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []*CohortActivity
+	for rows.Next() {
+		var a CohortActivity
+		var isTotal bool
+		if err := rows.Scan(&a.CohortMonth, &a.MonthOffset, &isTotal, &a.Members); err != nil {
+			return nil, err
+		}
+		// Members without any visit form a NULL group of their own.
+		if !isTotal && a.MonthOffset == nil {
+			continue
+		}
+		activity = append(activity, &a)
+	}
+	return activity, rows.Err()
+}
+
+func (r *repositoryImpl) Refresh(ctx context.Context) (time.Time, error) {
+	for _, view := range reportViews {
+		if _, err := r.db.Exec(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	var refreshedAt time.Time
+	query := `
+		INSERT INTO report_refreshes (view_name, refreshed_at)
+		SELECT unnest($1::text[]), NOW()
+		ON CONFLICT (view_name) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at
+		RETURNING refreshed_at
+	`
+	rows, err := r.db.Query(ctx, query, reportViews)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.Scan(&refreshedAt); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return refreshedAt, rows.Err()
+}
+
+func (r *repositoryImpl) RefreshedAt(ctx context.Context) (*time.Time, error) {
+	var refreshedAt *time.Time
+	err := r.db.QueryRow(ctx, `SELECT MIN(refreshed_at) FROM report_refreshes WHERE view_name = ANY($1)`, reportViews).Scan(&refreshedAt)
+	return refreshedAt, err
+}
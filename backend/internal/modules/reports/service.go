@@ -0,0 +1,297 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	monthLayout = "2006-01"
+
+	defaultRangeDays = 30
+	// maxRangeDays bounds a report to a bit over a year.
+	maxRangeDays = 366
+
+	// maxCohortMonths is how many months after joining retention is tracked.
+	maxCohortMonths = 12
+)
+
+var (
+	ErrBranchAccess       = errors.New("branch is outside your assignments")
+	ErrInvalidRange       = errors.New("from must not be after to, and the range must not exceed 366 days")
+	ErrInvalidGranularity = errors.New("granularity must be day or week")
+)
+
+type Service interface {
+	Attendance(ctx context.Context, filter *ReportFilter) (*AttendanceReportResponse, error)
+	Visitors(ctx context.Context, filter *ReportFilter) (*VisitorReportResponse, error)
+	PeakHours(ctx context.Context, filter *ReportFilter) (*PeakHoursReportResponse, error)
+	Retention(ctx context.Context, filter *ReportFilter) (*RetentionReportResponse, error)
+
+	// Refresh rebuilds the report views; it is also the scheduled job.
+	Refresh(ctx context.Context) (*RefreshResponse, error)
+
+	// ScopeFilter applies the caller's branch scope and fills in defaults.
+	// branchID is the optional branch requested.
+	ScopeFilter(filter *ReportFilter, branchID *uuid.UUID, userRole string, userBranchIDs []uuid.UUID) error
+}
+
+type serviceImpl struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &serviceImpl{repo: repo}
+}
+
+func (s *serviceImpl) ScopeFilter(filter *ReportFilter, branchID *uuid.UUID, userRole string, userBranchIDs []uuid.UUID) error {
+	switch {
+	case userRole == "staff" && branchID != nil:
+		if !slices.Contains(userBranchIDs, *branchID) {
+			return ErrBranchAccess
+		}
+		filter.BranchIDs = []uuid.UUID{*branchID}
+	case userRole == "staff":
+		// Staff without assignments see nothing rather than everything.
+		filter.BranchIDs = append([]uuid.UUID{}, userBranchIDs...)
+	case branchID != nil:
+		filter.BranchIDs = []uuid.UUID{*branchID}
+	}
+
+	if filter.To.IsZero() {
+		filter.To = today()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(0, 0, -(defaultRangeDays - 1))
+	}
+	if filter.From.After(filter.To) || filter.To.Sub(filter.From) > maxRangeDays*24*time.Hour {
+		return ErrInvalidRange
+	}
+
+	switch filter.Granularity {
+	case "":
+		filter.Granularity = GranularityDay
+	case GranularityDay, GranularityWeek:
+	default:
+		return ErrInvalidGranularity
+	}
+	return nil
+}
+
+func (s *serviceImpl) Attendance(ctx context.Context, filter *ReportFilter) (*AttendanceReportResponse, error) {
+	report := &AttendanceReportResponse{
+		From:        filter.From.Format(dateLayout),
+		To:          filter.To.Format(dateLayout),
+		Granularity: filter.Granularity,
+		Series:      []*AttendancePointResponse{},
+	}
+	if isEmptyScope(filter) {
+		report.Summary = &AttendancePointResponse{Period: report.From}
+		return report, nil
+	}
+
+	summary, err := s.repo.AttendanceSummary(ctx, filter)
+	if err != nil {
+		log.Printf("Service: Attendance report failed - summary: %v", err)
+		return nil, err
+	}
+	series, err := s.repo.AttendanceSeries(ctx, filter)
+	if err != nil {
+		log.Printf("Service: Attendance report failed - series: %v", err)
+		return nil, err
+	}
+
+	report.Summary = attendancePointResponse(summary)
+	for _, p := range series {
+		report.Series = append(report.Series, attendancePointResponse(p))
+	}
+	report.DataAsOf = s.dataAsOf(ctx)
+	return report, nil
+}
+
+func (s *serviceImpl) Visitors(ctx context.Context, filter *ReportFilter) (*VisitorReportResponse, error) {
+	report := &VisitorReportResponse{
+		From:        filter.From.Format(dateLayout),
+		To:          filter.To.Format(dateLayout),
+		Granularity: filter.Granularity,
+		Series:      []*VisitorPointResponse{},
+	}
+	if isEmptyScope(filter) {
+		return report, nil
+	}
+
+	series, err := s.repo.VisitorSeries(ctx, filter)
+	if err != nil {
+		log.Printf("Service: Visitors report failed: %v", err)
+		return nil, err
+	}
+	for _, p := range series {
+		report.New += p.New
+		report.Returning += p.Returning
+		report.Series = append(report.Series, &VisitorPointResponse{
+			Period:    p.Period.Format(dateLayout),
+			New:       p.New,
+			Returning: p.Returning,
+		})
+	}
+	report.DataAsOf = s.dataAsOf(ctx)
+	return report, nil
+}
+
+// PeakHours returns a full 7x24 grid (Monday first) of check-ins by
+// branch-local weekday and hour.
+func (s *serviceImpl) PeakHours(ctx context.Context, filter *ReportFilter) (*PeakHoursReportResponse, error) {
+	report := &PeakHoursReportResponse{
+		From: filter.From.Format(dateLayout),
+		To:   filter.To.Format(dateLayout),
+	}
+
+	var hours []*HourlyVisits
+	if !isEmptyScope(filter) {
+		var err error
+		hours, err = s.repo.HourlyVisits(ctx, filter)
+		if err != nil {
+			log.Printf("Service: PeakHours report failed: %v", err)
+			return nil, err
+		}
+		report.DataAsOf = s.dataAsOf(ctx)
+	}
+
+	var grid [7][24]int
+	for _, h := range hours {
+		if h.DayOfWeek >= 1 && h.DayOfWeek <= 7 && h.Hour >= 0 && h.Hour < 24 {
+			grid[h.DayOfWeek-1][h.Hour] = h.Visits
+		}
+	}
+	occurrences := weekdayOccurrences(filter.From, filter.To)
+
+	report.Cells = make([]*HeatmapCellResponse, 0, 7*24)
+	for day := range 7 {
+		for hour := range 24 {
+			cell := &HeatmapCellResponse{DayOfWeek: day + 1, Hour: hour, Visits: grid[day][hour]}
+			if occurrences[day] > 0 {
+				cell.AvgVisits = round1(float64(cell.Visits) / float64(occurrences[day]))
+			}
+			report.Cells = append(report.Cells, cell)
+			if cell.Visits > 0 && (report.Peak == nil || cell.Visits > report.Peak.Visits) {
+				report.Peak = cell
+			}
+		}
+	}
+	return report, nil
+}
+
+// Retention groups members by the month they joined (From to To) and reports,
+// for each month since, the share that visited at least once.
+func (s *serviceImpl) Retention(ctx context.Context, filter *ReportFilter) (*RetentionReportResponse, error) {
+	report := &RetentionReportResponse{
+		From:    filter.From.Format(dateLayout),
+		To:      filter.To.Format(dateLayout),
+		Cohorts: []*RetentionCohortResponse{},
+	}
+	if isEmptyScope(filter) {
+		return report, nil
+	}
+
+	activity, err := s.repo.CohortActivity(ctx, filter, maxCohortMonths)
+	if err != nil {
+		log.Printf("Service: Retention report failed: %v", err)
+		return nil, err
+	}
+
+	now := today()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var cohort *RetentionCohortResponse
+	for _, a := range activity {
+		if a.MonthOffset == nil {
+			// Offsets up to the current month, with zeros for months
+			// without visits.
+			elapsed := monthsBetween(a.CohortMonth, currentMonth)
+			cohort = &RetentionCohortResponse{
+				Cohort:    a.CohortMonth.Format(monthLayout),
+				Size:      a.Members,
+				Retention: make([]*RetentionPointResponse, 0, min(elapsed, maxCohortMonths)+1),
+			}
+			for offset := 0; offset <= min(elapsed, maxCohortMonths); offset++ {
+				cohort.Retention = append(cohort.Retention, &RetentionPointResponse{MonthOffset: offset})
+			}
+			report.Cohorts = append(report.Cohorts, cohort)
+			continue
+		}
+		if cohort == nil || *a.MonthOffset >= len(cohort.Retention) {
+			continue
+		}
+		point := cohort.Retention[*a.MonthOffset]
+		point.ActiveMembers = a.Members
+		if cohort.Size > 0 {
+			point.Rate = round1(float64(a.Members) * 100 / float64(cohort.Size))
+		}
+	}
+	report.DataAsOf = s.dataAsOf(ctx)
+	return report, nil
+}
+
+func (s *serviceImpl) Refresh(ctx context.Context) (*RefreshResponse, error) {
+	started := time.Now()
+	refreshedAt, err := s.repo.Refresh(ctx)
+	if err != nil {
+		log.Printf("Service: Refresh report views failed: %v", err)
+		return nil, err
+	}
+	log.Printf("Service: Refreshed report views in %s", time.Since(started).Round(time.Millisecond))
+	return &RefreshResponse{RefreshedAt: refreshedAt}, nil
+}
+
+// dataAsOf is informational, so a failure only omits it.
+func (s *serviceImpl) dataAsOf(ctx context.Context) *time.Time {
+	refreshedAt, err := s.repo.RefreshedAt(ctx)
+	if err != nil {
+		log.Printf("Service: failed to read report refresh time: %v", err)
+		return nil
+	}
+	return refreshedAt
+}
+
+// isEmptyScope reports a staff filter without any branches.
+func isEmptyScope(filter *ReportFilter) bool {
+	return filter.BranchIDs != nil && len(filter.BranchIDs) == 0
+}
+
+func attendancePointResponse(p *AttendancePoint) *AttendancePointResponse {
+	return &AttendancePointResponse{
+		Period:             p.Period.Format(dateLayout),
+		Visits:             p.Visits,
+		UniqueMembers:      p.UniqueMembers,
+		AvgDurationMinutes: p.AvgDurationMinutes(),
+	}
+}
+
+// weekdayOccurrences counts each ISO weekday (index 0 = Monday) between from
+// and to inclusive.
+func weekdayOccurrences(from, to time.Time) [7]int {
+	var counts [7]int
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		counts[(int(d.Weekday())+6)%7]++
+	}
+	return counts
+}
+
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -28,15 +28,18 @@ type RenewSubscriptionRequest struct {
 }
 
 type SubscriptionResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	MemberID  uuid.UUID  `json:"memberId"`
-	PlanID    *uuid.UUID `json:"planId,omitempty"`
-	BranchID  *uuid.UUID `json:"branchId,omitempty"`
-	StartDate string     `json:"startDate"`
-	EndDate   string     `json:"endDate"`
-	Status    string     `json:"status"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
+	ID          uuid.UUID  `json:"id"`
+	MemberID    uuid.UUID  `json:"memberId"`
+	PlanID      *uuid.UUID `json:"planId,omitempty"`
+	BranchID    *uuid.UUID `json:"branchId,omitempty"`
+	StartDate   string     `json:"startDate"`
+	EndDate     string     `json:"endDate"`
+	Status      string     `json:"status"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+	FrozenFrom  *string    `json:"frozenFrom,omitempty"`
+	FrozenUntil *string    `json:"frozenUntil,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
 }
 
 type CreateSubscriptionResponse struct {
@@ -15,29 +15,39 @@ const (
 	StatusExpired   SubscriptionStatus = "expired"
 )
 
+// Subscription is a member's plan over [StartDate, EndDate]. CancelledAt is
+// set when it was cancelled, or, while Status is still active, when it is due
+// to end at EndDate without renewal. FrozenFrom and FrozenUntil bound the days
+// of a freeze, during which the member cannot check in.
 type Subscription struct {
-	ID        uuid.UUID          `db:"id"`
-	MemberID  uuid.UUID          `db:"member_id"`
-	PlanID    *uuid.UUID         `db:"plan_id"`
-	BranchID  *uuid.UUID         `db:"branch_id"`
-	StartDate time.Time          `db:"start_date"`
-	EndDate   time.Time          `db:"end_date"`
-	Status    SubscriptionStatus `db:"status"`
-	CreatedAt time.Time          `db:"created_at"`
-	UpdatedAt time.Time          `db:"updated_at"`
+	ID          uuid.UUID          `db:"id"`
+	MemberID    uuid.UUID          `db:"member_id"`
+	PlanID      *uuid.UUID         `db:"plan_id"`
+	BranchID    *uuid.UUID         `db:"branch_id"`
+	StartDate   time.Time          `db:"start_date"`
+	EndDate     time.Time          `db:"end_date"`
+	Status      SubscriptionStatus `db:"status"`
+	CancelledAt *time.Time         `db:"cancelled_at"`
+	FrozenFrom  *time.Time         `db:"frozen_from"`
+	FrozenUntil *time.Time         `db:"frozen_until"`
+	CreatedAt   time.Time          `db:"created_at"`
+	UpdatedAt   time.Time          `db:"updated_at"`
 }
 
 func (s *Subscription) ToResponse() *SubscriptionResponse {
 	return &SubscriptionResponse{
-		ID:        s.ID,
-		MemberID:  s.MemberID,
-		PlanID:    s.PlanID,
-		BranchID:  s.BranchID,
-		StartDate: s.StartDate.Format("2006-01-02"),
-		EndDate:   s.EndDate.Format("2006-01-02"),
-		Status:    string(s.Status),
-		CreatedAt: s.CreatedAt,
-		UpdatedAt: s.UpdatedAt,
+		ID:          s.ID,
+		MemberID:    s.MemberID,
+		PlanID:      s.PlanID,
+		BranchID:    s.BranchID,
+		StartDate:   s.StartDate.Format("2006-01-02"),
+		EndDate:     s.EndDate.Format("2006-01-02"),
+		Status:      string(s.Status),
+		CancelledAt: s.CancelledAt,
+		FrozenFrom:  formatDate(s.FrozenFrom),
+		FrozenUntil: formatDate(s.FrozenUntil),
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
 	}
 }
 
@@ -45,6 +55,23 @@ func (s *Subscription) IsActive() bool {
 	return s.Status == StatusActive && time.Now().Before(s.EndDate)
 }
 
+// IsFrozen reports whether at falls on a day of the subscription's freeze.
+func (s *Subscription) IsFrozen(at time.Time) bool {
+	if s.FrozenFrom == nil || s.FrozenUntil == nil {
+		return false
+	}
+	day := at.Format("2006-01-02")
+	return day >= s.FrozenFrom.Format("2006-01-02") && day < s.FrozenUntil.Format("2006-01-02")
+}
+
+func formatDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	v := t.Format("2006-01-02")
+	return &v
+}
+
 func (s *Subscription) IsExpired() bool {
 	return time.Now().After(s.EndDate)
 }
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,13 +19,29 @@ type Repository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Subscription, error)
 	GetActiveByMemberID(ctx context.Context, memberID uuid.UUID) (*Subscription, error)
+	GetActiveGroupSubscription(ctx context.Context, memberID uuid.UUID) (*Subscription, error)
 	List(ctx context.Context, filter *SubscriptionListFilter) ([]*Subscription, error)
 	Count(ctx context.Context, filter *SubscriptionListFilter) (int, error)
 	ExpireOldSubscriptions(ctx context.Context) (int64, error)
+	// Freeze records the days [from, until) as frozen and moves end_date
+	// back by as many days.
+	Freeze(ctx context.Context, id uuid.UUID, from, until time.Time) error
+	// ScheduleCancellation stamps cancelled_at on an active subscription
+	// and leaves it running until end_date. It returns pgx.ErrNoRows when
+	// the subscription is not active.
+	ScheduleCancellation(ctx context.Context, id uuid.UUID) error
+}
+
+// querier is the part of *pgxpool.Pool the repository uses, so tests can
+// stand in for the database.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 }
 
 type repositoryImpl struct {
-	db *pgxpool.Pool
+	db querier
 }
 
 func NewRepository(db *pgxpool.Pool) Repository {
@@ -55,12 +74,20 @@ func (r *repositoryImpl) Create(ctx context.Context, sub *Subscription) error {
 	return nil
 }
 
+// Update stamps cancelled_at the first time a subscription is cancelled and
+// clears it when a cancelled one is reinstated.
 func (r *repositoryImpl) Update(ctx context.Context, sub *Subscription) error {
 	query := `
 		UPDATE subscriptions
-		SET plan_id = $1, branch_id = $2, start_date = $3, end_date = $4, status = $5, updated_at = NOW()
+		SET plan_id = $1, branch_id = $2, start_date = $3, end_date = $4, status = $5,
+			cancelled_at = CASE
+				WHEN $5 = 'cancelled' THEN COALESCE(cancelled_at, NOW())
+				WHEN status = 'cancelled' THEN NULL
+				ELSE cancelled_at
+			END,
+			updated_at = NOW()
 		WHERE id = $6
-		RETURNING updated_at
+		RETURNING cancelled_at, updated_at
 	`
 	return r.db.QueryRow(ctx, query,
 		sub.PlanID,
@@ -69,7 +96,7 @@ func (r *repositoryImpl) Update(ctx context.Context, sub *Subscription) error {
 		sub.EndDate,
 		sub.Status,
 		sub.ID,
-	).Scan(&sub.UpdatedAt)
+	).Scan(&sub.CancelledAt, &sub.UpdatedAt)
 }
 
 func (r *repositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
@@ -80,7 +107,7 @@ func (r *repositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
 
 func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Subscription, error) {
 	query := `
-		SELECT id, member_id, plan_id, branch_id, start_date, end_date, status, created_at, updated_at
+		SELECT id, member_id, plan_id, branch_id, start_date, end_date, status, cancelled_at, frozen_from, frozen_until, created_at, updated_at
 		FROM subscriptions
 		WHERE id = $1
 	`
@@ -93,6 +120,9 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Subscripti
 		&sub.StartDate,
 		&sub.EndDate,
 		&sub.Status,
+		&sub.CancelledAt,
+		&sub.FrozenFrom,
+		&sub.FrozenUntil,
 		&sub.CreatedAt,
 		&sub.UpdatedAt,
 	)
@@ -104,9 +134,14 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*Subscripti
 
 func (r *repositoryImpl) GetActiveByMemberID(ctx context.Context, memberID uuid.UUID) (*Subscription, error) {
 	query := `
-		SELECT id, member_id, plan_id, branch_id, start_date, end_date, status, created_at, updated_at
+		SELECT id, member_id, plan_id, branch_id, start_date, end_date, status, cancelled_at, frozen_from, frozen_until, created_at, updated_at
 		FROM subscriptions
 		WHERE member_id = $1 AND status = 'active' AND end_date >= CURRENT_DATE
+			-- Personal training packs do not grant gym access
+			AND NOT EXISTS (
+				SELECT 1 FROM membership_plans p
+				WHERE p.id = subscriptions.plan_id AND p.session_credits IS NOT NULL
+			)
 		ORDER BY end_date DESC
 		LIMIT 1
 	`
@@ -119,6 +154,44 @@ func (r *repositoryImpl) GetActiveByMemberID(ctx context.Context, memberID uuid.
 		&sub.StartDate,
 		&sub.EndDate,
 		&sub.Status,
+		&sub.CancelledAt,
+		&sub.FrozenFrom,
+		&sub.FrozenUntil,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetActiveGroupSubscription returns the active subscription of the primary
+// seat of the member's group on the group's plan.
+func (r *repositoryImpl) GetActiveGroupSubscription(ctx context.Context, memberID uuid.UUID) (*Subscription, error) {
+	query := `
+		SELECT s.id, s.member_id, s.plan_id, s.branch_id, s.start_date, s.end_date, s.status, s.cancelled_at, s.frozen_from, s.frozen_until, s.created_at, s.updated_at
+		FROM membership_group_members d
+		JOIN membership_groups g ON g.id = d.group_id AND g.deleted_at IS NULL
+		JOIN membership_group_members p ON p.group_id = g.id AND p.is_primary AND p.removed_at IS NULL
+		JOIN subscriptions s ON s.member_id = p.member_id AND s.plan_id = g.plan_id
+		WHERE d.member_id = $1 AND d.removed_at IS NULL AND NOT d.is_primary
+			AND s.status = 'active' AND s.end_date >= CURRENT_DATE
+		ORDER BY s.end_date DESC
+		LIMIT 1
+	`
+	var sub Subscription
+	err := r.db.QueryRow(ctx, query, memberID).Scan(
+		&sub.ID,
+		&sub.MemberID,
+		&sub.PlanID,
+		&sub.BranchID,
+		&sub.StartDate,
+		&sub.EndDate,
+		&sub.Status,
+		&sub.CancelledAt,
+		&sub.FrozenFrom,
+		&sub.FrozenUntil,
 		&sub.CreatedAt,
 		&sub.UpdatedAt,
 	)
@@ -175,7 +248,7 @@ func (r *repositoryImpl) List(ctx context.Context, filter *SubscriptionListFilte
 	offset := (page - 1) * limit
 
 	query := fmt.Sprintf(`
-		SELECT s.id, s.member_id, s.plan_id, s.branch_id, s.start_date, s.end_date, s.status, s.created_at, s.updated_at
+		SELECT s.id, s.member_id, s.plan_id, s.branch_id, s.start_date, s.end_date, s.status, s.cancelled_at, s.frozen_from, s.frozen_until, s.created_at, s.updated_at
 		FROM subscriptions s
 		LEFT JOIN members m ON s.member_id = m.id
 		%s
@@ -202,6 +275,9 @@ func (r *repositoryImpl) List(ctx context.Context, filter *SubscriptionListFilte
 			&sub.StartDate,
 			&sub.EndDate,
 			&sub.Status,
+			&sub.CancelledAt,
+			&sub.FrozenFrom,
+			&sub.FrozenUntil,
 			&sub.CreatedAt,
 			&sub.UpdatedAt,
 		); err != nil {
@@ -271,3 +347,29 @@ func (r *repositoryImpl) ExpireOldSubscriptions(ctx context.Context) (int64, err
 	}
 	return result.RowsAffected(), nil
 }
+
+func (r *repositoryImpl) Freeze(ctx context.Context, id uuid.UUID, from, until time.Time) error {
+	query := `
+		UPDATE subscriptions
+		SET frozen_from = $2, frozen_until = $3, end_date = end_date + ($3::date - $2::date), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, from, until)
+	return err
+}
+
+func (r *repositoryImpl) ScheduleCancellation(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE subscriptions
+		SET cancelled_at = COALESCE(cancelled_at, NOW()), updated_at = NOW()
+		WHERE id = $1 AND status = 'active'
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
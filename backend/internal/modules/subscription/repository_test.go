@@ -0,0 +1,145 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var selectList = regexp.MustCompile(`(?s)SELECT\s+(.*?)\s+FROM`)
+
+// fakeDB answers every query with the same rows and, like pgx, refuses a
+// Scan whose destinations do not match the selected columns.
+type fakeDB struct {
+	rows [][]any
+}
+
+func (db *fakeDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (db *fakeDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return &fakeRows{sql: sql, rows: db.rows, pos: -1}, nil
+}
+
+func (db *fakeDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	rows := &fakeRows{sql: sql, rows: db.rows, pos: -1}
+	if !rows.Next() {
+		return fakeRow{err: pgx.ErrNoRows}
+	}
+	return fakeRow{rows: rows}
+}
+
+type fakeRow struct {
+	rows *fakeRows
+	err  error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Scan(dest...)
+}
+
+type fakeRows struct {
+	pgx.Rows
+	sql  string
+	rows [][]any
+	pos  int
+}
+
+func (r *fakeRows) Next() bool {
+	r.pos++
+	return r.pos < len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	m := selectList.FindStringSubmatch(r.sql)
+	if m == nil {
+		return fmt.Errorf("no select list in %q", r.sql)
+	}
+	columns := len(strings.Split(m[1], ","))
+	if len(dest) != columns {
+		return fmt.Errorf("number of field descriptions must equal number of destinations, got %d and %d", columns, len(dest))
+	}
+	for i, value := range r.rows[r.pos] {
+		if value == nil {
+			continue
+		}
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error { return nil }
+func (r *fakeRows) Close()     {}
+
+func subscriptionRow(sub *Subscription) []any {
+	return []any{
+		sub.ID, sub.MemberID, sub.PlanID, sub.BranchID, sub.StartDate, sub.EndDate, sub.Status,
+		sub.CancelledAt, sub.FrozenFrom, sub.FrozenUntil, sub.CreatedAt, sub.UpdatedAt,
+	}
+}
+
+func testSubscription() *Subscription {
+	planID, branchID := uuid.New(), uuid.New()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	frozenFrom, frozenUntil := start.AddDate(0, 0, 10), start.AddDate(0, 0, 17)
+	return &Subscription{
+		ID:          uuid.New(),
+		MemberID:    uuid.New(),
+		PlanID:      &planID,
+		BranchID:    &branchID,
+		StartDate:   start,
+		EndDate:     start.AddDate(0, 1, 7),
+		Status:      StatusActive,
+		FrozenFrom:  &frozenFrom,
+		FrozenUntil: &frozenUntil,
+		CreatedAt:   start,
+		UpdatedAt:   start,
+	}
+}
+
+func TestRepositoryList(t *testing.T) {
+	want := testSubscription()
+	repo := &repositoryImpl{db: &fakeDB{rows: [][]any{subscriptionRow(want)}}}
+
+	got, err := repo.List(context.Background(), &SubscriptionListFilter{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0], want) {
+		t.Errorf("List() = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestRepositoryGetters(t *testing.T) {
+	want := testSubscription()
+	repo := &repositoryImpl{db: &fakeDB{rows: [][]any{subscriptionRow(want)}}}
+	ctx := context.Background()
+
+	getters := map[string]func() (*Subscription, error){
+		"GetByID":                    func() (*Subscription, error) { return repo.GetByID(ctx, want.ID) },
+		"GetActiveByMemberID":        func() (*Subscription, error) { return repo.GetActiveByMemberID(ctx, want.MemberID) },
+		"GetActiveGroupSubscription": func() (*Subscription, error) { return repo.GetActiveGroupSubscription(ctx, want.MemberID) },
+	}
+	for name, get := range getters {
+		got, err := get()
+		if err != nil {
+			t.Errorf("%s() error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s() = %+v, want %+v", name, got, want)
+		}
+	}
+}
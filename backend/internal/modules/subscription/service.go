@@ -8,15 +8,14 @@ import (
 	"time"
 
 	"fitcore/internal/config"
-	"fitcore/internal/middleware"
 	"fitcore/internal/modules/invoice"
 	"fitcore/internal/modules/plans"
 	"fitcore/internal/modules/user"
 	"fitcore/pkg/email"
 	"fitcore/pkg/polar"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // measureTime logs the duration of an operation
@@ -31,14 +30,36 @@ var (
 	ErrPlanNotFound         = errors.New("plan not found")
 	ErrInvalidDateRange     = errors.New("end date must be after start date")
 	ErrNoActiveSubscription = errors.New("no active subscription found")
+	ErrSubscriptionFrozen   = errors.New("subscription is frozen")
 )
 
 type Service interface {
-	CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest, çç string) (*CreateSubscriptionResponse, error)
+	// CreateSubscription creates the subscription and, when it has a plan, a
+	// checkout and invoice addressed to the member's account email.
+	// paymentType is passed to the checkout metadata; "new" makes the paid
+	// webhook set up the member's account.
+	CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest, paymentType string) (*CreateSubscriptionResponse, error)
 	UpdateSubscription(ctx context.Context, id uuid.UUID, req *UpdateSubscriptionRequest) (*Subscription, error)
 	DeleteSubscription(ctx context.Context, id uuid.UUID) error
 	GetSubscription(ctx context.Context, id uuid.UUID) (*Subscription, error)
+	// FreezeSubscription suspends access for days from the start date and
+	// extends the subscription by as many days.
+	FreezeSubscription(ctx context.Context, id uuid.UUID, start time.Time, days int) (*Subscription, error)
+	// CancelAtPeriodEnd cancels the subscription without cutting access
+	// short: it keeps running until its end date and is not renewed.
+	CancelAtPeriodEnd(ctx context.Context, id uuid.UUID) (*Subscription, error)
+	// GetActiveSubscription returns the member's own active subscription,
+	// the one to renew, freeze or cancel.
 	GetActiveSubscription(ctx context.Context, memberID uuid.UUID) (*Subscription, error)
+	// GetAccessSubscription returns the subscription the member trains on:
+	// their own active one or, for a dependent of a family or corporate
+	// group, the primary seat's. It does not look at freezes, so it suits
+	// showing the subscription; use CheckAccess to admit the member.
+	GetAccessSubscription(ctx context.Context, memberID uuid.UUID) (*Subscription, error)
+	// CheckAccess returns the access subscription if the member may use it
+	// at the given time, and ErrSubscriptionFrozen during a freeze.
+	// Check-ins, class bookings and guest passes go through it.
+	CheckAccess(ctx context.Context, memberID uuid.UUID, at time.Time) (*Subscription, error)
 	ListSubscriptions(ctx context.Context, filter *SubscriptionListFilter) ([]*Subscription, error)
 	RenewSubscription(ctx context.Context, memberID uuid.UUID, req *RenewSubscriptionRequest) (*Subscription, error)
 	ExpireOldSubscriptions(ctx context.Context) (int64, error)
@@ -121,12 +142,21 @@ func (s *serviceImpl) CreateSubscription(ctx context.Context, req *CreateSubscri
 	}
 
 	baseUrl := config.Get().App.BaseURL
-	claims := ctx.Value(middleware.UserClaimsKey).(jwt.MapClaims)
 	successUrl := fmt.Sprintf("%s/login", baseUrl)
 
 	if sub.PlanID != nil {
+		// The checkout is addressed to the member's account rather than the
+		// caller, which may be staff or, for online sign-ups, nobody.
+		getUserStart := time.Now()
+		user, err := s.userRepo.GetUserByMemberID(ctx, sub.MemberID)
+		measureTime("GetUserByMemberID (DB)", getUserStart)
+		if err != nil {
+			log.Printf("Service: CreateSubscription failed - user service error for member ID %s: %v", req.MemberID, err)
+			return nil, err
+		}
+
 		polarStart := time.Now()
-		res, err := s.polarSvc.CreateCheckout(ctx, []string{sub.PlanID.String()}, claims["email"].(string), successUrl, "new")
+		res, err := s.polarSvc.CreateCheckout(ctx, []string{sub.PlanID.String()}, user.Email, successUrl, paymentType)
 		measureTime("Polar CreateCheckout API", polarStart)
 		if err != nil {
 			log.Printf("Service: CreateSubscription failed - polar service error for member ID %s: %v", req.MemberID, err)
@@ -165,14 +195,6 @@ func (s *serviceImpl) CreateSubscription(ctx context.Context, req *CreateSubscri
 		subsResponse.InvoiceID = &resInvoice.ID
 		subsResponse.CheckoutURL = res.Checkout.URL
 
-		getUserStart := time.Now()
-		user, err := s.userRepo.GetUserByMemberID(ctx, sub.MemberID)
-		measureTime("GetUserByMemberID (DB)", getUserStart)
-		if err != nil {
-			log.Printf("Service: CreateSubscription failed - user service error for member ID %s: %v", req.MemberID, err)
-			return nil, err
-		}
-
 		// Send email asynchronously to avoid blocking the API response
 		go func(email, checkoutURL string) {
 			// Create a new context for the background operation since the request context may be cancelled
@@ -234,6 +256,28 @@ func (s *serviceImpl) UpdateSubscription(ctx context.Context, id uuid.UUID, req
 	return sub, nil
 }
 
+func (s *serviceImpl) FreezeSubscription(ctx context.Context, id uuid.UUID, start time.Time, days int) (*Subscription, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err := s.repo.Freeze(ctx, id, start, start.AddDate(0, 0, days)); err != nil {
+		log.Printf("Service: FreezeSubscription failed for subscription %s: %v", id, err)
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *serviceImpl) CancelAtPeriodEnd(ctx context.Context, id uuid.UUID) (*Subscription, error) {
+	if err := s.repo.ScheduleCancellation(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoActiveSubscription
+		}
+		log.Printf("Service: CancelAtPeriodEnd failed for subscription %s: %v", id, err)
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
 func (s *serviceImpl) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
 	return s.repo.Delete(ctx, id)
 }
@@ -246,6 +290,25 @@ func (s *serviceImpl) GetActiveSubscription(ctx context.Context, memberID uuid.U
 	return s.repo.GetActiveByMemberID(ctx, memberID)
 }
 
+func (s *serviceImpl) GetAccessSubscription(ctx context.Context, memberID uuid.UUID) (*Subscription, error) {
+	sub, err := s.repo.GetActiveByMemberID(ctx, memberID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.repo.GetActiveGroupSubscription(ctx, memberID)
+	}
+	return sub, err
+}
+
+func (s *serviceImpl) CheckAccess(ctx context.Context, memberID uuid.UUID, at time.Time) (*Subscription, error) {
+	sub, err := s.GetAccessSubscription(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.IsFrozen(at) {
+		return nil, ErrSubscriptionFrozen
+	}
+	return sub, nil
+}
+
 func (s *serviceImpl) ListSubscriptions(ctx context.Context, filter *SubscriptionListFilter) ([]*Subscription, error) {
 	if filter.Page < 1 {
 		filter.Page = 1
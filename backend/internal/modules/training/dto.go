@@ -0,0 +1,87 @@
+package training
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AvailabilitySlot struct {
+	BranchID  uuid.UUID `json:"branchId" validate:"required"`
+	DayOfWeek int       `json:"dayOfWeek" validate:"min=0,max=6"`
+	StartTime string    `json:"startTime" validate:"required"`
+	EndTime   string    `json:"endTime" validate:"required"`
+}
+
+// SetAvailabilityRequest replaces a trainer's weekly availability.
+type SetAvailabilityRequest struct {
+	Slots []AvailabilitySlot `json:"slots" validate:"dive"`
+}
+
+type BookSessionRequest struct {
+	TrainerID uuid.UUID `json:"trainerId" validate:"required"`
+	BranchID  uuid.UUID `json:"branchId" validate:"required"`
+	StartsAt  time.Time `json:"startsAt" validate:"required"`
+	// DurationMinutes defaults to 60.
+	DurationMinutes int `json:"durationMinutes,omitempty" validate:"omitempty,gt=0,lte=240"`
+	// MemberID is required when staff book on behalf of a member and ignored
+	// for members booking themselves.
+	MemberID *uuid.UUID `json:"memberId,omitempty"`
+	// SubscriptionID picks the pack to draw from; defaults to the pack that
+	// expires first.
+	SubscriptionID *uuid.UUID `json:"subscriptionId,omitempty"`
+}
+
+type CompleteSessionRequest struct {
+	Notes *string `json:"notes,omitempty"`
+}
+
+type AvailabilityResponse struct {
+	ID        uuid.UUID `json:"id"`
+	BranchID  uuid.UUID `json:"branchId"`
+	DayOfWeek int       `json:"dayOfWeek"`
+	StartTime string    `json:"startTime"`
+	EndTime   string    `json:"endTime"`
+}
+
+type PackResponse struct {
+	SubscriptionID uuid.UUID `json:"subscriptionId"`
+	PlanName       string    `json:"planName"`
+	Credits        int       `json:"credits"`
+	Used           int       `json:"used"`
+	Reserved       int       `json:"reserved"`
+	Remaining      int       `json:"remaining"`
+	ExpiresAt      string    `json:"expiresAt"`
+}
+
+type SessionResponse struct {
+	ID             uuid.UUID     `json:"id"`
+	TrainerID      uuid.UUID     `json:"trainerId"`
+	TrainerName    string        `json:"trainerName,omitempty"`
+	MemberID       uuid.UUID     `json:"memberId"`
+	MemberName     string        `json:"memberName,omitempty"`
+	SubscriptionID uuid.UUID     `json:"subscriptionId"`
+	BranchID       uuid.UUID     `json:"branchId"`
+	StartsAt       time.Time     `json:"startsAt"`
+	EndsAt         time.Time     `json:"endsAt"`
+	Status         SessionStatus `json:"status"`
+	Notes          *string       `json:"notes,omitempty"`
+	CompletedAt    *time.Time    `json:"completedAt,omitempty"`
+	CancelledAt    *time.Time    `json:"cancelledAt,omitempty"`
+}
+
+type TrainerStatsResponse struct {
+	TrainerID   uuid.UUID `json:"trainerId"`
+	TrainerName string    `json:"trainerName"`
+	Completed   int       `json:"completed"`
+	Cancelled   int       `json:"cancelled"`
+	Scheduled   int       `json:"scheduled"`
+	Members     int       `json:"members"`
+	Minutes     int       `json:"minutes"`
+}
+
+type TrainerReportResponse struct {
+	From     string                  `json:"from"`
+	To       string                  `json:"to"`
+	Trainers []*TrainerStatsResponse `json:"trainers"`
+}
@@ -0,0 +1,119 @@
+package training
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionStatus string
+
+const (
+	SessionScheduled SessionStatus = "scheduled"
+	SessionCompleted SessionStatus = "completed"
+	SessionCancelled SessionStatus = "cancelled"
+)
+
+// Availability is a weekly window in branch local time. Times are HH:MM.
+type Availability struct {
+	ID        uuid.UUID `db:"id"`
+	TrainerID uuid.UUID `db:"trainer_id"`
+	BranchID  uuid.UUID `db:"branch_id"`
+	DayOfWeek int       `db:"day_of_week"`
+	StartTime string    `db:"start_time"`
+	EndTime   string    `db:"end_time"`
+}
+
+// Pack is a member's personal training subscription with its credit balance.
+// Scheduled sessions reserve a credit; completed sessions consume it.
+type Pack struct {
+	SubscriptionID uuid.UUID `db:"subscription_id"`
+	PlanName       string    `db:"plan_name"`
+	Credits        int       `db:"credits"`
+	Used           int       `db:"used"`
+	Reserved       int       `db:"reserved"`
+	ExpiresAt      time.Time `db:"end_date"`
+}
+
+func (p *Pack) Remaining() int {
+	return max(p.Credits-p.Used-p.Reserved, 0)
+}
+
+type Session struct {
+	ID             uuid.UUID     `db:"id"`
+	TrainerID      uuid.UUID     `db:"trainer_id"`
+	TrainerName    string        `db:"trainer_name"`
+	MemberID       uuid.UUID     `db:"member_id"`
+	MemberName     string        `db:"member_name"`
+	SubscriptionID uuid.UUID     `db:"subscription_id"`
+	BranchID       uuid.UUID     `db:"branch_id"`
+	StartsAt       time.Time     `db:"starts_at"`
+	EndsAt         time.Time     `db:"ends_at"`
+	Status         SessionStatus `db:"status"`
+	Notes          *string       `db:"notes"`
+	CompletedAt    *time.Time    `db:"completed_at"`
+	CancelledAt    *time.Time    `db:"cancelled_at"`
+	CreatedAt      time.Time     `db:"created_at"`
+}
+
+type TrainerReport struct {
+	TrainerID   uuid.UUID `db:"trainer_id"`
+	TrainerName string    `db:"trainer_name"`
+	Completed   int       `db:"completed"`
+	Cancelled   int       `db:"cancelled"`
+	Scheduled   int       `db:"scheduled"`
+	Members     int       `db:"members"`
+	Minutes     int       `db:"minutes"`
+}
+
+func (a *Availability) ToResponse() *AvailabilityResponse {
+	return &AvailabilityResponse{
+		ID:        a.ID,
+		BranchID:  a.BranchID,
+		DayOfWeek: a.DayOfWeek,
+		StartTime: a.StartTime,
+		EndTime:   a.EndTime,
+	}
+}
+
+func (p *Pack) ToResponse() *PackResponse {
+	return &PackResponse{
+		SubscriptionID: p.SubscriptionID,
+		PlanName:       p.PlanName,
+		Credits:        p.Credits,
+		Used:           p.Used,
+		Reserved:       p.Reserved,
+		Remaining:      p.Remaining(),
+		ExpiresAt:      p.ExpiresAt.Format(dateLayout),
+	}
+}
+
+func (s *Session) ToResponse() *SessionResponse {
+	return &SessionResponse{
+		ID:             s.ID,
+		TrainerID:      s.TrainerID,
+		TrainerName:    s.TrainerName,
+		MemberID:       s.MemberID,
+		MemberName:     s.MemberName,
+		SubscriptionID: s.SubscriptionID,
+		BranchID:       s.BranchID,
+		StartsAt:       s.StartsAt,
+		EndsAt:         s.EndsAt,
+		Status:         s.Status,
+		Notes:          s.Notes,
+		CompletedAt:    s.CompletedAt,
+		CancelledAt:    s.CancelledAt,
+	}
+}
+
+func (t *TrainerReport) ToResponse() *TrainerStatsResponse {
+	return &TrainerStatsResponse{
+		TrainerID:   t.TrainerID,
+		TrainerName: t.TrainerName,
+		Completed:   t.Completed,
+		Cancelled:   t.Cancelled,
+		Scheduled:   t.Scheduled,
+		Members:     t.Members,
+		Minutes:     t.Minutes,
+	}
+}
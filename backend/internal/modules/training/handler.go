@@ -0,0 +1,402 @@
+package training
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitcore/internal/middleware"
+	"fitcore/internal/response"
+
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/training", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware)
+
+		r.Get("/trainers/{trainerId}/availability", h.GetAvailability)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member", "admin", "staff"))
+			r.Post("/sessions", h.BookSession)
+			r.Post("/sessions/{sessionId}/cancel", h.CancelSession)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("member"))
+			r.Get("/me/sessions", h.ListMySessions)
+			r.Get("/me/packs", h.ListMyPacks)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("admin", "staff"))
+			r.Put("/trainers/{trainerId}/availability", h.SetAvailability)
+			r.Get("/trainers/{trainerId}/sessions", h.ListTrainerSessions)
+			r.Get("/members/{memberId}/packs", h.ListMemberPacks)
+			r.Post("/sessions/{sessionId}/complete", h.CompleteSession)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("super_admin", "admin"))
+			r.Get("/reports/trainers", h.TrainerReport)
+		})
+	})
+}
+
+func (h *Handler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	trainerID, err := uuid.Parse(chi.URLParam(r, "trainerId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid trainer ID", nil)
+		return
+	}
+
+	slots, err := h.service.GetAvailability(r.Context(), trainerID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to get availability")
+		return
+	}
+
+	response.Success(w, "Availability retrieved successfully", availabilityResponses(slots))
+}
+
+// SetAvailability replaces a trainer's weekly availability. Staff can only
+// manage their own calendar.
+func (h *Handler) SetAvailability(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := userFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	trainerID, err := uuid.Parse(chi.URLParam(r, "trainerId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid trainer ID", nil)
+		return
+	}
+	if role == "staff" && trainerID != userID {
+		response.Forbidden(w, "Staff can only manage their own availability")
+		return
+	}
+
+	var req SetAvailabilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	slots, err := h.service.SetAvailability(r.Context(), trainerID, &req)
+	if err != nil {
+		switch err {
+		case ErrTrainerNotFound:
+			response.NotFound(w, err.Error())
+		case ErrInvalidTimeRange:
+			response.BadRequest(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to update availability")
+		}
+		return
+	}
+
+	response.Success(w, "Availability updated successfully", availabilityResponses(slots))
+}
+
+func (h *Handler) BookSession(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := userFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req BookSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid request payload", nil)
+		return
+	}
+
+	if !response.ValidateStructAndWrite(w, &req) {
+		return
+	}
+
+	session, err := h.service.BookSession(r.Context(), userID, role, &req)
+	if err != nil {
+		switch err {
+		case ErrMemberNotFound, ErrTrainerNotFound, ErrPackNotFound:
+			response.NotFound(w, err.Error())
+		case ErrSessionInPast:
+			response.BadRequest(w, err.Error(), nil)
+		case ErrOutsideAvailability, ErrSlotTaken:
+			response.Conflict(w, err.Error(), nil)
+		case ErrNoActivePack, ErrPackExpired, ErrNoSessionCredits:
+			response.Forbidden(w, err.Error())
+		default:
+			response.InternalServerError(w, "Failed to book training session")
+		}
+		return
+	}
+
+	response.Success(w, "Training session booked successfully", session.ToResponse())
+}
+
+func (h *Handler) CompleteSession(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := userFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid session ID", nil)
+		return
+	}
+
+	var req CompleteSessionRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.BadRequest(w, "Invalid request payload", nil)
+			return
+		}
+	}
+
+	session, err := h.service.CompleteSession(r.Context(), userID, role, sessionID, &req)
+	if err != nil {
+		switch err {
+		case ErrSessionNotFound:
+			response.NotFound(w, err.Error())
+		case ErrNotSessionParticipant:
+			response.Forbidden(w, err.Error())
+		case ErrSessionNotScheduled, ErrSessionNotStarted:
+			response.Conflict(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to complete training session")
+		}
+		return
+	}
+
+	response.Success(w, "Training session completed successfully", session.ToResponse())
+}
+
+func (h *Handler) CancelSession(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := userFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid session ID", nil)
+		return
+	}
+
+	if err := h.service.CancelSession(r.Context(), userID, role, sessionID); err != nil {
+		switch err {
+		case ErrSessionNotFound, ErrMemberNotFound:
+			response.NotFound(w, err.Error())
+		case ErrNotSessionParticipant:
+			response.Forbidden(w, err.Error())
+		case ErrSessionNotScheduled:
+			response.Conflict(w, err.Error(), nil)
+		default:
+			response.InternalServerError(w, "Failed to cancel training session")
+		}
+		return
+	}
+
+	response.OK(w, "Training session cancelled successfully")
+}
+
+// ListTrainerSessions returns a trainer's calendar. from/to accept YYYY-MM-DD
+// or RFC3339 and default to the coming 7 days.
+func (h *Handler) ListTrainerSessions(w http.ResponseWriter, r *http.Request) {
+	trainerID, err := uuid.Parse(chi.URLParam(r, "trainerId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid trainer ID", nil)
+		return
+	}
+
+	from, to, ok := parseRange(w, r, time.Now(), 7)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.ListTrainerSessions(r.Context(), trainerID, from, to)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list training sessions")
+		return
+	}
+
+	response.Success(w, "Training sessions retrieved successfully", sessionResponses(sessions))
+}
+
+func (h *Handler) ListMySessions(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := userFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	sessions, err := h.service.ListMySessions(r.Context(), userID, page, limit)
+	if err != nil {
+		if err == ErrMemberNotFound {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalServerError(w, "Failed to list training sessions")
+		return
+	}
+
+	response.Success(w, "Training sessions retrieved successfully", sessionResponses(sessions))
+}
+
+func (h *Handler) ListMyPacks(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := userFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	packs, err := h.service.ListMyPacks(r.Context(), userID)
+	if err != nil {
+		if err == ErrMemberNotFound {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalServerError(w, "Failed to list training packs")
+		return
+	}
+
+	response.Success(w, "Training packs retrieved successfully", packResponses(packs))
+}
+
+func (h *Handler) ListMemberPacks(w http.ResponseWriter, r *http.Request) {
+	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
+	if err != nil {
+		response.BadRequest(w, "Invalid member ID", nil)
+		return
+	}
+
+	packs, err := h.service.ListMemberPacks(r.Context(), memberID)
+	if err != nil {
+		response.InternalServerError(w, "Failed to list training packs")
+		return
+	}
+
+	response.Success(w, "Training packs retrieved successfully", packResponses(packs))
+}
+
+// TrainerReport summarises sessions delivered per trainer. from/to default to
+// the last 30 days; branchId is optional.
+func (h *Handler) TrainerReport(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseRange(w, r, time.Now().AddDate(0, 0, -30), 30)
+	if !ok {
+		return
+	}
+
+	var branchID *uuid.UUID
+	if v := r.URL.Query().Get("branchId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.BadRequest(w, "Invalid branchId parameter", nil)
+			return
+		}
+		branchID = &id
+	}
+
+	report, err := h.service.TrainerReport(r.Context(), branchID, from, to)
+	if err != nil {
+		response.InternalServerError(w, "Failed to build trainer report")
+		return
+	}
+
+	trainers := make([]*TrainerStatsResponse, 0, len(report))
+	for _, t := range report {
+		trainers = append(trainers, t.ToResponse())
+	}
+	response.Success(w, "Trainer report retrieved successfully", &TrainerReportResponse{
+		From:     from.Format(time.RFC3339),
+		To:       to.Format(time.RFC3339),
+		Trainers: trainers,
+	})
+}
+
+func userFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(gojwt.MapClaims)
+	if !ok {
+		response.Unauthorized(w, "Invalid user context")
+		return uuid.Nil, "", false
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		response.Unauthorized(w, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+
+	role, _ := claims["role"].(string)
+	return userID, role, true
+}
+
+func parseRange(w http.ResponseWriter, r *http.Request, defaultFrom time.Time, defaultDays int) (time.Time, time.Time, bool) {
+	var err error
+	from := defaultFrom
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = parseTimeParam(v); err != nil {
+			response.BadRequest(w, "Invalid from parameter", nil)
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	to := from.AddDate(0, 0, defaultDays)
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = parseTimeParam(v); err != nil {
+			response.BadRequest(w, "Invalid to parameter", nil)
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	return from, to, true
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(dateLayout, value)
+}
+
+func availabilityResponses(slots []*Availability) []*AvailabilityResponse {
+	responses := make([]*AvailabilityResponse, 0, len(slots))
+	for _, a := range slots {
+		responses = append(responses, a.ToResponse())
+	}
+	return responses
+}
+
+func sessionResponses(sessions []*Session) []*SessionResponse {
+	responses := make([]*SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		responses = append(responses, s.ToResponse())
+	}
+	return responses
+}
+
+func packResponses(packs []*Pack) []*PackResponse {
+	responses := make([]*PackResponse, 0, len(packs))
+	for _, p := range packs {
+		responses = append(responses, p.ToResponse())
+	}
+	return responses
+}
@@ -0,0 +1,382 @@
+package training
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetUserRole(ctx context.Context, userID uuid.UUID) (string, error)
+	GetMemberIDByUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+
+	ListAvailability(ctx context.Context, trainerID uuid.UUID) ([]*Availability, error)
+	ReplaceAvailability(ctx context.Context, trainerID uuid.UUID, slots []*Availability) error
+	IsWithinAvailability(ctx context.Context, trainerID, branchID uuid.UUID, startsAt, endsAt time.Time) (bool, error)
+
+	ListPacks(ctx context.Context, memberID uuid.UUID) ([]*Pack, error)
+	CreateSession(ctx context.Context, session *Session) error
+	GetSession(ctx context.Context, id uuid.UUID) (*Session, error)
+	CompleteSession(ctx context.Context, id uuid.UUID, notes *string) error
+	CancelSession(ctx context.Context, id uuid.UUID) error
+	ListTrainerSessions(ctx context.Context, trainerID uuid.UUID, from, to time.Time) ([]*Session, error)
+	ListMemberSessions(ctx context.Context, memberID uuid.UUID, limit, offset int) ([]*Session, error)
+	TrainerReport(ctx context.Context, branchID *uuid.UUID, from, to time.Time) ([]*TrainerReport, error)
+}
+
+type repositoryImpl struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repositoryImpl{db: db}
+}
+
+const sessionColumns = `
+	s.id, s.trainer_id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), s.member_id, m.first_name || ' ' || m.last_name,
+	s.subscription_id, s.branch_id, s.starts_at, s.ends_at, s.status, s.notes,
+	s.completed_at, s.cancelled_at, s.created_at
+`
+
+const sessionJoins = `
+	FROM training_sessions s
+	JOIN users u ON u.id = s.trainer_id
+	JOIN members m ON m.id = s.member_id
+`
+
+func scanSession(row pgx.Row) (*Session, error) {
+	var s Session
+	err := row.Scan(
+		&s.ID,
+		&s.TrainerID,
+		&s.TrainerName,
+		&s.MemberID,
+		&s.MemberName,
+		&s.SubscriptionID,
+		&s.BranchID,
+		&s.StartsAt,
+		&s.EndsAt,
+		&s.Status,
+		&s.Notes,
+		&s.CompletedAt,
+		&s.CancelledAt,
+		&s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *repositoryImpl) querySessions(ctx context.Context, query string, args ...any) ([]*Session, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *repositoryImpl) GetUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.QueryRow(ctx, `SELECT role::text FROM users WHERE id = $1 AND is_active IS TRUE`, userID).Scan(&role)
+	return role, err
+}
+
+func (r *repositoryImpl) GetMemberIDByUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	var memberID uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM members WHERE user_id = $1`, userID).Scan(&memberID)
+	return memberID, err
+}
+
+func (r *repositoryImpl) ListAvailability(ctx context.Context, trainerID uuid.UUID) ([]*Availability, error) {
+	query := `
+		SELECT id, trainer_id, branch_id, day_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM trainer_availability
+		WHERE trainer_id = $1
+		ORDER BY day_of_week, start_time
+	`
+	rows, err := r.db.Query(ctx, query, trainerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slots []*Availability
+	for rows.Next() {
+		var a Availability
+		if err := rows.Scan(&a.ID, &a.TrainerID, &a.BranchID, &a.DayOfWeek, &a.StartTime, &a.EndTime); err != nil {
+			return nil, err
+		}
+		slots = append(slots, &a)
+	}
+	return slots, rows.Err()
+}
+
+func (r *repositoryImpl) ReplaceAvailability(ctx context.Context, trainerID uuid.UUID, slots []*Availability) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM trainer_availability WHERE trainer_id = $1`, trainerID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO trainer_availability (trainer_id, branch_id, day_of_week, start_time, end_time)
+		VALUES ($1, $2, $3, $4::time, $5::time)
+		RETURNING id
+	`
+	for _, slot := range slots {
+		if err := tx.QueryRow(ctx, query, trainerID, slot.BranchID, slot.DayOfWeek, slot.StartTime, slot.EndTime).Scan(&slot.ID); err != nil {
+			return err
+		}
+		slot.TrainerID = trainerID
+	}
+
+	return tx.Commit(ctx)
+}
+
+// IsWithinAvailability reports whether [startsAt, endsAt) fits entirely inside
+// one of the trainer's weekly windows at the branch, in branch local time.
+// IsWithinAvailability reports whether the session fits inside one of the
+// trainer's slots at the branch. A slot ending at or before its start runs
+// into the next day, so the previous day's slots are checked too.
+func (r *repositoryImpl) IsWithinAvailability(ctx context.Context, trainerID, branchID uuid.UUID, startsAt, endsAt time.Time) (bool, error) {
+	query := `
+		WITH local AS (
+			SELECT $3::timestamptz AT TIME ZONE COALESCE(b.timezone, 'UTC') AS starts_at,
+			       $4::timestamptz AT TIME ZONE COALESCE(b.timezone, 'UTC') AS ends_at
+			FROM branches b
+			WHERE b.id = $2
+		)
+		SELECT EXISTS (
+			SELECT 1
+			FROM trainer_availability a, local l,
+				LATERAL (VALUES (l.starts_at::date), (l.starts_at::date - 1)) d(day)
+			WHERE a.trainer_id = $1
+				AND a.branch_id = $2
+				AND a.day_of_week = EXTRACT(DOW FROM d.day)
+				AND d.day + a.start_time <= l.starts_at
+				AND l.ends_at <= d.day + a.end_time
+					+ CASE WHEN a.end_time <= a.start_time THEN INTERVAL '1 day' ELSE INTERVAL '0' END
+		)
+	`
+	var ok bool
+	err := r.db.QueryRow(ctx, query, trainerID, branchID, startsAt, endsAt).Scan(&ok)
+	return ok, err
+}
+
+const packQuery = `
+	SELECT sub.id, p.name, p.session_credits, sub.end_date,
+		COUNT(ts.id) FILTER (WHERE ts.status = 'completed'),
+		COUNT(ts.id) FILTER (WHERE ts.status = 'scheduled')
+	FROM subscriptions sub
+	JOIN membership_plans p ON p.id = sub.plan_id
+	LEFT JOIN training_sessions ts ON ts.subscription_id = sub.id
+	WHERE p.session_credits IS NOT NULL
+		AND sub.status = 'active'
+		AND sub.end_date >= CURRENT_DATE
+`
+
+// ListPacks returns the member's usable training packs, soonest expiry first.
+func (r *repositoryImpl) ListPacks(ctx context.Context, memberID uuid.UUID) ([]*Pack, error) {
+	query := packQuery + `
+		AND sub.member_id = $1
+		GROUP BY sub.id, p.name, p.session_credits, sub.end_date
+		ORDER BY sub.end_date, sub.id
+	`
+	rows, err := r.db.Query(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var packs []*Pack
+	for rows.Next() {
+		var p Pack
+		if err := rows.Scan(&p.SubscriptionID, &p.PlanName, &p.Credits, &p.ExpiresAt, &p.Used, &p.Reserved); err != nil {
+			return nil, err
+		}
+		packs = append(packs, &p)
+	}
+	return packs, rows.Err()
+}
+
+// CreateSession locks the pack's subscription row so concurrent bookings
+// cannot overdraw it, and takes a per-trainer advisory lock so two bookings
+// cannot claim the same slot.
+func (r *repositoryImpl) CreateSession(ctx context.Context, session *Session) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text))`, session.TrainerID); err != nil {
+		return err
+	}
+
+	var credits int
+	lockQuery := `
+		SELECT p.session_credits
+		FROM subscriptions sub
+		JOIN membership_plans p ON p.id = sub.plan_id
+		WHERE sub.id = $1 AND sub.member_id = $2 AND p.session_credits IS NOT NULL
+		FOR UPDATE OF sub
+	`
+	if err := tx.QueryRow(ctx, lockQuery, session.SubscriptionID, session.MemberID).Scan(&credits); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPackNotFound
+		}
+		return err
+	}
+
+	var charged int
+	chargedQuery := `SELECT COUNT(*) FROM training_sessions WHERE subscription_id = $1 AND status <> 'cancelled'`
+	if err := tx.QueryRow(ctx, chargedQuery, session.SubscriptionID).Scan(&charged); err != nil {
+		return err
+	}
+	if charged >= credits {
+		return ErrNoSessionCredits
+	}
+
+	var overlapping bool
+	overlapQuery := `
+		SELECT EXISTS (
+			SELECT 1 FROM training_sessions
+			WHERE status = 'scheduled'
+				AND (trainer_id = $1 OR member_id = $2)
+				AND starts_at < $4 AND ends_at > $3
+		)
+	`
+	if err := tx.QueryRow(ctx, overlapQuery, session.TrainerID, session.MemberID, session.StartsAt, session.EndsAt).Scan(&overlapping); err != nil {
+		return err
+	}
+	if overlapping {
+		return ErrSlotTaken
+	}
+
+	query := `
+		INSERT INTO training_sessions (trainer_id, member_id, subscription_id, branch_id, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at
+	`
+	if err := tx.QueryRow(ctx, query,
+		session.TrainerID,
+		session.MemberID,
+		session.SubscriptionID,
+		session.BranchID,
+		session.StartsAt,
+		session.EndsAt,
+	).Scan(&session.ID, &session.Status, &session.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repositoryImpl) GetSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	query := `SELECT ` + sessionColumns + sessionJoins + `WHERE s.id = $1`
+	return scanSession(r.db.QueryRow(ctx, query, id))
+}
+
+// CompleteSession consumes the session's credit. Only scheduled sessions can
+// be completed.
+func (r *repositoryImpl) CompleteSession(ctx context.Context, id uuid.UUID, notes *string) error {
+	query := `
+		UPDATE training_sessions
+		SET status = 'completed', completed_at = NOW(), notes = COALESCE($2, notes), updated_at = NOW()
+		WHERE id = $1 AND status = 'scheduled'
+	`
+	tag, err := r.db.Exec(ctx, query, id, notes)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotScheduled
+	}
+	return nil
+}
+
+func (r *repositoryImpl) CancelSession(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE training_sessions
+		SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'scheduled'
+	`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotScheduled
+	}
+	return nil
+}
+
+func (r *repositoryImpl) ListTrainerSessions(ctx context.Context, trainerID uuid.UUID, from, to time.Time) ([]*Session, error) {
+	query := `SELECT ` + sessionColumns + sessionJoins + `
+		WHERE s.trainer_id = $1 AND s.starts_at >= $2 AND s.starts_at < $3
+		ORDER BY s.starts_at
+	`
+	return r.querySessions(ctx, query, trainerID, from, to)
+}
+
+func (r *repositoryImpl) ListMemberSessions(ctx context.Context, memberID uuid.UUID, limit, offset int) ([]*Session, error) {
+	query := `SELECT ` + sessionColumns + sessionJoins + `
+		WHERE s.member_id = $1
+		ORDER BY s.starts_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	return r.querySessions(ctx, query, memberID, limit, offset)
+}
+
+// TrainerReport aggregates sessions per trainer that started within
+// [from, to), optionally limited to one branch.
+func (r *repositoryImpl) TrainerReport(ctx context.Context, branchID *uuid.UUID, from, to time.Time) ([]*TrainerReport, error) {
+	query := `
+		SELECT s.trainer_id, TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')),
+			COUNT(*) FILTER (WHERE s.status = 'completed'),
+			COUNT(*) FILTER (WHERE s.status = 'cancelled'),
+			COUNT(*) FILTER (WHERE s.status = 'scheduled'),
+			COUNT(DISTINCT s.member_id) FILTER (WHERE s.status = 'completed'),
+			COALESCE(SUM(EXTRACT(EPOCH FROM s.ends_at - s.starts_at) / 60) FILTER (WHERE s.status = 'completed'), 0)::int
+		FROM training_sessions s
+		JOIN users u ON u.id = s.trainer_id
+		WHERE s.starts_at >= $1 AND s.starts_at < $2
+			AND ($3::uuid IS NULL OR s.branch_id = $3)
+		GROUP BY s.trainer_id, u.first_name, u.last_name
+		ORDER BY 3 DESC, 2
+	`
+	rows, err := r.db.Query(ctx, query, from, to, branchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []*TrainerReport
+	for rows.Next() {
+		var t TrainerReport
+		if err := rows.Scan(&t.TrainerID, &t.TrainerName, &t.Completed, &t.Cancelled, &t.Scheduled, &t.Members, &t.Minutes); err != nil {
+			return nil, err
+		}
+		report = append(report, &t)
+	}
+	return report, rows.Err()
+}
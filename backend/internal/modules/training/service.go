@@ -0,0 +1,337 @@
+package training
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04"
+
+	defaultSessionMinutes = 60
+)
+
+var (
+	ErrTrainerNotFound       = errors.New("trainer must be an active staff or admin user")
+	ErrMemberNotFound        = errors.New("member not found")
+	ErrSessionNotFound       = errors.New("training session not found")
+	ErrInvalidTimeRange      = errors.New("times must be HH:MM; an end at or before the start runs into the next day")
+	ErrSessionInPast         = errors.New("training sessions must be booked in the future")
+	ErrOutsideAvailability   = errors.New("trainer is not available at this time")
+	ErrSlotTaken             = errors.New("trainer or member already has a session at this time")
+	ErrNoActivePack          = errors.New("an active personal training pack is required")
+	ErrPackNotFound          = errors.New("personal training pack not found")
+	ErrPackExpired           = errors.New("personal training pack expires before this session")
+	ErrNoSessionCredits      = errors.New("no session credits left on this pack")
+	ErrSessionNotScheduled   = errors.New("training session is not scheduled")
+	ErrSessionNotStarted     = errors.New("training session has not started yet")
+	ErrNotSessionParticipant = errors.New("you are not part of this training session")
+)
+
+type Service interface {
+	GetAvailability(ctx context.Context, trainerID uuid.UUID) ([]*Availability, error)
+	SetAvailability(ctx context.Context, trainerID uuid.UUID, req *SetAvailabilityRequest) ([]*Availability, error)
+
+	// BookSession books a session as userID. Members always book for
+	// themselves; staff and admins book on behalf of req.MemberID.
+	BookSession(ctx context.Context, userID uuid.UUID, role string, req *BookSessionRequest) (*Session, error)
+	CompleteSession(ctx context.Context, userID uuid.UUID, role string, sessionID uuid.UUID, req *CompleteSessionRequest) (*Session, error)
+	CancelSession(ctx context.Context, userID uuid.UUID, role string, sessionID uuid.UUID) error
+
+	ListTrainerSessions(ctx context.Context, trainerID uuid.UUID, from, to time.Time) ([]*Session, error)
+	ListMySessions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*Session, error)
+	ListMyPacks(ctx context.Context, userID uuid.UUID) ([]*Pack, error)
+	ListMemberPacks(ctx context.Context, memberID uuid.UUID) ([]*Pack, error)
+
+	TrainerReport(ctx context.Context, branchID *uuid.UUID, from, to time.Time) ([]*TrainerReport, error)
+}
+
+type serviceImpl struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &serviceImpl{repo: repo}
+}
+
+func (s *serviceImpl) GetAvailability(ctx context.Context, trainerID uuid.UUID) ([]*Availability, error) {
+	return s.repo.ListAvailability(ctx, trainerID)
+}
+
+func (s *serviceImpl) SetAvailability(ctx context.Context, trainerID uuid.UUID, req *SetAvailabilityRequest) ([]*Availability, error) {
+	if err := s.ensureTrainer(ctx, trainerID); err != nil {
+		return nil, err
+	}
+
+	slots := make([]*Availability, 0, len(req.Slots))
+	for _, slot := range req.Slots {
+		// As with opening hours, a slot may run past midnight (20:00-01:00).
+		if _, err := time.Parse(timeLayout, slot.StartTime); err != nil {
+			return nil, ErrInvalidTimeRange
+		}
+		if slot.EndTime != "24:00" {
+			if _, err := time.Parse(timeLayout, slot.EndTime); err != nil {
+				return nil, ErrInvalidTimeRange
+			}
+		}
+		slots = append(slots, &Availability{
+			BranchID:  slot.BranchID,
+			DayOfWeek: slot.DayOfWeek,
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+		})
+	}
+
+	if err := s.repo.ReplaceAvailability(ctx, trainerID, slots); err != nil {
+		log.Printf("Service: SetAvailability failed for trainer %s - %v", trainerID, err)
+		return nil, err
+	}
+	return slots, nil
+}
+
+func (s *serviceImpl) BookSession(ctx context.Context, userID uuid.UUID, role string, req *BookSessionRequest) (*Session, error) {
+	var memberID uuid.UUID
+	if role == "member" {
+		id, err := s.memberIDForUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		memberID = id
+	} else {
+		if req.MemberID == nil {
+			return nil, ErrMemberNotFound
+		}
+		memberID = *req.MemberID
+	}
+
+	if err := s.ensureTrainer(ctx, req.TrainerID); err != nil {
+		return nil, err
+	}
+
+	if !req.StartsAt.After(time.Now()) {
+		return nil, ErrSessionInPast
+	}
+
+	duration := req.DurationMinutes
+	if duration == 0 {
+		duration = defaultSessionMinutes
+	}
+	startsAt := req.StartsAt.UTC()
+	endsAt := startsAt.Add(time.Duration(duration) * time.Minute)
+
+	available, err := s.repo.IsWithinAvailability(ctx, req.TrainerID, req.BranchID, startsAt, endsAt)
+	if err != nil {
+		return nil, err
+	}
+	if !available {
+		return nil, ErrOutsideAvailability
+	}
+
+	pack, err := s.pickPack(ctx, memberID, req.SubscriptionID, startsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		TrainerID:      req.TrainerID,
+		MemberID:       memberID,
+		SubscriptionID: pack.SubscriptionID,
+		BranchID:       req.BranchID,
+		StartsAt:       startsAt,
+		EndsAt:         endsAt,
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		if errors.Is(err, ErrNoSessionCredits) || errors.Is(err, ErrSlotTaken) || errors.Is(err, ErrPackNotFound) {
+			return nil, err
+		}
+		log.Printf("Service: BookSession failed for member %s - %v", memberID, err)
+		return nil, err
+	}
+
+	return s.getSession(ctx, session.ID)
+}
+
+// CompleteSession marks a session delivered, which consumes its credit.
+// Only the session's trainer or an admin may complete it.
+func (s *serviceImpl) CompleteSession(ctx context.Context, userID uuid.UUID, role string, sessionID uuid.UUID, req *CompleteSessionRequest) (*Session, error) {
+	session, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && session.TrainerID != userID {
+		return nil, ErrNotSessionParticipant
+	}
+	if session.Status != SessionScheduled {
+		return nil, ErrSessionNotScheduled
+	}
+	if time.Now().Before(session.StartsAt) {
+		return nil, ErrSessionNotStarted
+	}
+
+	if err := s.repo.CompleteSession(ctx, sessionID, req.Notes); err != nil {
+		if errors.Is(err, ErrSessionNotScheduled) {
+			return nil, err
+		}
+		log.Printf("Service: CompleteSession failed for %s - %v", sessionID, err)
+		return nil, err
+	}
+
+	return s.getSession(ctx, sessionID)
+}
+
+// CancelSession releases the reserved credit. Members may cancel their own
+// sessions; trainers their own; admins any.
+func (s *serviceImpl) CancelSession(ctx context.Context, userID uuid.UUID, role string, sessionID uuid.UUID) error {
+	session, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	switch role {
+	case "admin":
+	case "member":
+		memberID, err := s.memberIDForUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if session.MemberID != memberID {
+			return ErrNotSessionParticipant
+		}
+	default:
+		if session.TrainerID != userID {
+			return ErrNotSessionParticipant
+		}
+	}
+
+	if err := s.repo.CancelSession(ctx, sessionID); err != nil {
+		if errors.Is(err, ErrSessionNotScheduled) {
+			return err
+		}
+		log.Printf("Service: CancelSession failed for %s - %v", sessionID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *serviceImpl) ListTrainerSessions(ctx context.Context, trainerID uuid.UUID, from, to time.Time) ([]*Session, error) {
+	return s.repo.ListTrainerSessions(ctx, trainerID, from, to)
+}
+
+func (s *serviceImpl) ListMySessions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*Session, error) {
+	memberID, err := s.memberIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	return s.repo.ListMemberSessions(ctx, memberID, limit, offset)
+}
+
+func (s *serviceImpl) ListMyPacks(ctx context.Context, userID uuid.UUID) ([]*Pack, error) {
+	memberID, err := s.memberIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListPacks(ctx, memberID)
+}
+
+func (s *serviceImpl) ListMemberPacks(ctx context.Context, memberID uuid.UUID) ([]*Pack, error) {
+	return s.repo.ListPacks(ctx, memberID)
+}
+
+func (s *serviceImpl) TrainerReport(ctx context.Context, branchID *uuid.UUID, from, to time.Time) ([]*TrainerReport, error) {
+	return s.repo.TrainerReport(ctx, branchID, from, to)
+}
+
+// pickPack returns the requested pack, or the member's soonest-expiring pack
+// with credits left that is still valid on the session date.
+func (s *serviceImpl) pickPack(ctx context.Context, memberID uuid.UUID, subscriptionID *uuid.UUID, startsAt time.Time) (*Pack, error) {
+	packs, err := s.repo.ListPacks(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if len(packs) == 0 {
+		return nil, ErrNoActivePack
+	}
+
+	sessionDate := startsAt.Truncate(24 * time.Hour)
+	if subscriptionID != nil {
+		for _, pack := range packs {
+			if pack.SubscriptionID != *subscriptionID {
+				continue
+			}
+			if pack.ExpiresAt.Before(sessionDate) {
+				return nil, ErrPackExpired
+			}
+			if pack.Remaining() == 0 {
+				return nil, ErrNoSessionCredits
+			}
+			return pack, nil
+		}
+		return nil, ErrPackNotFound
+	}
+
+	expired := false
+	for _, pack := range packs {
+		if pack.ExpiresAt.Before(sessionDate) {
+			expired = true
+			continue
+		}
+		if pack.Remaining() > 0 {
+			return pack, nil
+		}
+	}
+	if expired {
+		return nil, ErrPackExpired
+	}
+	return nil, ErrNoSessionCredits
+}
+
+func (s *serviceImpl) ensureTrainer(ctx context.Context, trainerID uuid.UUID) error {
+	role, err := s.repo.GetUserRole(ctx, trainerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTrainerNotFound
+		}
+		return err
+	}
+	if role != "staff" && role != "admin" {
+		return ErrTrainerNotFound
+	}
+	return nil
+}
+
+func (s *serviceImpl) getSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	session, err := s.repo.GetSession(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *serviceImpl) memberIDForUser(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	memberID, err := s.repo.GetMemberIDByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrMemberNotFound
+		}
+		return uuid.Nil, err
+	}
+	return memberID, nil
+}
@@ -26,6 +26,7 @@ type Repository interface {
 	ListWithFilter(ctx context.Context, filter *UserListFilter) ([]*User, error)
 	GetUserBranchIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
 	GetMemberBranchID(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error)
+	GetUserOrganizationIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
 }
 
 type repositoryImpl struct {
@@ -310,6 +311,36 @@ func (r *repositoryImpl) GetUserBranchIDs(ctx context.Context, userID uuid.UUID)
 	return branchIDs, nil
 }
 
+// GetUserOrganizationIDs returns organizations the user owns or has a branch
+// assignment in.
+func (r *repositoryImpl) GetUserOrganizationIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM organization WHERE user_id = $1
+		UNION
+		SELECT b.organization_id
+		FROM user_branches ub
+		JOIN branches b ON b.id = ub.branch_id
+		WHERE ub.user_id = $1
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	organizationIDs := []uuid.UUID{}
+	for rows.Next() {
+		var organizationID uuid.UUID
+		if err := rows.Scan(&organizationID); err != nil {
+			return nil, err
+		}
+		organizationIDs = append(organizationIDs, organizationID)
+	}
+
+	return organizationIDs, rows.Err()
+}
+
 func (r *repositoryImpl) GetMemberBranchID(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error) {
 	query := `
 		SELECT home_branch_id
@@ -25,6 +25,7 @@ type Service interface {
 	ProfileByRole(ctx context.Context, id uuid.UUID, role string) (*UserProfileResponse, error)
 	ListUsersWithFilter(ctx context.Context, filter *UserListFilter) ([]*User, error)
 	GetUserBranchIDs(ctx context.Context, userID uuid.UUID, userRole string) ([]uuid.UUID, error)
+	GetUserOrganizationIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
 }
 
 type serviceImpl struct {
@@ -178,3 +179,7 @@ func (s *serviceImpl) GetUserBranchIDs(ctx context.Context, userID uuid.UUID, us
 
 	return s.repo.GetUserBranchIDs(ctx, userID)
 }
+
+func (s *serviceImpl) GetUserOrganizationIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return s.repo.GetUserOrganizationIDs(ctx, userID)
+}
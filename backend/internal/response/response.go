@@ -67,6 +67,17 @@ func Created(w http.ResponseWriter, message string) {
 	writeJSON(w, http.StatusCreated, response)
 }
 
+// Accepted creates a 202 Accepted response for work that continues in the
+// background
+func Accepted(w http.ResponseWriter, message string, data interface{}) {
+	response := Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	}
+	writeJSON(w, http.StatusAccepted, response)
+}
+
 // NoContent creates a 204 No Content response
 func NoContent(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNoContent)
@@ -116,6 +127,11 @@ func ValidationError(w http.ResponseWriter, message string, details interface{})
 	Error(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", message, details)
 }
 
+// TooManyRequests creates a 429 Too Many Requests response
+func TooManyRequests(w http.ResponseWriter, message string) {
+	Error(w, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", message, nil)
+}
+
 // InternalServerError creates a 500 Internal Server Error response
 func InternalServerError(w http.ResponseWriter, message string) {
 	Error(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", message, nil)
@@ -1,24 +1,47 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
+	"fitcore/internal/config"
+	"fitcore/internal/jobs"
+	authmw "fitcore/internal/middleware"
 	"fitcore/internal/modules/auth"
 	"fitcore/internal/modules/branch"
 	"fitcore/internal/modules/cache"
 	"fitcore/internal/modules/chat"
+	"fitcore/internal/modules/classes"
+	"fitcore/internal/modules/documents"
+	"fitcore/internal/modules/exports"
+	"fitcore/internal/modules/files"
+	"fitcore/internal/modules/finance"
+	"fitcore/internal/modules/groups"
+	"fitcore/internal/modules/hours"
+	"fitcore/internal/modules/imports"
+	"fitcore/internal/modules/insights"
 	"fitcore/internal/modules/invoice"
+	"fitcore/internal/modules/join"
+	"fitcore/internal/modules/leads"
 	"fitcore/internal/modules/member"
 	"fitcore/internal/modules/module"
+	"fitcore/internal/modules/occupancy"
 	"fitcore/internal/modules/organization"
+	"fitcore/internal/modules/passes"
 	"fitcore/internal/modules/plans"
+	"fitcore/internal/modules/portal"
+	"fitcore/internal/modules/privacy"
+	"fitcore/internal/modules/reports"
 	"fitcore/internal/modules/subscription"
+	"fitcore/internal/modules/training"
 	"fitcore/internal/modules/user"
 	"fitcore/internal/modules/webhooks"
 	"fitcore/pkg/email"
 	"fitcore/pkg/polar"
+	"fitcore/pkg/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -53,31 +76,132 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	userModule := user.NewModule(s.db.GetPool())
 	chatModule := chat.NewModule(s.db.GetPool())
-	cacheModule := cache.NewModule(s.db.GetPool())
+	cacheCfg := config.Get().Cache
+	cacheModule := cache.NewModule(s.db.GetPool(), cache.StoreConfig{
+		Driver:           cacheCfg.Driver,
+		Redis:            cacheCfg.Redis,
+		L1:               cacheCfg.L1,
+		L1TTL:            cacheCfg.L1TTL,
+		MemoryMaxEntries: cacheCfg.MemoryMaxEntries,
+		MemoryMaxBytes:   cacheCfg.MemoryMaxBytes,
+	})
 	authModule := auth.NewModule(s.db.GetPool(), userModule.Repository, emailService)
-	organizationModule := organization.NewModule(s.db.GetPool())
+	storageCfg := config.Get().Storage
+	store, err := storage.New(storage.Options{
+		Driver:   storageCfg.Driver,
+		LocalDir: storageCfg.LocalDir,
+		S3:       storageCfg.S3,
+	})
+	if err != nil {
+		log.Fatalf("Storage error: %v", err)
+	}
+	filesModule := files.NewProvider(s.db.GetPool(), store, storage.NewSigner(storageCfg.SigningKey), files.Config{
+		PublicURL: storageCfg.PublicURL,
+		URLTTL:    storageCfg.URLTTL,
+	})
+	organizationModule := organization.NewModule(s.db.GetPool(), filesModule.Service)
 	moduleModule := module.NewProvider(s.db.GetPool())
 	branchModule := branch.NewProvider(s.db.GetPool())
-	plansModule := plans.NewProvider(s.db.GetPool(), polarService)
+	plansModule := plans.NewProvider(s.db.GetPool(), polarService, cacheModule.Service)
 	invoiceModule := invoice.NewProvider(s.db.GetPool())
 	subscriptionModule := subscription.NewProvider(s.db.GetPool(), plansModule.Service, polarService, invoiceModule.Service, emailService, userModule.Repository)
-	memberModule := member.NewProvider(s.db.GetPool(), userModule.Service, subscriptionModule.Service, plansModule.Service, cacheModule.Service, chatModule.Service)
+	occupancyModule := occupancy.NewProvider(s.db.GetPool(), emailService)
+	classesModule := classes.NewProvider(s.db.GetPool(), subscriptionModule.Service, plansModule.Service)
+	trainingModule := training.NewProvider(s.db.GetPool())
+	hoursModule := hours.NewProvider(s.db.GetPool(), occupancyModule.Service, cacheModule.Service)
+	documentsModule := documents.NewProvider(s.db.GetPool(), userModule.Service, filesModule.Service)
+	memberModule := member.NewProvider(s.db.GetPool(), userModule.Service, subscriptionModule.Service, plansModule.Service, cacheModule.Service, chatModule.Service, occupancyModule.Service, hoursModule.Service, documentsModule.Service, filesModule.Service, s.analytics)
+	insightsModule := insights.NewProvider(s.db.GetPool(), userModule.Service)
+	reportsModule := reports.NewProvider(s.db.GetPool(), userModule.Service)
+	financeModule := finance.NewProvider(s.db.GetPool(), userModule.Service)
+	exportCfg := config.Get().Export
+	exportsModule := exports.NewProvider(s.db.GetPool(), userModule.Service, store, exports.Config{
+		MaxSyncRows: exportCfg.MaxSyncRows,
+	})
+	importsModule := imports.NewProvider(s.db.GetPool(), userModule.Service)
+	portalModule := portal.NewProvider(s.db.GetPool(), memberModule.Service, subscriptionModule.Service, invoiceModule.Service, plansModule.Service)
+	joinCfg := config.Get().Join
+	joinModule := join.NewProvider(s.db.GetPool(), organizationModule.Service, plansModule.Service, memberModule.Service, userModule.Service, documentsModule.Service, join.Config{
+		CaptchaSecret:    joinCfg.CaptchaSecret,
+		CaptchaVerifyURL: joinCfg.CaptchaVerifyURL,
+		RateLimit:        joinCfg.RateLimit,
+		RateWindow:       joinCfg.RateWindow,
+	})
+	leadsModule := leads.NewProvider(s.db.GetPool(), userModule.Service, branchModule.Service, memberModule.Service, subscriptionModule.Service, hoursModule.Service, occupancyModule.Service, documentsModule.Service)
+	passesModule := passes.NewProvider(s.db.GetPool(), userModule.Service, branchModule.Service, memberModule.Service, subscriptionModule.Service, plansModule.Service, hoursModule.Service, occupancyModule.Service, documentsModule.Service, polarService, emailService)
+	groupsModule := groups.NewProvider(s.db.GetPool(), userModule.Service, memberModule.Service, subscriptionModule.Service, emailService)
+	privacyModule := privacy.NewProvider(s.db.GetPool(), userModule.Service, filesModule.Service, cacheModule.Service, store)
 	webhooksModule := webhooks.NewProvider(s.db.GetPool(), invoiceModule.Service, subscriptionModule.Service, memberModule.Service, *emailService, userModule.Service, userModule.Repository)
 
 	userModule.RegisterRoutes(r)
 	cacheModule.RegisterRoutes(r)
 	authModule.RegisterRoutes(r)
 	organizationModule.RegisterRoutes(r)
+	filesModule.RegisterRoutes(r)
 	moduleModule.RegisterRoutes(r)
 	branchModule.RegisterRoutes(r)
 	plansModule.RegisterRoutes(r)
 	memberModule.RegisterRoutes(r)
+	portalModule.RegisterRoutes(r)
+	joinModule.RegisterRoutes(r)
+	leadsModule.RegisterRoutes(r)
+	passesModule.RegisterRoutes(r)
+	groupsModule.RegisterRoutes(r)
+	documentsModule.RegisterRoutes(r)
+	privacyModule.RegisterRoutes(r)
+	occupancyModule.RegisterRoutes(r)
+	hoursModule.RegisterRoutes(r)
+	classesModule.RegisterRoutes(r)
+	trainingModule.RegisterRoutes(r)
+	insightsModule.RegisterRoutes(r)
+	reportsModule.RegisterRoutes(r)
+	financeModule.RegisterRoutes(r)
+	exportsModule.RegisterRoutes(r)
+	importsModule.RegisterRoutes(r)
 	subscriptionModule.RegisterRoutes(r)
 	invoiceModule.RegisterRoutes(r)
 	webhooksModule.RegisterRoutes(r)
 
+	go occupancyModule.Service.Listen(context.Background())
+	go cacheModule.Service.Listen(context.Background())
+	go exportsModule.Service.Run(context.Background())
+	go privacyModule.Service.Run(context.Background())
+	go jobs.Every(context.Background(), "auto-checkout", time.Minute, func(ctx context.Context) error {
+		_, err := hoursModule.Service.AutoCheckout(ctx)
+		return err
+	})
+	go jobs.Every(context.Background(), "class-sessions", 5*time.Minute, func(ctx context.Context) error {
+		if err := classesModule.Service.GenerateSessions(ctx); err != nil {
+			return err
+		}
+		_, err := classesModule.Service.ProcessAttendance(ctx)
+		return err
+	})
+	go jobs.Every(context.Background(), "cache-cleanup", 15*time.Minute, func(ctx context.Context) error {
+		_, err := cacheModule.Service.Cleanup(ctx)
+		return err
+	})
+	go jobs.Every(context.Background(), "report-views", 15*time.Minute, func(ctx context.Context) error {
+		_, err := reportsModule.Service.Refresh(ctx)
+		return err
+	})
+	go jobs.Every(context.Background(), "lead-conversions", 15*time.Minute, func(ctx context.Context) error {
+		_, err := leadsModule.Service.ProcessConversions(ctx)
+		return err
+	})
+	go jobs.Every(context.Background(), "group-billing", time.Hour, func(ctx context.Context) error {
+		_, err := groupsModule.Service.BillDueGroups(ctx)
+		return err
+	})
+	go jobs.Every(context.Background(), "export-cleanup", time.Hour, exportsModule.Service.Cleanup)
+	go jobs.Every(context.Background(), "privacy-cleanup", time.Hour, privacyModule.Service.Cleanup)
+	go jobs.Every(context.Background(), "member-risk-scores", 24*time.Hour, insightsModule.Service.ScoreAllBranches)
+
 	r.Get("/", s.HelloWorldHandler)
 	r.Get("/health", s.healthHandler)
+	// Per-endpoint stats include upstream error messages, so they are for
+	// super admins only.
+	r.With(authmw.AuthMiddleware, authmw.RoleMiddleware("super_admin")).Get("/health/analytics", s.analyticsHealthHandler)
 
 	return r
 }
@@ -100,3 +224,9 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	jsonResp, _ := json.Marshal(s.db.Health())
 	_, _ = w.Write(jsonResp)
 }
+
+func (s *Server) analyticsHealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	jsonResp, _ := json.Marshal(s.analytics.Stats())
+	_, _ = w.Write(jsonResp)
+}
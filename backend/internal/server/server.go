@@ -3,6 +3,7 @@ package server
 import (
 	"fitcore/internal/config"
 	"fitcore/internal/database"
+	"fitcore/pkg/analytics"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,17 +13,30 @@ import (
 )
 
 type Server struct {
-	port int
-	db   database.Service
+	port      int
+	db        database.Service
+	analytics *analytics.Client
 }
 
 func NewServer() *http.Server {
 	if err := config.Init(); err != nil {
 		log.Fatalf("Config error: %v", err)
 	}
+	cfg := config.Get()
 	NewServer := &Server{
-		port: config.Get().App.Port,
+		port: cfg.App.Port,
 		db:   database.New(),
+		analytics: analytics.NewClient(analytics.Config{
+			BaseURL:         cfg.Analytics.BaseURL,
+			WellnessPath:    cfg.Analytics.WellnessPath,
+			ChatPath:        cfg.Analytics.ChatPath,
+			ChatStreamPath:  cfg.Analytics.ChatStreamPath,
+			ChatSummaryPath: cfg.Analytics.ChatSummaryPath,
+			Token:           cfg.Analytics.ServiceToken,
+			WellnessTimeout: cfg.Analytics.Timeout,
+			ChatTimeout:     cfg.Analytics.ChatTimeout,
+			MaxRetries:      2,
+		}),
 	}
 
 	server := &http.Server{
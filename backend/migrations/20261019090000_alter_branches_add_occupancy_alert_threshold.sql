@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Number of people inside a branch at which a "near capacity" event is emitted.
+ALTER TABLE branches ADD COLUMN occupancy_alert_threshold INT CHECK (occupancy_alert_threshold > 0);
+
+CREATE INDEX IF NOT EXISTS idx_checkins_branch_open ON check_ins(branch_id) WHERE check_out_time IS NULL AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_checkins_branch_open;
+ALTER TABLE branches DROP COLUMN IF EXISTS occupancy_alert_threshold;
+-- +goose StatementEnd
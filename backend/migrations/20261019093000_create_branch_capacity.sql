@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE branches ADD COLUMN max_occupancy INT CHECK (max_occupancy > 0);
+
+-- Overrides branches.max_occupancy during a time window (branch local time).
+-- day_of_week follows EXTRACT(DOW): 0 = Sunday ... 6 = Saturday, NULL = every day.
+CREATE TABLE branch_capacity_windows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    day_of_week SMALLINT CHECK (day_of_week BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    max_occupancy INT NOT NULL CHECK (max_occupancy > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX idx_branch_capacity_windows_branch_id ON branch_capacity_windows(branch_id);
+
+CREATE TABLE branch_waitlist (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'notified', 'admitted', 'cancelled')),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    notified_at TIMESTAMPTZ
+);
+
+-- A member can only wait once per branch at a time.
+CREATE UNIQUE INDEX idx_branch_waitlist_active ON branch_waitlist(branch_id, member_id) WHERE status = 'waiting';
+CREATE INDEX idx_branch_waitlist_queue ON branch_waitlist(branch_id, created_at) WHERE status = 'waiting';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS branch_waitlist;
+DROP TABLE IF EXISTS branch_capacity_windows;
+ALTER TABLE branches DROP COLUMN IF EXISTS max_occupancy;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Opening hours per weekday in branch local time. A branch without any rows
+-- is treated as open 24/7. day_of_week follows EXTRACT(DOW): 0 = Sunday.
+CREATE TABLE branch_opening_hours (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    open_time TIME NOT NULL,
+    close_time TIME NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (close_time > open_time),
+    UNIQUE(branch_id, day_of_week, open_time)
+);
+
+CREATE INDEX idx_branch_opening_hours_branch_id ON branch_opening_hours(branch_id);
+
+-- Full-day closures such as public holidays.
+CREATE TABLE branch_closures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    closure_date DATE NOT NULL,
+    reason VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(branch_id, closure_date)
+);
+
+-- Restricts when members on a plan may check in (e.g. off-peak plans).
+-- A plan without any rows can enter whenever the branch is open.
+CREATE TABLE plan_access_windows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    plan_id UUID NOT NULL REFERENCES membership_plans(id) ON DELETE CASCADE,
+    day_of_week SMALLINT CHECK (day_of_week BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX idx_plan_access_windows_plan_id ON plan_access_windows(plan_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS plan_access_windows;
+DROP TABLE IF EXISTS branch_closures;
+DROP TABLE IF EXISTS branch_opening_hours;
+-- +goose StatementEnd
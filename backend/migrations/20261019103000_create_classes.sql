@@ -0,0 +1,91 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE class_booking_status_enum AS ENUM ('booked', 'waitlisted', 'cancelled', 'attended', 'no_show');
+
+CREATE TABLE class_types (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    default_duration_minutes INT NOT NULL DEFAULT 60 CHECK (default_duration_minutes > 0),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(organization_id, name)
+);
+
+CREATE INDEX idx_class_types_organization_id ON class_types(organization_id);
+
+-- Recurring weekly slot. Concrete occurrences live in class_sessions.
+CREATE TABLE class_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    class_type_id UUID NOT NULL REFERENCES class_types(id) ON DELETE CASCADE,
+    instructor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    room VARCHAR(100),
+    capacity INT NOT NULL CHECK (capacity > 0),
+    day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    duration_minutes INT NOT NULL CHECK (duration_minutes > 0),
+    cancellation_cutoff_minutes INT NOT NULL DEFAULT 120 CHECK (cancellation_cutoff_minutes >= 0),
+    valid_from DATE NOT NULL DEFAULT CURRENT_DATE,
+    valid_until DATE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_class_schedules_branch_id ON class_schedules(branch_id);
+
+CREATE TABLE class_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    schedule_id UUID REFERENCES class_schedules(id) ON DELETE SET NULL,
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    class_type_id UUID NOT NULL REFERENCES class_types(id) ON DELETE CASCADE,
+    instructor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    room VARCHAR(100),
+    capacity INT NOT NULL CHECK (capacity > 0),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    cancellation_cutoff_minutes INT NOT NULL DEFAULT 120,
+    is_cancelled BOOLEAN DEFAULT FALSE,
+    attendance_processed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (ends_at > starts_at),
+    UNIQUE(schedule_id, starts_at)
+);
+
+CREATE INDEX idx_class_sessions_branch_starts ON class_sessions(branch_id, starts_at);
+
+CREATE TABLE class_bookings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    session_id UUID NOT NULL REFERENCES class_sessions(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    subscription_id UUID REFERENCES subscriptions(id) ON DELETE SET NULL,
+    status class_booking_status_enum NOT NULL DEFAULT 'booked',
+    check_in_id UUID REFERENCES check_ins(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    cancelled_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_class_bookings_active ON class_bookings(session_id, member_id) WHERE status <> 'cancelled';
+CREATE INDEX idx_class_bookings_member_id ON class_bookings(member_id);
+CREATE INDEX idx_class_bookings_subscription_id ON class_bookings(subscription_id);
+
+-- NULL class_credits = unlimited classes; NULL class_type_ids = all class types.
+ALTER TABLE membership_plans ADD COLUMN class_credits INT CHECK (class_credits >= 0);
+ALTER TABLE membership_plans ADD COLUMN class_type_ids UUID[];
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE membership_plans DROP COLUMN IF EXISTS class_type_ids;
+ALTER TABLE membership_plans DROP COLUMN IF EXISTS class_credits;
+DROP TABLE IF EXISTS class_bookings;
+DROP TABLE IF EXISTS class_sessions;
+DROP TABLE IF EXISTS class_schedules;
+DROP TABLE IF EXISTS class_types;
+DROP TYPE IF EXISTS class_booking_status_enum;
+-- +goose StatementEnd
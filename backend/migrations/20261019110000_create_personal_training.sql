@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A plan with session_credits is a personal training pack: it is sold through
+-- the normal subscription flow, expires after duration_days and does not
+-- grant gym access on its own.
+ALTER TABLE membership_plans
+    ADD COLUMN session_credits INT CHECK (session_credits IS NULL OR session_credits > 0);
+
+CREATE TYPE training_session_status_enum AS ENUM ('scheduled', 'completed', 'cancelled');
+
+-- Weekly windows in branch local time when a trainer can be booked.
+CREATE TABLE trainer_availability (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trainer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX idx_trainer_availability_trainer_id ON trainer_availability(trainer_id);
+
+CREATE TABLE training_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    trainer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    status training_session_status_enum NOT NULL DEFAULT 'scheduled',
+    notes TEXT,
+    completed_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_training_sessions_trainer_starts ON training_sessions(trainer_id, starts_at);
+CREATE INDEX idx_training_sessions_member_id ON training_sessions(member_id);
+CREATE INDEX idx_training_sessions_subscription_id ON training_sessions(subscription_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS training_sessions;
+DROP TABLE IF EXISTS trainer_availability;
+DROP TYPE IF EXISTS training_session_status_enum;
+ALTER TABLE membership_plans DROP COLUMN IF EXISTS session_credits;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- summary covers every message created up to summarized_until; later
+-- messages are sent to the assistant verbatim.
+ALTER TABLE chat_sessions
+    ADD COLUMN summarized_until TIMESTAMPTZ,
+    ADD COLUMN title_generated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE chat_sessions
+    DROP COLUMN IF EXISTS summarized_until,
+    DROP COLUMN IF EXISTS title_generated;
+
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE member_request_type_enum AS ENUM ('freeze', 'renewal');
+CREATE TYPE member_request_status_enum AS ENUM ('pending', 'approved', 'rejected', 'cancelled');
+
+-- Requests raised by members (in the app or through the chat assistant) that
+-- staff must review before anything changes on the subscription.
+CREATE TABLE member_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    type member_request_type_enum NOT NULL,
+    status member_request_status_enum NOT NULL DEFAULT 'pending',
+    plan_id UUID REFERENCES membership_plans(id) ON DELETE SET NULL,
+    freeze_start DATE,
+    freeze_days INT CHECK (freeze_days IS NULL OR freeze_days > 0),
+    note TEXT,
+    source VARCHAR(20) NOT NULL DEFAULT 'app',
+    chat_session_id UUID REFERENCES chat_sessions(id) ON DELETE SET NULL,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    resolution_note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (type <> 'freeze' OR (freeze_start IS NOT NULL AND freeze_days IS NOT NULL))
+);
+
+-- At most one open request of each type per member.
+CREATE UNIQUE INDEX idx_member_requests_pending ON member_requests(member_id, type) WHERE status = 'pending';
+CREATE INDEX idx_member_requests_status ON member_requests(status, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS member_requests;
+DROP TYPE IF EXISTS member_request_status_enum;
+DROP TYPE IF EXISTS member_request_type_enum;
+
+-- +goose StatementEnd
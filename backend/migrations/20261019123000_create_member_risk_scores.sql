@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- One row per member per scoring run. All rows of a branch run share the
+-- same computed_at, so the latest run can be selected by timestamp.
+CREATE TABLE member_risk_scores (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    churn_score SMALLINT NOT NULL CHECK (churn_score BETWEEN 0 AND 100),
+    churn_level VARCHAR(10) NOT NULL,
+    burnout_score SMALLINT NOT NULL CHECK (burnout_score BETWEEN 0 AND 100),
+    burnout_level VARCHAR(10) NOT NULL,
+    reasons JSONB NOT NULL DEFAULT '[]',
+    metrics JSONB NOT NULL DEFAULT '{}',
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_member_risk_scores_branch_computed ON member_risk_scores(branch_id, computed_at DESC);
+CREATE INDEX idx_member_risk_scores_member_computed ON member_risk_scores(member_id, computed_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS member_risk_scores;
+
+-- +goose StatementEnd
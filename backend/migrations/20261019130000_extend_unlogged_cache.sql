@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- stale_at marks when an entry should be refreshed in the background; it is
+-- still served until expires_at. Tags allow invalidating related entries.
+ALTER TABLE unlogged_cache
+    ADD COLUMN IF NOT EXISTS stale_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_unlogged_cache_tags ON unlogged_cache USING GIN (tags);
+
+-- Expired entries are now cleaned up by the application.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_cron') THEN
+        PERFORM cron.unschedule('cleanup_expired_cache');
+    END IF;
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'cleanup_expired_cache was not scheduled';
+END $$;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_unlogged_cache_tags;
+ALTER TABLE unlogged_cache
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS stale_at;
+
+DO $do$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_cron') THEN
+        PERFORM cron.schedule('cleanup_expired_cache', '0 * * * *', $$DELETE FROM unlogged_cache WHERE expires_at < NOW()$$);
+    END IF;
+END $do$;
+
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE export_job_status_enum AS ENUM ('pending', 'running', 'completed', 'failed', 'expired');
+
+-- Exports too large to stream in a request. The worker writes the file to
+-- the export directory and the owner downloads it until expires_at.
+CREATE TABLE export_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    -- The filter after role and organization scoping was applied.
+    filter JSONB NOT NULL DEFAULT '{}',
+    status export_job_status_enum NOT NULL DEFAULT 'pending',
+    row_count INT,
+    file_path TEXT,
+    file_size BIGINT,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_export_jobs_user ON export_jobs(user_id, created_at DESC);
+CREATE INDEX idx_export_jobs_queue ON export_jobs(created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_export_jobs_expires ON export_jobs(expires_at) WHERE status = 'completed';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS export_jobs;
+DROP TYPE IF EXISTS export_job_status_enum;
+
+-- +goose StatementEnd
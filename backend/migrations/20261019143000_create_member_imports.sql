@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE member_import_status_enum AS ENUM ('validated', 'running', 'completed', 'failed');
+
+-- One uploaded member CSV. A dry run stops at 'validated'; results holds the
+-- outcome of every row and backs the downloadable result file.
+CREATE TABLE member_imports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    dry_run BOOLEAN NOT NULL,
+    status member_import_status_enum NOT NULL,
+    total_rows INT NOT NULL DEFAULT 0,
+    valid_rows INT NOT NULL DEFAULT 0,
+    created_rows INT NOT NULL DEFAULT 0,
+    failed_rows INT NOT NULL DEFAULT 0,
+    results JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_member_imports_organization ON member_imports(organization_id, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS member_imports;
+DROP TYPE IF EXISTS member_import_status_enum;
+
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TYPE member_request_type_enum ADD VALUE IF NOT EXISTS 'cancellation';
+
+ALTER TABLE members
+    ADD COLUMN emergency_contact_name VARCHAR(255),
+    ADD COLUMN emergency_contact_phone VARCHAR(50),
+    ADD COLUMN emergency_contact_relationship VARCHAR(100);
+
+-- Opt-outs for optional emails. Members without a row get the defaults;
+-- payment and account emails are always sent.
+CREATE TABLE member_notification_preferences (
+    member_id UUID PRIMARY KEY REFERENCES members(id) ON DELETE CASCADE,
+    waitlist_emails BOOLEAN NOT NULL DEFAULT TRUE,
+    class_reminder_emails BOOLEAN NOT NULL DEFAULT TRUE,
+    renewal_reminder_emails BOOLEAN NOT NULL DEFAULT TRUE,
+    marketing_emails BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS member_notification_preferences;
+
+ALTER TABLE members
+    DROP COLUMN IF EXISTS emergency_contact_name,
+    DROP COLUMN IF EXISTS emergency_contact_phone,
+    DROP COLUMN IF EXISTS emergency_contact_relationship;
+
+-- Enum values cannot be dropped, so the type is rebuilt without it.
+DELETE FROM member_requests WHERE type = 'cancellation';
+DROP INDEX IF EXISTS idx_member_requests_pending;
+ALTER TYPE member_request_type_enum RENAME TO member_request_type_enum_old;
+CREATE TYPE member_request_type_enum AS ENUM ('freeze', 'renewal');
+ALTER TABLE member_requests
+    ALTER COLUMN type TYPE member_request_type_enum USING type::text::member_request_type_enum;
+DROP TYPE member_request_type_enum_old;
+CREATE UNIQUE INDEX idx_member_requests_pending ON member_requests(member_id, type) WHERE status = 'pending';
+
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Consent given on the public join form, kept as evidence of when and from
+-- where the member agreed to the terms.
+CREATE TABLE member_signups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    plan_id UUID REFERENCES membership_plans(id) ON DELETE SET NULL,
+    subscription_id UUID REFERENCES subscriptions(id) ON DELETE SET NULL,
+    email VARCHAR(255) NOT NULL,
+    terms_accepted_at TIMESTAMPTZ NOT NULL,
+    marketing_opt_in BOOLEAN NOT NULL DEFAULT FALSE,
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_member_signups_organization ON member_signups(organization_id, created_at DESC);
+CREATE INDEX idx_member_signups_member ON member_signups(member_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS member_signups;
+
+-- +goose StatementEnd
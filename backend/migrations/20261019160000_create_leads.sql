@@ -0,0 +1,92 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE lead_stage_enum AS ENUM ('new', 'contacted', 'trial', 'negotiating', 'won', 'lost');
+
+-- Where leads come from, e.g. walk-in, referral or a campaign.
+CREATE TABLE lead_sources (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(organization_id, name)
+);
+
+-- Sales pipeline data for a prospect. The person is a members row with
+-- status 'lead' and no login until they convert; converted_at is set by the
+-- conversion job from the member's first paid invoice.
+CREATE TABLE leads (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL UNIQUE REFERENCES members(id) ON DELETE CASCADE,
+    source_id UUID REFERENCES lead_sources(id) ON DELETE SET NULL,
+    assigned_to UUID REFERENCES users(id) ON DELETE SET NULL,
+    email VARCHAR(255),
+    stage lead_stage_enum NOT NULL DEFAULT 'new',
+    stage_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    lost_reason TEXT,
+    converted_at TIMESTAMPTZ,
+    converted_subscription_id UUID REFERENCES subscriptions(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_leads_organization_stage ON leads(organization_id, stage);
+CREATE INDEX idx_leads_branch_id ON leads(branch_id);
+CREATE INDEX idx_leads_assigned_to ON leads(assigned_to);
+CREATE INDEX idx_leads_unconverted ON leads(member_id) WHERE converted_at IS NULL;
+CREATE UNIQUE INDEX idx_leads_organization_email ON leads(organization_id, LOWER(email)) WHERE email IS NOT NULL;
+
+CREATE TABLE lead_tasks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lead_id UUID NOT NULL REFERENCES leads(id) ON DELETE CASCADE,
+    assigned_to UUID REFERENCES users(id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    notes TEXT,
+    due_at TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_lead_tasks_lead_id ON lead_tasks(lead_id);
+CREATE INDEX idx_lead_tasks_open ON lead_tasks(assigned_to, due_at) WHERE completed_at IS NULL;
+
+-- Lets a lead check in at a branch without a subscription, for a limited
+-- time and number of visits.
+CREATE TABLE trial_passes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lead_id UUID NOT NULL REFERENCES leads(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_until TIMESTAMPTZ NOT NULL,
+    max_visits INT NOT NULL DEFAULT 1 CHECK (max_visits > 0),
+    visits_used INT NOT NULL DEFAULT 0 CHECK (visits_used >= 0),
+    issued_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (valid_until > valid_from),
+    CHECK (visits_used <= max_visits)
+);
+
+CREATE INDEX idx_trial_passes_lead_id ON trial_passes(lead_id);
+
+ALTER TABLE check_ins ADD COLUMN trial_pass_id UUID REFERENCES trial_passes(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE check_ins DROP COLUMN IF EXISTS trial_pass_id;
+DROP TABLE IF EXISTS trial_passes;
+DROP TABLE IF EXISTS lead_tasks;
+DROP TABLE IF EXISTS leads;
+DROP TABLE IF EXISTS lead_sources;
+DROP TYPE IF EXISTS lead_stage_enum;
+
+-- +goose StatementEnd
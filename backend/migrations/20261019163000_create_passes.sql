@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- NULL or 0 means members on the plan cannot bring guests.
+ALTER TABLE membership_plans
+    ADD COLUMN guest_passes_per_month INT CHECK (guest_passes_per_month IS NULL OR guest_passes_per_month >= 0);
+
+-- What a branch charges for a day pass. product_id is the Polar product used
+-- for online checkouts.
+CREATE TABLE day_pass_prices (
+    branch_id UUID PRIMARY KEY REFERENCES branches(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    price DECIMAL(12, 2) NOT NULL CHECK (price >= 0),
+    product_id VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TYPE pass_kind_enum AS ENUM ('day', 'guest');
+
+-- A one-time entry for someone without a subscription at one branch on one
+-- date. The visitor is a members row with status 'lead'. Day passes are paid
+-- through their invoice; guest passes are issued by a host member out of
+-- their plan's monthly quota.
+CREATE TABLE passes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    branch_id UUID NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    kind pass_kind_enum NOT NULL,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    valid_on DATE NOT NULL,
+    guest_member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    host_member_id UUID REFERENCES members(id) ON DELETE CASCADE,
+    invoice_id UUID REFERENCES invoices(id) ON DELETE SET NULL,
+    redeemed_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    issued_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK ((kind = 'guest') = (host_member_id IS NOT NULL))
+);
+
+CREATE INDEX idx_passes_branch_valid_on ON passes(branch_id, valid_on);
+CREATE INDEX idx_passes_host_member_id ON passes(host_member_id, valid_on) WHERE host_member_id IS NOT NULL;
+CREATE INDEX idx_passes_invoice_id ON passes(invoice_id);
+
+ALTER TABLE check_ins ADD COLUMN pass_id UUID REFERENCES passes(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE check_ins DROP COLUMN IF EXISTS pass_id;
+DROP TABLE IF EXISTS passes;
+DROP TYPE IF EXISTS pass_kind_enum;
+DROP TABLE IF EXISTS day_pass_prices;
+ALTER TABLE membership_plans DROP COLUMN IF EXISTS guest_passes_per_month;
+
+-- +goose StatementEnd
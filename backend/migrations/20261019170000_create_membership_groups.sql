@@ -0,0 +1,72 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A plan with max_seats is sold to groups; the seats include the payer.
+ALTER TABLE membership_plans
+    ADD COLUMN max_seats INT CHECK (max_seats IS NULL OR max_seats > 1);
+
+CREATE TYPE membership_group_kind_enum AS ENUM ('family', 'corporate');
+
+-- A family or company sharing one plan. The primary seat pays: a family
+-- through the checkout of its subscription, a company through a monthly
+-- invoice for its active seats. Dependents check in on the primary's
+-- subscription.
+CREATE TABLE membership_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    branch_id UUID REFERENCES branches(id) ON DELETE SET NULL,
+    kind membership_group_kind_enum NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    plan_id UUID NOT NULL REFERENCES membership_plans(id),
+    billing_email VARCHAR(255),
+    next_billing_date DATE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_membership_groups_organization_id ON membership_groups(organization_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_membership_groups_next_billing_date ON membership_groups(next_billing_date)
+    WHERE kind = 'corporate' AND deleted_at IS NULL;
+
+-- Seats are kept after removal for the billing history.
+CREATE TABLE membership_group_members (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES membership_groups(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    added_at TIMESTAMPTZ DEFAULT NOW(),
+    removed_at TIMESTAMPTZ
+);
+
+-- A member holds at most one seat, and a group exactly one primary.
+CREATE UNIQUE INDEX idx_membership_group_members_member_id ON membership_group_members(member_id) WHERE removed_at IS NULL;
+CREATE UNIQUE INDEX idx_membership_group_members_primary ON membership_group_members(group_id) WHERE is_primary AND removed_at IS NULL;
+CREATE INDEX idx_membership_group_members_group_id ON membership_group_members(group_id) WHERE removed_at IS NULL;
+
+-- One row per billed month of a corporate group.
+CREATE TABLE membership_group_billings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES membership_groups(id) ON DELETE CASCADE,
+    invoice_id UUID REFERENCES invoices(id) ON DELETE SET NULL,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    seats INT NOT NULL,
+    seat_price DECIMAL(12, 2) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (group_id, period_start)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS membership_group_billings;
+DROP TABLE IF EXISTS membership_group_members;
+DROP TABLE IF EXISTS membership_groups;
+DROP TYPE IF EXISTS membership_group_kind_enum;
+ALTER TABLE membership_plans DROP COLUMN IF EXISTS max_seats;
+
+-- +goose StatementEnd
@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE document_kind_enum AS ENUM ('waiver', 'health_questionnaire', 'marketing_consent', 'other');
+
+-- A document members of an organization sign. current_version is the
+-- latest published text; a signature of an older one is outdated.
+CREATE TABLE document_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    kind document_kind_enum NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    is_required BOOLEAN NOT NULL DEFAULT TRUE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    current_version INT NOT NULL DEFAULT 1,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_document_templates_organization_id ON document_templates(organization_id) WHERE deleted_at IS NULL;
+
+-- Published text of a template, never edited once created. questions are
+-- the yes/no questions of a health questionnaire. content_hash is the
+-- SHA-256 of body and questions.
+CREATE TABLE document_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    template_id UUID NOT NULL REFERENCES document_templates(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    body TEXT NOT NULL,
+    questions JSONB,
+    content_hash CHAR(64) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (template_id, version)
+);
+
+-- The signed record. document_hash is the content hash of the version the
+-- member saw; record_hash covers the whole record so later edits show.
+-- flagged marks a health questionnaire with a yes answer.
+CREATE TABLE document_signatures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    template_id UUID NOT NULL REFERENCES document_templates(id) ON DELETE CASCADE,
+    version_id UUID NOT NULL REFERENCES document_versions(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    signer_name VARCHAR(255) NOT NULL,
+    signature TEXT NOT NULL,
+    answers JSONB,
+    flagged BOOLEAN NOT NULL DEFAULT FALSE,
+    document_hash CHAR(64) NOT NULL,
+    record_hash CHAR(64) NOT NULL,
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('onboarding', 'portal', 'front_desk')),
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    collected_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    signed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_document_signatures_member_template ON document_signatures(member_id, template_id, version DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS document_signatures;
+DROP TABLE IF EXISTS document_versions;
+DROP TABLE IF EXISTS document_templates;
+DROP TYPE IF EXISTS document_kind_enum;
+
+-- +goose StatementEnd
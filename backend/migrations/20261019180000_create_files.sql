@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An uploaded file. storage_key locates it in the configured store;
+-- thumbnail_key is a small JPEG kept next to uploaded images.
+CREATE TABLE files (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organization(id) ON DELETE CASCADE,
+    purpose VARCHAR(30) NOT NULL CHECK (purpose IN ('logo', 'member_photo', 'document')),
+    storage_key VARCHAR(512) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(512),
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    original_name VARCHAR(255),
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_files_organization_id ON files(organization_id) WHERE deleted_at IS NULL;
+
+ALTER TABLE organization ADD COLUMN logo_file_id UUID REFERENCES files(id) ON DELETE SET NULL;
+ALTER TABLE members ADD COLUMN photo_file_id UUID REFERENCES files(id) ON DELETE SET NULL;
+
+-- A scan or photo of the paper copy, e.g. of a waiver signed at the desk.
+ALTER TABLE document_signatures ADD COLUMN attachment_file_id UUID REFERENCES files(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE document_signatures DROP COLUMN IF EXISTS attachment_file_id;
+ALTER TABLE members DROP COLUMN IF EXISTS photo_file_id;
+ALTER TABLE organization DROP COLUMN IF EXISTS logo_file_id;
+DROP TABLE IF EXISTS files;
+
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE privacy_request_kind_enum AS ENUM ('export', 'erasure');
+CREATE TYPE privacy_request_status_enum AS ENUM ('pending', 'running', 'completed', 'failed', 'rejected', 'expired');
+
+-- A data subject request: a member, or an admin on their behalf, asking for
+-- a copy of the member's data or for it to be erased. Exports are built by
+-- the worker into an archive kept in storage until expires_at; erasures wait
+-- for an admin to approve or reject them.
+CREATE TABLE privacy_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    member_id UUID NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    kind privacy_request_kind_enum NOT NULL,
+    status privacy_request_status_enum NOT NULL DEFAULT 'pending',
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    storage_key VARCHAR(512),
+    file_size BIGINT,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_privacy_requests_organization ON privacy_requests(organization_id, created_at DESC);
+CREATE INDEX idx_privacy_requests_member ON privacy_requests(member_id, created_at DESC);
+CREATE INDEX idx_privacy_requests_queue ON privacy_requests(created_at) WHERE kind = 'export' AND status IN ('pending', 'running');
+CREATE INDEX idx_privacy_requests_expires ON privacy_requests(expires_at) WHERE status = 'completed';
+-- One open request of each kind per member.
+CREATE UNIQUE INDEX idx_privacy_requests_open ON privacy_requests(member_id, kind) WHERE status IN ('pending', 'running');
+
+-- The audit trail of a request: every step, who took it and when. Rows are
+-- only ever added.
+CREATE TABLE privacy_request_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    request_id UUID NOT NULL REFERENCES privacy_requests(id) ON DELETE CASCADE,
+    action VARCHAR(30) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    detail TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_privacy_request_events_request ON privacy_request_events(request_id, created_at);
+
+-- Set when the member's personal fields were erased; the row stays because
+-- invoices kept for tax purposes reference it.
+ALTER TABLE members ADD COLUMN anonymized_at TIMESTAMPTZ;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE members DROP COLUMN IF EXISTS anonymized_at;
+DROP TABLE IF EXISTS privacy_request_events;
+DROP TABLE IF EXISTS privacy_requests;
+DROP TYPE IF EXISTS privacy_request_status_enum;
+DROP TYPE IF EXISTS privacy_request_kind_enum;
+
+-- +goose StatementEnd
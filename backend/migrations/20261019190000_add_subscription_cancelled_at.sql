@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- When the subscription was cancelled; revenue reports stop counting it from
+-- that day.
+ALTER TABLE subscriptions ADD COLUMN cancelled_at TIMESTAMPTZ;
+
+-- Subscriptions cancelled before the column existed have nothing better than
+-- their last update.
+UPDATE subscriptions SET cancelled_at = updated_at WHERE status = 'cancelled';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancelled_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Export files now live in the shared file store so that any replica can
+-- serve and clean them up. Files written to the old export directory cannot
+-- be reached from there, so their jobs are expired.
+UPDATE export_jobs SET status = 'expired', updated_at = NOW() WHERE status = 'completed';
+ALTER TABLE export_jobs RENAME COLUMN file_path TO storage_key;
+ALTER TABLE export_jobs ALTER COLUMN storage_key TYPE VARCHAR(512);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE export_jobs ALTER COLUMN storage_key TYPE TEXT;
+ALTER TABLE export_jobs RENAME COLUMN storage_key TO file_path;
+
+-- +goose StatementEnd
@@ -0,0 +1,84 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Deleted check-ins must not count towards attendance, so both report views
+-- are rebuilt with the filter every other check-in query applies.
+DROP MATERIALIZED VIEW IF EXISTS report_member_daily_visits;
+DROP MATERIALIZED VIEW IF EXISTS report_hourly_visits;
+
+CREATE MATERIALIZED VIEW report_member_daily_visits AS
+SELECT
+    ci.branch_id,
+    ci.member_id,
+    (ci.check_in_time AT TIME ZONE COALESCE(b.timezone, 'UTC'))::date AS day,
+    COUNT(*) AS visits,
+    COUNT(*) FILTER (WHERE ci.check_out_time > ci.check_in_time) AS completed_visits,
+    COALESCE(SUM(EXTRACT(EPOCH FROM ci.check_out_time - ci.check_in_time) / 60)
+        FILTER (WHERE ci.check_out_time > ci.check_in_time), 0)::double precision AS total_minutes
+FROM check_ins ci
+JOIN branches b ON b.id = ci.branch_id
+WHERE ci.deleted_at IS NULL
+GROUP BY ci.branch_id, ci.member_id, day
+WITH DATA;
+
+CREATE UNIQUE INDEX idx_report_member_daily_visits_key ON report_member_daily_visits(branch_id, member_id, day);
+CREATE INDEX idx_report_member_daily_visits_branch_day ON report_member_daily_visits(branch_id, day);
+CREATE INDEX idx_report_member_daily_visits_member_day ON report_member_daily_visits(member_id, day);
+
+CREATE MATERIALIZED VIEW report_hourly_visits AS
+SELECT
+    ci.branch_id,
+    (ci.check_in_time AT TIME ZONE COALESCE(b.timezone, 'UTC'))::date AS day,
+    EXTRACT(HOUR FROM ci.check_in_time AT TIME ZONE COALESCE(b.timezone, 'UTC'))::int AS hour,
+    COUNT(*) AS visits
+FROM check_ins ci
+JOIN branches b ON b.id = ci.branch_id
+WHERE ci.deleted_at IS NULL
+GROUP BY ci.branch_id, day, hour
+WITH DATA;
+
+CREATE UNIQUE INDEX idx_report_hourly_visits_key ON report_hourly_visits(branch_id, day, hour);
+
+UPDATE report_refreshes SET refreshed_at = NOW()
+WHERE view_name IN ('report_member_daily_visits', 'report_hourly_visits');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP MATERIALIZED VIEW IF EXISTS report_member_daily_visits;
+DROP MATERIALIZED VIEW IF EXISTS report_hourly_visits;
+
+CREATE MATERIALIZED VIEW report_member_daily_visits AS
+SELECT
+    ci.branch_id,
+    ci.member_id,
+    (ci.check_in_time AT TIME ZONE COALESCE(b.timezone, 'UTC'))::date AS day,
+    COUNT(*) AS visits,
+    COUNT(*) FILTER (WHERE ci.check_out_time > ci.check_in_time) AS completed_visits,
+    COALESCE(SUM(EXTRACT(EPOCH FROM ci.check_out_time - ci.check_in_time) / 60)
+        FILTER (WHERE ci.check_out_time > ci.check_in_time), 0)::double precision AS total_minutes
+FROM check_ins ci
+JOIN branches b ON b.id = ci.branch_id
+GROUP BY ci.branch_id, ci.member_id, day
+WITH DATA;
+
+CREATE UNIQUE INDEX idx_report_member_daily_visits_key ON report_member_daily_visits(branch_id, member_id, day);
+CREATE INDEX idx_report_member_daily_visits_branch_day ON report_member_daily_visits(branch_id, day);
+CREATE INDEX idx_report_member_daily_visits_member_day ON report_member_daily_visits(member_id, day);
+
+CREATE MATERIALIZED VIEW report_hourly_visits AS
+SELECT
+    ci.branch_id,
+    (ci.check_in_time AT TIME ZONE COALESCE(b.timezone, 'UTC'))::date AS day,
+    EXTRACT(HOUR FROM ci.check_in_time AT TIME ZONE COALESCE(b.timezone, 'UTC'))::int AS hour,
+    COUNT(*) AS visits
+FROM check_ins ci
+JOIN branches b ON b.id = ci.branch_id
+GROUP BY ci.branch_id, day, hour
+WITH DATA;
+
+CREATE UNIQUE INDEX idx_report_hourly_visits_key ON report_hourly_visits(branch_id, day, hour);
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The days [frozen_from, frozen_until) of an approved freeze. The member
+-- cannot check in during them, and end_date was pushed back by as many days.
+ALTER TABLE subscriptions
+    ADD COLUMN frozen_from DATE,
+    ADD COLUMN frozen_until DATE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS frozen_until,
+    DROP COLUMN IF EXISTS frozen_from;
+
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Opening hours and access windows may now run past midnight: a close or end
+-- time at or before the opening time belongs to the next day (06:00-02:00).
+ALTER TABLE branch_opening_hours DROP CONSTRAINT IF EXISTS branch_opening_hours_check;
+ALTER TABLE plan_access_windows DROP CONSTRAINT IF EXISTS plan_access_windows_check;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- NOT VALID keeps any overnight rows saved in the meantime.
+ALTER TABLE branch_opening_hours
+    ADD CONSTRAINT branch_opening_hours_check CHECK (close_time > open_time) NOT VALID;
+ALTER TABLE plan_access_windows
+    ADD CONSTRAINT plan_access_windows_check CHECK (end_time > start_time) NOT VALID;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Capacity windows follow the opening-hours rule: an end time at or before
+-- the start time belongs to the next day (22:00-02:00).
+ALTER TABLE branch_capacity_windows DROP CONSTRAINT IF EXISTS branch_capacity_windows_check;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- NOT VALID keeps any overnight rows saved in the meantime.
+ALTER TABLE branch_capacity_windows
+    ADD CONSTRAINT branch_capacity_windows_check CHECK (end_time > start_time) NOT VALID;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Trainer availability follows the opening-hours rule: an end time at or
+-- before the start time belongs to the next day (20:00-01:00).
+ALTER TABLE trainer_availability DROP CONSTRAINT IF EXISTS trainer_availability_check;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- NOT VALID keeps any overnight rows saved in the meantime.
+ALTER TABLE trainer_availability
+    ADD CONSTRAINT trainer_availability_check CHECK (end_time > start_time) NOT VALID;
+
+-- +goose StatementEnd
@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// breaker is a consecutive-failure circuit breaker. Once open it rejects
+// calls until the cooldown passes, then lets a single probe through; the
+// probe's outcome closes or re-opens the circuit.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.open = false
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.probing || b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+}
+
+// release ends a probe whose outcome says nothing about service health,
+// e.g. because the caller cancelled it.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) state() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case !b.open:
+		return CircuitClosed
+	case b.probing || time.Since(b.openedAt) >= b.cooldown:
+		return CircuitHalfOpen
+	default:
+		return CircuitOpen
+	}
+}
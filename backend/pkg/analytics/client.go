@@ -0,0 +1,315 @@
+// Package analytics is the HTTP client for the Python analytics and chat
+// service. It adds per-endpoint timeouts, retries for idempotent calls, a
+// circuit breaker and latency/failure metrics on top of net/http.
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Endpoint names, used as metric keys.
+const (
+	EndpointWellness    = "wellness"
+	EndpointChat        = "chat"
+	EndpointChatStream  = "chat_stream"
+	EndpointChatSummary = "chat_summary"
+)
+
+var ErrCircuitOpen = errors.New("analytics service unavailable")
+
+// StatusError is returned when the service answers with a non-200 status.
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("analytics %s returned status %d", e.Endpoint, e.StatusCode)
+}
+
+type Config struct {
+	// BaseURL is the API root, e.g. http://localhost:8000/api/v1
+	BaseURL         string
+	WellnessPath    string
+	ChatPath        string
+	ChatStreamPath  string
+	ChatSummaryPath string
+
+	// Token is sent as a bearer token on every request when set.
+	Token string
+
+	WellnessTimeout    time.Duration
+	ChatTimeout        time.Duration
+	ChatSummaryTimeout time.Duration
+	// StreamHeaderTimeout bounds the wait for a streaming response to start.
+	StreamHeaderTimeout time.Duration
+
+	// MaxRetries applies to idempotent endpoints only.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// The breaker opens after BreakerThreshold consecutive failures and
+	// lets a probe through after BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type endpoint struct {
+	name       string
+	path       string
+	timeout    time.Duration
+	idempotent bool
+}
+
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+	breaker    *breaker
+	metrics    *metrics
+
+	wellness    endpoint
+	chat        endpoint
+	chatStream  endpoint
+	chatSummary endpoint
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.WellnessTimeout <= 0 {
+		cfg.WellnessTimeout = 20 * time.Second
+	}
+	if cfg.ChatTimeout <= 0 {
+		cfg.ChatTimeout = 60 * time.Second
+	}
+	if cfg.ChatSummaryTimeout <= 0 {
+		cfg.ChatSummaryTimeout = 60 * time.Second
+	}
+	if cfg.StreamHeaderTimeout <= 0 {
+		cfg.StreamHeaderTimeout = 30 * time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+
+	return &Client{
+		cfg:        cfg,
+		httpClient: &http.Client{},
+		breaker:    newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		metrics:    newMetrics(),
+
+		wellness:    endpoint{name: EndpointWellness, path: cfg.WellnessPath, timeout: cfg.WellnessTimeout, idempotent: true},
+		chat:        endpoint{name: EndpointChat, path: cfg.ChatPath, timeout: cfg.ChatTimeout},
+		chatStream:  endpoint{name: EndpointChatStream, path: cfg.ChatStreamPath, timeout: cfg.StreamHeaderTimeout},
+		chatSummary: endpoint{name: EndpointChatSummary, path: cfg.ChatSummaryPath, timeout: cfg.ChatSummaryTimeout, idempotent: true},
+	}
+}
+
+// Wellness posts a wellness analysis request and decodes the result into out.
+func (c *Client) Wellness(ctx context.Context, req, out any) error {
+	return c.postJSON(ctx, c.wellness, req, out)
+}
+
+// Chat posts a chat request and decodes the full answer into out. It is not
+// retried since every call produces a new answer.
+func (c *Client) Chat(ctx context.Context, req, out any) error {
+	return c.postJSON(ctx, c.chat, req, out)
+}
+
+// ChatSummary asks the service to summarize a conversation.
+func (c *Client) ChatSummary(ctx context.Context, req, out any) error {
+	return c.postJSON(ctx, c.chatSummary, req, out)
+}
+
+// ChatStream opens a Server-Sent Events stream. Only the wait for response
+// headers is bounded; the caller must close the returned body, and
+// cancelling ctx aborts the stream.
+func (c *Client) ChatStream(ctx context.Context, req any) (io.ReadCloser, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.breaker.allow(); err != nil {
+		c.metrics.rejected(c.chatStream.name)
+		return nil, err
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(c.chatStream.timeout, cancel)
+
+	start := time.Now()
+	resp, err := c.do(streamCtx, c.chatStream, body, "text/event-stream")
+	timer.Stop()
+	c.metrics.observe(c.chatStream.name, time.Since(start), err)
+	if err != nil {
+		cancel()
+		c.recordFailure(err)
+		return nil, err
+	}
+	c.breaker.success()
+
+	return &streamBody{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// Stats returns a snapshot of per-endpoint metrics and the breaker state.
+func (c *Client) Stats() *Stats {
+	return &Stats{
+		Circuit:   c.breaker.state(),
+		Endpoints: c.metrics.snapshot(),
+	}
+}
+
+func (c *Client) postJSON(ctx context.Context, ep endpoint, req, out any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	attempts := 1
+	if ep.idempotent {
+		attempts += c.cfg.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			c.metrics.retried(ep.name)
+			if err := sleep(ctx, c.backoff(attempt)); err != nil {
+				return lastErr
+			}
+		}
+
+		if err := c.breaker.allow(); err != nil {
+			c.metrics.rejected(ep.name)
+			return err
+		}
+
+		start := time.Now()
+		lastErr = c.attempt(ctx, ep, body, out)
+		c.metrics.observe(ep.name, time.Since(start), lastErr)
+		if lastErr == nil {
+			c.breaker.success()
+			return nil
+		}
+
+		c.recordFailure(lastErr)
+		if !retryable(lastErr) || ctx.Err() != nil {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, ep endpoint, body []byte, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, ep.timeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, ep, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("analytics %s: invalid response: %w", ep.name, err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, ep endpoint, body []byte, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(ep.path), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("analytics %s: %w", ep.name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		return nil, &StatusError{Endpoint: ep.name, StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+func (c *Client) url(path string) string {
+	return strings.TrimRight(c.cfg.BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// recordFailure trips the breaker only for failures that indicate the
+// service is unhealthy; client errors (4xx) do not count.
+func (c *Client) recordFailure(err error) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode < 500 {
+		c.breaker.success()
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		c.breaker.release()
+		return
+	}
+	c.breaker.failure()
+}
+
+// backoff is exponential with full jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.cfg.RetryBackoff << (attempt - 1)
+	return time.Duration(rand.Int64N(int64(ceiling)) + 1)
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// streamBody releases the stream context when the caller closes the body.
+type streamBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
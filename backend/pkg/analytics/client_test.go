@@ -0,0 +1,170 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer answers each request with the next status in statuses,
+// repeating the last one, and counts the requests it sees.
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testClient(baseURL string, cfg Config) *Client {
+	cfg.BaseURL = baseURL
+	cfg.WellnessPath = "/wellness"
+	cfg.ChatPath = "/chat"
+	cfg.RetryBackoff = time.Millisecond
+	return NewClient(cfg)
+}
+
+func TestClientRetriesThenSucceeds(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	c := testClient(srv.URL, Config{MaxRetries: 2, BreakerThreshold: 5})
+
+	var out struct{ OK bool }
+	if err := c.Wellness(context.Background(), map[string]string{}, &out); err != nil {
+		t.Fatalf("Wellness() error: %v", err)
+	}
+	if !out.OK {
+		t.Errorf("Wellness() decoded %+v, want ok", out)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server saw %d requests, want 3", got)
+	}
+
+	stats := c.Stats()
+	if stats.Circuit != CircuitClosed {
+		t.Errorf("circuit = %s, want %s", stats.Circuit, CircuitClosed)
+	}
+	e := stats.Endpoints[EndpointWellness]
+	if e.Requests != 3 || e.Failures != 2 || e.Retries != 2 {
+		t.Errorf("stats = %+v, want 3 requests, 2 failures, 2 retries", e)
+	}
+}
+
+func TestClientDoesNotRetryChat(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	c := testClient(srv.URL, Config{MaxRetries: 2, BreakerThreshold: 5})
+
+	var out struct{ OK bool }
+	var statusErr *StatusError
+	if err := c.Chat(context.Background(), map[string]string{}, &out); !errors.As(err, &statusErr) {
+		t.Fatalf("Chat() error = %v, want *StatusError", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestClientBreakerOpensAndRejects(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusInternalServerError)
+	c := testClient(srv.URL, Config{BreakerThreshold: 3, BreakerCooldown: time.Hour})
+	ctx := context.Background()
+
+	var out struct{ OK bool }
+	for i := 0; i < 3; i++ {
+		if err := c.Chat(ctx, map[string]string{}, &out); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Chat() #%d error = %v, want a status error", i+1, err)
+		}
+	}
+	if got := c.Stats().Circuit; got != CircuitOpen {
+		t.Fatalf("circuit after 3 failures = %s, want %s", got, CircuitOpen)
+	}
+
+	if err := c.Chat(ctx, map[string]string{}, &out); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Chat() while open error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if err := c.Wellness(ctx, map[string]string{}, &out); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Wellness() while open error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server saw %d requests, want 3", got)
+	}
+	if got := c.Stats().Endpoints[EndpointChat].Rejected; got != 1 {
+		t.Errorf("chat rejected = %d, want 1", got)
+	}
+}
+
+func TestClientClientErrorsDoNotOpenBreaker(t *testing.T) {
+	srv, _ := statusServer(t, http.StatusBadRequest)
+	c := testClient(srv.URL, Config{BreakerThreshold: 2, BreakerCooldown: time.Hour})
+
+	var out struct{ OK bool }
+	for i := 0; i < 3; i++ {
+		c.Chat(context.Background(), map[string]string{}, &out)
+	}
+	if got := c.Stats().Circuit; got != CircuitClosed {
+		t.Errorf("circuit after 4xx responses = %s, want %s", got, CircuitClosed)
+	}
+}
+
+func TestClientHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name        string
+		probeStatus int
+		wantCircuit string
+	}{
+		{"successful probe closes", http.StatusOK, CircuitClosed},
+		{"failed probe re-opens", http.StatusInternalServerError, CircuitOpen},
+	}
+	for _, tt := range tests {
+		const cooldown = 50 * time.Millisecond
+		srv, calls := statusServer(t, http.StatusInternalServerError, http.StatusInternalServerError, tt.probeStatus)
+		c := testClient(srv.URL, Config{BreakerThreshold: 2, BreakerCooldown: cooldown})
+		ctx := context.Background()
+
+		var out struct{ OK bool }
+		c.Chat(ctx, map[string]string{}, &out)
+		c.Chat(ctx, map[string]string{}, &out)
+		if got := c.Stats().Circuit; got != CircuitOpen {
+			t.Fatalf("%s: circuit = %s, want %s", tt.name, got, CircuitOpen)
+		}
+
+		time.Sleep(cooldown)
+		if got := c.Stats().Circuit; got != CircuitHalfOpen {
+			t.Errorf("%s: circuit after cooldown = %s, want %s", tt.name, got, CircuitHalfOpen)
+		}
+
+		c.Chat(ctx, map[string]string{}, &out)
+		if got := calls.Load(); got != 3 {
+			t.Errorf("%s: server saw %d requests, want 3", tt.name, got)
+		}
+		if got := c.Stats().Circuit; got != tt.wantCircuit {
+			t.Errorf("%s: circuit after probe = %s, want %s", tt.name, got, tt.wantCircuit)
+		}
+	}
+}
+
+func TestBreakerAllowsOneProbe(t *testing.T) {
+	b := newBreaker(1, 0)
+	b.failure()
+
+	if err := b.allow(); err != nil {
+		t.Fatalf("first allow() after cooldown = %v, want nil", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow() during probe = %v, want %v", err, ErrCircuitOpen)
+	}
+	b.release()
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() after released probe = %v, want nil", err)
+	}
+}
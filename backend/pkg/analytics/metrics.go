@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+)
+
+type Stats struct {
+	Circuit   string                    `json:"circuit"`
+	Endpoints map[string]*EndpointStats `json:"endpoints"`
+}
+
+type EndpointStats struct {
+	Requests      int64      `json:"requests"`
+	Failures      int64      `json:"failures"`
+	Retries       int64      `json:"retries"`
+	Rejected      int64      `json:"rejected"`
+	AvgLatencyMs  float64    `json:"avgLatencyMs"`
+	MaxLatencyMs  float64    `json:"maxLatencyMs"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastFailureAt *time.Time `json:"lastFailureAt,omitempty"`
+}
+
+type endpointMetrics struct {
+	requests      int64
+	failures      int64
+	retries       int64
+	rejected      int64
+	totalLatency  time.Duration
+	maxLatency    time.Duration
+	lastError     string
+	lastFailureAt time.Time
+}
+
+type metrics struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointMetrics
+}
+
+func newMetrics() *metrics {
+	return &metrics{endpoints: make(map[string]*endpointMetrics)}
+}
+
+func (m *metrics) get(name string) *endpointMetrics {
+	e, ok := m.endpoints[name]
+	if !ok {
+		e = &endpointMetrics{}
+		m.endpoints[name] = e
+	}
+	return e
+}
+
+// observe records one attempt against the service.
+func (m *metrics) observe(name string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.get(name)
+	e.requests++
+	e.totalLatency += latency
+	e.maxLatency = max(e.maxLatency, latency)
+	if err != nil {
+		e.failures++
+		e.lastError = err.Error()
+		e.lastFailureAt = time.Now()
+	}
+}
+
+func (m *metrics) retried(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(name).retries++
+}
+
+// rejected counts calls refused by the open circuit without reaching the
+// service.
+func (m *metrics) rejected(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(name).rejected++
+}
+
+func (m *metrics) snapshot() map[string]*EndpointStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[string]*EndpointStats, len(m.endpoints))
+	for name, e := range m.endpoints {
+		s := &EndpointStats{
+			Requests:     e.requests,
+			Failures:     e.failures,
+			Retries:      e.retries,
+			Rejected:     e.rejected,
+			MaxLatencyMs: float64(e.maxLatency.Microseconds()) / 1000,
+			LastError:    e.lastError,
+		}
+		if e.requests > 0 {
+			s.AvgLatencyMs = float64(e.totalLatency.Microseconds()) / 1000 / float64(e.requests)
+		}
+		if !e.lastFailureAt.IsZero() {
+			lastFailureAt := e.lastFailureAt
+			s.LastFailureAt = &lastFailureAt
+		}
+		stats[name] = s
+	}
+	return stats
+}
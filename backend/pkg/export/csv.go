@@ -0,0 +1,93 @@
+// Package export writes report tables as downloadable files.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Table is a report flattened to rows of cells, ready to be written in any
+// export format.
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+func (t *Table) Append(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// WriteCSV sends the table as a CSV attachment named filename.
+func WriteCSV(w http.ResponseWriter, filename string, t *Table) error {
+	w.Header().Set("Content-Type", FormatCSV.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	cw := newCSVWriter(w)
+	if err := cw.WriteHeader(t.Columns); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := cw.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return cw.Close()
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) WriteRow(cells []string) error {
+	return c.w.Write(escapeRow(cells))
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Money formats an amount with two decimals.
+func Money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// Float formats a ratio or average with one decimal.
+func Float(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// escapeRow keeps spreadsheet apps from evaluating text cells (for example
+// member names) as formulas. Numbers, including negative ones, are kept.
+func escapeRow(row []string) []string {
+	var escaped []string
+	for i, cell := range row {
+		if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			continue
+		}
+		if numberPattern.MatchString(cell) {
+			continue
+		}
+		if escaped == nil {
+			escaped = slices.Clone(row)
+		}
+		escaped[i] = "'" + cell
+	}
+	if escaped == nil {
+		return row
+	}
+	return escaped
+}
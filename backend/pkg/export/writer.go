@@ -0,0 +1,64 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Format is a file format an export can be written in.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("format must be csv or xlsx")
+
+// numberPattern matches cells written as numbers in spreadsheets. Values with
+// leading zeros (phone numbers, codes) are kept as text.
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// ParseFormat maps a format query parameter to a Format; empty means CSV.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filename appends the format's extension to name.
+func (f Format) Filename(name string) string {
+	return fmt.Sprintf("%s.%s", name, f)
+}
+
+// RowWriter writes a table one row at a time so exports never hold the whole
+// result in memory. Close must be called to complete the file.
+type RowWriter interface {
+	WriteHeader(columns []string) error
+	WriteRow(cells []string) error
+	Close() error
+}
+
+// NewWriter returns a RowWriter for format that writes to w.
+func NewWriter(format Format, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
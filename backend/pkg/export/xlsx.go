@@ -0,0 +1,157 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXRows is the worksheet row limit in Excel, header included.
+const maxXLSXRows = 1 << 20
+
+var ErrTooManyRows = errors.New("export exceeds the 1,048,576 row limit of an xlsx sheet; use csv")
+
+// The fixed parts of a single-sheet workbook. Cells use inline strings so no
+// shared string table has to be built up in memory.
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// Style 1 is the bold header.
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+const (
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxWriter streams rows into the worksheet entry of a zip archive. The zip
+// writer emits each entry as it goes, so memory use does not grow with the
+// number of rows.
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{zw: zip.NewWriter(w)}
+}
+
+// start writes the fixed parts and opens the worksheet on first use.
+func (x *xlsxWriter) start() error {
+	if x.sheet != nil || x.err != nil {
+		return x.err
+	}
+	for _, part := range xlsxParts {
+		f, err := x.zw.Create(part.name)
+		if err != nil {
+			x.err = err
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			x.err = err
+			return err
+		}
+	}
+	f, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		x.err = err
+		return err
+	}
+	x.sheet = bufio.NewWriter(f)
+	_, x.err = x.sheet.WriteString(xlsxSheetStart)
+	return x.err
+}
+
+func (x *xlsxWriter) WriteHeader(columns []string) error {
+	return x.writeRow(columns, true)
+}
+
+func (x *xlsxWriter) WriteRow(cells []string) error {
+	return x.writeRow(cells, false)
+}
+
+func (x *xlsxWriter) writeRow(cells []string, header bool) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if x.rows >= maxXLSXRows {
+		x.err = ErrTooManyRows
+		return x.err
+	}
+	x.rows++
+
+	var b strings.Builder
+	b.WriteString(`<row r="`)
+	b.WriteString(strconv.Itoa(x.rows))
+	b.WriteString(`">`)
+	for _, cell := range cells {
+		switch {
+		case cell == "":
+			b.WriteString(`<c/>`)
+		case !header && numberPattern.MatchString(cell):
+			b.WriteString(`<c><v>`)
+			b.WriteString(cell)
+			b.WriteString(`</v></c>`)
+		default:
+			if header {
+				b.WriteString(`<c t="inlineStr" s="1"><is><t xml:space="preserve">`)
+			} else {
+				b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			}
+			// EscapeText also replaces characters XML cannot carry.
+			xml.EscapeText(&b, []byte(cell))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+
+	_, x.err = x.sheet.WriteString(b.String())
+	return x.err
+}
+
+func (x *xlsxWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
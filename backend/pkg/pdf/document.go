@@ -0,0 +1,135 @@
+// Package pdf writes plain single-page text documents such as invoices and
+// receipts, without pulling in a PDF library. Text uses the standard
+// Helvetica fonts, so only Latin-1 characters render; others become '?'.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	pageWidth  = 595 // A4 in points
+	pageHeight = 842
+	margin     = 56
+	// valueX is where the value column of Row starts.
+	valueX = 300
+)
+
+type line struct {
+	label string
+	value string
+	size  float64
+	bold  bool
+	// before is the space above the line, in points.
+	before float64
+}
+
+// Document collects lines top to bottom. Lines past the bottom margin are
+// dropped.
+type Document struct {
+	title string
+	lines []line
+}
+
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// Heading adds a bold line.
+func (d *Document) Heading(text string) {
+	d.lines = append(d.lines, line{label: text, size: 16, bold: true, before: 8})
+}
+
+// Text adds a regular line.
+func (d *Document) Text(text string) {
+	d.lines = append(d.lines, line{label: text, size: 10, before: 4})
+}
+
+// Row adds a label with its value in a second column; bold is for totals.
+func (d *Document) Row(label, value string, bold bool) {
+	d.lines = append(d.lines, line{label: label, value: value, size: 10, bold: bold, before: 4})
+}
+
+// Space adds an empty gap.
+func (d *Document) Space() {
+	d.lines = append(d.lines, line{before: 12})
+}
+
+func (d *Document) content() []byte {
+	var b bytes.Buffer
+	y := float64(pageHeight - margin)
+	for _, l := range d.lines {
+		y -= l.before + l.size
+		if y < margin {
+			break
+		}
+		if l.label == "" && l.value == "" {
+			continue
+		}
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "BT /%s %.0f Tf %d %.1f Td (%s) Tj ET\n", font, l.size, margin, y, escape(l.label))
+		if l.value != "" {
+			fmt.Fprintf(&b, "BT /%s %.0f Tf %d %.1f Td (%s) Tj ET\n", font, l.size, valueX, y, escape(l.value))
+		}
+	}
+	return b.Bytes()
+}
+
+// WriteTo writes the document as a PDF file.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	content := d.content()
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>", pageWidth, pageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /Producer (FitCore) >>", escape(d.title)),
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, len(objects), xref)
+
+	return b.WriteTo(w)
+}
+
+// escape encodes text as a PDF string body: Latin-1 bytes with the string
+// delimiters escaped.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		case r < 0x80:
+			b.WriteByte(byte(r))
+		default:
+			fmt.Fprintf(&b, "\\%03o", r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,313 @@
+// Package redis is a small RESP2 client covering the commands the cache
+// needs: strings with expiry, sets, Lua scripts and pub/sub. Connections
+// are pooled and dialled lazily.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNil is returned when a reply is the RESP null value, e.g. GET on a
+// missing key.
+var ErrNil = errors.New("redis: nil")
+
+// Error is an error reply from the server.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+type Options struct {
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	TLS      bool
+
+	PoolSize    int
+	DialTimeout time.Duration
+}
+
+// ParseURL reads redis://[user:password@]host:port[/db]; rediss:// enables
+// TLS.
+func ParseURL(raw string) (Options, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Options{}, err
+	}
+
+	opts := Options{Addr: u.Host}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		opts.TLS = true
+	default:
+		return Options{}, fmt.Errorf("redis: unsupported scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		opts.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		opts.DB, err = strconv.Atoi(db)
+		if err != nil {
+			return Options{}, fmt.Errorf("redis: invalid database %q", db)
+		}
+	}
+	return opts, nil
+}
+
+type Client struct {
+	opts Options
+	pool chan *conn
+}
+
+func NewClient(opts Options) *Client {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	return &Client{opts: opts, pool: make(chan *conn, opts.PoolSize)}
+}
+
+// Do sends one command and returns its reply: string, int64, []any or nil.
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, args)
+	if err != nil {
+		var replyErr Error
+		if !errors.As(err, &replyErr) {
+			// The connection state is unknown after an I/O error.
+			cn.Close()
+			return nil, err
+		}
+	}
+	c.put(cn)
+	return reply, err
+}
+
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.pool:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNil
+	}
+	return []byte(reply.(string)), nil
+}
+
+// MGet returns one entry per key, nil for missing keys.
+func (c *Client) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	reply, err := c.Do(ctx, append([]any{"MGET"}, toAny(keys)...)...)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.([]any)
+	values := make([][]byte, len(items))
+	for i, item := range items {
+		if s, ok := item.(string); ok {
+			values[i] = []byte(s)
+		}
+	}
+	return values, nil
+}
+
+// Set stores value with a TTL; ttl <= 0 stores it without expiry.
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []any{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", max(ttl.Milliseconds(), 1))
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return c.int(c.Do(ctx, append([]any{"DEL"}, toAny(keys)...)...))
+}
+
+func (c *Client) PExpire(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := c.Do(ctx, "PEXPIRE", key, max(ttl.Milliseconds(), 1))
+	return err
+}
+
+// PTTL returns the remaining TTL, or a negative duration when the key has
+// no expiry (-1ms) or does not exist (-2ms).
+func (c *Client) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	ms, err := c.int(c.Do(ctx, "PTTL", key))
+	return time.Duration(ms) * time.Millisecond, err
+}
+
+func (c *Client) SAdd(ctx context.Context, key string, members ...string) error {
+	_, err := c.Do(ctx, append([]any{"SADD", key}, toAny(members)...)...)
+	return err
+}
+
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	reply, err := c.Do(ctx, "SMEMBERS", key)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.([]any)
+	members := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			members = append(members, s)
+		}
+	}
+	return members, nil
+}
+
+func (c *Client) Publish(ctx context.Context, channel string, message []byte) error {
+	_, err := c.Do(ctx, "PUBLISH", channel, message)
+	return err
+}
+
+// Subscribe blocks on a dedicated connection and calls handle for every
+// message on channel until ctx is cancelled or the connection drops. ready
+// is called once the subscription is confirmed.
+func (c *Client) Subscribe(ctx context.Context, channel string, ready func(), handle func(payload []byte)) error {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer cn.Close()
+
+	stop := context.AfterFunc(ctx, func() { cn.Close() })
+	defer stop()
+
+	if err := cn.write([]any{"SUBSCRIBE", channel}); err != nil {
+		return err
+	}
+	for {
+		reply, err := cn.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		msg, ok := reply.([]any)
+		if !ok || len(msg) < 3 {
+			continue
+		}
+		switch kind, _ := msg[0].(string); kind {
+		case "subscribe":
+			ready()
+		case "message":
+			if payload, ok := msg[2].(string); ok {
+				handle([]byte(payload))
+			}
+		}
+	}
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+func (c *Client) int(reply any, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %T", reply)
+	}
+	return n, nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+		return c.dial(ctx)
+	}
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.pool <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := &net.Dialer{Timeout: c.opts.DialTimeout}
+	var (
+		nc  net.Conn
+		err error
+	)
+	if c.opts.TLS {
+		host, _, _ := net.SplitHostPort(c.opts.Addr)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		nc, err = tlsDialer.DialContext(ctx, "tcp", c.opts.Addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial %s: %w", c.opts.Addr, err)
+	}
+
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if c.opts.Password != "" {
+		args := []any{"AUTH", c.opts.Password}
+		if c.opts.Username != "" {
+			args = []any{"AUTH", c.opts.Username, c.opts.Password}
+		}
+		if _, err := cn.do(ctx, args); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.do(ctx, []any{"SELECT", c.opts.DB}); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func toAny(values []string) []any {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (cn *conn) do(ctx context.Context, args []any) (any, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		cn.SetDeadline(deadline)
+	} else {
+		cn.SetDeadline(time.Time{})
+	}
+	if err := cn.write(args); err != nil {
+		return nil, err
+	}
+	return cn.read()
+}
+
+// write encodes args as a RESP array of bulk strings.
+func (cn *conn) write(args []any) error {
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(nil, v, 10)
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		fmt.Fprintf(cn.w, "$%d\r\n", len(b))
+		cn.w.Write(b)
+		cn.w.WriteString("\r\n")
+	}
+	return cn.w.Flush()
+}
+
+func (cn *conn) read() (any, error) {
+	line, err := cn.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(cn.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := cn.read()
+			if _, isReplyErr := err.(Error); err != nil && !isReplyErr {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", line[0])
+	}
+}
+
+func (cn *conn) readLine() (string, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed reply line")
+	}
+	return line[:len(line)-2], nil
+}
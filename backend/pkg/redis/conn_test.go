@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeConn wraps a canned server reply and records what the client sends.
+func fakeConn(reply string) (*conn, *bytes.Buffer) {
+	sent := &bytes.Buffer{}
+	return &conn{r: bufio.NewReader(strings.NewReader(reply)), w: bufio.NewWriter(sent)}, sent
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		args []any
+		want string
+	}{
+		{[]any{"PING"}, "*1\r\n$4\r\nPING\r\n"},
+		{[]any{"SET", "k", []byte("v\r\n"), "PX", int64(1500)}, "*5\r\n$3\r\nSET\r\n$1\r\nk\r\n$3\r\nv\r\n\r\n$2\r\nPX\r\n$4\r\n1500\r\n"},
+		{[]any{"SELECT", 2}, "*2\r\n$6\r\nSELECT\r\n$1\r\n2\r\n"},
+		{[]any{"GET", ""}, "*2\r\n$3\r\nGET\r\n$0\r\n\r\n"},
+		{[]any{"INCRBY", "n", -7}, "*3\r\n$6\r\nINCRBY\r\n$1\r\nn\r\n$2\r\n-7\r\n"},
+	}
+	for _, tt := range tests {
+		cn, sent := fakeConn("")
+		if err := cn.write(tt.args); err != nil {
+			t.Errorf("write(%q) error: %v", tt.args, err)
+			continue
+		}
+		if got := sent.String(); got != tt.want {
+			t.Errorf("write(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestWriteUnsupportedType(t *testing.T) {
+	cn, _ := fakeConn("")
+	if err := cn.write([]any{"SET", "k", 1.5}); err == nil {
+		t.Error("write with a float64 argument succeeded, want an error")
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  any
+		err   error
+	}{
+		{"+OK\r\n", "OK", nil},
+		{"+\r\n", "", nil},
+		{"-ERR unknown command\r\n", nil, Error("ERR unknown command")},
+		{":42\r\n", int64(42), nil},
+		{":-2\r\n", int64(-2), nil},
+		{"$5\r\nhello\r\n", "hello", nil},
+		{"$0\r\n\r\n", "", nil},
+		{"$4\r\na\r\nb\r\n", "a\r\nb", nil},
+		{"$-1\r\n", nil, nil},
+		{"*0\r\n", []any{}, nil},
+		{"*-1\r\n", nil, nil},
+		{"*3\r\n$1\r\na\r\n:1\r\n$-1\r\n", []any{"a", int64(1), nil}, nil},
+		{"*2\r\n*1\r\n+x\r\n*0\r\n", []any{[]any{"x"}, []any{}}, nil},
+		// An error inside an array, e.g. from a script, is kept as nil.
+		{"*2\r\n-ERR boom\r\n:1\r\n", []any{nil, int64(1)}, nil},
+	}
+	for _, tt := range tests {
+		cn, _ := fakeConn(tt.reply)
+		got, err := cn.read()
+		if err != tt.err {
+			t.Errorf("read(%q) error = %v, want %v", tt.reply, err, tt.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("read(%q) = %#v, want %#v", tt.reply, got, tt.want)
+		}
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	tests := []struct {
+		reply string
+		io    bool
+	}{
+		{"", true},
+		{"+OK", true},
+		{"$5\r\nhel", true},
+		{"*2\r\n:1\r\n", true},
+		{"+OK\n", false},
+		{"\r\n", false},
+		{"?what\r\n", false},
+		{":x\r\n", false},
+		{"$x\r\n", false},
+		{"*x\r\n", false},
+	}
+	for _, tt := range tests {
+		cn, _ := fakeConn(tt.reply)
+		_, err := cn.read()
+		if err == nil {
+			t.Errorf("read(%q) succeeded, want an error", tt.reply)
+			continue
+		}
+		var replyErr Error
+		if errors.As(err, &replyErr) {
+			t.Errorf("read(%q) = reply error %v, want a protocol error", tt.reply, err)
+		}
+		if isIO := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF); isIO != tt.io {
+			t.Errorf("read(%q) error = %v, want I/O error %v", tt.reply, err, tt.io)
+		}
+	}
+}
+
+// TestReadPipelined checks that a reply is consumed exactly, leaving the
+// next one intact on the connection.
+func TestReadPipelined(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	go func() {
+		server.Write([]byte("$3\r\nfoo\r\n:7\r\n"))
+	}()
+
+	cn := &conn{Conn: client, r: bufio.NewReader(client), w: bufio.NewWriter(client)}
+	for _, want := range []any{"foo", int64(7)} {
+		got, err := cn.read()
+		if err != nil || got != want {
+			t.Fatalf("read() = %#v, %v, want %#v", got, err, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Script is a Lua script run atomically on the server. It is sent by hash
+// with EVALSHA and only falls back to the full source with EVAL when the
+// server has not cached it yet.
+type Script struct {
+	src  string
+	hash string
+}
+
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Run executes the script with keys and args and returns its reply.
+func (s *Script) Run(ctx context.Context, c *Client, keys []string, args ...any) (any, error) {
+	cmd := append([]any{"EVALSHA", s.hash, len(keys)}, toAny(keys)...)
+	cmd = append(cmd, args...)
+
+	reply, err := c.Do(ctx, cmd...)
+	var replyErr Error
+	if errors.As(err, &replyErr) && strings.HasPrefix(string(replyErr), "NOSCRIPT") {
+		cmd[0], cmd[1] = "EVAL", s.src
+		return c.Do(ctx, cmd...)
+	}
+	return reply, err
+}
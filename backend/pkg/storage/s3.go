@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+)
+
+type S3Options struct {
+	// Endpoint is the service root, e.g. http://minio:9000; empty means AWS
+	// S3 in Region.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path rather than the host name,
+	// which MinIO and most S3 stand-ins need.
+	PathStyle bool
+	Timeout   time.Duration
+}
+
+// S3 talks to an S3-compatible bucket over its REST API, signing requests
+// with AWS Signature Version 4.
+type S3 struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+}
+
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Bucket == "" || opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, errors.New("storage: s3 bucket and credentials are required")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 60 * time.Second
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", opts.Endpoint)
+	}
+	return &S3{
+		opts:     opts,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: opts.Timeout},
+	}, nil
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := &url.URL{Scheme: s.endpoint.Scheme, Host: s.endpoint.Host}
+	base := strings.TrimSuffix(s.endpoint.Path, "/")
+	if s.opts.PathStyle {
+		u.Path = base + "/" + s.opts.Bucket + "/" + key
+	} else {
+		u.Host = s.opts.Bucket + "." + s.endpoint.Host
+		u.Path = base + "/" + key
+	}
+	u.RawPath = escapePath(u.Path)
+	return u
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do signs and sends a request. Responses outside 2xx are closed and turned
+// into errors.
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 %s: %w", req.Method, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("storage: s3 %s returned %d: %s", req.Method, resp.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// sign adds the Signature Version 4 headers. Only host and the x-amz
+// headers are signed, which is all S3 requires.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	scope := day + "/" + s.opts.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest(req, payloadHash, amzDate)))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), day)
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+
+func canonicalRequest(req *http.Request, payloadHash, amzDate string) string {
+	return strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+}
+
+// canonicalQuery sorts the parameters and encodes every name and value,
+// keeping the "=" of parameters without a value.
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, escapeQuery(name)+"="+escapeQuery(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath encodes a path the way SigV4 expects: everything but unreserved
+// characters and the slashes between segments.
+func escapePath(p string) string {
+	return uriEncode(p, true)
+}
+
+// escapeQuery encodes a query name or value, slashes included.
+func escapeQuery(v string) string {
+	return uriEncode(v, false)
+}
+
+func uriEncode(v string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignerVerify(t *testing.T) {
+	signer := NewSigner("secret")
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	expires, signature := signer.Sign("file-1/original", now.Add(time.Minute))
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		resource  string
+		expires   int64
+		signature string
+		now       time.Time
+		want      error
+	}{
+		{"valid", signer, "file-1/original", expires, signature, now, nil},
+		{"valid at expiry", signer, "file-1/original", expires, signature, now.Add(time.Minute), nil},
+		{"expired", signer, "file-1/original", expires, signature, now.Add(time.Minute + time.Second), ErrURLExpired},
+		{"other resource", signer, "file-2/original", expires, signature, now, ErrInvalidSignature},
+		{"other variant", signer, "file-1/thumbnail", expires, signature, now, ErrInvalidSignature},
+		{"extended expiry", signer, "file-1/original", expires + 3600, signature, now, ErrInvalidSignature},
+		{"tampered signature", signer, "file-1/original", expires, signature[:len(signature)-1] + "A", now, ErrInvalidSignature},
+		{"empty signature", signer, "file-1/original", expires, "", now, ErrInvalidSignature},
+		{"other key", NewSigner("other"), "file-1/original", expires, signature, now, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.Verify(tt.resource, tt.expires, tt.signature, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package storage keeps uploaded files on the local disk or in an
+// S3-compatible bucket such as AWS S3 or MinIO. Objects are addressed by
+// slash-separated keys and carry no metadata; callers keep the content type
+// and size themselves.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when no object exists under a key.
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidKey is returned for keys that are empty, absolute or climb out
+// of the store with "..".
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens an object; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+type Options struct {
+	// Driver is local or s3
+	Driver   string
+	LocalDir string
+	S3       S3Options
+}
+
+func New(opts Options) (Storage, error) {
+	switch opts.Driver {
+	case "", "local":
+		return NewLocal(opts.LocalDir)
+	case "s3":
+		return NewS3(opts.S3)
+	default:
+		return nil, fmt.Errorf("storage: unsupported driver %q", opts.Driver)
+	}
+}
+
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want error
+	}{
+		{"photos/2026/a.jpg", nil},
+		{"a.jpg", nil},
+		{"exports/x..y.csv", nil},
+		{"", ErrInvalidKey},
+		{"/etc/passwd", ErrInvalidKey},
+		{"..", ErrInvalidKey},
+		{"../a.jpg", ErrInvalidKey},
+		{"photos/../../a.jpg", ErrInvalidKey},
+		{"photos/./a.jpg", ErrInvalidKey},
+		{"photos//a.jpg", ErrInvalidKey},
+		{"photos/", ErrInvalidKey},
+		{".", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		if err := checkKey(tt.key); !errors.Is(err, tt.want) {
+			t.Errorf("checkKey(%q) = %v, want %v", tt.key, err, tt.want)
+		}
+	}
+}
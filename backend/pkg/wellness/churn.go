@@ -0,0 +1,106 @@
+package wellness
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChurnInput is what churn risk is scored from. Current covers the last 30
+// days; PreviousSessions counts visits in the 30 days before that.
+type ChurnInput struct {
+	Current          Metrics
+	PreviousSessions int
+	// DaysSinceLastVisit is nil when the member never visited.
+	DaysSinceLastVisit *int
+	DaysUntilRenewal   int
+	FailedPayments     int
+	OverduePayments    int
+	TenureDays         int
+}
+
+type Churn struct {
+	RiskScore int
+	RiskLevel string
+	// Reasons are ordered by how much they contributed to the score.
+	Reasons []string
+}
+
+type churnFactor struct {
+	points float64
+	reason string
+}
+
+// ChurnRisk scores how likely a member is to leave at renewal: inactivity
+// 35%, attendance decline 25%, low frequency 15%, renewal proximity 15%,
+// payment problems 15% and short tenure 5%, capped at 100.
+func ChurnRisk(in ChurnInput) Churn {
+	var factors []churnFactor
+	add := func(points float64, reason string) {
+		if points > 0 {
+			factors = append(factors, churnFactor{points: points, reason: reason})
+		}
+	}
+
+	switch {
+	case in.DaysSinceLastVisit == nil:
+		add(35, "Has not visited yet")
+	case *in.DaysSinceLastVisit >= 21:
+		add(35, fmt.Sprintf("No visit in %d days", *in.DaysSinceLastVisit))
+	case *in.DaysSinceLastVisit >= 14:
+		add(25, fmt.Sprintf("No visit in %d days", *in.DaysSinceLastVisit))
+	case *in.DaysSinceLastVisit >= 7:
+		add(12, fmt.Sprintf("No visit in %d days", *in.DaysSinceLastVisit))
+	}
+
+	if in.PreviousSessions >= 4 && in.Current.Sessions < in.PreviousSessions {
+		drop := float64(in.PreviousSessions-in.Current.Sessions) / float64(in.PreviousSessions)
+		add(25*clamp(drop), fmt.Sprintf("Visits dropped from %d to %d compared to the previous 30 days", in.PreviousSessions, in.Current.Sessions))
+	}
+
+	switch {
+	case in.Current.Sessions == 0:
+		add(15, "No visits in the last 30 days")
+	case in.Current.Sessions < 4:
+		add(10, fmt.Sprintf("Only %d visits in the last 30 days", in.Current.Sessions))
+	case in.Current.Sessions < 8:
+		add(5, fmt.Sprintf("%.1f visits per week", in.Current.SessionsPerWeek))
+	}
+
+	switch {
+	case in.DaysUntilRenewal <= 7:
+		add(15, fmt.Sprintf("Membership ends in %d days", max(0, in.DaysUntilRenewal)))
+	case in.DaysUntilRenewal <= 14:
+		add(10, fmt.Sprintf("Membership ends in %d days", in.DaysUntilRenewal))
+	case in.DaysUntilRenewal <= 30:
+		add(5, fmt.Sprintf("Membership ends in %d days", in.DaysUntilRenewal))
+	}
+
+	if in.FailedPayments > 0 || in.OverduePayments > 0 {
+		points := min(15, float64(in.FailedPayments*8+in.OverduePayments*5))
+		add(points, fmt.Sprintf("%d failed and %d overdue payments", in.FailedPayments, in.OverduePayments))
+	}
+
+	if in.TenureDays < 90 {
+		add(5, "Joined less than 90 days ago")
+	}
+
+	sort.SliceStable(factors, func(i, j int) bool { return factors[i].points > factors[j].points })
+
+	total := 0.0
+	c := Churn{Reasons: make([]string, 0, len(factors))}
+	for _, f := range factors {
+		total += f.points
+		c.Reasons = append(c.Reasons, f.reason)
+	}
+	c.RiskScore = min(100, int(total+0.5))
+
+	switch {
+	case c.RiskScore >= 60:
+		c.RiskLevel = "High"
+	case c.RiskScore >= 35:
+		c.RiskLevel = "Medium"
+	default:
+		c.RiskLevel = "Low"
+	}
+	return c
+}
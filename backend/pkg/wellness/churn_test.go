@@ -0,0 +1,196 @@
+package wellness
+
+import (
+	"reflect"
+	"testing"
+)
+
+// healthy is a long-standing regular with nothing to flag; cases change
+// one or two fields from it.
+func healthy() ChurnInput {
+	return ChurnInput{
+		Current:            Metrics{Sessions: 12, Days: 30, SessionsPerWeek: 2.8},
+		PreviousSessions:   12,
+		DaysSinceLastVisit: intPtr(2),
+		DaysUntilRenewal:   60,
+		TenureDays:         365,
+	}
+}
+
+func TestChurnRisk(t *testing.T) {
+	tests := []struct {
+		name        string
+		edit        func(in *ChurnInput)
+		wantScore   int
+		wantLevel   string
+		wantReasons []string
+	}{
+		{
+			name:        "healthy regular",
+			edit:        func(in *ChurnInput) {},
+			wantLevel:   "Low",
+			wantReasons: []string{},
+		},
+		{
+			name: "new member who never visited",
+			edit: func(in *ChurnInput) {
+				in.Current = Metrics{Days: 30}
+				in.PreviousSessions = 0
+				in.DaysSinceLastVisit = nil
+				in.DaysUntilRenewal = 20
+				in.TenureDays = 10
+			},
+			wantScore: 60,
+			wantLevel: "High",
+			wantReasons: []string{
+				"Has not visited yet",
+				"No visits in the last 30 days",
+				"Membership ends in 20 days",
+				"Joined less than 90 days ago",
+			},
+		},
+		{
+			name: "single session after a long gap",
+			edit: func(in *ChurnInput) {
+				in.Current = Metrics{Sessions: 1, Days: 30, SessionsPerWeek: 0.2}
+				in.PreviousSessions = 0
+				in.DaysSinceLastVisit = intPtr(25)
+			},
+			wantScore:   45,
+			wantLevel:   "Medium",
+			wantReasons: []string{"No visit in 25 days", "Only 1 visits in the last 30 days"},
+		},
+		{
+			name: "attendance decline rounds up",
+			edit: func(in *ChurnInput) {
+				in.Current = Metrics{Sessions: 3, Days: 30, SessionsPerWeek: 0.7}
+			},
+			// 25 * 9/12 = 18.75, plus 10 for low frequency
+			wantScore: 29,
+			wantLevel: "Low",
+			wantReasons: []string{
+				"Visits dropped from 12 to 3 compared to the previous 30 days",
+				"Only 3 visits in the last 30 days",
+			},
+		},
+		{
+			name: "decline ignored below four previous visits",
+			edit: func(in *ChurnInput) {
+				in.Current = Metrics{Days: 30}
+				in.PreviousSessions = 3
+				in.DaysSinceLastVisit = intPtr(40)
+			},
+			wantScore:   50,
+			wantLevel:   "Medium",
+			wantReasons: []string{"No visit in 40 days", "No visits in the last 30 days"},
+		},
+		{
+			name: "moderate frequency",
+			edit: func(in *ChurnInput) {
+				in.Current = Metrics{Sessions: 5, Days: 30, SessionsPerWeek: 1.2}
+				in.PreviousSessions = 5
+			},
+			wantScore:   5,
+			wantLevel:   "Low",
+			wantReasons: []string{"1.2 visits per week"},
+		},
+		{
+			name: "payment problems are capped",
+			edit: func(in *ChurnInput) {
+				in.FailedPayments = 2
+				in.OverduePayments = 1
+			},
+			wantScore:   15,
+			wantLevel:   "Low",
+			wantReasons: []string{"2 failed and 1 overdue payments"},
+		},
+		{
+			name: "score is capped at 100",
+			edit: func(in *ChurnInput) {
+				in.Current = Metrics{Days: 30}
+				in.PreviousSessions = 10
+				in.DaysSinceLastVisit = nil
+				in.DaysUntilRenewal = 0
+				in.FailedPayments = 1
+				in.OverduePayments = 2
+				in.TenureDays = 30
+			},
+			wantScore: 100,
+			wantLevel: "High",
+			wantReasons: []string{
+				"Has not visited yet",
+				"Visits dropped from 10 to 0 compared to the previous 30 days",
+				"No visits in the last 30 days",
+				"Membership ends in 0 days",
+				"1 failed and 2 overdue payments",
+				"Joined less than 90 days ago",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := healthy()
+			tt.edit(&in)
+			got := ChurnRisk(in)
+			if got.RiskScore != tt.wantScore || got.RiskLevel != tt.wantLevel {
+				t.Errorf("ChurnRisk() = %d, %q, want %d, %q", got.RiskScore, got.RiskLevel, tt.wantScore, tt.wantLevel)
+			}
+			if !reflect.DeepEqual(got.Reasons, tt.wantReasons) {
+				t.Errorf("Reasons = %q, want %q", got.Reasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestChurnRiskInactivity(t *testing.T) {
+	tests := []struct {
+		days      int
+		wantScore int
+		wantLevel string
+	}{
+		{0, 0, "Low"},
+		{6, 0, "Low"},
+		{7, 12, "Low"},
+		{13, 12, "Low"},
+		{14, 25, "Low"},
+		{20, 25, "Low"},
+		{21, 35, "Medium"},
+		{365, 35, "Medium"},
+	}
+	for _, tt := range tests {
+		in := healthy()
+		in.DaysSinceLastVisit = intPtr(tt.days)
+		got := ChurnRisk(in)
+		if got.RiskScore != tt.wantScore || got.RiskLevel != tt.wantLevel {
+			t.Errorf("ChurnRisk() with %d days since last visit = %d, %q, want %d, %q", tt.days, got.RiskScore, got.RiskLevel, tt.wantScore, tt.wantLevel)
+		}
+	}
+}
+
+func TestChurnRiskRenewal(t *testing.T) {
+	tests := []struct {
+		days       int
+		wantScore  int
+		wantReason string
+	}{
+		{-3, 15, "Membership ends in 0 days"},
+		{7, 15, "Membership ends in 7 days"},
+		{8, 10, "Membership ends in 8 days"},
+		{14, 10, "Membership ends in 14 days"},
+		{15, 5, "Membership ends in 15 days"},
+		{30, 5, "Membership ends in 30 days"},
+		{31, 0, ""},
+	}
+	for _, tt := range tests {
+		in := healthy()
+		in.DaysUntilRenewal = tt.days
+		got := ChurnRisk(in)
+		reason := ""
+		if len(got.Reasons) > 0 {
+			reason = got.Reasons[0]
+		}
+		if got.RiskScore != tt.wantScore || reason != tt.wantReason {
+			t.Errorf("ChurnRisk() with renewal in %d days = %d, %q, want %d, %q", tt.days, got.RiskScore, reason, tt.wantScore, tt.wantReason)
+		}
+	}
+}
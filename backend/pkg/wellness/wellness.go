@@ -0,0 +1,212 @@
+// Package wellness computes attendance consistency, burnout and churn risk
+// from a member's daily attendance. The attendance and burnout scoring
+// mirrors the deterministic part of the Python analytics service so results
+// stay comparable when that service is down.
+package wellness
+
+import (
+	"fmt"
+	"math"
+)
+
+// Day is one calendar day of attendance, as returned by get_member_attendance.
+type Day struct {
+	Date            string
+	Attended        bool
+	DurationMinutes float64
+}
+
+type Profile struct {
+	Age int
+	// DaysUntilRenewal is nil when the member has no active subscription.
+	DaysUntilRenewal *int
+}
+
+type Metrics struct {
+	Sessions           int
+	Days               int
+	SessionsPerWeek    float64
+	LongestStreak      int
+	RestDays           int
+	AvgDurationMinutes float64
+	MaxDurationMinutes float64
+}
+
+type Attendance struct {
+	Score                  int
+	ConsistencyLevel       string
+	ScoreExplanation       string
+	PatternInsight         string
+	RenewalBehaviorInsight string
+	PositiveNudge          string
+	Recommendation         string
+}
+
+type Burnout struct {
+	RiskScore          int
+	RiskLevel          string
+	WarningSigns       []string
+	RecoverySuggestion string
+}
+
+type Result struct {
+	Metrics    Metrics
+	Attendance Attendance
+	Burnout    Burnout
+}
+
+// ComputeMetrics derives volume and recovery metrics from days, which must be
+// in date order and contain one entry per calendar day.
+func ComputeMetrics(days []Day) Metrics {
+	m := Metrics{Days: len(days)}
+
+	streak := 0
+	totalDuration := 0.0
+	for _, d := range days {
+		if !d.Attended {
+			m.RestDays++
+			streak = 0
+			continue
+		}
+		m.Sessions++
+		streak++
+		m.LongestStreak = max(m.LongestStreak, streak)
+		totalDuration += d.DurationMinutes
+		m.MaxDurationMinutes = max(m.MaxDurationMinutes, d.DurationMinutes)
+	}
+
+	if m.Sessions > 0 {
+		m.AvgDurationMinutes = round1(totalDuration / float64(m.Sessions))
+	}
+	if m.Days > 0 {
+		m.SessionsPerWeek = round1(float64(m.Sessions) * 7 / float64(m.Days))
+	}
+	return m
+}
+
+// ConsistencyScore matches the analytics service: 8 points per session in the
+// window, capped at 100.
+func ConsistencyScore(sessions int) (int, string) {
+	score := min(100, sessions*8)
+	switch {
+	case score > 75:
+		return score, "High"
+	case score > 40:
+		return score, "Medium"
+	default:
+		return score, "Low"
+	}
+}
+
+// Analyze scores attendance and burnout risk and fills in template text for
+// the fields the analytics service would otherwise generate with an LLM.
+func Analyze(days []Day, profile Profile) *Result {
+	m := ComputeMetrics(days)
+	return &Result{
+		Metrics:    m,
+		Attendance: analyzeAttendance(m, profile),
+		Burnout:    analyzeBurnout(m, profile),
+	}
+}
+
+func analyzeAttendance(m Metrics, profile Profile) Attendance {
+	score, level := ConsistencyScore(m.Sessions)
+	a := Attendance{
+		Score:            score,
+		ConsistencyLevel: level,
+		ScoreExplanation: fmt.Sprintf("%d sessions in the last %d days (%.1f per week) gives a consistency score of %d/100.", m.Sessions, m.Days, m.SessionsPerWeek, score),
+	}
+
+	switch {
+	case m.Sessions == 0:
+		a.PatternInsight = "No visits recorded in this period."
+	case m.LongestStreak >= 3:
+		a.PatternInsight = fmt.Sprintf("Your longest run was %d days in a row, averaging %.0f minutes per visit.", m.LongestStreak, m.AvgDurationMinutes)
+	default:
+		a.PatternInsight = fmt.Sprintf("Visits are spread out, averaging %.0f minutes each.", m.AvgDurationMinutes)
+	}
+
+	switch {
+	case profile.DaysUntilRenewal == nil:
+		a.RenewalBehaviorInsight = "No active membership to renew."
+	case *profile.DaysUntilRenewal <= 7:
+		a.RenewalBehaviorInsight = fmt.Sprintf("Your membership renews in %d days.", *profile.DaysUntilRenewal)
+	default:
+		a.RenewalBehaviorInsight = fmt.Sprintf("%d days left on your current membership.", *profile.DaysUntilRenewal)
+	}
+
+	switch level {
+	case "High":
+		a.PositiveNudge = "Great consistency, keep it up!"
+		a.Recommendation = "Vary intensity across the week and keep at least one full rest day."
+	case "Medium":
+		a.PositiveNudge = "You're building a solid habit."
+		a.Recommendation = "Add one more session per week to reach a high consistency level."
+	default:
+		a.PositiveNudge = "Every visit counts, a small step today makes a difference."
+		a.Recommendation = "Aim for two short sessions per week on fixed days to build the habit."
+	}
+	return a
+}
+
+// analyzeBurnout applies the weighting used by the analytics prompt: volume
+// 40%, recovery 30%, intensity 20%, individual factors 10%.
+func analyzeBurnout(m Metrics, profile Profile) Burnout {
+	var b Burnout
+
+	restPerWeek := 7.0
+	if m.Days > 0 {
+		restPerWeek = float64(m.RestDays) * 7 / float64(m.Days)
+	}
+	hoursPerWeek := m.SessionsPerWeek * m.AvgDurationMinutes / 60
+
+	volume := 40 * clamp((m.SessionsPerWeek-3)/4)
+	if hoursPerWeek > 10 {
+		volume = 40
+	}
+	recovery := 15*clamp(float64(m.LongestStreak-3)/4) + 15*clamp((2-restPerWeek)/2)
+	intensity := 20 * clamp((m.MaxDurationMinutes-60)/60)
+	individual := 0.0
+	switch {
+	case profile.Age >= 55:
+		individual = 10
+	case profile.Age >= 40:
+		individual = 5
+	}
+
+	b.RiskScore = int(math.Round(volume + recovery + intensity + individual))
+	switch {
+	case b.RiskScore <= 35:
+		b.RiskLevel = "Low"
+		b.RecoverySuggestion = "Your training load looks well managed. Keep scheduling regular rest days."
+	case b.RiskScore <= 65:
+		b.RiskLevel = "Moderate"
+		b.RecoverySuggestion = "Add an extra rest day this week and keep sessions under 75 minutes. Prioritise sleep and light mobility work."
+	default:
+		b.RiskLevel = "High"
+		b.RecoverySuggestion = "Take two consecutive rest days now, then return at reduced volume. Avoid back-to-back long sessions until recovery improves."
+	}
+
+	b.WarningSigns = []string{}
+	if m.LongestStreak > 5 {
+		b.WarningSigns = append(b.WarningSigns, fmt.Sprintf("%d consecutive training days without rest", m.LongestStreak))
+	}
+	if m.Days >= 7 && restPerWeek < 1 {
+		b.WarningSigns = append(b.WarningSigns, fmt.Sprintf("Only %d rest days in the last %d days", m.RestDays, m.Days))
+	}
+	if hoursPerWeek > 10 {
+		b.WarningSigns = append(b.WarningSigns, fmt.Sprintf("%.1f training hours per week", hoursPerWeek))
+	}
+	if m.MaxDurationMinutes >= 90 {
+		b.WarningSigns = append(b.WarningSigns, fmt.Sprintf("Sessions up to %.0f minutes long", m.MaxDurationMinutes))
+	}
+	return b
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
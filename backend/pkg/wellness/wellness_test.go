@@ -0,0 +1,238 @@
+package wellness
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// attendance builds one Day per character of pattern, starting on a fixed
+// date: 'x' is a visit of minutes length, anything else a rest day.
+func attendance(pattern string, minutes float64) []Day {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	days := make([]Day, len(pattern))
+	for i, c := range pattern {
+		days[i] = Day{Date: start.AddDate(0, 0, i).Format("2006-01-02")}
+		if c == 'x' {
+			days[i].Attended = true
+			days[i].DurationMinutes = minutes
+		}
+	}
+	return days
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestComputeMetrics(t *testing.T) {
+	tests := []struct {
+		name string
+		days []Day
+		want Metrics
+	}{
+		{
+			name: "no days",
+			days: nil,
+			want: Metrics{},
+		},
+		{
+			name: "zero visits",
+			days: attendance(strings.Repeat(".", 30), 0),
+			want: Metrics{Days: 30, RestDays: 30},
+		},
+		{
+			name: "single session",
+			days: attendance("x"+strings.Repeat(".", 29), 45),
+			want: Metrics{Sessions: 1, Days: 30, SessionsPerWeek: 0.2, LongestStreak: 1, RestDays: 29, AvgDurationMinutes: 45, MaxDurationMinutes: 45},
+		},
+		{
+			name: "single day window",
+			days: attendance("x", 45),
+			want: Metrics{Sessions: 1, Days: 1, SessionsPerWeek: 7, LongestStreak: 1, AvgDurationMinutes: 45, MaxDurationMinutes: 45},
+		},
+		{
+			name: "long gap between visits",
+			days: attendance("x"+strings.Repeat(".", 58)+"x", 60),
+			want: Metrics{Sessions: 2, Days: 60, SessionsPerWeek: 0.2, LongestStreak: 1, RestDays: 58, AvgDurationMinutes: 60, MaxDurationMinutes: 60},
+		},
+		{
+			name: "streak broken by a rest day",
+			days: attendance("xxx.xxxxx.", 60),
+			want: Metrics{Sessions: 8, Days: 10, SessionsPerWeek: 5.6, LongestStreak: 5, RestDays: 2, AvgDurationMinutes: 60, MaxDurationMinutes: 60},
+		},
+		{
+			name: "mixed durations",
+			days: []Day{
+				{Date: "2026-09-01", Attended: true, DurationMinutes: 30},
+				{Date: "2026-09-02"},
+				{Date: "2026-09-03", Attended: true, DurationMinutes: 95},
+				{Date: "2026-09-04", Attended: true, DurationMinutes: 50},
+			},
+			want: Metrics{Sessions: 3, Days: 4, SessionsPerWeek: 5.3, LongestStreak: 2, RestDays: 1, AvgDurationMinutes: 58.3, MaxDurationMinutes: 95},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeMetrics(tt.days); got != tt.want {
+				t.Errorf("ComputeMetrics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsistencyScore(t *testing.T) {
+	tests := []struct {
+		sessions  int
+		wantScore int
+		wantLevel string
+	}{
+		{0, 0, "Low"},
+		{1, 8, "Low"},
+		{5, 40, "Low"},
+		{6, 48, "Medium"},
+		{9, 72, "Medium"},
+		{10, 80, "High"},
+		{12, 96, "High"},
+		{13, 100, "High"},
+		{60, 100, "High"},
+	}
+	for _, tt := range tests {
+		score, level := ConsistencyScore(tt.sessions)
+		if score != tt.wantScore || level != tt.wantLevel {
+			t.Errorf("ConsistencyScore(%d) = %d, %q, want %d, %q", tt.sessions, score, level, tt.wantScore, tt.wantLevel)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    []Day
+		profile Profile
+
+		wantScore    int
+		wantLevel    string
+		wantPattern  string
+		wantRisk     int
+		wantRiskLvl  string
+		wantWarnings []string
+	}{
+		{
+			name:         "no data",
+			days:         nil,
+			profile:      Profile{Age: 60},
+			wantLevel:    "Low",
+			wantPattern:  "No visits recorded in this period.",
+			wantRisk:     10,
+			wantRiskLvl:  "Low",
+			wantWarnings: []string{},
+		},
+		{
+			name:         "zero visits",
+			days:         attendance(strings.Repeat(".", 30), 0),
+			profile:      Profile{Age: 30},
+			wantLevel:    "Low",
+			wantPattern:  "No visits recorded in this period.",
+			wantRiskLvl:  "Low",
+			wantWarnings: []string{},
+		},
+		{
+			name:         "single session",
+			days:         attendance(strings.Repeat(".", 29)+"x", 45),
+			profile:      Profile{Age: 25},
+			wantScore:    8,
+			wantLevel:    "Low",
+			wantPattern:  "Visits are spread out, averaging 45 minutes each.",
+			wantRiskLvl:  "Low",
+			wantWarnings: []string{},
+		},
+		{
+			name:         "long gap after a short streak",
+			days:         attendance("xxx"+strings.Repeat(".", 27), 40),
+			profile:      Profile{Age: 41},
+			wantScore:    24,
+			wantLevel:    "Low",
+			wantPattern:  "Your longest run was 3 days in a row, averaging 40 minutes per visit.",
+			wantRisk:     5,
+			wantRiskLvl:  "Low",
+			wantWarnings: []string{},
+		},
+		{
+			name:        "six days on, one off",
+			days:        attendance(strings.Repeat("xxxxxx.", 2), 60),
+			profile:     Profile{Age: 40},
+			wantScore:   96,
+			wantLevel:   "High",
+			wantPattern: "Your longest run was 6 days in a row, averaging 60 minutes per visit.",
+			// volume 30 + recovery 11.25 + 7.5 + individual 5
+			wantRisk:     54,
+			wantRiskLvl:  "Moderate",
+			wantWarnings: []string{"6 consecutive training days without rest"},
+		},
+		{
+			name:        "daily long sessions",
+			days:        attendance(strings.Repeat("x", 30), 120),
+			profile:     Profile{Age: 45},
+			wantScore:   100,
+			wantLevel:   "High",
+			wantPattern: "Your longest run was 30 days in a row, averaging 120 minutes per visit.",
+			wantRisk:    95,
+			wantRiskLvl: "High",
+			wantWarnings: []string{
+				"30 consecutive training days without rest",
+				"Only 0 rest days in the last 30 days",
+				"14.0 training hours per week",
+				"Sessions up to 120 minutes long",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Analyze(tt.days, tt.profile)
+			a, b := got.Attendance, got.Burnout
+			if a.Score != tt.wantScore || a.ConsistencyLevel != tt.wantLevel {
+				t.Errorf("attendance = %d, %q, want %d, %q", a.Score, a.ConsistencyLevel, tt.wantScore, tt.wantLevel)
+			}
+			if a.PatternInsight != tt.wantPattern {
+				t.Errorf("PatternInsight = %q, want %q", a.PatternInsight, tt.wantPattern)
+			}
+			if b.RiskScore != tt.wantRisk || b.RiskLevel != tt.wantRiskLvl {
+				t.Errorf("burnout = %d, %q, want %d, %q", b.RiskScore, b.RiskLevel, tt.wantRisk, tt.wantRiskLvl)
+			}
+			if !reflect.DeepEqual(b.WarningSigns, tt.wantWarnings) {
+				t.Errorf("WarningSigns = %q, want %q", b.WarningSigns, tt.wantWarnings)
+			}
+			if b.RecoverySuggestion == "" || a.PositiveNudge == "" || a.Recommendation == "" {
+				t.Errorf("template text missing: %+v %+v", a, b)
+			}
+		})
+	}
+}
+
+func TestAnalyzeExplanation(t *testing.T) {
+	got := Analyze(attendance("x"+strings.Repeat(".", 29), 45), Profile{}).Attendance.ScoreExplanation
+	want := "1 sessions in the last 30 days (0.2 per week) gives a consistency score of 8/100."
+	if got != want {
+		t.Errorf("ScoreExplanation = %q, want %q", got, want)
+	}
+}
+
+func TestRenewalInsight(t *testing.T) {
+	tests := []struct {
+		daysUntilRenewal *int
+		want             string
+	}{
+		{nil, "No active membership to renew."},
+		{intPtr(0), "Your membership renews in 0 days."},
+		{intPtr(7), "Your membership renews in 7 days."},
+		{intPtr(8), "8 days left on your current membership."},
+	}
+	for _, tt := range tests {
+		got := Analyze(nil, Profile{DaysUntilRenewal: tt.daysUntilRenewal}).Attendance.RenewalBehaviorInsight
+		if got != tt.want {
+			t.Errorf("RenewalBehaviorInsight(%v) = %q, want %q", tt.daysUntilRenewal, got, tt.want)
+		}
+	}
+}